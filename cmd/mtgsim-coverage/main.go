@@ -0,0 +1,176 @@
+// MTGSim Coverage - parses every card in a local Scryfall card database and
+// checks the result against an approved golden report, so parser changes
+// can't silently break cards.
+//
+// Usage:
+//
+//	go run ./cmd/mtgsim-coverage               # compare against the golden report
+//	go run ./cmd/mtgsim-coverage -update       # approve the current output
+//	go run ./cmd/mtgsim-coverage -out now.jsonl
+//
+// The command exits 1 when a card that was implemented in the golden report
+// no longer is, or when an implemented card's parsed abilities change.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mtgsim/mtgsim/internal/logger"
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/card"
+)
+
+func main() {
+	cardDBPath := flag.String("carddb", card.CardDBFile, "Local Scryfall oracle-cards JSON file")
+	cardsDir := flag.String("cards", "cards", "Directory of JSON card definitions merged over parsed abilities (empty = none)")
+	goldenPath := flag.String("golden", "testdata/coverage/golden.jsonl", "Approved coverage report")
+	outPath := flag.String("out", "", "Also write the current report to this file")
+	update := flag.Bool("update", false, "Overwrite the golden report with the current output")
+	limit := flag.Int("limit", 25, "Maximum cards listed per section (0 = all)")
+	flag.Parse()
+
+	logger.SetLogLevel(logger.ParseLogLevel("META"))
+
+	db, err := card.LoadCardDatabaseFile(*cardDBPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading card database: %v\n", err)
+		os.Exit(2)
+	}
+	if *cardsDir != "" {
+		if _, err := abil.LoadCardDefinitions(*cardsDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading card definitions: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	current := abil.BuildCoverage(db.ListAll())
+	implemented := 0
+	for _, e := range current {
+		if e.Implemented {
+			implemented++
+		}
+	}
+	fmt.Printf("Parsed %d cards: %d implemented (%.1f%%)\n", len(current), implemented, pct(implemented, len(current)))
+
+	if *outPath != "" {
+		if err := writeReport(*outPath, current); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	if *update {
+		if err := writeReport(*goldenPath, current); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing golden report: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Printf("Updated %s\n", *goldenPath)
+		return
+	}
+
+	golden, err := readReport(*goldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "No golden report at %s; run with -update to create one\n", *goldenPath)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading golden report: %v\n", err)
+		os.Exit(2)
+	}
+
+	diff := abil.CompareCoverage(golden, current)
+	printChanges("Regressions (no longer implemented)", diff.Regressions, *limit, true)
+	printChanges("Changed abilities", diff.Changed, *limit, true)
+	printChanges("Newly implemented", diff.Improvements, *limit, false)
+	printNames("New cards", diff.Added, *limit)
+	printNames("Removed cards", diff.Removed, *limit)
+
+	if diff.Failed() {
+		fmt.Printf("\nFAIL: %d regressions, %d changed; review and rerun with -update to approve\n", len(diff.Regressions), len(diff.Changed))
+		os.Exit(1)
+	}
+	fmt.Println("OK: no regressions against", *goldenPath)
+}
+
+func writeReport(path string, entries []abil.CoverageEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := abil.WriteCoverage(f, entries); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readReport(path string) ([]abil.CoverageEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return abil.ReadCoverage(f)
+}
+
+func printChanges(title string, changes []abil.CoverageChange, limit int, detail bool) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Printf("\n%s: %d\n", title, len(changes))
+	for i, ch := range changes {
+		if limit > 0 && i == limit {
+			fmt.Printf("  ... and %d more\n", len(changes)-limit)
+			break
+		}
+		fmt.Printf("  %s\n", ch.Name)
+		if !detail {
+			continue
+		}
+		if ch.Now.Reason != "" {
+			fmt.Printf("    reason: %s\n", ch.Now.Reason)
+		}
+		fmt.Printf("    - %s\n", abilitiesLine(ch.Golden.Abilities))
+		fmt.Printf("    + %s\n", abilitiesLine(ch.Now.Abilities))
+	}
+}
+
+func printNames(title string, names []string, limit int) {
+	if len(names) == 0 {
+		return
+	}
+	fmt.Printf("\n%s: %d\n", title, len(names))
+	for i, name := range names {
+		if limit > 0 && i == limit {
+			fmt.Printf("  ... and %d more\n", len(names)-limit)
+			break
+		}
+		fmt.Printf("  %s\n", name)
+	}
+}
+
+func abilitiesLine(abilities []abil.CoverageAbility) string {
+	if len(abilities) == 0 {
+		return "(no abilities)"
+	}
+	parts := make([]string, 0, len(abilities))
+	for _, ab := range abilities {
+		parts = append(parts, fmt.Sprintf("%s{%s}", ab.Name, strings.Join(ab.Effects, "; ")))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func pct(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
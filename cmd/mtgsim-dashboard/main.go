@@ -385,9 +385,7 @@ func simulateGame(d1, d2 deck.Deck) (winner, loser *game.Player) {
 		ap := g.GetActivePlayerRaw()
 		switch g.GetCurrentPhase() {
 		case game.PhaseUntap:
-			for _, perm := range ap.Battlefield {
-				perm.Untap()
-			}
+			g.UntapStep(ap)
 		case game.PhaseDraw:
 			ap.Draw(1)
 		case game.PhaseMain1:
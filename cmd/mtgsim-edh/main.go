@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -22,6 +23,7 @@ import (
 	"github.com/mtgsim/mtgsim/internal/logger"
 	abil "github.com/mtgsim/mtgsim/pkg/ability"
 	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
 	"github.com/mtgsim/mtgsim/pkg/dashboard"
 	"github.com/mtgsim/mtgsim/pkg/database"
 	"github.com/mtgsim/mtgsim/pkg/deck"
@@ -48,6 +50,9 @@ type EDHGameRunner struct {
 	maxTurns       int
 	mulligans      int
 	replayDir      string
+	mulligan       *simulation.MulliganModel
+	// combos looks up the combos of uploaded decks; nil skips the lookup.
+	combos          simulation.ComboFinder
 	suggestedDeck   *simulation.EDHSeat
 	suggestedDeckMu sync.Mutex
 	gameLogBuffer   []simulation.EDHGameRecord
@@ -56,6 +61,11 @@ type EDHGameRunner struct {
 
 // SetSuggestedDeck sets the suggested deck to use in every pod
 func (gr *EDHGameRunner) SetSuggestedDeck(seat *simulation.EDHSeat) {
+	if seat != nil && gr.combos != nil {
+		one := []simulation.EDHSeat{*seat}
+		simulation.LoadSeatCombos(one, gr.combos)
+		seat = &one[0]
+	}
 	gr.suggestedDeckMu.Lock()
 	defer gr.suggestedDeckMu.Unlock()
 	gr.suggestedDeck = seat
@@ -121,6 +131,9 @@ func (gr *EDHGameRunner) runBatch(count int) {
 		}
 	}
 	uploadedRRIdx := 0
+	gr.mu.RLock()
+	mulligan := gr.mulligan
+	gr.mu.RUnlock()
 
 	// Merge uploaded seats with filesystem seats for pod picking
 	gr.uploadedMu.Lock()
@@ -155,7 +168,7 @@ func (gr *EDHGameRunner) runBatch(count int) {
 
 			rec, err := simulation.SimulateEDHGame(simulation.EDHRunOptions{
 				Seats: pod, MaxTurns: gr.maxTurns, RNG: rand.New(rand.NewSource(gr.rng.Int63())),
-				RecordEvents: true,
+				RecordEvents: true, Mulligan: mulligan,
 			})
 				if err != nil {
 					logger.LogMeta("Pod skipped: %v", err)
@@ -211,6 +224,36 @@ func (gr *EDHGameRunner) pickPodWithUploaded(seats []simulation.EDHSeat, n int,
 	return pickPodFromPool(seats, n, rng, mulligans, chosen)
 }
 
+// playPod seats a pod for an interactive dashboard game from the
+// filesystem and uploaded decks, including deck when it is named.
+func (gr *EDHGameRunner) playPod(deck string, size int) ([]simulation.EDHSeat, error) {
+	gr.uploadedMu.Lock()
+	all := make([]simulation.EDHSeat, 0, len(gr.seats)+len(gr.uploadedSeats))
+	all = append(all, gr.seats...)
+	all = append(all, gr.uploadedSeats...)
+	gr.uploadedMu.Unlock()
+
+	if size > len(all) {
+		return nil, fmt.Errorf("only %d decks are loaded; need %d", len(all), size)
+	}
+	if deck != "" {
+		found := false
+		for _, s := range all {
+			if s.DeckName == deck {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no deck named %q", deck)
+		}
+	}
+	gr.mu.Lock()
+	rng := rand.New(rand.NewSource(gr.rng.Int63()))
+	gr.mu.Unlock()
+	return pickPodFromPool(all, size, rng, gr.mulligans, deck), nil
+}
+
 func pickPodFromPool(seats []simulation.EDHSeat, n int, rng *rand.Rand, mulligans int, chosen string) []simulation.EDHSeat {
 	pod := make([]simulation.EDHSeat, 0, n)
 	used := make(map[string]bool)
@@ -271,6 +314,13 @@ func main() {
 	cardStatsFlag := flag.String("card-stats", "card_library.json", "Path to a JSON file for persistent global card stats (loads existing, merges new, saves on exit)")
 	dbPath := flag.String("db", "", "PostgreSQL DSN for persistent results (empty = disabled)")
 	workerMode := flag.Bool("worker", false, "Run in worker mode: poll simulation_jobs table instead of serving the dashboard")
+	cardsDir := flag.String("cards", "cards", "Directory of JSON card definitions merged over parsed abilities")
+	mctsDecks := flag.String("mcts", "", "Comma-separated deck names piloted by the MCTS agent (\"all\" for every deck)")
+	mctsIterations := flag.Int("mcts-iterations", 32, "MCTS rollouts per decision (0 = limited by -mcts-budget only)")
+	mctsBudget := flag.Duration("mcts-budget", 0, "MCTS time budget per decision (0 = limited by -mcts-iterations only)")
+	archetypes := flag.String("archetype", "", "Comma-separated deck=archetype pairs (aggro, midrange, control, stax, turbo) overriding deck headers and inference, e.g. \"Deck A=stax,Deck B=turbo\"")
+	comboLookup := flag.Bool("combos", true, "Look up each deck's combos on Commander Spellbook (cached in .cache/combos) for mulligans, tutors and combo finishes")
+	mulliganPath := flag.String("mulligan-model", "", "Path to a JSON mulligan model (loads existing, refits from the recorded hands and saves after each batch; empty = built-in weights)")
 	flag.Parse()
 
 	if *podSize < 2 || *podSize > 6 {
@@ -288,6 +338,13 @@ func main() {
 	}
 	logger.LogMeta("Card database loaded with %d cards", cardDB.Size())
 
+	if n, err := abil.LoadCardDefinitions(*cardsDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading card definitions: %v\n", err)
+		os.Exit(1)
+	} else if n > 0 {
+		logger.LogMeta("Loaded %d card definitions from %s", n, *cardsDir)
+	}
+
 	deckFiles, err := simulation.GetDecks(*decksDir)
 	if err != nil || len(deckFiles) < *podSize {
 		fmt.Fprintf(os.Stderr, "Need at least %d deck files in %s\n", *podSize, *decksDir)
@@ -302,6 +359,18 @@ func main() {
 	rng := rand.New(rand.NewSource(rngSeed))
 
 	seats := loadSeats(deckFiles, cardDB)
+	if *archetypes != "" {
+		if err := assignArchetypes(seats, *archetypes); err != nil {
+			fmt.Fprintf(os.Stderr, "-archetype: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var comboFinder simulation.ComboFinder
+	if *comboLookup {
+		comboFinder = combo.NewClient()
+		n := simulation.LoadSeatCombos(seats, comboFinder)
+		logger.LogMeta("Indexed combos for %d of %d decks", n, len(seats))
+	}
 	if *sideboardVariants > 0 {
 		before := len(seats)
 		seats = simulation.ExpandSideboardVariants(seats, simulation.SideboardVariantOptions{
@@ -315,6 +384,11 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Only %d decklists/variants were importable; need %d\n", len(seats), *podSize)
 		os.Exit(1)
 	}
+	if *mctsDecks != "" {
+		agent := simulation.NewMCTSAgent(simulation.MCTSOptions{Iterations: *mctsIterations, Budget: *mctsBudget, Seed: rng.Int63()})
+		n := assignAgent(seats, *mctsDecks, agent)
+		logger.LogMeta("MCTS agent pilots %d seats", n)
+	}
 
 	// Warn about unimplemented cards in loaded EDH decks
 	implTracker := abil.NewImplementationTracker()
@@ -430,6 +504,16 @@ func main() {
 			replayDir:  *replayDir,
 		}
 	}
+	gameRunner.combos = comboFinder
+	if *mulliganPath != "" {
+		if model, err := simulation.LoadMulliganModel(*mulliganPath); err == nil {
+			gameRunner.mulligan = model
+			logger.LogMeta("Loaded mulligan model from %s", *mulliganPath)
+		} else if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error loading mulligan model: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	batchSize := *games
 	if batchSize <= 0 {
 		batchSize = 50
@@ -505,6 +589,7 @@ func main() {
 		batchSize, elapsed.Seconds(), edhResults.AverageTurns())
 
 	printSummary(edhResults, cardLib)
+	refitMulliganModel(gameRunner, *mulliganPath)
 
 	if *games == 0 {
 		logger.LogMeta("Continuous mode: looping batches of %d pods", batchSize)
@@ -516,6 +601,7 @@ func main() {
 				time.Sleep(1 * time.Second)
 			}
 			logger.LogMeta("Continuous batch completed")
+			refitMulliganModel(gameRunner, *mulliganPath)
 		}
 	}
 
@@ -525,6 +611,23 @@ func main() {
 	}
 }
 
+// refitMulliganModel fits a mulligan model to every pod recorded so far,
+// saves it to path and has the runner's later pods mulligan with it.
+func refitMulliganModel(gr *EDHGameRunner, path string) {
+	if path == "" {
+		return
+	}
+	model := simulation.FitMulliganModel(gr.edhResults.RecentGames(gr.edhResults.GameCount()))
+	if err := model.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving mulligan model: %v\n", err)
+		return
+	}
+	gr.mu.Lock()
+	gr.mulligan = model
+	gr.mu.Unlock()
+	logger.LogMeta("Mulligan model refit and saved to %s", path)
+}
+
 func loadSeats(deckFiles []string, cardDB *card.CardDB) []simulation.EDHSeat {
 	out := make([]simulation.EDHSeat, 0, len(deckFiles))
 	for _, path := range deckFiles {
@@ -538,6 +641,52 @@ func loadSeats(deckFiles []string, cardDB *card.CardDB) []simulation.EDHSeat {
 	return out
 }
 
+// assignAgent seats agent in every seat whose deck is named in the
+// comma-separated list names, or in every seat for "all". Returns the
+// number of seats assigned.
+func assignAgent(seats []simulation.EDHSeat, names string, agent simulation.Agent) int {
+	wanted := map[string]bool{}
+	for _, name := range strings.Split(names, ",") {
+		wanted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	n := 0
+	for i := range seats {
+		if wanted["all"] || wanted[strings.ToLower(seats[i].DeckName)] {
+			seats[i].Agent = agent
+			n++
+		}
+	}
+	return n
+}
+
+// assignArchetypes sets the archetype of every seat whose deck is named
+// in spec, a comma-separated list of deck=archetype pairs. A pair naming
+// no seated deck is an error.
+func assignArchetypes(seats []simulation.EDHSeat, spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("%q is not deck=archetype", strings.TrimSpace(pair))
+		}
+		a, ok := card.ParseArchetype(value)
+		if !ok {
+			return fmt.Errorf("unknown archetype %q", strings.TrimSpace(value))
+		}
+		name = strings.TrimSpace(name)
+		found := false
+		for i := range seats {
+			if strings.EqualFold(seats[i].DeckName, name) {
+				seats[i].Archetype = a
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("no deck named %q", name)
+		}
+	}
+	return nil
+}
+
 // loadEDHSeat imports a deck file as a runner seat. If the file declares
 // a commander it is registered; otherwise the player is seated at 40
 // life with no commander but the rest of the EDH plumbing still applies.
@@ -549,6 +698,7 @@ func loadEDHSeat(path string, cardDB deck.CardDatabase) (simulation.EDHSeat, err
 			DeckPath: path, DeckName: main.Name,
 			Library: librarySimpleCards(main), Sideboard: librarySimpleCards(side),
 			Commanders: commanders, Commander: &primary,
+			Archetype: main.Archetype,
 		}, nil
 	}
 	main, side, err := deck.ImportDeckfile(path, cardDB)
@@ -558,6 +708,7 @@ func loadEDHSeat(path string, cardDB deck.CardDatabase) (simulation.EDHSeat, err
 	return simulation.EDHSeat{
 		DeckPath: path, DeckName: main.Name,
 		Library: librarySimpleCards(main), Sideboard: librarySimpleCards(side),
+		Archetype: main.Archetype,
 	}, nil
 }
 
@@ -980,6 +1131,7 @@ func startDashboard(legacy *simulation.Results, edh *simulation.EDHResults, card
 	}
 	server.SetGameRunner(gameRunner)
 	server.SetDataResetter(gameRunner)
+	server.SetPlayPodProvider(gameRunner.playPod)
 
 	server.SetGameLogProvider(func() ([]dashboard.GameLogEntry, func(id int) *simulation.EDHGameRecord) {
 		gameRunner.gameLogMu.Lock()
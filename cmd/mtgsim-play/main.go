@@ -0,0 +1,187 @@
+// MTGSim-Play — play a Commander pod against the AI from a terminal.
+//
+// Seats your deck in a pod with decks drawn at random from a directory
+// and puts every decision for your seat to you at the prompt: mulligans,
+// land drops, spells, targets, attacks, blocks and responses on the
+// stack. A blank answer leaves the decision to the AI.
+//
+// Usage:
+//
+//	go run ./cmd/mtgsim-play -deck decks/mine.txt
+//	go run ./cmd/mtgsim-play -deck decks/mine.txt -decks decks -pod 3 -seed 7
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mtgsim/mtgsim/internal/logger"
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/deck"
+	"github.com/mtgsim/mtgsim/pkg/game"
+	"github.com/mtgsim/mtgsim/pkg/simulation"
+)
+
+func main() {
+	deckPath := flag.String("deck", "", "Deck file you play (required)")
+	decksDir := flag.String("decks", "decks", "Directory of opponent deck files")
+	podSize := flag.Int("pod", 4, "Players per pod (2-6)")
+	maxTurns := flag.Int("max-turns", 50, "Hard turn limit")
+	seed := flag.Int64("seed", 0, "RNG seed (0 = time-based)")
+	cardsDir := flag.String("cards", "cards", "Directory of JSON card definitions merged over parsed abilities")
+	archetype := flag.String("archetype", "", "Archetype the AI plays your seat with when you leave it a decision (default: the deck's header or inferred)")
+	comboLookup := flag.Bool("combos", true, "Look up each seated deck's combos on Commander Spellbook (cached in .cache/combos)")
+	logLevel := flag.String("log", "META", "Log level (META, GAME, PLAYER, CARD)")
+	flag.Parse()
+
+	if *deckPath == "" {
+		fmt.Fprintln(os.Stderr, "-deck is required")
+		os.Exit(1)
+	}
+	if *podSize < 2 || *podSize > 6 {
+		fmt.Fprintln(os.Stderr, "pod size must be in [2,6]")
+		os.Exit(1)
+	}
+	logger.SetLogLevel(logger.ParseLogLevel(*logLevel))
+
+	cardDB, err := card.LoadCardDatabase()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading card database: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := abil.LoadCardDefinitions(*cardsDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading card definitions: %v\n", err)
+		os.Exit(1)
+	}
+
+	mine, err := loadEDHSeat(*deckPath, cardDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", *deckPath, err)
+		os.Exit(1)
+	}
+	if *archetype != "" {
+		a, ok := card.ParseArchetype(*archetype)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown archetype %q\n", *archetype)
+			os.Exit(1)
+		}
+		mine.Archetype = a
+	}
+
+	rngSeed := *seed
+	if rngSeed == 0 {
+		rngSeed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(rngSeed))
+
+	opponents, err := loadOpponents(*decksDir, *deckPath, cardDB)
+	if err != nil || len(opponents) < *podSize-1 {
+		fmt.Fprintf(os.Stderr, "Need at least %d other importable deck files in %s\n", *podSize-1, *decksDir)
+		os.Exit(1)
+	}
+	rng.Shuffle(len(opponents), func(i, j int) { opponents[i], opponents[j] = opponents[j], opponents[i] })
+
+	seats := append([]simulation.EDHSeat{mine}, opponents[:*podSize-1]...)
+	if *comboLookup {
+		simulation.LoadSeatCombos(seats, combo.NewClient())
+	}
+	human := simulation.NewHumanAgent(simulation.NewTerminalChooser(os.Stdin, os.Stdout))
+	human.Combos, human.Archetype = seats[0].Combos, seats[0].Archetype
+	seats[0].Agent = human
+	rng.Shuffle(len(seats), func(i, j int) { seats[i], seats[j] = seats[j], seats[i] })
+
+	fmt.Printf("Seed %d. Turn order:", rngSeed)
+	for _, s := range seats {
+		fmt.Printf(" %s", s.DeckName)
+	}
+	fmt.Println()
+
+	rec, err := simulation.SimulateEDHGame(simulation.EDHRunOptions{
+		Seats: seats, MaxTurns: *maxTurns, RNG: rand.New(rand.NewSource(rng.Int63())), RecordEvents: true,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Game error: %v\n", err)
+		os.Exit(1)
+	}
+	printResult(rec, mine.DeckName)
+}
+
+// loadOpponents imports every deck file in dir except skip.
+func loadOpponents(dir, skip string, cardDB deck.CardDatabase) ([]simulation.EDHSeat, error) {
+	files, err := simulation.GetDecks(dir)
+	if err != nil {
+		return nil, err
+	}
+	skipAbs, _ := filepath.Abs(skip)
+	var out []simulation.EDHSeat
+	for _, path := range files {
+		if abs, _ := filepath.Abs(path); abs == skipAbs {
+			continue
+		}
+		seat, err := loadEDHSeat(path, cardDB)
+		if err != nil {
+			logger.LogMeta("Skipping %s: %v", path, err)
+			continue
+		}
+		out = append(out, seat)
+	}
+	return out, nil
+}
+
+func printResult(rec simulation.EDHGameRecord, you string) {
+	fmt.Printf("\n== Game over after %d turns ==\n", rec.Turns)
+	switch rec.Winner {
+	case "":
+		fmt.Println("No winner: the turn limit was reached or everyone lost.")
+	case you:
+		fmt.Printf("You win (%s)!\n", rec.WinnerCondition)
+	default:
+		fmt.Printf("%s wins (%s).\n", rec.Winner, rec.WinnerCondition)
+	}
+	for _, p := range rec.Players {
+		fmt.Printf("  %-30s life %d\n", p.DeckName, p.FinalLife)
+	}
+}
+
+// loadEDHSeat imports a deck file as a seat, registering its commander
+// when the file declares one.
+func loadEDHSeat(path string, cardDB deck.CardDatabase) (simulation.EDHSeat, error) {
+	if cmdrs, main, side, err := deck.ImportCommanderDeckfileWithCommanders(path, cardDB); err == nil {
+		commanders := simpleCards(cmdrs)
+		primary := commanders[0]
+		return simulation.EDHSeat{
+			DeckPath: path, DeckName: main.Name,
+			Library: simpleCards(main.Cards), Sideboard: simpleCards(side.Cards),
+			Commanders: commanders, Commander: &primary,
+			Archetype: main.Archetype,
+		}, nil
+	}
+	main, side, err := deck.ImportDeckfile(path, cardDB)
+	if err != nil {
+		return simulation.EDHSeat{}, err
+	}
+	return simulation.EDHSeat{
+		DeckPath: path, DeckName: main.Name,
+		Library: simpleCards(main.Cards), Sideboard: simpleCards(side.Cards),
+		Archetype: main.Archetype,
+	}, nil
+}
+
+func simpleCards(cards []card.Card) []game.SimpleCard {
+	out := make([]game.SimpleCard, len(cards))
+	for i, c := range cards {
+		out[i] = game.SimpleCard{
+			Name: c.Name, TypeLine: c.TypeLine, Power: c.Power,
+			Toughness: c.Toughness, OracleText: c.OracleText, Colors: c.Colors,
+			ColorIdentity: c.ColorIdentity,
+			ManaCost:      c.ManaCost,
+		}
+	}
+	return out
+}
@@ -30,6 +30,7 @@ var (
 	verbosityFlag  = flag.Int("v", 1, "Verbosity: 0=minimal, 1=summary, 2=per-game details")
 	logLevelFlag   = flag.String("log", "META", "Log level (META, GAME, PLAYER, CARD)")
 	cardStatsFlag  = flag.String("card-stats", "card_library.json", "Path to a JSON file for persistent global card stats (loads existing, merges new, saves on exit)")
+	cardsDirFlag   = flag.String("cards", "cards", "Directory of JSON card definitions merged over parsed abilities")
 )
 
 // Stats accumulators
@@ -171,11 +172,9 @@ func clearTempEffects(p *game.Player) {
 	}
 }
 
-// Untap all permanents a player controls
-func untapAll(p *game.Player) {
-	for _, perm := range p.Battlefield {
-		perm.Untap()
-	}
+// Untap a player's permanents, subject to untap limits like Winter Orb's
+func untapAll(g *game.Game, p *game.Player) {
+	g.UntapStep(p)
 }
 
 // Parse a mana cost string like "{2}{G}{G}" into game.Mana
@@ -348,8 +347,7 @@ func (a *abilityStackAdapter) EnqueueSpell(name string, _ int, manaCost string,
 		g := a.gs.G
 		if g != nil {
 			registry := g.GetStaticEffects()
-			spellsCastThisTurn := 0 // TODO: track per-player spells cast per turn accurately
-			if !registry.CanCastSpell(gp, spellsCastThisTurn) {
+			if !registry.CanCastSpell(gp, g.SpellsCastThisTurnBy(gp)) {
 				return fmt.Errorf("cannot cast %s: cast constraint active", name)
 			}
 		}
@@ -820,6 +818,7 @@ func buildGameFromDecks(d1, d2 deck.Deck) (*game.Game, *game.Player, *game.Playe
 	p2.Draw(7)
 
 	g := game.NewGame(p1, p2)
+	abil.InstallCardScripts(g)
 	return g, p1, p2
 }
 
@@ -901,13 +900,13 @@ func playOneGame(g *game.Game, p1, p2 *game.Player, verbosity int, cardDB *card.
 
 		switch phase {
 		case game.PhaseUntap:
-			untapAll(ap)
+			untapAll(g, ap)
 			landDropUsed[ap] = false
 			clearManaPool(ap)
 		case game.PhaseUpkeep:
 			// no-op (hooks for triggers would go here)
 		case game.PhaseDraw:
-			if ap.Draw(1) == 0 && len(ap.Library) == 0 {
+			if !g.SkipsDrawStep(ap) && ap.Draw(1) == 0 && len(ap.Library) == 0 {
 				ap.Lose("deckout")
 			}
 		case game.PhaseMain1, game.PhaseMain2:
@@ -1168,6 +1167,13 @@ func main() {
 	}
 	logger.LogMeta("Card database loaded with %d cards", cardDB.Size())
 
+	if n, err := abil.LoadCardDefinitions(*cardsDirFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading card definitions: %v\n", err)
+		os.Exit(1)
+	} else if n > 0 {
+		logger.LogMeta("Loaded %d card definitions from %s", n, *cardsDirFlag)
+	}
+
 	// Initialize combo and scryfall clients.
 	comboClient := combo.NewClient()
 	scryfallClient := scryfall.NewClient()
@@ -10,7 +10,10 @@ import (
 	"strings"
 
 	"github.com/mtgsim/mtgsim/internal/logger"
+	"github.com/mtgsim/mtgsim/pkg/card"
 	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+	"github.com/mtgsim/mtgsim/pkg/threat"
 )
 
 // AbilityPriority represents the priority level for different ability types.
@@ -29,6 +32,16 @@ type AIDecisionMaker struct {
 	priorities   map[EffectType]AbilityPriority
 	rng          *rand.Rand
 	comboIndices map[string]*combo.Index // keyed by player/deck name
+	// archetypes are the players' play styles, keyed by player name;
+	// players without one use the default priorities.
+	archetypes map[string]card.Archetype
+	// threatCombos is the combo index assumed for players without one of
+	// their own when ranking threats.
+	threatCombos *combo.Index
+
+	// OnThreatAssessed, if set, is called with the threat ranking behind
+	// each harmful target the AI picks on a real game.
+	OnThreatAssessed func(playerName, target string, ranked []threat.Assessment)
 }
 
 // NewAIDecisionMaker creates a new AI decision maker.
@@ -38,6 +51,7 @@ func NewAIDecisionMaker(engine *ExecutionEngine) *AIDecisionMaker {
 		priorities:   make(map[EffectType]AbilityPriority),
 		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
 		comboIndices: make(map[string]*combo.Index),
+		archetypes:   make(map[string]card.Archetype),
 	}
 	ai.initializePriorities()
 	return ai
@@ -49,6 +63,32 @@ func (ai *AIDecisionMaker) SetComboIndex(playerName string, ci *combo.Index) {
 	ai.comboIndices[playerName] = ci
 }
 
+// SetArchetype sets the play style the AI pilots a player's deck with.
+func (ai *AIDecisionMaker) SetArchetype(playerName string, a card.Archetype) {
+	ai.archetypes[playerName] = a
+}
+
+// SetThreatCombos sets the combo lines players without a combo index of
+// their own are assumed to play toward when the AI ranks threats.
+func (ai *AIDecisionMaker) SetThreatCombos(ci *combo.Index) {
+	ai.threatCombos = ci
+}
+
+// rankThreats ranks player's opponents with threat.Rank, or returns nil
+// when the engine doesn't run on a real game.
+func (ai *AIDecisionMaker) rankThreats(player AbilityPlayer) []threat.Assessment {
+	view := ai.viewFor(player)
+	if view == nil {
+		return nil
+	}
+	return threat.Rank(view, func(p *game.Player) *combo.Index {
+		if ci := ai.comboIndices[p.GetName()]; ci != nil {
+			return ci
+		}
+		return ai.threatCombos
+	})
+}
+
 // initializePriorities sets up the default priority levels for different effect types.
 func (ai *AIDecisionMaker) initializePriorities() {
 	// Mana abilities are highest priority (needed for everything else)
@@ -78,6 +118,40 @@ func (ai *AIDecisionMaker) initializePriorities() {
 	ai.priorities[PreventDamage] = PriorityLow
 }
 
+// archetypePriorities are the priorities each archetype changes from the
+// default table: aggro develops and pumps its board, control removes and
+// draws, stax taps and strips hands, and turbo combo digs for its pieces.
+var archetypePriorities = map[card.Archetype]map[EffectType]AbilityPriority{
+	card.ArchetypeAggro: {
+		PumpCreature: PriorityHigh, CreateToken: PriorityHigh, DealDamage: PriorityCritical,
+		DrawCards: PriorityMedium, SearchLibrary: PriorityMedium,
+	},
+	card.ArchetypeControl: {
+		DestroyPermanent: PriorityCritical, DrawCards: PriorityCritical,
+		PumpCreature: PriorityLow, CreateToken: PriorityLow,
+	},
+	card.ArchetypeStax: {
+		TapUntap: PriorityHigh, DiscardCards: PriorityCritical, DestroyPermanent: PriorityCritical,
+		PumpCreature: PriorityLow,
+	},
+	card.ArchetypeTurbo: {
+		SearchLibrary: PriorityCritical, DrawCards: PriorityCritical,
+		PumpCreature: PriorityLow, CreateToken: PriorityLow, GainLife: PriorityLow,
+	},
+}
+
+// priorityFor returns the priority of effect type t for player: their
+// archetype's, or the default.
+func (ai *AIDecisionMaker) priorityFor(player AbilityPlayer, t EffectType) (AbilityPriority, bool) {
+	if player != nil {
+		if p, ok := archetypePriorities[ai.archetypes[player.GetName()]][t]; ok {
+			return p, true
+		}
+	}
+	p, ok := ai.priorities[t]
+	return p, ok
+}
+
 // DecisionContext provides context for AI decision making.
 type DecisionContext struct {
 	Player              AbilityPlayer
@@ -90,6 +164,12 @@ type DecisionContext struct {
 	CanCastMoreSpells   bool
 	ComboPiecesInHand   []string // card names in hand that are combo pieces
 	MissingComboPieces  []string // card names that would complete a partial combo
+	// OpponentHandSizes and KnownOpponentCards come from the player's
+	// game.PlayerView, keyed by opponent name: hand sizes are public, and
+	// only revealed or shown cards are known. Opponents' GetHand must not
+	// be read for decisions.
+	OpponentHandSizes  map[string]int
+	KnownOpponentCards map[string][]string
 }
 
 // BoardState represents the current state of the battlefield.
@@ -192,7 +272,7 @@ func (ai *AIDecisionMaker) scoreAbility(ability *Ability, context DecisionContex
 
 	// Base score from priority
 	for _, effect := range ability.Effects {
-		priority := ai.priorities[effect.Type]
+		priority, _ := ai.priorityFor(context.Player, effect.Type)
 		switch priority {
 		case PriorityCritical:
 			baseScore += 10.0
@@ -427,6 +507,18 @@ func (ai *AIDecisionMaker) BuildDecisionContext(player AbilityPlayer, opponents
 		ctx.ThreatLevel += 3
 	}
 
+	if view := ai.viewFor(player); view != nil {
+		ctx.OpponentHandSizes = map[string]int{}
+		ctx.KnownOpponentCards = map[string][]string{}
+		for _, opp := range view.Opponents() {
+			info := view.Of(opp)
+			ctx.OpponentHandSizes[info.Name] = info.HandSize
+			for _, c := range info.KnownHand {
+				ctx.KnownOpponentCards[info.Name] = append(ctx.KnownOpponentCards[info.Name], c.Name)
+			}
+		}
+	}
+
 	// Populate combo awareness if a combo index is attached.
 	if ci := ai.comboIndices[player.GetName()]; ci != nil {
 		handNames := handCardNames(player)
@@ -437,6 +529,16 @@ func (ai *AIDecisionMaker) BuildDecisionContext(player AbilityPlayer, opponents
 	return ctx
 }
 
+// viewFor returns player's view of the game when the engine runs on a
+// real game, or nil.
+func (ai *AIDecisionMaker) viewFor(player AbilityPlayer) *game.PlayerView {
+	g, p := ai.engine.underlyingGame(), underlyingPlayer(player)
+	if g == nil || p == nil {
+		return nil
+	}
+	return g.ViewFor(p)
+}
+
 // handCardNames extracts card names from a player's hand.
 func handCardNames(player AbilityPlayer) []string {
 	hand := player.GetHand()
@@ -454,6 +556,28 @@ func (ai *AIDecisionMaker) ChooseTargetsFor(ability *Ability, context DecisionCo
 	return ai.chooseTargets(ability, context)
 }
 
+// LegalTargetsFor lists the players and permanents that can legally be
+// chosen for req by context.Player, for callers that pick targets
+// themselves.
+func (ai *AIDecisionMaker) LegalTargetsFor(req Target, context DecisionContext) []interface{} {
+	targetType := req.Type
+	if req.Enhanced != nil {
+		targetType = req.Enhanced.Type
+	}
+	var legal []interface{}
+	for _, target := range ai.getPotentialTargets(targetType, context) {
+		if ai.engine.IsLegalTarget(target, req, context.Player) {
+			legal = append(legal, target)
+		}
+	}
+	return legal
+}
+
+// ChooseModesFor exposes mode selection for a modal (ChooseMode) effect.
+func (ai *AIDecisionMaker) ChooseModesFor(effect Effect, context DecisionContext) []int {
+	return ai.chooseModes(effect, context)
+}
+
 // buildDecisionContext builds a decision context for the given player.
 func (ai *AIDecisionMaker) buildDecisionContext(player AbilityPlayer, phase string) DecisionContext {
 	// Calculate available mana
@@ -486,7 +610,7 @@ func (ai *AIDecisionMaker) buildDecisionContext(player AbilityPlayer, phase stri
 		}
 		// Count artifacts and enchantments on the battlefield
 		if named, ok := c.(interface{ GetName() string }); ok {
-			if isHighUtilityPermanent(named.GetName()) {
+			if isHighUtilityPermanent(named) {
 				boardState.MyUtilityPerms++
 			}
 		}
@@ -541,7 +665,7 @@ func estimateUtilityPermanents(player AbilityPlayer) int {
 	// Lands are not utility for this heuristic; artifacts/enchantments are.
 	for _, perm := range player.GetLands() {
 		if named, ok := perm.(interface{ GetName() string }); ok {
-			if isHighUtilityPermanent(named.GetName()) {
+			if isHighUtilityPermanent(named) {
 				utility++
 			}
 		}
@@ -554,7 +678,7 @@ func estimateLockPermanents(opponent AbilityPlayer) int {
 	lock := 0
 	for _, perm := range opponent.GetLands() {
 		if named, ok := perm.(interface{ GetName() string }); ok {
-			if isLockPiece(named.GetName()) {
+			if isLockPiece(named) {
 				lock++
 			}
 		}
@@ -562,33 +686,28 @@ func estimateLockPermanents(opponent AbilityPlayer) int {
 	return lock
 }
 
-// isHighUtilityPermanent heuristically detects combo-enablers and engines.
-func isHighUtilityPermanent(name string) bool {
-	switch name {
-	case "Sol Ring", "Mana Crypt", "Grim Monolith", "Basalt Monolith",
-		"Gilded Lotus", "Thran Dynamo", "Chrome Mox", "Mox Diamond",
-		"Mind's Eye", "Rhystic Study", "Mystic Remora",
-		"Sylvan Library", "Sensei's Divining Top", "Skullclamp",
-		"Ashnod's Altar", "Phyrexian Altar", "Earthcraft",
-		"Intruder Alarm", "Training Grounds":
-		return true
-	}
-	return false
+// isHighUtilityPermanent reports whether perm is a combo enabler or an
+// engine: a mana rock or dork, or a card advantage engine.
+func isHighUtilityPermanent(perm any) bool {
+	return permanentRoles(perm).HasAny(card.RoleRamp, card.RoleDrawEngine)
 }
 
-// isLockPiece heuristically detects stax/prison pieces that shut down strategies.
-func isLockPiece(name string) bool {
-	switch name {
-	case "Null Rod", "Stony Silence", "Collector Ouphe", "Kataki, War's Wage",
-		"Rule of Law", "Eidolon of Rhetoric", "Arcane Laboratory",
-		"Blood Moon", "Magus of the Moon", "Contamination",
-		"Trinisphere", "Sphere of Resistance", "Thorn of Amethyst",
-		"Thalia, Guardian of Thraben", "Glowrider", "Vryn Wingmare",
-		"Armageddon", "Ravages of War", "Smokestack", "Tangle Wire",
-		"Static Orb", "Winter Orb":
-		return true
+// isLockPiece reports whether perm is a stax or prison piece: one whose
+// static abilities restrict what players can do.
+func isLockPiece(perm any) bool {
+	return permanentRoles(perm).Has(card.RoleStax)
+}
+
+// permanentRoles returns the card roles of perm. A permanent known only
+// by name gets the roles its name alone gives away.
+func permanentRoles(perm any) card.Roles {
+	switch p := perm.(type) {
+	case interface{ GetSource() game.SimpleCard }:
+		return card.ClassifyRoles(p.GetSource())
+	case interface{ GetName() string }:
+		return card.ClassifyRoles(game.SimpleCard{Name: p.GetName()})
 	}
-	return false
+	return nil
 }
 
 // chooseTargets chooses targets for an ability using enhanced targeting validation.
@@ -645,7 +764,7 @@ func (ai *AIDecisionMaker) chooseModes(effect Effect, context DecisionContext) [
 	for i, mode := range effect.Modes {
 		s := 1.0
 		// Score based on effect type priority
-		if p, ok := ai.priorities[mode.Type]; ok {
+		if p, ok := ai.priorityFor(context.Player, mode.Type); ok {
 			s += float64(p)
 		}
 		// Context-aware scoring
@@ -844,40 +963,12 @@ func (ai *AIDecisionMaker) chooseBestTarget(validTargets []interface{}, effect E
 	// Simple strategy: prefer opponents for harmful effects, self for beneficial effects
 	switch effect.Type {
 	case DealDamage, DestroyPermanent, LoseLife, TapUntap, DiscardCards:
-		// Prefer opponent targets, especially lock pieces or combo pieces when destroying/tapping
-		for _, target := range validTargets {
-			if player, ok := target.(AbilityPlayer); ok {
-				if player.GetName() != context.Player.GetName() {
-					return target
-				}
-			}
-		}
-		// For permanents: prefer opponent combo pieces, then lock pieces
-		for _, target := range validTargets {
-			if named, ok := target.(interface{ GetName() string; GetControllerName() string }); ok {
-				if named.GetControllerName() != context.Player.GetName() {
-					if isOpponentComboPiece(named.GetName()) {
-						return target
-					}
-				}
-			}
-		}
-		for _, target := range validTargets {
-			if named, ok := target.(interface{ GetName() string; GetControllerName() string }); ok {
-				if named.GetControllerName() != context.Player.GetName() {
-					if isLockPiece(named.GetName()) {
-						return target
-					}
-				}
-			}
-		}
-		// Fallback: any opponent permanent
-		for _, target := range validTargets {
-			if named, ok := target.(interface{ GetName() string; GetControllerName() string }); ok {
-				if named.GetControllerName() != context.Player.GetName() {
-					return target
-				}
+		ranked := ai.rankThreats(context.Player)
+		if target := mostThreateningTarget(validTargets, context, ranked, isOpponentComboPiece); target != nil {
+			if ai.OnThreatAssessed != nil && len(ranked) > 0 {
+				ai.OnThreatAssessed(context.Player.GetName(), targetName(target), ranked)
 			}
+			return target
 		}
 	case DrawCards, GainLife, PumpCreature:
 		// Prefer own targets; for pump, prefer our combo-enabling creatures
@@ -901,7 +992,7 @@ func (ai *AIDecisionMaker) chooseBestTarget(validTargets []interface{}, effect E
 		for _, target := range validTargets {
 			if named, ok := target.(interface{ GetName() string; GetControllerName() string }); ok {
 				if named.GetControllerName() == context.Player.GetName() {
-					if isHighUtilityPermanent(named.GetName()) {
+					if isHighUtilityPermanent(named) {
 						return target
 					}
 				}
@@ -919,3 +1010,67 @@ func (ai *AIDecisionMaker) chooseBestTarget(validTargets []interface{}, effect E
 	// If no preference-based target found, return the first valid target
 	return validTargets[0]
 }
+
+// mostThreateningTarget picks the opponent or opponent permanent a harmful
+// effect should hit. Opponent players come first, the highest in ranked
+// (threat.Rank's order) best. Among permanents, combo pieces beat lock
+// pieces, which beat the rest, and each is weighted by its controller's
+// chance to win next turn. Ties keep the order of validTargets. Returns
+// nil if no target belongs to an opponent.
+func mostThreateningTarget(validTargets []interface{}, context DecisionContext, ranked []threat.Assessment, isOpponentComboPiece func(string) bool) interface{} {
+	rankOf := func(name string) (int, float64) {
+		for i, a := range ranked {
+			if a.Name == name {
+				return i, a.WinChance
+			}
+		}
+		return len(ranked), 0
+	}
+	me := context.Player.GetName()
+
+	var bestPlayer interface{}
+	bestRank := 0
+	for _, target := range validTargets {
+		if player, ok := target.(AbilityPlayer); ok && player.GetName() != me {
+			if rank, _ := rankOf(player.GetName()); bestPlayer == nil || rank < bestRank {
+				bestPlayer, bestRank = target, rank
+			}
+		}
+	}
+	if bestPlayer != nil {
+		return bestPlayer
+	}
+
+	var best interface{}
+	bestScore := 0
+	for _, target := range validTargets {
+		named, ok := target.(interface {
+			GetName() string
+			GetControllerName() string
+		})
+		if !ok || named.GetControllerName() == me {
+			continue
+		}
+		score := 1
+		switch {
+		case isOpponentComboPiece(named.GetName()):
+			score += 300
+		case isLockPiece(named):
+			score += 150
+		}
+		_, win := rankOf(named.GetControllerName())
+		score += int(100 * win)
+		if best == nil || score > bestScore {
+			best, bestScore = target, score
+		}
+	}
+	return best
+}
+
+// targetName names a chosen target for logs.
+func targetName(target interface{}) string {
+	if named, ok := target.(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package ability
+
+import "github.com/mtgsim/mtgsim/pkg/game"
+
+// AIDecisionMaker implements game.LibraryChooser so tutors, fetchlands,
+// scry, surveil and dig effects find and keep the cards the AI wants.
+var _ game.LibraryChooser = (*AIDecisionMaker)(nil)
+
+// libraryCardScore extends game.LibraryCardScore with combo awareness: a
+// card that completes a combo with the current hand beats everything, and
+// other combo pieces beat generic spells.
+func (ai *AIDecisionMaker) libraryCardScore(p *game.Player, c game.SimpleCard) int {
+	score := game.LibraryCardScore(p, c)
+	ci := ai.comboIndices[p.GetName()]
+	if ci == nil {
+		return score
+	}
+	hand := make([]string, 0, len(p.Hand))
+	for _, h := range p.Hand {
+		hand = append(hand, h.Name)
+	}
+	for _, missing := range ci.MissingPiecesForHand(hand) {
+		if missing == c.Name {
+			return score + 40
+		}
+	}
+	if ci.IsComboPiece(c.Name) {
+		score += 20
+	}
+	return score
+}
+
+// ChooseSearch picks the highest-scoring candidates.
+func (ai *AIDecisionMaker) ChooseSearch(p *game.Player, candidates []game.SimpleCard, max int) []int {
+	order := game.RankCards(p, candidates, ai.libraryCardScore)
+	if len(order) > max {
+		order = order[:max]
+	}
+	return order
+}
+
+// ChooseScry keeps cards worth drawing on top, best first.
+func (ai *AIDecisionMaker) ChooseScry(p *game.Player, cards []game.SimpleCard) (top, bottom []int) {
+	return ai.splitLibraryCards(p, cards)
+}
+
+// ChooseSurveil keeps cards worth drawing on top and bins the rest.
+func (ai *AIDecisionMaker) ChooseSurveil(p *game.Player, cards []game.SimpleCard) (top, graveyard []int) {
+	return ai.splitLibraryCards(p, cards)
+}
+
+// ChooseFromTop takes the best matching cards and bottoms the rest.
+func (ai *AIDecisionMaker) ChooseFromTop(p *game.Player, cards []game.SimpleCard, take int, filter game.CardFilter) (taken, bottom []int) {
+	return game.TakeBest(p, cards, take, filter, ai.libraryCardScore)
+}
+
+func (ai *AIDecisionMaker) splitLibraryCards(p *game.Player, cards []game.SimpleCard) (keep, away []int) {
+	for _, i := range game.RankCards(p, cards, ai.libraryCardScore) {
+		if ai.libraryCardScore(p, cards[i]) >= game.LibraryKeepThreshold {
+			keep = append(keep, i)
+		} else {
+			away = append(away, i)
+		}
+	}
+	return keep, away
+}
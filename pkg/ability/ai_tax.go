@@ -0,0 +1,19 @@
+package ability
+
+// AIDecisionMaker implements TaxDecider for Mana Leak and Rhystic Study
+// style payments.
+var _ TaxDecider = (*AIDecisionMaker)(nil)
+
+// maxCourtesyTax is the largest tax the AI pays to deny an opponent a
+// card or other value, as opposed to saving its own spell.
+const maxCourtesyTax = 1
+
+// ShouldPayTax always saves the AI's own spell from a tax counter when it
+// can afford to, and pays small taxes that would otherwise give an opponent
+// value.
+func (ai *AIDecisionMaker) ShouldPayTax(payer AbilityPlayer, amount int, effect Effect) bool {
+	if effect.Type == CounterSpell {
+		return true
+	}
+	return amount <= maxCourtesyTax
+}
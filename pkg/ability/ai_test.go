@@ -0,0 +1,40 @@
+package ability
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+func TestBuildDecisionContext_SeesOnlyKnownOpponentCards(t *testing.T) {
+	engine, sp, g := newScriptHarness(t)
+	p2 := g.GetPlayersRaw()[1]
+	p2.Hand = []game.SimpleCard{{Name: "Counterspell", TypeLine: "Instant"}, {Name: "Island", TypeLine: "Basic Land — Island"}}
+	ai := NewAIDecisionMaker(engine)
+
+	ctx := ai.BuildDecisionContext(sp, nil, "main")
+	if ctx.OpponentHandSizes["P2"] != 2 || len(ctx.KnownOpponentCards["P2"]) != 0 {
+		t.Fatalf("expected P2's hand size only, got sizes=%v known=%v", ctx.OpponentHandSizes, ctx.KnownOpponentCards)
+	}
+
+	g.ShowCards(sp.p, p2, p2.Hand[0])
+	ctx = ai.BuildDecisionContext(sp, nil, "main")
+	if known := ctx.KnownOpponentCards["P2"]; len(known) != 1 || known[0] != "Counterspell" {
+		t.Fatalf("expected the shown Counterspell to be known, got %v", known)
+	}
+}
+
+func TestScoreAbility_FollowsThePlayersArchetype(t *testing.T) {
+	engine, sp, _ := newScriptHarness(t)
+	ai := NewAIDecisionMaker(engine)
+	pump := &Ability{Type: Activated, Effects: []Effect{{Type: PumpCreature, Value: 1}}}
+	ctx := DecisionContext{Player: sp, HandSize: 5}
+
+	ai.SetArchetype(sp.GetName(), card.ArchetypeControl)
+	control := ai.scoreAbility(pump, ctx)
+	ai.SetArchetype(sp.GetName(), card.ArchetypeAggro)
+	if aggro := ai.scoreAbility(pump, ctx); aggro <= control {
+		t.Errorf("aggro should value a pump above control: aggro %.1f, control %.1f", aggro, control)
+	}
+}
@@ -0,0 +1,545 @@
+package ability
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Hand-written implementations of cards whose oracle text is beyond the
+// parser. Each script follows the card's rules text; where a card asks its
+// controller for a choice, the script makes the choice a competent player
+// would and the comment says what it picks.
+
+func init() {
+	RegisterCardScript("Thassa's Oracle", CardScript{Abilities: thassasOracleAbilities})
+	RegisterCardScript("Demonic Consultation", CardScript{Abilities: demonicConsultationAbilities})
+	RegisterCardScript("Tainted Pact", CardScript{Abilities: taintedPactAbilities})
+	RegisterCardScript("Ad Nauseam", CardScript{Abilities: adNauseamAbilities})
+	RegisterCardScript("Necropotence", CardScript{
+		Abilities:     necropotenceAbilities,
+		OnBattlefield: necropotenceOnBattlefield,
+	})
+	RegisterCardScript("Twinflame", CardScript{Abilities: twinflameAbilities})
+	RegisterCardScript("Dualcaster Mage", CardScript{Abilities: dualcasterMageAbilities})
+	RegisterCardScript("Godo, Bandit Warlord", CardScript{
+		Abilities:     godoAbilities,
+		OnBattlefield: godoOnBattlefield,
+	})
+	RegisterCardScript("Helm of the Host", CardScript{
+		Abilities:     helmOfTheHostAbilities,
+		OnBattlefield: helmOfTheHostOnBattlefield,
+	})
+}
+
+// emptyLibraryPayoffs win or survive when their controller's library is
+// empty; Demonic Consultation names a missing card when one is available.
+var emptyLibraryPayoffs = []string{"Thassa's Oracle", "Laboratory Maniac", "Jace, Wielder of Mysteries"}
+
+// adNauseamLifeFloor is the life total Ad Nauseam stops revealing above.
+const adNauseamLifeFloor = 6
+
+// dualcasterLoopCap is the most Dualcaster Mages the Twinflame loop makes
+// before it stops choosing new targets, in case the copies never get to
+// lethal.
+const dualcasterLoopCap = 100
+
+// When Thassa's Oracle enters, look at the top X cards of your library, where
+// X is your devotion to blue. Put up to one of them on top of your library and
+// the rest on the bottom of your library in a random order. If X is greater
+// than or equal to the number of cards in your library, you win the game.
+func thassasOracleAbilities(source any) []*Ability {
+	text := "When Thassa's Oracle enters, look at the top X cards of your library, where X is your devotion to blue. Put up to one of them on top of your library and the rest on the bottom of your library in a random order. If X is greater than or equal to the number of cards in your library, you win the game."
+	return []*Ability{{
+		Name:             "Thassa's Oracle ETB",
+		Type:             Triggered,
+		TriggerCondition: EntersTheBattlefield,
+		OracleText:       text,
+		Effects: []Effect{scriptEffect("Look at the top X cards; win if X is at least your library size", func(ctx *ScriptContext) error {
+			p := ctx.Controller
+			x := devotion(p, "U")
+			if x >= len(p.Library) {
+				ctx.Game.WinGame(p, "effect")
+				return nil
+			}
+			oracleLook(p, x)
+			return nil
+		})},
+	}}
+}
+
+// oracleLook keeps the best of the top n cards of p's library on top and
+// puts the rest on the bottom in a random order.
+func oracleLook(p *game.Player, n int) {
+	n = min(n, len(p.Library))
+	if n <= 0 {
+		return
+	}
+	seen := append([]game.SimpleCard(nil), p.Library[:n]...)
+	p.Library = p.Library[n:]
+	keep, rest := game.TakeBest(p, seen, 1, nil, game.LibraryCardScore)
+	bottom := make([]game.SimpleCard, len(rest))
+	for i, j := range rand.Perm(len(rest)) {
+		bottom[i] = seen[rest[j]]
+	}
+	p.PutOnBottom(bottom...)
+	for _, i := range keep {
+		p.PutOnTop(seen[i])
+	}
+}
+
+// Choose a card name. Exile the top six cards of your library, then reveal
+// cards from the top of your library until you reveal a card with the chosen
+// name. Put that card into your hand and exile all other cards revealed this
+// way.
+func demonicConsultationAbilities(source any) []*Ability {
+	text := "Choose a card name. Exile the top six cards of your library, then reveal cards from the top of your library until you reveal a card with the chosen name. Put that card into your hand and exile all other cards revealed this way."
+	return []*Ability{{
+		Name:       "Spell",
+		Type:       Activated,
+		OracleText: text,
+		Effects: []Effect{scriptEffect("Exile the top six, then reveal until the named card", func(ctx *ScriptContext) error {
+			p := ctx.Controller
+			name := consultationName(p)
+			exiled := min(6, len(p.Library))
+			p.Exile = append(p.Exile, p.Library[:exiled]...)
+			p.Library = p.Library[exiled:]
+			for len(p.Library) > 0 {
+				top := p.Library[0]
+				p.Library = p.Library[1:]
+				if name != "" && top.Name == name {
+					p.Hand = append(p.Hand, top)
+					return nil
+				}
+				p.Exile = append(p.Exile, top)
+			}
+			return nil
+		})},
+	}}
+}
+
+// consultationName names a card that isn't in the library when p can win
+// off an empty library this turn, and otherwise the most expensive nonland
+// card below the top six. The empty name exiles the whole library.
+func consultationName(p *game.Player) string {
+	if canFinishOnEmptyLibrary(p) {
+		return ""
+	}
+	best, bestMV := "", -1
+	for i := 6; i < len(p.Library); i++ {
+		c := p.Library[i]
+		if !c.IsLand() && c.ManaValue() > bestMV {
+			best, bestMV = c.Name, c.ManaValue()
+		}
+	}
+	return best
+}
+
+// Exile the top card of your library. You may put that card into your hand
+// unless it has the same name as another card exiled this way. Repeat this
+// process until you put a card into your hand or you exile two cards with
+// the same name, whichever comes first.
+//
+// The script keeps exiling when p can win off an empty library this turn,
+// and otherwise takes the first nonland card.
+func taintedPactAbilities(source any) []*Ability {
+	text := "Exile the top card of your library. You may put that card into your hand unless it has the same name as another card exiled this way. Repeat this process until you put a card into your hand or you exile two cards with the same name, whichever comes first."
+	return []*Ability{{
+		Name:       "Spell",
+		Type:       Activated,
+		OracleText: text,
+		Effects: []Effect{scriptEffect("Exile from the top until taking a card or exiling a duplicate name", func(ctx *ScriptContext) error {
+			p := ctx.Controller
+			dig := canFinishOnEmptyLibrary(p)
+			seen := map[string]bool{}
+			for len(p.Library) > 0 {
+				top := p.Library[0]
+				p.Library = p.Library[1:]
+				if seen[top.Name] {
+					p.Exile = append(p.Exile, top)
+					return nil
+				}
+				seen[top.Name] = true
+				if !dig && !top.IsLand() {
+					p.Hand = append(p.Hand, top)
+					return nil
+				}
+				p.Exile = append(p.Exile, top)
+			}
+			return nil
+		})},
+	}}
+}
+
+// Reveal the top card of your library and put that card into your hand. You
+// lose life equal to its mana value. You may repeat this process any number
+// of times.
+func adNauseamAbilities(source any) []*Ability {
+	text := "Reveal the top card of your library and put that card into your hand. You lose life equal to its mana value. You may repeat this process any number of times."
+	return []*Ability{{
+		Name:       "Spell",
+		Type:       Activated,
+		OracleText: text,
+		Effects: []Effect{scriptEffect("Reveal and draw, losing life equal to mana value, until stopping", func(ctx *ScriptContext) error {
+			p := ctx.Controller
+			for first := true; len(p.Library) > 0; first = false {
+				top := p.Library[0]
+				if !first && p.GetLifeTotal()-top.ManaValue() <= adNauseamLifeFloor {
+					break
+				}
+				p.Reveal(top)
+				p.Library = p.Library[1:]
+				p.Hand = append(p.Hand, top)
+				ctx.Game.LoseLife(p, top.ManaValue())
+			}
+			ctx.Game.ApplyStateBasedActions()
+			return nil
+		})},
+	}}
+}
+
+// Skip your draw step.
+// Whenever you discard a card, exile that card from your graveyard.
+// Pay 1 life: Exile the top card of your library face down. Put that card
+// into your hand at the beginning of your next end step.
+//
+// The discard trigger is not modelled: the engine has no discard event, and
+// the card only matters to graveyard recursion.
+func necropotenceAbilities(source any) []*Ability {
+	return []*Ability{{
+		Name:       "Activated Ability",
+		Type:       Activated,
+		Cost:       Cost{LifeCost: 1},
+		OracleText: "Pay 1 life: Exile the top card of your library face down. Put that card into your hand at the beginning of your next end step.",
+		Effects: []Effect{scriptEffect("Exile the top card face down until your next end step", func(ctx *ScriptContext) error {
+			p := ctx.Controller
+			if len(p.Library) == 0 {
+				return nil
+			}
+			top := p.Library[0]
+			p.Library = p.Library[1:]
+			p.Exile = append(p.Exile, top)
+			ctx.Game.ScheduleAtNextEndStep(game.DelayedAction{Player: p, Card: top, Do: returnExiledCard})
+			return nil
+		})},
+	}}
+}
+
+// Strive — This spell costs {2}{R} more to cast for each target beyond the
+// first.
+// Choose any number of target creatures you control. For each of them,
+// create a token that's a copy of that creature, except it has haste. Exile
+// them at the beginning of the next end step.
+//
+// The script takes at most one target; casting it with none is legal and
+// is how the Dualcaster Mage loop starts.
+func twinflameAbilities(source any) []*Ability {
+	text := "Strive — This spell costs {2}{R} more to cast for each target beyond the first.\nChoose any number of target creatures you control. For each of them, create a token that's a copy of that creature, except it has haste. Exile them at the beginning of the next end step."
+	copyCreatures := scriptEffect("Create hasty token copies of target creatures you control", func(ctx *ScriptContext) error {
+		for _, t := range ctx.Targets {
+			perm := scriptPermanent(t)
+			if perm == nil || !perm.IsCreature() || perm.GetController() != ctx.Controller {
+				continue
+			}
+			token := ctx.Controller.PutTokenOnBattlefield(perm.GetSource())
+			token.GrantKeyword(game.KWHaste)
+			token.SetEnteredTurn(ctx.Game.GetTurnNumber())
+			ctx.Game.ScheduleAtNextEndStep(game.DelayedAction{
+				Player:    ctx.Game.GetActivePlayerRaw(),
+				Permanent: token,
+				Do:        func(_ *game.Game, d game.DelayedAction) { removeToken(d.Permanent) },
+			})
+			if ctx.Stack != nil {
+				ctx.Stack.AddEntersTriggers(token, ctx.Player)
+			}
+		}
+		return nil
+	})
+	copyCreatures.Targets = []Target{{Type: CreatureTarget, Count: 1}}
+	return []*Ability{{
+		Name:       "Spell",
+		Type:       Activated,
+		OracleText: text,
+		Effects:    []Effect{copyCreatures},
+	}}
+}
+
+// Flash
+// When Dualcaster Mage enters, copy target instant or sorcery spell. You may
+// choose new targets for the copy.
+//
+// When the spell makes token copies of its controller's creatures
+// (Twinflame), the copy targets the newest Dualcaster Mage until the hasty
+// copies are lethal, then targets nothing, which ends the loop.
+func dualcasterMageAbilities(source any) []*Ability {
+	text := "When Dualcaster Mage enters, copy target instant or sorcery spell. You may choose new targets for the copy."
+	copySpell := scriptEffect("Copy target instant or sorcery spell", func(ctx *ScriptContext) error {
+		if ctx.Stack == nil || len(ctx.Targets) == 0 {
+			return nil
+		}
+		item, ok := ctx.Targets[0].(*StackItem)
+		if !ok || item.Spell == nil {
+			return nil
+		}
+		var targets []any
+		if copiesOwnCreatures(item.Spell) {
+			targets = []any{}
+			if mage := newestPermanent(ctx.Controller, "Dualcaster Mage"); mage != nil && !hastyLethal(ctx.Game, ctx.Controller) &&
+				countPermanents(ctx.Controller, "Dualcaster Mage") < dualcasterLoopCap {
+				targets = []any{mage}
+			}
+		}
+		ctx.Stack.CopyItem(item, ctx.Player, targets)
+		return nil
+	})
+	copySpell.Targets = []Target{{Type: SpellTarget, Required: true, Count: 1}}
+	return []*Ability{{
+		Name:             "Dualcaster Mage ETB",
+		Type:             Triggered,
+		TriggerCondition: EntersTheBattlefield,
+		OracleText:       text,
+		Effects:          []Effect{copySpell},
+	}}
+}
+
+// When Godo, Bandit Warlord enters, you may search your library for an
+// Equipment card, put it onto the battlefield, then shuffle.
+// Whenever Godo attacks for the first time each turn, untap all Samurai you
+// control. After this phase, there is an additional combat phase.
+//
+// The attack trigger is a game trigger (godoOnBattlefield); the engine's
+// abilities have no attack condition.
+func godoAbilities(source any) []*Ability {
+	text := "When Godo, Bandit Warlord enters, you may search your library for an Equipment card, put it onto the battlefield, then shuffle."
+	return []*Ability{{
+		Name:             "Godo, Bandit Warlord ETB",
+		Type:             Triggered,
+		TriggerCondition: EntersTheBattlefield,
+		OracleText:       text,
+		Effects: []Effect{scriptEffect("Search for an Equipment and put it onto the battlefield", func(ctx *ScriptContext) error {
+			ctx.Game.SearchLibrary(ctx.Controller, game.LibrarySearch{
+				Filter:  func(c game.SimpleCard) bool { return strings.Contains(c.TypeLine, "Equipment") },
+				Max:     1,
+				Dest:    game.Battlefield,
+				Shuffle: true,
+			}, nil)
+			return nil
+		})},
+	}}
+}
+
+func godoOnBattlefield(g *game.Game, perm *game.Permanent) {
+	g.AddTrigger(&game.Trigger{
+		On:         game.EventAttacks,
+		Controller: perm.GetController(),
+		Source:     perm,
+		Condition: func(t *game.Trigger, e game.Event) bool {
+			return e.Attack != nil && e.Attack.Attacker == t.Source
+		},
+		Action: func(g *game.Game, t *game.Trigger, e game.Event) {
+			if t.Source.AttacksThisTurn(g.GetTurnNumber()) != 1 {
+				return
+			}
+			for _, samurai := range t.Controller.Battlefield {
+				if strings.Contains(samurai.GetSource().TypeLine, "Samurai") {
+					samurai.Untap()
+				}
+			}
+			g.AddCombatPhase()
+		},
+	})
+}
+
+// At the beginning of combat on your turn, create a token that's a copy of
+// equipped creature, except the token isn't legendary. That token gains
+// haste.
+// Equip {5}
+//
+// The combat trigger is a game trigger (helmOfTheHostOnBattlefield).
+func helmOfTheHostAbilities(source any) []*Ability {
+	equip := scriptEffect("Attach to target creature you control", func(ctx *ScriptContext) error {
+		helm := scriptPermanent(source)
+		if helm == nil || len(ctx.Targets) == 0 {
+			return nil
+		}
+		if target := scriptPermanent(ctx.Targets[0]); target != nil && target.IsCreature() && target.GetController() == ctx.Controller {
+			helm.AttachTo(target)
+		}
+		return nil
+	})
+	equip.Targets = []Target{{Type: CreatureTarget, Required: true, Count: 1}}
+	return []*Ability{{
+		Name:              "Equip",
+		Type:              Activated,
+		Cost:              Cost{ManaCost: map[game.ManaType]int{game.Any: 5}},
+		TimingRestriction: SorcerySpeed,
+		OracleText:        "Equip {5}",
+		Effects:           []Effect{equip},
+	}}
+}
+
+func helmOfTheHostOnBattlefield(g *game.Game, perm *game.Permanent) {
+	g.AddTrigger(&game.Trigger{
+		On:         game.EventBeginningOfCombat,
+		Controller: perm.GetController(),
+		Source:     perm,
+		Action: func(g *game.Game, t *game.Trigger, e game.Event) {
+			equipped := t.Source.GetAttachedTo()
+			if g.GetActivePlayerRaw() != t.Controller || !onBattlefield(t.Source) || equipped == nil || !onBattlefield(equipped) {
+				return
+			}
+			copied := equipped.GetSource()
+			copied.TypeLine = strings.TrimSpace(strings.Replace(copied.TypeLine, "Legendary", "", 1))
+			token := g.CreateToken(t.Controller, copied)
+			token.GrantKeyword(game.KWHaste)
+		},
+	})
+}
+
+// onBattlefield reports whether perm is still on its controller's
+// battlefield.
+func onBattlefield(perm *game.Permanent) bool {
+	for _, p := range perm.GetController().Battlefield {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// copiesOwnCreatures reports whether spell makes token copies of creatures
+// its controller controls, as Twinflame does.
+func copiesOwnCreatures(spell *Spell) bool {
+	text := strings.ToLower(spell.OracleText)
+	return strings.Contains(text, "creatures you control") && strings.Contains(text, "token that's a copy of")
+}
+
+// scriptPermanent unwraps a target into the permanent behind it.
+func scriptPermanent(target any) *game.Permanent {
+	switch t := target.(type) {
+	case *game.Permanent:
+		return t
+	case interface{ Underlying() *game.Permanent }:
+		return t.Underlying()
+	}
+	return nil
+}
+
+// removeToken takes a token off the battlefield; tokens cease to exist
+// outside it.
+// returnExiledCard puts d.Card from d.Player's exile into their hand.
+func returnExiledCard(_ *game.Game, d game.DelayedAction) {
+	p := d.Player
+	for i, c := range p.Exile {
+		if c.Name == d.Card.Name {
+			p.Exile = append(p.Exile[:i], p.Exile[i+1:]...)
+			p.Hand = append(p.Hand, c)
+			return
+		}
+	}
+}
+
+func removeToken(token *game.Permanent) {
+	p := token.GetController()
+	for i, perm := range p.Battlefield {
+		if perm == token {
+			p.Battlefield = append(p.Battlefield[:i], p.Battlefield[i+1:]...)
+			return
+		}
+	}
+}
+
+func newestPermanent(p *game.Player, name string) *game.Permanent {
+	for i := len(p.Battlefield) - 1; i >= 0; i-- {
+		if p.Battlefield[i].GetName() == name {
+			return p.Battlefield[i]
+		}
+	}
+	return nil
+}
+
+func countPermanents(p *game.Player, name string) int {
+	n := 0
+	for _, perm := range p.Battlefield {
+		if perm.GetName() == name {
+			n++
+		}
+	}
+	return n
+}
+
+// hastyLethal reports whether the creatures p could attack with this turn
+// have at least as much power as p's opponents have life.
+func hastyLethal(g *game.Game, p *game.Player) bool {
+	power := 0
+	for _, perm := range p.GetCreatures() {
+		if perm.GetEnteredTurn() < g.GetTurnNumber() || perm.HasKeyword(game.KWHaste) {
+			power += perm.GetPower()
+		}
+	}
+	life := 0
+	for _, opp := range g.GetPlayersRaw() {
+		if opp != p && !opp.HasLost() {
+			life += opp.GetLifeTotal()
+		}
+	}
+	return life > 0 && power >= life
+}
+
+func necropotenceOnBattlefield(g *game.Game, perm *game.Permanent) {
+	g.RegisterStaticEffect(&game.StaticEffect{
+		Type:        game.SkipDrawStep,
+		Source:      perm,
+		Controller:  perm.GetController(),
+		Description: "Skip your draw step.",
+	})
+}
+
+// devotion counts the mana symbols of color among the mana costs of
+// permanents p controls; hybrid symbols count toward each of their colors.
+func devotion(p *game.Player, color string) int {
+	n := 0
+	for _, perm := range p.Battlefield {
+		cost := perm.GetSource().ManaCost
+		for {
+			open := strings.Index(cost, "{")
+			if open < 0 {
+				break
+			}
+			end := strings.Index(cost[open:], "}")
+			if end < 0 {
+				break
+			}
+			sym := cost[open+1 : open+end]
+			cost = cost[open+end+1:]
+			for _, part := range strings.Split(sym, "/") {
+				if part == color {
+					n++
+					break
+				}
+			}
+		}
+	}
+	return n
+}
+
+// canFinishOnEmptyLibrary reports whether p can still cast an empty-library
+// payoff this turn, or already controls one that wins on its next draw.
+// Thassa's Oracle on the battlefield doesn't count: its check is an
+// enters trigger.
+func canFinishOnEmptyLibrary(p *game.Player) bool {
+	for _, payoff := range emptyLibraryPayoffs {
+		for _, c := range p.Hand {
+			if c.Name == payoff && p.CanPayForCard(c) {
+				return true
+			}
+		}
+		for _, perm := range p.Battlefield {
+			if perm.GetName() == payoff && payoff != "Thassa's Oracle" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package ability
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+var costKindNames = map[CostKind]string{
+	SacrificePermanents:  "sacrifice",
+	ExileFromGraveyard:   "exile-graveyard",
+	RemoveCounters:       "remove-counters",
+	TapUntappedCreatures: "tap-untapped",
+	ReturnLands:          "return",
+	LoyaltyCost:          "loyalty",
+	ExertSource:          "exert",
+}
+
+// String renders the cost for coverage reports, e.g. "sacrifice creature=1".
+func (c TypedCost) String() string {
+	name := costKindNames[c.Kind]
+	switch c.Kind {
+	case ExertSource:
+		return name
+	case LoyaltyCost:
+		return fmt.Sprintf("%s=%+d", name, c.Count)
+	case RemoveCounters:
+		return fmt.Sprintf("%s %s=%d", name, c.CounterType, c.Count)
+	case TapUntappedCreatures:
+		return fmt.Sprintf("%s creature=%d", name, c.Count)
+	case ReturnLands:
+		return fmt.Sprintf("%s land=%d", name, c.Count)
+	}
+	return fmt.Sprintf("%s %s=%d", name, c.Filter, c.Count)
+}
+
+// matches reports whether c's Filter admits a card with the given type line.
+func (c TypedCost) matches(sc game.SimpleCard) bool {
+	switch c.Filter {
+	case "", "card", "permanent":
+		return true
+	case "creature":
+		return sc.IsCreature()
+	case "land":
+		return sc.IsLand()
+	case "artifact":
+		return sc.IsArtifact()
+	case "enchantment":
+		return sc.IsEnchantment()
+	case "planeswalker":
+		return sc.IsPlaneswalker()
+	case "instant":
+		return sc.IsInstant()
+	case "sorcery":
+		return sc.IsSorcery()
+	}
+	return false
+}
+
+func hasTypedCost(ab *Ability, kind CostKind) bool {
+	for _, c := range ab.Cost.Typed {
+		if c.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// costStep is one planned part of an ability's additional costs. undo is
+// nil for steps that move cards between zones; those are paid last, once
+// nothing else can fail.
+type costStep struct {
+	pay  func()
+	undo func()
+}
+
+// planTypedCosts chooses the permanents and cards that pay ab's
+// additional costs without changing anything. ok is false when some cost
+// can't be paid. Each permanent pays at most one cost, and the source only
+// pays costs that name it.
+func (ee *ExecutionEngine) planTypedCosts(ab *Ability, controller AbilityPlayer) (steps []costStep, ok bool) {
+	if len(ab.Cost.Typed) == 0 {
+		return nil, true
+	}
+	p := underlyingPlayer(controller)
+	if p == nil {
+		return nil, false
+	}
+	g := ee.underlyingGame()
+	src := scriptPermanent(ab.Source)
+	used := map[*game.Permanent]bool{src: true}
+	exiled := map[int]bool{}
+
+	for _, c := range ab.Cost.Typed {
+		c := c
+		switch c.Kind {
+		case SacrificePermanents:
+			perms := choosePermanents(p, used, c.Count, func(perm *game.Permanent) bool { return c.matches(perm.GetSource()) }, byManaValue)
+			if perms == nil {
+				return nil, false
+			}
+			for _, perm := range perms {
+				perm := perm
+				steps = append(steps, costStep{pay: func() {
+					if g != nil {
+						g.SacrificePermanent(perm)
+					} else {
+						p.DestroyPermanent(perm)
+					}
+				}})
+			}
+		case ExileFromGraveyard:
+			var names []string
+			for i := 0; i < len(p.Graveyard) && len(names) < c.Count; i++ {
+				if !exiled[i] && c.matches(p.Graveyard[i]) {
+					exiled[i] = true
+					names = append(names, p.Graveyard[i].Name)
+				}
+			}
+			if len(names) < c.Count {
+				return nil, false
+			}
+			for _, name := range names {
+				name := name
+				steps = append(steps, costStep{pay: func() {
+					if g != nil {
+						g.ExileFromGraveyard(p, name)
+					} else {
+						p.ExileFromGraveyard(name)
+					}
+				}})
+			}
+		case RemoveCounters:
+			if src == nil || src.GetCounters(c.CounterType) < c.Count {
+				return nil, false
+			}
+			steps = append(steps, costStep{
+				pay:  func() { src.AddCounters(c.CounterType, -c.Count) },
+				undo: func() { src.AddCounters(c.CounterType, c.Count) },
+			})
+		case TapUntappedCreatures:
+			perms := choosePermanents(p, used, c.Count, func(perm *game.Permanent) bool {
+				return perm.IsCreature() && !perm.IsTapped()
+			}, byPower)
+			if perms == nil {
+				return nil, false
+			}
+			for _, perm := range perms {
+				steps = append(steps, costStep{pay: perm.Tap, undo: perm.Untap})
+			}
+		case ReturnLands:
+			// Tapped lands have already given their mana.
+			perms := choosePermanents(p, used, c.Count, (*game.Permanent).IsLand, func(a, b *game.Permanent) bool {
+				return a.IsTapped() && !b.IsTapped()
+			})
+			if perms == nil {
+				return nil, false
+			}
+			for _, perm := range perms {
+				perm := perm
+				steps = append(steps, costStep{pay: func() { p.ReturnPermanentToHand(perm) }})
+			}
+		case LoyaltyCost:
+			if src == nil || src.GetCounters("loyalty")+c.Count < 0 {
+				return nil, false
+			}
+			steps = append(steps, costStep{
+				pay:  func() { src.AddCounters("loyalty", c.Count) },
+				undo: func() { src.AddCounters("loyalty", -c.Count) },
+			})
+		case ExertSource:
+			if src == nil || src.IsExerted() {
+				return nil, false
+			}
+			steps = append(steps, costStep{
+				pay:  func() { src.SetExerted(true) },
+				undo: func() { src.SetExerted(false) },
+			})
+		default:
+			return nil, false
+		}
+	}
+	return steps, true
+}
+
+func byManaValue(a, b *game.Permanent) bool {
+	return a.GetSource().ManaValue() < b.GetSource().ManaValue()
+}
+func byPower(a, b *game.Permanent) bool { return a.GetPower() < b.GetPower() }
+
+// choosePermanents picks n of p's permanents that match and aren't used
+// yet, preferring those that sort first under less, and marks them used.
+// It returns nil when fewer than n qualify.
+func choosePermanents(p *game.Player, used map[*game.Permanent]bool, n int, match func(*game.Permanent) bool, less func(a, b *game.Permanent) bool) []*game.Permanent {
+	var candidates []*game.Permanent
+	for _, perm := range p.Battlefield {
+		if !used[perm] && match(perm) {
+			candidates = append(candidates, perm)
+		}
+	}
+	if len(candidates) < n {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return less(candidates[i], candidates[j]) })
+	chosen := candidates[:n]
+	for _, perm := range chosen {
+		used[perm] = true
+	}
+	return chosen
+}
+
+// activationKey identifies ab among its source's activations. All loyalty
+// abilities share one key: a planeswalker activates one of them per turn
+// (CR 606.3).
+func activationKey(ab *Ability) string {
+	if hasTypedCost(ab, LoyaltyCost) {
+		return "loyalty"
+	}
+	if ab.OracleText != "" {
+		return ab.OracleText
+	}
+	return ab.Name
+}
+
+// activationsThisTurn counts ab's activations this turn. The count lives on
+// the source permanent when there is one, since adapters re-parse abilities.
+func (ee *ExecutionEngine) activationsThisTurn(ab *Ability) int {
+	perm, g := scriptPermanent(ab.Source), ee.underlyingGame()
+	if perm == nil || g == nil {
+		return ab.UsedThisTurn
+	}
+	return perm.ActivationsOnTurn(activationKey(ab), g.GetTurnNumber())
+}
+
+func (ee *ExecutionEngine) recordActivation(ab *Ability) {
+	ab.UsedThisTurn++
+	if perm, g := scriptPermanent(ab.Source), ee.underlyingGame(); perm != nil && g != nil {
+		perm.RecordActivation(activationKey(ab), g.GetTurnNumber())
+	}
+}
+
+// withinActivationLimits applies "activate only once each turn", per-turn
+// use limits and the one-loyalty-ability-per-turn rule.
+func (ee *ExecutionEngine) withinActivationLimits(ab *Ability) bool {
+	used := ee.activationsThisTurn(ab)
+	if (ab.TimingRestriction == OncePerTurn || hasTypedCost(ab, LoyaltyCost)) && used >= 1 {
+		return false
+	}
+	return ab.UsesPerTurn <= 0 || used < ab.UsesPerTurn
+}
+
+// summoningSick reports whether source is a creature that can't use {T}
+// abilities yet (CR 302.6).
+func (ee *ExecutionEngine) summoningSick(source any) bool {
+	if s, ok := source.(SummoningSickness); ok {
+		return s.HasSummoningSickness()
+	}
+	if perm, g := scriptPermanent(source), ee.underlyingGame(); perm != nil && g != nil {
+		return g.SummoningSick(perm)
+	}
+	return false
+}
+
+func (ee *ExecutionEngine) underlyingGame() *game.Game {
+	if ee == nil {
+		return nil
+	}
+	if gs, ok := ee.gameState.(interface{ UnderlyingGame() *game.Game }); ok {
+		return gs.UnderlyingGame()
+	}
+	return nil
+}
+
+func underlyingPlayer(p AbilityPlayer) *game.Player {
+	if u, ok := any(p).(interface{ Underlying() *game.Player }); ok {
+		return u.Underlying()
+	}
+	return nil
+}
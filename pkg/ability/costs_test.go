@@ -0,0 +1,173 @@
+package ability
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// failingPayer can afford every cost but fails to pay mana, so the engine
+// has to roll back whatever it already paid.
+type failingPayer struct {
+	scriptPlayer
+}
+
+func (f *failingPayer) CanPayCost(Cost) bool { return true }
+func (f *failingPayer) PayCost(Cost) error   { return ErrInsufficientMana }
+
+func newCostHarness(t *testing.T) (*ExecutionEngine, *scriptGameState, *scriptPlayer) {
+	t.Helper()
+	engine, sp, _ := newScriptHarness(t)
+	gs := engine.gameState.(*scriptGameState)
+	gs.isMainPhase = true
+	return engine, gs, sp
+}
+
+func drawAbility(source any, cost Cost) *Ability {
+	return &Ability{Name: "Draw", Type: Activated, Source: source, Cost: cost, OracleText: "Draw a card.", Effects: []Effect{{Type: DrawCards, Value: 1}}}
+}
+
+func TestTypedCosts_SacrificeCheapestOtherCreature(t *testing.T) {
+	engine, _, sp := newCostHarness(t)
+	p := sp.p
+	altar := p.PutTokenOnBattlefield(game.SimpleCard{Name: "Ashnod's Altar", TypeLine: "Artifact", ManaCost: "{3}"})
+	p.PutTokenOnBattlefield(game.SimpleCard{Name: "Ogre", TypeLine: "Creature — Ogre", ManaCost: "{2}{R}"})
+	p.PutTokenOnBattlefield(game.SimpleCard{Name: "Goblin", TypeLine: "Creature — Goblin", ManaCost: "{R}"})
+
+	ab := drawAbility(altar, Cost{Typed: []TypedCost{{Kind: SacrificePermanents, Count: 1, Filter: "creature"}}})
+	if err := engine.ExecuteAbility(ab, sp, nil); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	if len(p.Graveyard) != 1 || p.Graveyard[0].Name != "Goblin" {
+		t.Fatalf("expected the Goblin to be sacrificed, graveyard = %+v", p.Graveyard)
+	}
+
+	p.Graveyard = nil
+	ab = drawAbility(altar, Cost{Typed: []TypedCost{{Kind: SacrificePermanents, Count: 2, Filter: "creature"}}})
+	if engine.canActivateAbility(ab, sp) {
+		t.Fatal("only one creature is left to sacrifice")
+	}
+}
+
+func TestTypedCosts_RollBackWhenManaPaymentFails(t *testing.T) {
+	engine, _, sp := newCostHarness(t)
+	p := sp.p
+	src := p.PutTokenOnBattlefield(game.SimpleCard{Name: "Battery", TypeLine: "Artifact"})
+	src.AddCounters("charge", 2)
+	bear := p.PutTokenOnBattlefield(game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"})
+	fodder := p.PutTokenOnBattlefield(game.SimpleCard{Name: "Thopter", TypeLine: "Artifact Creature — Thopter"})
+	fodder.SetPower(1)
+	bear.SetPower(2)
+
+	ab := drawAbility(src, Cost{
+		ManaCost: map[game.ManaType]int{game.Any: 1},
+		TapCost:  true,
+		Typed: []TypedCost{
+			{Kind: RemoveCounters, Count: 1, CounterType: "charge"},
+			{Kind: TapUntappedCreatures, Count: 1},
+			{Kind: SacrificePermanents, Count: 1, Filter: "creature"},
+			{Kind: ExertSource},
+		},
+	})
+	payer := &failingPayer{scriptPlayer: *sp}
+	err := engine.ExecuteAbility(ab, payer, nil)
+	if !errors.Is(err, ErrInsufficientMana) {
+		t.Fatalf("expected the mana payment error, got %v", err)
+	}
+	if src.GetCounters("charge") != 2 || src.IsTapped() || src.IsExerted() {
+		t.Fatalf("source costs weren't rolled back: counters=%d tapped=%v exerted=%v", src.GetCounters("charge"), src.IsTapped(), src.IsExerted())
+	}
+	if bear.IsTapped() || fodder.IsTapped() || len(p.Battlefield) != 3 || len(p.Graveyard) != 0 {
+		t.Fatalf("other permanents weren't restored: battlefield=%d graveyard=%+v", len(p.Battlefield), p.Graveyard)
+	}
+	if engine.activationsThisTurn(ab) != 0 {
+		t.Fatal("a failed activation shouldn't count")
+	}
+}
+
+func TestLoyaltyAbilities_OncePerTurnAtSorcerySpeed(t *testing.T) {
+	engine, gs, sp := newCostHarness(t)
+	walker := sp.p.PutTokenOnBattlefield(game.SimpleCard{Name: "Jace", TypeLine: "Legendary Planeswalker — Jace"})
+	walker.AddCounters("loyalty", 3)
+	plus := drawAbility(walker, Cost{Typed: []TypedCost{{Kind: LoyaltyCost, Count: 1}}})
+	plus.TimingRestriction = SorcerySpeed
+	minus := drawAbility(walker, Cost{Typed: []TypedCost{{Kind: LoyaltyCost, Count: -2}}})
+	minus.OracleText, minus.TimingRestriction = "Draw two cards.", SorcerySpeed
+	ultimate := drawAbility(walker, Cost{Typed: []TypedCost{{Kind: LoyaltyCost, Count: -8}}})
+	ultimate.OracleText, ultimate.TimingRestriction = "You win the game.", SorcerySpeed
+
+	gs.isMainPhase = false
+	if engine.canActivateAbility(plus, sp) {
+		t.Fatal("loyalty abilities are sorcery speed")
+	}
+	gs.isMainPhase = true
+	if engine.canActivateAbility(ultimate, sp) {
+		t.Fatal("not enough loyalty for the ultimate")
+	}
+	if err := engine.ExecuteAbility(plus, sp, nil); err != nil {
+		t.Fatalf("activate +1: %v", err)
+	}
+	if walker.GetCounters("loyalty") != 4 {
+		t.Fatalf("expected 4 loyalty, got %d", walker.GetCounters("loyalty"))
+	}
+	if engine.canActivateAbility(minus, sp) {
+		t.Fatal("only one loyalty ability per planeswalker each turn")
+	}
+
+	for turn := gs.g.GetTurnNumber(); gs.g.GetTurnNumber() == turn; {
+		gs.g.AdvancePhase()
+	}
+	if err := engine.ExecuteAbility(minus, sp, nil); err != nil {
+		t.Fatalf("activate -2 next turn: %v", err)
+	}
+	if walker.GetCounters("loyalty") != 2 {
+		t.Fatalf("expected 2 loyalty, got %d", walker.GetCounters("loyalty"))
+	}
+}
+
+func TestOncePerTurn_SurvivesReparsedAbilities(t *testing.T) {
+	engine, _, sp := newCostHarness(t)
+	src := sp.p.PutTokenOnBattlefield(game.SimpleCard{Name: "Relic", TypeLine: "Artifact"})
+	first := drawAbility(src, Cost{})
+	first.TimingRestriction = OncePerTurn
+	if err := engine.ExecuteAbility(first, sp, nil); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	// Adapters parse a fresh Ability each time they're asked.
+	again := drawAbility(src, Cost{})
+	again.TimingRestriction = OncePerTurn
+	if engine.canActivateAbility(again, sp) {
+		t.Fatal("the limit belongs to the permanent, not the parsed ability")
+	}
+}
+
+func TestTapAbility_SummoningSickCreature(t *testing.T) {
+	engine, gs, sp := newCostHarness(t)
+	elf := sp.p.PutTokenOnBattlefield(game.SimpleCard{Name: "Looter", TypeLine: "Creature — Human", Power: "1", Toughness: "1"})
+	elf.SetEnteredTurn(gs.g.GetTurnNumber())
+	ab := drawAbility(elf, Cost{TapCost: true})
+	if engine.canActivateAbility(ab, sp) {
+		t.Fatal("a summoning-sick creature can't use {T} abilities")
+	}
+	elf.GrantKeyword(game.KWHaste)
+	if !engine.canActivateAbility(ab, sp) {
+		t.Fatal("haste lets the creature tap the turn it arrives")
+	}
+	if err := engine.ExecuteAbility(ab, sp, nil); err != nil || !elf.IsTapped() {
+		t.Fatalf("expected the creature to tap, err=%v", err)
+	}
+}
+
+func TestNullRod_StopsArtifactActivations(t *testing.T) {
+	engine, gs, sp := newCostHarness(t)
+	rock := sp.p.PutTokenOnBattlefield(game.SimpleCard{Name: "Mind Stone", TypeLine: "Artifact"})
+	ab := drawAbility(rock, Cost{TapCost: true})
+	if !engine.canActivateAbility(ab, sp) {
+		t.Fatal("nothing stops the artifact yet")
+	}
+	gs.g.GetPlayersRaw()[1].PutTokenOnBattlefield(game.SimpleCard{Name: "Null Rod", TypeLine: "Artifact"})
+	if engine.canActivateAbility(ab, sp) {
+		t.Fatal("Null Rod stops activated abilities of artifacts")
+	}
+}
@@ -0,0 +1,286 @@
+package ability
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// CoverageEntry is one card's line in a parser coverage report: whether the
+// engine implements it and the structure of the abilities it runs with.
+// Everything is rendered as strings so reports diff cleanly line by line.
+type CoverageEntry struct {
+	Name        string            `json:"name"`
+	Implemented bool              `json:"implemented"`
+	Scripted    bool              `json:"scripted,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	Abilities   []CoverageAbility `json:"abilities,omitempty"`
+}
+
+// CoverageAbility is the stable rendering of an Ability. IDs and sources are
+// left out. Effect descriptions stay in: keyword and search effects read
+// their payload from them.
+type CoverageAbility struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Trigger string   `json:"trigger,omitempty"`
+	Timing  string   `json:"timing,omitempty"`
+	Cost    string   `json:"cost,omitempty"`
+	Effects []string `json:"effects"`
+}
+
+// BuildCoverage parses every card and returns one entry per card, sorted by
+// name.
+func BuildCoverage(cards []card.Card) []CoverageEntry {
+	out := make([]CoverageEntry, 0, len(cards))
+	for _, c := range cards {
+		impl, reason := testCardImplementation(c)
+		entry := CoverageEntry{
+			Name:        c.Name,
+			Implemented: impl,
+			Scripted:    HasCardScript(c.Name) || HasCardDefinition(c.Name),
+			Reason:      reason,
+		}
+		abilities, _ := sharedParser.ParseAbilities(c.OracleText, c)
+		if def, ok := LookupCardDefinition(c.Name); ok {
+			abilities = def.mergeOver(abilities, c)
+		}
+		for _, ab := range abilities {
+			entry.Abilities = append(entry.Abilities, coverageAbility(ab))
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func coverageAbility(ab *Ability) CoverageAbility {
+	ca := CoverageAbility{Name: ab.Name, Type: enumName(abilityTypes, ab.Type), Cost: costString(ab.Cost)}
+	if ab.Type == Triggered {
+		ca.Trigger = enumName(triggerConditions, ab.TriggerCondition)
+	}
+	if ab.TimingRestriction != AnyTime {
+		ca.Timing = enumName(timingRestrictions, ab.TimingRestriction)
+	}
+	ca.Effects = make([]string, 0, len(ab.Effects))
+	for _, eff := range ab.Effects {
+		ca.Effects = append(ca.Effects, effectString(eff))
+	}
+	return ca
+}
+
+func enumName[T comparable](values map[string]T, v T) string {
+	for name, x := range values {
+		if x == v {
+			return name
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func costString(c Cost) string {
+	var parts []string
+	for _, mt := range []game.ManaType{game.White, game.Blue, game.Black, game.Red, game.Green, game.Colorless, game.Any, game.Phyrexian, game.Snow, game.X} {
+		if n := c.ManaCost[mt]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", mt, n))
+		}
+	}
+	if c.TapCost {
+		parts = append(parts, "tap")
+	}
+	if c.SacrificeCost {
+		parts = append(parts, "sacrifice")
+	}
+	if c.DiscardCost > 0 {
+		parts = append(parts, fmt.Sprintf("discard=%d", c.DiscardCost))
+	}
+	if c.LifeCost > 0 {
+		parts = append(parts, fmt.Sprintf("life=%d", c.LifeCost))
+	}
+	for _, t := range c.Typed {
+		parts = append(parts, t.String())
+	}
+	for _, o := range c.OtherCosts {
+		parts = append(parts, fmt.Sprintf("other=%q", o))
+	}
+	return strings.Join(parts, " ")
+}
+
+// effectString renders an effect as "Type key=value ...", e.g.
+// `DealDamage value=3 targets=[AnyTarget] text="deals 3 damage"`.
+func effectString(e Effect) string {
+	var b strings.Builder
+	b.WriteString(e.Type.String())
+	if e.Value != 0 {
+		fmt.Fprintf(&b, " value=%d", e.Value)
+	}
+	if e.Duration != Instant {
+		fmt.Fprintf(&b, " duration=%s", enumName(effectDurations, e.Duration))
+	}
+	if e.HasPTDelta {
+		fmt.Fprintf(&b, " pt=%+d/%+d", e.PTPower, e.PTToughness)
+	}
+	if e.HasToken {
+		fmt.Fprintf(&b, " token=%dx%q(%s %d/%d)", e.Token.Count, e.Token.Name, e.Token.TypeLine, e.Token.Power, e.Token.Toughness)
+	}
+	if len(e.Targets) > 0 {
+		targets := make([]string, 0, len(e.Targets))
+		for _, t := range e.Targets {
+			targets = append(targets, targetString(t))
+		}
+		fmt.Fprintf(&b, " targets=[%s]", strings.Join(targets, ", "))
+	}
+	if len(e.Conditions) > 0 {
+		conds := make([]string, 0, len(e.Conditions))
+		for _, c := range e.Conditions {
+			if c.Value != "" {
+				conds = append(conds, fmt.Sprintf("%s(%s)", c.Type, c.Value))
+			} else {
+				conds = append(conds, c.Type.String())
+			}
+		}
+		fmt.Fprintf(&b, " conditions=[%s]", strings.Join(conds, ", "))
+	}
+	if len(e.Modes) > 0 {
+		modes := make([]string, 0, len(e.Modes))
+		for _, m := range e.Modes {
+			modes = append(modes, effectString(m))
+		}
+		fmt.Fprintf(&b, " modes=[%s]", strings.Join(modes, "; "))
+	}
+	if e.Approximate {
+		b.WriteString(" approximate")
+	}
+	if e.Description != "" {
+		fmt.Fprintf(&b, " text=%q", e.Description)
+	}
+	return b.String()
+}
+
+func targetString(t Target) string {
+	var b strings.Builder
+	b.WriteString(enumName(targetTypes, t.Type))
+	if t.Count > 1 {
+		fmt.Fprintf(&b, " x%d", t.Count)
+	}
+	if t.Required {
+		b.WriteString(" required")
+	}
+	if t.Enhanced != nil {
+		if t.Enhanced.IsEach {
+			b.WriteString(" each")
+		}
+		for _, r := range t.Enhanced.Restrictions {
+			b.WriteString(" ")
+			if r.Negated {
+				b.WriteString("non-")
+			}
+			b.WriteString(enumName(targetRestrictions, r.Type))
+			if r.Value != nil {
+				fmt.Fprintf(&b, "(%v)", r.Value)
+			}
+		}
+	}
+	return b.String()
+}
+
+// WriteCoverage writes entries as JSON lines, one card per line.
+func WriteCoverage(w io.Writer, entries []CoverageEntry) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadCoverage reads a report written by WriteCoverage.
+func ReadCoverage(r io.Reader) ([]CoverageEntry, error) {
+	var out []CoverageEntry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var e CoverageEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// CoverageDiff is the difference between a golden report and a new one.
+// Regressions and Changed fail a coverage check; the rest are informational.
+type CoverageDiff struct {
+	Regressions  []CoverageChange // implemented before, not now
+	Changed      []CoverageChange // still implemented, but the abilities differ
+	Improvements []CoverageChange // implemented now, not before
+	Added        []string         // cards missing from the golden report
+	Removed      []string         // cards missing from the new report
+}
+
+// CoverageChange pairs a card's golden and current entries.
+type CoverageChange struct {
+	Name   string
+	Golden CoverageEntry
+	Now    CoverageEntry
+}
+
+// Failed reports whether the diff contains changes that need an approved
+// golden update.
+func (d CoverageDiff) Failed() bool {
+	return len(d.Regressions) > 0 || len(d.Changed) > 0
+}
+
+// CompareCoverage diffs current against golden.
+func CompareCoverage(golden, current []CoverageEntry) CoverageDiff {
+	var d CoverageDiff
+	before := make(map[string]CoverageEntry, len(golden))
+	for _, e := range golden {
+		before[e.Name] = e
+	}
+	seen := make(map[string]bool, len(current))
+	for _, now := range current {
+		seen[now.Name] = true
+		old, ok := before[now.Name]
+		if !ok {
+			d.Added = append(d.Added, now.Name)
+			continue
+		}
+		ch := CoverageChange{Name: now.Name, Golden: old, Now: now}
+		switch {
+		case old.Implemented && !now.Implemented:
+			d.Regressions = append(d.Regressions, ch)
+		case !old.Implemented && now.Implemented:
+			d.Improvements = append(d.Improvements, ch)
+		case old.Implemented && !sameAbilities(old.Abilities, now.Abilities):
+			d.Changed = append(d.Changed, ch)
+		}
+	}
+	for _, e := range golden {
+		if !seen[e.Name] {
+			d.Removed = append(d.Removed, e.Name)
+		}
+	}
+	return d
+}
+
+func sameAbilities(a, b []CoverageAbility) bool {
+	return slices.EqualFunc(a, b, func(x, y CoverageAbility) bool {
+		return x.Name == y.Name && x.Type == y.Type && x.Trigger == y.Trigger &&
+			x.Timing == y.Timing && x.Cost == y.Cost && slices.Equal(x.Effects, y.Effects)
+	})
+}
@@ -0,0 +1,119 @@
+package ability
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+)
+
+func TestBuildCoverage_StableAndSorted(t *testing.T) {
+	cards := []card.Card{
+		{Name: "Lightning Bolt", TypeLine: "Instant", OracleText: "Lightning Bolt deals 3 damage to any target."},
+		{Name: "Divination", TypeLine: "Sorcery", OracleText: "Draw two cards."},
+		{Name: "Gibberish Card", TypeLine: "Sorcery", OracleText: "Frobnicate the zorblax twice."},
+	}
+	first := BuildCoverage(cards)
+	if len(first) != 3 || first[0].Name != "Divination" || first[2].Name != "Lightning Bolt" {
+		t.Fatalf("expected entries sorted by name, got %+v", first)
+	}
+	if !first[0].Implemented || first[1].Implemented {
+		t.Fatalf("unexpected implementation flags: %+v", first)
+	}
+	if got := first[0].Abilities[0].Effects; len(got) != 1 || got[0] != `DrawCards value=2 text="Draw two cards"` {
+		t.Fatalf("unexpected Divination effects %q", got)
+	}
+
+	var a, b bytes.Buffer
+	if err := WriteCoverage(&a, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCoverage(&b, BuildCoverage([]card.Card{cards[2], cards[0], cards[1]})); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Fatalf("report should not depend on input order:\n%s\n%s", a.String(), b.String())
+	}
+	if lines := strings.Count(a.String(), "\n"); lines != 3 {
+		t.Fatalf("expected one line per card, got %d", lines)
+	}
+
+	back, err := ReadCoverage(&a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := CompareCoverage(first, back); d.Failed() || len(d.Added)+len(d.Removed)+len(d.Improvements) != 0 {
+		t.Fatalf("round-tripped report should match, got %+v", d)
+	}
+}
+
+func TestCompareCoverage(t *testing.T) {
+	bolt := CoverageEntry{Name: "Lightning Bolt", Implemented: true, Abilities: []CoverageAbility{{Name: "Spell", Type: "Activated", Effects: []string{"DealDamage value=3 targets=[AnyTarget]"}}}}
+	golden := []CoverageEntry{
+		bolt,
+		{Name: "Divination", Implemented: true, Abilities: []CoverageAbility{{Name: "Spell", Type: "Activated", Effects: []string{"DrawCards value=2"}}}},
+		{Name: "Gibberish Card"},
+		{Name: "Old Card", Implemented: true},
+	}
+	changedBolt := bolt
+	changedBolt.Abilities = []CoverageAbility{{Name: "Spell", Type: "Activated", Effects: []string{"DealDamage value=2 targets=[AnyTarget]"}}}
+	current := []CoverageEntry{
+		changedBolt,
+		{Name: "Divination", Reason: "parser failed"},
+		{Name: "Gibberish Card", Implemented: true},
+		{Name: "New Card", Implemented: true},
+	}
+
+	d := CompareCoverage(golden, current)
+	if !d.Failed() {
+		t.Fatalf("expected the diff to fail")
+	}
+	if len(d.Regressions) != 1 || d.Regressions[0].Name != "Divination" {
+		t.Errorf("expected Divination regression, got %+v", d.Regressions)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Name != "Lightning Bolt" {
+		t.Errorf("expected Lightning Bolt change, got %+v", d.Changed)
+	}
+	if len(d.Improvements) != 1 || d.Improvements[0].Name != "Gibberish Card" {
+		t.Errorf("expected Gibberish Card improvement, got %+v", d.Improvements)
+	}
+	if len(d.Added) != 1 || d.Added[0] != "New Card" || len(d.Removed) != 1 || d.Removed[0] != "Old Card" {
+		t.Errorf("unexpected added/removed %v / %v", d.Added, d.Removed)
+	}
+
+	if d := CompareCoverage(golden, golden); d.Failed() {
+		t.Errorf("identical reports should not fail")
+	}
+}
+
+// TestCoverageGolden guards the parser output for a fixed set of cards. When a
+// parser change is intended, regenerate the golden report with:
+//
+//	go run ./cmd/mtgsim-coverage -carddb pkg/ability/testdata/coverage/cards.json \
+//	    -golden pkg/ability/testdata/coverage/golden.jsonl -cards "" -update
+func TestCoverageGolden(t *testing.T) {
+	db, err := card.LoadCardDatabaseFile(filepath.Join("testdata", "coverage", "cards.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join("testdata", "coverage", "golden.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	golden, err := ReadCoverage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := CompareCoverage(golden, BuildCoverage(db.ListAll()))
+	for _, ch := range append(d.Regressions, d.Changed...) {
+		t.Errorf("%s changed:\n  golden: %+v\n  now:    %+v", ch.Name, ch.Golden, ch.Now)
+	}
+	if len(d.Added)+len(d.Removed) > 0 {
+		t.Errorf("golden report is out of date: added %v, removed %v", d.Added, d.Removed)
+	}
+}
@@ -0,0 +1,541 @@
+package ability
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// CardDefinition describes a card's abilities as data, so card behaviour can
+// be corrected without recompiling. Definitions live as JSON files in a cards
+// directory (one card per file) and use the engine's own vocabulary: enum
+// values are spelled exactly as their Go constants, e.g. "Triggered",
+// "EntersTheBattlefield", "DrawCards", "CreatureTarget", "KickerPaid".
+//
+// A definition is merged over the parser's output for the card: each defined
+// ability replaces the parsed ability with the same name, and the rest are
+// appended. With Replace set the parser's output is discarded entirely.
+type CardDefinition struct {
+	Name      string              `json:"name"`
+	Replace   bool                `json:"replace,omitempty"`
+	Abilities []AbilityDefinition `json:"abilities"`
+
+	path     string
+	compiled []*Ability
+}
+
+// AbilityDefinition is the data form of an Ability.
+type AbilityDefinition struct {
+	Name        string             `json:"name"`
+	Type        string             `json:"type"`
+	Trigger     string             `json:"trigger,omitempty"`
+	Timing      string             `json:"timing,omitempty"`
+	Optional    bool               `json:"optional,omitempty"`
+	UsesPerTurn int                `json:"uses_per_turn,omitempty"`
+	OracleText  string             `json:"oracle_text,omitempty"`
+	Cost        CostDefinition     `json:"cost"`
+	Effects     []EffectDefinition `json:"effects"`
+}
+
+// CostDefinition is the data form of a Cost. Mana is keyed by mana symbol
+// ("W", "U", "B", "R", "G", "C").
+type CostDefinition struct {
+	Mana      map[string]int `json:"mana,omitempty"`
+	Tap       bool           `json:"tap,omitempty"`
+	Sacrifice bool           `json:"sacrifice,omitempty"`
+	Discard   int            `json:"discard,omitempty"`
+	Life      int            `json:"life,omitempty"`
+	Other     []string       `json:"other,omitempty"`
+}
+
+// EffectDefinition is the data form of an Effect. Power and Toughness set a
+// P/T delta when either is non-zero.
+type EffectDefinition struct {
+	Type        string                `json:"type"`
+	Value       int                   `json:"value,omitempty"`
+	Duration    string                `json:"duration,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Targets     []TargetDefinition    `json:"targets,omitempty"`
+	Conditions  []ConditionDefinition `json:"conditions,omitempty"`
+	Power       int                   `json:"power,omitempty"`
+	Toughness   int                   `json:"toughness,omitempty"`
+	Token       *TokenSpec            `json:"token,omitempty"`
+	Modes       []EffectDefinition    `json:"modes,omitempty"`
+}
+
+// TargetDefinition is the data form of a Target. Restrictions are
+// TargetRestrictionType names and produce an EnhancedTarget.
+type TargetDefinition struct {
+	Type         string                  `json:"type"`
+	Required     bool                    `json:"required,omitempty"`
+	Count        int                     `json:"count,omitempty"`
+	Each         bool                    `json:"each,omitempty"`
+	Restrictions []RestrictionDefinition `json:"restrictions,omitempty"`
+}
+
+// RestrictionDefinition is the data form of a TargetRestriction.
+type RestrictionDefinition struct {
+	Type    string `json:"type"`
+	Value   any    `json:"value,omitempty"`
+	Negated bool   `json:"negated,omitempty"`
+}
+
+// ConditionDefinition is the data form of a Condition.
+type ConditionDefinition struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// The enum constants a definition may use, by name.
+var (
+	abilityTypes = map[string]AbilityType{
+		"Triggered":   Triggered,
+		"Activated":   Activated,
+		"Static":      Static,
+		"Replacement": Replacement,
+		"Mana":        Mana,
+	}
+	triggerConditions = map[string]TriggerCondition{
+		"EntersTheBattlefield": EntersTheBattlefield,
+		"LeavesTheBattlefield": LeavesTheBattlefield,
+		"Dies":                 Dies,
+		"BeginningOfUpkeep":    BeginningOfUpkeep,
+		"EndOfTurn":            EndOfTurn,
+		"DealsCombatDamage":    DealsCombatDamage,
+		"BecomesTargeted":      BecomesTargeted,
+		"AttacksOrBlocks":      AttacksOrBlocks,
+		"SpellCast":            SpellCast,
+		"CreatureEnters":       CreatureEnters,
+		"LandPlayed":           LandPlayed,
+		"AnyTrigger":           AnyTrigger,
+	}
+	timingRestrictions = map[string]TimingRestriction{
+		"AnyTime":          AnyTime,
+		"SorcerySpeed":     SorcerySpeed,
+		"OncePerTurn":      OncePerTurn,
+		"OnlyOnYourTurn":   OnlyOnYourTurn,
+		"OnlyDuringCombat": OnlyDuringCombat,
+		"OnlyMainPhase":    OnlyMainPhase,
+	}
+	effectDurations = map[string]EffectDuration{
+		"Instant":          Instant,
+		"UntilEndOfTurn":   UntilEndOfTurn,
+		"UntilEndOfCombat": UntilEndOfCombat,
+		"Permanent":        Permanent,
+		"UntilLeavesPlay":  UntilLeavesPlay,
+	}
+	targetTypes = map[string]TargetType{
+		"NoTarget":              NoTarget,
+		"AnyTarget":             AnyTarget,
+		"CreatureTarget":        CreatureTarget,
+		"PlayerTarget":          PlayerTarget,
+		"PermanentTarget":       PermanentTarget,
+		"SpellTarget":           SpellTarget,
+		"CardInGraveyardTarget": CardInGraveyardTarget,
+		"CardInHandTarget":      CardInHandTarget,
+		"AbilityTarget":         AbilityTarget,
+	}
+	targetRestrictions = map[string]TargetRestrictionType{
+		"NoRestriction":                    NoRestriction,
+		"CreatureRestriction":              CreatureRestriction,
+		"ArtifactRestriction":              ArtifactRestriction,
+		"EnchantmentRestriction":           EnchantmentRestriction,
+		"LandRestriction":                  LandRestriction,
+		"PlaneswalkerRestriction":          PlaneswalkerRestriction,
+		"PermanentRestriction":             PermanentRestriction,
+		"SpellRestriction":                 SpellRestriction,
+		"PlayerRestriction":                PlayerRestriction,
+		"FlyingRestriction":                FlyingRestriction,
+		"TrampleRestriction":               TrampleRestriction,
+		"VigilanceRestriction":             VigilanceRestriction,
+		"FirstStrikeRestriction":           FirstStrikeRestriction,
+		"DoubleStrikeRestriction":          DoubleStrikeRestriction,
+		"DeathtouchRestriction":            DeathtouchRestriction,
+		"LifelinkRestriction":              LifelinkRestriction,
+		"HexproofRestriction":              HexproofRestriction,
+		"ShroudRestriction":                ShroudRestriction,
+		"ProtectionRestriction":            ProtectionRestriction,
+		"PowerRestriction":                 PowerRestriction,
+		"ToughnessRestriction":             ToughnessRestriction,
+		"CMCRestriction":                   CMCRestriction,
+		"PowerLessEqualRestriction":        PowerLessEqualRestriction,
+		"ToughnessLessEqualRestriction":    ToughnessLessEqualRestriction,
+		"CMCLessEqualRestriction":          CMCLessEqualRestriction,
+		"PowerGreaterEqualRestriction":     PowerGreaterEqualRestriction,
+		"ToughnessGreaterEqualRestriction": ToughnessGreaterEqualRestriction,
+		"CMCGreaterEqualRestriction":       CMCGreaterEqualRestriction,
+		"YouControlRestriction":            YouControlRestriction,
+		"YouDontControlRestriction":        YouDontControlRestriction,
+		"OpponentControlsRestriction":      OpponentControlsRestriction,
+		"WhiteRestriction":                 WhiteRestriction,
+		"BlueRestriction":                  BlueRestriction,
+		"BlackRestriction":                 BlackRestriction,
+		"RedRestriction":                   RedRestriction,
+		"GreenRestriction":                 GreenRestriction,
+		"ColorlessRestriction":             ColorlessRestriction,
+		"MonocoloredRestriction":           MonocoloredRestriction,
+		"MulticoloredRestriction":          MulticoloredRestriction,
+		"TappedRestriction":                TappedRestriction,
+		"UntappedRestriction":              UntappedRestriction,
+		"AttackingRestriction":             AttackingRestriction,
+		"BlockingRestriction":              BlockingRestriction,
+		"EnchantedRestriction":             EnchantedRestriction,
+		"EquippedRestriction":              EquippedRestriction,
+	}
+)
+
+func enumValue[T any](kind string, values map[string]T, name string) (T, error) {
+	v, ok := values[name]
+	if !ok {
+		return v, fmt.Errorf("unknown %s %q", kind, name)
+	}
+	return v, nil
+}
+
+func parseEffectTypeName(name string) (EffectType, error) {
+	for et := EffectType(0); !strings.HasPrefix(et.String(), "EffectType("); et++ {
+		if et.String() == name {
+			return et, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown effect type %q", name)
+}
+
+func parseConditionTypeName(name string) (ConditionType, error) {
+	for ct := ConditionType(0); !strings.HasPrefix(ct.String(), "ConditionType("); ct++ {
+		if ct.String() == name {
+			return ct, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown condition type %q", name)
+}
+
+// compile validates the definition and builds its abilities.
+func (d *CardDefinition) compile() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return errors.New("missing card name")
+	}
+	if len(d.Abilities) == 0 && !d.Replace {
+		return errors.New("no abilities defined")
+	}
+	d.compiled = nil
+	seen := map[string]bool{}
+	for i, ad := range d.Abilities {
+		ab, err := ad.compile()
+		if err != nil {
+			return fmt.Errorf("ability %d: %w", i, err)
+		}
+		if seen[ab.Name] {
+			return fmt.Errorf("ability %d: duplicate ability name %q", i, ab.Name)
+		}
+		seen[ab.Name] = true
+		d.compiled = append(d.compiled, ab)
+	}
+	return nil
+}
+
+func (ad AbilityDefinition) compile() (*Ability, error) {
+	if ad.Name == "" {
+		return nil, errors.New("missing ability name")
+	}
+	ab := &Ability{Name: ad.Name, IsOptional: ad.Optional, UsesPerTurn: ad.UsesPerTurn, OracleText: ad.OracleText}
+	var err error
+	if ab.Type, err = enumValue("ability type", abilityTypes, ad.Type); err != nil {
+		return nil, err
+	}
+	if ad.Trigger != "" {
+		if ab.TriggerCondition, err = enumValue("trigger condition", triggerConditions, ad.Trigger); err != nil {
+			return nil, err
+		}
+	} else if ab.Type == Triggered {
+		return nil, errors.New("triggered ability needs a trigger")
+	}
+	if ad.Timing != "" {
+		if ab.TimingRestriction, err = enumValue("timing restriction", timingRestrictions, ad.Timing); err != nil {
+			return nil, err
+		}
+	}
+	if ab.Cost, err = ad.Cost.compile(); err != nil {
+		return nil, err
+	}
+	if len(ad.Effects) == 0 {
+		return nil, errors.New("no effects defined")
+	}
+	for i, ed := range ad.Effects {
+		eff, err := ed.compile()
+		if err != nil {
+			return nil, fmt.Errorf("effect %d: %w", i, err)
+		}
+		ab.Effects = append(ab.Effects, eff)
+	}
+	return ab, nil
+}
+
+func (cd CostDefinition) compile() (Cost, error) {
+	c := Cost{TapCost: cd.Tap, SacrificeCost: cd.Sacrifice, DiscardCost: cd.Discard, LifeCost: cd.Life, OtherCosts: cd.Other}
+	if cd.Discard < 0 || cd.Life < 0 {
+		return c, errors.New("negative cost")
+	}
+	if len(cd.Mana) > 0 {
+		c.ManaCost = map[game.ManaType]int{}
+		for sym, n := range cd.Mana {
+			mt := game.ManaType(strings.ToUpper(sym))
+			switch mt {
+			case game.White, game.Blue, game.Black, game.Red, game.Green, game.Colorless, game.Any:
+			default:
+				return c, fmt.Errorf("unknown mana symbol %q", sym)
+			}
+			if n < 0 {
+				return c, fmt.Errorf("negative mana cost for %q", sym)
+			}
+			c.ManaCost[mt] = n
+		}
+	}
+	return c, nil
+}
+
+func (ed EffectDefinition) compile() (Effect, error) {
+	et, err := parseEffectTypeName(ed.Type)
+	if err != nil {
+		return Effect{}, err
+	}
+	if et == Scripted {
+		return Effect{}, errors.New("scripted effects need a Go card script")
+	}
+	if !CanExecuteEffect(et) {
+		return Effect{}, fmt.Errorf("effect type %s is not executable", et)
+	}
+	eff := Effect{Type: et, Value: ed.Value, Description: ed.Description}
+	if eff.Description == "" {
+		eff.Description = et.String()
+	}
+	if ed.Duration != "" {
+		if eff.Duration, err = enumValue("duration", effectDurations, ed.Duration); err != nil {
+			return Effect{}, err
+		}
+	}
+	if ed.Power != 0 || ed.Toughness != 0 {
+		eff.HasPTDelta, eff.PTPower, eff.PTToughness = true, ed.Power, ed.Toughness
+	}
+	if ed.Token != nil {
+		eff.HasToken, eff.Token = true, *ed.Token
+	}
+	for i, td := range ed.Targets {
+		tgt, err := td.compile()
+		if err != nil {
+			return Effect{}, fmt.Errorf("target %d: %w", i, err)
+		}
+		eff.Targets = append(eff.Targets, tgt)
+	}
+	for i, cd := range ed.Conditions {
+		ct, err := parseConditionTypeName(cd.Type)
+		if err != nil {
+			return Effect{}, fmt.Errorf("condition %d: %w", i, err)
+		}
+		if !CanExecuteCondition(ct) {
+			return Effect{}, fmt.Errorf("condition %d: condition type %s is not executable", i, ct)
+		}
+		eff.Conditions = append(eff.Conditions, Condition{Type: ct, Value: cd.Value})
+	}
+	for i, md := range ed.Modes {
+		mode, err := md.compile()
+		if err != nil {
+			return Effect{}, fmt.Errorf("mode %d: %w", i, err)
+		}
+		eff.Modes = append(eff.Modes, mode)
+	}
+	return eff, nil
+}
+
+func (td TargetDefinition) compile() (Target, error) {
+	typ, err := enumValue("target type", targetTypes, td.Type)
+	if err != nil {
+		return Target{}, err
+	}
+	count := td.Count
+	if count == 0 {
+		count = 1
+	}
+	tgt := Target{Type: typ, Required: td.Required, Count: count}
+	if len(td.Restrictions) == 0 && !td.Each {
+		return tgt, nil
+	}
+	enh := &EnhancedTarget{Type: tgt.Type, Required: td.Required, Count: count, IsEach: td.Each}
+	for i, rd := range td.Restrictions {
+		rt, err := enumValue("target restriction", targetRestrictions, rd.Type)
+		if err != nil {
+			return Target{}, fmt.Errorf("restriction %d: %w", i, err)
+		}
+		if !CanExecuteTargetRestriction(rt) {
+			return Target{}, fmt.Errorf("restriction %d: %s is not executable", i, rd.Type)
+		}
+		value := rd.Value
+		if f, ok := value.(float64); ok {
+			value = int(f) // JSON numbers decode as float64; numeric restrictions compare ints
+		}
+		enh.Restrictions = append(enh.Restrictions, TargetRestriction{Type: rt, Value: value, Negated: rd.Negated, Description: rd.Type})
+		tgt.Restrictions = append(tgt.Restrictions, rd.Type)
+	}
+	tgt.Enhanced = enh
+	return tgt, nil
+}
+
+// abilitiesFor returns fresh copies of the definition's abilities for source.
+func (d *CardDefinition) abilitiesFor(source any) []*Ability {
+	out := make([]*Ability, 0, len(d.compiled))
+	for _, ab := range d.compiled {
+		cp := *ab
+		cp.ID = uuid.New()
+		cp.Source = source
+		cp.Effects = append([]Effect(nil), ab.Effects...)
+		out = append(out, &cp)
+	}
+	return out
+}
+
+// mergeOver applies the definition on top of parsed abilities.
+func (d *CardDefinition) mergeOver(parsed []*Ability, source any) []*Ability {
+	defined := d.abilitiesFor(source)
+	if d.Replace {
+		return defined
+	}
+	byName := make(map[string]*Ability, len(defined))
+	for _, ab := range defined {
+		byName[ab.Name] = ab
+	}
+	merged := make([]*Ability, 0, len(parsed)+len(defined))
+	used := map[string]bool{}
+	for _, ab := range parsed {
+		if def, ok := byName[ab.Name]; ok {
+			merged = append(merged, def)
+			used[ab.Name] = true
+			continue
+		}
+		merged = append(merged, ab)
+	}
+	for _, ab := range defined {
+		if !used[ab.Name] {
+			merged = append(merged, ab)
+		}
+	}
+	return merged
+}
+
+var (
+	cardDefinitionsMu sync.RWMutex
+	cardDefinitions   = map[string]*CardDefinition{}
+)
+
+// RegisterCardDefinition validates def and registers it, replacing any
+// earlier definition for the same card.
+func RegisterCardDefinition(def CardDefinition) error {
+	if err := def.compile(); err != nil {
+		if def.path != "" {
+			return fmt.Errorf("%s: %w", def.path, err)
+		}
+		return fmt.Errorf("card definition %q: %w", def.Name, err)
+	}
+	cardDefinitionsMu.Lock()
+	defer cardDefinitionsMu.Unlock()
+	cardDefinitions[cardScriptKey(def.Name)] = &def
+	return nil
+}
+
+// LookupCardDefinition returns the definition registered for the named card.
+func LookupCardDefinition(name string) (*CardDefinition, bool) {
+	cardDefinitionsMu.RLock()
+	defer cardDefinitionsMu.RUnlock()
+	d, ok := cardDefinitions[cardScriptKey(name)]
+	return d, ok
+}
+
+// HasCardDefinition reports whether the named card has a data definition.
+func HasCardDefinition(name string) bool {
+	_, ok := LookupCardDefinition(name)
+	return ok
+}
+
+// ClearCardDefinitions drops every registered definition.
+func ClearCardDefinitions() {
+	cardDefinitionsMu.Lock()
+	defer cardDefinitionsMu.Unlock()
+	cardDefinitions = map[string]*CardDefinition{}
+}
+
+// LoadCardDefinitions reads every *.json file under dir as a CardDefinition
+// and registers it. A missing directory is not an error. All files are
+// validated before any is registered, so a bad file leaves the registry
+// untouched; the returned error names every file that failed.
+func LoadCardDefinitions(dir string) (int, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(paths)
+
+	var defs []CardDefinition
+	var errs []error
+	names := map[string]string{}
+	for _, path := range paths {
+		def, err := readCardDefinition(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		key := cardScriptKey(def.Name)
+		if prev, ok := names[key]; ok {
+			errs = append(errs, fmt.Errorf("%s: %q is already defined in %s", path, def.Name, prev))
+			continue
+		}
+		names[key] = path
+		defs = append(defs, def)
+	}
+	if len(errs) > 0 {
+		return 0, errors.Join(errs...)
+	}
+	for _, def := range defs {
+		if err := RegisterCardDefinition(def); err != nil {
+			return 0, err
+		}
+	}
+	return len(defs), nil
+}
+
+func readCardDefinition(path string) (CardDefinition, error) {
+	var def CardDefinition
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return def, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&def); err != nil {
+		return def, fmt.Errorf("%s: %w", path, err)
+	}
+	def.path = path
+	if err := def.compile(); err != nil {
+		return def, fmt.Errorf("%s: %w", path, err)
+	}
+	return def, nil
+}
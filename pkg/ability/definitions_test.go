@@ -0,0 +1,165 @@
+package ability
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+)
+
+func writeDefinition(t *testing.T, dir, file, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadCardDefinitions_RepoCards(t *testing.T) {
+	t.Cleanup(ClearCardDefinitions)
+	n, err := LoadCardDefinitions(filepath.Join("..", "..", "cards"))
+	if err != nil {
+		t.Fatalf("repo card definitions failed to load: %v", err)
+	}
+	if n == 0 {
+		t.Fatalf("expected at least one definition in cards/")
+	}
+}
+
+func TestLoadCardDefinitions_MissingDirIsEmpty(t *testing.T) {
+	n, err := LoadCardDefinitions(filepath.Join(t.TempDir(), "nope"))
+	if err != nil || n != 0 {
+		t.Fatalf("expected no definitions and no error, got %d, %v", n, err)
+	}
+}
+
+func TestLoadCardDefinitions_ValidatesBeforeRegistering(t *testing.T) {
+	t.Cleanup(ClearCardDefinitions)
+	dir := t.TempDir()
+	writeDefinition(t, dir, "good.json", `{"name": "Good Card", "abilities": [{"name": "Spell", "type": "Activated", "effects": [{"type": "GainLife", "value": 3}]}]}`)
+	writeDefinition(t, dir, "bad.json", `{"name": "Bad Card", "abilities": [{"name": "Spell", "type": "Activated", "effects": [{"type": "GainLyfe", "value": 3}]}]}`)
+	writeDefinition(t, dir, "typo.json", `{"name": "Typo Card", "abilitys": []}`)
+
+	_, err := LoadCardDefinitions(dir)
+	if err == nil {
+		t.Fatalf("expected validation errors")
+	}
+	for _, want := range []string{"bad.json", `unknown effect type "GainLyfe"`, "typo.json"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+	if HasCardDefinition("Good Card") {
+		t.Fatalf("a failed load should not register any definition")
+	}
+}
+
+func TestCardDefinition_RejectsUnknownEnums(t *testing.T) {
+	cases := map[string]CardDefinition{
+		"ability type": {Name: "X", Abilities: []AbilityDefinition{{Name: "A", Type: "Sometimes", Effects: []EffectDefinition{{Type: "DrawCards"}}}}},
+		"trigger":      {Name: "X", Abilities: []AbilityDefinition{{Name: "A", Type: "Triggered", Effects: []EffectDefinition{{Type: "DrawCards"}}}}},
+		"target":       {Name: "X", Abilities: []AbilityDefinition{{Name: "A", Type: "Activated", Effects: []EffectDefinition{{Type: "DealDamage", Targets: []TargetDefinition{{Type: "Creature"}}}}}}},
+		"condition":    {Name: "X", Abilities: []AbilityDefinition{{Name: "A", Type: "Activated", Effects: []EffectDefinition{{Type: "DrawCards", Conditions: []ConditionDefinition{{Type: "Kicked"}}}}}}},
+		"mana":         {Name: "X", Abilities: []AbilityDefinition{{Name: "A", Type: "Activated", Cost: CostDefinition{Mana: map[string]int{"Q": 1}}, Effects: []EffectDefinition{{Type: "DrawCards"}}}}},
+		"scripted":     {Name: "X", Abilities: []AbilityDefinition{{Name: "A", Type: "Activated", Effects: []EffectDefinition{{Type: "Scripted"}}}}},
+	}
+	for name, def := range cases {
+		if err := RegisterCardDefinition(def); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestCardDefinition_NamesEveryEnumConstant(t *testing.T) {
+	for kind, c := range map[string]struct{ named, last int }{
+		"ability type":       {len(abilityTypes), int(Mana)},
+		"trigger condition":  {len(triggerConditions), int(AnyTrigger)},
+		"timing restriction": {len(timingRestrictions), int(OnlyMainPhase)},
+		"duration":           {len(effectDurations), int(UntilLeavesPlay)},
+		"target type":        {len(targetTypes), int(AbilityTarget)},
+		"target restriction": {len(targetRestrictions), int(EquippedRestriction)},
+	} {
+		if c.named != c.last+1 {
+			t.Errorf("%s: %d names for %d constants", kind, c.named, c.last+1)
+		}
+	}
+	if tt, err := enumValue("target type", targetTypes, "AbilityTarget"); err != nil || tt != AbilityTarget {
+		t.Errorf("AbilityTarget maps to %v, %v", tt, err)
+	}
+}
+
+func TestParseAndRegisterAbilities_MergesDefinition(t *testing.T) {
+	t.Cleanup(ClearCardDefinitions)
+	oracle := "Flying\nWhen Test Drake enters, draw a card."
+	src := card.Card{Name: "Test Drake", TypeLine: "Creature — Drake", OracleText: oracle}
+	engine := NewExecutionEngine(&mockGameState{})
+	parsed, err := engine.ParseAndRegisterAbilities(oracle, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	def := CardDefinition{Name: "Test Drake", Abilities: []AbilityDefinition{{
+		Name:    "Attack Trigger",
+		Type:    "Triggered",
+		Trigger: "AttacksOrBlocks",
+		Effects: []EffectDefinition{{Type: "PumpCreature", Duration: "UntilEndOfTurn", Power: 1, Toughness: 0}},
+	}}}
+	if err := RegisterCardDefinition(def); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := engine.ParseAndRegisterAbilities(oracle, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged) != len(parsed)+1 {
+		t.Fatalf("expected the defined ability appended to %d parsed, got %d", len(parsed), len(merged))
+	}
+	last := merged[len(merged)-1]
+	if last.Name != "Attack Trigger" || last.TriggerCondition != AttacksOrBlocks || last.Source == nil {
+		t.Fatalf("unexpected defined ability %+v", last)
+	}
+	if eff := last.Effects[0]; !eff.HasPTDelta || eff.PTPower != 1 || eff.Duration != UntilEndOfTurn {
+		t.Fatalf("unexpected defined effect %+v", eff)
+	}
+
+	// Same-named abilities replace the parsed one in place.
+	def.Abilities[0].Name = parsed[0].Name
+	if err := RegisterCardDefinition(def); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, _ = engine.ParseAndRegisterAbilities(oracle, src)
+	if len(merged) != len(parsed) || merged[0].TriggerCondition != AttacksOrBlocks {
+		t.Fatalf("expected %q to be replaced in place, got %+v", parsed[0].Name, merged)
+	}
+
+	def.Replace = true
+	if err := RegisterCardDefinition(def); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, _ = engine.ParseAndRegisterAbilities(oracle, src)
+	if len(merged) != 1 {
+		t.Fatalf("replace should drop parsed abilities, got %d", len(merged))
+	}
+}
+
+func TestCardDefinition_ReportedAsScripted(t *testing.T) {
+	t.Cleanup(ClearCardDefinitions)
+	c := card.Card{Name: "Gibberish Card", TypeLine: "Sorcery", OracleText: "Frobnicate the zorblax twice."}
+	if impl, _ := testCardImplementation(c); impl {
+		t.Fatalf("expected unparseable card to be unimplemented before it is defined")
+	}
+	err := RegisterCardDefinition(CardDefinition{Name: c.Name, Replace: true, Abilities: []AbilityDefinition{{
+		Name: "Spell", Type: "Activated", Effects: []EffectDefinition{{Type: "DrawCards", Value: 2}},
+	}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if impl, reason := testCardImplementation(c); !impl {
+		t.Fatalf("expected defined card to be implemented, got %q", reason)
+	}
+	tracker := &ImplementationTracker{entries: map[string]ImplementationStatus{c.Name: {Implemented: false}}}
+	if impl, _ := tracker.EvaluateCard(c); !impl || !tracker.IsScripted(c) {
+		t.Fatalf("defined card should bypass the cache and be reported as scripted")
+	}
+}
@@ -104,6 +104,20 @@ type ExecutionEngine struct {
 	parser          *AbilityParser
 	targetValidator *TargetValidator
 	targetParser    *TargetParser
+	resolving       *StackItem // the stack item whose effects are being applied
+	stack           *Stack     // the stack this engine resolves, set by NewStack
+}
+
+// TaxPayer is implemented by game states that let a player pay the mana in
+// "unless that player pays {N}". Without it, nobody pays.
+type TaxPayer interface {
+	PayTax(payer AbilityPlayer, amount int, effect Effect) bool
+}
+
+// TaxDecider chooses whether a player pays an "unless ... pays {N}" tax
+// they can afford.
+type TaxDecider interface {
+	ShouldPayTax(payer AbilityPlayer, amount int, effect Effect) bool
 }
 
 // NewExecutionEngine creates a new execution engine.
@@ -151,7 +165,11 @@ func (ee *ExecutionEngine) canActivateAbility(ability *Ability, controller Abili
 	// Check timing restrictions
 	switch ability.TimingRestriction {
 	case SorcerySpeed:
-		if !ee.gameState.IsMainPhase() {
+		// CR 307.1: main phase of your own turn with an empty stack.
+		if !ee.gameState.IsMainPhase() || !ee.isActivePlayer(controller) {
+			return false
+		}
+		if stack := ee.currentStack(); stack != nil && !stack.IsEmpty() {
 			return false
 		}
 	case OnlyDuringCombat:
@@ -164,27 +182,26 @@ func (ee *ExecutionEngine) canActivateAbility(ability *Ability, controller Abili
 		}
 	}
 
-	// Check tap cost requirements
+	// Check tap cost requirements: the source must be untapped, and a
+	// summoning-sick creature can't use {T} abilities other than mana
+	// abilities (CR 302.6).
 	if ability.Cost.TapCost {
-		if tapper, ok := ability.Source.(interface{ IsTapped() bool }); ok {
-			if tapper.IsTapped() {
-				return false // Cannot activate tap abilities when source is tapped
-			}
-
-			// Check summoning sickness for non-mana abilities
-			if ability.Type != Mana {
-				// Check if this permanent has summoning sickness
-				if summoningSickPerm, ok := ability.Source.(SummoningSickness); ok {
-					if summoningSickPerm.HasSummoningSickness() {
-						return false // Creatures with summoning sickness cannot use tap abilities (except mana abilities)
-					}
-				}
-			}
+		if isTapped(ability.Source) {
+			return false
+		}
+		if ability.Type != Mana && ee.summoningSick(ability.Source) {
+			return false
 		}
 	}
 
+	// Static abilities such as Null Rod's stop the source's activated
+	// abilities, mana abilities included.
+	if perm, g := scriptPermanent(ability.Source), ee.underlyingGame(); perm != nil && g != nil && !g.CanActivateAbilities(perm) {
+		return false
+	}
+
 	// Check usage limits
-	if !ability.CanActivate(controller) {
+	if !ee.withinActivationLimits(ability) {
 		return false
 	}
 
@@ -192,6 +209,9 @@ func (ee *ExecutionEngine) canActivateAbility(ability *Ability, controller Abili
 	if !controller.CanPayCost(ability.Cost) {
 		return false
 	}
+	if _, ok := ee.planTypedCosts(ability, controller); !ok {
+		return false
+	}
 
 	// Check if valid targets exist using the new target validation system
 	if ee.requiresTargets(ability) && !ee.hasValidTargets(ability, controller) {
@@ -201,11 +221,57 @@ func (ee *ExecutionEngine) canActivateAbility(ability *Ability, controller Abili
 	return true
 }
 
-// payCosts pays the costs for an ability.
+// isActivePlayer reports whether p is the player whose turn it is. Game
+// states without an active player don't restrict.
+func (ee *ExecutionEngine) isActivePlayer(p AbilityPlayer) bool {
+	active := ee.gameState.GetActivePlayer()
+	return active == nil || active.GetName() == p.GetName()
+}
+
+// PayActivationCosts checks that ability can be activated and pays all of
+// its costs, for callers that put the ability on a stack themselves.
+func (ee *ExecutionEngine) PayActivationCosts(ability *Ability, controller AbilityPlayer) error {
+	if !ee.canActivateAbility(ability, controller) {
+		return ErrCannotActivate
+	}
+	return ee.payCosts(ability, controller, ability.Source)
+}
+
+// payCosts pays the costs for an ability atomically: reversible costs
+// (tapping, counters, exert) are paid first and undone if the mana, life
+// or discard payment fails; zone changes come last, once nothing can fail.
 func (ee *ExecutionEngine) payCosts(ability *Ability, controller AbilityPlayer, source any) error {
+	steps, ok := ee.planTypedCosts(ability, controller)
+	if !ok {
+		return ErrInvalidCost
+	}
+	var paid []costStep
+	for _, st := range steps {
+		if st.undo != nil {
+			st.pay()
+			paid = append(paid, st)
+		}
+	}
 	if ability.Cost.TapCost {
-		if tapper, ok := source.(interface{ Tap() }); ok {
+		if tapper, ok := source.(interface {
+			Tap()
+			Untap()
+		}); ok {
 			tapper.Tap()
+			paid = append(paid, costStep{undo: tapper.Untap})
+		}
+	}
+	// Pay player-side costs (mana, life, discard) while the source is still
+	// on the battlefield; the adapter's affordability check counts it.
+	if err := controller.PayCost(ability.Cost); err != nil {
+		for i := len(paid) - 1; i >= 0; i-- {
+			paid[i].undo()
+		}
+		return err
+	}
+	for _, st := range steps {
+		if st.undo == nil {
+			st.pay()
 		}
 	}
 	if ability.Cost.SacrificeCost && source != nil {
@@ -213,7 +279,8 @@ func (ee *ExecutionEngine) payCosts(ability *Ability, controller AbilityPlayer,
 			s.SacrificeSource(source)
 		}
 	}
-	return controller.PayCost(ability.Cost)
+	ee.recordActivation(ability)
+	return nil
 }
 
 // resolveManaAbility resolves a mana ability immediately.
@@ -285,7 +352,13 @@ func (ee *ExecutionEngine) collectDistinctPermanentColors(controller AbilityPlay
 // resolveAbility resolves a non-mana ability.
 func (ee *ExecutionEngine) resolveAbility(ability *Ability, controller AbilityPlayer, targets []any) error {
 	for _, effect := range ability.Effects {
-		if err := ee.applyEffect(effect, controller, targets); err != nil {
+		effectTargets := targets
+		if effect.Self {
+			if perm, ok := ability.Source.(*game.Permanent); ok {
+				effectTargets = []any{perm}
+			}
+		}
+		if err := ee.applyEffect(effect, controller, effectTargets); err != nil {
 			// Mark card as unimplemented if we can identify the source card
 			if ability != nil && ability.Source != nil {
 				if cc, ok := ability.Source.(card.Card); ok {
@@ -323,8 +396,8 @@ func (ee *ExecutionEngine) checkConditions(effect Effect, controller AbilityPlay
 			}
 	case KickerPaid:
 		return false
-	case UnlessPaysMana:
-		return false
+		case UnlessPaysMana:
+			continue // settled by payTax
 		case HaveMoreLandsThanOpponent:
 			if !ee.hasMoreLandsThanAnOpponent(controller) {
 				return false
@@ -350,6 +423,9 @@ func (ee *ExecutionEngine) applyEffect(effect Effect, controller AbilityPlayer,
 		logger.LogCard("Effect skipped: conditions not met")
 		return nil
 	}
+	if ee.payTax(effect, controller, targets) {
+		return nil
+	}
 
 	switch effect.Type {
 	case DrawCards:
@@ -357,9 +433,18 @@ func (ee *ExecutionEngine) applyEffect(effect Effect, controller AbilityPlayer,
 		logger.LogCard("%s draws %d cards", controller.GetName(), effect.Value)
 
 	case DealDamage:
-		if len(targets) > 0 {
-			ee.gameState.DealDamage(controller, targets[0], effect.Value)
-			logger.LogCard("Deal %d damage to target", effect.Value)
+		// Divided damage is split among the targets (CR 601.2d); otherwise
+		// each target takes the full amount ("each of up to two targets").
+		for i, target := range targets {
+			amount := effect.Value
+			if effect.Divided {
+				amount = divideAmount(effect.Value, len(targets))[i]
+			}
+			if amount <= 0 {
+				continue
+			}
+			ee.gameState.DealDamage(controller, target, amount)
+			logger.LogCard("Deal %d damage to target", amount)
 		}
 
 	case SourcePowerDamage:
@@ -442,6 +527,10 @@ func (ee *ExecutionEngine) applyEffect(effect Effect, controller AbilityPlayer,
 		// Counter spell effect - mark the target spell as countered
 		if len(targets) > 0 {
 			if stackItem, ok := targets[0].(*StackItem); ok {
+				if stackItem.CantBeCountered() {
+					logger.LogCard("Counterspell effect resolves - %s can't be countered", stackItem.Description)
+					break
+				}
 				stackItem.Countered = true
 				logger.LogCard("Counterspell effect resolves - %s is countered", stackItem.Description)
 			} else {
@@ -452,8 +541,8 @@ func (ee *ExecutionEngine) applyEffect(effect Effect, controller AbilityPlayer,
 		}
 
 	case DestroyPermanent:
-		if len(targets) > 0 {
-			if perm, ok := targets[0].(*game.Permanent); ok {
+		for _, target := range targets {
+			if perm, ok := target.(*game.Permanent); ok {
 				// Destroy the permanent (put into graveyard)
 				owner := perm.GetOwner()
 				if owner != nil {
@@ -566,11 +655,21 @@ func (ee *ExecutionEngine) applyEffect(effect Effect, controller AbilityPlayer,
 		}
 
 	case MillCards:
-		ee.gameState.MillCards(controller, effect.Value)
-		logger.LogCard("%s mills %d cards", controller.GetName(), effect.Value)
+		milled := controller
+		if len(targets) > 0 {
+			if p, ok := targets[0].(AbilityPlayer); ok {
+				milled = p
+			}
+		}
+		ee.gameState.MillCards(milled, effect.Value)
+		logger.LogCard("%s mills %d cards", milled.GetName(), effect.Value)
 
 	case ScryCards:
-		ee.gameState.ScryLibrary(controller, effect.Value)
+		if adv, ok := ee.gameState.(interface{ ScryLibraryAdvanced(AbilityPlayer, int, string) }); ok {
+			adv.ScryLibraryAdvanced(controller, effect.Value, effect.Description)
+		} else {
+			ee.gameState.ScryLibrary(controller, effect.Value)
+		}
 		logger.LogCard("%s scries %d", controller.GetName(), effect.Value)
 
 	case AddCounters:
@@ -607,30 +706,21 @@ func (ee *ExecutionEngine) applyEffect(effect Effect, controller AbilityPlayer,
 		}
 
 	case CopySpell:
-		if s, ok := ee.gameState.(interface{ GetStack() *Stack }); ok {
-			stack := s.GetStack()
-			if stack.Size() > 0 {
-				top := stack.Peek()
-				if top != nil && top.Spell != nil {
-					copyEffects := make([]Effect, len(top.Spell.Effects))
-					copy(copyEffects, top.Spell.Effects)
-					copyTargets := make([]interface{}, len(top.Targets))
-					copy(copyTargets, top.Targets)
-					copied := &Spell{
-						ID:         uuid.New(),
-						Name:       top.Spell.Name,
-						ManaCost:   top.Spell.ManaCost,
-						CMC:        top.Spell.CMC,
-						TypeLine:   top.Spell.TypeLine,
-						OracleText: top.Spell.OracleText,
-						Effects:    copyEffects,
-						Source:     top.Spell.Source,
-					}
-					stack.AddSpell(copied, controller, copyTargets)
-					logger.LogCard("Copied spell: %s (new ID: %s)", top.Spell.Name, copied.ID)
-				}
-			}
+		stack := ee.currentStack()
+		if stack == nil {
+			logger.LogCard("CopySpell: no stack to copy from")
+			break
+		}
+		original := spellToCopy(stack, targets)
+		if original == nil {
+			logger.LogCard("CopySpell: no spell to copy")
+			break
 		}
+		copies := max(effect.Value, 1)
+		for i := 0; i < copies; i++ {
+			stack.CopyItem(original, controller, nil)
+		}
+		logger.LogCard("%s copies %s %d time(s)", controller.GetName(), original.Description, copies)
 
 	case CantAttackBlock:
 		if len(targets) > 0 {
@@ -697,9 +787,26 @@ func (ee *ExecutionEngine) applyEffect(effect Effect, controller AbilityPlayer,
 			logger.LogCard("ReanimateCreature: no valid creature target found, skipping")
 			return nil
 		}
+		if g := ee.underlyingGame(); g != nil && !g.CanEnterBattlefield(reanimatedCard, game.Graveyard) {
+			logger.LogCard("ReanimateCreature: %s can't enter the battlefield from a graveyard", reanimatedCard.Name)
+			return nil
+		}
 		ee.gameState.ReanimateCreature(controller, reanimatedCard)
 		logger.LogCard("Reanimated %s from graveyard", reanimatedCard.Name)
 
+	case Scripted:
+		if effect.Script == nil {
+			return fmt.Errorf("scripted effect %q has no script", effect.Description)
+		}
+		ctx, err := ee.scriptContext(controller, targets)
+		if err != nil {
+			return err
+		}
+		if err := effect.Script(ctx); err != nil {
+			return err
+		}
+		logger.LogCard("%s resolves script: %s", controller.GetName(), effect.Description)
+
 	default:
 		logger.LogCard("Unimplemented effect type during applyEffect: %v for %s", effect.Type, effect.Description)
 		return fmt.Errorf("unimplemented effect type: %v", effect.Type)
@@ -773,13 +880,103 @@ func CanExecuteEffect(effectType EffectType) bool {
 		KeywordAbility, ChooseMode, TakeExtraTurn, Exile,
 		MillCards, ScryCards, AddCounters, UntapPermanent, CopySpell,
 		CantAttackBlock, AdditionalLand, SacrificePermanent, ReanimateCreature,
-		WinGame, LoseGame, LookAtLibraryTop, RevealInformation, ImprintCards:
+		WinGame, LoseGame, LookAtLibraryTop, RevealInformation, ImprintCards,
+		Scripted:
 		return true
 	default:
 		return false
 	}
 }
 
+// currentStack returns the stack this engine resolves, falling back to a
+// game state that exposes one.
+func (ee *ExecutionEngine) currentStack() *Stack {
+	if ee.stack != nil {
+		return ee.stack
+	}
+	if s, ok := ee.gameState.(interface{ GetStack() *Stack }); ok {
+		return s.GetStack()
+	}
+	return nil
+}
+
+// spellToCopy returns the targeted stack item, or else the topmost spell on
+// the stack.
+func spellToCopy(stack *Stack, targets []any) *StackItem {
+	if len(targets) > 0 {
+		if item, ok := targets[0].(*StackItem); ok {
+			return item
+		}
+	}
+	items := stack.GetItems()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == StackItemSpell && items[i].Spell != nil {
+			return items[i]
+		}
+	}
+	return nil
+}
+
+// payTax offers the payer of an "unless ... pays {N}" effect the chance to
+// pay. It returns true when the tax was paid and the effect does nothing.
+func (ee *ExecutionEngine) payTax(effect Effect, controller AbilityPlayer, targets []any) bool {
+	amount, ok := unlessPaysAmount(effect)
+	if !ok {
+		return false
+	}
+	tp, ok := ee.gameState.(TaxPayer)
+	if !ok {
+		return false
+	}
+	payer := ee.taxPayer(effect, controller, targets)
+	if payer == nil || !tp.PayTax(payer, amount, effect) {
+		return false
+	}
+	logger.LogCard("%s pays {%d}: %s does nothing", payer.GetName(), amount, effect.Description)
+	return true
+}
+
+// unlessPaysAmount returns N from an effect's "unless ... pays {N}"
+// condition.
+func unlessPaysAmount(effect Effect) (int, bool) {
+	for _, cond := range effect.Conditions {
+		if cond.Type != UnlessPaysMana {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.Trim(cond.Value, "{}")); err == nil && n > 0 {
+			return n, true
+		}
+		if effect.Type == CounterSpell && effect.Value > 0 {
+			return effect.Value, true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// taxPayer picks who may pay the tax: the controller of the countered item
+// (Mana Leak), the player who triggered the ability (Rhystic Study), or
+// else the first targeted player other than the controller.
+func (ee *ExecutionEngine) taxPayer(effect Effect, controller AbilityPlayer, targets []any) AbilityPlayer {
+	if effect.Type == CounterSpell {
+		if len(targets) > 0 {
+			if item, ok := targets[0].(*StackItem); ok {
+				return item.Controller
+			}
+		}
+		return nil
+	}
+	if ee.resolving != nil && ee.resolving.TriggeringPlayer != nil {
+		return ee.resolving.TriggeringPlayer
+	}
+	for _, t := range targets {
+		if p, ok := t.(AbilityPlayer); ok && p.GetName() != controller.GetName() {
+			return p
+		}
+	}
+	return nil
+}
+
 // CanExecuteCondition returns true if the execution engine can evaluate the
 // given condition type during ability resolution.
 func CanExecuteCondition(conditionType ConditionType) bool {
@@ -906,8 +1103,8 @@ func (ee *ExecutionEngine) hasValidTargetsBasic(target Target, _ AbilityPlayer)
 			}
 		}
 		return true // Players are always valid targets
-	case SpellTarget:
-		// Spells on the stack are dynamic targets; assume possible
+	case SpellTarget, AbilityTarget:
+		// Stack items are dynamic targets; assume possible
 		return true
 	}
 	return false
@@ -946,11 +1143,17 @@ func (ee *ExecutionEngine) GetPotentialTargets(targetType TargetType, controller
 				targets = append(targets, gp.GetGraveyard()...)
 			}
 		}
-	case SpellTarget:
+	case SpellTarget, AbilityTarget:
 		// Stack items are dynamic; potential targets are queried from the stack
-		if s, ok := ee.gameState.(interface{ GetStack() *Stack }); ok {
-			for _, item := range s.GetStack().GetItems() {
-				targets = append(targets, item)
+		want := StackItemSpell
+		if targetType == AbilityTarget {
+			want = StackItemAbility
+		}
+		if stack := ee.currentStack(); stack != nil {
+			for _, item := range stack.GetItems() {
+				if item.Type == want {
+					targets = append(targets, item)
+				}
 			}
 		}
 	}
@@ -1007,8 +1210,9 @@ func (ee *ExecutionEngine) isValidBasicTarget(target any, targetReq Target) bool
 	case CardInGraveyardTarget:
 		return target != nil
 	case SpellTarget:
-		_, ok := target.(*StackItem)
-		return ok
+		return isStackItemOfType(target, StackItemSpell)
+	case AbilityTarget:
+		return isStackItemOfType(target, StackItemAbility)
 	default:
 		return false
 	}
@@ -1024,14 +1228,12 @@ func (ee *ExecutionEngine) applyPumpEffect(target any, power, toughness int, dur
 			// Also register as a layered effect when the game state supports it
 			if lgs, ok := ee.gameState.(interface{ AddLayeredEffect(*game.LayeredEffect) uint64 }); ok {
 				lgs.AddLayeredEffect(&game.LayeredEffect{
-					Layer:    game.Layer7PT,
-					Sublayer: game.Sublayer7C,
-					Source:   gp,
-					Affects:  func(p *game.Permanent) bool { return p == gp },
-					Apply: func(_ *game.Permanent, v *game.PermanentView) {
-						v.Power += power
-						v.Toughness += toughness
-					},
+					Layer:      game.Layer7PT,
+					Sublayer:   game.Sublayer7C,
+					Source:     gp,
+					Target:     gp,
+					Power:      power,
+					Toughness:  toughness,
 					ExpiresEOT: true,
 				})
 			}
@@ -1066,8 +1268,14 @@ func (ee *ExecutionEngine) applyTapEffect(target any, shouldTap bool) {
 }
 
 // ParseAndRegisterAbilities parses abilities from oracle text and registers them.
+// A registered CardDefinition for the source is merged over the parsed
+// abilities; parse errors are ignored for defined cards.
 func (ee *ExecutionEngine) ParseAndRegisterAbilities(oracleText string, source any) ([]*Ability, error) {
 	abilities, err := ee.parser.ParseAbilities(oracleText, source)
+	def, defined := LookupCardDefinition(oracleSourceName(source))
+	if defined {
+		abilities, err = def.mergeOver(abilities, source), nil
+	}
 	if err != nil {
 		if cc, ok := source.(card.Card); ok {
 			markUnimplementedCard(cc.Name, fmt.Sprintf("parse error: %v", err))
@@ -1077,7 +1285,7 @@ func (ee *ExecutionEngine) ParseAndRegisterAbilities(oracleText string, source a
 
 	// A card with oracle text inherently has abilities. If the parser returns
 	// zero abilities, that is a parser failure, not a missing-ability card.
-	if len(abilities) == 0 {
+	if len(abilities) == 0 && !defined {
 		if cc, ok := source.(card.Card); ok {
 			if strings.TrimSpace(cc.OracleText) != "" && !isBasicLand(cc.TypeLine) {
 				markUnimplementedCard(cc.Name, "parser failed to extract abilities from oracle text")
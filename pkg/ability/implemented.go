@@ -21,6 +21,7 @@ var sharedParser = NewAbilityParser()
 // ImplementationStatus tracks whether a card is fully supported by the engine.
 type ImplementationStatus struct {
 	Implemented bool   `json:"implemented"`
+	Scripted    bool   `json:"scripted,omitempty"`
 	Reason      string `json:"reason,omitempty"`
 	ColorID     string `json:"color_id,omitempty"`
 	Set         string `json:"set,omitempty"`
@@ -66,13 +67,16 @@ func (t *ImplementationTracker) EvaluateCard(c card.Card) (bool, string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if existing, ok := t.entries[c.Name]; ok {
+	// Definitions can change between runs without a rebuild, so cached
+	// results for defined cards are never trusted.
+	if existing, ok := t.entries[c.Name]; ok && !HasCardDefinition(c.Name) {
 		return existing.Implemented, existing.Reason
 	}
 
 	impl, reason := testCardImplementation(c)
 	t.entries[c.Name] = ImplementationStatus{
 		Implemented: impl,
+		Scripted:    t.IsScripted(c),
 		Reason:      reason,
 		ColorID:     colorIDString(c.ColorIdentity),
 		Set:         c.Set,
@@ -81,6 +85,12 @@ func (t *ImplementationTracker) EvaluateCard(c card.Card) (bool, string) {
 	return impl, reason
 }
 
+// IsScripted reports whether c is implemented by a hand-written CardScript or
+// a CardDefinition rather than parsed from its oracle text.
+func (t *ImplementationTracker) IsScripted(c card.Card) bool {
+	return HasCardScript(c.Name) || HasCardDefinition(c.Name)
+}
+
 // CheckDeck returns the names of unimplemented cards in a deck list.
 func (t *ImplementationTracker) CheckDeck(deckCards []card.Card, db *card.CardDB) []string {
 	var unimpl []string
@@ -114,6 +124,7 @@ func (t *ImplementationTracker) EvaluateAllInDB(db *card.CardDB) {
 		t.mu.Lock()
 		t.entries[c.Name] = ImplementationStatus{
 			Implemented: impl,
+			Scripted:    t.IsScripted(c),
 			Reason:      reason,
 			ColorID:     colorIDString(c.ColorIdentity),
 			Set:         c.Set,
@@ -166,10 +177,22 @@ func testCardImplementation(c card.Card) (bool, string) {
 	if isBasicLand(c.TypeLine) {
 		return true, ""
 	}
+	if HasCardScript(c.Name) {
+		return true, ""
+	}
 
-	abilities, err := sharedParser.ParseAbilities(oracle, c)
-	if err != nil {
-		return false, fmt.Sprintf("parse error: %v", err)
+	report := sharedParser.ParseOracle(oracle, c)
+	abilities := report.Abilities
+	if def, ok := LookupCardDefinition(c.Name); ok {
+		// A definition vouches for the whole card, so unparsed text no
+		// longer counts against it; only its merged abilities are checked.
+		abilities = def.mergeOver(abilities, c)
+		if len(abilities) == 0 {
+			return true, ""
+		}
+	} else if len(report.Unparsed) > 0 {
+		u := report.Unparsed[0]
+		return false, fmt.Sprintf("parser failed on %q: %s", u.Text, u.Reason)
 	}
 
 	// A card with oracle text inherently has abilities. If the parser returns
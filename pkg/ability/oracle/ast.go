@@ -0,0 +1,339 @@
+package oracle
+
+// Span is a half-open byte range [Start, End) in the oracle text.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Text returns the slice of src covered by s.
+func (s Span) Text(src string) string {
+	if s.Start < 0 || s.End > len(src) || s.Start > s.End {
+		return ""
+	}
+	return src[s.Start:s.End]
+}
+
+// Document is the parse of one card's oracle text.
+type Document struct {
+	Text   string
+	Blocks []*Block
+}
+
+// Block is one ability's worth of text: a single line, or a modal header
+// together with its bullet lines. Ability is nil when the grammar could not
+// account for the whole block; Unparsed then says exactly which spans it
+// gave up on and why.
+type Block struct {
+	Span     Span
+	Ability  *Ability
+	Unparsed []Unparsed
+}
+
+// Abilities returns the abilities of every fully parsed block.
+func (d *Document) Abilities() []*Ability {
+	var out []*Ability
+	for _, b := range d.Blocks {
+		if b.Ability != nil {
+			out = append(out, b.Ability)
+		}
+	}
+	return out
+}
+
+// Unparsed returns every span the grammar could not parse, in text order.
+func (d *Document) Unparsed() []Unparsed {
+	var out []Unparsed
+	for _, b := range d.Blocks {
+		out = append(out, b.Unparsed...)
+	}
+	return out
+}
+
+// Unparsed is a span of oracle text the grammar rejected.
+type Unparsed struct {
+	Span   Span
+	Text   string
+	Reason string
+}
+
+// AbilityKind is the syntactic shape of an ability.
+type AbilityKind int
+
+const (
+	KindSpell     AbilityKind = iota // instructions on an instant or sorcery
+	KindActivated                    // cost: effect
+	KindTriggered                    // when/whenever/at trigger, effect
+	KindStatic                       // continuous effect of a permanent
+	KindKeywords                     // comma-separated keyword list
+)
+
+func (k AbilityKind) String() string {
+	switch k {
+	case KindSpell:
+		return "spell"
+	case KindActivated:
+		return "activated"
+	case KindTriggered:
+		return "triggered"
+	case KindStatic:
+		return "static"
+	case KindKeywords:
+		return "keywords"
+	default:
+		return "unknown"
+	}
+}
+
+// Ability is the AST root for one block.
+type Ability struct {
+	Kind      AbilityKind
+	Span      Span
+	Cost      *Cost
+	Trigger   *Trigger
+	Condition *Condition
+	Effects   []*Effect
+	Keywords  []Keyword
+	Modes     *Modes
+	// Timing restricts activation: "sorcery", "once", "your turn".
+	Timing string
+}
+
+// Keyword is one entry of a keyword line, e.g. "Flying" or "Protection
+// from red" (Param "red").
+type Keyword struct {
+	Name  string
+	Param string
+	Span  Span
+}
+
+// Modes is a "Choose one —" header and its bullet options.
+type Modes struct {
+	Min, Max int // Max 0 means any number
+	Options  [][]*Effect
+}
+
+// Cost is the part of an activated ability before the colon.
+type Cost struct {
+	Span Span
+	// Mana holds symbols without braces, in order: "2", "U", "X".
+	Mana      []string
+	Tap       bool
+	Untap     bool
+	Life      int
+	Loyalty   int
+	IsLoyalty bool
+	Sacrifice *Object
+	Discard   *Object
+	Exile     *Object
+	// RemoveCounters is N in "remove N <Counter> counters from ~".
+	RemoveCounters int
+	Counter        string
+	// TapUntapped is the object in "tap an untapped creature you control".
+	TapUntapped *Object
+	// Return is the object in "return a land you control to its owner's hand".
+	Return *Object
+	Exert  bool
+}
+
+// TriggerEvent is the event a triggered ability waits for.
+type TriggerEvent int
+
+const (
+	EventEnters TriggerEvent = iota
+	EventLeaves
+	EventDies
+	EventAttacks
+	EventBlocks
+	EventAttacksOrBlocks
+	EventCombatDamage
+	EventBecomesTarget
+	EventUpkeep
+	EventEndStep
+	EventCast
+	EventLandPlayed
+)
+
+func (e TriggerEvent) String() string {
+	switch e {
+	case EventEnters:
+		return "enters"
+	case EventLeaves:
+		return "leaves"
+	case EventDies:
+		return "dies"
+	case EventAttacks:
+		return "attacks"
+	case EventBlocks:
+		return "blocks"
+	case EventAttacksOrBlocks:
+		return "attacks or blocks"
+	case EventCombatDamage:
+		return "combat damage"
+	case EventBecomesTarget:
+		return "becomes target"
+	case EventUpkeep:
+		return "upkeep"
+	case EventEndStep:
+		return "end step"
+	case EventCast:
+		return "cast"
+	case EventLandPlayed:
+		return "land played"
+	default:
+		return "unknown"
+	}
+}
+
+// Trigger is the "When X does Y" clause of a triggered ability.
+type Trigger struct {
+	Span    Span
+	Event   TriggerEvent
+	Subject *Object
+}
+
+// ConditionKind is an "if" clause the grammar understands.
+type ConditionKind int
+
+const (
+	CondControl    ConditionKind = iota // if you control a Forest
+	CondEmptyHand                       // if you have no cards in hand
+	CondKicked                          // if this spell was kicked
+	CondUnlessPays                      // unless its controller pays {3}
+)
+
+// Condition gates an ability or an effect.
+type Condition struct {
+	Span  Span
+	Kind  ConditionKind
+	Value string
+}
+
+// Object is a noun phrase: what an effect acts on or who performs it.
+type Object struct {
+	Span   Span
+	Self   bool // the card itself
+	Target bool
+	Any    bool // "any target"
+	UpTo   bool
+	Each   bool // each/all
+	Count  int
+	// Types are singular lower-case type words: creature, player, spell.
+	Types []string
+	// Qualifiers are adjectives and trailing clauses in lower case:
+	// "nonland", "tapped", "another", "with flying", "you control".
+	Qualifiers []string
+	// Ref is set for anaphora: "it", "them", "that player".
+	Ref string
+}
+
+// Is reports whether o names type t.
+func (o *Object) Is(t string) bool {
+	if o == nil {
+		return false
+	}
+	for _, x := range o.Types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Verb is the action of an effect clause.
+type Verb int
+
+const (
+	VerbDraw Verb = iota
+	VerbDiscard
+	VerbGainLife
+	VerbLoseLife
+	VerbDamage
+	VerbDestroy
+	VerbExile
+	VerbReturnToHand
+	VerbReturnToBattlefield
+	VerbCounter
+	VerbCreateToken
+	VerbSearch
+	VerbScry
+	VerbSurveil
+	VerbMill
+	VerbTap
+	VerbUntap
+	VerbAddMana
+	VerbPutCounters
+	VerbPump
+	VerbGainKeyword
+	VerbSacrifice
+	VerbExtraTurn
+	VerbWin
+	VerbLose
+	VerbLookAtTop
+	VerbCantAttackBlock
+	VerbPreventCombatDamage
+	VerbAdditionalLand
+)
+
+var verbNames = [...]string{
+	"draw", "discard", "gain life", "lose life", "deal damage", "destroy",
+	"exile", "return to hand", "return to battlefield", "counter",
+	"create token", "search", "scry", "surveil", "mill", "tap", "untap",
+	"add mana", "put counters", "pump", "gain keyword", "sacrifice",
+	"extra turn", "win", "lose", "look at top",
+	"can't attack or block", "prevent combat damage", "additional land",
+}
+
+func (v Verb) String() string {
+	if int(v) < len(verbNames) {
+		return verbNames[v]
+	}
+	return "unknown"
+}
+
+// Duration is how long an effect lasts.
+type Duration int
+
+const (
+	DurationInstant Duration = iota
+	DurationEndOfTurn
+	DurationEndOfCombat
+	DurationStatic
+)
+
+// Amount is a count in an effect: a number, a number word or X.
+type Amount struct {
+	N int
+	X bool
+}
+
+// TokenSpec describes the tokens a create effect makes.
+type TokenSpec struct {
+	Power, Toughness int
+	HasPT            bool
+	Colors           []string
+	Types            []string // capitalised as printed: Soldier, Creature
+	Keywords         []string
+}
+
+// Effect is one clause: an optional subject, a verb and its arguments.
+type Effect struct {
+	Span      Span
+	Verb      Verb
+	Subject   *Object
+	Object    *Object
+	Amount    Amount
+	Power     int
+	Toughness int
+	Keywords  []string
+	Token     *TokenSpec
+	Mana      []string // symbols for add mana; "any" for one mana of any color
+	// ManaChoice means Mana lists alternatives ("{R} or {G}") rather than
+	// symbols that are all added.
+	ManaChoice bool
+	Counter    string // counter kind: "+1/+1", "loyalty"
+	Duration   Duration
+	Optional   bool
+	Condition  *Condition
+	Tapped     bool // enters/returns tapped
+}
@@ -0,0 +1,264 @@
+// Package oracle tokenizes and parses Magic oracle text into a typed syntax
+// tree. It knows nothing about the execution engine; pkg/ability compiles
+// the tree into runnable abilities and reports any span the grammar could
+// not account for.
+package oracle
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind classifies a lexical token.
+type TokenKind int
+
+const (
+	TokWord    TokenKind = iota // plain word, lower-cased in Token.Lower
+	TokNumber                   // 3, 10, X
+	TokSymbol                   // one mana/tap symbol: {T}, {2}, {U/P}
+	TokPT                       // power/toughness: +1/+1, -2/-0, 3/3, X/X
+	TokPunct                    // , . : ; — •
+	TokQuote                    // " (quoted granted abilities)
+	TokSelf                     // the card's own name, "this creature", ~
+	TokNewline                  // ability separator
+	TokEOF
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokWord:
+		return "word"
+	case TokNumber:
+		return "number"
+	case TokSymbol:
+		return "symbol"
+	case TokPT:
+		return "p/t"
+	case TokPunct:
+		return "punctuation"
+	case TokQuote:
+		return "quote"
+	case TokSelf:
+		return "self reference"
+	case TokNewline:
+		return "newline"
+	case TokEOF:
+		return "end of text"
+	default:
+		return "unknown"
+	}
+}
+
+// Token is one lexeme with its byte span in the original oracle text.
+type Token struct {
+	Kind  TokenKind
+	Text  string
+	Lower string
+	Pos   int
+	End   int
+}
+
+// selfPhrases are the ways oracle text refers to the object it is printed
+// on besides its name.
+var selfPhrases = []string{
+	"this creature", "this land", "this artifact", "this enchantment",
+	"this planeswalker", "this permanent", "this spell", "this card",
+	"this equipment", "this aura", "this vehicle", "this saga",
+}
+
+// Tokenize splits oracle text into tokens. Reminder text in parentheses is
+// skipped, since it never carries rules. cardName (and its short form
+// before a comma, e.g. "Urza" for "Urza, Lord High Artificer") is emitted
+// as TokSelf.
+func Tokenize(text, cardName string) []Token {
+	names := selfNames(cardName)
+	lower := strings.ToLower(text)
+	var toks []Token
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '\n':
+			toks = append(toks, Token{Kind: TokNewline, Text: "\n", Lower: "\n", Pos: i, End: i + 1})
+			i++
+			continue
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r == '(':
+			i = skipParen(text, i)
+			continue
+		case r == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				end = len(text) - i - 1
+			}
+			sym := text[i : i+end+1]
+			toks = append(toks, Token{Kind: TokSymbol, Text: sym, Lower: strings.ToUpper(sym), Pos: i, End: i + end + 1})
+			i += end + 1
+			continue
+		case r == '"' || r == '“' || r == '”':
+			toks = append(toks, Token{Kind: TokQuote, Text: text[i : i+size], Lower: "\"", Pos: i, End: i + size})
+			i += size
+			continue
+		case r == '~':
+			toks = append(toks, Token{Kind: TokSelf, Text: "~", Lower: "~", Pos: i, End: i + 1})
+			i++
+			continue
+		}
+
+		if n := matchSelf(lower, i, names); n > 0 {
+			toks = append(toks, Token{Kind: TokSelf, Text: text[i : i+n], Lower: lower[i : i+n], Pos: i, End: i + n})
+			i += n
+			continue
+		}
+		if n := matchPT(text[i:]); n > 0 {
+			toks = append(toks, Token{Kind: TokPT, Text: text[i : i+n], Lower: strings.ToLower(text[i : i+n]), Pos: i, End: i + n})
+			i += n
+			continue
+		}
+		if strings.ContainsRune(",.:;•", r) || r == '—' || r == '−' && !nextIsDigit(text, i+size) {
+			toks = append(toks, Token{Kind: TokPunct, Text: text[i : i+size], Lower: text[i : i+size], Pos: i, End: i + size})
+			i += size
+			continue
+		}
+
+		start := i
+		for i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+			if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’' || r == '-' || r == '+' || r == '−') {
+				break
+			}
+			i += size
+		}
+		if i == start {
+			// Unknown rune: emit as punctuation so the parser can report it.
+			toks = append(toks, Token{Kind: TokPunct, Text: text[i : i+size], Lower: text[i : i+size], Pos: i, End: i + size})
+			i += size
+			continue
+		}
+		word := text[start:i]
+		kind := TokWord
+		if isNumber(word) {
+			kind = TokNumber
+		}
+		toks = append(toks, Token{Kind: kind, Text: word, Lower: normalizeWord(word), Pos: start, End: i})
+	}
+	toks = append(toks, Token{Kind: TokEOF, Pos: len(text), End: len(text)})
+	return toks
+}
+
+func selfNames(cardName string) []string {
+	var names []string
+	if cardName != "" {
+		names = append(names, strings.ToLower(cardName))
+		if i := strings.Index(cardName, ","); i > 0 {
+			names = append(names, strings.ToLower(cardName[:i]))
+		}
+		// Double-faced cards refer to each face by its own name.
+		for _, face := range strings.Split(cardName, " // ") {
+			if face != cardName {
+				names = append(names, strings.ToLower(strings.TrimSpace(face)))
+			}
+		}
+	}
+	return append(names, selfPhrases...)
+}
+
+func matchSelf(lower string, i int, names []string) int {
+	if i > 0 {
+		prev, _ := utf8.DecodeLastRuneInString(lower[:i])
+		if unicode.IsLetter(prev) {
+			return 0
+		}
+	}
+	best := 0
+	for _, n := range names {
+		if n == "" || !strings.HasPrefix(lower[i:], n) {
+			continue
+		}
+		end := i + len(n)
+		if end < len(lower) {
+			next, _ := utf8.DecodeRuneInString(lower[end:])
+			if unicode.IsLetter(next) {
+				continue
+			}
+		}
+		if len(n) > best {
+			best = len(n)
+		}
+	}
+	return best
+}
+
+func skipParen(text string, i int) int {
+	depth := 0
+	for j := i; j < len(text); j++ {
+		switch text[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(text)
+}
+
+// matchPT matches "+1/+1", "-2/-0", "3/3", "X/X", "+X/+0" at the start of s
+// and returns its byte length.
+func matchPT(s string) int {
+	part := func(s string) int {
+		n := 0
+		if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+			n = 1
+		} else if strings.HasPrefix(s, "−") {
+			n = len("−")
+		}
+		digits := 0
+		for n < len(s) && (s[n] >= '0' && s[n] <= '9' || s[n] == 'X' || s[n] == '*') {
+			n++
+			digits++
+		}
+		if digits == 0 {
+			return 0
+		}
+		return n
+	}
+	a := part(s)
+	if a == 0 || a >= len(s) || s[a] != '/' {
+		return 0
+	}
+	b := part(s[a+1:])
+	if b == 0 {
+		return 0
+	}
+	return a + 1 + b
+}
+
+func nextIsDigit(s string, i int) bool {
+	return i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == 'X')
+}
+
+func isNumber(w string) bool {
+	if w == "X" {
+		return true
+	}
+	for _, r := range w {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return w != ""
+}
+
+// normalizeWord lower-cases a word and folds typographic apostrophes and
+// the minus sign so the grammar matches one spelling.
+func normalizeWord(w string) string {
+	w = strings.ToLower(w)
+	w = strings.ReplaceAll(w, "’", "'")
+	return strings.ReplaceAll(w, "−", "-")
+}
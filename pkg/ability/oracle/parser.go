@@ -0,0 +1,1514 @@
+package oracle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse tokenizes and parses oracle text. Every line (a modal header plus
+// its bullets counts as one) becomes a Block; blocks the grammar cannot
+// fully account for carry Unparsed spans instead of an Ability.
+func Parse(text, cardName string) *Document {
+	doc := &Document{Text: text}
+	lines := splitLines(Tokenize(text, cardName))
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		var bullets [][]Token
+		for i+1 < len(lines) && isBullet(lines[i+1]) {
+			i++
+			bullets = append(bullets, lines[i][1:])
+		}
+		b := parseBlock(text, line, bullets)
+		b.Span = lineExtent(text, b.Span)
+		if b.Ability != nil {
+			b.Ability.Span = b.Span
+		}
+		doc.Blocks = append(doc.Blocks, b)
+	}
+	return doc
+}
+
+// lineExtent widens s to the whole lines it touches, so a block's text
+// keeps leading ability words and trailing reminder text.
+func lineExtent(text string, s Span) Span {
+	start := strings.LastIndexByte(text[:s.Start], '\n') + 1
+	end := len(text)
+	if i := strings.IndexByte(text[s.End:], '\n'); i >= 0 {
+		end = s.End + i
+	}
+	for start < end && (text[start] == ' ' || text[start] == '\t' || text[start] == '\r') {
+		start++
+	}
+	for end > start && (text[end-1] == ' ' || text[end-1] == '\t' || text[end-1] == '\r') {
+		end--
+	}
+	return Span{Start: start, End: end}
+}
+
+func splitLines(toks []Token) [][]Token {
+	var lines [][]Token
+	var cur []Token
+	for _, t := range toks {
+		if t.Kind == TokNewline || t.Kind == TokEOF {
+			if len(cur) > 0 {
+				lines = append(lines, cur)
+			}
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	return lines
+}
+
+func isBullet(line []Token) bool {
+	return len(line) > 0 && line[0].Kind == TokPunct && line[0].Text == "•"
+}
+
+// parseError marks the token at which the grammar gave up.
+type parseError struct {
+	at     int
+	reason string
+}
+
+func (e *parseError) Error() string { return e.reason }
+
+type parser struct {
+	src         string
+	toks        []Token
+	pos         int
+	bullets     [][]Token
+	usedBullets bool
+	unparsed    *[]Unparsed
+}
+
+func newParser(src string, line []Token, unparsed *[]Unparsed) *parser {
+	end := line[len(line)-1].End
+	toks := append(append([]Token(nil), line...), Token{Kind: TokEOF, Pos: end, End: end})
+	return &parser{src: src, toks: toks, unparsed: unparsed}
+}
+
+func parseBlock(src string, line []Token, bullets [][]Token) *Block {
+	var unparsed []Unparsed
+	p := newParser(src, line, &unparsed)
+	p.bullets = bullets
+	end := line[len(line)-1].End
+	if n := len(bullets); n > 0 && len(bullets[n-1]) > 0 {
+		last := bullets[n-1]
+		end = last[len(last)-1].End
+	}
+	b := &Block{Span: Span{Start: line[0].Pos, End: end}}
+	ab := p.parseAbility()
+	if !p.usedBullets {
+		for _, bl := range bullets {
+			if len(bl) > 0 {
+				p.report(Span{Start: bl[0].Pos, End: bl[len(bl)-1].End}, "mode without a choose header")
+			}
+		}
+	}
+	b.Unparsed = unparsed
+	if len(unparsed) == 0 && ab != nil {
+		b.Ability = ab
+	}
+	return b
+}
+
+// --- token helpers ---------------------------------------------------------
+
+func (p *parser) peek() Token { return p.toks[p.pos] }
+
+func (p *parser) peekAt(n int) Token {
+	if p.pos+n < len(p.toks) {
+		return p.toks[p.pos+n]
+	}
+	return p.toks[len(p.toks)-1]
+}
+
+func (p *parser) next() Token {
+	t := p.toks[p.pos]
+	if t.Kind != TokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) atEOF() bool { return p.peek().Kind == TokEOF }
+
+// at reports whether the next tokens spell words (compared lower-cased).
+func (p *parser) at(words ...string) bool {
+	for i, w := range words {
+		t := p.peekAt(i)
+		if t.Kind == TokEOF || t.Lower != w {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) accept(words ...string) bool {
+	if !p.at(words...) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *parser) acceptAny(words ...string) (string, bool) {
+	for _, w := range words {
+		if p.accept(w) {
+			return w, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(words ...string) error {
+	if p.accept(words...) {
+		return nil
+	}
+	return p.errf("expected %q, found %s", strings.Join(words, " "), p.describe())
+}
+
+func (p *parser) describe() string {
+	t := p.peek()
+	if t.Kind == TokEOF {
+		return "end of text"
+	}
+	return strconv.Quote(t.Text)
+}
+
+func (p *parser) errf(format string, args ...interface{}) error {
+	return &parseError{at: p.pos, reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) errAt(at int, format string, args ...interface{}) error {
+	return &parseError{at: at, reason: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) spanFrom(start int) Span {
+	last := p.pos - 1
+	if last < start {
+		last = start
+	}
+	return Span{Start: p.toks[start].Pos, End: p.toks[last].End}
+}
+
+func (p *parser) report(s Span, reason string) {
+	*p.unparsed = append(*p.unparsed, Unparsed{Span: s, Text: s.Text(p.src), Reason: reason})
+}
+
+// sentence runs fn and requires it to stop at a period or the end of the
+// line. On failure it reports the span from the failing token to the end
+// of the sentence and resynchronises after it.
+func (p *parser) sentence(fn func() error) bool {
+	start := p.pos
+	err := fn()
+	if err == nil {
+		if p.accept(".") || p.atEOF() {
+			return true
+		}
+		err = p.errf("expected end of sentence, found %s", p.describe())
+	}
+	at := start
+	if pe, ok := err.(*parseError); ok && pe.at > start {
+		at = pe.at
+	}
+	if t := p.toks[at]; t.Kind == TokEOF || t.Text == "." {
+		at = start
+	}
+	p.pos = at
+	p.skipSentence()
+	end := p.pos - 1
+	if end < at {
+		end = at
+	}
+	p.report(Span{Start: p.toks[at].Pos, End: p.toks[end].End}, err.Error())
+	p.accept(".")
+	return false
+}
+
+// skipSentence advances to the next period outside quotes, or the end.
+func (p *parser) skipSentence() {
+	quoted := false
+	for !p.atEOF() {
+		t := p.peek()
+		if t.Kind == TokQuote {
+			quoted = !quoted
+		} else if !quoted && t.Text == "." {
+			return
+		}
+		p.pos++
+	}
+}
+
+// hasTopLevel reports whether punct appears in the line outside quotes.
+func (p *parser) hasTopLevel(punct string) bool {
+	quoted := false
+	for _, t := range p.toks[p.pos:] {
+		if t.Kind == TokQuote {
+			quoted = !quoted
+		} else if !quoted && t.Kind == TokPunct && t.Text == punct {
+			return true
+		}
+	}
+	return false
+}
+
+// skipAbilityWord drops a flavour label such as "Landfall —" that has no
+// rules meaning.
+func (p *parser) skipAbilityWord() {
+	if p.at("choose") {
+		return
+	}
+	for i := 0; i < 5; i++ {
+		t := p.peekAt(i)
+		if t.Kind == TokPunct && t.Text == "—" {
+			if i > 0 {
+				p.pos += i + 1
+			}
+			return
+		}
+		if t.Kind != TokWord {
+			return
+		}
+	}
+}
+
+// --- abilities -------------------------------------------------------------
+
+func (p *parser) parseAbility() *Ability {
+	p.skipAbilityWord()
+	switch {
+	case p.at("when") || p.at("whenever") || p.at("at", "the", "beginning"):
+		return p.parseTriggered()
+	case p.hasTopLevel(":"):
+		return p.parseActivated()
+	case p.atKeyword():
+		return p.parseKeywordLine()
+	}
+	ab := &Ability{Kind: KindSpell}
+	p.parseBody(ab)
+	if ab.Modes == nil && len(ab.Effects) > 0 && allStatic(ab.Effects) {
+		ab.Kind = KindStatic
+	}
+	return ab
+}
+
+func allStatic(effs []*Effect) bool {
+	for _, e := range effs {
+		if e.Duration != DurationStatic {
+			return false
+		}
+	}
+	return true
+}
+
+// parseBody parses effect sentences to the end of the line, including a
+// modal header and trailing activation restrictions.
+func (p *parser) parseBody(ab *Ability) {
+	for !p.atEOF() {
+		switch {
+		case p.at("choose"):
+			p.sentence(func() error { return p.parseModes(ab) })
+		case p.at("activate"):
+			p.sentence(func() error { return p.parseTiming(ab) })
+		default:
+			var effs []*Effect
+			if p.sentence(func() (err error) { effs, err = p.parseSentence(); return }) {
+				ab.Effects = append(ab.Effects, effs...)
+			}
+		}
+	}
+}
+
+func (p *parser) parseTiming(ab *Ability) error {
+	if err := p.expect("activate"); err != nil {
+		return err
+	}
+	p.accept("this", "ability")
+	if err := p.expect("only"); err != nil {
+		return err
+	}
+	switch {
+	case p.accept("as", "a", "sorcery"):
+		ab.Timing = "sorcery"
+	case p.accept("once", "each", "turn"):
+		ab.Timing = "once"
+	case p.accept("during", "your", "turn"):
+		ab.Timing = "your turn"
+	default:
+		return p.errf("unsupported activation restriction %s", p.describe())
+	}
+	return nil
+}
+
+func (p *parser) parseModes(ab *Ability) error {
+	if err := p.expect("choose"); err != nil {
+		return err
+	}
+	m := &Modes{}
+	switch {
+	case p.accept("one", "or", "both"):
+		m.Min, m.Max = 1, 2
+	case p.accept("one", "or", "more"):
+		m.Min, m.Max = 1, 0
+	case p.accept("any", "number"):
+		m.Min, m.Max = 0, 0
+	default:
+		amt, ok := p.parseAmount()
+		if !ok || amt.X {
+			return p.errf("expected number of modes, found %s", p.describe())
+		}
+		m.Min, m.Max = amt.N, amt.N
+	}
+	if t := p.peek(); t.Kind != TokPunct || t.Text != "—" {
+		return p.errf("expected \"—\", found %s", p.describe())
+	}
+	p.next()
+	if !p.atEOF() {
+		return p.errf("expected modes on the following lines, found %s", p.describe())
+	}
+	if len(p.bullets) == 0 {
+		return p.errf("modal ability without modes")
+	}
+	for _, bl := range p.bullets {
+		if len(bl) == 0 {
+			continue
+		}
+		sub := newParser(p.src, bl, p.unparsed)
+		opt := &Ability{}
+		sub.parseBody(opt)
+		m.Options = append(m.Options, opt.Effects)
+	}
+	p.usedBullets = true
+	ab.Modes = m
+	return nil
+}
+
+func (p *parser) parseTriggered() *Ability {
+	ab := &Ability{Kind: KindTriggered}
+	ok := p.sentenceUntilComma(func() error {
+		tr, err := p.parseTrigger()
+		ab.Trigger = tr
+		return err
+	})
+	if !ok {
+		return nil
+	}
+	if p.accept("if") {
+		cond, err := p.parseCondition()
+		if err == nil {
+			err = p.expect(",")
+		}
+		if err != nil {
+			p.sentence(func() error { return err })
+			return nil
+		}
+		ab.Condition = cond
+	}
+	p.parseBody(ab)
+	return ab
+}
+
+// sentenceUntilComma is sentence for clauses that end in a comma (triggers).
+func (p *parser) sentenceUntilComma(fn func() error) bool {
+	err := fn()
+	if err == nil {
+		err = p.expect(",")
+	}
+	if err == nil {
+		return true
+	}
+	p.sentence(func() error { return err })
+	return false
+}
+
+func (p *parser) parseActivated() *Ability {
+	ab := &Ability{Kind: KindActivated}
+	start := p.pos
+	cost, err := p.parseCost()
+	if err == nil {
+		err = p.expect(":")
+	}
+	if err != nil {
+		p.sentence(func() error { return err })
+		return nil
+	}
+	cost.Span = p.spanFrom(start)
+	ab.Cost = cost
+	if cost.IsLoyalty {
+		ab.Timing = "sorcery"
+	}
+	p.parseBody(ab)
+	return ab
+}
+
+// --- costs -----------------------------------------------------------------
+
+func (p *parser) parseCost() (*Cost, error) {
+	c := &Cost{}
+	// Loyalty abilities: "+1:", "−2:", "0:".
+	if t := p.peek(); (t.Kind == TokWord || t.Kind == TokNumber) && p.peekAt(1).Text == ":" {
+		if n, err := strconv.Atoi(strings.TrimPrefix(t.Lower, "+")); err == nil {
+			p.next()
+			c.IsLoyalty, c.Loyalty = true, n
+			return c, nil
+		}
+	}
+	for {
+		if err := p.parseCostItem(c); err != nil {
+			return nil, err
+		}
+		if !p.accept(",") {
+			return c, nil
+		}
+	}
+}
+
+func (p *parser) parseCostItem(c *Cost) error {
+	if p.peek().Kind == TokSymbol {
+		for p.peek().Kind == TokSymbol {
+			sym := strings.Trim(p.peek().Lower, "{}")
+			switch {
+			case sym == "T":
+				c.Tap = true
+			case sym == "Q":
+				c.Untap = true
+			case isManaSymbol(sym):
+				c.Mana = append(c.Mana, sym)
+			default:
+				return p.errf("unsupported cost symbol %s", p.describe())
+			}
+			p.next()
+		}
+		return nil
+	}
+	switch {
+	case p.accept("pay"):
+		amt, ok := p.parseAmount()
+		if !ok || amt.X {
+			return p.errf("expected life amount, found %s", p.describe())
+		}
+		c.Life = amt.N
+		return p.expect("life")
+	case p.accept("sacrifice"):
+		o, err := p.parseObject()
+		c.Sacrifice = o
+		return err
+	case p.accept("discard"):
+		o, err := p.parseObject()
+		c.Discard = o
+		return err
+	case p.accept("exile"):
+		o, err := p.parseObject()
+		c.Exile = o
+		return err
+	case p.accept("remove"):
+		return p.parseRemoveCounters(c)
+	case p.accept("tap"):
+		o, err := p.parseObject()
+		c.TapUntapped = o
+		return err
+	case p.accept("return"):
+		o, err := p.parseObject()
+		if err != nil {
+			return err
+		}
+		c.Return = o
+		if !p.accept("to", "its", "owner's", "hand") && !p.accept("to", "your", "hand") {
+			return p.errf("unsupported return destination %s", p.describe())
+		}
+		return nil
+	case p.accept("exert"):
+		at := p.pos
+		o, err := p.parseObject()
+		if err == nil && !o.Self {
+			return p.errAt(at, "exerting another permanent")
+		}
+		c.Exert = true
+		return err
+	}
+	return p.errf("unsupported cost %s", p.describe())
+}
+
+// parseRemoveCounters reads "N <kind> counter(s) from ~" after "remove".
+func (p *parser) parseRemoveCounters(c *Cost) error {
+	amt, ok := p.parseAmount()
+	if !ok || amt.X {
+		return p.errf("expected counter amount, found %s", p.describe())
+	}
+	t := p.peek()
+	if t.Kind != TokPT && t.Kind != TokWord {
+		return p.errf("expected counter kind, found %s", p.describe())
+	}
+	p.next()
+	if _, ok := p.acceptAny("counter", "counters"); !ok {
+		return p.errf("expected counters, found %s", p.describe())
+	}
+	if err := p.expect("from"); err != nil {
+		return err
+	}
+	at := p.pos
+	o, err := p.parseObject()
+	if err != nil {
+		return err
+	}
+	if !o.Self {
+		return p.errAt(at, "removing counters from another permanent")
+	}
+	c.RemoveCounters, c.Counter = amt.N, t.Lower
+	return nil
+}
+
+func isManaSymbol(sym string) bool {
+	if _, err := strconv.Atoi(sym); err == nil {
+		return true
+	}
+	switch sym {
+	case "W", "U", "B", "R", "G", "C", "X", "S":
+		return true
+	}
+	return false
+}
+
+// --- keywords --------------------------------------------------------------
+
+// staticKeywords are keyword abilities with no cost or parameter that the
+// engine models directly.
+var staticKeywords = []string{
+	"first strike", "double strike", "flying", "deathtouch", "defender",
+	"haste", "hexproof", "indestructible", "lifelink", "menace", "reach",
+	"trample", "vigilance", "flash", "prowess", "shroud", "fear",
+	"intimidate", "changeling", "skulk", "horsemanship", "split second",
+}
+
+var colorWords = map[string]bool{"white": true, "blue": true, "black": true, "red": true, "green": true, "colorless": true}
+
+// matchKeyword returns the keyword at the cursor and how many tokens it
+// spans, without consuming it.
+func (p *parser) matchKeyword() (Keyword, int) {
+	for _, k := range staticKeywords {
+		words := strings.Fields(k)
+		if p.at(words...) {
+			return Keyword{Name: k}, len(words)
+		}
+	}
+	if p.at("protection", "from") {
+		t := p.peekAt(2)
+		if colorWords[t.Lower] || t.Lower == "everything" || t.Lower == "creatures" {
+			return Keyword{Name: "protection", Param: t.Lower}, 3
+		}
+	}
+	return Keyword{}, 0
+}
+
+func (p *parser) atKeyword() bool {
+	_, n := p.matchKeyword()
+	return n > 0
+}
+
+func (p *parser) parseKeywordLine() *Ability {
+	ab := &Ability{Kind: KindKeywords}
+	p.sentence(func() error {
+		for {
+			start := p.pos
+			k, n := p.matchKeyword()
+			if n == 0 {
+				return p.errf("unknown keyword %s", p.describe())
+			}
+			p.pos += n
+			k.Span = p.spanFrom(start)
+			ab.Keywords = append(ab.Keywords, k)
+			if !p.accept(",") && !p.accept(";") {
+				return nil
+			}
+		}
+	})
+	for !p.atEOF() {
+		// Trailing text after a keyword list is another ability on the same
+		// line, e.g. "Flash. When ~ enters, ...", which the grammar does not
+		// split further.
+		p.sentence(func() error { return p.errf("text after keyword list") })
+	}
+	return ab
+}
+
+// parseKeywordList parses "flying", "flying and haste",
+// "flying, vigilance, and lifelink".
+func (p *parser) parseKeywordList() ([]string, error) {
+	var out []string
+	for {
+		k, n := p.matchKeyword()
+		if n == 0 {
+			return nil, p.errf("unknown keyword %s", p.describe())
+		}
+		p.pos += n
+		name := k.Name
+		if k.Param != "" {
+			name += " from " + k.Param
+		}
+		out = append(out, name)
+		save := p.pos
+		p.accept(",")
+		p.accept("and")
+		if save == p.pos {
+			return out, nil
+		}
+		if _, n := p.matchKeyword(); n == 0 {
+			p.pos = save
+			return out, nil
+		}
+	}
+}
+
+// --- triggers --------------------------------------------------------------
+
+func (p *parser) parseTrigger() (*Trigger, error) {
+	start := p.pos
+	tr := &Trigger{}
+	if p.accept("at", "the", "beginning", "of") {
+		p.acceptAny("your", "each", "the")
+		p.accept("player's")
+		switch {
+		case p.accept("upkeep"):
+			tr.Event = EventUpkeep
+		case p.accept("end", "step"):
+			tr.Event = EventEndStep
+		default:
+			return nil, p.errf("unsupported trigger step %s", p.describe())
+		}
+		tr.Span = p.spanFrom(start)
+		return tr, nil
+	}
+	if _, ok := p.acceptAny("when", "whenever"); !ok {
+		return nil, p.errf("expected trigger, found %s", p.describe())
+	}
+	subj, err := p.parseObject()
+	if err != nil {
+		return nil, err
+	}
+	tr.Subject = subj
+	switch {
+	case p.accept("enters"):
+		p.accept("the", "battlefield")
+		if p.accept("under", "your", "control") {
+			subj.Qualifiers = append(subj.Qualifiers, "you control")
+		}
+		tr.Event = EventEnters
+		if subj.Is("land") && !subj.Self {
+			tr.Event = EventLandPlayed
+		}
+	case p.accept("dies"):
+		tr.Event = EventDies
+	case p.accept("leaves", "the", "battlefield"):
+		tr.Event = EventLeaves
+	case p.accept("attacks", "or", "blocks"):
+		tr.Event = EventAttacksOrBlocks
+	case p.accept("attacks"):
+		tr.Event = EventAttacks
+	case p.accept("blocks"):
+		tr.Event = EventBlocks
+	case p.accept("deals", "combat", "damage", "to"):
+		if _, ok := p.acceptAny("a", "an"); !ok {
+			return nil, p.errf("expected combat damage recipient, found %s", p.describe())
+		}
+		if _, ok := p.acceptAny("player", "opponent"); !ok {
+			return nil, p.errf("unsupported combat damage recipient %s", p.describe())
+		}
+		tr.Event = EventCombatDamage
+	case p.accept("becomes", "the", "target", "of", "a", "spell", "or", "ability"):
+		if p.accept("an", "opponent", "controls") {
+			subj.Qualifiers = append(subj.Qualifiers, "opponent's spell or ability")
+		}
+		tr.Event = EventBecomesTarget
+	case p.accept("cast") || p.accept("casts"):
+		spell, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		if !spell.Is("spell") {
+			return nil, p.errAt(start, "cast trigger without a spell")
+		}
+		tr.Event = EventCast
+	case p.accept("play", "a", "land"):
+		tr.Event = EventLandPlayed
+	default:
+		return nil, p.errf("unsupported trigger event %s", p.describe())
+	}
+	tr.Span = p.spanFrom(start)
+	return tr, nil
+}
+
+// --- conditions ------------------------------------------------------------
+
+func (p *parser) parseCondition() (*Condition, error) {
+	start := p.pos
+	c := &Condition{}
+	switch {
+	case p.accept("you", "control"):
+		if _, ok := p.acceptAny("a", "an"); !ok {
+			return nil, p.errf("unsupported condition %s", p.describe())
+		}
+		t := p.peek()
+		if t.Kind != TokWord {
+			return nil, p.errf("unsupported condition %s", p.describe())
+		}
+		p.next()
+		c.Kind, c.Value = CondControl, t.Text
+	case p.accept("you", "have", "no", "cards", "in", "hand"):
+		c.Kind = CondEmptyHand
+	case p.peek().Kind == TokSelf && p.peekAt(1).Lower == "was" && p.peekAt(2).Lower == "kicked":
+		p.pos += 3
+		c.Kind = CondKicked
+	case p.accept("it", "was", "kicked"):
+		c.Kind = CondKicked
+	default:
+		return nil, p.errf("unsupported condition %s", p.describe())
+	}
+	c.Span = p.spanFrom(start)
+	return c, nil
+}
+
+// --- effects ---------------------------------------------------------------
+
+// parseSentence parses clauses joined by "and", ", then" and "then",
+// optionally prefixed by an "If ..., " condition.
+func (p *parser) parseSentence() ([]*Effect, error) {
+	var cond *Condition
+	if p.accept("if") {
+		c, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		cond = c
+	}
+	var effs []*Effect
+	var subj *Object
+	for {
+		e, err := p.parseClause(subj)
+		if err != nil {
+			return nil, err
+		}
+		if cond != nil {
+			e.Condition = cond
+		}
+		effs = append(effs, e)
+		subj = e.Subject
+		if p.accept(",", "then") || p.accept("then") || p.accept("and") || p.accept(",", "and") {
+			continue
+		}
+		break
+	}
+	// A trailing duration covers the whole sentence: "gets +2/+2 and
+	// gains flying until end of turn".
+	last := effs[len(effs)-1].Duration
+	if last == DurationEndOfTurn || last == DurationEndOfCombat {
+		for _, e := range effs {
+			if (e.Verb == VerbPump || e.Verb == VerbGainKeyword) && e.Duration != last {
+				e.Duration = last
+			}
+		}
+	}
+	return effs, nil
+}
+
+var verbWords = map[string]bool{
+	"draw": true, "draws": true, "discard": true, "discards": true,
+	"gain": true, "gains": true, "lose": true, "loses": true,
+	"deal": true, "deals": true, "destroy": true, "exile": true,
+	"return": true, "counter": true, "create": true, "creates": true,
+	"search": true, "scry": true, "surveil": true, "mill": true, "mills": true,
+	"tap": true, "untap": true, "add": true, "put": true, "puts": true,
+	"get": true, "gets": true, "have": true, "has": true,
+	"sacrifice": true, "sacrifices": true, "take": true, "takes": true,
+	"win": true, "wins": true, "look": true, "can't": true,
+	"prevent": true, "play": true,
+}
+
+// thirdPerson verbs can inherit the subject of the previous clause.
+func thirdPerson(w string) bool {
+	return strings.HasSuffix(w, "s") && verbWords[w] || w == "can't"
+}
+
+func (p *parser) parseClause(inherit *Object) (*Effect, error) {
+	start := p.pos
+	e := &Effect{}
+	if p.accept("you", "may") {
+		e.Optional = true
+	}
+	w := p.peek().Lower
+	if !verbWords[w] || p.peek().Kind != TokWord {
+		if !p.atObject() {
+			return nil, p.errf("unknown verb %s", p.describe())
+		}
+		subj, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		e.Subject = subj
+		if p.accept("may") {
+			e.Optional = true
+		}
+		if !verbWords[p.peek().Lower] {
+			return nil, p.errf("unknown verb %s", p.describe())
+		}
+	} else if inherit != nil && thirdPerson(w) {
+		e.Subject = inherit
+	}
+	if err := p.parseVerbPhrase(e); err != nil {
+		return nil, err
+	}
+	p.parseDuration(e)
+	if p.accept("unless") {
+		cstart := p.pos
+		if !p.accept("its", "controller", "pays") && !p.accept("that", "player", "pays") {
+			return nil, p.errf("unsupported unless clause %s", p.describe())
+		}
+		var cost []string
+		for p.peek().Kind == TokSymbol {
+			cost = append(cost, strings.Trim(p.next().Lower, "{}"))
+		}
+		if len(cost) != 1 {
+			return nil, p.errAt(cstart, "unsupported unless payment")
+		}
+		e.Condition = &Condition{Kind: CondUnlessPays, Value: cost[0], Span: p.spanFrom(cstart)}
+	}
+	e.Span = p.spanFrom(start)
+	return e, nil
+}
+
+func (p *parser) parseDuration(e *Effect) {
+	switch {
+	case p.accept("until", "end", "of", "turn"), p.accept("this", "turn"):
+		e.Duration = DurationEndOfTurn
+	case p.accept("until", "end", "of", "combat"):
+		e.Duration = DurationEndOfCombat
+	}
+}
+
+// staticOrInstant marks continuous effects of a permanent ("Creatures you
+// control get +1/+1") as static; targeted ones resolve once.
+func staticOrInstant(subj *Object) Duration {
+	if subj == nil || subj.Target || subj.Ref != "" {
+		return DurationInstant
+	}
+	return DurationStatic
+}
+
+func (p *parser) parseVerbPhrase(e *Effect) error {
+	w := p.next().Lower
+	switch w {
+	case "draw", "draws":
+		e.Verb = VerbDraw
+		return p.parseCardCount(e)
+	case "discard", "discards":
+		e.Verb = VerbDiscard
+		if err := p.parseCardCount(e); err != nil {
+			return err
+		}
+		p.accept("at", "random")
+		return nil
+	case "mill", "mills":
+		e.Verb = VerbMill
+		return p.parseCardCount(e)
+	case "gain", "gains", "lose", "loses":
+		if p.accept("the", "game") {
+			if w == "gain" || w == "gains" {
+				return p.errAt(p.pos-2, "unknown verb phrase")
+			}
+			e.Verb = VerbLose
+			return nil
+		}
+		if amt, ok := p.parseAmount(); ok {
+			e.Amount = amt
+			e.Verb = VerbGainLife
+			if w == "lose" || w == "loses" {
+				e.Verb = VerbLoseLife
+			}
+			return p.expect("life")
+		}
+		if w == "lose" || w == "loses" {
+			return p.errf("unsupported loss %s", p.describe())
+		}
+		kws, err := p.parseKeywordList()
+		if err != nil {
+			return err
+		}
+		e.Verb, e.Keywords = VerbGainKeyword, kws
+		return nil
+	case "deal", "deals":
+		e.Verb = VerbDamage
+		amt, ok := p.parseAmount()
+		if !ok {
+			return p.errf("expected damage amount, found %s", p.describe())
+		}
+		e.Amount = amt
+		if err := p.expect("damage", "to"); err != nil {
+			return err
+		}
+		o, err := p.parseObject()
+		e.Object = o
+		return err
+	case "destroy", "exile", "tap", "untap", "sacrifice", "sacrifices", "counter":
+		e.Verb = map[string]Verb{
+			"destroy": VerbDestroy, "exile": VerbExile, "tap": VerbTap, "untap": VerbUntap,
+			"sacrifice": VerbSacrifice, "sacrifices": VerbSacrifice, "counter": VerbCounter,
+		}[w]
+		o, err := p.parseObject()
+		e.Object = o
+		return err
+	case "return":
+		o, err := p.parseObject()
+		if err != nil {
+			return err
+		}
+		e.Object = o
+		return p.parseReturnDestination(e)
+	case "create", "creates":
+		e.Verb = VerbCreateToken
+		return p.parseTokenSpec(e)
+	case "search":
+		return p.parseSearch(e)
+	case "scry", "surveil":
+		e.Verb = VerbScry
+		if w == "surveil" {
+			e.Verb = VerbSurveil
+		}
+		amt, ok := p.parseAmount()
+		if !ok {
+			return p.errf("expected %s amount, found %s", w, p.describe())
+		}
+		e.Amount = amt
+		return nil
+	case "add":
+		return p.parseAddMana(e)
+	case "put", "puts":
+		return p.parsePut(e)
+	case "get", "gets":
+		t := p.peek()
+		if t.Kind != TokPT {
+			return p.errf("expected +N/+N, found %s", p.describe())
+		}
+		pow, tou, ok := parsePT(t.Lower)
+		if !ok {
+			return p.errf("unsupported p/t change %s", p.describe())
+		}
+		p.next()
+		e.Verb, e.Power, e.Toughness = VerbPump, pow, tou
+		e.Duration = staticOrInstant(e.Subject)
+		return nil
+	case "have", "has":
+		kws, err := p.parseKeywordList()
+		if err != nil {
+			return err
+		}
+		e.Verb, e.Keywords = VerbGainKeyword, kws
+		e.Duration = DurationStatic
+		return nil
+	case "take", "takes":
+		e.Verb = VerbExtraTurn
+		return p.expect("an", "extra", "turn", "after", "this", "one")
+	case "win", "wins":
+		e.Verb = VerbWin
+		return p.expect("the", "game")
+	case "look":
+		return p.parseLook(e)
+	case "can't":
+		e.Verb = VerbCantAttackBlock
+		switch {
+		case p.accept("attack", "or", "block"):
+		case p.accept("attack"):
+		case p.accept("block"):
+		default:
+			return p.errf("unsupported restriction %s", p.describe())
+		}
+		e.Duration = staticOrInstant(e.Subject)
+		return nil
+	case "prevent":
+		e.Verb = VerbPreventCombatDamage
+		return p.expect("all", "combat", "damage", "that", "would", "be", "dealt", "this", "turn")
+	case "play":
+		e.Verb = VerbAdditionalLand
+		if err := p.expect("an", "additional", "land"); err != nil {
+			return err
+		}
+		if p.accept("on", "each", "of", "your", "turns") {
+			e.Duration = DurationStatic
+			return nil
+		}
+		return p.expect("this", "turn")
+	}
+	return p.errAt(p.pos-1, "unknown verb %q", w)
+}
+
+func (p *parser) parseCardCount(e *Effect) error {
+	amt, ok := p.parseAmount()
+	if !ok {
+		return p.errf("expected number of cards, found %s", p.describe())
+	}
+	e.Amount = amt
+	if _, ok := p.acceptAny("card", "cards"); !ok {
+		return p.errf("expected \"cards\", found %s", p.describe())
+	}
+	return nil
+}
+
+func (p *parser) parseReturnDestination(e *Effect) error {
+	switch {
+	case p.accept("to", "its", "owner's", "hand"), p.accept("to", "their", "owners'", "hands"),
+		p.accept("to", "their", "owner's", "hand"), p.accept("to", "your", "hand"):
+		e.Verb = VerbReturnToHand
+		return nil
+	case p.accept("to", "the", "battlefield"):
+		e.Verb = VerbReturnToBattlefield
+		e.Tapped = p.accept("tapped")
+		if !p.accept("under", "your", "control") {
+			p.accept("under", "its", "owner's", "control")
+		}
+		return nil
+	}
+	return p.errf("unsupported return destination %s", p.describe())
+}
+
+func (p *parser) parsePut(e *Effect) error {
+	save := p.pos
+	if amt, ok := p.parseAmount(); ok {
+		t := p.peek()
+		if t.Kind == TokPT || t.Kind == TokWord {
+			p.next()
+			if _, ok := p.acceptAny("counter", "counters"); ok {
+				e.Verb, e.Amount, e.Counter = VerbPutCounters, amt, t.Lower
+				if err := p.expect("on"); err != nil {
+					return err
+				}
+				o, err := p.parseObject()
+				e.Object = o
+				return err
+			}
+		}
+	}
+	p.pos = save
+	o, err := p.parseObject()
+	if err != nil {
+		return err
+	}
+	e.Object = o
+	if err := p.expect("onto", "the", "battlefield"); err != nil {
+		return err
+	}
+	e.Verb = VerbReturnToBattlefield
+	e.Tapped = p.accept("tapped")
+	p.accept("under", "your", "control")
+	return nil
+}
+
+// parseSearch consumes a tutor sentence whole; pkg/bridge reads the
+// filter and destination from its text at resolution.
+func (p *parser) parseSearch(e *Effect) error {
+	e.Verb = VerbSearch
+	if err := p.expect("your", "library", "for"); err != nil {
+		return err
+	}
+	e.Amount = Amount{N: 1}
+	if p.accept("up", "to") {
+		if amt, ok := p.parseAmount(); ok {
+			e.Amount = amt
+		}
+	} else if amt, ok := p.parseAmount(); ok {
+		e.Amount = amt
+	}
+	for !p.atEOF() && p.peek().Text != "." {
+		if p.peek().Kind == TokQuote {
+			return p.errf("quoted text in search")
+		}
+		p.next()
+	}
+	return nil
+}
+
+// parseLook handles "look at the top N cards of your library" and, when the
+// next sentence says what happens to them, folds that sentence in so the
+// effect describes the whole dig.
+func (p *parser) parseLook(e *Effect) error {
+	e.Verb = VerbLookAtTop
+	if err := p.expect("at", "the", "top"); err != nil {
+		return err
+	}
+	amt, ok := p.parseAmount()
+	if !ok {
+		return p.errf("expected number of cards, found %s", p.describe())
+	}
+	e.Amount = amt
+	if _, ok := p.acceptAny("card", "cards"); !ok {
+		return p.errf("expected \"cards\", found %s", p.describe())
+	}
+	if err := p.expect("of", "your", "library"); err != nil {
+		return err
+	}
+	if !p.at(".") {
+		return nil
+	}
+	// Peek at the following sentence.
+	j := p.pos + 1
+	mentionsThem := false
+	for ; j < len(p.toks) && p.toks[j].Kind != TokEOF && p.toks[j].Text != "."; j++ {
+		if p.toks[j].Lower == "them" {
+			mentionsThem = true
+		}
+	}
+	if mentionsThem && (p.peekAt(1).Lower == "put" || p.peekAt(1).Lower == "you") {
+		p.pos = j
+	}
+	return nil
+}
+
+func (p *parser) parseAddMana(e *Effect) error {
+	e.Verb = VerbAddMana
+	if p.accept("one", "mana", "of", "any", "color") {
+		e.Mana, e.Amount = []string{"any"}, Amount{N: 1}
+		return nil
+	}
+	for p.peek().Kind == TokSymbol {
+		sym := strings.Trim(p.peek().Lower, "{}")
+		if !isManaSymbol(sym) || sym == "X" {
+			return p.errf("unsupported mana symbol %s", p.describe())
+		}
+		p.next()
+		e.Mana = append(e.Mana, sym)
+		save := p.pos
+		p.accept(",")
+		if p.accept("or") && p.peek().Kind == TokSymbol {
+			e.ManaChoice = true
+			continue
+		}
+		if p.peek().Kind == TokSymbol && p.pos > save {
+			e.ManaChoice = true // "{R}, {G}, or {W}"
+			continue
+		}
+		p.pos = save
+	}
+	if len(e.Mana) == 0 {
+		return p.errf("unsupported mana %s", p.describe())
+	}
+	e.Amount = Amount{N: len(e.Mana)}
+	if e.ManaChoice {
+		e.Amount.N = 1
+	}
+	return nil
+}
+
+func (p *parser) parseTokenSpec(e *Effect) error {
+	amt, ok := p.parseAmount()
+	if !ok {
+		return p.errf("expected number of tokens, found %s", p.describe())
+	}
+	e.Amount = amt
+	tok := &TokenSpec{}
+	if t := p.peek(); t.Kind == TokPT {
+		pow, tou, ok := parsePT(t.Lower)
+		if !ok {
+			return p.errf("unsupported token p/t %s", p.describe())
+		}
+		p.next()
+		tok.Power, tok.Toughness, tok.HasPT = pow, tou, true
+	}
+	for colorWords[p.peek().Lower] {
+		tok.Colors = append(tok.Colors, p.next().Lower)
+		if p.at("and") && colorWords[p.peekAt(1).Lower] {
+			p.next()
+		}
+	}
+	for {
+		t := p.peek()
+		if t.Lower == "token" || t.Lower == "tokens" {
+			p.next()
+			break
+		}
+		if t.Kind != TokWord || !(isCapitalised(t.Text) || tokenTypeWords[t.Lower]) {
+			return p.errf("unsupported token description %s", p.describe())
+		}
+		tok.Types = append(tok.Types, t.Text)
+		p.next()
+	}
+	if len(tok.Types) == 0 {
+		return p.errf("token without a type")
+	}
+	if p.accept("with") {
+		kws, err := p.parseKeywordList()
+		if err != nil {
+			return err
+		}
+		tok.Keywords = kws
+	}
+	e.Token = tok
+	return nil
+}
+
+var tokenTypeWords = map[string]bool{"creature": true, "artifact": true, "enchantment": true, "legendary": true, "snow": true, "land": true}
+
+func isCapitalised(s string) bool {
+	return s != "" && s[0] >= 'A' && s[0] <= 'Z'
+}
+
+// parsePT reads "+2/+2", "-1/-1" or "3/3".
+func parsePT(s string) (int, int, bool) {
+	parts := strings.SplitN(strings.ReplaceAll(s, "−", "-"), "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	a, err1 := strconv.Atoi(strings.TrimPrefix(parts[0], "+"))
+	b, err2 := strconv.Atoi(strings.TrimPrefix(parts[1], "+"))
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return a, b, true
+}
+
+// --- amounts and objects ---------------------------------------------------
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+}
+
+func (p *parser) parseAmount() (Amount, bool) {
+	t := p.peek()
+	if t.Kind == TokNumber {
+		p.next()
+		if t.Text == "X" {
+			return Amount{X: true}, true
+		}
+		n, _ := strconv.Atoi(t.Text)
+		return Amount{N: n}, true
+	}
+	if t.Kind == TokWord {
+		if n, ok := numberWords[t.Lower]; ok {
+			p.next()
+			return Amount{N: n}, true
+		}
+	}
+	return Amount{}, false
+}
+
+var typeWords = map[string]string{
+	"creature": "creature", "creatures": "creature",
+	"artifact": "artifact", "artifacts": "artifact",
+	"enchantment": "enchantment", "enchantments": "enchantment",
+	"land": "land", "lands": "land",
+	"planeswalker": "planeswalker", "planeswalkers": "planeswalker",
+	"permanent": "permanent", "permanents": "permanent",
+	"player": "player", "players": "player",
+	"opponent": "opponent", "opponents": "opponent",
+	"spell": "spell", "spells": "spell",
+	"card": "card", "cards": "card",
+	"instant": "instant", "instants": "instant",
+	"sorcery": "sorcery", "sorceries": "sorcery",
+	"token": "token", "tokens": "token",
+	"battle": "battle", "battles": "battle",
+}
+
+var preQualifiers = map[string]bool{
+	"tapped": true, "untapped": true, "attacking": true, "blocking": true,
+	"legendary": true, "basic": true, "another": true, "other": true,
+	"white": true, "blue": true, "black": true, "red": true, "green": true,
+	"colorless": true, "multicolored": true, "monocolored": true,
+}
+
+// abilityNouns name abilities on the stack, as in "counter target activated
+// or triggered ability". They parse as the type "ability" with the words
+// before the noun kept as a qualifier.
+var abilityNouns = [][]string{
+	{"activated", "or", "triggered", "ability"},
+	{"activated", "ability"}, {"triggered", "ability"},
+}
+
+var refPhrases = [][]string{
+	{"that", "creature"}, {"that", "player"}, {"that", "card"}, {"that", "permanent"},
+	{"its", "controller"}, {"its", "owner"}, {"it"}, {"them"}, {"you"},
+}
+
+// atObject reports whether a noun phrase plausibly starts at the cursor.
+func (p *parser) atObject() bool {
+	t := p.peek()
+	if t.Kind == TokSelf || t.Kind == TokNumber {
+		return true
+	}
+	if t.Kind != TokWord {
+		return false
+	}
+	switch t.Lower {
+	case "target", "any", "up", "each", "all", "another", "other", "that", "its", "it", "them", "you":
+		return true
+	}
+	_, isNum := numberWords[t.Lower]
+	_, isType := typeWords[t.Lower]
+	return isNum || isType || preQualifiers[t.Lower] || strings.HasPrefix(t.Lower, "non")
+}
+
+func (p *parser) parseObject() (*Object, error) {
+	start := p.pos
+	o := &Object{Count: 1}
+	if p.peek().Kind == TokSelf {
+		p.next()
+		o.Self = true
+		o.Span = p.spanFrom(start)
+		return o, nil
+	}
+	for _, ref := range refPhrases {
+		if p.accept(ref...) {
+			o.Ref = strings.Join(ref, " ")
+			if ref[0] == "you" {
+				o.Types = []string{"player"}
+			}
+			o.Span = p.spanFrom(start)
+			return o, nil
+		}
+	}
+	if p.accept("any", "target") {
+		o.Any, o.Target = true, true
+		o.Span = p.spanFrom(start)
+		return o, nil
+	}
+	switch {
+	case p.accept("up", "to"):
+		amt, ok := p.parseAmount()
+		if !ok || amt.X {
+			return nil, p.errf("expected count, found %s", p.describe())
+		}
+		o.UpTo, o.Count = true, amt.N
+	case p.accept("each") || p.accept("all"):
+		o.Each, o.Count = true, 0
+	default:
+		if amt, ok := p.parseAmount(); ok {
+			if amt.X {
+				return nil, p.errAt(start, "variable number of objects")
+			}
+			o.Count = amt.N
+		}
+	}
+	for {
+		w := p.peek().Lower
+		if p.peek().Kind != TokWord {
+			break
+		}
+		if w == "target" {
+			p.next()
+			o.Target = true
+			continue
+		}
+		if preQualifiers[w] || (strings.HasPrefix(w, "non") && len(w) > 3 && !strings.Contains(w, "-")) {
+			p.next()
+			o.Qualifiers = append(o.Qualifiers, w)
+			continue
+		}
+		break
+	}
+	for _, noun := range abilityNouns {
+		if p.accept(noun...) {
+			o.Types = []string{"ability"}
+			o.Qualifiers = append(o.Qualifiers, strings.Join(noun[:len(noun)-1], " "))
+			o.Span = p.spanFrom(start)
+			return o, nil
+		}
+	}
+	for {
+		t, ok := typeWords[p.peek().Lower]
+		if !ok || p.peek().Kind != TokWord {
+			break
+		}
+		p.next()
+		o.Types = append(o.Types, t)
+		save := p.pos
+		p.accept(",")
+		p.accept("or")
+		if p.pos == save {
+			continue // "creature card": conjunction
+		}
+		if _, ok := typeWords[p.peek().Lower]; !ok {
+			p.pos = save
+			break
+		}
+	}
+	if len(o.Types) == 0 {
+		return nil, p.errf("expected a noun, found %s", p.describe())
+	}
+	p.parsePostQualifiers(o)
+	o.Span = p.spanFrom(start)
+	return o, nil
+}
+
+var postQualifiers = [][]string{
+	{"you", "control"}, {"you", "don't", "control"}, {"an", "opponent", "controls"},
+	{"your", "opponents", "control"}, {"you", "own"},
+	{"from", "your", "graveyard"}, {"from", "a", "graveyard"}, {"in", "your", "graveyard"},
+	{"from", "your", "hand"}, {"in", "a", "graveyard"},
+}
+
+func (p *parser) parsePostQualifiers(o *Object) {
+	for {
+		matched := false
+		for _, q := range postQualifiers {
+			if p.accept(q...) {
+				o.Qualifiers = append(o.Qualifiers, strings.Join(q, " "))
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		switch {
+		case p.at("with") || p.at("without"):
+			save := p.pos
+			w := p.next().Lower
+			if k, n := p.matchKeyword(); n > 0 {
+				p.pos += n
+				o.Qualifiers = append(o.Qualifiers, w+" "+k.Name)
+				continue
+			}
+			if q, ok := p.parseStatQualifier(); ok && w == "with" {
+				o.Qualifiers = append(o.Qualifiers, "with "+q)
+				continue
+			}
+			p.pos = save
+		}
+		return
+	}
+}
+
+// parseStatQualifier reads "power 2 or less", "mana value 3 or greater".
+func (p *parser) parseStatQualifier() (string, bool) {
+	save := p.pos
+	stat := ""
+	switch {
+	case p.accept("power"):
+		stat = "power"
+	case p.accept("toughness"):
+		stat = "toughness"
+	case p.accept("mana", "value"):
+		stat = "mana value"
+	default:
+		return "", false
+	}
+	t := p.peek()
+	if t.Kind != TokNumber || t.Text == "X" {
+		p.pos = save
+		return "", false
+	}
+	p.next()
+	cmp, ok := "", false
+	if p.accept("or", "less") {
+		cmp, ok = "or less", true
+	} else if p.accept("or", "greater") {
+		cmp, ok = "or greater", true
+	}
+	if !ok {
+		p.pos = save
+		return "", false
+	}
+	return stat + " " + t.Text + " " + cmp, true
+}
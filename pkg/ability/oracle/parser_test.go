@@ -0,0 +1,167 @@
+package oracle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenize_SelfReferencesAndReminderText(t *testing.T) {
+	toks := Tokenize("Flying (This creature can't be blocked except by creatures with flying or reach.)\nWhen Urza enters, draw a card.", "Urza, Lord High Artificer")
+	var kinds []TokenKind
+	var texts []string
+	for _, tok := range toks {
+		kinds = append(kinds, tok.Kind)
+		texts = append(texts, tok.Text)
+	}
+	if strings.Contains(strings.Join(texts, " "), "blocked") {
+		t.Fatalf("reminder text was not skipped: %v", texts)
+	}
+	if texts[2] != "When" || kinds[3] != TokSelf || texts[3] != "Urza" {
+		t.Fatalf("expected short legendary name as self reference, got %v", texts)
+	}
+}
+
+func TestTokenize_PTAndLoyalty(t *testing.T) {
+	toks := Tokenize("−2: Target creature gets -2/-2 until end of turn.", "")
+	if toks[0].Kind != TokWord || toks[0].Lower != "-2" {
+		t.Fatalf("loyalty cost token = %+v", toks[0])
+	}
+	var pt *Token
+	for i := range toks {
+		if toks[i].Kind == TokPT {
+			pt = &toks[i]
+		}
+	}
+	if pt == nil || pt.Text != "-2/-2" {
+		t.Fatalf("expected -2/-2 p/t token, got %+v", pt)
+	}
+}
+
+func parseOne(t *testing.T, text, name string) *Ability {
+	t.Helper()
+	doc := Parse(text, name)
+	if u := doc.Unparsed(); len(u) > 0 {
+		t.Fatalf("unexpected unparsed spans: %+v", u)
+	}
+	abs := doc.Abilities()
+	if len(abs) != 1 {
+		t.Fatalf("got %d abilities, want 1", len(abs))
+	}
+	return abs[0]
+}
+
+func TestParse_SpellWithSelfDamage(t *testing.T) {
+	ab := parseOne(t, "Lightning Bolt deals 3 damage to any target.", "Lightning Bolt")
+	if ab.Kind != KindSpell || len(ab.Effects) != 1 {
+		t.Fatalf("ability = %+v", ab)
+	}
+	e := ab.Effects[0]
+	if e.Verb != VerbDamage || e.Amount.N != 3 || !e.Subject.Self || !e.Object.Any {
+		t.Fatalf("effect = %+v", e)
+	}
+}
+
+func TestParse_ActivatedCostAndTiming(t *testing.T) {
+	ab := parseOne(t, "{2}{U}, {T}, Sacrifice Mind Stone: Draw a card. Activate only as a sorcery.", "Mind Stone")
+	if ab.Kind != KindActivated || ab.Timing != "sorcery" {
+		t.Fatalf("ability = %+v", ab)
+	}
+	c := ab.Cost
+	if strings.Join(c.Mana, "") != "2U" || !c.Tap || c.Sacrifice == nil || !c.Sacrifice.Self {
+		t.Fatalf("cost = %+v", c)
+	}
+	if ab.Effects[0].Verb != VerbDraw || ab.Effects[0].Amount.N != 1 {
+		t.Fatalf("effect = %+v", ab.Effects[0])
+	}
+}
+
+func TestParse_TriggerWithConditionAndConjoinedClauses(t *testing.T) {
+	ab := parseOne(t, "Whenever another creature you control dies, if you control a Swamp, each opponent loses 1 life and you gain 1 life.", "")
+	if ab.Kind != KindTriggered || ab.Trigger.Event != EventDies {
+		t.Fatalf("ability = %+v", ab)
+	}
+	subj := ab.Trigger.Subject
+	if !subj.Is("creature") || strings.Join(subj.Qualifiers, ",") != "another,you control" {
+		t.Fatalf("trigger subject = %+v", subj)
+	}
+	if ab.Condition == nil || ab.Condition.Kind != CondControl || ab.Condition.Value != "Swamp" {
+		t.Fatalf("condition = %+v", ab.Condition)
+	}
+	if len(ab.Effects) != 2 || ab.Effects[0].Verb != VerbLoseLife || !ab.Effects[0].Subject.Each || ab.Effects[1].Verb != VerbGainLife {
+		t.Fatalf("effects = %+v", ab.Effects)
+	}
+}
+
+func TestParse_SharedDurationAndStaticAnthem(t *testing.T) {
+	ab := parseOne(t, "Target creature gets +2/+2 and gains trample until end of turn.", "")
+	if len(ab.Effects) != 2 {
+		t.Fatalf("effects = %+v", ab.Effects)
+	}
+	for _, e := range ab.Effects {
+		if e.Duration != DurationEndOfTurn || !e.Subject.Target {
+			t.Fatalf("effect = %+v", e)
+		}
+	}
+	if ab.Effects[0].Power != 2 || ab.Effects[1].Keywords[0] != "trample" {
+		t.Fatalf("effects = %+v %+v", ab.Effects[0], ab.Effects[1])
+	}
+
+	anthem := parseOne(t, "Creatures you control get +1/+1.", "")
+	if anthem.Kind != KindStatic {
+		t.Fatalf("anthem kind = %v", anthem.Kind)
+	}
+}
+
+func TestParse_ModalBlockGroupsBullets(t *testing.T) {
+	text := "Choose one —\n• Destroy target artifact.\n• Target player discards two cards."
+	doc := Parse(text, "")
+	if len(doc.Blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(doc.Blocks))
+	}
+	ab := doc.Blocks[0].Ability
+	if ab == nil || ab.Modes == nil || ab.Modes.Min != 1 || len(ab.Modes.Options) != 2 {
+		t.Fatalf("ability = %+v", ab)
+	}
+	if doc.Blocks[0].Span.Text(text) != text {
+		t.Fatalf("block span = %q", doc.Blocks[0].Span.Text(text))
+	}
+}
+
+func TestParse_TokenAndMana(t *testing.T) {
+	ab := parseOne(t, "Create two 1/1 white Soldier creature tokens with flying.", "")
+	tok := ab.Effects[0].Token
+	if ab.Effects[0].Amount.N != 2 || tok.Power != 1 || strings.Join(tok.Types, " ") != "Soldier creature" || tok.Keywords[0] != "flying" {
+		t.Fatalf("token = %+v", tok)
+	}
+
+	mana := parseOne(t, "{T}: Add {R}, {G}, or {W}.", "")
+	e := mana.Effects[0]
+	if !e.ManaChoice || strings.Join(e.Mana, "") != "RGW" || e.Amount.N != 1 {
+		t.Fatalf("mana effect = %+v", e)
+	}
+}
+
+func TestParse_ReportsPreciseUnparsedSpans(t *testing.T) {
+	text := "Flying\nDraw a card for each creature you control.\nYou get an emblem with \"Creatures you control get +1/+1.\""
+	doc := Parse(text, "")
+	if len(doc.Blocks) != 3 || doc.Blocks[0].Ability == nil {
+		t.Fatalf("blocks = %+v", doc.Blocks)
+	}
+	u := doc.Unparsed()
+	if len(u) != 2 {
+		t.Fatalf("unparsed = %+v", u)
+	}
+	if u[0].Text != "for each creature you control" || !strings.Contains(u[0].Reason, `"for"`) {
+		t.Fatalf("first span = %+v", u[0])
+	}
+	if u[1].Span.Text(text) != u[1].Text || !strings.HasPrefix(u[1].Text, "an emblem") {
+		t.Fatalf("second span = %+v", u[1])
+	}
+}
+
+func TestParse_AbilityWordIsSkipped(t *testing.T) {
+	ab := parseOne(t, "Landfall — Whenever a land enters the battlefield under your control, you gain 1 life.", "")
+	if ab.Trigger.Event != EventLandPlayed {
+		t.Fatalf("trigger = %+v", ab.Trigger)
+	}
+}
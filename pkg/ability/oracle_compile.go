@@ -0,0 +1,597 @@
+package ability
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mtgsim/mtgsim/internal/logger"
+	"github.com/mtgsim/mtgsim/pkg/ability/oracle"
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// ParseReport is the result of ParseOracle: the abilities recovered from a
+// card's text and every span neither parser could account for.
+type ParseReport struct {
+	Abilities []*Ability
+	Unparsed  []oracle.Unparsed
+}
+
+// Complete reports whether every ability in the text was parsed.
+func (r ParseReport) Complete() bool { return len(r.Unparsed) == 0 }
+
+// ParseOracle parses oracle text one ability block at a time. The oracle
+// grammar's AST is used when it covers the block exactly; blocks it cannot
+// parse, or can only approximate, fall back to the regex patterns. When
+// both parse a block into the same ability, the grammar's ability keeps
+// the regex parser's name and timing, which the engine and AI look
+// abilities up by. When neither covers a block, the regex result (if any) is kept
+// and the grammar's unparsed spans are reported.
+func (ap *AbilityParser) ParseOracle(oracleText string, source interface{}) ParseReport {
+	var rep ParseReport
+	doc := oracle.Parse(oracleText, oracleSourceName(source))
+	for _, b := range doc.Blocks {
+		text := b.Span.Text(oracleText)
+		var compiled *Ability
+		var compileErr error
+		if b.Ability != nil {
+			compiled, compileErr = compileOracle(b.Ability, oracleText)
+		}
+		matched, complete := ap.parseSentences(text, source)
+		if compiled != nil && !isApproximate(compiled) {
+			if complete && len(matched) == 1 && sameAbilityShape(compiled, matched[0]) {
+				compiled.Name = matched[0].Name
+				compiled.TimingRestriction = matched[0].TimingRestriction
+			}
+			rep.Abilities = append(rep.Abilities, ap.finishOracleAbility(compiled, text, source))
+			continue
+		}
+		switch {
+		case complete:
+			rep.Abilities = append(rep.Abilities, matched...)
+		case compiled != nil:
+			rep.Abilities = append(rep.Abilities, ap.finishOracleAbility(compiled, text, source))
+		case compileErr != nil:
+			rep.Abilities = append(rep.Abilities, matched...)
+			rep.Unparsed = append(rep.Unparsed, oracle.Unparsed{Span: b.Span, Text: text, Reason: compileErr.Error()})
+		default:
+			rep.Abilities = append(rep.Abilities, matched...)
+			rep.Unparsed = append(rep.Unparsed, b.Unparsed...)
+		}
+	}
+	return rep
+}
+
+// sameAbilityShape reports whether two parses of one block agree on the
+// ability's kind, costs and effects, so that one only differs from the
+// other in what it's called.
+func sameAbilityShape(a, b *Ability) bool {
+	if a.Type != b.Type || a.Cost.TapCost != b.Cost.TapCost || !maps.Equal(a.Cost.ManaCost, b.Cost.ManaCost) || len(a.Effects) != len(b.Effects) {
+		return false
+	}
+	for i := range a.Effects {
+		if a.Effects[i].Type != b.Effects[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+func (ap *AbilityParser) finishOracleAbility(ability *Ability, text string, source interface{}) *Ability {
+	ability.ID = uuid.New()
+	ability.Source = source
+	ability.OracleText = text
+	ability.ParsedFromText = true
+	if err := ap.parseEnhancedTargets(ability, text); err != nil {
+		logger.LogCard("Failed to parse enhanced targets for %s: %v", ability.Name, err)
+	}
+	return ability
+}
+
+// oracleSourceName returns the card name the grammar treats as a self
+// reference.
+func oracleSourceName(source interface{}) string {
+	switch s := source.(type) {
+	case card.Card:
+		return s.Name
+	case *card.Card:
+		if s != nil {
+			return s.Name
+		}
+	case game.SimpleCard:
+		return s.Name
+	case *game.SimpleCard:
+		if s != nil {
+			return s.Name
+		}
+	case interface{ GetName() string }:
+		return s.GetName()
+	}
+	return ""
+}
+
+// compileOracle lowers a grammar AST ability to the engine's Ability,
+// following the conventions of the regex parsers: spells are Activated,
+// keyword lines are one Static ability with a KeywordAbility effect per
+// keyword, and tap-for-mana abilities are Mana.
+func compileOracle(a *oracle.Ability, src string) (*Ability, error) {
+	ab := &Ability{}
+	switch a.Kind {
+	case oracle.KindKeywords:
+		ab.Name, ab.Type = "Keyword Abilities", Static
+		for _, k := range a.Keywords {
+			ab.Effects = append(ab.Effects, Effect{
+				Type:        KeywordAbility,
+				Duration:    Permanent,
+				Description: k.Span.Text(src),
+			})
+		}
+		return ab, nil
+	case oracle.KindSpell:
+		ab.Name, ab.Type = "Spell", Activated
+	case oracle.KindStatic:
+		ab.Name, ab.Type = "Static Ability", Static
+	case oracle.KindActivated:
+		ab.Name, ab.Type = "Activated Ability", Activated
+		cost, err := compileOracleCost(a.Cost)
+		if err != nil {
+			return nil, err
+		}
+		ab.Cost = cost
+	case oracle.KindTriggered:
+		ab.Name, ab.Type = "Triggered Ability", Triggered
+		ab.TriggerCondition = compileOracleTrigger(a.Trigger)
+	}
+
+	switch a.Timing {
+	case "sorcery":
+		ab.TimingRestriction = SorcerySpeed
+	case "once":
+		ab.TimingRestriction = OncePerTurn
+	case "your turn":
+		ab.TimingRestriction = OnlyOnYourTurn
+	}
+
+	for _, e := range a.Effects {
+		eff, err := compileOracleEffect(e, src)
+		if err != nil {
+			return nil, err
+		}
+		if a.Condition != nil {
+			cond, err := compileOracleCondition(a.Condition)
+			if err != nil {
+				return nil, err
+			}
+			eff.Conditions = append(eff.Conditions, cond)
+		}
+		ab.Effects = append(ab.Effects, eff)
+	}
+	if a.Modes != nil {
+		modal, err := compileOracleModes(a.Modes, src)
+		if err != nil {
+			return nil, err
+		}
+		ab.Effects = append(ab.Effects, modal)
+	}
+	if len(ab.Effects) == 0 {
+		return nil, fmt.Errorf("ability has no effects")
+	}
+	if len(a.Effects) > 0 {
+		ab.IsOptional = a.Effects[0].Optional
+	}
+	if a.Kind == oracle.KindActivated && isManaAbility(ab) {
+		ab.Name, ab.Type = "Mana Ability", Mana
+	}
+	for _, eff := range ab.Effects {
+		if eff.Approximate {
+			ab.Approximate = true
+			ab.ApproximationReason = eff.ApproximationReason
+			break
+		}
+	}
+	return ab, nil
+}
+
+// isManaAbility reports whether every effect adds mana without a target
+// (CR 605.1a).
+func isManaAbility(ab *Ability) bool {
+	for _, eff := range ab.Effects {
+		if eff.Type != AddMana || len(eff.Targets) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func compileOracleCost(c *oracle.Cost) (Cost, error) {
+	var out Cost
+	if c == nil {
+		return out, nil
+	}
+	for _, sym := range c.Mana {
+		if out.ManaCost == nil {
+			out.ManaCost = map[game.ManaType]int{}
+		}
+		if n, err := strconv.Atoi(sym); err == nil {
+			out.ManaCost[game.Any] += n
+			continue
+		}
+		if sym == "X" {
+			return out, fmt.Errorf("X in activation cost")
+		}
+		out.ManaCost[game.ManaType(sym)]++
+	}
+	if c.Untap {
+		return out, fmt.Errorf("untap symbol in activation cost")
+	}
+	out.TapCost = c.Tap
+	out.LifeCost = c.Life
+	if c.Sacrifice != nil {
+		// The engine sacrifices the ability's source for "Sacrifice ~".
+		if c.Sacrifice.Self {
+			out.SacrificeCost = true
+		} else {
+			if len(c.Sacrifice.Types) > 1 || c.Sacrifice.Each {
+				return out, fmt.Errorf("sacrificing %v as a cost", c.Sacrifice.Types)
+			}
+			out.Typed = append(out.Typed, TypedCost{Kind: SacrificePermanents, Count: c.Sacrifice.Count, Filter: c.Sacrifice.Types[0]})
+		}
+	}
+	if c.Discard != nil {
+		if len(c.Discard.Qualifiers) > 0 || !c.Discard.Is("card") || len(c.Discard.Types) > 1 {
+			return out, fmt.Errorf("discarding a specific card as a cost")
+		}
+		out.DiscardCost = c.Discard.Count
+	}
+	if c.Exile != nil {
+		if c.Exile.Self || !hasQualifier(c.Exile, "from your graveyard") || len(c.Exile.Types) > 1 {
+			return out, fmt.Errorf("exiling as a cost")
+		}
+		out.Typed = append(out.Typed, TypedCost{Kind: ExileFromGraveyard, Count: c.Exile.Count, Filter: c.Exile.Types[0]})
+	}
+	if c.RemoveCounters > 0 {
+		out.Typed = append(out.Typed, TypedCost{Kind: RemoveCounters, Count: c.RemoveCounters, CounterType: c.Counter})
+	}
+	if o := c.TapUntapped; o != nil {
+		if !o.Is("creature") || !hasQualifier(o, "untapped") || !hasQualifier(o, "you control") {
+			return out, fmt.Errorf("tapping %v as a cost", o.Types)
+		}
+		out.Typed = append(out.Typed, TypedCost{Kind: TapUntappedCreatures, Count: o.Count})
+	}
+	if o := c.Return; o != nil {
+		if !o.Is("land") || !hasQualifier(o, "you control") {
+			return out, fmt.Errorf("returning %v as a cost", o.Types)
+		}
+		out.Typed = append(out.Typed, TypedCost{Kind: ReturnLands, Count: o.Count})
+	}
+	if c.Exert {
+		out.Typed = append(out.Typed, TypedCost{Kind: ExertSource})
+	}
+	if c.IsLoyalty {
+		out.Typed = append(out.Typed, TypedCost{Kind: LoyaltyCost, Count: c.Loyalty})
+	}
+	return out, nil
+}
+
+func compileOracleTrigger(t *oracle.Trigger) TriggerCondition {
+	if t == nil {
+		return AnyTrigger
+	}
+	switch t.Event {
+	case oracle.EventEnters:
+		if t.Subject != nil && !t.Subject.Self && t.Subject.Is("creature") {
+			return CreatureEnters
+		}
+		return EntersTheBattlefield
+	case oracle.EventLandPlayed:
+		return LandPlayed
+	case oracle.EventLeaves:
+		return LeavesTheBattlefield
+	case oracle.EventDies:
+		return Dies
+	case oracle.EventAttacks, oracle.EventBlocks, oracle.EventAttacksOrBlocks:
+		return AttacksOrBlocks
+	case oracle.EventCombatDamage:
+		return DealsCombatDamage
+	case oracle.EventBecomesTarget:
+		return BecomesTargeted
+	case oracle.EventUpkeep:
+		return BeginningOfUpkeep
+	case oracle.EventEndStep:
+		return EndOfTurn
+	case oracle.EventCast:
+		return SpellCast
+	}
+	return AnyTrigger
+}
+
+func compileOracleCondition(c *oracle.Condition) (Condition, error) {
+	switch c.Kind {
+	case oracle.CondControl:
+		return Condition{Type: ControlPermanentType, Value: c.Value}, nil
+	case oracle.CondEmptyHand:
+		return Condition{Type: NoCardsInHand}, nil
+	case oracle.CondKicked:
+		return Condition{Type: KickerPaid}, nil
+	case oracle.CondUnlessPays:
+		return Condition{Type: UnlessPaysMana, Value: c.Value}, nil
+	}
+	return Condition{}, fmt.Errorf("unsupported condition")
+}
+
+func compileOracleModes(m *oracle.Modes, src string) (Effect, error) {
+	modal := Effect{Type: ChooseMode, Value: m.Max, Duration: Instant, Description: "Choose modal effects"}
+	if m.Max != 0 && m.Min != m.Max {
+		modal.Approximate = true
+		modal.ApproximationReason = fmt.Sprintf("choose between %d and %d modes", m.Min, m.Max)
+	}
+	for _, opt := range m.Options {
+		if len(opt) != 1 {
+			return modal, fmt.Errorf("mode with %d effects", len(opt))
+		}
+		eff, err := compileOracleEffect(opt[0], src)
+		if err != nil {
+			return modal, err
+		}
+		modal.Modes = append(modal.Modes, eff)
+	}
+	return modal, nil
+}
+
+var oracleVerbEffects = map[oracle.Verb]EffectType{
+	oracle.VerbDraw:                DrawCards,
+	oracle.VerbDiscard:             DiscardCards,
+	oracle.VerbGainLife:            GainLife,
+	oracle.VerbLoseLife:            LoseLife,
+	oracle.VerbDamage:              DealDamage,
+	oracle.VerbDestroy:             DestroyPermanent,
+	oracle.VerbExile:               Exile,
+	oracle.VerbReturnToHand:        ReturnToHand,
+	oracle.VerbReturnToBattlefield: ReanimateCreature,
+	oracle.VerbCounter:             CounterSpell,
+	oracle.VerbCreateToken:         CreateToken,
+	oracle.VerbSearch:              SearchLibrary,
+	oracle.VerbScry:                ScryCards,
+	oracle.VerbSurveil:             ScryCards,
+	oracle.VerbMill:                MillCards,
+	oracle.VerbTap:                 TapUntap,
+	oracle.VerbUntap:               UntapPermanent,
+	oracle.VerbAddMana:             AddMana,
+	oracle.VerbPutCounters:         AddCounters,
+	oracle.VerbPump:                PumpCreature,
+	oracle.VerbGainKeyword:         KeywordAbility,
+	oracle.VerbSacrifice:           SacrificePermanent,
+	oracle.VerbExtraTurn:           TakeExtraTurn,
+	oracle.VerbWin:                 WinGame,
+	oracle.VerbLose:                LoseGame,
+	oracle.VerbLookAtTop:           LookAtLibraryTop,
+	oracle.VerbCantAttackBlock:     CantAttackBlock,
+	oracle.VerbPreventCombatDamage: PreventDamage,
+	oracle.VerbAdditionalLand:      AdditionalLand,
+}
+
+// compileOracleEffect lowers one clause. Shapes the engine would misapply,
+// such as a mass effect it resolves against a single target or a player
+// effect it always applies to the controller, are marked Approximate.
+func compileOracleEffect(e *oracle.Effect, src string) (Effect, error) {
+	typ, ok := oracleVerbEffects[e.Verb]
+	if !ok {
+		return Effect{}, fmt.Errorf("no engine effect for %s", e.Verb)
+	}
+	if e.Amount.X {
+		return Effect{}, fmt.Errorf("variable amount X")
+	}
+	eff := Effect{
+		Type:        typ,
+		Value:       e.Amount.N,
+		Duration:    compileOracleDuration(e.Duration),
+		Description: e.Span.Text(src),
+	}
+	if e.Condition != nil {
+		cond, err := compileOracleCondition(e.Condition)
+		if err != nil {
+			return eff, err
+		}
+		if cond.Type == UnlessPaysMana {
+			// The counterspell patterns carry the tax in Value.
+			eff.Value, _ = strconv.Atoi(cond.Value)
+		}
+		eff.Conditions = append(eff.Conditions, cond)
+	}
+	approximate := func(reason string) {
+		eff.Approximate = true
+		eff.ApproximationReason = reason
+	}
+
+	switch e.Verb {
+	case oracle.VerbDraw, oracle.VerbGainLife, oracle.VerbLoseLife, oracle.VerbMill,
+		oracle.VerbScry, oracle.VerbSurveil, oracle.VerbSearch, oracle.VerbLookAtTop,
+		oracle.VerbCreateToken, oracle.VerbExtraTurn, oracle.VerbWin, oracle.VerbAdditionalLand:
+		if !controllerSubject(e.Subject) {
+			approximate(fmt.Sprintf("%s for a player other than the controller", e.Verb))
+		}
+	case oracle.VerbDiscard:
+		switch {
+		case controllerSubject(e.Subject):
+		case e.Subject.Target && e.Subject.Count == 1:
+			eff.Targets = []Target{compileOracleTarget(e.Subject)}
+		default:
+			approximate("discard for several players")
+		}
+	case oracle.VerbLose:
+		switch {
+		case e.Subject != nil && e.Subject.Target:
+			eff.Targets = []Target{compileOracleTarget(e.Subject)}
+		case e.Subject != nil && e.Subject.Each && e.Subject.Is("opponent"):
+		default:
+			approximate("lose the game without an opposing player")
+		}
+	case oracle.VerbSacrifice:
+		if !controllerSubject(e.Subject) || !e.Object.Self {
+			approximate("sacrifice of a chosen permanent")
+		}
+	case oracle.VerbPump, oracle.VerbGainKeyword, oracle.VerbCantAttackBlock:
+		if e.Verb == oracle.VerbPump {
+			eff.HasPTDelta, eff.PTPower, eff.PTToughness = true, e.Power, e.Toughness
+			eff.Value = LegacyEncodePT(e.Power, e.Toughness)
+		}
+		if e.Verb == oracle.VerbGainKeyword {
+			eff.Value = len(e.Keywords)
+		}
+		switch {
+		case e.Subject != nil && e.Subject.Target:
+			eff.Targets = []Target{compileOracleTarget(e.Subject)}
+			if e.Subject.Count > 1 {
+				approximate(fmt.Sprintf("%s on several targets", e.Verb))
+			}
+		case e.Duration == oracle.DurationStatic:
+		default:
+			approximate(fmt.Sprintf("%s on an untargeted object", e.Verb))
+		}
+	case oracle.VerbAddMana:
+		eff.Description = "Add " + strings.Join(braced(e.Mana), "")
+		if e.ManaChoice {
+			eff.Description = "Add " + strings.Join(braced(e.Mana), " or ")
+		}
+		if len(e.Mana) == 1 && e.Mana[0] == "any" {
+			eff.Description = "Add one mana of any color"
+		}
+	case oracle.VerbPreventCombatDamage:
+		eff.Value = 0
+	case oracle.VerbUntap:
+		if e.Object != nil && e.Object.Self {
+			eff.Self = true
+			break
+		}
+		if e.Object.Each && e.Object.Is("land") || e.Object.Each && e.Object.Is("permanent") {
+			if !hasQualifier(e.Object, "you control") {
+				approximate("untap permanents you don't control")
+			}
+			break
+		}
+		fallthrough
+	default:
+		if e.Object == nil {
+			break
+		}
+		switch {
+		case e.Object.Target && e.Object.Count == 1:
+			eff.Targets = []Target{compileOracleTarget(e.Object)}
+		case e.Object.Target:
+			eff.Targets = []Target{compileOracleTarget(e.Object)}
+			approximate(fmt.Sprintf("%s on several targets", e.Verb))
+		default:
+			approximate(fmt.Sprintf("%s on an untargeted object", e.Verb))
+		}
+	}
+	if e.Verb == oracle.VerbLookAtTop && strings.Contains(strings.ToLower(eff.Description), "into your hand") {
+		// Digging resolves through ScryLibraryAdvanced.
+		eff.Type = ScryCards
+	}
+	if e.Token != nil {
+		eff.HasToken = true
+		eff.Token = compileOracleToken(e)
+		if len(e.Token.Keywords) > 0 {
+			approximate("token keywords")
+		}
+	}
+	if e.Verb == oracle.VerbCreateToken && e.Token != nil && !e.Token.HasPT && hasWord(e.Token.Types, "Creature") {
+		return eff, fmt.Errorf("creature token without power and toughness")
+	}
+	return eff, nil
+}
+
+func controllerSubject(o *oracle.Object) bool {
+	return o == nil || o.Ref == "you"
+}
+
+func hasQualifier(o *oracle.Object, q string) bool {
+	for _, x := range o.Qualifiers {
+		if x == q {
+			return true
+		}
+	}
+	return false
+}
+
+func hasWord(words []string, w string) bool {
+	for _, x := range words {
+		if strings.EqualFold(x, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func braced(syms []string) []string {
+	out := make([]string, len(syms))
+	for i, s := range syms {
+		out[i] = "{" + s + "}"
+	}
+	return out
+}
+
+func compileOracleDuration(d oracle.Duration) EffectDuration {
+	switch d {
+	case oracle.DurationEndOfTurn:
+		return UntilEndOfTurn
+	case oracle.DurationEndOfCombat:
+		return UntilEndOfCombat
+	case oracle.DurationStatic:
+		return Permanent
+	}
+	return Instant
+}
+
+func compileOracleTarget(o *oracle.Object) Target {
+	t := Target{Type: PermanentTarget, Required: !o.UpTo, Count: o.Count, Restrictions: o.Qualifiers}
+	graveyard := false
+	for _, q := range o.Qualifiers {
+		if strings.Contains(q, "graveyard") {
+			graveyard = true
+		}
+	}
+	switch {
+	case o.Any:
+		t.Type = AnyTarget
+	case graveyard || o.Is("card"):
+		t.Type = CardInGraveyardTarget
+	case o.Is("spell"):
+		t.Type = SpellTarget
+	case o.Is("ability"):
+		t.Type = AbilityTarget
+	case o.Is("player") || o.Is("opponent"):
+		t.Type = PlayerTarget
+	case len(o.Types) == 1 && o.Is("creature"):
+		t.Type = CreatureTarget
+	}
+	return t
+}
+
+func compileOracleToken(e *oracle.Effect) TokenSpec {
+	tok := e.Token
+	var name []string
+	var types []string
+	for _, t := range tok.Types {
+		switch strings.ToLower(t) {
+		case "creature", "artifact", "enchantment", "legendary", "snow", "land":
+			types = append(types, strings.ToUpper(t[:1])+strings.ToLower(t[1:]))
+		default:
+			name = append(name, t)
+		}
+	}
+	spec := TokenSpec{Count: e.Amount.N, Power: tok.Power, Toughness: tok.Toughness}
+	spec.Name = strings.Join(name, " ")
+	if spec.Name == "" {
+		spec.Name = "Token"
+	}
+	spec.TypeLine = strings.Join(types, " ") + " — " + spec.Name
+	if len(types) == 0 {
+		// Predefined tokens (Treasure, Food, Clue) are artifacts.
+		spec.TypeLine = "Artifact — " + spec.Name
+	}
+	return spec
+}
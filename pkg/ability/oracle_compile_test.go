@@ -0,0 +1,138 @@
+package ability
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/ability/oracle"
+	"github.com/mtgsim/mtgsim/pkg/card"
+)
+
+func TestParseOracle_GrammarCoversWhatRegexDrops(t *testing.T) {
+	parser := NewAbilityParser()
+	rep := parser.ParseOracle("At the beginning of your upkeep, you lose 1 life and draw a card.", nil)
+	if !rep.Complete() || len(rep.Abilities) != 1 {
+		t.Fatalf("report = %+v", rep)
+	}
+	ab := rep.Abilities[0]
+	if ab.Type != Triggered || ab.TriggerCondition != BeginningOfUpkeep {
+		t.Fatalf("ability = %+v", ab)
+	}
+	if len(ab.Effects) != 2 || ab.Effects[0].Type != LoseLife || ab.Effects[1].Type != DrawCards {
+		t.Fatalf("effects = %+v", ab.Effects)
+	}
+}
+
+func TestParseOracle_SelfReferenceUsesSourceName(t *testing.T) {
+	parser := NewAbilityParser()
+	src := card.Card{Name: "Sengir Bat", TypeLine: "Creature — Bat"}
+	rep := parser.ParseOracle("Whenever Sengir Bat deals combat damage to a player, you gain 2 life and scry 1.", src)
+	if !rep.Complete() || len(rep.Abilities) != 1 {
+		t.Fatalf("report = %+v", rep)
+	}
+	ab := rep.Abilities[0]
+	if ab.TriggerCondition != DealsCombatDamage || ab.Source == nil || len(ab.Effects) != 2 {
+		t.Fatalf("ability = %+v", ab)
+	}
+}
+
+func TestParseOracle_CompilesTargetsCostsAndConditions(t *testing.T) {
+	parser := NewAbilityParser()
+	rep := parser.ParseOracle("{2}{U}, {T}: Tap target creature. Activate only as a sorcery.", nil)
+	ab := rep.Abilities[0]
+	if ab.Type != Activated || ab.TimingRestriction != SorcerySpeed || !ab.Cost.TapCost || ab.Cost.ManaCost["A"] != 2 || ab.Cost.ManaCost["U"] != 1 {
+		t.Fatalf("ability = %+v", ab)
+	}
+	if tg := ab.Effects[0].Targets; len(tg) != 1 || tg[0].Type != CreatureTarget {
+		t.Fatalf("targets = %+v", tg)
+	}
+
+	rep = parser.ParseOracle("Counter target noncreature spell unless its controller pays {2}.", nil)
+	eff := rep.Abilities[0].Effects[0]
+	if eff.Type != CounterSpell || eff.Value != 2 || eff.Conditions[0].Type != UnlessPaysMana || eff.Targets[0].Type != SpellTarget {
+		t.Fatalf("effect = %+v", eff)
+	}
+}
+
+func TestParseOracle_CompilesTypedCosts(t *testing.T) {
+	parser := NewAbilityParser()
+	src := card.Card{Name: "Test Engine", TypeLine: "Artifact"}
+	cases := []struct {
+		text string
+		want TypedCost
+	}{
+		{"Sacrifice a creature: Add {C}{C}.", TypedCost{Kind: SacrificePermanents, Count: 1, Filter: "creature"}},
+		{"{1}, Exile two cards from your graveyard: Draw a card.", TypedCost{Kind: ExileFromGraveyard, Count: 2, Filter: "card"}},
+		{"{T}, Remove a charge counter from Test Engine: Draw a card.", TypedCost{Kind: RemoveCounters, Count: 1, CounterType: "charge"}},
+		{"Tap an untapped creature you control: Add {G}.", TypedCost{Kind: TapUntappedCreatures, Count: 1}},
+		{"Return a land you control to its owner's hand: Draw a card.", TypedCost{Kind: ReturnLands, Count: 1}},
+		{"−2: Draw two cards.", TypedCost{Kind: LoyaltyCost, Count: -2}},
+	}
+	for _, tc := range cases {
+		rep := parser.ParseOracle(tc.text, src)
+		if !rep.Complete() || len(rep.Abilities) != 1 {
+			t.Fatalf("%q: report = %+v", tc.text, rep)
+		}
+		typed := rep.Abilities[0].Cost.Typed
+		if len(typed) != 1 || typed[0] != tc.want {
+			t.Errorf("%q: typed costs = %+v, want %+v", tc.text, typed, tc.want)
+		}
+	}
+}
+
+func TestParseOracle_SelfUntapTargetsTheSource(t *testing.T) {
+	parser := NewAbilityParser()
+	src := card.Card{Name: "Basalt Monolith", TypeLine: "Artifact"}
+	rep := parser.ParseOracle("{3}: Untap Basalt Monolith.", src)
+	if !rep.Complete() || len(rep.Abilities) != 1 {
+		t.Fatalf("report = %+v", rep)
+	}
+	eff := rep.Abilities[0].Effects[0]
+	if eff.Type != UntapPermanent || !eff.Self || eff.Approximate {
+		t.Fatalf("effect = %+v", eff)
+	}
+}
+
+func TestParseOracle_ApproximatesEffectsTheEngineMisapplies(t *testing.T) {
+	ab, err := compileOracleFromText("Each opponent draws a card.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isApproximate(ab) || !strings.Contains(ab.ApproximationReason, "other than the controller") {
+		t.Fatalf("ability = %+v", ab)
+	}
+}
+
+func TestParseOracle_ReportsUnparsedSpans(t *testing.T) {
+	impl, reason := testCardImplementation(card.Card{
+		Name:       "Odd Bauble",
+		TypeLine:   "Artifact",
+		OracleText: "{1}, {T}: Frobnicate target creature.",
+	})
+	if impl {
+		t.Fatal("unknown verb should not count as implemented")
+	}
+	if !strings.Contains(reason, `"Frobnicate target creature"`) || !strings.Contains(reason, "unknown verb") {
+		t.Fatalf("reason = %q", reason)
+	}
+}
+
+func compileOracleFromText(text string) (*Ability, error) {
+	abs := oracle.Parse(text, "").Abilities()
+	if len(abs) != 1 {
+		return nil, fmt.Errorf("got %d abilities from %q", len(abs), text)
+	}
+	return compileOracle(abs[0], text)
+}
+
+func TestParseOracle_KeepsRegexNamesWithASource(t *testing.T) {
+	parser := NewAbilityParser()
+	text := "{T}, Pay 1 life, Sacrifice Flooded Strand: Search your library for a Plains or Island card, put it onto the battlefield, then shuffle."
+	for _, src := range []interface{}{nil, card.Card{Name: "Flooded Strand", TypeLine: "Land"}} {
+		rep := parser.ParseOracle(text, src)
+		if len(rep.Abilities) != 1 || rep.Abilities[0].Name != "Fetchland Search" {
+			t.Fatalf("source %v: abilities = %+v", src, rep.Abilities)
+		}
+	}
+}
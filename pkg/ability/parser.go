@@ -110,7 +110,7 @@ func (ap *AbilityParser) initializePatterns() {
 	ap.addPattern(Activated, `\{T\}:\s*Amass\s+(\d+)`, CreateToken, "Tap to amass", ap.activatedParserFactory(CreateToken, "Tap to Amass"))
 
 	// Fetchlands: sacrifice-to-search-and-put-onto-battlefield (must precede broad Sacrifice patterns)
-	ap.addPattern(Activated, `\{T\},\s*Pay\s*1\s*life,\s*Sacrifice\s+[^:]+:\s*Search\s+your\s+library\s+for\s+an?\s+.*\s+card.*put\s+it\s+onto\s+the\s+battlefield.*`, SearchLibrary, "Fetchland search", ap.parseFetchland)
+	ap.addPattern(Activated, `\{T\},\s*(?:Pay\s*(\d+)\s*life,\s*)?Sacrifice\s+[^:]+:\s*Search\s+your\s+library\s+for\s+an?\s+.*\s+card.*put\s+it\s+onto\s+the\s+battlefield.*`, SearchLibrary, "Fetchland search", ap.parseFetchland)
 
 	// Sacrifice activated abilities
 	ap.addPattern(Activated, `Sacrifice\s+[^,:]+:\s*Draw\s+(a|\d+)\s+cards?`, DrawCards, "Sacrifice to draw", ap.activatedParserFactory(DrawCards, "Sacrifice to Draw"))
@@ -681,12 +681,22 @@ func (ap *AbilityParser) addPattern(abilityType AbilityType, pattern string, eff
 	ap.patterns[abilityType] = append(ap.patterns[abilityType], abilityPattern)
 }
 
-// ParseAbilities parses oracle text and returns a list of abilities.
+// ParseAbilities parses oracle text and returns a list of abilities. Cards
+// with a registered CardScript get their scripted abilities instead.
 func (ap *AbilityParser) ParseAbilities(oracleText string, source interface{}) ([]*Ability, error) {
-	var abilities []*Ability
+	if abilities, ok := scriptedAbilities(source); ok {
+		return abilities, nil
+	}
+	return ap.ParseOracle(oracleText, source).Abilities, nil
+}
 
+// parseSentences runs the regex patterns over each sentence of text.
+// complete is false when a sentence matched no pattern or only matched
+// approximately.
+func (ap *AbilityParser) parseSentences(text string, source interface{}) (abilities []*Ability, complete bool) {
+	complete = true
 	// Split oracle text by sentences/lines for better parsing
-	sentences := ap.splitOracleText(oracleText)
+	sentences := ap.splitOracleText(text)
 	cleaned := make([]string, 0, len(sentences))
 	for _, s := range sentences {
 		stripped := ap.stripAbilityWords(s)
@@ -725,14 +735,37 @@ func (ap *AbilityParser) ParseAbilities(oracleText string, source interface{}) (
 					}
 
 					abilities = append(abilities, ability)
+					if isApproximate(ability) {
+						complete = false
+					}
 					matched = true
 					break // Found a match, don't try other patterns for this sentence
 				}
 			}
 		}
+		if !matched {
+			complete = false
+		}
 	}
 
-	return abilities, nil
+	return abilities, complete
+}
+
+func isApproximate(ability *Ability) bool {
+	if ability.Approximate {
+		return true
+	}
+	for _, eff := range ability.Effects {
+		if eff.Approximate {
+			return true
+		}
+		for _, mode := range eff.Modes {
+			if mode.Approximate {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // parseEnhancedTargets parses enhanced targeting information for an ability.
@@ -1987,6 +2020,7 @@ func (ap *AbilityParser) parseDividedDamage(matches []string, fullText string) (
 				Type:     DealDamage,
 				Value:    damage,
 				Duration: Instant,
+				Divided:  true,
 				Targets: []Target{
 					{
 						Type:     AnyTarget, // Can target multiple things
@@ -3175,6 +3209,7 @@ func (ap *AbilityParser) parseXCostDividedDamage(matches []string, fullText stri
 				Type:     DealDamage,
 				Value:    -1, // -1 indicates variable X value
 				Duration: Instant,
+				Divided:  true,
 				Targets: []Target{
 					{
 						Type:     targetType,
@@ -4512,25 +4547,22 @@ func (ap *AbilityParser) parseEachPlayerMills(matches []string, fullText string)
 	}, nil
 }
 
+// parseFetchland handles "{T}, Pay 1 life, Sacrifice ~: Search your library
+// for a Forest or Island card, put it onto the battlefield, then shuffle"
+// and the life-free Evolving Wilds form. The full sentence is kept as the
+// effect description so the search resolves with the card's real filter
+// and tapped-ness.
 func (ap *AbilityParser) parseFetchland(matches []string, fullText string) (*Ability, error) {
-	searchFor := "land"
-	lower := strings.ToLower(fullText)
-	for _, prefix := range []string{"search your library for a ", "search your library for an "} {
-		if idx := strings.Index(lower, prefix); idx >= 0 {
-			rest := fullText[idx+len(prefix):]
-			if end := strings.Index(strings.ToLower(rest), " card"); end >= 0 {
-				searchFor = strings.TrimSpace(rest[:end])
-			}
-			break
-		}
+	life := 0
+	if len(matches) > 1 && matches[1] != "" {
+		life, _ = strconv.Atoi(matches[1])
 	}
-	desc := "Search library for a " + searchFor + " card and put onto battlefield"
 	return &Ability{
 		Name: "Fetchland Search",
 		Type: Activated,
 		Cost: Cost{
 			TapCost:       true,
-			LifeCost:      1,
+			LifeCost:      life,
 			SacrificeCost: true,
 		},
 		Effects: []Effect{
@@ -4538,7 +4570,7 @@ func (ap *AbilityParser) parseFetchland(matches []string, fullText string) (*Abi
 				Type:        SearchLibrary,
 				Value:       1,
 				Duration:    Instant,
-				Description: desc,
+				Description: fullText,
 			},
 		},
 		TimingRestriction: SorcerySpeed,
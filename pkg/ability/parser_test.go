@@ -1,6 +1,7 @@
 package ability
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mtgsim/mtgsim/pkg/game"
@@ -372,6 +373,22 @@ func TestAbilityParser_FetchlandLifePaymentIsCostOnly(t *testing.T) {
 	}
 }
 
+func TestAbilityParser_EvolvingWildsIsLifeFreeFetch(t *testing.T) {
+	parser := NewAbilityParser()
+	text := "{T}, Sacrifice Evolving Wilds: Search your library for a basic land card, put it onto the battlefield tapped, then shuffle."
+	abilities, err := parser.ParseAbilities(text, nil)
+	if err != nil || len(abilities) != 1 {
+		t.Fatalf("expected one ability, got %d (err=%v)", len(abilities), err)
+	}
+	ab := abilities[0]
+	if ab.Name != "Fetchland Search" || ab.Cost.LifeCost != 0 || !ab.Cost.SacrificeCost || !ab.Cost.TapCost {
+		t.Fatalf("expected a life-free tap-sacrifice fetch, got %s %+v", ab.Name, ab.Cost)
+	}
+	if !strings.Contains(ab.Effects[0].Description, "battlefield tapped") {
+		t.Fatalf("expected the search description to keep the tapped clause, got %q", ab.Effects[0].Description)
+	}
+}
+
 func TestAbilityParser_ParseComplexAbilities(t *testing.T) {
 	parser := NewAbilityParser()
 
@@ -107,6 +107,10 @@ func (pm *PriorityManager) CastSpell(player AbilityPlayer, spell *Spell, targets
 		return fmt.Errorf("player %s does not have priority", player.GetName())
 	}
 
+	if pm.stack.SplitSecondActive() {
+		return fmt.Errorf("cannot cast %s: a spell with split second is on the stack", spell.Name)
+	}
+
 	// Check timing restrictions
 	if err := pm.checkSpellTiming(spell); err != nil {
 		return err
@@ -138,6 +142,17 @@ func (pm *PriorityManager) ActivateAbility(player AbilityPlayer, ability *Abilit
 		return pm.resolveManaAbility(ability, player, targets)
 	}
 
+	if pm.stack.SplitSecondActive() {
+		return fmt.Errorf("cannot activate %s: a spell with split second is on the stack", ability.Name)
+	}
+
+	// Pay costs before the ability goes on the stack (CR 602.2)
+	if ee := pm.stack.executionEngine; ee != nil {
+		if err := ee.PayActivationCosts(ability, player); err != nil {
+			return fmt.Errorf("cannot activate %s: %w", ability.Name, err)
+		}
+	}
+
 	// Add ability to stack
 	pm.stack.AddAbility(ability, player, targets)
 
@@ -199,10 +214,24 @@ func (pm *PriorityManager) CanCastSpell(spell *Spell, player AbilityPlayer) bool
 		return false
 	}
 
+	if pm.stack.SplitSecondActive() || !pm.castAllowedByStatics(spell, player) {
+		return false
+	}
+
 	// Check timing restrictions
 	return pm.checkSpellTiming(spell) == nil
 }
 
+// castAllowedByStatics applies the static effects in force, such as Rule
+// of Law's, to player casting spell from their hand.
+func (pm *PriorityManager) castAllowedByStatics(spell *Spell, player AbilityPlayer) bool {
+	g, p := pm.stack.executionEngine.underlyingGame(), underlyingPlayer(player)
+	if g == nil || p == nil {
+		return true
+	}
+	return g.CanCast(p, game.SimpleCard{Name: spell.Name, ManaCost: spell.ManaCost, TypeLine: spell.TypeLine}, game.Hand)
+}
+
 // CanActivateAbility checks if an ability can be activated at the current time
 func (pm *PriorityManager) CanActivateAbility(ability *Ability, player AbilityPlayer) bool {
 	// Check if player has priority
@@ -210,6 +239,10 @@ func (pm *PriorityManager) CanActivateAbility(ability *Ability, player AbilityPl
 		return false
 	}
 
+	if ability.Type != Mana && pm.stack.SplitSecondActive() {
+		return false
+	}
+
 	// Check timing restrictions
 	return pm.checkAbilityTiming(ability) == nil
 }
@@ -338,7 +371,9 @@ func (pm *PriorityManager) resolveManaAbility(ability *Ability, player AbilityPl
 }
 
 func (pm *PriorityManager) getPlayerDecision(player AbilityPlayer) *PriorityDecision {
-	if pm.DecisionFunc != nil {
+	// Under split second the only possible action is passing, so the
+	// decision function isn't consulted.
+	if pm.DecisionFunc != nil && !pm.stack.SplitSecondActive() {
 		return pm.DecisionFunc(player)
 	}
 	return &PriorityDecision{
@@ -0,0 +1,138 @@
+package ability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// ScriptContext is what a Scripted effect sees when it resolves. Game and
+// Controller are nil when the engine is not backed by a *game.Game. Stack
+// and Item are nil when the effect doesn't resolve from a stack.
+type ScriptContext struct {
+	Game       *game.Game
+	Controller *game.Player
+	Player     AbilityPlayer
+	Targets    []any
+	Stack      *Stack
+	Item       *StackItem
+}
+
+// ScriptFunc resolves a Scripted effect.
+type ScriptFunc func(ctx *ScriptContext) error
+
+// CardScript is a hand-written implementation of a card whose oracle text the
+// parser can't express. A registered script takes precedence over parsing.
+type CardScript struct {
+	// Abilities builds the card's abilities for source.
+	Abilities func(source any) []*Ability
+	// OnBattlefield wires rules that aren't abilities on the stack, such as
+	// static effects, when a permanent with this name enters. It runs only
+	// for games passed to InstallCardScripts.
+	OnBattlefield func(g *game.Game, perm *game.Permanent)
+}
+
+var (
+	cardScriptsMu sync.RWMutex
+	cardScripts   = map[string]CardScript{}
+)
+
+func cardScriptKey(name string) string { return strings.ToLower(strings.TrimSpace(name)) }
+
+// RegisterCardScript registers script for the named card, replacing any
+// earlier registration.
+func RegisterCardScript(name string, script CardScript) {
+	cardScriptsMu.Lock()
+	defer cardScriptsMu.Unlock()
+	cardScripts[cardScriptKey(name)] = script
+}
+
+// LookupCardScript returns the script registered for the named card.
+func LookupCardScript(name string) (CardScript, bool) {
+	cardScriptsMu.RLock()
+	defer cardScriptsMu.RUnlock()
+	s, ok := cardScripts[cardScriptKey(name)]
+	return s, ok
+}
+
+// HasCardScript reports whether the named card is implemented by a script.
+func HasCardScript(name string) bool {
+	_, ok := LookupCardScript(name)
+	return ok
+}
+
+// ScriptedCardNames returns the registry keys in sorted order.
+func ScriptedCardNames() []string {
+	cardScriptsMu.RLock()
+	defer cardScriptsMu.RUnlock()
+	names := make([]string, 0, len(cardScripts))
+	for name := range cardScripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scriptedAbilities returns the scripted abilities for source, if its card
+// has a script.
+func scriptedAbilities(source any) ([]*Ability, bool) {
+	name := oracleSourceName(source)
+	if name == "" {
+		return nil, false
+	}
+	script, ok := LookupCardScript(name)
+	if !ok || script.Abilities == nil {
+		return nil, false
+	}
+	abilities := script.Abilities(source)
+	for _, ab := range abilities {
+		if ab.ID == uuid.Nil {
+			ab.ID = uuid.New()
+		}
+		ab.Source = source
+	}
+	return abilities, true
+}
+
+// scriptEffect wraps fn as a Scripted effect.
+func scriptEffect(description string, fn ScriptFunc) Effect {
+	return Effect{Type: Scripted, Description: description, Script: fn}
+}
+
+// scriptContext resolves the concrete game objects behind the engine's
+// adapters for a Scripted effect.
+func (ee *ExecutionEngine) scriptContext(controller AbilityPlayer, targets []any) (*ScriptContext, error) {
+	ctx := &ScriptContext{Player: controller, Targets: targets}
+	if ee.resolving != nil {
+		ctx.Stack = ee.currentStack()
+		ctx.Item = ee.resolving
+	}
+	if gs, ok := ee.gameState.(interface{ UnderlyingGame() *game.Game }); ok {
+		ctx.Game = gs.UnderlyingGame()
+	}
+	if p, ok := any(controller).(interface{ Underlying() *game.Player }); ok {
+		ctx.Controller = p.Underlying()
+	}
+	if ctx.Game == nil || ctx.Controller == nil {
+		return nil, fmt.Errorf("scripted effect needs a game-backed state")
+	}
+	return ctx, nil
+}
+
+// InstallCardScripts runs OnBattlefield hooks for scripted permanents that
+// enter the battlefield in g.
+func InstallCardScripts(g *game.Game) {
+	g.AddListener(func(g *game.Game, e game.Event) {
+		if e.Type != game.EventEntersBattlefield || e.ZoneChange == nil || e.ZoneChange.Permanent == nil {
+			return
+		}
+		perm := e.ZoneChange.Permanent
+		if script, ok := LookupCardScript(perm.GetName()); ok && script.OnBattlefield != nil {
+			script.OnBattlefield(g, perm)
+		}
+	})
+}
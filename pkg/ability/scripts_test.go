@@ -0,0 +1,189 @@
+package ability
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// scriptGameState backs the mock game state with a real *game.Game so
+// Scripted effects can reach it.
+type scriptGameState struct {
+	mockGameState
+	g *game.Game
+}
+
+func (s *scriptGameState) UnderlyingGame() *game.Game { return s.g }
+
+type scriptPlayer struct {
+	mockPlayer
+	p *game.Player
+}
+
+func (s *scriptPlayer) Underlying() *game.Player { return s.p }
+
+func newScriptHarness(t *testing.T) (*ExecutionEngine, *scriptPlayer, *game.Game) {
+	t.Helper()
+	p1 := game.NewPlayer("P1", 20)
+	p2 := game.NewPlayer("P2", 20)
+	g := game.NewGame(p1, p2)
+	sp := &scriptPlayer{mockPlayer: mockPlayer{name: "P1", life: 20}, p: p1}
+	gs := &scriptGameState{mockGameState: mockGameState{players: []AbilityPlayer{sp}, currentPlayer: sp}, g: g}
+	return NewExecutionEngine(gs), sp, g
+}
+
+func TestParseAbilities_UsesCardScript(t *testing.T) {
+	parser := NewAbilityParser()
+	abilities, err := parser.ParseAbilities("", card.Card{Name: "Thassa's Oracle"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(abilities) != 1 || abilities[0].TriggerCondition != EntersTheBattlefield {
+		t.Fatalf("expected the scripted ETB trigger, got %+v", abilities)
+	}
+	if len(abilities[0].Effects) != 1 || abilities[0].Effects[0].Type != Scripted {
+		t.Fatalf("expected a single Scripted effect, got %+v", abilities[0].Effects)
+	}
+}
+
+func TestCardScripts_CountAsImplemented(t *testing.T) {
+	necro := card.Card{Name: "Necropotence", TypeLine: "Enchantment", OracleText: "Skip your draw step.\nWhenever you discard a card, exile that card from your graveyard.\nPay 1 life: Exile the top card of your library face down. Put that card into your hand at the beginning of your next end step."}
+	if impl, reason := testCardImplementation(necro); !impl {
+		t.Fatalf("expected scripted card to be implemented, got %q", reason)
+	}
+	tracker := &ImplementationTracker{entries: map[string]ImplementationStatus{}}
+	if !tracker.IsScripted(necro) {
+		t.Fatalf("expected Necropotence to be reported as scripted")
+	}
+	if tracker.IsScripted(card.Card{Name: "Lightning Bolt"}) {
+		t.Fatalf("Lightning Bolt is parsed, not scripted")
+	}
+}
+
+func TestScriptedEffect_RequiresGameBackedState(t *testing.T) {
+	engine := NewExecutionEngine(&mockGameState{})
+	ab := &Ability{Name: "Spell", Type: Activated, Effects: []Effect{scriptEffect("noop", func(*ScriptContext) error { return nil })}}
+	if err := engine.ExecuteAbility(ab, &mockPlayer{name: "P1"}, nil); err == nil {
+		t.Fatalf("expected an error without a game-backed state")
+	}
+}
+
+func TestThassasOracle_WinsWithEnoughDevotion(t *testing.T) {
+	engine, sp, g := newScriptHarness(t)
+	p := sp.p
+	oracle := game.SimpleCard{Name: "Thassa's Oracle", TypeLine: "Creature — Merfolk Wizard", ManaCost: "{U}{U}"}
+	p.Battlefield = append(p.Battlefield, game.NewPermanent(oracle, p, p))
+	p.Library = []game.SimpleCard{{Name: "Island"}, {Name: "Island"}}
+
+	abilities, _ := scriptedAbilities(card.Card{Name: "Thassa's Oracle"})
+	if err := engine.ExecuteAbility(abilities[0], sp, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !g.GetPlayersRaw()[1].HasLost() {
+		t.Fatalf("expected the opponent to lose to Thassa's Oracle")
+	}
+}
+
+func TestThassasOracle_KeepsOneOnTopAndBottomsTheRest(t *testing.T) {
+	engine, sp, g := newScriptHarness(t)
+	p := sp.p
+	oracle := game.SimpleCard{Name: "Thassa's Oracle", TypeLine: "Creature — Merfolk Wizard", ManaCost: "{U}{U}"}
+	p.Battlefield = append(p.Battlefield, game.NewPermanent(oracle, p, p))
+	ritual := game.SimpleCard{Name: "Dark Ritual", TypeLine: "Instant", ManaCost: "{B}"}
+	island := game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"}
+	p.Library = []game.SimpleCard{island, ritual, {Name: "Swamp"}, {Name: "Forest"}, {Name: "Plains"}}
+
+	abilities, _ := scriptedAbilities(card.Card{Name: "Thassa's Oracle"})
+	if err := engine.ExecuteAbility(abilities[0], sp, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.GetPlayersRaw()[1].HasLost() {
+		t.Fatalf("two devotion can't win with five cards left")
+	}
+	if len(p.Library) != 5 || p.Library[1].Name != "Swamp" || p.Library[4].Name == p.Library[0].Name {
+		t.Fatalf("expected one of the two cards on top and the other on the bottom, got %v", p.Library)
+	}
+}
+
+func TestAdNauseam_LosesLifeThroughTheGame(t *testing.T) {
+	engine, sp, g := newScriptHarness(t)
+	p := sp.p
+	p.Library = []game.SimpleCard{{Name: "Dark Ritual", ManaCost: "{B}"}, {Name: "Lotus Petal"}, {Name: "Swamp"}}
+	life, lost := p.GetLifeTotal(), 0
+	g.AddListener(func(_ *game.Game, e game.Event) {
+		if e.Type == game.EventLifeLost && e.LifeLoss.Player == p {
+			lost += e.LifeLoss.Amount
+		}
+	})
+
+	abilities, _ := scriptedAbilities(card.Card{Name: "Ad Nauseam"})
+	if err := engine.ExecuteAbility(abilities[0], sp, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Hand) != 3 || lost != 1 || p.GetLifeTotal() != life-1 {
+		t.Fatalf("expected the whole library drawn for 1 life lost, hand %v lost %d life %d", p.Hand, lost, p.GetLifeTotal())
+	}
+}
+
+func TestDemonicConsultation_NamesBuriedCard(t *testing.T) {
+	engine, sp, _ := newScriptHarness(t)
+	p := sp.p
+	for i := 0; i < 8; i++ {
+		p.Library = append(p.Library, game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"})
+	}
+	p.Library = append(p.Library, game.SimpleCard{Name: "Ad Nauseam", TypeLine: "Instant", ManaCost: "{3}{B}{B}"})
+	p.Library = append(p.Library, game.SimpleCard{Name: "Swamp", TypeLine: "Basic Land — Swamp"})
+
+	abilities, _ := scriptedAbilities(card.Card{Name: "Demonic Consultation"})
+	if err := engine.ExecuteAbility(abilities[0], sp, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Hand) != 1 || p.Hand[0].Name != "Ad Nauseam" {
+		t.Fatalf("expected Ad Nauseam in hand, got %v", p.Hand)
+	}
+	if len(p.Exile) != 8 || len(p.Library) != 1 {
+		t.Fatalf("expected 8 exiled and 1 left in library, got %d and %d", len(p.Exile), len(p.Library))
+	}
+}
+
+func TestTaintedPact_StopsAtADuplicateName(t *testing.T) {
+	engine, sp, _ := newScriptHarness(t)
+	p := sp.p
+	p.Hand = []game.SimpleCard{{Name: "Thassa's Oracle", TypeLine: "Creature — Merfolk Wizard", ManaCost: "{U}{U}"}}
+	p.AddManaToPool(game.Blue, 2)
+	p.Library = []game.SimpleCard{
+		{Name: "Ad Nauseam", TypeLine: "Instant", ManaCost: "{3}{B}{B}"},
+		{Name: "Island", TypeLine: "Basic Land — Island"},
+		{Name: "Island", TypeLine: "Basic Land — Island"},
+		{Name: "Swamp", TypeLine: "Basic Land — Swamp"},
+	}
+
+	abilities, _ := scriptedAbilities(card.Card{Name: "Tainted Pact"})
+	if err := engine.ExecuteAbility(abilities[0], sp, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Exile) != 3 || len(p.Library) != 1 || len(p.Hand) != 1 {
+		t.Fatalf("with the Oracle ready Pact should dig to the second Island, exile %d library %d hand %d", len(p.Exile), len(p.Library), len(p.Hand))
+	}
+}
+
+func TestNecropotence_DeliversAtEndStep(t *testing.T) {
+	engine, sp, g := newScriptHarness(t)
+	p := sp.p
+	p.Library = []game.SimpleCard{{Name: "Dark Ritual"}, {Name: "Swamp"}}
+
+	abilities, _ := scriptedAbilities(card.Card{Name: "Necropotence"})
+	if err := engine.ExecuteAbility(abilities[0], sp, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Exile) != 1 || len(p.Hand) != 0 {
+		t.Fatalf("expected the top card exiled face down, got exile=%v hand=%v", p.Exile, p.Hand)
+	}
+	for g.GetCurrentPhase() != game.PhaseEnd {
+		g.AdvancePhase()
+	}
+	if len(p.Hand) != 1 || p.Hand[0].Name != "Dark Ritual" || len(p.Exile) != 0 {
+		t.Fatalf("expected Dark Ritual in hand at end step, got exile=%v hand=%v", p.Exile, p.Hand)
+	}
+}
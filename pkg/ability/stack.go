@@ -3,9 +3,12 @@ package ability
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/mtgsim/mtgsim/internal/logger"
+	"github.com/mtgsim/mtgsim/pkg/game"
 )
 
 // StackItem represents an item on the stack (spell or ability)
@@ -20,6 +23,53 @@ type StackItem struct {
 	Countered   bool
 	Fizzled     bool
 	Description string
+	// TriggeringPlayer is "that player" for a triggered ability, e.g. the
+	// opponent whose spell triggered Rhystic Study. Nil for everything else.
+	TriggeringPlayer AbilityPlayer
+	// targetGroups binds Targets to the requirements they were chosen for;
+	// see TargetGroups.
+	targetGroups []TargetGroup
+	// IsCopy marks a copy of a spell or ability (storm, Twinflame,
+	// Reverberate). A copy isn't cast, so it fires no cast triggers and
+	// doesn't count toward storm (CR 707.10).
+	IsCopy bool
+}
+
+// SpellCounter is implemented by game states that count the spells cast
+// each turn. Storm copies a spell once for each spell cast before it.
+type SpellCounter interface {
+	RecordSpellCast(caster AbilityPlayer)
+	SpellsCastThisTurn() int
+}
+
+// CantBeCountered reports whether the item is a spell whose text says it
+// can't be countered (Abrupt Decay, Carnage Tyrant).
+func (item *StackItem) CantBeCountered() bool {
+	if item == nil || item.Type != StackItemSpell || item.Spell == nil {
+		return false
+	}
+	text := strings.ToLower(item.Spell.OracleText)
+	return strings.Contains(text, "this spell can't be countered") ||
+		(item.Spell.Name != "" && strings.Contains(text, strings.ToLower(item.Spell.Name)+" can't be countered"))
+}
+
+// HasSplitSecond reports whether the item is a spell with split second.
+func (item *StackItem) HasSplitSecond() bool {
+	if item == nil || item.Type != StackItemSpell || item.Spell == nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(item.Spell.OracleText), "split second")
+}
+
+// stormKeyword matches the storm keyword line of a spell's oracle text.
+var stormKeyword = regexp.MustCompile(`(?m)^storm\b`)
+
+// HasStorm reports whether the item is a spell with storm.
+func (item *StackItem) HasStorm() bool {
+	if item == nil || item.Type != StackItemSpell || item.Spell == nil {
+		return false
+	}
+	return stormKeyword.MatchString(strings.ToLower(item.Spell.OracleText))
 }
 
 // StackItemType represents the type of item on the stack
@@ -52,17 +102,22 @@ type Stack struct {
 	executionEngine *ExecutionEngine
 	lastCastItem    *StackItem
 	OnResolve       func(item *StackItem)
-	OnAfterResolve  func() // called after each successful resolution (e.g., for SBAs)
+	OnCast          func(item *StackItem) // called after a spell is put on the stack (e.g., for cast triggers)
+	OnAfterResolve  func()                // called after each successful resolution (e.g., for SBAs)
 }
 
 // NewStack creates a new stack instance
 func NewStack(gameState GameState, executionEngine *ExecutionEngine) *Stack {
-	return &Stack{
+	s := &Stack{
 		items:           make([]*StackItem, 0),
 		passedPriority:  make(map[string]bool),
 		gameState:       gameState,
 		executionEngine: executionEngine,
 	}
+	if executionEngine != nil {
+		executionEngine.stack = s
+	}
+	return s
 }
 
 // Push adds a spell or ability to the top of the stack
@@ -129,8 +184,112 @@ func (s *Stack) AddSpell(spell *Spell, controller AbilityPlayer, targets []inter
 		Targets:     targets,
 		Description: fmt.Sprintf("%s (spell)", spell.Name),
 	}
+	spellsBefore := 0
+	if counter, ok := s.gameState.(SpellCounter); ok {
+		spellsBefore = counter.SpellsCastThisTurn()
+		counter.RecordSpellCast(controller)
+	}
 	s.lastCastItem = item
 	s.Push(item)
+	if item.HasStorm() {
+		s.addStormTrigger(item, spellsBefore)
+	}
+	if s.OnCast != nil {
+		s.OnCast(item)
+	}
+}
+
+// addStormTrigger puts item's storm trigger on the stack above it: "copy it
+// for each spell cast before it this turn" (CR 702.40a). The trigger copies
+// the spell even if the spell has been countered by the time it resolves.
+func (s *Stack) addStormTrigger(item *StackItem, copies int) {
+	if copies <= 0 {
+		return
+	}
+	storm := &Ability{
+		ID:               uuid.New(),
+		Name:             item.Spell.Name + " storm",
+		Type:             Triggered,
+		Source:           item.Source,
+		TriggerCondition: SpellCast,
+		Effects: []Effect{{
+			Type:        CopySpell,
+			Value:       copies,
+			Description: fmt.Sprintf("Copy %s for each spell cast before it this turn", item.Spell.Name),
+		}},
+	}
+	s.AddTriggeredAbility(storm, item.Controller, nil, []interface{}{item})
+}
+
+// CopyItem puts a copy of a spell or ability on the stack under
+// controller's control. Nil targets keeps the original's targets; otherwise
+// targets are the copy's new targets. No mana is paid for a copy and it
+// isn't cast, so OnCast and storm don't see it.
+func (s *Stack) CopyItem(item *StackItem, controller AbilityPlayer, targets []interface{}) *StackItem {
+	if targets == nil {
+		targets = item.Targets
+	}
+	cp := &StackItem{
+		ID:               uuid.New(),
+		Type:             item.Type,
+		Controller:       controller,
+		Source:           item.Source,
+		Targets:          append([]interface{}(nil), targets...),
+		TriggeringPlayer: item.TriggeringPlayer,
+		IsCopy:           true,
+	}
+	switch {
+	case item.Spell != nil:
+		spell := *item.Spell
+		spell.ID = uuid.New()
+		spell.Effects = append([]Effect(nil), item.Spell.Effects...)
+		cp.Spell = &spell
+		cp.Description = fmt.Sprintf("%s (copy)", spell.Name)
+	case item.Ability != nil:
+		ability := *item.Ability
+		ability.ID = uuid.New()
+		ability.Effects = append([]Effect(nil), item.Ability.Effects...)
+		cp.Ability = &ability
+		cp.Description = fmt.Sprintf("%s (ability copy)", ability.Name)
+	}
+	s.Push(cp)
+	return cp
+}
+
+// AddEntersTriggers puts perm's "when ... enters" triggered abilities on
+// the stack under controller's control, for permanents that enter while
+// the stack resolves (Twinflame's token copies). Each required target goes
+// to the first candidate, as the runner does for cast permanents. It
+// returns the number of abilities added.
+func (s *Stack) AddEntersTriggers(perm *game.Permanent, controller AbilityPlayer) int {
+	if perm == nil || s.executionEngine == nil {
+		return 0
+	}
+	src := perm.GetSource()
+	abilities, err := s.executionEngine.ParseAndRegisterAbilities(src.OracleText, src)
+	if err != nil {
+		return 0
+	}
+	added := 0
+	for _, ab := range abilities {
+		if ab.Type != Triggered || ab.TriggerCondition != EntersTheBattlefield {
+			continue
+		}
+		var targets []interface{}
+		for _, eff := range ab.Effects {
+			for _, tgt := range eff.Targets {
+				if !tgt.Required {
+					continue
+				}
+				if potentials := s.executionEngine.GetPotentialTargets(tgt.Type, controller); len(potentials) > 0 {
+					targets = append(targets, potentials[0])
+				}
+			}
+		}
+		s.AddTriggeredAbility(ab, controller, nil, targets)
+		added++
+	}
+	return added
 }
 
 // LastCastItem returns the most recently added StackItem via AddSpell/AddAbility.
@@ -151,6 +310,23 @@ func (s *Stack) AddAbility(ability *Ability, controller AbilityPlayer, targets [
 	s.Push(item)
 }
 
+// AddTriggeredAbility adds a triggered ability to the stack and records the
+// player whose action triggered it. Unlike AddAbility it leaves
+// LastCastItem alone, so triggers put on the stack by OnCast don't hide the
+// spell that caused them.
+func (s *Stack) AddTriggeredAbility(ability *Ability, controller, triggeringPlayer AbilityPlayer, targets []interface{}) {
+	s.Push(&StackItem{
+		ID:               uuid.New(),
+		Type:             StackItemAbility,
+		Ability:          ability,
+		Controller:       controller,
+		Source:           ability.Source,
+		Targets:          targets,
+		Description:      fmt.Sprintf("%s (ability)", ability.Name),
+		TriggeringPlayer: triggeringPlayer,
+	})
+}
+
 // PassPriority handles a player passing priority
 func (s *Stack) PassPriority(player AbilityPlayer) bool {
 	playerName := player.GetName()
@@ -236,17 +412,36 @@ func (s *Stack) CounterSpell(targetSpell *StackItem, counteringPlayer AbilityPla
 	if targetSpell.Type != StackItemSpell {
 		return fmt.Errorf("can only counter spells")
 	}
+	return s.Counter(targetSpell, counteringPlayer)
+}
 
-	// Find the spell on the stack
+// Counter counters a spell or an activated or triggered ability on the
+// stack (Stifle). Spells that can't be countered stay on the stack.
+func (s *Stack) Counter(target *StackItem, counteringPlayer AbilityPlayer) error {
 	for _, item := range s.items {
-		if item.ID == targetSpell.ID {
-			item.Countered = true
-			logger.LogCard("%s counters %s", counteringPlayer.GetName(), item.Description)
-			return nil
+		if item.ID != target.ID {
+			continue
 		}
+		if item.CantBeCountered() {
+			return fmt.Errorf("%s can't be countered", item.Description)
+		}
+		item.Countered = true
+		logger.LogCard("%s counters %s", counteringPlayer.GetName(), item.Description)
+		return nil
 	}
+	return fmt.Errorf("%s not found on stack", target.Description)
+}
 
-	return fmt.Errorf("spell not found on stack")
+// SplitSecondActive reports whether a spell with split second is on the
+// stack. While it is, players can't cast spells or activate abilities that
+// aren't mana abilities (CR 702.61a).
+func (s *Stack) SplitSecondActive() bool {
+	for _, item := range s.items {
+		if item.HasSplitSecond() && !item.Countered {
+			return true
+		}
+	}
+	return false
 }
 
 // Helper methods
@@ -286,32 +481,30 @@ func (s *Stack) passPriorityToNextPlayer() {
 	}
 }
 
+// checkFizzle reports whether every target item was cast or put on the
+// stack with has become illegal, in which case it doesn't resolve (CR
+// 608.2b). Items that didn't target anything never fizzle.
 func (s *Stack) checkFizzle(item *StackItem) bool {
 	if item == nil || len(item.Targets) == 0 || s.executionEngine == nil {
 		return false
 	}
-
-	effects := itemEffects(item)
-	targetIndex := 0
-	requiredTargets := 0
-	legalTargets := 0
-
-	for _, effect := range effects {
-		for _, targetReq := range effect.Targets {
-			if !targetReq.Required {
-				continue
-			}
-			for i := 0; i < targetReq.Count; i++ {
-				requiredTargets++
-				if targetIndex < len(item.Targets) && s.executionEngine.isTargetStillLegal(item.Targets[targetIndex], targetReq, item.Controller) {
-					legalTargets++
-				}
-				targetIndex++
-			}
+	legal, checked := s.legalTargets(item)
+	if checked == 0 {
+		return false
+	}
+	for _, objs := range legal {
+		if len(objs) > 0 {
+			return false
 		}
 	}
+	return true
+}
 
-	return requiredTargets > 0 && legalTargets == 0
+// isStackItemOfType reports whether target is a stack item of type t: spell
+// targets must be spells and ability targets must be abilities.
+func isStackItemOfType(target any, t StackItemType) bool {
+	item, ok := target.(*StackItem)
+	return ok && item.Type == t
 }
 
 func itemEffects(item *StackItem) []Effect {
@@ -332,13 +525,11 @@ func (s *Stack) resolveSpell(item *StackItem) error {
 		return fmt.Errorf("spell item has no spell")
 	}
 
-	// Apply spell effects
-	targetCursor := 0
-	for _, effect := range item.Spell.Effects {
-		err := s.executionEngine.ApplyEffect(effect, item.Controller, targetsForEffect(effect, item.Targets, &targetCursor))
-		if err != nil {
-			return err
-		}
+	s.executionEngine.resolving = item
+	defer func() { s.executionEngine.resolving = nil }()
+
+	if err := s.applyEffects(item, item.Spell.Effects); err != nil {
+		return err
 	}
 
 	logger.LogCard("%s resolved", item.Spell.Name)
@@ -350,13 +541,11 @@ func (s *Stack) resolveAbility(item *StackItem) error {
 		return fmt.Errorf("ability item has no ability")
 	}
 
-	// Apply ability effects
-	targetCursor := 0
-	for _, effect := range item.Ability.Effects {
-		err := s.executionEngine.ApplyEffect(effect, item.Controller, targetsForEffect(effect, item.Targets, &targetCursor))
-		if err != nil {
-			return err
-		}
+	s.executionEngine.resolving = item
+	defer func() { s.executionEngine.resolving = nil }()
+
+	if err := s.applyEffects(item, item.Ability.Effects); err != nil {
+		return err
 	}
 
 	logger.LogCard("%s resolved", item.Ability.Name)
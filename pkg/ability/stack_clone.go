@@ -0,0 +1,99 @@
+package ability
+
+import (
+	"maps"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Clone copies the stack into a clone of its game. gs and engine are the
+// clone's game state and execution engine; players are looked up in gs by
+// name, and permanents, spells and abilities are remapped through m, so an
+// activated ability on the stack is the same copy as the one on its
+// source. Priority passes and the OnCast / OnResolve / OnAfterResolve
+// hooks belong to the handler running the original game and aren't
+// copied.
+func (s *Stack) Clone(gs GameState, engine *ExecutionEngine, m *game.CloneMap) *Stack {
+	c := NewStack(gs, engine)
+	c.allPlayers = gs.GetAllPlayers()
+	items := make(map[*StackItem]*StackItem, len(s.items))
+	for _, item := range s.items {
+		ci := *item
+		items[item] = &ci
+		c.items = append(c.items, &ci)
+	}
+	remap := func(v any) any {
+		switch o := v.(type) {
+		case *game.Player, *game.Permanent, game.ObjectCloner:
+			return m.Object(o)
+		case *StackItem:
+			if ci, ok := items[o]; ok {
+				return ci
+			}
+		case AbilityPlayer:
+			if p := gs.GetPlayer(o.GetName()); p != nil {
+				return p
+			}
+		}
+		return v
+	}
+	for _, ci := range c.items {
+		if ci.Controller != nil {
+			ci.Controller, _ = remap(ci.Controller).(AbilityPlayer)
+		}
+		if ci.TriggeringPlayer != nil {
+			ci.TriggeringPlayer, _ = remap(ci.TriggeringPlayer).(AbilityPlayer)
+		}
+		if ci.Ability != nil {
+			ci.Ability = m.Object(ci.Ability).(*Ability)
+		}
+		if ci.Spell != nil {
+			ci.Spell = m.Object(ci.Spell).(*Spell)
+		}
+		ci.Source = remap(ci.Source)
+		ci.Targets = remapAll(ci.Targets, remap)
+		if ci.targetGroups != nil {
+			groups := make([]TargetGroup, len(ci.targetGroups))
+			for i, tg := range ci.targetGroups {
+				tg.Objects = remapAll(tg.Objects, remap)
+				groups[i] = tg
+			}
+			ci.targetGroups = groups
+		}
+	}
+	if s.lastCastItem != nil {
+		c.lastCastItem = items[s.lastCastItem]
+	}
+	return c
+}
+
+func remapAll(objs []any, remap func(any) any) []any {
+	if objs == nil {
+		return nil
+	}
+	out := make([]any, len(objs))
+	for i, o := range objs {
+		out[i] = remap(o)
+	}
+	return out
+}
+
+// CloneObject copies the ability for a clone of its game, with its source
+// remapped through m. Its effects are shared, as resolving them doesn't
+// change them.
+func (a *Ability) CloneObject(m *game.CloneMap) any {
+	c := *a
+	m.Copied(a, &c)
+	c.Cost.ManaCost = maps.Clone(a.Cost.ManaCost)
+	c.Source = m.Object(a.Source)
+	return &c
+}
+
+// CloneObject copies the spell for a clone of its game, with its source
+// remapped through m.
+func (sp *Spell) CloneObject(m *game.CloneMap) any {
+	c := *sp
+	m.Copied(sp, &c)
+	c.Source = m.Object(sp.Source)
+	return &c
+}
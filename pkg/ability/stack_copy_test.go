@@ -0,0 +1,136 @@
+package ability
+
+import (
+	"testing"
+)
+
+// stormGameState counts spells cast and records damage so storm copies can
+// be observed.
+type stormGameState struct {
+	mockStackGameState
+	spellsCast int
+	damaged    []any
+}
+
+func (g *stormGameState) RecordSpellCast(caster AbilityPlayer) { g.spellsCast++ }
+func (g *stormGameState) SpellsCastThisTurn() int              { return g.spellsCast }
+func (g *stormGameState) DealDamage(source any, target any, amount int) {
+	for i := 0; i < amount; i++ {
+		g.damaged = append(g.damaged, target)
+	}
+}
+
+func resolveAll(t *testing.T, stack *Stack) {
+	t.Helper()
+	for i := 0; !stack.IsEmpty(); i++ {
+		if i > 100 {
+			t.Fatal("stack did not empty")
+		}
+		if err := stack.ResolveTop(); err != nil {
+			t.Fatalf("resolve: %v", err)
+		}
+	}
+}
+
+func TestCopyItem_NewControllerAndTargets(t *testing.T) {
+	gs := &stormGameState{}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+	casts := 0
+	stack.OnCast = func(*StackItem) { casts++ }
+
+	bolt := parsedSpell(t, "Lightning Bolt", "Instant", "Lightning Bolt deals 3 damage to any target.")
+	stack.AddSpell(bolt, p1, []interface{}{p2})
+	original := stack.Peek()
+
+	retargeted := stack.CopyItem(original, p2, []interface{}{p1})
+	kept := stack.CopyItem(original, p2, nil)
+
+	if !retargeted.IsCopy || retargeted.Controller != p2 || retargeted.Targets[0] != p1 {
+		t.Fatalf("expected a P2 copy aimed at P1, got %+v", retargeted)
+	}
+	if kept.Targets[0] != p2 {
+		t.Fatalf("nil targets should keep the original's, got %v", kept.Targets)
+	}
+	if retargeted.Spell == original.Spell || retargeted.Spell.ID == original.Spell.ID {
+		t.Fatal("the copy should have its own spell")
+	}
+	if casts != 1 || gs.spellsCast != 1 || stack.LastCastItem() != original {
+		t.Fatalf("copies aren't cast: casts=%d spells=%d", casts, gs.spellsCast)
+	}
+	if stack.Size() != 3 {
+		t.Fatalf("expected the spell and two copies, got %d items", stack.Size())
+	}
+}
+
+func TestStorm_CopiesForEachSpellCastBefore(t *testing.T) {
+	gs := &stormGameState{spellsCast: 3}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+
+	grapeshot := parsedSpell(t, "Grapeshot", "Sorcery", "Grapeshot deals 1 damage to any target.\nStorm (When you cast this spell, copy it for each spell cast before it this turn. You may choose new targets for the copies.)")
+	stack.AddSpell(grapeshot, p1, []interface{}{p2})
+
+	if top := stack.Peek(); top.Type != StackItemAbility || top.Ability.Effects[0].Value != 3 {
+		t.Fatalf("expected a storm trigger for 3 copies on top, got %+v", top)
+	}
+	resolveAll(t, stack)
+
+	if len(gs.damaged) != 4 {
+		t.Fatalf("expected Grapeshot and 3 copies to deal 4 damage, got %d", len(gs.damaged))
+	}
+	for _, target := range gs.damaged {
+		if target != p2 {
+			t.Fatalf("copies should keep Grapeshot's target, got %v", target)
+		}
+	}
+	if gs.spellsCast != 4 {
+		t.Fatalf("only Grapeshot itself should count as cast, got %d", gs.spellsCast)
+	}
+}
+
+func TestStorm_CopiesWhenOriginalCountered(t *testing.T) {
+	gs := &stormGameState{spellsCast: 2}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+
+	grapeshot := parsedSpell(t, "Grapeshot", "Sorcery", "Grapeshot deals 1 damage to any target.\nStorm (When you cast this spell, copy it for each spell cast before it this turn. You may choose new targets for the copies.)")
+	stack.AddSpell(grapeshot, p1, []interface{}{p2})
+	original := stack.GetItems()[0]
+	if err := stack.Counter(original, p2); err != nil {
+		t.Fatalf("counter: %v", err)
+	}
+	resolveAll(t, stack)
+
+	if len(gs.damaged) != 2 {
+		t.Fatalf("expected the 2 storm copies to resolve, got %d damage", len(gs.damaged))
+	}
+}
+
+func TestCopySpell_ReverberateCopiesTargetSpell(t *testing.T) {
+	gs := &stormGameState{}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+
+	bolt := parsedSpell(t, "Lightning Bolt", "Instant", "Lightning Bolt deals 3 damage to any target.")
+	stack.AddSpell(bolt, p2, []interface{}{p1})
+	boltItem := stack.Peek()
+	reverberate := parsedSpell(t, "Reverberate", "Instant", "Copy target instant or sorcery spell. You may choose new targets for the copy.")
+	stack.AddSpell(reverberate, p1, []interface{}{boltItem})
+
+	if err := stack.ResolveTop(); err != nil {
+		t.Fatalf("resolve Reverberate: %v", err)
+	}
+	cp := stack.Peek()
+	if cp == boltItem || !cp.IsCopy || cp.Controller != p1 || cp.Spell.Name != "Lightning Bolt" {
+		t.Fatalf("expected a P1 copy of Lightning Bolt on top, got %+v", cp)
+	}
+	resolveAll(t, stack)
+	if len(gs.damaged) != 6 {
+		t.Fatalf("expected the bolt and its copy to deal 6 damage, got %d", len(gs.damaged))
+	}
+}
@@ -0,0 +1,207 @@
+package ability
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mtgsim/mtgsim/pkg/card"
+)
+
+// taxGameState lets players in canPay pay any tax and records who paid.
+type taxGameState struct {
+	mockStackGameState
+	canPay map[string]bool
+	paid   []string
+}
+
+func (g *taxGameState) PayTax(payer AbilityPlayer, amount int, effect Effect) bool {
+	if !g.canPay[payer.GetName()] {
+		return false
+	}
+	g.paid = append(g.paid, payer.GetName())
+	return true
+}
+
+func parsedSpell(t *testing.T, name, typeLine, oracle string) *Spell {
+	t.Helper()
+	c := card.Card{Name: name, TypeLine: typeLine, OracleText: oracle}
+	abilities, err := NewAbilityParser().ParseAbilities(oracle, c)
+	if err != nil {
+		t.Fatalf("parse %s: %v", name, err)
+	}
+	var effects []Effect
+	for _, ab := range abilities {
+		effects = append(effects, ab.Effects...)
+	}
+	return &Spell{ID: uuid.New(), Name: name, TypeLine: typeLine, OracleText: oracle, Effects: effects, Source: c}
+}
+
+func TestStifleCountersTriggeredAbility(t *testing.T) {
+	gs := &mockStackGameState{}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+
+	trigger := &Ability{ID: uuid.New(), Name: "Thassa's Oracle ETB", Type: Triggered, Effects: []Effect{{Type: WinGame}}}
+	stack.AddAbility(trigger, p1, nil)
+	triggerItem := stack.Peek()
+
+	stifle := parsedSpell(t, "Stifle", "Instant", "Counter target activated or triggered ability. (Mana abilities can't be targeted.)")
+	if len(stifle.Effects) != 1 || len(stifle.Effects[0].Targets) != 1 || stifle.Effects[0].Targets[0].Type != AbilityTarget {
+		t.Fatalf("Stifle should target an ability, got %+v", stifle.Effects)
+	}
+	stack.AddSpell(stifle, p2, []interface{}{triggerItem})
+
+	if err := stack.ResolveTop(); err != nil {
+		t.Fatalf("resolve Stifle: %v", err)
+	}
+	if !triggerItem.Countered {
+		t.Fatal("the trigger should be countered")
+	}
+}
+
+func TestStifleCantTargetSpells(t *testing.T) {
+	ee := NewExecutionEngine(&mockStackGameState{})
+	spell := &StackItem{Type: StackItemSpell, Spell: &Spell{Name: "Lightning Bolt"}}
+	ability := &StackItem{Type: StackItemAbility, Ability: &Ability{Name: "ETB"}}
+	req := Target{Type: AbilityTarget, Required: true, Count: 1}
+	if ee.IsLegalTarget(spell, req, nil) {
+		t.Error("an ability target must not accept a spell")
+	}
+	if !ee.IsLegalTarget(ability, req, nil) {
+		t.Error("an ability target must accept an ability")
+	}
+	if ee.IsLegalTarget(ability, Target{Type: SpellTarget, Required: true, Count: 1}, nil) {
+		t.Error("a spell target must not accept an ability")
+	}
+}
+
+func TestCounterRespectsCantBeCountered(t *testing.T) {
+	gs := &mockStackGameState{}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+
+	decay := &Spell{ID: uuid.New(), Name: "Abrupt Decay", TypeLine: "Instant",
+		OracleText: "This spell can't be countered.\nDestroy target nonland permanent with mana value 3 or less."}
+	stack.AddSpell(decay, p1, nil)
+	decayItem := stack.Peek()
+
+	if err := stack.CounterSpell(decayItem, p2); err == nil {
+		t.Error("countering Abrupt Decay should fail")
+	}
+	counterspell := parsedSpell(t, "Counterspell", "Instant", "Counter target spell.")
+	stack.AddSpell(counterspell, p2, []interface{}{decayItem})
+	if err := stack.ResolveTop(); err != nil {
+		t.Fatalf("resolve Counterspell: %v", err)
+	}
+	if decayItem.Countered {
+		t.Error("Abrupt Decay can't be countered")
+	}
+}
+
+func TestManaLeakTax(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		canPay    bool
+		countered bool
+	}{
+		{"controller pays", true, false},
+		{"controller can't pay", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gs := &taxGameState{canPay: map[string]bool{"P1": tc.canPay}}
+			stack := NewStack(gs, NewExecutionEngine(gs))
+			p1 := &mockStackPlayer{name: "P1"}
+			p2 := &mockStackPlayer{name: "P2"}
+
+			stack.AddSpell(&Spell{ID: uuid.New(), Name: "Rhystic Study", TypeLine: "Enchantment"}, p1, nil)
+			target := stack.Peek()
+			leak := parsedSpell(t, "Mana Leak", "Instant", "Counter target spell unless its controller pays {3}.")
+			stack.AddSpell(leak, p2, []interface{}{target})
+			if err := stack.ResolveTop(); err != nil {
+				t.Fatalf("resolve Mana Leak: %v", err)
+			}
+			if target.Countered != tc.countered {
+				t.Errorf("countered = %v, want %v", target.Countered, tc.countered)
+			}
+			if tc.canPay && (len(gs.paid) != 1 || gs.paid[0] != "P1") {
+				t.Errorf("P1 should have paid, payments: %v", gs.paid)
+			}
+		})
+	}
+}
+
+func TestTriggerTaxIsOfferedToTriggeringPlayer(t *testing.T) {
+	gs := &taxGameState{canPay: map[string]bool{"P1": true, "P2": true}}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+
+	text := "Whenever an opponent casts a spell, you may draw a card unless that player pays {1}."
+	abilities, err := NewAbilityParser().ParseAbilities(text, card.Card{Name: "Rhystic Study", OracleText: text})
+	if err != nil || len(abilities) != 1 {
+		t.Fatalf("parse Rhystic Study: %v %v", abilities, err)
+	}
+	stack.AddSpell(&Spell{ID: uuid.New(), Name: "Sol Ring", TypeLine: "Artifact"}, p2, nil)
+	cast := stack.LastCastItem()
+	stack.AddTriggeredAbility(abilities[0], p1, p2, nil)
+	if stack.LastCastItem() != cast {
+		t.Error("a trigger must not replace the last cast item")
+	}
+	if err := stack.ResolveTop(); err != nil {
+		t.Fatalf("resolve trigger: %v", err)
+	}
+	if len(gs.paid) != 1 || gs.paid[0] != "P2" {
+		t.Errorf("the caster should pay Rhystic Study's tax, payments: %v", gs.paid)
+	}
+}
+
+func TestSplitSecondStopsResponses(t *testing.T) {
+	gs := &mockStackGameState{}
+	stack := NewStack(gs, NewExecutionEngine(gs))
+	pm := NewPriorityManager(stack, gs)
+	p1 := &mockStackPlayer{name: "P1"}
+	p2 := &mockStackPlayer{name: "P2"}
+	gs.activePlayer = p1
+	pm.SetPlayers([]AbilityPlayer{p1, p2})
+	pm.SetActivePlayer(p1)
+	pm.SetPhase("Main Phase")
+
+	grip := &Spell{ID: uuid.New(), Name: "Krosan Grip", TypeLine: "Instant",
+		OracleText: "Split second (As long as this spell is on the stack, players can't cast spells or activate abilities that aren't mana abilities.)\nDestroy target artifact or enchantment."}
+	if err := pm.CastSpell(p1, grip, nil); err != nil {
+		t.Fatalf("cast Krosan Grip: %v", err)
+	}
+	if !stack.SplitSecondActive() {
+		t.Fatal("split second should be active")
+	}
+	bolt := &Spell{ID: uuid.New(), Name: "Lightning Bolt", TypeLine: "Instant"}
+	if pm.CanCastSpell(bolt, p1) || pm.CastSpell(p1, bolt, nil) == nil {
+		t.Error("no spells can be cast under split second")
+	}
+	activated := &Ability{ID: uuid.New(), Name: "Pump", Type: Activated}
+	if pm.ActivateAbility(p1, activated, nil) == nil {
+		t.Error("no non-mana abilities can be activated under split second")
+	}
+	if !pm.CanActivateAbility(&Ability{Name: "Tap for mana", Type: Mana}, p1) {
+		t.Error("mana abilities stay available under split second")
+	}
+
+	asked := 0
+	pm.DecisionFunc = func(player AbilityPlayer) *PriorityDecision {
+		if !stack.IsEmpty() {
+			asked++
+		}
+		return &PriorityDecision{Action: PriorityActionPass, Player: player}
+	}
+	if err := pm.ProcessPriorityRound(10); err != nil {
+		t.Fatalf("priority round: %v", err)
+	}
+	if !stack.IsEmpty() {
+		t.Error("Krosan Grip should resolve")
+	}
+	if asked != 0 {
+		t.Errorf("players shouldn't be asked to respond to split second, asked %d times", asked)
+	}
+}
@@ -423,3 +423,57 @@ func TestStackGetState(t *testing.T) {
 		t.Error("Second item should be Counterspell")
 	}
 }
+
+func TestStackClone_RemapsControllersAndTargets(t *testing.T) {
+	p1 := game.NewPlayer("P1", 20)
+	g := game.NewGame(p1, game.NewPlayer("P2", 20))
+	bear := p1.PutTokenOnBattlefield(game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear"})
+	caster := &mockPlayer{name: "P1"}
+	stack := NewStack(&mockGameState{players: []AbilityPlayer{caster}}, nil)
+	bolt := &StackItem{ID: uuid.New(), Type: StackItemSpell, Controller: caster, Targets: []interface{}{bear}}
+	stack.Push(bolt)
+	stack.Push(&StackItem{ID: uuid.New(), Type: StackItemSpell, Controller: caster, Targets: []interface{}{bolt}})
+
+	_, m := g.Clone()
+	forkCaster := &mockPlayer{name: "P1"}
+	fork := stack.Clone(&mockGameState{players: []AbilityPlayer{forkCaster}}, nil, m)
+	items := fork.GetItems()
+	if len(items) != 2 || items[0] == bolt || items[0].Controller != forkCaster {
+		t.Fatalf("expected copied items controlled by the fork's player, got %+v", items)
+	}
+	if items[0].Targets[0] != m.Permanent(bear) || items[1].Targets[0] != items[0] {
+		t.Fatal("expected targets remapped to the fork's permanent and stack item")
+	}
+	if bolt.Targets[0] != bear || stack.Size() != 2 {
+		t.Fatal("cloning must not change the original stack")
+	}
+}
+
+func TestStackClone_CopiesAbilitiesAndSpells(t *testing.T) {
+	p1 := game.NewPlayer("P1", 20)
+	g := game.NewGame(p1, game.NewPlayer("P2", 20))
+	engine := p1.PutTokenOnBattlefield(game.SimpleCard{Name: "Engine", TypeLine: "Artifact"})
+	ab := &Ability{Name: "Engine Draw", Type: Activated, Source: engine, Cost: Cost{ManaCost: map[game.ManaType]int{game.Any: 1}}}
+	engine.SetAbilities([]any{ab})
+	caster := &mockPlayer{name: "P1"}
+	stack := NewStack(&mockGameState{players: []AbilityPlayer{caster}}, nil)
+	stack.Push(&StackItem{ID: uuid.New(), Type: StackItemAbility, Ability: ab, Controller: caster, Source: engine})
+	spell := &Spell{Name: "Shock", Source: engine}
+	stack.Push(&StackItem{ID: uuid.New(), Type: StackItemSpell, Spell: spell, Controller: caster})
+
+	_, m := g.Clone()
+	fork := stack.Clone(&mockGameState{players: []AbilityPlayer{&mockPlayer{name: "P1"}}}, nil, m)
+	items := fork.GetItems()
+	fab, fspell := items[0].Ability, items[1].Spell
+	if fab == ab || fab.Source != m.Permanent(engine) || fspell == spell || fspell.Source != m.Permanent(engine) {
+		t.Fatalf("expected copied abilities and spells sourced from the fork's permanent, got %+v %+v", fab, fspell)
+	}
+	if abs := m.Permanent(engine).GetAbilities(); len(abs) != 1 || abs[0] != fab {
+		t.Fatal("expected the ability on the stack to be its source's copy")
+	}
+	fab.UsedThisTurn++
+	fab.Cost.ManaCost[game.Any] = 2
+	if ab.UsedThisTurn != 0 || ab.Cost.ManaCost[game.Any] != 1 {
+		t.Fatal("changing the fork's ability must not change the original")
+	}
+}
@@ -1,32 +1,84 @@
 package ability
 
-func targetsForEffect(effect Effect, allTargets []any, cursor *int) []any {
-	needed := effectTargetSlots(effect)
-	if needed == 0 || cursor == nil || *cursor >= len(allTargets) {
-		return nil
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mtgsim/mtgsim/internal/logger"
+)
+
+// TargetGroup holds the objects chosen for one target requirement of one of
+// a stack item's effects, such as Rabid Bite's "target creature you
+// control" (CR 601.2c).
+type TargetGroup struct {
+	// Effect indexes the item's effects.
+	Effect int
+	// Requirement is what the objects were chosen for. Effects that take
+	// targets without declaring them get a NoTarget requirement, which
+	// isn't rechecked on resolution.
+	Requirement Target
+	Objects     []any
+}
+
+// TargetGroups returns item's targets grouped by the requirement each was
+// chosen for, in the order the effects declare them.
+func (item *StackItem) TargetGroups() []TargetGroup {
+	if item.targetGroups == nil && len(item.Targets) > 0 {
+		item.targetGroups = bindTargets(itemEffects(item), item.Targets)
 	}
-	end := *cursor + needed
-	if end > len(allTargets) {
-		end = len(allTargets)
+	return item.targetGroups
+}
+
+// bindTargets splits targets, chosen in the order the effects declare
+// their requirements, into one group per requirement. A requirement for
+// "any number of" targets takes every object the requirements after it
+// don't need.
+func bindTargets(effects []Effect, targets []any) []TargetGroup {
+	var groups []TargetGroup
+	fixed := 0
+	for i, effect := range effects {
+		for _, req := range targetRequirements(effect) {
+			groups = append(groups, TargetGroup{Effect: i, Requirement: req})
+			if req.Count > 0 {
+				fixed += req.Count
+			}
+		}
 	}
-	out := allTargets[*cursor:end]
-	*cursor = end
-	return out
+	cursor := 0
+	for i := range groups {
+		n := groups[i].Requirement.Count
+		if n > 0 {
+			fixed -= n
+		} else {
+			n = len(targets) - cursor - fixed
+		}
+		n = max(0, min(n, len(targets)-cursor))
+		groups[i].Objects = append([]any(nil), targets[cursor:cursor+n]...)
+		cursor += n
+	}
+	return groups
 }
 
-func effectTargetSlots(effect Effect) int {
-	total := 0
-	for _, target := range effect.Targets {
-		count := target.Count
-		if count <= 0 && target.Required {
-			count = 1
+// targetRequirements lists the targets effect takes. A required target
+// with no count is a single target; a negative count means any number.
+func targetRequirements(effect Effect) []Target {
+	if len(effect.Targets) == 0 {
+		if n := legacyEffectTargetSlots(effect.Type); n > 0 {
+			return []Target{{Type: NoTarget, Required: true, Count: n}}
 		}
-		total += count
+		return nil
 	}
-	if total == 0 {
-		return legacyEffectTargetSlots(effect.Type)
+	reqs := make([]Target, 0, len(effect.Targets))
+	for _, req := range effect.Targets {
+		if req.Count == 0 {
+			if !req.Required {
+				continue
+			}
+			req.Count = 1
+		}
+		reqs = append(reqs, req)
 	}
-	return total
+	return reqs
 }
 
 func legacyEffectTargetSlots(effectType EffectType) int {
@@ -34,9 +86,189 @@ func legacyEffectTargetSlots(effectType EffectType) int {
 	case SourcePowerDamage:
 		return 2
 	case DealDamage, PumpCreature, DestroyPermanent, CounterSpell, ReturnToHand,
-		TapUntap, ChangeControl, Exile, AddCounters, UntapPermanent, SacrificePermanent:
+		TapUntap, ChangeControl, Exile, AddCounters, UntapPermanent, SacrificePermanent,
+		CopySpell:
 		return 1
 	default:
 		return 0
 	}
 }
+
+// legalTargets rechecks every target of item as it starts to resolve and
+// returns the objects of each group that are still legal (CR 608.2b).
+// checked counts the targets that were rechecked at all.
+func (s *Stack) legalTargets(item *StackItem) (legal [][]any, checked int) {
+	groups := item.TargetGroups()
+	legal = make([][]any, len(groups))
+	for i, group := range groups {
+		if group.Requirement.Type == NoTarget {
+			legal[i] = group.Objects
+			continue
+		}
+		checked += len(group.Objects)
+		for _, obj := range group.Objects {
+			if s.targetStillLegal(item, group.Requirement, obj) {
+				legal[i] = append(legal[i], obj)
+			}
+		}
+	}
+	return legal, checked
+}
+
+// targetStillLegal reports whether obj can still be a target of item for
+// req. Besides req's own checks, a permanent that left the battlefield and
+// a spell or ability that left the stack are new objects, not the ones
+// that were targeted (CR 400.7).
+func (s *Stack) targetStillLegal(item *StackItem, req Target, obj any) bool {
+	if target, ok := obj.(*StackItem); ok && !s.contains(target) {
+		return false
+	}
+	if perm, g := scriptPermanent(obj), s.executionEngine.underlyingGame(); perm != nil && g != nil && !g.OnBattlefield(perm) {
+		return false
+	}
+	return s.executionEngine.isTargetStillLegal(obj, req, item.Controller)
+}
+
+func (s *Stack) contains(item *StackItem) bool {
+	for _, it := range s.items {
+		if it == item {
+			return true
+		}
+	}
+	return false
+}
+
+// applyEffects applies item's effects in order. Each effect gets the
+// targets of its groups that were legal when item started to resolve, so
+// Decimate still destroys the targets it has left (CR 608.2b). An illegal
+// target is neither affected nor used to work out the effect, so an effect
+// that relates its targets to each other does nothing once one of them is
+// gone: Rabid Bite deals no damage without both creatures.
+func (s *Stack) applyEffects(item *StackItem, effects []Effect) error {
+	legal, _ := s.legalTargets(item)
+	groups := item.TargetGroups()
+	for i, effect := range effects {
+		var chosen, targets []any
+		lost := false
+		for g, group := range groups {
+			if group.Effect != i {
+				continue
+			}
+			if relatesTargets(effect) && group.Requirement.Required && len(group.Objects) > 0 && len(legal[g]) == 0 {
+				lost = true
+			}
+			chosen = append(chosen, group.Objects...)
+			targets = append(targets, legal[g]...)
+		}
+		if lost {
+			logger.LogCard("%s: %s does nothing, its target is illegal", item.Description, effect.Type)
+			continue
+		}
+		if effect.Divided {
+			if err := s.applyDivided(item, effect, chosen, targets); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.executionEngine.ApplyEffect(effect, item.Controller, targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relatesTargets reports whether effect works out its result from several
+// of its targets together, rather than affecting each on its own.
+func relatesTargets(effect Effect) bool {
+	return effect.Type == SourcePowerDamage
+}
+
+// applyDivided applies a divided effect to the targets still legal. The
+// division was announced over every chosen target as the spell was cast,
+// so an illegal target's share is lost rather than shared out again
+// (CR 601.2d, 608.2b).
+func (s *Stack) applyDivided(item *StackItem, effect Effect, chosen, legal []any) error {
+	shares := divideAmount(effect.Value, len(chosen))
+	effect.Divided = false
+	for i, obj := range chosen {
+		if shares[i] <= 0 || !containsObject(legal, obj) {
+			continue
+		}
+		effect.Value = shares[i]
+		if err := s.executionEngine.ApplyEffect(effect, item.Controller, []any{obj}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// divideAmount splits total as evenly as it can among n targets, the
+// earlier targets taking any remainder.
+func divideAmount(total, n int) []int {
+	shares := make([]int, n)
+	for i := range shares {
+		shares[i] = total / n
+		if i < total%n {
+			shares[i]++
+		}
+	}
+	return shares
+}
+
+func containsObject(objs []any, obj any) bool {
+	for _, o := range objs {
+		if sameObject(o, obj) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeTarget makes to a target of item in place of from, for effects that
+// change or choose new targets (Deflecting Swat, Misdirection). to must be
+// legal for the requirement from was chosen for, and an object can't be
+// chosen twice for one requirement (CR 115.3, 115.7).
+func (s *Stack) ChangeTarget(item *StackItem, from, to any) error {
+	if !s.contains(item) {
+		return fmt.Errorf("%s not found on stack", item.Description)
+	}
+	groups := item.TargetGroups()
+	for g := range groups {
+		for i, obj := range groups[g].Objects {
+			if !sameObject(obj, from) {
+				continue
+			}
+			for _, other := range groups[g].Objects {
+				if sameObject(other, to) {
+					return fmt.Errorf("%v is already a target of %s", to, item.Description)
+				}
+			}
+			if req := groups[g].Requirement; req.Type != NoTarget && !s.targetStillLegal(item, req, to) {
+				return fmt.Errorf("%v isn't a legal target for %s", to, item.Description)
+			}
+			groups[g].Objects[i] = to
+			item.Targets = flattenTargets(groups)
+			logger.LogCard("%s now targets %v instead of %v", item.Description, to, from)
+			return nil
+		}
+	}
+	return fmt.Errorf("%v isn't a target of %s", from, item.Description)
+}
+
+func flattenTargets(groups []TargetGroup) []any {
+	var out []any
+	for _, group := range groups {
+		out = append(out, group.Objects...)
+	}
+	return out
+}
+
+// sameObject compares targets, which may be values of types that can't be
+// compared with ==.
+func sameObject(a, b any) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ta := reflect.TypeOf(a)
+	return ta == reflect.TypeOf(b) && ta.Comparable() && a == b
+}
@@ -0,0 +1,165 @@
+package ability
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// bindingGameState records the damage each object is dealt.
+type bindingGameState struct {
+	scriptGameState
+	damage map[any]int
+}
+
+func (b *bindingGameState) DealDamage(source, target any, amount int) { b.damage[target] += amount }
+
+func newBindingHarness(t *testing.T) (*Stack, *bindingGameState, *scriptPlayer, *game.Player) {
+	t.Helper()
+	p1 := game.NewPlayer("P1", 20)
+	p2 := game.NewPlayer("P2", 20)
+	sp := &scriptPlayer{mockPlayer: mockPlayer{name: "P1", life: 20}, p: p1}
+	gs := &bindingGameState{
+		scriptGameState: scriptGameState{mockGameState: mockGameState{players: []AbilityPlayer{sp}, currentPlayer: sp}, g: game.NewGame(p1, p2)},
+		damage:          map[any]int{},
+	}
+	return NewStack(gs, NewExecutionEngine(gs)), gs, sp, p2
+}
+
+func creatureTargets(count int) []Target {
+	return []Target{{Type: CreatureTarget, Required: true, Count: count}}
+}
+
+func TestBindTargets_AnyNumberLeavesRoomForLaterTargets(t *testing.T) {
+	effects := []Effect{
+		{Type: DealDamage, Value: 1, Targets: creatureTargets(-1)},
+		{Type: DestroyPermanent, Targets: []Target{{Type: PermanentTarget, Required: true}}},
+		{Type: DrawCards, Value: 1},
+	}
+	groups := bindTargets(effects, []any{"a", "b", "c"})
+	if len(groups) != 2 || len(groups[0].Objects) != 2 || groups[1].Effect != 1 || groups[1].Objects[0] != "c" {
+		t.Fatalf("expected [a b] for the damage and [c] for the destroy, got %+v", groups)
+	}
+}
+
+func TestRabidBite_DoesNothingWhenEitherCreatureIsGone(t *testing.T) {
+	for _, gone := range []string{"Bear", "Ogre"} {
+		t.Run(gone, func(t *testing.T) {
+			stack, gs, sp, p2 := newBindingHarness(t)
+			bear := sp.p.PutTokenOnBattlefield(game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"})
+			ogre := p2.PutTokenOnBattlefield(game.SimpleCard{Name: "Ogre", TypeLine: "Creature — Ogre", Power: "3", Toughness: "3"})
+			bite := &Spell{Name: "Rabid Bite", Effects: []Effect{{
+				Type:    SourcePowerDamage,
+				Targets: []Target{{Type: CreatureTarget, Required: true, Count: 1}, {Type: CreatureTarget, Required: true, Count: 1}},
+			}}}
+			stack.AddSpell(bite, sp, []any{bear, ogre})
+			item := stack.Peek()
+
+			if gone == "Bear" {
+				sp.p.DestroyPermanent(bear)
+			} else {
+				p2.DestroyPermanent(ogre)
+			}
+			resolveAll(t, stack)
+			if item.Fizzled {
+				t.Fatal("one legal target is enough to resolve")
+			}
+			if len(gs.damage) != 0 {
+				t.Fatalf("no damage without both creatures, got %v", gs.damage)
+			}
+		})
+	}
+}
+
+func TestDecimate_DestroysTheTargetsItHasLeft(t *testing.T) {
+	stack, _, sp, p2 := newBindingHarness(t)
+	var targets []any
+	for _, c := range []game.SimpleCard{
+		{Name: "Sol Ring", TypeLine: "Artifact"},
+		{Name: "Ogre", TypeLine: "Creature — Ogre", Power: "3", Toughness: "3"},
+		{Name: "Rhystic Study", TypeLine: "Enchantment"},
+		{Name: "Forest", TypeLine: "Basic Land — Forest"},
+	} {
+		targets = append(targets, p2.PutTokenOnBattlefield(c))
+	}
+	permanent := Target{Type: PermanentTarget, Required: true, Count: 1}
+	decimate := &Spell{Name: "Decimate", Effects: []Effect{{
+		Type: DestroyPermanent, Targets: []Target{permanent, permanent, permanent, permanent},
+	}}}
+	stack.AddSpell(decimate, sp, targets)
+	item := stack.Peek()
+	p2.DestroyPermanent(targets[1].(*game.Permanent))
+	resolveAll(t, stack)
+	if item.Fizzled {
+		t.Fatal("three legal targets are enough to resolve")
+	}
+	if len(p2.Battlefield) != 0 || len(p2.Graveyard) != 4 {
+		t.Fatalf("expected the artifact, enchantment and land destroyed, battlefield %v", p2.Battlefield)
+	}
+}
+
+func TestResolution_DropsIllegalTargetsAndFizzlesWhenAllAreGone(t *testing.T) {
+	stack, gs, sp, p2 := newBindingHarness(t)
+	var ogres []any
+	for i := 0; i < 3; i++ {
+		ogres = append(ogres, p2.PutTokenOnBattlefield(game.SimpleCard{Name: "Ogre", TypeLine: "Creature — Ogre", Power: "3", Toughness: "3"}))
+	}
+	covenant := &Spell{Name: "Fire Covenant", Effects: []Effect{{Type: DealDamage, Value: 6, Divided: true, Targets: creatureTargets(-1)}}}
+	stack.AddSpell(covenant, sp, ogres)
+	p2.DestroyPermanent(ogres[1].(*game.Permanent))
+	resolveAll(t, stack)
+	if gs.damage[ogres[0]] != 2 || gs.damage[ogres[2]] != 2 || gs.damage[ogres[1]] != 0 {
+		t.Fatalf("expected the two remaining Ogres to take their 2 and the lost share to go unused, got %v", gs.damage)
+	}
+
+	stack.AddSpell(covenant, sp, ogres[:2])
+	item := stack.Peek()
+	p2.DestroyPermanent(ogres[0].(*game.Permanent))
+	resolveAll(t, stack)
+	if !item.Fizzled {
+		t.Fatal("a spell whose targets are all illegal doesn't resolve")
+	}
+}
+
+func TestDividedDamage_SplitsAmongTheTargets(t *testing.T) {
+	stack, gs, sp, p2 := newBindingHarness(t)
+	bear := p2.PutTokenOnBattlefield(game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"})
+	ogre := p2.PutTokenOnBattlefield(game.SimpleCard{Name: "Ogre", TypeLine: "Creature — Ogre", Power: "3", Toughness: "3"})
+	bolt := &Spell{Name: "Forked Bolt", Effects: []Effect{{Type: DealDamage, Value: 2, Divided: true, Targets: []Target{{Type: CreatureTarget, Required: true, Count: 2}}}}}
+	stack.AddSpell(bolt, sp, []any{bear, ogre})
+	resolveAll(t, stack)
+	if gs.damage[bear] != 1 || gs.damage[ogre] != 1 {
+		t.Fatalf("expected Forked Bolt to deal 1 to each, got %v", gs.damage)
+	}
+
+	ab, err := NewAbilityParser().ParseAbilities("Forked Bolt deals 2 damage divided as you choose among one or two targets.", nil)
+	if err != nil || len(ab) == 0 || !ab[0].Effects[0].Divided {
+		t.Fatalf("the parser should mark divided damage, got %+v, %v", ab, err)
+	}
+}
+
+func TestChangeTarget_RedirectsToANewLegalTarget(t *testing.T) {
+	stack, gs, sp, p2 := newBindingHarness(t)
+	bear := sp.p.PutTokenOnBattlefield(game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"})
+	ogre := p2.PutTokenOnBattlefield(game.SimpleCard{Name: "Ogre", TypeLine: "Creature — Ogre", Power: "3", Toughness: "3"})
+	shock := &Spell{Name: "Shock", Effects: []Effect{{Type: DealDamage, Value: 2, Targets: creatureTargets(1)}}}
+	stack.AddSpell(shock, sp, []any{bear})
+	item := stack.Peek()
+
+	if err := stack.ChangeTarget(item, bear, sp); err == nil {
+		t.Fatal("a player can't be the new target of a creature requirement")
+	}
+	if err := stack.ChangeTarget(item, ogre, bear); err == nil {
+		t.Fatal("the Ogre isn't a target of Shock")
+	}
+	if err := stack.ChangeTarget(item, bear, ogre); err != nil {
+		t.Fatalf("change target: %v", err)
+	}
+	if item.Targets[0] != ogre || item.TargetGroups()[0].Objects[0] != ogre {
+		t.Fatalf("expected Shock to target the Ogre, got %v", item.Targets)
+	}
+	resolveAll(t, stack)
+	if gs.damage[ogre] != 2 || gs.damage[bear] != 0 {
+		t.Fatalf("expected the Ogre to take the damage, got %v", gs.damage)
+	}
+}
@@ -9,3 +9,9 @@ func (ee *ExecutionEngine) isTargetStillLegal(target any, targetReq Target, cont
 	}
 	return ee.isValidBasicTarget(target, targetReq)
 }
+
+// IsLegalTarget reports whether target satisfies req for controller, e.g.
+// whether a counterspell can target the spell on top of the stack.
+func (ee *ExecutionEngine) IsLegalTarget(target any, req Target, controller AbilityPlayer) bool {
+	return ee.isTargetStillLegal(target, req, controller)
+}
@@ -140,8 +140,9 @@ func (tv *TargetValidator) isValidTargetType(target interface{}, targetType Targ
 	case AnyTarget:
 		return tv.isPlayer(target) || tv.isPermanent(target)
 	case SpellTarget:
-		_, ok := target.(*StackItem)
-		return ok
+		return isStackItemOfType(target, StackItemSpell)
+	case AbilityTarget:
+		return isStackItemOfType(target, StackItemAbility)
 	case CardInGraveyardTarget:
 		// Graveyard targets are filtered by getPotentialTargets; accept any non-nil object
 		return target != nil
@@ -80,6 +80,7 @@ const (
 	LookAtLibraryTop   // Look at top N cards of library (informational; no game state change)
 	RevealInformation  // Reveal hand, top card, etc. (informational; no game state change)
 	ImprintCards       // Imprint — exile a card from hand when ETB (Chrome Mox, etc.)
+	Scripted           // Hand-written card script; see RegisterCardScript
 )
 
 // String returns the human-readable name of an EffectType.
@@ -153,6 +154,8 @@ func (et EffectType) String() string {
 		return "RevealInformation"
 	case ImprintCards:
 		return "ImprintCards"
+	case Scripted:
+		return "Scripted"
 	default:
 		return fmt.Sprintf("EffectType(%d)", et)
 	}
@@ -205,7 +208,31 @@ type Cost struct {
 	SacrificeCost bool
 	DiscardCost   int
 	LifeCost      int
-	OtherCosts    []string // For complex costs that need special handling
+	Typed         []TypedCost // typed non-mana costs beyond the source's own
+	OtherCosts    []string    // For complex costs that need special handling
+}
+
+// CostKind identifies a typed non-mana activation cost.
+type CostKind int
+
+const (
+	SacrificePermanents  CostKind = iota // sacrifice Count permanents matching Filter
+	ExileFromGraveyard                   // exile Count cards matching Filter from your graveyard
+	RemoveCounters                       // remove Count CounterType counters from the source
+	TapUntappedCreatures                 // tap Count untapped creatures you control
+	ReturnLands                          // return Count lands you control to their owner's hand
+	LoyaltyCost                          // put Count (negative: remove) loyalty counters on the source
+	ExertSource                          // exert the source (CR 701.39)
+)
+
+// TypedCost is a non-mana cost paid alongside an ability's mana. Filter is
+// a card type word ("creature", "artifact") the paid objects must have; an
+// empty Filter, "card" or "permanent" matches anything.
+type TypedCost struct {
+	Kind        CostKind
+	Count       int
+	Filter      string
+	CounterType string
 }
 
 // Target represents a target for an ability.
@@ -229,6 +256,7 @@ const (
 	SpellTarget
 	CardInGraveyardTarget
 	CardInHandTarget
+	AbilityTarget // an activated or triggered ability on the stack
 )
 
 // ConditionType represents a condition that must be met for an effect.
@@ -284,6 +312,17 @@ type Effect struct {
 	// (1, 2, 3, or 0 for "any number").
 	Modes []Effect
 
+	// Script resolves a Scripted effect.
+	Script ScriptFunc
+
+	// Self marks an effect on the ability's own source, such as Basalt
+	// Monolith's "Untap Basalt Monolith".
+	Self bool
+
+	// Divided marks damage divided as the controller chooses among the
+	// targets, such as Forked Bolt's, rather than dealt to each of them.
+	Divided bool
+
 	// Approximate marks parser/runtime support that is recognized but not exact.
 	Approximate         bool
 	ApproximationReason string
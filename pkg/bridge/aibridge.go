@@ -9,6 +9,7 @@ import (
 func AutoActivateMainPhaseAbilities(g *game.Game) {
 	gs := NewAbilityGameState(g)
 	ai := abil.NewAIDecisionMaker(abil.NewExecutionEngine(gs))
+	gs.Chooser = ai
 	active := gs.GetActivePlayer()
 	if active == nil {
 		return
@@ -22,6 +23,7 @@ func AutoActivateMainPhaseAbilitiesWithLog(g *game.Game, onActivate func(cardNam
 	gs := NewAbilityGameState(g)
 	gs.OnActivate = onActivate
 	ai := abil.NewAIDecisionMaker(abil.NewExecutionEngine(gs))
+	gs.Chooser = ai
 	active := gs.GetActivePlayer()
 	if active == nil {
 		return
@@ -33,6 +35,7 @@ func AutoActivateMainPhaseAbilitiesWithLog(g *game.Game, onActivate func(cardNam
 func AutoActivateForPlayer(g *game.Game, playerName, phase string) {
 	gs := NewAbilityGameState(g)
 	ai := abil.NewAIDecisionMaker(abil.NewExecutionEngine(gs))
+	gs.Chooser = ai
 	p := gs.GetPlayer(playerName)
 	if p == nil {
 		return
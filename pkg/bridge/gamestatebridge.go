@@ -1,7 +1,7 @@
 package bridge
 
 import (
-	"strings"
+	"fmt"
 
 	abil "github.com/mtgsim/mtgsim/pkg/ability"
 	"github.com/google/uuid"
@@ -14,6 +14,12 @@ type AbilityGameState struct {
 	players        []abil.AbilityPlayer
 	OnActivate     func(cardName, detail string)
 	OnSearchResult func(foundCardName string)
+	// Chooser makes library choices (search picks, scry/surveil ordering).
+	// Nil uses game.DefaultLibraryChooser.
+	Chooser game.LibraryChooser
+	// TaxDecider decides whether a player pays an "unless ... pays {N}"
+	// tax. Nil pays whenever the player can afford it.
+	TaxDecider abil.TaxDecider
 }
 
 // NewAbilityGameState creates the bridge for a given game.
@@ -42,6 +48,9 @@ func (p *playerAdapter) GetCreatures() []any       { return wrapPerms(p.P.GetCre
 func (p *playerAdapter) GetLands() []any           { return wrapPerms(p.P.GetLands(), p.Game) }
 func (p *playerAdapter) AddLandPlay(n int)         { p.P.AddLandPlay(n) }
 
+// Underlying exposes the wrapped player to card scripts.
+func (p *playerAdapter) Underlying() *game.Player { return p.P }
+
 func wrapPerms(perms []*game.Permanent, g *game.Game) []any {
 	out := make([]any, len(perms))
 	for i, perm := range perms {
@@ -65,6 +74,11 @@ func (pa *permAdapter) GetAbilities() []*abil.Ability {
 			engine := abil.NewExecutionEngine(NewAbilityGameState(pa.Game))
 			abs, err := engine.ParseAndRegisterAbilities(src.OracleText, src)
 			if err == nil {
+				// Costs and activation limits act on the permanent, not
+				// the card it was parsed from.
+				for _, ab := range abs {
+					ab.Source = pa
+				}
 				pa.abilities = abs
 			}
 		}
@@ -81,6 +95,20 @@ func (pa *permAdapter) RemoveAbility(id uuid.UUID) {
 	}
 }
 func (pa *permAdapter) GetSource() game.SimpleCard { return pa.P.GetSource() }
+
+// Underlying exposes the wrapped permanent to card scripts.
+func (pa *permAdapter) Underlying() *game.Permanent { return pa.P }
+
+// CloneObject wraps the permanent's copy in a clone of its game, keeping
+// the abilities parsed so far (and their activation counts).
+func (pa *permAdapter) CloneObject(m *game.CloneMap) any {
+	c := &permAdapter{P: m.Permanent(pa.P), Game: m.Game(), parsed: pa.parsed}
+	m.Copied(pa, c)
+	for _, ab := range pa.abilities {
+		c.abilities = append(c.abilities, m.Object(ab).(*abil.Ability))
+	}
+	return c
+}
 func (pa *permAdapter) Tap()            { pa.P.Tap() }
 func (pa *permAdapter) Untap()          { pa.P.Untap() }
 func (pa *permAdapter) IsTapped() bool  { return pa.P.IsTapped() }
@@ -92,11 +120,23 @@ func (pa *permAdapter) GetOwner() abil.AbilityPlayer {
 func (pa *permAdapter) GetController() abil.AbilityPlayer {
 	return &playerAdapter{P: pa.P.GetController(), Game: pa.Game}
 }
+
+// GetControllerName and the type checks let the AI's target validation
+// tell whose permanent this is and what it is.
+func (pa *permAdapter) GetControllerName() string { return pa.P.GetControllerName() }
+func (pa *permAdapter) IsCreature() bool          { return pa.P.IsCreature() }
+func (pa *permAdapter) IsArtifact() bool          { return pa.P.IsArtifact() }
+func (pa *permAdapter) IsEnchantment() bool       { return pa.P.IsEnchantment() }
+func (pa *permAdapter) IsLand() bool              { return pa.P.IsLand() }
+func (pa *permAdapter) IsPlaneswalker() bool      { return pa.P.IsPlaneswalker() }
 func (p *playerAdapter) GetManaPool() map[game.ManaType]int {
 	return p.P.GetManaPool()
 }
 
 func (p *playerAdapter) CanPayCost(c abil.Cost) bool {
+	if !p.P.CanPayMana(c.ManaCost) {
+		return false
+	}
 	if c.LifeCost > 0 && p.P.GetLifeTotal() < c.LifeCost {
 		return false
 	}
@@ -110,8 +150,8 @@ func (p *playerAdapter) CanPayCost(c abil.Cost) bool {
 }
 
 func (p *playerAdapter) PayCost(c abil.Cost) error {
-	if !p.CanPayCost(c) {
-		return nil
+	if !p.CanPayCost(c) || !p.P.PayMana(c.ManaCost) {
+		return abil.ErrInvalidCost
 	}
 	if c.LifeCost > 0 {
 		p.P.SetLifeTotal(p.P.GetLifeTotal() - c.LifeCost)
@@ -174,6 +214,9 @@ func (b *AbilityGameState) GetActivePlayer() abil.AbilityPlayer {
 	return nil
 }
 
+// UnderlyingGame exposes the wrapped game to card scripts.
+func (b *AbilityGameState) UnderlyingGame() *game.Game { return b.G }
+
 func (b *AbilityGameState) IsMainPhase() bool          { return b.G.IsMainPhase() }
 func (b *AbilityGameState) IsCombatPhase() bool        { return b.G.IsCombatPhase() }
 func (b *AbilityGameState) CanActivateAbilities() bool { return true }
@@ -192,6 +235,8 @@ func (b *AbilityGameState) DealDamage(_ any, target any, amount int) {
 		b.G.ApplyDamageToPlayer(t, amount)
 	case *game.Permanent:
 		b.G.ApplyDamageToPermanent(t, amount)
+	case *permAdapter:
+		b.G.ApplyDamageToPermanent(t.P, amount)
 	}
 	b.G.ApplyStateBasedActions()
 }
@@ -210,7 +255,7 @@ func (b *AbilityGameState) GainLife(player abil.AbilityPlayer, amount int) {
 
 func (b *AbilityGameState) LoseLife(player abil.AbilityPlayer, amount int) {
 	if pa, ok := player.(*playerAdapter); ok {
-		pa.P.SetLifeTotal(pa.P.GetLifeTotal() - amount)
+		b.G.LoseLife(pa.P, amount)
 	}
 	b.G.ApplyStateBasedActions()
 }
@@ -222,107 +267,28 @@ func (b *AbilityGameState) DiscardCards(player abil.AbilityPlayer, count int) {
 }
 
 func (b *AbilityGameState) SearchLibrary(player abil.AbilityPlayer, count int) {
-	if pa, ok := player.(*playerAdapter); ok {
-		pa.P.SearchLibraryToHand(count)
-	}
+	b.SearchLibraryAdvanced(player, count, "")
 }
 
+// SearchLibraryAdvanced resolves a search effect from its oracle text: the
+// filter, destination, tapped-ness and reveal are read from description
+// and the chooser picks among the matching cards.
 func (b *AbilityGameState) SearchLibraryAdvanced(player abil.AbilityPlayer, count int, description string) {
-	if pa, ok := player.(*playerAdapter); ok {
-		searchLibrarySmart(pa.P, count, description, b.OnSearchResult)
-	}
-}
-
-func searchLibrarySmart(p *game.Player, count int, description string, onResult func(string)) {
-	desc := strings.ToLower(description)
-	targetBattlefield := strings.Contains(desc, "onto the battlefield") || strings.Contains(desc, "put it onto")
-
-	var filter func(game.SimpleCard) bool
-	switch {
-	case strings.Contains(desc, "creature card") || strings.Contains(desc, "creature spell"):
-		filter = func(c game.SimpleCard) bool { return c.IsCreature() }
-	case strings.Contains(desc, "artifact card"):
-		filter = func(c game.SimpleCard) bool { return c.IsArtifact() }
-	case strings.Contains(desc, "enchantment card"):
-		filter = func(c game.SimpleCard) bool { return c.IsEnchantment() }
-	case strings.Contains(desc, "instant card") || strings.Contains(desc, "instant spell"):
-		filter = func(c game.SimpleCard) bool { return c.IsInstant() }
-	case strings.Contains(desc, "sorcery card") || strings.Contains(desc, "sorcery spell"):
-		filter = func(c game.SimpleCard) bool { return c.IsSorcery() }
-	case strings.Contains(desc, "planeswalker card"):
-		filter = func(c game.SimpleCard) bool { return c.IsPlaneswalker() }
-	case strings.Contains(desc, "basic land"):
-		filter = func(c game.SimpleCard) bool {
-			return c.IsLand() && strings.Contains(strings.ToLower(c.TypeLine), "basic")
-		}
-	case strings.Contains(desc, "land card") || strings.Contains(desc, "island") || strings.Contains(desc, "swamp") ||
-		strings.Contains(desc, "mountain") || strings.Contains(desc, "forest") || strings.Contains(desc, "plains"):
-		filter = func(c game.SimpleCard) bool {
-			if !c.IsLand() {
-				return false
-			}
-			ct := strings.ToLower(c.TypeLine)
-			matchedAny := (strings.Contains(desc, "island") && strings.Contains(ct, "island")) ||
-				(strings.Contains(desc, "swamp") && strings.Contains(ct, "swamp")) ||
-				(strings.Contains(desc, "mountain") && strings.Contains(ct, "mountain")) ||
-				(strings.Contains(desc, "forest") && strings.Contains(ct, "forest"))
-			if strings.Contains(desc, "plains") && strings.Contains(ct, "plains") {
-				matchedAny = true
-			}
-			if !matchedAny && (strings.Contains(desc, "island") || strings.Contains(desc, "swamp") ||
-				strings.Contains(desc, "mountain") || strings.Contains(desc, "forest") || strings.Contains(desc, "plains")) {
-				return false
-			}
-			return true
-		}
-	case strings.Contains(desc, "card") && (strings.Contains(desc, "search") || strings.Contains(desc, "tutor") || strings.Contains(desc, "find")):
-		filter = func(c game.SimpleCard) bool { return true }
-	default:
-		searchLibrarySmartFallback(p, count, targetBattlefield)
+	pa, ok := player.(*playerAdapter)
+	if !ok {
 		return
 	}
-
-	found := 0
-	var remaining []game.SimpleCard
-	for _, c := range p.Library {
-		if found < count && filter(c) {
-			found++
-			if onResult != nil {
-				onResult(c.Name)
-			}
-			if targetBattlefield {
-				perm := game.NewPermanent(c, p, p)
-				p.Battlefield = append(p.Battlefield, perm)
-			} else {
-				p.Hand = append(p.Hand, c)
-			}
-		} else {
-			remaining = append(remaining, c)
-		}
-	}
-	p.Library = remaining
-}
-
-func searchLibrarySmartFallback(p *game.Player, count int, toBattlefield bool) {
-	if count > len(p.Library) {
-		count = len(p.Library)
-	}
-	found := make([]game.SimpleCard, count)
-	copy(found, p.Library[:count])
-	p.Library = p.Library[count:]
-	if toBattlefield {
-		for _, c := range found {
-			perm := game.NewPermanent(c, p, p)
-			p.Battlefield = append(p.Battlefield, perm)
+	res := b.G.SearchLibrary(pa.P, searchFromDescription(description, count), b.Chooser)
+	if b.OnSearchResult != nil {
+		for _, c := range res.Cards {
+			b.OnSearchResult(c.Name)
 		}
-	} else {
-		p.Hand = append(p.Hand, found...)
 	}
 }
 
 func (b *AbilityGameState) CreateToken(controller abil.AbilityPlayer, token game.SimpleCard) {
 	if pa, ok := controller.(*playerAdapter); ok {
-		pa.P.PutTokenOnBattlefield(token)
+		b.G.CreateToken(pa.P, token)
 	}
 }
 
@@ -350,27 +316,66 @@ func (b *AbilityGameState) ReanimateCreature(player abil.AbilityPlayer, card gam
 }
 
 func (b *AbilityGameState) ScryLibrary(player abil.AbilityPlayer, count int) {
-	if pa, ok := player.(*playerAdapter); ok {
-		if count > len(pa.P.Library) {
-			count = len(pa.P.Library)
-		}
-		if count == 0 {
-			return
-		}
-		var keep, bottom []game.SimpleCard
-		for i := 0; i < count; i++ {
-			c := pa.P.Library[i]
-			if c.IsLand() || c.IsCreature() {
-				keep = append(keep, c)
-			} else {
-				bottom = append(bottom, c)
+	b.ScryLibraryAdvanced(player, count, "")
+}
+
+// ScryLibraryAdvanced resolves scry, surveil and "look at the top N, put
+// some into your hand and the rest on the bottom" effects, letting the
+// chooser order the cards.
+func (b *AbilityGameState) ScryLibraryAdvanced(player abil.AbilityPlayer, count int, description string) {
+	pa, ok := player.(*playerAdapter)
+	if !ok {
+		return
+	}
+	l := lookFromDescription(description, count)
+	switch l.kind {
+	case "surveil":
+		pa.P.Surveil(l.n, b.Chooser)
+	case "dig":
+		taken, _ := pa.P.LookAtTop(l.n, l.take, l.filter, game.Hand, b.Chooser)
+		if b.OnSearchResult != nil {
+			for _, c := range taken {
+				b.OnSearchResult(c.Name)
 			}
 		}
-		rest := pa.P.Library[count:]
-		pa.P.Library = append(append(keep, bottom...), rest...)
+	default:
+		pa.P.Scry(l.n, b.Chooser)
+	}
+}
+
+// PayTax pays a generic mana tax from payer's pool when they can afford it
+// and the TaxDecider agrees.
+func (b *AbilityGameState) PayTax(payer abil.AbilityPlayer, amount int, effect abil.Effect) bool {
+	pa, ok := payer.(*playerAdapter)
+	if !ok {
+		return false
+	}
+	tax := game.SimpleCard{ManaCost: fmt.Sprintf("{%d}", amount)}
+	if !pa.P.CanPayForCard(tax) {
+		return false
+	}
+	if b.TaxDecider != nil && !b.TaxDecider.ShouldPayTax(payer, amount, effect) {
+		return false
+	}
+	return pa.P.PayForCard(tax)
+}
+
+// RecordSpellCast counts a spell cast on the ability stack toward the
+// game's per-turn spell count.
+func (b *AbilityGameState) RecordSpellCast(caster abil.AbilityPlayer) {
+	if pa, ok := caster.(*playerAdapter); ok && b.G != nil {
+		b.G.RecordSpellCast(pa.P)
 	}
 }
 
+// SpellsCastThisTurn returns the game's storm count.
+func (b *AbilityGameState) SpellsCastThisTurn() int {
+	if b.G == nil {
+		return 0
+	}
+	return b.G.SpellsCastThisTurn()
+}
+
 func (b *AbilityGameState) TakeExtraTurn() {
 	if b.G != nil {
 		b.G.TakeExtraTurn()
@@ -378,6 +383,12 @@ func (b *AbilityGameState) TakeExtraTurn() {
 }
 
 func (b *AbilityGameState) SacrificeSource(source any) {
+	if perm, ok := source.(interface{ Underlying() *game.Permanent }); ok {
+		if p := perm.Underlying(); b.G.SacrificePermanent(p) && b.OnActivate != nil {
+			b.OnActivate(p.GetName(), "sacrificed")
+		}
+		return
+	}
 	if srcCard, ok := source.(game.SimpleCard); ok {
 		for _, p := range b.G.GetPlayersRaw() {
 			for i, perm := range p.Battlefield {
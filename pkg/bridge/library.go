@@ -0,0 +1,176 @@
+package bridge
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+var (
+	searchForRe     = regexp.MustCompile(`(?i)search (?:your|their|his or her) library for (.+?) cards?\b`)
+	cardNamedRe     = regexp.MustCompile(`(?i)card named ([^,.]+)`)
+	maxManaValueRe  = regexp.MustCompile(`(?i)mana value (\d+) or less`)
+	exactManaValRe  = regexp.MustCompile(`(?i)mana value (\d+)\b`)
+	lookAtTopRe     = regexp.MustCompile(`(?i)look at the top (\w+) cards?`)
+	surveilRe       = regexp.MustCompile(`(?i)surveil (\w+)`)
+	takeFromTopRe   = regexp.MustCompile(`(?i)(?:put|reveal) (a|an|one|two|three|four|five|up to \w+|any number) (?:of them|(.+?) cards? from among them)`)
+	numberWords     = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "x": 0}
+	searchTypeWords = []string{"creature", "artifact", "enchantment", "instant", "sorcery", "planeswalker", "land", "equipment", "aura", "legendary"}
+	basicTypeWords  = []string{"Plains", "Island", "Swamp", "Mountain", "Forest"}
+)
+
+// parseCount reads a digit string or a small English number word.
+func parseCount(s string) (int, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	n, ok := numberWords[s]
+	return n, ok
+}
+
+// searchFromDescription translates oracle text such as "Search your library
+// for a Forest or Island card, put it onto the battlefield tapped, then
+// shuffle" into a game.LibrarySearch. count is the number of cards the
+// parsed effect asked for.
+func searchFromDescription(description string, count int) game.LibrarySearch {
+	desc := strings.ToLower(description)
+	s := game.LibrarySearch{Max: count, Dest: game.Hand, Shuffle: true}
+	if s.Max <= 0 {
+		s.Max = 1
+	}
+
+	switch {
+	case strings.Contains(desc, "onto the battlefield"):
+		s.Dest = game.Battlefield
+		s.Tapped = strings.Contains(desc, "battlefield tapped")
+	case strings.Contains(desc, "into your graveyard"):
+		s.Dest = game.Graveyard
+	case strings.Contains(desc, "on top of your library") || strings.Contains(desc, "card on top"):
+		s.Dest = game.Library
+	case strings.Contains(desc, "exile it") || strings.Contains(desc, "exile them") || strings.Contains(desc, "exile that card"):
+		s.Dest = game.Exile
+	}
+	s.Reveal = strings.Contains(desc, "reveal")
+
+	var filters []game.CardFilter
+	if m := cardNamedRe.FindStringSubmatch(description); m != nil {
+		filters = append(filters, game.ByName(strings.TrimSpace(m[1])))
+	} else if m := searchForRe.FindStringSubmatch(description); m != nil {
+		filters = append(filters, clauseFilter(m[1]))
+	}
+	if m := maxManaValueRe.FindStringSubmatch(desc); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		filters = append(filters, game.ByMaxManaValue(n))
+	} else if m := exactManaValRe.FindStringSubmatch(desc); m != nil && !strings.Contains(desc, "mana value "+m[1]+" or greater") {
+		n, _ := strconv.Atoi(m[1])
+		filters = append(filters, game.ByManaValue(n))
+	}
+	s.Filter = game.AllOf(filters...)
+	return s
+}
+
+// ParseLibrarySearch reads the search instruction of a tutor's oracle
+// text, for callers that resolve the search themselves. count is the
+// number of cards the search finds.
+func ParseLibrarySearch(oracleText string, count int) game.LibrarySearch {
+	return searchFromDescription(oracleText, count)
+}
+
+// clauseFilter interprets the noun phrase between "search your library for"
+// and "card", e.g. "a basic Forest", "an instant or sorcery", "up to two
+// basic land", "a nonland".
+func clauseFilter(clause string) game.CardFilter {
+	lower := strings.ToLower(clause)
+	var all []game.CardFilter
+	if strings.Contains(lower, "basic") {
+		all = append(all, game.IsBasicLand())
+	}
+	if strings.Contains(lower, "nonland") {
+		all = append(all, game.Not(game.ByType("Land")))
+	}
+	if strings.Contains(lower, "noncreature") {
+		all = append(all, game.Not(game.ByType("Creature")))
+	}
+
+	var basics []string
+	for _, t := range basicTypeWords {
+		if strings.Contains(lower, strings.ToLower(t)) {
+			basics = append(basics, t)
+		}
+	}
+	if len(basics) > 0 {
+		all = append(all, game.ByBasicLandType(basics...))
+	}
+
+	// "instant or sorcery" and "artifact or enchantment" are disjunctions;
+	// "legendary creature" is a conjunction.
+	var anyTypes []game.CardFilter
+	for _, part := range strings.Split(lower, " or ") {
+		var conj []game.CardFilter
+		for _, w := range strings.Fields(part) {
+			w = strings.Trim(w, ",")
+			for _, t := range searchTypeWords {
+				if w == t {
+					conj = append(conj, game.ByType(t))
+				}
+			}
+		}
+		if len(conj) > 0 {
+			anyTypes = append(anyTypes, game.AllOf(conj...))
+		}
+	}
+	if len(anyTypes) > 0 {
+		all = append(all, game.AnyOf(anyTypes...))
+	}
+	return game.AllOf(all...)
+}
+
+// libraryLook is the parsed shape of a scry / surveil / "look at the top N"
+// effect.
+type libraryLook struct {
+	kind   string // "scry", "surveil" or "dig"
+	n      int
+	take   int
+	filter game.CardFilter
+}
+
+func lookFromDescription(description string, count int) libraryLook {
+	desc := strings.ToLower(description)
+	l := libraryLook{kind: "scry", n: count}
+	if m := surveilRe.FindStringSubmatch(desc); m != nil {
+		l.kind = "surveil"
+		if n, ok := parseCount(m[1]); ok && n > 0 {
+			l.n = n
+		}
+		return l
+	}
+	m := lookAtTopRe.FindStringSubmatch(desc)
+	if m == nil {
+		return l
+	}
+	if n, ok := parseCount(m[1]); ok && n > 0 {
+		l.n = n
+	}
+	t := takeFromTopRe.FindStringSubmatch(desc)
+	if t == nil || !strings.Contains(desc, "hand") {
+		return l
+	}
+	l.kind = "dig"
+	amount := strings.TrimPrefix(t[1], "up to ")
+	switch {
+	case amount == "any number":
+		l.take = l.n
+	default:
+		l.take, _ = parseCount(amount)
+	}
+	if l.take <= 0 {
+		l.take = 1
+	}
+	if t[2] != "" {
+		l.filter = clauseFilter(t[2])
+	}
+	return l
+}
@@ -0,0 +1,81 @@
+package bridge
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+func TestSearchFromDescription_Fetchland(t *testing.T) {
+	s := searchFromDescription("{T}, Pay 1 life, Sacrifice Flooded Strand: Search your library for a Plains or Island card, put it onto the battlefield, then shuffle.", 1)
+	if s.Dest != game.Battlefield || s.Tapped {
+		t.Fatalf("expected untapped battlefield destination, got %v tapped=%v", s.Dest, s.Tapped)
+	}
+	if !s.Filter(game.SimpleCard{Name: "Hallowed Fountain", TypeLine: "Land — Plains Island"}) {
+		t.Fatalf("expected typed dual to match")
+	}
+	if s.Filter(game.SimpleCard{Name: "Swamp", TypeLine: "Basic Land — Swamp"}) {
+		t.Fatalf("Swamp should not match a Plains or Island search")
+	}
+}
+
+func TestSearchFromDescription_Tutors(t *testing.T) {
+	s := searchFromDescription("Search your library for a card, then shuffle and put that card on top.", 1)
+	if s.Dest != game.Library {
+		t.Fatalf("Vampiric Tutor should put the card on top, got %v", s.Dest)
+	}
+	s = searchFromDescription("Search your library for a basic land card, put it onto the battlefield tapped, then shuffle.", 1)
+	if s.Dest != game.Battlefield || !s.Tapped {
+		t.Fatalf("expected tapped battlefield destination")
+	}
+	if s.Filter(game.SimpleCard{Name: "Watery Grave", TypeLine: "Land — Island Swamp"}) {
+		t.Fatalf("nonbasic should not match a basic land search")
+	}
+	s = searchFromDescription("Search your library for an instant or sorcery card, reveal it, put it into your hand, then shuffle.", 1)
+	if !s.Reveal || s.Dest != game.Hand {
+		t.Fatalf("expected revealed search to hand")
+	}
+	if !s.Filter(game.SimpleCard{TypeLine: "Sorcery"}) || s.Filter(game.SimpleCard{TypeLine: "Creature — Elf"}) {
+		t.Fatalf("instant-or-sorcery filter mismatch")
+	}
+	s = searchFromDescription("Search your library for a creature card with mana value 2 or less, put it onto the battlefield, then shuffle.", 1)
+	if s.Filter(game.SimpleCard{TypeLine: "Creature — Beast", ManaCost: "{5}{G}{G}{G}"}) {
+		t.Fatalf("mana value restriction ignored")
+	}
+}
+
+func TestSearchLibraryAdvanced_FindsRealCard(t *testing.T) {
+	p1 := game.NewPlayer("P1", 20)
+	p2 := game.NewPlayer("P2", 20)
+	g := game.NewGame(p1, p2)
+	p1.Library = []game.SimpleCard{
+		{Name: "Mountain", TypeLine: "Basic Land — Mountain"},
+		{Name: "Ponder", TypeLine: "Sorcery", ManaCost: "{U}"},
+		{Name: "Island", TypeLine: "Basic Land — Island"},
+	}
+	gs := NewAbilityGameState(g)
+	var found []string
+	gs.OnSearchResult = func(name string) { found = append(found, name) }
+	gs.SearchLibraryAdvanced(gs.GetPlayer("P1"), 1, "Search your library for an Island card, put it onto the battlefield tapped, then shuffle.")
+	if len(found) != 1 || found[0] != "Island" {
+		t.Fatalf("expected to find Island, got %v", found)
+	}
+	if len(p1.Battlefield) != 1 || !p1.Battlefield[0].IsTapped() {
+		t.Fatalf("expected tapped Island on battlefield")
+	}
+}
+
+func TestScryLibraryAdvanced_Dig(t *testing.T) {
+	p1 := game.NewPlayer("P1", 20)
+	g := game.NewGame(p1, game.NewPlayer("P2", 20))
+	p1.Library = []game.SimpleCard{
+		{Name: "Forest", TypeLine: "Basic Land — Forest"},
+		{Name: "Llanowar Elves", TypeLine: "Creature — Elf Druid", ManaCost: "{G}"},
+		{Name: "Ponder", TypeLine: "Sorcery", ManaCost: "{U}"},
+	}
+	gs := NewAbilityGameState(g)
+	gs.ScryLibraryAdvanced(gs.GetPlayer("P1"), 0, "Look at the top three cards of your library. You may reveal a creature card from among them and put it into your hand. Put the rest on the bottom of your library in any order.")
+	if p1.FindCardInHand("Llanowar Elves") < 0 || len(p1.Library) != 2 {
+		t.Fatalf("expected Llanowar Elves taken and two cards bottomed; hand=%v library=%v", p1.Hand, p1.Library)
+	}
+}
@@ -0,0 +1,100 @@
+package card
+
+import (
+	"strings"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Archetype is the way a deck plays to win, which sets how the AI pilots
+// it: how hard it attacks, how much interaction it holds up, what its
+// tutors find and when it goes for the win.
+type Archetype string
+
+const (
+	ArchetypeAggro    Archetype = "aggro"
+	ArchetypeMidrange Archetype = "midrange"
+	ArchetypeControl  Archetype = "control"
+	ArchetypeStax     Archetype = "stax"
+	ArchetypeTurbo    Archetype = "turbo"
+)
+
+// Archetypes lists every archetype.
+var Archetypes = []Archetype{ArchetypeAggro, ArchetypeMidrange, ArchetypeControl, ArchetypeStax, ArchetypeTurbo}
+
+// ParseArchetype reads an archetype name as a deck header or a flag
+// writes it: case, spaces and dashes don't matter, and "combo" and "turbo
+// combo" mean ArchetypeTurbo.
+func ParseArchetype(s string) (Archetype, bool) {
+	norm := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+	switch norm {
+	case "combo", "turbocombo":
+		return ArchetypeTurbo, true
+	}
+	for _, a := range Archetypes {
+		if string(a) == norm {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+// Shares of a deck's nonland cards at which InferArchetype picks an
+// archetype, checked in the order listed.
+const (
+	// staxShare of stax pieces makes a stax deck.
+	staxShare = 0.10
+	// turboShare of tutors and fast mana makes a turbo combo deck.
+	turboShare = 0.18
+	// controlShare of counterspells, removal and board wipes makes a
+	// control deck, if creatures stay under controlCreatureShare.
+	controlShare         = 0.25
+	controlCreatureShare = 0.25
+	// aggroCreatureShare of creatures with a mana value averaging at
+	// most aggroManaValue makes an aggro deck.
+	aggroCreatureShare = 0.45
+	aggroManaValue     = 3.2
+)
+
+// InferArchetype guesses a deck's archetype from the roles of its cards
+// (ClassifyRoles): stax pieces, then tutors and fast mana, then
+// interaction, then a low curve of creatures. A deck that leans no way is
+// midrange.
+func InferArchetype(cards []game.SimpleCard) Archetype {
+	nonland, stax, turbo, interaction, creatures, manaValue := 0, 0, 0, 0, 0, 0
+	for _, c := range cards {
+		if c.IsLand() {
+			continue
+		}
+		nonland++
+		manaValue += c.ManaValue()
+		if c.IsCreature() {
+			creatures++
+		}
+		roles := ClassifyRoles(c)
+		if roles.Has(RoleStax) {
+			stax++
+		}
+		if roles.HasAny(RoleTutor, RoleFastMana) {
+			turbo++
+		}
+		if roles.HasAny(RoleCounterspell, RoleRemoval, RoleBoardWipe) {
+			interaction++
+		}
+	}
+	if nonland == 0 {
+		return ArchetypeMidrange
+	}
+	share := func(n int) float64 { return float64(n) / float64(nonland) }
+	switch {
+	case share(stax) >= staxShare:
+		return ArchetypeStax
+	case share(turbo) >= turboShare:
+		return ArchetypeTurbo
+	case share(interaction) >= controlShare && share(creatures) < controlCreatureShare:
+		return ArchetypeControl
+	case share(creatures) >= aggroCreatureShare && float64(manaValue)/float64(nonland) <= aggroManaValue:
+		return ArchetypeAggro
+	}
+	return ArchetypeMidrange
+}
@@ -0,0 +1,62 @@
+package card
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// copies is n copies of a card in a test deck.
+type copies struct {
+	c game.SimpleCard
+	n int
+}
+
+func deckOf(parts ...copies) []game.SimpleCard {
+	var out []game.SimpleCard
+	for _, p := range parts {
+		for i := 0; i < p.n; i++ {
+			out = append(out, p.c)
+		}
+	}
+	return out
+}
+
+func TestInferArchetype(t *testing.T) {
+	forest := game.SimpleCard{Name: "Forest", TypeLine: "Basic Land — Forest"}
+	bears := game.SimpleCard{Name: "Grizzly Bears", TypeLine: "Creature — Bear", ManaCost: "{1}{G}"}
+	giant := game.SimpleCard{Name: "Hill Giant", TypeLine: "Creature — Giant", ManaCost: "{3}{R}"}
+	thalia := game.SimpleCard{Name: "Thalia, Guardian of Thraben", TypeLine: "Legendary Creature — Human Soldier", ManaCost: "{1}{W}", OracleText: "First strike\nNoncreature spells cost {1} more to cast."}
+	tutor := game.SimpleCard{Name: "Demonic Tutor", TypeLine: "Sorcery", ManaCost: "{1}{B}", OracleText: "Search your library for a card, put that card into your hand, then shuffle."}
+	counter := game.SimpleCard{Name: "Counterspell", TypeLine: "Instant", ManaCost: "{U}{U}", OracleText: "Counter target spell."}
+	divination := game.SimpleCard{Name: "Divination", TypeLine: "Sorcery", ManaCost: "{2}{U}", OracleText: "Draw two cards."}
+
+	tests := []struct {
+		name string
+		deck []copies
+		want Archetype
+	}{
+		{"stax pieces", []copies{{forest, 37}, {thalia, 8}, {giant, 55}}, ArchetypeStax},
+		{"tutors", []copies{{forest, 30}, {tutor, 14}, {giant, 56}}, ArchetypeTurbo},
+		{"counters", []copies{{forest, 37}, {counter, 20}, {divination, 30}, {giant, 13}}, ArchetypeControl},
+		{"cheap creatures", []copies{{forest, 37}, {bears, 40}, {divination, 23}}, ArchetypeAggro},
+		{"a bit of everything", []copies{{forest, 37}, {giant, 20}, {divination, 30}, {counter, 5}, {tutor, 5}, {thalia, 3}}, ArchetypeMidrange},
+		{"lands only", []copies{{forest, 40}}, ArchetypeMidrange},
+	}
+	for _, tt := range tests {
+		if got := InferArchetype(deckOf(tt.deck...)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseArchetype(t *testing.T) {
+	for in, want := range map[string]Archetype{"Stax": ArchetypeStax, "turbo combo": ArchetypeTurbo, "Turbo-Combo": ArchetypeTurbo, "combo": ArchetypeTurbo, " control ": ArchetypeControl} {
+		if got, ok := ParseArchetype(in); !ok || got != want {
+			t.Errorf("ParseArchetype(%q) = %q, %v, want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseArchetype("burn"); ok {
+		t.Error("unknown archetypes should not parse")
+	}
+}
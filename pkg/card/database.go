@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 const (
@@ -19,6 +20,8 @@ const (
 // CardDB represents a database of Magic: The Gathering cards.
 type CardDB struct {
 	cards map[string]Card
+
+	roles sync.Map // roleKey -> Roles, classified lazily by RolesOf
 }
 
 // NewCardDB creates a new card database from a slice of cards.
@@ -105,6 +108,24 @@ func LoadCardDatabase() (*CardDB, error) {
 	return cardDB, nil
 }
 
+// LoadCardDatabaseFile loads a card database from a local JSON file without
+// falling back to a download.
+func LoadCardDatabaseFile(path string) (*CardDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cards []Card
+	if err := json.Unmarshal(data, &cards); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	cardDB := NewCardDB(cards)
+	if cardDB == nil {
+		return nil, fmt.Errorf("%s contains no cards", path)
+	}
+	return cardDB, nil
+}
+
 // downloadAndParseJSON downloads card data from the given URL and parses it.
 // Uses a proper User-Agent header as required by Scryfall's API policy.
 func downloadAndParseJSON(url string) ([]Card, error) {
@@ -1,6 +1,7 @@
 package card
 
 import (
+	"os"
 	"strings"
 	"testing"
 
@@ -183,3 +184,21 @@ func TestCardDatabase(t *testing.T) {
 		t.Errorf("Expected 'NonExistentCard' not to exist in database")
 	}
 }
+
+func TestLoadCardDatabaseFile(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/cards.json"
+	if err := os.WriteFile(path, []byte(`[{"name": "Sol Ring", "type_line": "Artifact"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadCardDatabaseFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := db.GetCardByName("Sol Ring"); !ok || db.Size() != 1 {
+		t.Fatalf("expected Sol Ring in a one-card database")
+	}
+	if _, err := LoadCardDatabaseFile(dir + "/missing.json"); err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
@@ -10,6 +10,12 @@ type ImplementationEvaluator interface {
 	EvaluateCard(c Card) (implemented bool, reason string)
 }
 
+// ScriptedEvaluator is implemented by evaluators that can tell hand-written
+// card implementations apart from parsed ones.
+type ScriptedEvaluator interface {
+	IsScripted(c Card) bool
+}
+
 // Bucket holds aggregate counts for one group (color, set, or type).
 type Bucket struct {
 	Name        string  `json:"name"`
@@ -37,6 +43,7 @@ type FailureBucket struct {
 type ImplementationReport struct {
 	TotalCards         int                 `json:"total_cards"`
 	ImplementedCount   int                 `json:"implemented_count"`
+	ScriptedCount      int                 `json:"scripted_count"` // implemented by hand-written scripts; included in ImplementedCount
 	UnimplementedCount int                 `json:"unimplemented_count"`
 	Percentage         float64             `json:"percentage"`
 	ByColor            []Bucket            `json:"by_color"`
@@ -74,12 +81,16 @@ func ComputeImplementationStatus(db *CardDB, evaluator ImplementationEvaluator)
 	setCounts := map[string]int{}
 	typeBuckets := map[string]*Bucket{}
 	failureCounts := map[string]int{}
+	scripted, _ := evaluator.(ScriptedEvaluator)
 
 	for _, c := range all {
 		impl, reason := evaluator.EvaluateCard(c)
 		report.TotalCards++
 		if impl {
 			report.ImplementedCount++
+			if scripted != nil && scripted.IsScripted(c) {
+				report.ScriptedCount++
+			}
 		} else {
 			report.UnimplementedCount++
 			report.UnimplementedCards = append(report.UnimplementedCards, UnimplementedCard{
@@ -83,3 +83,31 @@ func TestComputeImplementationStatus(t *testing.T) {
 		t.Errorf("Sorcery bucket wrong: %+v", typeMap["Sorcery"])
 	}
 }
+
+type mockScriptedEvaluator struct {
+	mockEvaluator
+	scripted map[string]bool
+}
+
+func (m *mockScriptedEvaluator) IsScripted(c Card) bool {
+	return m.scripted[c.Name]
+}
+
+func TestComputeImplementationStatus_ScriptedCount(t *testing.T) {
+	db := NewCardDB([]Card{
+		{Name: "Lightning Bolt", TypeLine: "Instant", ColorIdentity: []string{"R"}, Set: "lea"},
+		{Name: "Necropotence", TypeLine: "Enchantment", ColorIdentity: []string{"B"}, Set: "ice"},
+	})
+	m := &mockScriptedEvaluator{
+		mockEvaluator: mockEvaluator{results: map[string]bool{"Lightning Bolt": true, "Necropotence": true}},
+		scripted:      map[string]bool{"Necropotence": true},
+	}
+
+	report, err := ComputeImplementationStatus(db, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.ImplementedCount != 2 || report.ScriptedCount != 1 {
+		t.Errorf("expected 2 implemented with 1 scripted, got %d and %d", report.ImplementedCount, report.ScriptedCount)
+	}
+}
@@ -0,0 +1,335 @@
+package card
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mtgsim/mtgsim/pkg/ability/oracle"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Role is a job a card does in a deck, as the AI and the dashboard think
+// of it. A card can have several roles or none.
+type Role string
+
+const (
+	RoleRamp         Role = "ramp"
+	RoleFastMana     Role = "fast_mana"
+	RoleTutor        Role = "tutor"
+	RoleCardDraw     Role = "card_draw"
+	RoleDrawEngine   Role = "draw_engine"
+	RoleCounterspell Role = "counterspell"
+	RoleRemoval      Role = "removal"
+	RoleBoardWipe    Role = "board_wipe"
+	RoleStax         Role = "stax"
+	RoleWincon       Role = "wincon"
+	RoleProtection   Role = "protection"
+)
+
+// allRoles is every role in the order Roles lists them.
+var allRoles = []Role{
+	RoleRamp, RoleFastMana, RoleTutor, RoleCardDraw, RoleDrawEngine, RoleCounterspell,
+	RoleRemoval, RoleBoardWipe, RoleStax, RoleWincon, RoleProtection,
+}
+
+// Roles is the set of roles of one card.
+type Roles []Role
+
+// Has reports whether r includes role.
+func (r Roles) Has(role Role) bool {
+	for _, x := range r {
+		if x == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAny reports whether r includes any of roles.
+func (r Roles) HasAny(roles ...Role) bool {
+	for _, role := range roles {
+		if r.Has(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Roles classifies the card. See ClassifyRoles.
+func (c *Card) Roles() Roles {
+	return ClassifyRoles(game.SimpleCard{Name: c.Name, TypeLine: c.TypeLine, OracleText: c.OracleText, ManaCost: c.ManaCost})
+}
+
+// Roles returns the roles of the card named name, or nil if the
+// database doesn't have it.
+func (db *CardDB) Roles(name string) Roles {
+	c, ok := db.cards[name]
+	if !ok {
+		return nil
+	}
+	return db.RolesOf(game.SimpleCard{Name: c.Name, TypeLine: c.TypeLine, OracleText: c.OracleText, ManaCost: c.ManaCost})
+}
+
+// roleKey is what a card's roles depend on.
+type roleKey struct {
+	name, typeLine, oracleText, manaCost string
+}
+
+// RolesOf returns c's roles, classifying each card once per database so
+// the AI can ask about the same card on every decision.
+func (db *CardDB) RolesOf(c game.SimpleCard) Roles {
+	key := roleKey{c.Name, c.TypeLine, c.OracleText, c.ManaCost}
+	if r, ok := db.roles.Load(key); ok {
+		return r.(Roles)
+	}
+	r, _ := db.roles.LoadOrStore(key, classify(c))
+	return r.(Roles)
+}
+
+// looseCards caches the roles of cards classified outside a loaded
+// database, such as the simulation's decks.
+var looseCards = &CardDB{}
+
+// ClassifyRoles tags c with its roles from its type line and its oracle
+// text, parsed with the oracle grammar. Lines the grammar can't parse
+// fall back to matching the text.
+func ClassifyRoles(c game.SimpleCard) Roles {
+	return looseCards.RolesOf(c)
+}
+
+func classify(c game.SimpleCard) Roles {
+	rc := &roleClassifier{
+		c:         c,
+		land:      c.IsLand(),
+		spell:     c.IsInstant() || c.IsSorcery(),
+		manaValue: c.ManaValue(),
+		found:     map[Role]bool{},
+	}
+	doc := oracle.Parse(c.OracleText, c.Name)
+	for _, b := range doc.Blocks {
+		if b.Ability != nil {
+			rc.ability(b.Ability)
+		} else {
+			rc.text(rc.spanText(b.Span))
+		}
+	}
+	lower := strings.ToLower(c.OracleText)
+	if !rc.land && !rc.spell && (staxRe.MatchString(lower) || staxUntapRe.MatchString(lower)) {
+		rc.found[RoleStax] = true
+	}
+	if rc.found[RoleRamp] && rc.manaValue <= 1 && !rc.land {
+		rc.found[RoleFastMana] = true
+	}
+	var out Roles
+	for _, role := range allRoles {
+		if rc.found[role] {
+			out = append(out, role)
+		}
+	}
+	return out
+}
+
+// roleClassifier accumulates the roles of one card.
+type roleClassifier struct {
+	c         game.SimpleCard
+	land      bool
+	spell     bool // instant or sorcery
+	manaValue int
+	found     map[Role]bool
+}
+
+// protectionKeywords are the keywords that keep a permanent from being
+// targeted or destroyed when another card grants them.
+var protectionKeywords = []string{"hexproof", "indestructible", "shroud", "protection", "ward"}
+
+func (rc *roleClassifier) ability(a *oracle.Ability) {
+	for _, kw := range a.Keywords {
+		if strings.EqualFold(kw.Name, "storm") {
+			rc.found[RoleWincon] = true
+		}
+	}
+	effects := a.Effects
+	if a.Modes != nil {
+		for _, opt := range a.Modes.Options {
+			effects = append(effects, opt...)
+		}
+	}
+	for _, e := range effects {
+		rc.effect(a, e)
+	}
+}
+
+func (rc *roleClassifier) effect(a *oracle.Ability, e *oracle.Effect) {
+	switch e.Verb {
+	case oracle.VerbAddMana, oracle.VerbAdditionalLand:
+		if !rc.land {
+			rc.found[RoleRamp] = true
+		}
+	case oracle.VerbSearch:
+		if e.Object.Is("land") || strings.Contains(rc.spanText(e.Span), " land") {
+			if !rc.land {
+				rc.found[RoleRamp] = true
+			}
+		} else {
+			rc.found[RoleTutor] = true
+		}
+	case oracle.VerbDraw:
+		if rc.isEngine(a) {
+			rc.found[RoleDrawEngine] = true
+		} else {
+			rc.found[RoleCardDraw] = true
+		}
+	case oracle.VerbCounter:
+		rc.found[RoleCounterspell] = true
+	case oracle.VerbDestroy, oracle.VerbExile, oracle.VerbReturnToHand, oracle.VerbDamage:
+		rc.removal(e.Object)
+	case oracle.VerbPump:
+		if o := subjectOrObject(e); e.Power < 0 && o != nil && o.Each && o.Is("creature") {
+			rc.found[RoleBoardWipe] = true
+		}
+	case oracle.VerbWin, oracle.VerbExtraTurn:
+		rc.found[RoleWincon] = true
+	case oracle.VerbLose:
+		if e.Subject.Is("opponent") || hasQualifier(e.Subject, "opponent") {
+			rc.found[RoleWincon] = true
+		}
+	case oracle.VerbGainKeyword:
+		if o := subjectOrObject(e); o != nil && o.Self {
+			return
+		}
+		for _, kw := range e.Keywords {
+			for _, p := range protectionKeywords {
+				if strings.HasPrefix(strings.ToLower(kw), p) {
+					rc.found[RoleProtection] = true
+				}
+			}
+		}
+	}
+}
+
+// subjectOrObject is what a pump or keyword effect applies to: the
+// grammar puts "All creatures get -1/-1" in the subject.
+func subjectOrObject(e *oracle.Effect) *oracle.Object {
+	if e.Subject != nil {
+		return e.Subject
+	}
+	return e.Object
+}
+
+func (rc *roleClassifier) spanText(s oracle.Span) string {
+	return strings.ToLower(s.Text(rc.c.OracleText))
+}
+
+// isEngine reports whether a draws cards again and again: a triggered or
+// activated ability of a permanent, other than one that triggers when the
+// permanent itself enters.
+func (rc *roleClassifier) isEngine(a *oracle.Ability) bool {
+	if rc.spell {
+		return false
+	}
+	switch a.Kind {
+	case oracle.KindActivated, oracle.KindStatic:
+		return true
+	case oracle.KindTriggered:
+		return a.Trigger == nil || a.Trigger.Event != oracle.EventEnters || a.Trigger.Subject == nil || !a.Trigger.Subject.Self
+	}
+	return false
+}
+
+// removalTypes are the objects worth spending removal on.
+var removalTypes = []string{"creature", "artifact", "enchantment", "planeswalker", "permanent", "battle"}
+
+// removal records o as the object of a destroy, exile, bounce or damage
+// effect: a wipe if it is each such permanent, removal if it is a target.
+func (rc *roleClassifier) removal(o *oracle.Object) {
+	if o == nil || o.Self || o.Ref != "" {
+		return
+	}
+	if o.Any {
+		rc.found[RoleRemoval] = true
+		return
+	}
+	permanent := false
+	for _, t := range removalTypes {
+		if o.Is(t) {
+			permanent = true
+		}
+	}
+	if !permanent || hasQualifier(o, "you control") {
+		return
+	}
+	switch {
+	case o.Each:
+		rc.found[RoleBoardWipe] = true
+	case o.Target:
+		rc.found[RoleRemoval] = true
+	}
+}
+
+func hasQualifier(o *oracle.Object, q string) bool {
+	if o == nil {
+		return false
+	}
+	for _, x := range o.Qualifiers {
+		if strings.Contains(x, q) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	staxRe = regexp.MustCompile(`costs? \{\d+\} more|can't cast|can't be cast|can't activate|can't be activated|can't search|can't draw more than|can't cast more than`)
+	// staxUntapRe only counts untap and enters-tapped clauses that hold
+	// other players back, not a card's own drawback like Colossus of
+	// Sardia's or a tapland's.
+	staxUntapRe = regexp.MustCompile(`(opponents?|each player|players) [^.]*(don't untap|doesn't untap|can't untap|enters? (the battlefield )?tapped)|(don't|doesn't) untap during (their|its) controllers?'?s? untap steps?`)
+
+	textWipeRe      = regexp.MustCompile(`(destroy|exile) (all|each) (nonland )?(creature|artifact|enchantment|permanent|planeswalker)|return (all|each) .*to (its|their) owners?'s? hands?|damage to each creature|all creatures get -`)
+	textRemovalRe   = regexp.MustCompile(`(destroy|exile) (up to (one|two) )?(another )?target (\w+ )?(creature|artifact|enchantment|planeswalker|permanent)|damage to (any target|target creature)|return target (nonland )?(creature|permanent) to its owner's hand`)
+	textDrawRe      = regexp.MustCompile(`\bdraws? (a|an|one|two|three|four|five|x|\d+|that many|cards|additional)\b`)
+	textEngineRe    = regexp.MustCompile(`^(whenever|at the beginning|[^.]*:)`)
+	textSearchRe    = regexp.MustCompile(`search (your|their) library for ([^.]*)`)
+	textRampRe      = regexp.MustCompile(`\badd (\{|one mana|two mana|three mana|x mana|mana|an amount)`)
+	textWinconRe    = regexp.MustCompile(`you win the game|(each opponent|that player|target player) loses the game|take an extra turn|\bstorm\b`)
+	textProtectRe   = regexp.MustCompile(`(gain|gains|have|has) [^.]*(hexproof|indestructible|shroud|protection from)|phases? out|change the target|spells you control can't be countered`)
+	textCounterRe   = regexp.MustCompile(`counter target`)
+	textSelfEnterRe = regexp.MustCompile(`^when [^,]* enters`)
+)
+
+// text classifies a line of oracle text the grammar could not parse.
+func (rc *roleClassifier) text(line string) {
+	if textCounterRe.MatchString(line) {
+		rc.found[RoleCounterspell] = true
+	}
+	if m := textSearchRe.FindStringSubmatch(line); m != nil {
+		if strings.Contains(m[2], "land") {
+			if !rc.land {
+				rc.found[RoleRamp] = true
+			}
+		} else {
+			rc.found[RoleTutor] = true
+		}
+	}
+	if !rc.land && textRampRe.MatchString(line) {
+		rc.found[RoleRamp] = true
+	}
+	if textDrawRe.MatchString(line) {
+		if !rc.spell && textEngineRe.MatchString(line) && !textSelfEnterRe.MatchString(line) {
+			rc.found[RoleDrawEngine] = true
+		} else {
+			rc.found[RoleCardDraw] = true
+		}
+	}
+	if textWipeRe.MatchString(line) {
+		rc.found[RoleBoardWipe] = true
+	} else if textRemovalRe.MatchString(line) {
+		rc.found[RoleRemoval] = true
+	}
+	if textWinconRe.MatchString(line) {
+		rc.found[RoleWincon] = true
+	}
+	if textProtectRe.MatchString(line) {
+		rc.found[RoleProtection] = true
+	}
+}
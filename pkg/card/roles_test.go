@@ -0,0 +1,72 @@
+package card
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+func TestClassifyRoles(t *testing.T) {
+	tests := []struct {
+		card game.SimpleCard
+		want Roles
+	}{
+		{game.SimpleCard{Name: "Sol Ring", TypeLine: "Artifact", ManaCost: "{1}", OracleText: "{T}: Add {C}{C}."}, Roles{RoleRamp, RoleFastMana}},
+		{game.SimpleCard{Name: "Cultivate", TypeLine: "Sorcery", ManaCost: "{2}{G}", OracleText: "Search your library for up to two basic land cards, reveal those cards, put one onto the battlefield tapped and the other into your hand, then shuffle."}, Roles{RoleRamp}},
+		{game.SimpleCard{Name: "Demonic Tutor", TypeLine: "Sorcery", ManaCost: "{1}{B}", OracleText: "Search your library for a card, put that card into your hand, then shuffle."}, Roles{RoleTutor}},
+		{game.SimpleCard{Name: "Rhystic Study", TypeLine: "Enchantment", ManaCost: "{2}{U}", OracleText: "Whenever an opponent casts a spell, you may draw a card unless that player pays {1}."}, Roles{RoleDrawEngine}},
+		{game.SimpleCard{Name: "Elvish Visionary", TypeLine: "Creature — Elf Shaman", ManaCost: "{1}{G}", OracleText: "When Elvish Visionary enters, draw a card."}, Roles{RoleCardDraw}},
+		{game.SimpleCard{Name: "Counterspell", TypeLine: "Instant", ManaCost: "{U}{U}", OracleText: "Counter target spell."}, Roles{RoleCounterspell}},
+		{game.SimpleCard{Name: "Swords to Plowshares", TypeLine: "Instant", ManaCost: "{W}", OracleText: "Exile target creature. Its controller gains life equal to its power."}, Roles{RoleRemoval}},
+		{game.SimpleCard{Name: "Wrath of God", TypeLine: "Sorcery", ManaCost: "{2}{W}{W}", OracleText: "Destroy all creatures. They can't be regenerated."}, Roles{RoleBoardWipe}},
+		{game.SimpleCard{Name: "Toxic Deluge", TypeLine: "Sorcery", ManaCost: "{2}{B}", OracleText: "As an additional cost to cast this spell, pay X life.\nAll creatures get -X/-X until end of turn."}, Roles{RoleBoardWipe}},
+		{game.SimpleCard{Name: "Thalia, Guardian of Thraben", TypeLine: "Legendary Creature — Human Soldier", ManaCost: "{1}{W}", OracleText: "First strike\nNoncreature spells cost {1} more to cast."}, Roles{RoleStax}},
+		{game.SimpleCard{Name: "Thassa's Oracle", TypeLine: "Creature — Merfolk Wizard", ManaCost: "{U}{U}", OracleText: "When Thassa's Oracle enters, look at the top X cards of your library, where X is your devotion to blue. Put up to one of them on top of your library and the rest on the bottom of your library in a random order. If X is greater than or equal to the number of cards in your library, you win the game."}, Roles{RoleWincon}},
+		{game.SimpleCard{Name: "Brain Freeze", TypeLine: "Instant", ManaCost: "{1}{U}", OracleText: "Target player mills three cards.\nStorm"}, Roles{RoleWincon}},
+		{game.SimpleCard{Name: "Heroic Intervention", TypeLine: "Instant", ManaCost: "{1}{G}", OracleText: "Permanents you control gain hexproof and indestructible until end of turn."}, Roles{RoleProtection}},
+		{game.SimpleCard{Name: "Forest", TypeLine: "Basic Land — Forest"}, nil},
+		{game.SimpleCard{Name: "Grizzly Bears", TypeLine: "Creature — Bear", ManaCost: "{1}{G}"}, nil},
+	}
+	for _, tt := range tests {
+		if got := ClassifyRoles(tt.card); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.card.Name, got, tt.want)
+		}
+	}
+}
+
+func TestCardDBRoles(t *testing.T) {
+	db := NewCardDB([]Card{{Name: "Counterspell", TypeLine: "Instant", ManaCost: "{U}{U}", OracleText: "Counter target spell."}})
+	if !db.Roles("Counterspell").Has(RoleCounterspell) {
+		t.Fatalf("expected Counterspell to be a counterspell, got %v", db.Roles("Counterspell"))
+	}
+	if db.Roles("Unknown Card") != nil {
+		t.Fatal("cards outside the database have no roles")
+	}
+}
+
+func TestClassifyRoles_StaxComesFromTheText(t *testing.T) {
+	// Drannith Magistrate is a registered lock piece; without its text
+	// it's only a creature.
+	if got := ClassifyRoles(game.SimpleCard{Name: "Drannith Magistrate", TypeLine: "Creature — Human Wizard", ManaCost: "{1}{W}"}); got.Has(RoleStax) {
+		t.Fatalf("stax should come from the card's text, got %v", got)
+	}
+}
+
+func TestClassifyRoles_UntapLocksMustHoldOthersBack(t *testing.T) {
+	tests := []struct {
+		card game.SimpleCard
+		stax bool
+	}{
+		{game.SimpleCard{Name: "Colossus of Sardia", TypeLine: "Artifact Creature — Golem", ManaCost: "{9}", OracleText: "Trample\nColossus of Sardia doesn't untap during your untap step.\n{9}: Untap Colossus of Sardia. Activate only during your upkeep."}, false},
+		{game.SimpleCard{Name: "Grim Monolith", TypeLine: "Artifact", ManaCost: "{2}", OracleText: "Grim Monolith doesn't untap during your untap step.\n{T}: Add {C}{C}{C}.\n{4}: Untap Grim Monolith."}, false},
+		{game.SimpleCard{Name: "Winter Orb", TypeLine: "Artifact", ManaCost: "{2}", OracleText: "As long as Winter Orb is untapped, players can't untap more than one land during their untap steps."}, true},
+		{game.SimpleCard{Name: "Back to Basics", TypeLine: "Enchantment", ManaCost: "{2}{U}", OracleText: "Nonbasic lands don't untap during their controllers' untap steps."}, true},
+		{game.SimpleCard{Name: "Blind Obedience", TypeLine: "Enchantment", ManaCost: "{1}{W}", OracleText: "Extort\nArtifacts and creatures your opponents control enter tapped."}, true},
+	}
+	for _, tt := range tests {
+		if got := ClassifyRoles(tt.card).Has(RoleStax); got != tt.stax {
+			t.Errorf("%s: stax = %v, want %v", tt.card.Name, got, tt.stax)
+		}
+	}
+}
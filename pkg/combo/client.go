@@ -59,6 +59,8 @@ type Variant struct {
 	Identity    string          `json:"identity"`
 	ManaNeeded  string          `json:"manaNeeded"`
 	Description string          `json:"description"`
+	EasyPrerequisites    string `json:"easyPrerequisites"`
+	NotablePrerequisites string `json:"notablePrerequisites"`
 	Notes       string          `json:"notes"`
 	Popularity  *int            `json:"popularity"`
 	Spoiler     bool            `json:"spoiler"`
@@ -72,6 +74,7 @@ type CardInVariant struct {
 	ExileCardState       string    `json:"exileCardState"`
 	LibraryCardState     string    `json:"libraryCardState"`
 	GraveyardCardState   string    `json:"graveyardCardState"`
+	MustBeCommander      bool      `json:"mustBeCommander"`
 }
 
 // ComboCard is the minimal card object inside a variant.
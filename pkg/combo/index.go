@@ -1,5 +1,10 @@
 package combo
 
+import (
+	"sort"
+	"strings"
+)
+
 // Index maps cards to the combo variants they participate in for a specific deck.
 type Index struct {
 	// card name -> list of variant IDs this card is part of.
@@ -10,6 +15,16 @@ type Index struct {
 	DeckCards map[string]bool
 }
 
+// Zone codes Commander Spellbook uses for where a combo piece starts.
+const (
+	ZoneBattlefield = "B"
+	ZoneHand        = "H"
+	ZoneGraveyard   = "G"
+	ZoneLibrary     = "L"
+	ZoneExile       = "E"
+	ZoneCommand     = "C"
+)
+
 // VariantInfo holds distilled combo information for AI use.
 type VariantInfo struct {
 	ID           string
@@ -17,6 +32,30 @@ type VariantInfo struct {
 	MissingCards []string // empty if fully included
 	IsIncluded   bool
 	Description  string
+	// Pieces lists the cards in CardNames order with the zones each may
+	// start the combo in.
+	Pieces []Piece
+	// Templates names the cards the combo needs that Commander Spellbook
+	// describes by a template rather than by name, e.g. "Sacrifice outlet".
+	Templates []string
+	// ManaNeeded is the mana the combo needs beyond its pieces, e.g.
+	// "{U}{U}{B}".
+	ManaNeeded string
+	// Produces names the combo's results, e.g. "Infinite mana" or "Win
+	// the game".
+	Produces []string
+	// Prerequisites joins the variant's easy and notable prerequisites.
+	Prerequisites string
+	// Steps are the lines of Description, one action each.
+	Steps []string
+}
+
+// Piece is a card a combo uses and the zones it may start in.
+type Piece struct {
+	Name  string
+	Zones []string
+	// Commander is set when the piece must be a commander.
+	Commander bool
 }
 
 // NewIndex builds a combo index from a Commander Spellbook result and a decklist.
@@ -55,12 +94,38 @@ func (idx *Index) addVariant(v Variant, included bool) {
 		}
 	}
 
+	pieces := make([]Piece, 0, len(v.Uses))
+	for _, u := range v.Uses {
+		pieces = append(pieces, Piece{Name: u.Card.Name, Zones: u.ZoneLocations, Commander: u.MustBeCommander})
+	}
+	var templates []string
+	for _, r := range v.Requires {
+		templates = append(templates, r.Template.Name)
+	}
+	var produces []string
+	for _, f := range v.Produces {
+		produces = append(produces, f.Feature.Name)
+	}
+	var steps []string
+	for _, line := range strings.Split(v.Description, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			steps = append(steps, line)
+		}
+	}
+	prereqs := strings.TrimSpace(strings.TrimSpace(v.EasyPrerequisites) + "\n" + strings.TrimSpace(v.NotablePrerequisites))
+
 	idx.Variants[v.ID] = VariantInfo{
-		ID:           v.ID,
-		CardNames:    names,
-		MissingCards: missing,
-		IsIncluded:   included,
-		Description:  v.Description,
+		ID:            v.ID,
+		CardNames:     names,
+		MissingCards:  missing,
+		IsIncluded:    included,
+		Description:   v.Description,
+		Pieces:        pieces,
+		Templates:     templates,
+		ManaNeeded:    v.ManaNeeded,
+		Produces:      produces,
+		Prerequisites: prereqs,
+		Steps:         steps,
 	}
 }
 
@@ -125,35 +190,48 @@ func (idx *Index) ComboPiecesInHand(hand []string) []string {
 	return out
 }
 
-// MissingPiecesForHand returns missing combo pieces that could be tutored given the current hand.
+// MissingPiecesForHand returns the combo pieces that could be tutored
+// given the current hand: the other pieces of every variant with a card in
+// hand, including the cards an almost-included variant is missing from the
+// deck. Pieces of the variants closest to completion come first, then by
+// name, so the order is stable.
 func (idx *Index) MissingPiecesForHand(hand []string) []string {
 	handSet := make(map[string]bool)
 	for _, c := range hand {
 		handSet[c] = true
 	}
-	seen := make(map[string]bool)
-	var out []string
+	// need maps each piece to the fewest cards still needed by a variant
+	// it helps complete.
+	need := make(map[string]int)
 	for _, v := range idx.Variants {
-		if v.IsIncluded {
-			continue
-		}
 		// A variant is "relevant" if at least one of its cards is in hand.
 		hasPiece := false
+		var absent []string
 		for _, c := range v.CardNames {
 			if handSet[c] {
 				hasPiece = true
-				break
+			} else {
+				absent = append(absent, c)
 			}
 		}
 		if !hasPiece {
 			continue
 		}
-		for _, m := range v.MissingCards {
-			if !seen[m] {
-				seen[m] = true
-				out = append(out, m)
+		for _, m := range absent {
+			if n, ok := need[m]; !ok || len(absent) < n {
+				need[m] = len(absent)
 			}
 		}
 	}
+	out := make([]string, 0, len(need))
+	for m := range need {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if need[out[a]] != need[out[b]] {
+			return need[out[a]] < need[out[b]]
+		}
+		return out[a] < out[b]
+	})
 	return out
 }
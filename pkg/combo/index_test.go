@@ -0,0 +1,55 @@
+package combo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingPiecesForHand(t *testing.T) {
+	idx := &Index{Variants: map[string]VariantInfo{
+		"pair":   {ID: "pair", CardNames: []string{"A", "B"}, IsIncluded: true},
+		"triple": {ID: "triple", CardNames: []string{"A", "C", "D"}, IsIncluded: true},
+		"almost": {ID: "almost", CardNames: []string{"A", "E"}, MissingCards: []string{"E"}},
+		"other":  {ID: "other", CardNames: []string{"X", "Y"}, IsIncluded: true},
+	}}
+	got := idx.MissingPiecesForHand([]string{"A", "Z"})
+	want := []string{"B", "E", "C", "D"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := idx.MissingPiecesForHand([]string{"Z"}); len(got) != 0 {
+		t.Fatalf("no piece in hand, got %v", got)
+	}
+}
+
+func TestNewIndex_KeepsWhatTheExecutorNeeds(t *testing.T) {
+	v := Variant{
+		ID: "1-2",
+		Uses: []CardInVariant{
+			{Card: ComboCard{Name: "Thassa's Oracle"}, ZoneLocations: []string{ZoneHand}},
+			{Card: ComboCard{Name: "Demonic Consultation"}, ZoneLocations: []string{ZoneHand}},
+		},
+		Produces:          []FeatureInVariant{{Feature: Feature{Name: "Win the game"}}},
+		ManaNeeded:        "{U}{U}{B}",
+		EasyPrerequisites: "All permanents in hand.",
+		Description:       "Cast Demonic Consultation, naming a card not in the deck.\n\nCast Thassa's Oracle.\n",
+	}
+	idx := NewIndex(&FindMyCombosResult{Included: []Variant{v}}, []string{"Thassa's Oracle", "Demonic Consultation"})
+	got := idx.Variants["1-2"]
+	want := []Piece{
+		{Name: "Thassa's Oracle", Zones: []string{ZoneHand}},
+		{Name: "Demonic Consultation", Zones: []string{ZoneHand}},
+	}
+	if !reflect.DeepEqual(got.Pieces, want) {
+		t.Errorf("pieces = %+v, want %+v", got.Pieces, want)
+	}
+	if !reflect.DeepEqual(got.Produces, []string{"Win the game"}) || got.ManaNeeded != "{U}{U}{B}" {
+		t.Errorf("produces %v mana %q", got.Produces, got.ManaNeeded)
+	}
+	if len(got.Steps) != 2 || got.Steps[1] != "Cast Thassa's Oracle." {
+		t.Errorf("steps = %q", got.Steps)
+	}
+	if got.Prerequisites != "All permanents in hand." {
+		t.Errorf("prerequisites = %q", got.Prerequisites)
+	}
+}
@@ -0,0 +1,295 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mtgsim/mtgsim/pkg/simulation"
+)
+
+// PlayPodProvider seats a pod of size players for an interactive game,
+// including the deck named deck, or picking every deck itself when deck
+// is empty.
+type PlayPodProvider func(deck string, size int) ([]simulation.EDHSeat, error)
+
+// playWaitLimit bounds how long a state request waits for the next
+// decision.
+const playWaitLimit = 30 * time.Second
+
+var (
+	errStaleDecision = errors.New("no decision with that id is waiting")
+	errInvalidChoice = errors.New("choice is not a legal answer to the decision")
+)
+
+// playSession is one interactive game. The dashboard client plays one
+// seat through the simulation.Chooser the session implements, while
+// SimulateEDHGame runs the game and the other seats' agents.
+type playSession struct {
+	seat    string
+	answers chan []int
+	// done is closed when the session is abandoned; the AI then makes
+	// the seat's remaining decisions until the game stops at the end of
+	// the turn.
+	done chan struct{}
+
+	mu sync.Mutex
+	// id counts the decisions put to the client; pending is the one
+	// waiting for an answer, if any.
+	id      int
+	pending *simulation.Decision
+	record  *simulation.EDHGameRecord
+	err     error
+	// changed is closed and replaced whenever the state changes.
+	changed chan struct{}
+}
+
+func newPlaySession(seat string) *playSession {
+	return &playSession{seat: seat, answers: make(chan []int, 1), done: make(chan struct{}), changed: make(chan struct{})}
+}
+
+// Choose puts d to the client and waits for its answer.
+func (s *playSession) Choose(d simulation.Decision) []int {
+	s.mu.Lock()
+	s.id++
+	s.pending = &d
+	s.notifyLocked()
+	s.mu.Unlock()
+
+	var answer []int
+	select {
+	case answer = <-s.answers:
+	case <-s.done:
+	}
+	s.mu.Lock()
+	s.pending = nil
+	s.mu.Unlock()
+	return answer
+}
+
+// answer delivers choice to the waiting decision id. A nil choice leaves
+// the decision to the AI; any other must be one the decision allows.
+func (s *playSession) answer(id int, choice []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil || id != s.id {
+		return errStaleDecision
+	}
+	if choice != nil && !s.pending.Valid(choice) {
+		return errInvalidChoice
+	}
+	s.pending = nil
+	s.answers <- choice
+	return nil
+}
+
+func (s *playSession) finish(rec simulation.EDHGameRecord, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.err = err
+	} else {
+		s.record = &rec
+	}
+	s.notifyLocked()
+}
+
+func (s *playSession) abandon() { close(s.done) }
+
+func (s *playSession) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// playState is what the state endpoint reports.
+type playState struct {
+	Seat string `json:"seat"`
+	// ID is the number of the latest decision; answers name it.
+	ID       int                       `json:"id"`
+	Decision *simulation.Decision      `json:"decision,omitempty"`
+	Finished bool                      `json:"finished"`
+	Record   *simulation.EDHGameRecord `json:"record,omitempty"`
+	Error    string                    `json:"error,omitempty"`
+}
+
+// state reports the session, waiting up to wait for a decision after
+// after or the end of the game.
+func (s *playSession) state(after int, wait time.Duration) playState {
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	for {
+		s.mu.Lock()
+		st := playState{Seat: s.seat, ID: s.id, Decision: s.pending, Finished: s.record != nil || s.err != nil, Record: s.record}
+		if s.err != nil {
+			st.Error = s.err.Error()
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		if st.ID > after || st.Finished {
+			return st
+		}
+		select {
+		case <-changed:
+		case <-timeout.C:
+			return st
+		}
+	}
+}
+
+// SetPlayPodProvider enables the interactive play endpoints.
+func (s *Server) SetPlayPodProvider(fn PlayPodProvider) { s.playPods = fn }
+
+// playStartRequest starts an interactive game. Deck is the deck the
+// client plays, empty for a random one; Pod defaults to 4 and MaxTurns
+// to the runner's default.
+type playStartRequest struct {
+	Deck     string `json:"deck"`
+	Pod      int    `json:"pod"`
+	MaxTurns int    `json:"max_turns"`
+	Seed     int64  `json:"seed"`
+}
+
+// handlePlayStart seats the client in a new game with AI opponents,
+// abandoning any game already in progress; that game stops at the end of
+// its turn.
+func (s *Server) handlePlayStart(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "POST required"})
+		return
+	}
+	if s.playPods == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "play mode not configured"})
+		return
+	}
+	var req playStartRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid JSON: " + err.Error()})
+			return
+		}
+	}
+	if req.Pod < 2 || req.Pod > 6 {
+		req.Pod = 4
+	}
+	seats, err := s.playPods(req.Deck, req.Pod)
+	if err != nil || len(seats) < 2 {
+		if err == nil {
+			err = errors.New("not enough decks for a pod")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+		return
+	}
+	human := 0
+	for i, seat := range seats {
+		if seat.DeckName == req.Deck {
+			human = i
+		}
+	}
+	session := newPlaySession(seats[human].DeckName)
+	agent := simulation.NewHumanAgent(session)
+	agent.Combos, agent.Archetype = seats[human].Combos, seats[human].Archetype
+	seats[human].Agent = agent
+
+	s.playMu.Lock()
+	if s.play != nil {
+		s.play.abandon()
+	}
+	s.play = session
+	s.playMu.Unlock()
+
+	seed := req.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	go func() {
+		rec, err := simulation.SimulateEDHGame(simulation.EDHRunOptions{
+			Seats: seats, MaxTurns: req.MaxTurns, RNG: rand.New(rand.NewSource(seed)), RecordEvents: true,
+			Stop: session.done,
+		})
+		session.finish(rec, err)
+	}()
+
+	players := make([]string, len(seats))
+	for i, seat := range seats {
+		players[i] = seat.DeckName
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"seat": session.seat, "players": players, "seed": seed})
+}
+
+// handlePlayState reports the interactive game: the decision waiting for
+// the client, or the record once the game is over. With after, it waits
+// up to wait seconds for a decision newer than after.
+func (s *Server) handlePlayState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	session := s.playSession()
+	if session == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "no game in progress"})
+		return
+	}
+	after, err := strconv.Atoi(r.URL.Query().Get("after"))
+	if err != nil {
+		after = -1
+	}
+	var wait time.Duration
+	if secs, err := strconv.Atoi(r.URL.Query().Get("wait")); err == nil && secs > 0 {
+		wait = min(time.Duration(secs)*time.Second, playWaitLimit)
+	}
+	_ = json.NewEncoder(w).Encode(session.state(after, wait))
+}
+
+// playChooseRequest answers decision ID. A null Choice leaves the
+// decision to the AI; an empty one chooses nothing.
+type playChooseRequest struct {
+	ID     int   `json:"id"`
+	Choice []int `json:"choice"`
+}
+
+// handlePlayChoose answers the decision waiting for the client. An answer
+// the decision doesn't allow is refused so the client can answer again.
+func (s *Server) handlePlayChoose(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "POST required"})
+		return
+	}
+	session := s.playSession()
+	if session == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "no game in progress"})
+		return
+	}
+	var req playChooseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	if err := session.answer(req.ID, req.Choice); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, errInvalidChoice) {
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"message": "answered", "id": req.ID})
+}
+
+func (s *Server) playSession() *playSession {
+	s.playMu.Lock()
+	defer s.playMu.Unlock()
+	return s.play
+}
@@ -132,6 +132,12 @@ type Server struct {
 	// Job-based execution (optional, replaces direct game runner).
 	jobCreator       JobCreator
 	jobStatusProvider JobStatusProvider
+
+	// Interactive play (optional): playPods seats the pods, play is the
+	// game in progress.
+	playPods PlayPodProvider
+	play     *playSession
+	playMu   sync.Mutex
 }
 
 // NewServer creates a new dashboard server backed by the given results provider.
@@ -277,6 +283,9 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/game-log", s.handleGameLog)
 	s.mux.HandleFunc("/api/reset-card-library", s.requireAuth(s.handleResetCardLibrary))
 	s.mux.HandleFunc("/api/reset-game-logs", s.requireAuth(s.handleResetGameLogs))
+	s.mux.HandleFunc("/api/play/start", s.requireAuth(s.handlePlayStart))
+	s.mux.HandleFunc("/api/play/state", s.requireAuth(s.handlePlayState))
+	s.mux.HandleFunc("/api/play/choose", s.requireAuth(s.handlePlayChoose))
 	s.mux.HandleFunc("/style.css", serveStatic("style.css", "text/css"))
 	s.mux.HandleFunc("/app.js", serveStatic("app.js", "application/javascript"))
 	s.mux.HandleFunc("/", s.handleIndex)
@@ -560,11 +569,12 @@ type cardLibraryResponse struct {
 }
 
 type cardMeta struct {
-	TypeLine string   `json:"type_line"`
-	CMC      float32  `json:"cmc"`
-	ManaCost string   `json:"mana_cost"`
-	Colors   []string `json:"colors,omitempty"`
-	ImageURL string   `json:"image_url,omitempty"`
+	TypeLine string     `json:"type_line"`
+	CMC      float32    `json:"cmc"`
+	ManaCost string     `json:"mana_cost"`
+	Colors   []string   `json:"colors,omitempty"`
+	ImageURL string     `json:"image_url,omitempty"`
+	Roles    card.Roles `json:"roles,omitempty"`
 }
 
 func (s *Server) handleCardLibrary(w http.ResponseWriter, r *http.Request) {
@@ -595,6 +605,7 @@ func (s *Server) handleCardLibrary(w http.ResponseWriter, r *http.Request) {
 				resp.CardDB[name] = cardMeta{
 					TypeLine: c.TypeLine, CMC: c.CMC, ManaCost: c.ManaCost,
 					Colors: c.ColorIdentity, ImageURL: imageURL,
+					Roles: s.cardDB.Roles(name),
 				}
 			}
 		}
@@ -851,6 +862,7 @@ func (s *Server) handleUploadDeck(w http.ResponseWriter, r *http.Request) {
 		Library:    convertCards(main.Cards),
 		Sideboard:  convertCards(side.Cards),
 		Commanders: convertCards(commanders),
+		Archetype:  main.Archetype,
 	}
 	
 	// Set as suggested deck
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mtgsim/mtgsim/pkg/game"
 	"github.com/mtgsim/mtgsim/pkg/simulation"
 	"github.com/mtgsim/mtgsim/pkg/stats"
 )
@@ -542,3 +544,115 @@ func benchHandleResultsPhases(b *testing.B, n int) {
 func BenchmarkHandleResultsPhases_1k(b *testing.B)   { benchHandleResultsPhases(b, 1000) }
 func BenchmarkHandleResultsPhases_10k(b *testing.B)  { benchHandleResultsPhases(b, 10000) }
 func BenchmarkHandleResultsPhases_100k(b *testing.B) { benchHandleResultsPhases(b, 100000) }
+
+func playSeat(name, land, creature string) simulation.EDHSeat {
+	seat := simulation.EDHSeat{DeckName: name}
+	for i := 0; i < 40; i++ {
+		c := game.SimpleCard{Name: land, TypeLine: "Basic Land — " + land}
+		if i%3 == 0 {
+			c = game.SimpleCard{Name: creature, TypeLine: "Creature", ManaCost: "{1}", Power: "2", Toughness: "2"}
+		}
+		seat.Library = append(seat.Library, c)
+	}
+	return seat
+}
+
+func TestServer_PlayEndpoints(t *testing.T) {
+	server := NewServer(snapshotFromResults(simulation.NewResults()), 0)
+	h := server.Handler()
+	do := func(method, path, body string) (int, map[string]any) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+server.authToken)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var out map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return w.Code, out
+	}
+
+	req := httptest.NewRequest("POST", "/api/play/start", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("starting a game needs the API token, got %d", w.Code)
+	}
+	if code, _ := do("POST", "/api/play/start", `{}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("play without a pod provider should be unavailable, got %d", code)
+	}
+	server.SetPlayPodProvider(func(deck string, size int) ([]simulation.EDHSeat, error) {
+		return []simulation.EDHSeat{playSeat("Mine", "Mountain", "Goblin"), playSeat("Theirs", "Forest", "Bear")}, nil
+	})
+	req = httptest.NewRequest("GET", "/api/play/state", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("reading the game state needs the API token, got %d", w.Code)
+	}
+	if code, _ := do("GET", "/api/play/state", ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 before a game starts, got %d", code)
+	}
+	code, started := do("POST", "/api/play/start", `{"deck":"Mine","pod":2,"max_turns":4,"seed":3}`)
+	if code != http.StatusOK || started["seat"] != "Mine" {
+		t.Fatalf("start: %d %v", code, started)
+	}
+
+	answered := 0
+	for after := 0; ; {
+		var st playState
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/play/state?after=%d&wait=5", after), nil)
+		req.Header.Set("Authorization", "Bearer "+server.authToken)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil {
+			t.Fatalf("state: %v", err)
+		}
+		if st.Finished {
+			if st.Record == nil || st.Record.Turns == 0 {
+				t.Fatalf("a finished game should report its record, got %+v", st)
+			}
+			break
+		}
+		if st.Decision == nil {
+			t.Fatalf("state returned without a decision or a result: %+v", st)
+		}
+		if st.Decision.Player != "Mine" {
+			t.Fatalf("only the client's seat should be asked, got %+v", st.Decision)
+		}
+		if code, _ := do("POST", "/api/play/choose", fmt.Sprintf(`{"id":%d,"choice":null}`, st.ID-1)); st.ID > 1 && code != http.StatusConflict {
+			t.Fatalf("answering a stale decision should conflict, got %d", code)
+		}
+		if code, _ := do("POST", "/api/play/choose", fmt.Sprintf(`{"id":%d,"choice":[%d]}`, st.ID, len(st.Decision.Options))); code != http.StatusBadRequest {
+			t.Fatalf("an option the decision doesn't offer should be refused, got %d", code)
+		}
+		if code, out := do("POST", "/api/play/choose", fmt.Sprintf(`{"id":%d,"choice":null}`, st.ID)); code != http.StatusOK {
+			t.Fatalf("choose: %d %v", code, out)
+		}
+		after = st.ID
+		answered++
+	}
+	if answered == 0 {
+		t.Fatal("the client should have been asked something")
+	}
+}
+
+func TestServer_PlayStartStopsTheAbandonedGame(t *testing.T) {
+	server := NewServer(snapshotFromResults(simulation.NewResults()), 0)
+	server.SetAuthToken("")
+	server.SetPlayPodProvider(func(deck string, size int) ([]simulation.EDHSeat, error) {
+		return []simulation.EDHSeat{playSeat("Mine", "Mountain", "Goblin"), playSeat("Theirs", "Forest", "Bear")}, nil
+	})
+	h := server.Handler()
+	start := func() *playSession {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/api/play/start", strings.NewReader(`{"deck":"Mine","pod":2,"seed":3}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("start: %d %s", w.Code, w.Body.String())
+		}
+		return server.playSession()
+	}
+	first := start()
+	start()
+	if st := first.state(math.MaxInt, 10*time.Second); st.Error != simulation.ErrPodStopped.Error() {
+		t.Fatalf("the abandoned game should stop, got %+v", st)
+	}
+}
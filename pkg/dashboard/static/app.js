@@ -1823,6 +1823,9 @@
 		if (meta.colors && meta.colors.length > 0) {
 			html += '<div style="margin-bottom:4px;">Colors: <strong>' + meta.colors.join(', ') + '</strong></div>';
 		}
+		if (meta.roles && meta.roles.length > 0) {
+			html += '<div style="margin-bottom:4px;">Roles: <strong>' + escapeHtml(meta.roles.map(r => r.replace(/_/g, ' ')).join(', ')) + '</strong></div>';
+		}
 		html += '<hr style="border-color:#333; margin:10px 0;">';
 		if (lib) {
 			const wrColor = lib.winRate >= deckWR + 5 ? '#2ecc71' : lib.winRate >= deckWR - 10 ? '#f39c12' : '#e74c3c';
@@ -20,6 +20,9 @@ import (
 type Deck struct {
 	Cards []card.Card
 	Name  string
+	// Archetype is the play style a "// Archetype: stax" comment in the
+	// deck file names, or empty to infer it from the cards.
+	Archetype card.Archetype
 }
 
 // Shuffle randomizes the order of cards in the deck.
@@ -147,6 +150,7 @@ func importDeckfileWithCommanders(filename string, cardDB CardDatabase, inferCom
 
 	var entries []parsedDeckEntry
 	var deckName = filepath.Base(filename)
+	var archetype card.Archetype
 	section := sectionMain
 	group := 0
 
@@ -155,6 +159,10 @@ func importDeckfileWithCommanders(filename string, cardDB CardDatabase, inferCom
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
+		if a, ok := parseArchetypeComment(line); ok {
+			archetype = a
+			continue
+		}
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, "//") {
 			if line == "" {
@@ -229,7 +237,24 @@ func importDeckfileWithCommanders(filename string, cardDB CardDatabase, inferCom
 	}
 
 	cards, sideboardCards, commanders := materializeDeckEntries(entries)
-	return Deck{Cards: cards, Name: deckName}, Deck{Cards: sideboardCards}, commanders, nil
+	return Deck{Cards: cards, Name: deckName, Archetype: archetype}, Deck{Cards: sideboardCards}, commanders, nil
+}
+
+// parseArchetypeComment reads a "// Archetype: <name>" comment line.
+func parseArchetypeComment(line string) (card.Archetype, bool) {
+	rest, ok := strings.CutPrefix(line, "//")
+	if !ok {
+		return "", false
+	}
+	rest, ok = trimInlinePrefix(strings.TrimSpace(rest), "ARCHETYPE:")
+	if !ok {
+		return "", false
+	}
+	a, ok := card.ParseArchetype(rest)
+	if !ok {
+		logger.LogDeck("Unknown archetype: %s", rest)
+	}
+	return a, ok
 }
 
 func parseDeckName(line string) (string, bool) {
@@ -331,6 +331,27 @@ func TestImportSimpleDeckFormat(t *testing.T) {
 	}
 }
 
+func TestImportDeckfile_ArchetypeComment(t *testing.T) {
+	deckFile := filepath.Join(t.TempDir(), "stax.deck")
+	deckContent := `// Archetype: Stax
+4 Lightning Bolt
+20 Mountain
+`
+	if err := os.WriteFile(deckFile, []byte(deckContent), 0644); err != nil {
+		t.Fatalf("Failed to create test deck file: %v", err)
+	}
+	mainDeck, _, err := ImportDeckfile(deckFile, createMockCardDB())
+	if err != nil {
+		t.Fatalf("Failed to import deck: %v", err)
+	}
+	if mainDeck.Archetype != card.ArchetypeStax {
+		t.Errorf("Expected archetype stax, got %q", mainDeck.Archetype)
+	}
+	if mainDeck.Size() != 24 {
+		t.Errorf("Expected 24 cards, got %d", mainDeck.Size())
+	}
+}
+
 // TestParseAllDecksInRepository tests parsing every deck file in the decks directory
 func TestParseAllDecksInRepository(t *testing.T) {
 	// Get the project root directory (go up from pkg/deck to project root)
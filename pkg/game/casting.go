@@ -10,6 +10,8 @@ type SimpleStack interface {
 
 type casting struct {
 	stack SimpleStack
+	// spellsThisTurn counts spells cast this turn per caster, for storm.
+	spellsThisTurn map[*Player]int
 }
 
 // SetStack injects a SimpleStack implementation for casting/activating.
@@ -23,6 +25,44 @@ func (g *Game) CastSimpleSpell(name string, cmc int, manaCost string, typeLine s
 	return g.casting.stack.EnqueueSpell(name, cmc, manaCost, typeLine, controller, targets)
 }
 
+// RecordSpellCast counts a spell p cast this turn. Copies of spells aren't
+// cast and must not be recorded (CR 707.10).
+func (g *Game) RecordSpellCast(p *Player) {
+	c := g.ensureCasting()
+	if c.spellsThisTurn == nil {
+		c.spellsThisTurn = map[*Player]int{}
+	}
+	c.spellsThisTurn[p]++
+}
+
+// SpellsCastThisTurn returns the number of spells all players have cast this
+// turn, which is what storm counts (CR 702.40a).
+func (g *Game) SpellsCastThisTurn() int {
+	if g.casting == nil {
+		return 0
+	}
+	total := 0
+	for _, n := range g.casting.spellsThisTurn {
+		total += n
+	}
+	return total
+}
+
+// SpellsCastThisTurnBy returns the number of spells p has cast this turn.
+func (g *Game) SpellsCastThisTurnBy(p *Player) int {
+	if g.casting == nil {
+		return 0
+	}
+	return g.casting.spellsThisTurn[p]
+}
+
+// resetSpellCounts clears the per-turn spell counts when a new turn begins.
+func (g *Game) resetSpellCounts() {
+	if g.casting != nil {
+		g.casting.spellsThisTurn = nil
+	}
+}
+
 // helper to ensure casting sub-struct is initialized
 func (g *Game) ensureCasting() *casting {
 	if g.casting == nil {
@@ -89,3 +89,27 @@ func TestGame_CastSimpleSpell_DelegatesToStack(t *testing.T) {
 		t.Fatalf("expected stack size 1, got %d", adapter.Size())
 	}
 }
+
+func TestGame_SpellsCastThisTurn_ResetsAtNextTurn(t *testing.T) {
+	p1 := game.NewPlayer("P1", 20)
+	p2 := game.NewPlayer("P2", 20)
+	g := game.NewGame(p1, p2)
+
+	g.RecordSpellCast(p1)
+	g.RecordSpellCast(p1)
+	g.RecordSpellCast(p2)
+	if got := g.SpellsCastThisTurn(); got != 3 {
+		t.Fatalf("expected 3 spells this turn, got %d", got)
+	}
+	if got := g.SpellsCastThisTurnBy(p1); got != 2 {
+		t.Fatalf("expected P1 to have cast 2 spells, got %d", got)
+	}
+
+	for g.GetCurrentPhase() != game.PhaseCleanup {
+		g.AdvancePhase()
+	}
+	g.AdvancePhase()
+	if got := g.SpellsCastThisTurn(); got != 0 {
+		t.Fatalf("expected spell count to reset on the next turn, got %d", got)
+	}
+}
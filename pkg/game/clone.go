@@ -0,0 +1,312 @@
+package game
+
+// Cloning. Search-based AI forks a game, plays the fork out and throws it
+// away, so a clone must share no mutable state with the original: every
+// player, permanent, effect and trigger is copied, and every pointer
+// between them is remapped to the copies. Callbacks (LayeredEffect.Apply,
+// Trigger.Condition and Action, DelayedAction.Do, listeners) are shared,
+// which is safe because they get the objects they act on as arguments
+// rather than capturing them.
+
+// CloneMap maps the objects of a game to their copies in a clone.
+type CloneMap struct {
+	game     *Game
+	players  map[*Player]*Player
+	perms    map[*Permanent]*Permanent
+	triggers map[*Trigger]*Trigger
+	objects  map[any]any
+}
+
+// Game returns the clone.
+func (m *CloneMap) Game() *Game { return m.game }
+
+// Player returns p's copy, or nil if p isn't in the cloned game.
+func (m *CloneMap) Player(p *Player) *Player {
+	if p == nil {
+		return nil
+	}
+	return m.players[p]
+}
+
+// Permanent returns p's copy. A permanent that had already left the
+// battlefield when the game was cloned (last known information in an
+// event, say) gets a detached copy the first time it is asked for.
+func (m *CloneMap) Permanent(p *Permanent) *Permanent {
+	if p == nil {
+		return nil
+	}
+	if cp, ok := m.perms[p]; ok {
+		return cp
+	}
+	cp := copyPermanent(p)
+	m.perms[p] = cp
+	cp.owner = m.Player(p.owner)
+	cp.controller = m.Player(p.controller)
+	cp.attachedTo = m.Permanent(p.attachedTo)
+	m.userData(p, cp)
+	return cp
+}
+
+// Object remaps v if it is a *Player, a *Permanent or an ObjectCloner
+// and returns any other value unchanged. An ObjectCloner is copied once;
+// asking again returns the same copy.
+func (m *CloneMap) Object(v any) any {
+	switch o := v.(type) {
+	case *Player:
+		return m.Player(o)
+	case *Permanent:
+		return m.Permanent(o)
+	case ObjectCloner:
+		if c, ok := m.objects[o]; ok {
+			return c
+		}
+		return o.CloneObject(m)
+	}
+	return v
+}
+
+// Copied records c as the copy of v. ObjectCloners call it before
+// copying anything that may refer back to v.
+func (m *CloneMap) Copied(v, c any) { m.objects[v] = c }
+
+// ObjectCloner is implemented by objects outside this package that refer
+// to a game's objects, such as the abilities stored on a permanent
+// (SetAbilities) and the spells and abilities on the stack. CloneObject
+// returns a copy referring to the clone's objects instead, after
+// recording it with m.Copied. Objects that aren't ObjectCloners are
+// shared with the clone.
+type ObjectCloner interface {
+	CloneObject(m *CloneMap) any
+}
+
+// StackCloner is implemented by SimpleStacks that can be copied into a
+// clone of their game. Stacks that can't are left out of the clone.
+type StackCloner interface {
+	CloneStack(m *CloneMap) SimpleStack
+}
+
+// WatcherCloner is implemented by Watchers that can be copied into a clone
+// of their game. Watchers that can't are left out of the clone.
+type WatcherCloner interface {
+	CloneWatcher(m *CloneMap) Watcher
+}
+
+// Clone returns a deep copy of the game and the map from its objects to
+// their copies. The copy has the same players, zones, permanents and
+// counters, turn, combat, spells cast this turn, layered and static
+// effects, replacement and prevention shields, triggers (including those
+// waiting to be put on the stack), watchers and delayed actions, all
+// pointing at the copied objects.
+//
+// Listeners are copied too, and a permanent's abilities (SetAbilities)
+// are copied through ObjectCloner.
+func (g *Game) Clone() (*Game, *CloneMap) {
+	m := &CloneMap{
+		players:  make(map[*Player]*Player, len(g.players)),
+		perms:    map[*Permanent]*Permanent{},
+		triggers: map[*Trigger]*Trigger{},
+		objects:  map[any]any{},
+	}
+	copies := make([]*Player, len(g.players))
+	for i, p := range g.players {
+		copies[i] = p.clonePlayer(m.perms)
+		m.players[p] = copies[i]
+	}
+	for old, perm := range m.perms {
+		perm.owner = m.Player(old.owner)
+		perm.controller = m.Player(old.controller)
+	}
+	for old, perm := range m.perms {
+		perm.attachedTo = m.Permanent(old.attachedTo)
+	}
+
+	c := NewGame(copies...)
+	m.game = c
+	for old, perm := range m.perms {
+		m.userData(old, perm)
+	}
+	c.listeners = append(c.listeners, g.listeners...)
+	c.currentIdx = g.currentIdx
+	c.activeIdx = g.activeIdx
+	c.turnNumber = g.turnNumber
+	c.currentPhase = g.currentPhase
+	c.extraTurns = g.extraTurns
+	c.extraCombats = g.extraCombats
+	c.landLifePolicy = g.landLifePolicy
+
+	if g.casting != nil {
+		c.casting = &casting{}
+		if sc, ok := g.casting.stack.(StackCloner); ok {
+			c.casting.stack = sc.CloneStack(m)
+		}
+		if g.casting.spellsThisTurn != nil {
+			c.casting.spellsThisTurn = make(map[*Player]int, len(g.casting.spellsThisTurn))
+			for p, n := range g.casting.spellsThisTurn {
+				c.casting.spellsThisTurn[m.Player(p)] = n
+			}
+		}
+	}
+	if g.combat != nil {
+		c.BeginCombat()
+		for att, def := range g.combat.attackers {
+			c.combat.attackers[m.Permanent(att)] = m.Player(def)
+		}
+		for att, blockers := range g.combat.blocks {
+			cb := make([]*Permanent, len(blockers))
+			for i, b := range blockers {
+				cb[i] = m.Permanent(b)
+			}
+			c.combat.blocks[m.Permanent(att)] = cb
+		}
+	}
+	if g.continuous != nil {
+		c.continuous = &continuous{nextID: g.continuous.nextID, timestamp: g.continuous.timestamp}
+		for _, eff := range g.continuous.effects {
+			ce := *eff
+			ce.Source = m.Permanent(eff.Source)
+			ce.Target = m.Permanent(eff.Target)
+			c.continuous.effects = append(c.continuous.effects, &ce)
+		}
+		if r := g.continuous.staticEffects; r != nil {
+			c.continuous.staticEffects = NewStaticEffectRegistry()
+			for _, e := range r.effects {
+				ce := *e
+				ce.Source = m.Permanent(e.Source)
+				ce.Controller = m.Player(e.Controller)
+				c.continuous.staticEffects.Register(&ce)
+			}
+		}
+	}
+	if g.replacements != nil {
+		c.ensureReplacements()
+		for p, on := range g.replacements.wouldDieExile {
+			c.replacements.wouldDieExile[m.Permanent(p)] = on
+		}
+	}
+	if g.prevention != nil {
+		c.ensurePrevention()
+		for target, n := range g.prevention.pool {
+			c.prevention.pool[m.Object(target)] = n
+		}
+	}
+
+	for _, t := range g.triggers {
+		c.triggers = append(c.triggers, m.trigger(t))
+	}
+	for _, pt := range g.pendingTriggers {
+		c.pendingTriggers = append(c.pendingTriggers, PendingTrigger{Trigger: m.trigger(pt.Trigger), Event: m.event(pt.Event)})
+	}
+	for _, w := range g.watchers {
+		if wc, ok := w.(WatcherCloner); ok {
+			c.watchers = append(c.watchers, wc.CloneWatcher(m))
+		}
+	}
+	for _, d := range g.endStepActions {
+		d.Player = m.Player(d.Player)
+		d.Permanent = m.Permanent(d.Permanent)
+		c.endStepActions = append(c.endStepActions, d)
+	}
+	for viewer, owners := range g.shown {
+		for owner, cards := range owners {
+			c.ShowCards(m.Player(viewer), m.Player(owner), cards...)
+		}
+	}
+	return c, m
+}
+
+// trigger returns t's copy, copying it on first use so that pending
+// triggers share their copies with the registered ones.
+func (m *CloneMap) trigger(t *Trigger) *Trigger {
+	if t == nil {
+		return nil
+	}
+	if ct, ok := m.triggers[t]; ok {
+		return ct
+	}
+	ct := *t
+	ct.Controller = m.Player(t.Controller)
+	ct.Source = m.Permanent(t.Source)
+	m.triggers[t] = &ct
+	return &ct
+}
+
+// userData copies old's abilities (or other user data) onto its copy cp.
+func (m *CloneMap) userData(old, cp *Permanent) {
+	if m.game == nil {
+		return // the clone's permanents are still being copied
+	}
+	abilities, ok := old.userData.([]any)
+	if !ok {
+		cp.userData = m.Object(old.userData)
+		return
+	}
+	copied := make([]any, len(abilities))
+	for i, a := range abilities {
+		copied[i] = m.Object(a)
+	}
+	cp.userData = copied
+}
+
+// event returns a copy of e pointing at the cloned objects.
+func (m *CloneMap) event(e Event) Event {
+	if e.ZoneChange != nil {
+		zc := *e.ZoneChange
+		zc.Permanent = m.Permanent(zc.Permanent)
+		e.ZoneChange = &zc
+	}
+	if e.Attack != nil {
+		e.Attack = &Attack{Attacker: m.Permanent(e.Attack.Attacker), Defender: m.Player(e.Attack.Defender)}
+	}
+	return e
+}
+
+// clonePlayer copies p and its permanents, recording each permanent's
+// copy in perms. Player references inside the permanents still point at
+// the originals.
+func (p *Player) clonePlayer(perms map[*Permanent]*Permanent) *Player {
+	cp := *p
+	cp.Library = append([]SimpleCard{}, p.Library...)
+	cp.Hand = append([]SimpleCard{}, p.Hand...)
+	cp.Graveyard = append([]SimpleCard{}, p.Graveyard...)
+	cp.Exile = append([]SimpleCard{}, p.Exile...)
+	cp.CommandZone = append([]SimpleCard{}, p.CommandZone...)
+	cp.revealed = append([]SimpleCard(nil), p.revealed...)
+	cp.knownTop = append([]SimpleCard(nil), p.knownTop...)
+	cp.knownBottom = append([]SimpleCard(nil), p.knownBottom...)
+	cp.manaPool = NewManaPool()
+	for mt, n := range p.manaPool.pool {
+		cp.manaPool.pool[mt] = n
+	}
+	cp.commanderNames = copyMap(p.commanderNames)
+	cp.commanderCastCount = copyMap(p.commanderCastCount)
+	cp.commanderDamageReceived = copyMap(p.commanderDamageReceived)
+	cp.Battlefield = make([]*Permanent, len(p.Battlefield))
+	for i, perm := range p.Battlefield {
+		cp.Battlefield[i] = copyPermanent(perm)
+		perms[perm] = cp.Battlefield[i]
+	}
+	return &cp
+}
+
+// copyPermanent copies perm's own state; its player and permanent
+// references still point at the originals.
+func copyPermanent(perm *Permanent) *Permanent {
+	pc := *perm
+	pc.userData = nil
+	pc.counters = copyMap(perm.counters)
+	pc.activations = copyMap(perm.activations)
+	pc.printedKeywords = copyMap(perm.printedKeywords)
+	pc.grantedKeywords = copyMap(perm.grantedKeywords)
+	return &pc
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package game
+
+import "testing"
+
+func TestClone_CopiesStateAndRemapsPointers(t *testing.T) {
+	p1 := NewPlayer("P1", 40)
+	p2 := NewPlayer("P2", 40)
+	g := NewGame(p1, p2)
+	p1.Hand = []SimpleCard{{Name: "Brainstorm"}}
+	bear := p1.PutTokenOnBattlefield(SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"})
+	bear.SetEnteredTurn(0)
+	g.ApplySetPTUntilEOT(bear, 5, 5)
+	g.AddDamagePrevention(p2, 3)
+	if err := g.DeclareAttacker(bear, p2); err != nil {
+		t.Fatalf("declare attacker: %v", err)
+	}
+
+	c, m := g.Clone()
+	cp1, cp2, cbear := m.Player(p1), m.Player(p2), m.Permanent(bear)
+	if c.GetPlayerByIndex(0) != cp1 || cp1 == p1 || cbear == bear || cp1.Battlefield[0] != cbear {
+		t.Fatal("expected the clone to hold copies of the players and permanents")
+	}
+	if cbear.GetController() != cp1 || c.GetAttackers()[cbear] != cp2 {
+		t.Fatal("expected the copies to point at each other")
+	}
+
+	// The set-P/T effect follows the copied Bear, not the original.
+	c.RecomputeContinuous()
+	if cbear.GetPower() != 5 {
+		t.Fatalf("expected the copied effect to apply to the copy, got power %d", cbear.GetPower())
+	}
+	c.ApplyDamageToPlayer(cp2, 5)
+	if cp2.GetLifeTotal() != 38 {
+		t.Fatalf("expected the copied shield to prevent 3, life %d", cp2.GetLifeTotal())
+	}
+
+	cp1.Hand = nil
+	cbear.Untap()
+	if len(p1.Hand) != 1 || !bear.IsTapped() || p2.GetLifeTotal() != 40 || g.GetAttackers()[bear] != p2 {
+		t.Fatal("changing the clone must not change the original")
+	}
+}
+
+func TestClone_CopiesDelayedActionsAndPendingTriggers(t *testing.T) {
+	p1 := NewPlayer("P1", 40)
+	p2 := NewPlayer("P2", 40)
+	g := NewGame(p1, p2)
+	token := p1.PutTokenOnBattlefield(SimpleCard{Name: "Elemental", TypeLine: "Creature — Elemental"})
+	g.ScheduleAtNextEndStep(DelayedAction{Player: p1, Permanent: token, Do: func(_ *Game, d DelayedAction) { d.Permanent.Tap() }})
+	fired := map[*Player]int{}
+	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Controller: p1, Action: func(_ *Game, _ *Trigger, e Event) {
+		fired[e.ZoneChange.Permanent.GetController()]++
+	}})
+	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: token}})
+
+	c, m := g.Clone()
+	for c.GetCurrentPhase() != PhaseEnd {
+		c.AdvancePhase()
+	}
+	if !m.Permanent(token).IsTapped() || token.IsTapped() {
+		t.Fatal("expected the delayed action to act on the clone's token only")
+	}
+	c.ProcessPendingTriggers()
+	if fired[m.Player(p1)] != 1 || fired[p1] != 0 {
+		t.Fatalf("expected the pending trigger to see the clone's token, got %v", fired)
+	}
+}
+
+// testAbility stands in for an ability stored on a permanent.
+type testAbility struct{ source *Permanent }
+
+func (a *testAbility) CloneObject(m *CloneMap) any {
+	c := &testAbility{}
+	m.Copied(a, c)
+	c.source = m.Permanent(a.source)
+	return c
+}
+
+func TestClone_CopiesTriggersListenersAndAbilities(t *testing.T) {
+	p1 := NewPlayer("P1", 40)
+	p2 := NewPlayer("P2", 40)
+	g := NewGame(p1, p2)
+	wall := p1.PutTokenOnBattlefield(SimpleCard{Name: "Wall", TypeLine: "Creature — Wall"})
+	ab := &testAbility{source: wall}
+	wall.SetAbilities([]any{ab})
+	// Whenever another creature enters under its controller's control,
+	// tap the Wall.
+	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Controller: p1, Source: wall,
+		Condition: func(t *Trigger, e Event) bool {
+			return e.ZoneChange.Permanent != t.Source && e.ZoneChange.Permanent.GetController() == t.Controller
+		},
+		Action: func(_ *Game, t *Trigger, _ Event) { t.Source.Tap() },
+	})
+	entered := map[*Game]int{}
+	g.AddListener(func(g *Game, _ Event) { entered[g]++ })
+
+	c, m := g.Clone()
+	cwall := m.Permanent(wall)
+	if abs := cwall.GetAbilities(); len(abs) != 1 || abs[0] == ab || abs[0].(*testAbility).source != cwall {
+		t.Fatalf("expected the Wall's ability copied onto its copy, got %+v", abs)
+	}
+	bear := m.Player(p1).PutTokenOnBattlefield(SimpleCard{Name: "Bear", TypeLine: "Creature — Bear"})
+	c.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: bear}})
+	c.ProcessPendingTriggers()
+	if !cwall.IsTapped() || wall.IsTapped() {
+		t.Fatal("expected the trigger to tap the clone's Wall only")
+	}
+	if entered[c] != 1 || entered[g] != 0 {
+		t.Fatalf("expected the listener to hear the clone's event from the clone, got %v", entered)
+	}
+}
@@ -16,6 +16,13 @@ func (g *Game) BeginCombat() {
 	}
 }
 
+// SummoningSick reports whether perm is a creature that came under its
+// controller's control this turn and lacks haste, so it can't attack or
+// use {T} abilities (CR 302.6).
+func (g *Game) SummoningSick(perm *Permanent) bool {
+	return perm.IsCreature() && perm.GetEnteredTurn() == g.GetTurnNumber() && !perm.HasKeyword(KWHaste)
+}
+
 // DeclareAttacker declares a single attacker against the specified defending player.
 func (g *Game) DeclareAttacker(attacker *Permanent, defendingPlayer *Player) error {
 	if g.combat == nil {
@@ -34,7 +41,7 @@ func (g *Game) DeclareAttacker(attacker *Permanent, defendingPlayer *Player) err
 		return fmt.Errorf("only active player may declare attackers")
 	}
 	// CR 302.6 / 702.10: Creatures with summoning sickness can't attack unless they have haste.
-	if attacker.GetEnteredTurn() == g.GetTurnNumber() && !attacker.HasKeyword(KWHaste) {
+	if g.SummoningSick(attacker) {
 		return fmt.Errorf("summoning sickness: creature can't attack this turn")
 	}
 	// CR 702.20: defenders can't attack.
@@ -47,6 +54,11 @@ func (g *Game) DeclareAttacker(attacker *Permanent, defendingPlayer *Player) err
 		attacker.Tap()
 	}
 	g.combat.attackers[attacker] = defendingPlayer
+	if attacker.attackTurn != g.turnNumber {
+		attacker.attackTurn, attacker.attacks = g.turnNumber, 0
+	}
+	attacker.attacks++
+	g.emit(Event{Type: EventAttacks, Attack: &Attack{Attacker: attacker, Defender: defendingPlayer}})
 	return nil
 }
 
@@ -186,7 +198,7 @@ func (g *Game) ResolveCombatDamage() {
 		if dmg <= 0 {
 			return 0
 		}
-		pl.SetLifeTotal(pl.GetLifeTotal() - dmg)
+		g.LoseLife(pl, dmg)
 		// CR 704.5u: track commander damage for the 21-damage SBA.
 		if src.IsCommander() {
 			pl.AddCommanderDamage(src.GetOwner(), src.GetName(), dmg)
@@ -227,7 +239,7 @@ func (g *Game) ResolveCombatDamage() {
 		}
 		// Trample excess
 		if a.HasKeyword(KWTrample) && remainingDmg > 0 && defender != nil {
-			defender.SetLifeTotal(defender.GetLifeTotal() - remainingDmg)
+			g.LoseLife(defender, remainingDmg)
 			if a.IsCommander() {
 				defender.AddCommanderDamage(a.GetOwner(), a.GetName(), remainingDmg)
 			}
@@ -49,17 +49,48 @@ type PermanentView struct {
 // LayeredEffect is one continuous effect registered with the engine.
 // Apply mutates view in place; Affects gates which permanents the effect
 // applies to. Source may be nil for global / game-level effects.
+//
+// Effects are data so that Game.Clone can copy them: Source and Target
+// are remapped to the clone's permanents, while Affects and Apply are
+// shared between a game and its clones and so must reach permanents only
+// through their arguments. A nil Apply applies Power and Toughness: set
+// in Sublayer 7B, added otherwise.
 type LayeredEffect struct {
-	ID         uint64
-	Layer      Layer
-	Sublayer   Sublayer
-	Source     *Permanent
+	ID       uint64
+	Layer    Layer
+	Sublayer Sublayer
+	Source   *Permanent
+	// Target, when set, limits the effect to that one permanent.
+	Target     *Permanent
 	Affects    func(p *Permanent) bool
 	Apply      func(p *Permanent, v *PermanentView)
+	Power      int
+	Toughness  int
 	ExpiresEOT bool
 	Timestamp  uint64
 }
 
+// applies reports whether the effect applies to p.
+func (eff *LayeredEffect) applies(p *Permanent) bool {
+	if eff.Target != nil && p != eff.Target {
+		return false
+	}
+	return eff.Affects == nil || eff.Affects(p)
+}
+
+// apply transforms v, the view of p, by the effect.
+func (eff *LayeredEffect) apply(p *Permanent, v *PermanentView) {
+	switch {
+	case eff.Apply != nil:
+		eff.Apply(p, v)
+	case eff.Sublayer == Sublayer7B:
+		v.Power, v.Toughness = eff.Power, eff.Toughness
+	default:
+		v.Power += eff.Power
+		v.Toughness += eff.Toughness
+	}
+}
+
 // continuous holds the active layered effects and bookkeeping for the
 // recompute pipeline.
 type continuous struct {
@@ -137,11 +168,11 @@ func (g *Game) RecomputeContinuous() {
 	for _, eff := range ordered {
 		for _, pl := range g.players {
 			for _, p := range pl.Battlefield {
-				if eff.Affects != nil && !eff.Affects(p) {
+				if !eff.applies(p) {
 					continue
 				}
 				v := &PermanentView{Power: p.effPower, Toughness: p.effToughness, SwapPT: p.effSwapPT}
-				eff.Apply(p, v)
+				eff.apply(p, v)
 				p.effPower = v.Power
 				p.effToughness = v.Toughness
 				p.effSwapPT = v.SwapPT
@@ -178,16 +209,13 @@ func (g *Game) ApplySetPTUntilEOT(p *Permanent, power, toughness int) {
 	if p == nil {
 		return
 	}
-	target := p
 	g.AddLayeredEffect(&LayeredEffect{
-		Layer:    Layer7PT,
-		Sublayer: Sublayer7B,
-		Source:   target,
-		Affects:  func(q *Permanent) bool { return q == target },
-		Apply: func(_ *Permanent, v *PermanentView) {
-			v.Power = power
-			v.Toughness = toughness
-		},
+		Layer:      Layer7PT,
+		Sublayer:   Sublayer7B,
+		Source:     p,
+		Target:     p,
+		Power:      power,
+		Toughness:  toughness,
 		ExpiresEOT: true,
 	})
 }
@@ -0,0 +1,48 @@
+package game
+
+// DelayedAction is a one-shot action waiting for a future step, e.g.
+// Necropotence's "put that card into your hand at the beginning of your next
+// end step" (CR 603.7).
+//
+// The action is data so that Game.Clone can copy it: Player and Permanent
+// are remapped to the clone's objects, and Do should reach the game only
+// through its arguments.
+type DelayedAction struct {
+	// Player is the player whose end step the action waits for.
+	Player *Player
+	// Permanent and Card are the objects the action acts on, if any.
+	Permanent *Permanent
+	Card      SimpleCard
+	Do        func(g *Game, d DelayedAction)
+}
+
+// AtBeginningOfNextEndStep schedules action to run when p's next end step
+// begins. If p's end step is already under way, it waits for the following one.
+// action is shared with clones of the game; use ScheduleAtNextEndStep for
+// actions that refer to players or permanents.
+func (g *Game) AtBeginningOfNextEndStep(p *Player, action func(g *Game)) {
+	g.ScheduleAtNextEndStep(DelayedAction{Player: p, Do: func(g *Game, _ DelayedAction) { action(g) }})
+}
+
+// ScheduleAtNextEndStep schedules d to run when d.Player's next end step
+// begins, like AtBeginningOfNextEndStep.
+func (g *Game) ScheduleAtNextEndStep(d DelayedAction) {
+	g.endStepActions = append(g.endStepActions, d)
+}
+
+// runEndStepActions fires the delayed actions belonging to the active player.
+func (g *Game) runEndStepActions() {
+	active := g.GetActivePlayerRaw()
+	var due, kept []DelayedAction
+	for _, d := range g.endStepActions {
+		if d.Player == active {
+			due = append(due, d)
+		} else {
+			kept = append(kept, d)
+		}
+	}
+	g.endStepActions = kept
+	for _, d := range due {
+		d.Do(g, d)
+	}
+}
@@ -0,0 +1,57 @@
+package game
+
+import "math/rand"
+
+// Determinization for search-based AI. A search can't look at hidden
+// cards, so it plays out many "possible worlds": copies of the game in
+// which the cards the searching player doesn't know are dealt at random
+// from the cards they could be.
+
+// Determinize returns an independent copy of the game as viewer might
+// imagine it. The copy is a Clone, except that only public state,
+// viewer's hand and the cards viewer knows to be in other hands are kept:
+// every other card in a hand or library is shuffled together with the
+// rest of its owner's unknown cards and dealt back, so zone sizes are
+// unchanged. viewer's own library is shuffled, except for the cards viewer
+// knows to be on top or at the bottom of it (KnownLibrary), which stay
+// where they are.
+func (g *Game) Determinize(viewer *Player, rng *rand.Rand) (*Game, *CloneMap) {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(1))
+	}
+	view := g.ViewFor(viewer)
+	fork, m := g.Clone()
+	for _, p := range g.players {
+		cp := m.Player(p)
+		if p == viewer {
+			top, bottom := p.KnownLibrary()
+			middle := cp.Library[len(top) : len(cp.Library)-len(bottom)]
+			rng.Shuffle(len(middle), func(a, b int) { middle[a], middle[b] = middle[b], middle[a] })
+			continue
+		}
+		known := view.KnownHand(p)
+		unknown := append(withoutCards(p.Hand, known), p.Library...)
+		rng.Shuffle(len(unknown), func(a, b int) { unknown[a], unknown[b] = unknown[b], unknown[a] })
+		n := len(p.Hand) - len(known)
+		cp.Hand = append(append([]SimpleCard{}, known...), unknown[:n]...)
+		cp.Library = append([]SimpleCard{}, unknown[n:]...)
+	}
+	return fork, m
+}
+
+// withoutCards returns cards minus one copy of each card in remove.
+func withoutCards(cards, remove []SimpleCard) []SimpleCard {
+	drop := map[string]int{}
+	for _, c := range remove {
+		drop[c.Name]++
+	}
+	var out []SimpleCard
+	for _, c := range cards {
+		if drop[c.Name] > 0 {
+			drop[c.Name]--
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -19,10 +19,10 @@ func TestE2E_TriggerWatcher_ETBAndReset(t *testing.T) {
 	// Trigger: when any creature ETBs under P1, draw a card
 	g.AddTrigger(&Trigger{
 		On: EventEntersBattlefield,
-		Condition: func(e Event) bool {
+		Condition: func(_ *Trigger, e Event) bool {
 			return e.ZoneChange != nil && e.ZoneChange.Permanent != nil && e.ZoneChange.Permanent.GetController() == p1 && e.ZoneChange.Permanent.IsCreature()
 		},
-		Action: func(g *Game, e Event) {
+		Action: func(g *Game, _ *Trigger, e Event) {
 			p1.Draw(1)
 		},
 	})
@@ -84,7 +84,7 @@ func (p *Player) LondonMulligan(rng *rand.Rand, mulligansTaken int) (int, error)
 	bottomed := make([]SimpleCard, bottom)
 	copy(bottomed, p.Hand[keep:])
 	p.Hand = p.Hand[:keep]
-	p.Library = append(p.Library, bottomed...)
+	p.PutOnBottom(bottomed...)
 	return bottom, nil
 }
 
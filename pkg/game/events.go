@@ -6,6 +6,11 @@ const (
 	EventZoneChange EventType = iota
 	EventEntersBattlefield
 	EventLeavesBattlefield
+	EventLifeLost
+	// EventBeginningOfCombat starts a combat phase (CR 507.1).
+	EventBeginningOfCombat
+	// EventAttacks is a creature being declared as an attacker (CR 508.1).
+	EventAttacks
 )
 
 type PermanentSnapshot struct {
@@ -23,18 +28,34 @@ type ZoneChange struct {
 	LKI       *PermanentSnapshot
 }
 
+// LifeLoss is the life a player lost (CR 119.3), whether to damage,
+// an effect or a cost.
+type LifeLoss struct {
+	Player *Player
+	Amount int
+}
+
+// Attack is a creature declared as an attacker and the player it attacks.
+type Attack struct {
+	Attacker *Permanent
+	Defender *Player
+}
+
 type Event struct {
 	Type       EventType
 	ZoneChange *ZoneChange
+	LifeLoss   *LifeLoss
+	Attack     *Attack
 }
 
-// Listener registration
-func (g *Game) AddListener(l func(Event)) { g.listeners = append(g.listeners, l) }
+// Listener registration. Listeners are shared with clones of the game
+// (Game.Clone), so they should reach the game through their arguments.
+func (g *Game) AddListener(l func(g *Game, e Event)) { g.listeners = append(g.listeners, l) }
 
 func (g *Game) emit(e Event) {
 	// Notify listeners first
 	for _, l := range g.listeners {
-		l(e)
+		l(g, e)
 	}
 	// Then process triggers and watchers
 	g.handleTriggers(e)
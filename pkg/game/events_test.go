@@ -12,7 +12,7 @@ func TestEvents_ETB_LTB_ZoneChange(t *testing.T) {
 	p1.Hand = append(p1.Hand, c)
 
 	var seen []Event
-	g.AddListener(func(e Event) { seen = append(seen, e) })
+	g.AddListener(func(_ *Game, e Event) { seen = append(seen, e) })
 
 	perm, err := g.SummonCreature(p1, "Bear")
 	if err != nil {
@@ -56,7 +56,7 @@ func TestEvents_GraveyardToExile(t *testing.T) {
 	p1.Graveyard = append(p1.Graveyard, card)
 
 	var last Event
-	g.AddListener(func(e Event) { last = e })
+	g.AddListener(func(_ *Game, e Event) { last = e })
 
 	ok := g.ExileFromGraveyard(p1, "Spell")
 	if !ok {
@@ -24,7 +24,7 @@ type Game struct {
 	currentPhase Phase
 
 	// event listeners
-	listeners []func(Event)
+	listeners []func(g *Game, e Event)
 
 	casting      *casting
 	combat       *combat
@@ -39,6 +39,18 @@ type Game struct {
 
 	// extra turns queued by card effects (e.g. Time Warp)
 	extraTurns int
+	// extra combat phases queued this turn (e.g. Godo, Bandit Warlord)
+	extraCombats int
+
+	// endStepActions are delayed actions waiting for a player's end step.
+	endStepActions []DelayedAction
+
+	// landLifePolicy decides shockland payments; nil uses DefaultLandLifePolicy.
+	landLifePolicy LandLifePolicy
+
+	// shown holds the cards each player has privately seen in other
+	// players' hands, keyed by viewer then owner; see ViewFor.
+	shown map[*Player]map[*Player][]SimpleCard
 }
 
 // ApplyTempPump grants a temporary power/toughness boost until end of turn.
@@ -75,6 +87,10 @@ func (g *Game) GetCurrentPhase() Phase { return g.currentPhase }
 
 // TakeExtraTurn queues one extra turn for the active player.
 func (g *Game) TakeExtraTurn() { g.extraTurns++ }
+
+// AddCombatPhase adds an additional combat phase after the current one
+// (CR 500.8).
+func (g *Game) AddCombatPhase() { g.extraCombats++ }
 func (g *Game) IsMainPhase() bool {
 	return g.currentPhase == PhaseMain1 || g.currentPhase == PhaseMain2
 }
@@ -131,10 +147,17 @@ func (g *Game) AdvancePhase() {
 		g.currentPhase = PhaseMain1
 	case PhaseMain1:
 		g.currentPhase = PhaseCombat
+		g.emit(Event{Type: EventBeginningOfCombat})
 	case PhaseCombat:
+		if g.extraCombats > 0 {
+			g.extraCombats--
+			g.emit(Event{Type: EventBeginningOfCombat})
+			break
+		}
 		g.currentPhase = PhaseMain2
 	case PhaseMain2:
 		g.currentPhase = PhaseEnd
+		g.runEndStepActions()
 	case PhaseEnd:
 		g.currentPhase = PhaseCleanup
 		g.clearUntilEndOfTurnEffects()
@@ -154,6 +177,8 @@ func (g *Game) AdvancePhase() {
 			}
 		}
 		g.currentPhase = PhaseUntap
+		g.extraCombats = 0
+		g.resetSpellCounts()
 	}
 }
 
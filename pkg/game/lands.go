@@ -0,0 +1,186 @@
+package game
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LandEntryKind classifies the replacement effect (CR 614.1c) that decides
+// whether a land enters the battlefield tapped.
+type LandEntryKind int
+
+const (
+	LandEntersUntapped              LandEntryKind = iota
+	LandEntersTapped                              // taplands, gainlands, tri-lands
+	LandEntersTappedUnlessPay                     // shocklands: "you may pay 2 life"
+	LandEntersTappedUnlessControl                 // checklands: "unless you control a Plains or an Island"
+	LandEntersTappedUnlessFewLands                // fastlands: "two or fewer other lands"
+	LandEntersTappedUnlessManyLands               // slowlands: "two or more other lands"
+	LandEntersTappedUnlessOpponents               // battlebond lands: "two or more opponents"
+)
+
+func (k LandEntryKind) String() string {
+	switch k {
+	case LandEntersUntapped:
+		return "untapped"
+	case LandEntersTapped:
+		return "tapped"
+	case LandEntersTappedUnlessPay:
+		return "shock"
+	case LandEntersTappedUnlessControl:
+		return "check"
+	case LandEntersTappedUnlessFewLands:
+		return "fast"
+	case LandEntersTappedUnlessManyLands:
+		return "slow"
+	case LandEntersTappedUnlessOpponents:
+		return "bond"
+	default:
+		return "unknown"
+	}
+}
+
+// LandEntry is a land's parsed entry condition.
+type LandEntry struct {
+	Kind LandEntryKind
+	// LifeCost is the life a shockland asks for to enter untapped.
+	LifeCost int
+	// CheckTypes are the land types a checkland looks for.
+	CheckTypes []string
+}
+
+var (
+	shockEntryRe = regexp.MustCompile(`you may pay (\d+) life\. if you don't, it enters(?: the battlefield)? tapped`)
+	checkEntryRe = regexp.MustCompile(`enters(?: the battlefield)? tapped unless you control an? (\w+)(?: or an? (\w+))?`)
+	fastEntryRe  = regexp.MustCompile(`enters(?: the battlefield)? tapped unless you control two or fewer other lands`)
+	slowEntryRe  = regexp.MustCompile(`enters(?: the battlefield)? tapped unless you control two or more other lands`)
+	bondEntryRe  = regexp.MustCompile(`enters(?: the battlefield)? tapped unless you have two or more opponents`)
+	tapEntryRe   = regexp.MustCompile(`enters(?: the battlefield)? tapped\.`)
+)
+
+// ClassifyLandEntry reads a land's oracle text for its entry condition.
+// Nonlands and lands without one enter untapped.
+func ClassifyLandEntry(c SimpleCard) LandEntry {
+	if !c.IsLand() {
+		return LandEntry{Kind: LandEntersUntapped}
+	}
+	text := strings.ToLower(c.OracleText)
+	if m := shockEntryRe.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return LandEntry{Kind: LandEntersTappedUnlessPay, LifeCost: n}
+	}
+	switch {
+	case fastEntryRe.MatchString(text):
+		return LandEntry{Kind: LandEntersTappedUnlessFewLands}
+	case slowEntryRe.MatchString(text):
+		return LandEntry{Kind: LandEntersTappedUnlessManyLands}
+	case bondEntryRe.MatchString(text):
+		return LandEntry{Kind: LandEntersTappedUnlessOpponents}
+	}
+	if m := checkEntryRe.FindStringSubmatch(text); m != nil {
+		var types []string
+		for _, t := range m[1:] {
+			if t != "" {
+				types = append(types, strings.ToUpper(t[:1])+t[1:])
+			}
+		}
+		return LandEntry{Kind: LandEntersTappedUnlessControl, CheckTypes: types}
+	}
+	if tapEntryRe.MatchString(text) {
+		return LandEntry{Kind: LandEntersTapped}
+	}
+	return LandEntry{Kind: LandEntersUntapped}
+}
+
+// LandLifePolicy decides whether p pays life to have land enter untapped.
+type LandLifePolicy func(g *Game, p *Player, land SimpleCard, life int) bool
+
+// SetLandLifePolicy overrides DefaultLandLifePolicy for this game.
+func (g *Game) SetLandLifePolicy(f LandLifePolicy) { g.landLifePolicy = f }
+
+// DefaultLandLifePolicy pays for a shockland only when the extra untapped
+// mana lets the player cast a spell from hand this turn that they could not
+// cast otherwise, and the payment leaves them above 5 life.
+func DefaultLandLifePolicy(g *Game, p *Player, land SimpleCard, life int) bool {
+	if p.GetLifeTotal()-life <= 5 {
+		return false
+	}
+	if g != nil && g.GetActivePlayerRaw() != p {
+		return false
+	}
+	untapped := 0
+	for _, perm := range p.GetLands() {
+		if !perm.IsTapped() && perm.GetSource().Name != land.Name {
+			untapped++
+		}
+	}
+	for _, c := range p.Hand {
+		if c.IsLand() {
+			continue
+		}
+		if mv := c.ManaValue(); mv == untapped+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// applyLandEntry resolves perm's entry condition as it enters the
+// battlefield under p's control (CR 614.12), tapping it or charging life.
+func (g *Game) applyLandEntry(p *Player, perm *Permanent) {
+	src := perm.GetSource()
+	entry := ClassifyLandEntry(src)
+	tapped := g.entersTapped(p, entry, perm)
+	if entry.Kind == LandEntersTappedUnlessPay {
+		policy := g.landLifePolicy
+		if policy == nil {
+			policy = DefaultLandLifePolicy
+		}
+		tapped = !(policy(g, p, src, entry.LifeCost) && p.PayLife(entry.LifeCost))
+	}
+	if tapped {
+		perm.Tap()
+	}
+}
+
+// WouldEnterTapped reports whether land would enter tapped if p put it onto
+// the battlefield now without paying any optional life.
+func (g *Game) WouldEnterTapped(p *Player, land SimpleCard) bool {
+	return g.entersTapped(p, ClassifyLandEntry(land), nil)
+}
+
+// entersTapped evaluates entry against p's board, ignoring self (the land
+// itself once it is already on the battlefield).
+func (g *Game) entersTapped(p *Player, entry LandEntry, self *Permanent) bool {
+	otherLands := 0
+	for _, l := range p.GetLands() {
+		if l != self {
+			otherLands++
+		}
+	}
+	switch entry.Kind {
+	case LandEntersTapped, LandEntersTappedUnlessPay:
+		return true
+	case LandEntersTappedUnlessControl:
+		for _, l := range p.GetLands() {
+			if l != self && ByBasicLandType(entry.CheckTypes...)(l.GetSource()) {
+				return false
+			}
+		}
+		return true
+	case LandEntersTappedUnlessFewLands:
+		return otherLands > 2
+	case LandEntersTappedUnlessManyLands:
+		return otherLands < 2
+	case LandEntersTappedUnlessOpponents:
+		opponents := 0
+		for _, o := range g.players {
+			if o != p && !o.HasLost() {
+				opponents++
+			}
+		}
+		return opponents < 2
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package game
+
+import "testing"
+
+func landCard(name, typeLine, text string) SimpleCard {
+	return SimpleCard{Name: name, TypeLine: typeLine, OracleText: text}
+}
+
+var (
+	wateryGrave     = landCard("Watery Grave", "Land — Island Swamp", "({T}: Add {U} or {B}.)\nAs Watery Grave enters the battlefield, you may pay 2 life. If you don't, it enters the battlefield tapped.")
+	drownedCatacomb = landCard("Drowned Catacomb", "Land", "Drowned Catacomb enters the battlefield tapped unless you control an Island or a Swamp.\n{T}: Add {U} or {B}.")
+	darkslickShores = landCard("Darkslick Shores", "Land", "Darkslick Shores enters the battlefield tapped unless you control two or fewer other lands.\n{T}: Add {U} or {B}.")
+	dimirGuildgate  = landCard("Dimir Guildgate", "Land — Gate", "Dimir Guildgate enters the battlefield tapped.\n{T}: Add {U} or {B}.")
+	morphicPool     = landCard("Morphic Pool", "Land", "Morphic Pool enters the battlefield tapped unless you have two or more opponents.\n{T}: Add {U} or {B}.")
+	basicIsland     = landCard("Island", "Basic Land — Island", "")
+)
+
+func TestClassifyLandEntry(t *testing.T) {
+	cases := []struct {
+		card SimpleCard
+		kind LandEntryKind
+	}{
+		{wateryGrave, LandEntersTappedUnlessPay},
+		{drownedCatacomb, LandEntersTappedUnlessControl},
+		{darkslickShores, LandEntersTappedUnlessFewLands},
+		{dimirGuildgate, LandEntersTapped},
+		{morphicPool, LandEntersTappedUnlessOpponents},
+		{basicIsland, LandEntersUntapped},
+		{landCard("Hallowed Fountain", "Land — Plains Island", "As this land enters, you may pay 2 life. If you don't, it enters tapped."), LandEntersTappedUnlessPay},
+	}
+	for _, tc := range cases {
+		if got := ClassifyLandEntry(tc.card); got.Kind != tc.kind {
+			t.Errorf("%s: got %v, want %v", tc.card.Name, got.Kind, tc.kind)
+		}
+	}
+	if e := ClassifyLandEntry(wateryGrave); e.LifeCost != 2 {
+		t.Errorf("expected shock life cost 2, got %d", e.LifeCost)
+	}
+	if e := ClassifyLandEntry(drownedCatacomb); len(e.CheckTypes) != 2 || e.CheckTypes[0] != "Island" || e.CheckTypes[1] != "Swamp" {
+		t.Errorf("unexpected check types %v", e.CheckTypes)
+	}
+}
+
+func TestPlayLand_EntryConditions(t *testing.T) {
+	p := NewPlayer("Alice", 40)
+	g := NewGame(p, NewPlayer("Bob", 40))
+
+	p.Hand = []SimpleCard{dimirGuildgate, drownedCatacomb}
+	gate, _ := g.PlayLand(p, "Dimir Guildgate")
+	if !gate.IsTapped() {
+		t.Fatalf("guildgate should enter tapped")
+	}
+	// Gate is not an Island or Swamp, so the checkland enters tapped.
+	check, _ := g.PlayLand(p, "Drowned Catacomb")
+	if !check.IsTapped() {
+		t.Fatalf("checkland should enter tapped without an Island or Swamp")
+	}
+
+	p.Battlefield = append(p.Battlefield, NewPermanent(basicIsland, p, p))
+	p.Hand = []SimpleCard{drownedCatacomb}
+	check, _ = g.PlayLand(p, "Drowned Catacomb")
+	if check.IsTapped() {
+		t.Fatalf("checkland should enter untapped with an Island")
+	}
+
+	// Four other lands: the fastland enters tapped.
+	p.Hand = []SimpleCard{darkslickShores}
+	fast, _ := g.PlayLand(p, "Darkslick Shores")
+	if !fast.IsTapped() {
+		t.Fatalf("fastland should enter tapped with more than two other lands")
+	}
+
+	// Two players: one opponent, so the bond land enters tapped.
+	p.Hand = []SimpleCard{morphicPool}
+	bond, _ := g.PlayLand(p, "Morphic Pool")
+	if !bond.IsTapped() {
+		t.Fatalf("bond land should enter tapped with one opponent")
+	}
+}
+
+func TestPlayLand_ShocklandPaysLife(t *testing.T) {
+	p := NewPlayer("Alice", 40)
+	g := NewGame(p, NewPlayer("Bob", 40))
+	g.SetLandLifePolicy(func(*Game, *Player, SimpleCard, int) bool { return true })
+	p.Hand = []SimpleCard{wateryGrave}
+	perm, _ := g.PlayLand(p, "Watery Grave")
+	if perm.IsTapped() || p.GetLifeTotal() != 38 {
+		t.Fatalf("expected untapped shock for 2 life, tapped=%v life=%d", perm.IsTapped(), p.GetLifeTotal())
+	}
+
+	g.SetLandLifePolicy(func(*Game, *Player, SimpleCard, int) bool { return false })
+	p.Hand = []SimpleCard{wateryGrave}
+	perm, _ = g.PlayLand(p, "Watery Grave")
+	if !perm.IsTapped() || p.GetLifeTotal() != 38 {
+		t.Fatalf("declined shock should enter tapped without life loss")
+	}
+}
+
+func TestDefaultLandLifePolicy_PaysOnlyToCastThisTurn(t *testing.T) {
+	p := NewPlayer("Alice", 40)
+	g := NewGame(p, NewPlayer("Bob", 40))
+	p.Hand = []SimpleCard{wateryGrave, {Name: "Counterspell", TypeLine: "Instant", ManaCost: "{U}{U}"}}
+	p.Battlefield = append(p.Battlefield, NewPermanent(basicIsland, p, p))
+	perm, _ := g.PlayLand(p, "Watery Grave")
+	if perm.IsTapped() || p.GetLifeTotal() != 38 {
+		t.Fatalf("expected payment to enable a two-mana spell")
+	}
+
+	q := NewPlayer("Carol", 40)
+	g2 := NewGame(q, NewPlayer("Dan", 40))
+	q.Hand = []SimpleCard{wateryGrave, {Name: "Cryptic Command", TypeLine: "Instant", ManaCost: "{1}{U}{U}{U}"}}
+	perm, _ = g2.PlayLand(q, "Watery Grave")
+	if !perm.IsTapped() || q.GetLifeTotal() != 40 {
+		t.Fatalf("no spell needs the mana; shock should enter tapped for free")
+	}
+}
+
+func TestSearchLibrary_FetchedShockAppliesEntry(t *testing.T) {
+	p := NewPlayer("Alice", 40)
+	g := NewGame(p, NewPlayer("Bob", 40))
+	g.SetLandLifePolicy(func(*Game, *Player, SimpleCard, int) bool { return true })
+	p.Library = []SimpleCard{wateryGrave}
+	res := g.SearchLibrary(p, LibrarySearch{Filter: ByBasicLandType("Island"), Max: 1, Dest: Battlefield}, nil)
+	if len(res.Permanents) != 1 || res.Permanents[0].IsTapped() || p.GetLifeTotal() != 38 {
+		t.Fatalf("fetched shockland should offer the life payment")
+	}
+}
+
+func TestPayLife(t *testing.T) {
+	p := NewPlayer("Alice", 1)
+	if p.PayLife(2) {
+		t.Fatalf("cannot pay more life than you have")
+	}
+	if !p.PayLife(1) || p.GetLifeTotal() != 0 {
+		t.Fatalf("paying exactly your life total is allowed")
+	}
+}
@@ -0,0 +1,545 @@
+package game
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// CardFilter is a predicate over cards used by library searches and
+// "look at the top N" effects.
+type CardFilter func(SimpleCard) bool
+
+// AnyCard matches every card ("search your library for a card").
+func AnyCard() CardFilter { return func(SimpleCard) bool { return true } }
+
+// ByName matches cards with the given name, case-insensitively.
+func ByName(name string) CardFilter {
+	return func(c SimpleCard) bool { return strings.EqualFold(c.Name, name) }
+}
+
+// ByType matches cards whose type line contains the given card type or
+// subtype word (e.g. "Creature", "Artifact", "Equipment").
+func ByType(t string) CardFilter {
+	return func(c SimpleCard) bool { return hasTypeWord(c.TypeLine, t) }
+}
+
+// ByMaxManaValue matches cards with mana value n or less (CR 202.3).
+func ByMaxManaValue(n int) CardFilter {
+	return func(c SimpleCard) bool { return c.ManaValue() <= n }
+}
+
+// ByManaValue matches cards with mana value exactly n.
+func ByManaValue(n int) CardFilter {
+	return func(c SimpleCard) bool { return c.ManaValue() == n }
+}
+
+// ByBasicLandType matches cards having at least one of the given basic land
+// types (CR 205.3i). Fetchlands such as Flooded Strand search this way, so
+// typed duals ("Land — Island Swamp") are valid results.
+func ByBasicLandType(types ...string) CardFilter {
+	return func(c SimpleCard) bool {
+		for _, have := range c.BasicLandTypes() {
+			for _, want := range types {
+				if strings.EqualFold(have, want) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// IsBasicLand matches cards with the Basic supertype that are lands.
+func IsBasicLand() CardFilter {
+	return func(c SimpleCard) bool { return c.IsLand() && hasTypeWord(c.TypeLine, "Basic") }
+}
+
+// AllOf matches cards satisfying every filter. Nil filters are ignored.
+func AllOf(filters ...CardFilter) CardFilter {
+	return func(c SimpleCard) bool {
+		for _, f := range filters {
+			if f != nil && !f(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf matches cards satisfying at least one filter.
+func AnyOf(filters ...CardFilter) CardFilter {
+	return func(c SimpleCard) bool {
+		for _, f := range filters {
+			if f != nil && f(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not inverts a filter.
+func Not(f CardFilter) CardFilter {
+	return func(c SimpleCard) bool { return !f(c) }
+}
+
+func hasTypeWord(typeLine, word string) bool {
+	for _, w := range strings.FieldsFunc(typeLine, func(r rune) bool { return r == ' ' || r == '—' || r == '-' || r == '/' }) {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// LibraryChooser makes the hidden-information choices for library effects.
+// Implementations return indices into the cards they were shown; invalid
+// answers are ignored in favour of DefaultLibraryChooser's.
+type LibraryChooser interface {
+	// ChooseSearch picks up to max of the candidates (all matching the
+	// search filter). Returning fewer is allowed: a search may fail to
+	// find.
+	ChooseSearch(p *Player, candidates []SimpleCard, max int) []int
+	// ChooseScry splits the looked-at cards into the new top of library
+	// (first index on top) and the cards to put on the bottom (first
+	// index placed first, so the last index ends up lowest).
+	ChooseScry(p *Player, cards []SimpleCard) (top, bottom []int)
+	// ChooseSurveil splits the looked-at cards into those kept on top (in
+	// order) and those put into the graveyard.
+	ChooseSurveil(p *Player, cards []SimpleCard) (top, graveyard []int)
+	// ChooseFromTop picks up to take cards satisfying filter to keep, and
+	// orders the remainder for the bottom of the library.
+	ChooseFromTop(p *Player, cards []SimpleCard, take int, filter CardFilter) (taken, bottom []int)
+}
+
+// LibrarySearch describes one "search your library for ..." instruction.
+type LibrarySearch struct {
+	Filter  CardFilter
+	Max     int
+	Dest    Zone // Hand (the default), Battlefield, Graveyard, Exile, or Library (top)
+	Tapped  bool // battlefield destination only
+	Reveal  bool
+	Shuffle bool
+	// Rng drives the shuffle; nil uses the package-level source.
+	Rng *rand.Rand
+}
+
+// SearchResult reports what a search found and where it went.
+type SearchResult struct {
+	Cards      []SimpleCard
+	Permanents []*Permanent
+}
+
+// SearchLibrary searches the library for up to s.Max cards matching
+// s.Filter and moves the chosen cards to s.Dest, or into the hand when
+// s.Dest is unset. The chooser picks among candidates; nil uses
+// DefaultLibraryChooser. Cards put onto the battlefield become permanents
+// owned and controlled by p, tapped when s.Tapped is set. When s.Dest is
+// Library the shuffle happens first and the found cards are placed on
+// top, as tutors like Vampiric Tutor read.
+func (p *Player) SearchLibrary(s LibrarySearch, chooser LibraryChooser) SearchResult {
+	if chooser == nil {
+		chooser = DefaultLibraryChooser{}
+	}
+	filter := s.Filter
+	if filter == nil {
+		filter = AnyCard()
+	}
+	var res SearchResult
+	if s.Max <= 0 {
+		if s.Shuffle {
+			p.ShuffleLibrary(s.Rng)
+		}
+		return res
+	}
+
+	var candIdx []int
+	var candidates []SimpleCard
+	for i, c := range p.Library {
+		if filter(c) {
+			candIdx = append(candIdx, i)
+			candidates = append(candidates, c)
+		}
+	}
+	picks := validPicks(chooser.ChooseSearch(p, candidates, s.Max), len(candidates), s.Max)
+	if picks == nil {
+		picks = validPicks(DefaultLibraryChooser{}.ChooseSearch(p, candidates, s.Max), len(candidates), s.Max)
+	}
+
+	remove := map[int]bool{}
+	for _, pi := range picks {
+		res.Cards = append(res.Cards, candidates[pi])
+		remove[candIdx[pi]] = true
+	}
+	if len(remove) > 0 {
+		rest := make([]SimpleCard, 0, len(p.Library)-len(remove))
+		for i, c := range p.Library {
+			if !remove[i] {
+				rest = append(rest, c)
+			}
+		}
+		p.Library = rest
+	}
+	if s.Reveal {
+		p.Reveal(res.Cards...)
+	}
+	if s.Shuffle {
+		p.ShuffleLibrary(s.Rng)
+	}
+
+	switch s.Dest {
+	case Battlefield:
+		for _, c := range res.Cards {
+			perm := NewPermanent(c, p, p)
+			if s.Tapped {
+				perm.Tap()
+			}
+			p.Battlefield = append(p.Battlefield, perm)
+			res.Permanents = append(res.Permanents, perm)
+		}
+	case Graveyard:
+		p.Graveyard = append(p.Graveyard, res.Cards...)
+	case Exile:
+		p.Exile = append(p.Exile, res.Cards...)
+	case Library:
+		p.PutOnTop(res.Cards...)
+	default:
+		p.Hand = append(p.Hand, res.Cards...)
+	}
+	return res
+}
+
+// ShuffleLibrary randomizes the library. A nil rng uses the
+// package-level source.
+func (p *Player) ShuffleLibrary(rng *rand.Rand) {
+	p.knownTop, p.knownBottom = nil, nil
+	swap := func(i, j int) { p.Library[i], p.Library[j] = p.Library[j], p.Library[i] }
+	if rng == nil {
+		rand.Shuffle(len(p.Library), swap)
+		return
+	}
+	rng.Shuffle(len(p.Library), swap)
+}
+
+// PutOnTop places cards on top of the library; cards[0] ends up on top.
+func (p *Player) PutOnTop(cards ...SimpleCard) {
+	top, _ := p.KnownLibrary()
+	p.knownTop = append(append([]SimpleCard{}, cards...), top...)
+	lib := make([]SimpleCard, 0, len(cards)+len(p.Library))
+	lib = append(lib, cards...)
+	p.Library = append(lib, p.Library...)
+}
+
+// PutOnBottom places cards on the bottom of the library in the given order;
+// the last card ends up lowest.
+func (p *Player) PutOnBottom(cards ...SimpleCard) {
+	_, bottom := p.KnownLibrary()
+	p.knownBottom = append(append([]SimpleCard{}, bottom...), cards...)
+	p.Library = append(p.Library, cards...)
+}
+
+// KnownLibrary returns the cards the player knows to be on top of and at
+// the bottom of their library, top first: those they put there with
+// PutOnTop and PutOnBottom (a tutor's card, a scry, mulliganed cards)
+// since the library was last shuffled, less any that have left it.
+func (p *Player) KnownLibrary() (top, bottom []SimpleCard) {
+	for i := range p.knownTop {
+		if hasPrefix(p.Library, p.knownTop[i:]) {
+			top = p.knownTop[i:]
+			break
+		}
+	}
+	rest := p.Library[len(top):]
+	for i := len(p.knownBottom); i >= 0; i-- {
+		if hasPrefix(rest[max(len(rest)-i, 0):], p.knownBottom[:i]) {
+			bottom = p.knownBottom[:i]
+			break
+		}
+	}
+	return top, bottom
+}
+
+// hasPrefix reports whether cards starts with prefix, by name.
+func hasPrefix(cards, prefix []SimpleCard) bool {
+	if len(prefix) > len(cards) {
+		return false
+	}
+	for i, c := range prefix {
+		if cards[i].Name != c.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// Reveal records cards as revealed. Revealed cards that are
+// still in hand are public information; see RevealedCards.
+func (p *Player) Reveal(cards ...SimpleCard) {
+	p.revealed = append(p.revealed, cards...)
+}
+
+// RevealedCards returns the revealed cards currently in the player's hand.
+// A card revealed on its way to the top of the library becomes known once
+// it is drawn; copies that have left the hand are not reported.
+func (p *Player) RevealedCards() []SimpleCard {
+	return stillInHand(p, p.revealed)
+}
+
+// Scry looks at the top n cards and lets the chooser keep any of them on
+// top in any order and put the rest on the bottom in any order.
+func (p *Player) Scry(n int, chooser LibraryChooser) (top, bottom []SimpleCard) {
+	cards := p.takeTop(n)
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	if chooser == nil {
+		chooser = DefaultLibraryChooser{}
+	}
+	t, b := chooser.ChooseScry(p, cards)
+	if !isPartition(len(cards), t, b) {
+		t, b = DefaultLibraryChooser{}.ChooseScry(p, cards)
+	}
+	top, bottom = pick(cards, t), pick(cards, b)
+	p.PutOnTop(top...)
+	p.PutOnBottom(bottom...)
+	return top, bottom
+}
+
+// Surveil looks at the top n cards and lets the chooser put any of them
+// into the graveyard and the rest back on top in any order.
+func (p *Player) Surveil(n int, chooser LibraryChooser) (top, graveyard []SimpleCard) {
+	cards := p.takeTop(n)
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	if chooser == nil {
+		chooser = DefaultLibraryChooser{}
+	}
+	t, gy := chooser.ChooseSurveil(p, cards)
+	if !isPartition(len(cards), t, gy) {
+		t, gy = DefaultLibraryChooser{}.ChooseSurveil(p, cards)
+	}
+	top, graveyard = pick(cards, t), pick(cards, gy)
+	p.PutOnTop(top...)
+	p.Graveyard = append(p.Graveyard, graveyard...)
+	return top, graveyard
+}
+
+// LookAtTop implements "look at the top n cards of your library, put up to
+// take of them [matching filter] into dest, and the rest on the bottom of
+// your library in any order" (Impulse, Dig Through Time, Sylvan Scrying's
+// cousins). A nil filter allows any card.
+func (p *Player) LookAtTop(n, take int, filter CardFilter, dest Zone, chooser LibraryChooser) (taken, bottom []SimpleCard) {
+	cards := p.takeTop(n)
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	if chooser == nil {
+		chooser = DefaultLibraryChooser{}
+	}
+	if filter == nil {
+		filter = AnyCard()
+	}
+	tk, b := chooser.ChooseFromTop(p, cards, take, filter)
+	if !isPartition(len(cards), tk, b) || len(tk) > take || !allMatch(cards, tk, filter) {
+		tk, b = DefaultLibraryChooser{}.ChooseFromTop(p, cards, take, filter)
+	}
+	taken, bottom = pick(cards, tk), pick(cards, b)
+	switch dest {
+	case Battlefield:
+		for _, c := range taken {
+			p.Battlefield = append(p.Battlefield, NewPermanent(c, p, p))
+		}
+	case Graveyard:
+		p.Graveyard = append(p.Graveyard, taken...)
+	case Exile:
+		p.Exile = append(p.Exile, taken...)
+	default:
+		p.Hand = append(p.Hand, taken...)
+	}
+	p.PutOnBottom(bottom...)
+	return taken, bottom
+}
+
+func (p *Player) takeTop(n int) []SimpleCard {
+	if n > len(p.Library) {
+		n = len(p.Library)
+	}
+	if n <= 0 {
+		return nil
+	}
+	cards := make([]SimpleCard, n)
+	copy(cards, p.Library[:n])
+	p.Library = p.Library[n:]
+	return cards
+}
+
+func pick(cards []SimpleCard, idx []int) []SimpleCard {
+	out := make([]SimpleCard, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, cards[i])
+	}
+	return out
+}
+
+// validPicks returns the picks if they are distinct, in range and no more
+// than max; otherwise nil. An empty non-nil slice is a valid "fail to find".
+func validPicks(picks []int, n, max int) []int {
+	if picks == nil || len(picks) > max {
+		return nil
+	}
+	seen := map[int]bool{}
+	for _, i := range picks {
+		if i < 0 || i >= n || seen[i] {
+			return nil
+		}
+		seen[i] = true
+	}
+	return picks
+}
+
+// isPartition reports whether a and b together name each of 0..n-1 once.
+func isPartition(n int, a, b []int) bool {
+	if len(a)+len(b) != n {
+		return false
+	}
+	seen := make([]bool, n)
+	for _, i := range append(append([]int(nil), a...), b...) {
+		if i < 0 || i >= n || seen[i] {
+			return false
+		}
+		seen[i] = true
+	}
+	return true
+}
+
+func allMatch(cards []SimpleCard, idx []int, f CardFilter) bool {
+	for _, i := range idx {
+		if !f(cards[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultLibraryChooser ranks cards with LibraryCardScore: lands while the
+// player is short on mana, castable spells otherwise.
+type DefaultLibraryChooser struct{}
+
+func (DefaultLibraryChooser) ChooseSearch(p *Player, candidates []SimpleCard, max int) []int {
+	order := RankCards(p, candidates, LibraryCardScore)
+	if len(order) > max {
+		order = order[:max]
+	}
+	return order
+}
+
+func (DefaultLibraryChooser) ChooseScry(p *Player, cards []SimpleCard) (top, bottom []int) {
+	return splitByScore(p, cards, LibraryCardScore)
+}
+
+func (DefaultLibraryChooser) ChooseSurveil(p *Player, cards []SimpleCard) (top, graveyard []int) {
+	return splitByScore(p, cards, LibraryCardScore)
+}
+
+func (DefaultLibraryChooser) ChooseFromTop(p *Player, cards []SimpleCard, take int, filter CardFilter) (taken, bottom []int) {
+	return TakeBest(p, cards, take, filter, LibraryCardScore)
+}
+
+// RankCards returns indices of cards ordered best-first by score. Ties keep
+// library order so results are deterministic.
+func RankCards(p *Player, cards []SimpleCard, score func(*Player, SimpleCard) int) []int {
+	idx := make([]int, len(cards))
+	scores := make([]int, len(cards))
+	for i, c := range cards {
+		idx[i] = i
+		scores[i] = score(p, c)
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	return idx
+}
+
+// TakeBest picks up to take of the highest-scoring cards matching filter and
+// returns the rest worst-last for the bottom of the library.
+func TakeBest(p *Player, cards []SimpleCard, take int, filter CardFilter, score func(*Player, SimpleCard) int) (taken, bottom []int) {
+	for _, i := range RankCards(p, cards, score) {
+		if len(taken) < take && (filter == nil || filter(cards[i])) {
+			taken = append(taken, i)
+		} else {
+			bottom = append(bottom, i)
+		}
+	}
+	return taken, bottom
+}
+
+// splitByScore keeps cards scoring at least LibraryKeepThreshold on top, best
+// first, and sends the rest away worst-last.
+func splitByScore(p *Player, cards []SimpleCard, score func(*Player, SimpleCard) int) (keep, away []int) {
+	for _, i := range RankCards(p, cards, score) {
+		if score(p, cards[i]) >= LibraryKeepThreshold {
+			keep = append(keep, i)
+		} else {
+			away = append(away, i)
+		}
+	}
+	return keep, away
+}
+
+// LibraryKeepThreshold is the score at or above which scry and surveil keep
+// a card on top.
+const LibraryKeepThreshold = 10
+
+// LibraryCardScore is the default value of drawing c next. Lands are worth
+// the most while the player has fewer than five mana sources; spells score
+// higher when castable with the lands the player will have next turn.
+func LibraryCardScore(p *Player, c SimpleCard) int {
+	lands := len(p.GetLands())
+	for _, h := range p.Hand {
+		if h.IsLand() {
+			lands++
+		}
+	}
+	if c.IsLand() {
+		switch {
+		case lands < 3:
+			return 30
+		case lands < 5:
+			return 15
+		default:
+			return 5
+		}
+	}
+	mv := c.ManaValue()
+	if mv <= lands+1 {
+		return 12 + mv
+	}
+	return 8 - (mv - lands)
+}
+
+// ManaValue returns the card's mana value (CR 202.3). For split alternate
+// costs the cheapest option is used.
+func (c SimpleCard) ManaValue() int {
+	return c.GetMinManaCost().Total()
+}
+
+// BasicLandTypes returns the basic land subtypes on the type line
+// (Plains, Island, Swamp, Mountain, Forest).
+func (c SimpleCard) BasicLandTypes() []string {
+	var out []string
+	for _, t := range []string{"Plains", "Island", "Swamp", "Mountain", "Forest"} {
+		if c.IsLand() && hasTypeWord(subtypes(c.TypeLine), t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func subtypes(typeLine string) string {
+	for _, sep := range []string{"—", " - "} {
+		if i := strings.Index(typeLine, sep); i >= 0 {
+			return typeLine[i+len(sep):]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func libCard(name, typeLine, cost string) SimpleCard {
+	return SimpleCard{Name: name, TypeLine: typeLine, ManaCost: cost}
+}
+
+func TestSearchLibrary_FiltersByBasicLandTypeOntoBattlefieldTapped(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	p.Library = []SimpleCard{
+		libCard("Counterspell", "Instant", "{U}{U}"),
+		libCard("Mountain", "Basic Land — Mountain", ""),
+		libCard("Watery Grave", "Land — Island Swamp", ""),
+		libCard("Island", "Basic Land — Island", ""),
+	}
+	g := NewGame(p, NewPlayer("Bob", 20))
+
+	res := g.SearchLibrary(p, LibrarySearch{
+		Filter: ByBasicLandType("Island", "Swamp"), Max: 1, Dest: Battlefield, Tapped: true,
+		Shuffle: true, Rng: rand.New(rand.NewSource(1)),
+	}, nil)
+	if len(res.Permanents) != 1 {
+		t.Fatalf("expected one permanent, got %d", len(res.Permanents))
+	}
+	name := res.Permanents[0].GetName()
+	if name != "Watery Grave" && name != "Island" {
+		t.Fatalf("found %s, which is not an Island or Swamp", name)
+	}
+	if !res.Permanents[0].IsTapped() {
+		t.Fatalf("expected fetched land to enter tapped")
+	}
+	if len(p.Library) != 3 || len(p.Battlefield) != 1 {
+		t.Fatalf("expected library=3 battlefield=1, got %d %d", len(p.Library), len(p.Battlefield))
+	}
+}
+
+func TestSearchLibrary_NameAndManaValueFilters(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	p.Library = []SimpleCard{
+		libCard("Grizzly Bears", "Creature — Bear", "{1}{G}"),
+		libCard("Thassa's Oracle", "Creature — Merfolk Wizard", "{U}{U}"),
+		libCard("Craterhoof Behemoth", "Creature — Beast", "{5}{G}{G}{G}"),
+	}
+	got := p.SearchLibrary(LibrarySearch{Filter: ByName("thassa's oracle"), Max: 1, Dest: Hand}, nil)
+	if len(got.Cards) != 1 || got.Cards[0].Name != "Thassa's Oracle" || len(p.Hand) != 1 {
+		t.Fatalf("expected Thassa's Oracle in hand, got %+v", got.Cards)
+	}
+	got = p.SearchLibrary(LibrarySearch{Filter: AllOf(ByType("Creature"), ByMaxManaValue(3)), Max: 2, Dest: Graveyard}, nil)
+	if len(got.Cards) != 1 || got.Cards[0].Name != "Grizzly Bears" || len(p.Graveyard) != 1 {
+		t.Fatalf("expected only Grizzly Bears to match mana value 3 or less, got %+v", got.Cards)
+	}
+}
+
+func TestSearchLibrary_UnsetDestinationIsTheHand(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	p.Library = []SimpleCard{libCard("Forest", "Basic Land — Forest", ""), libCard("Sol Ring", "Artifact", "{1}")}
+	p.SearchLibrary(LibrarySearch{Filter: ByName("Sol Ring"), Max: 1}, nil)
+	if len(p.Hand) != 1 || p.Hand[0].Name != "Sol Ring" || len(p.Library) != 1 {
+		t.Fatalf("expected Sol Ring in hand, hand %v library %v", p.Hand, p.Library)
+	}
+}
+
+func TestSearchLibrary_TopOfLibraryAndReveal(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	p.Library = []SimpleCard{
+		libCard("Forest", "Basic Land — Forest", ""),
+		libCard("Demonic Consultation", "Instant", "{B}"),
+		libCard("Swamp", "Basic Land — Swamp", ""),
+	}
+	p.SearchLibrary(LibrarySearch{Filter: ByName("Demonic Consultation"), Max: 1, Dest: Library, Shuffle: true, Reveal: true}, nil)
+	if p.Library[0].Name != "Demonic Consultation" {
+		t.Fatalf("expected tutored card on top, got %s", p.Library[0].Name)
+	}
+	if len(p.RevealedCards()) != 0 {
+		t.Fatalf("revealed card is not in hand yet; expected none known")
+	}
+	p.Draw(1)
+	if rc := p.RevealedCards(); len(rc) != 1 || rc[0].Name != "Demonic Consultation" {
+		t.Fatalf("expected drawn revealed card to be known, got %+v", rc)
+	}
+}
+
+func TestKnownLibrary_TracksTutoredAndBottomedCardsUntilShuffled(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	for _, name := range []string{"Forest", "Island", "Swamp", "Plains", "Mountain"} {
+		p.Library = append(p.Library, libCard(name, "Basic Land", ""))
+	}
+	p.Library = append(p.Library, libCard("Demonic Consultation", "Instant", "{B}"))
+	p.SearchLibrary(LibrarySearch{Filter: ByName("Demonic Consultation"), Max: 1, Dest: Library, Shuffle: true, Rng: rand.New(rand.NewSource(1))}, nil)
+	p.Scry(2, scriptedChooser{top: []int{0}, bottom: []int{1}})
+	top, bottom := p.KnownLibrary()
+	if len(top) != 1 || top[0].Name != "Demonic Consultation" || len(bottom) != 1 || bottom[0].Name != p.Library[len(p.Library)-1].Name {
+		t.Fatalf("expected the tutored card on top and the scried card at the bottom, got %+v / %+v", top, bottom)
+	}
+	p.Draw(1)
+	if top, _ := p.KnownLibrary(); len(top) != 0 {
+		t.Fatalf("a drawn card is no longer in the library, got %+v", top)
+	}
+	p.ShuffleLibrary(nil)
+	if top, bottom := p.KnownLibrary(); len(top)+len(bottom) != 0 {
+		t.Fatalf("shuffling forgets the library's order, got %+v / %+v", top, bottom)
+	}
+}
+
+type scriptedChooser struct {
+	DefaultLibraryChooser
+	top, bottom []int
+}
+
+func (s scriptedChooser) ChooseScry(*Player, []SimpleCard) ([]int, []int) { return s.top, s.bottom }
+
+func TestScry_UsesChooserOrdering(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	a, b, c, d := libCard("A", "Instant", "{U}"), libCard("B", "Instant", "{U}"), libCard("C", "Instant", "{U}"), libCard("D", "Instant", "{U}")
+	p.Library = []SimpleCard{a, b, c, d}
+
+	top, bottom := p.Scry(3, scriptedChooser{top: []int{2, 0}, bottom: []int{1}})
+	if len(top) != 2 || len(bottom) != 1 {
+		t.Fatalf("unexpected split top=%v bottom=%v", top, bottom)
+	}
+	want := []string{"C", "A", "D", "B"}
+	for i, w := range want {
+		if p.Library[i].Name != w {
+			t.Fatalf("library[%d]=%s, want %s", i, p.Library[i].Name, w)
+		}
+	}
+
+	// An invalid answer (index repeated) falls back to the default chooser
+	// and never loses cards.
+	p.Scry(2, scriptedChooser{top: []int{0, 0}})
+	if len(p.Library) != 4 {
+		t.Fatalf("expected library size preserved, got %d", len(p.Library))
+	}
+}
+
+func TestSurveil_DefaultBinsExcessLands(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	for i := 0; i < 6; i++ {
+		p.Battlefield = append(p.Battlefield, NewPermanent(libCard("Island", "Basic Land — Island", ""), p, p))
+	}
+	p.Library = []SimpleCard{libCard("Island", "Basic Land — Island", ""), libCard("Brainstorm", "Instant", "{U}")}
+	top, gy := p.Surveil(2, nil)
+	if len(top) != 1 || top[0].Name != "Brainstorm" || len(gy) != 1 || gy[0].Name != "Island" {
+		t.Fatalf("expected Brainstorm kept and Island binned, got top=%v gy=%v", top, gy)
+	}
+	if len(p.Graveyard) != 1 || p.Library[0].Name != "Brainstorm" {
+		t.Fatalf("unexpected zones after surveil")
+	}
+}
+
+func TestLookAtTop_TakesMatchingAndBottomsRest(t *testing.T) {
+	p := NewPlayer("Alice", 20)
+	p.Library = []SimpleCard{
+		libCard("Ponder", "Sorcery", "{U}"),
+		libCard("Llanowar Elves", "Creature — Elf Druid", "{G}"),
+		libCard("Forest", "Basic Land — Forest", ""),
+		libCard("Deep Card", "Sorcery", "{U}"),
+	}
+	taken, bottom := p.LookAtTop(3, 1, ByType("Creature"), Hand, nil)
+	if len(taken) != 1 || taken[0].Name != "Llanowar Elves" {
+		t.Fatalf("expected to take Llanowar Elves, got %v", taken)
+	}
+	if len(bottom) != 2 || len(p.Library) != 3 || p.Library[0].Name != "Deep Card" {
+		t.Fatalf("expected two cards bottomed under Deep Card, got library %v", p.Library)
+	}
+	if p.FindCardInHand("Llanowar Elves") < 0 {
+		t.Fatalf("expected taken card in hand")
+	}
+}
+
+func TestBasicLandTypes(t *testing.T) {
+	if got := libCard("Watery Grave", "Land — Island Swamp", "").BasicLandTypes(); len(got) != 2 {
+		t.Fatalf("expected Island and Swamp, got %v", got)
+	}
+	if got := libCard("Islandwalker", "Creature — Island Spirit", "").BasicLandTypes(); len(got) != 0 {
+		t.Fatalf("non-land should have no basic land types, got %v", got)
+	}
+}
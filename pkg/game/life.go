@@ -0,0 +1,12 @@
+package game
+
+// LoseLife makes p lose n life and emits EventLifeLost, so "whenever a
+// player loses life" triggers see it. Damage dealt to a player goes
+// through here too (CR 120.3a).
+func (g *Game) LoseLife(p *Player, n int) {
+	if p == nil || n <= 0 {
+		return
+	}
+	p.SetLifeTotal(p.GetLifeTotal() - n)
+	g.emit(Event{Type: EventLifeLost, LifeLoss: &LifeLoss{Player: p, Amount: n}})
+}
@@ -44,6 +44,16 @@ type Permanent struct {
 	// Summoning sickness tracking (CR 302.6)
 	enteredTurn int
 
+	// Activated abilities used on activationTurn, keyed by ability text, for
+	// "activate only once each turn" (CR 602.5b) and loyalty abilities
+	// (CR 606.3).
+	activationTurn int
+	activations    map[string]int
+
+	// exerted permanents don't untap during their controller's next untap
+	// step (CR 701.39).
+	exerted bool
+
 	// Minimal keyword flags (subset for Task 10) — kept as fast-path
 	// fields used by combat.go; mirrored into printedKeywords below.
 	firstStrike  bool
@@ -63,6 +73,14 @@ type Permanent struct {
 	// Commander status (CR 903.3)
 	isCommander bool
 
+	// token is set for permanents created as tokens (CR 111.1).
+	token bool
+
+	// attackTurn and attacks count the times the permanent was declared
+	// as an attacker during turn attackTurn.
+	attackTurn int
+	attacks    int
+
 	cantBlock  bool
 }
 
@@ -137,6 +155,28 @@ func (p *Permanent) ClearDamage()    { p.damage = 0; p.markedLethal = false }
 func (p *Permanent) SetEnteredTurn(turn int) { p.enteredTurn = turn }
 func (p *Permanent) GetEnteredTurn() int     { return p.enteredTurn }
 
+// RecordActivation notes that the ability identified by key was activated
+// on turn.
+func (p *Permanent) RecordActivation(key string, turn int) {
+	if p.activations == nil || p.activationTurn != turn {
+		p.activationTurn, p.activations = turn, map[string]int{}
+	}
+	p.activations[key]++
+}
+
+// ActivationsOnTurn returns how many times the ability identified by key
+// was activated on turn.
+func (p *Permanent) ActivationsOnTurn(key string, turn int) int {
+	if p.activationTurn != turn {
+		return 0
+	}
+	return p.activations[key]
+}
+
+// Exert helpers (CR 701.39)
+func (p *Permanent) SetExerted(v bool) { p.exerted = v }
+func (p *Permanent) IsExerted() bool   { return p.exerted }
+
 // Temporary pump helpers
 func (p *Permanent) addTempPump(dp, dt int) { p.tempPowerMod += dp; p.tempToughnessMod += dt }
 func (p *Permanent) clearTempPump()         { p.tempPowerMod = 0; p.tempToughnessMod = 0 }
@@ -161,6 +201,15 @@ func (p *Permanent) GetCounters(counterType string) int {
 	return p.counters[counterType]
 }
 
+// Counters returns a copy of the counters on the permanent by type.
+func (p *Permanent) Counters() map[string]int {
+	out := make(map[string]int, len(p.counters))
+	for k, n := range p.counters {
+		out[k] = n
+	}
+	return out
+}
+
 // Minimal keyword setters/getters
 func (p *Permanent) SetFirstStrike(v bool)  { p.firstStrike = v }
 func (p *Permanent) HasFirstStrike() bool   { return p.firstStrike }
@@ -171,6 +220,18 @@ func (p *Permanent) HasDoubleStrike() bool  { return p.doubleStrike }
 func (p *Permanent) SetIsCommander(v bool) { p.isCommander = v }
 func (p *Permanent) IsCommander() bool     { return p.isCommander }
 
+// IsToken reports whether the permanent was created as a token (CR 111.1).
+func (p *Permanent) IsToken() bool { return p.token }
+
+// AttacksThisTurn is how many times the permanent has been declared as an
+// attacker during turn.
+func (p *Permanent) AttacksThisTurn(turn int) int {
+	if p.attackTurn != turn {
+		return 0
+	}
+	return p.attacks
+}
+
 func (p *Permanent) SetCantBlock(v bool) { p.cantBlock = v }
 func (p *Permanent) CantBlock() bool     { return p.cantBlock }
 
@@ -31,6 +31,14 @@ type Player struct {
 	commanderDamageReceived map[string]int
 
 	additionalLands int
+
+	// revealed holds cards revealed from library or hand; see RevealedCards.
+	revealed []SimpleCard
+
+	// knownTop and knownBottom hold the cards the player put on top of
+	// and at the bottom of their library since it was last shuffled; see
+	// KnownLibrary.
+	knownTop, knownBottom []SimpleCard
 }
 
 func NewPlayer(name string, startingLife int) *Player {
@@ -58,6 +66,19 @@ func (p *Player) HasLost() bool         { return p.lost }
 func (p *Player) GetLossReason() string { return p.lossReason }
 func (p *Player) SetLossReason(r string) { p.lossReason = r }
 
+// PayLife pays n life as a cost. A player can pay life only if their life
+// total is at least n (CR 119.4); paying 0 always succeeds.
+func (p *Player) PayLife(n int) bool {
+	if n <= 0 {
+		return true
+	}
+	if p.life < n {
+		return false
+	}
+	p.life -= n
+	return true
+}
+
 // Lose marks the player as lost with the given reason and exiles all of their zones.
 func (p *Player) Lose(reason string) {
 	if p.lost {
@@ -245,6 +266,18 @@ func (p *Player) GetLands() []*Permanent {
 	return out
 }
 
+// UntapPermanents performs the untap step (CR 502.3): everything the player
+// controls untaps except exerted permanents, which stay tapped this once.
+func (p *Player) UntapPermanents() {
+	for _, perm := range p.Battlefield {
+		if perm.IsExerted() {
+			perm.SetExerted(false)
+			continue
+		}
+		perm.Untap()
+	}
+}
+
 // CanPayForCard checks if the player can pay the mana cost of the given card,
 // prioritizing cheaper alternate costs if available.
 func (p *Player) CanPayForCard(c SimpleCard) bool {
@@ -296,6 +329,13 @@ func (p *Player) PayForCard(c SimpleCard) bool {
 	return false
 }
 
+// CanPayMana reports whether the mana pool covers cost.
+func (p *Player) CanPayMana(cost Mana) bool { return p.manaPool.CanPay(cost) }
+
+// PayMana removes cost from the mana pool, or returns false and pays
+// nothing when it can't.
+func (p *Player) PayMana(cost Mana) bool { return p.manaPool.Pay(cost) }
+
 // CanPayForCommander checks the printed commander cost plus commander tax.
 func (p *Player) CanPayForCommander(c SimpleCard) bool {
 	cost := c.GetManaCost()
@@ -322,16 +362,11 @@ func (p *Player) Discard(n int) []SimpleCard {
 	return discarded
 }
 
-// SearchLibraryToHand moves up to n cards from library to hand.
+// SearchLibraryToHand searches for up to n cards of any kind and puts them
+// into hand, letting DefaultLibraryChooser pick. Use SearchLibrary for
+// restricted searches and other destinations.
 func (p *Player) SearchLibraryToHand(n int) []SimpleCard {
-	if n > len(p.Library) {
-		n = len(p.Library)
-	}
-	found := make([]SimpleCard, n)
-	copy(found, p.Library[:n])
-	p.Library = p.Library[n:]
-	p.Hand = append(p.Hand, found...)
-	return found
+	return p.SearchLibrary(LibrarySearch{Filter: AnyCard(), Max: n, Dest: Hand}, nil).Cards
 }
 
 // PutTokenOnBattlefield creates a token permanent and adds it to the battlefield.
@@ -1,26 +1,13 @@
 package game
 
-import "reflect"
-
 type prevention struct {
-	pool map[uintptr]int // remaining prevention by target pointer
+	pool map[any]int // remaining prevention by target (*Player or *Permanent)
 }
 
 func (g *Game) ensurePrevention() {
 	if g.prevention == nil {
-		g.prevention = &prevention{pool: map[uintptr]int{}}
-	}
-}
-
-func keyFor(target any) uintptr {
-	if target == nil {
-		return 0
-	}
-	v := reflect.ValueOf(target)
-	if v.Kind() != 22 {
-		return 0
+		g.prevention = &prevention{pool: map[any]int{}}
 	}
-	return v.Pointer()
 }
 
 // AddDamagePrevention adds a prevention shield for the target until EOT.
@@ -29,14 +16,13 @@ func (g *Game) AddDamagePrevention(target any, amount int) {
 		return
 	}
 	g.ensurePrevention()
-	k := keyFor(target)
-	g.prevention.pool[k] = g.prevention.pool[k] + amount
+	g.prevention.pool[target] += amount
 }
 
 // clearPreventionEOT resets all damage prevention at end of turn.
 func (g *Game) clearPreventionEOT() {
 	if g.prevention != nil {
-		g.prevention.pool = map[uintptr]int{}
+		g.prevention.pool = map[any]int{}
 	}
 }
 
@@ -47,7 +33,7 @@ func (g *Game) ApplyDamageToPlayer(p *Player, amount int) {
 	}
 	rem := g.consumePrevention(p, amount)
 	if rem > 0 {
-		p.SetLifeTotal(p.GetLifeTotal() - rem)
+		g.LoseLife(p, rem)
 	}
 }
 
@@ -66,16 +52,15 @@ func (g *Game) consumePrevention(target any, amount int) int {
 	if g.prevention == nil || amount <= 0 {
 		return amount
 	}
-	k := keyFor(target)
-	shield := g.prevention.pool[k]
+	shield := g.prevention.pool[target]
 	if shield <= 0 {
 		return amount
 	}
 	if shield >= amount {
-		g.prevention.pool[k] = shield - amount
+		g.prevention.pool[target] = shield - amount
 		return 0
 	}
 	// consume all shield, return leftover
-	g.prevention.pool[k] = 0
+	g.prevention.pool[target] = 0
 	return amount - shield
 }
@@ -75,6 +75,10 @@ func (g *Game) ApplyStateBasedActions() {
 
 }
 
+// OnBattlefield reports whether p is still on the battlefield. A permanent
+// that left is gone for good; if its card returns it's a new object.
+func (g *Game) OnBattlefield(p *Permanent) bool { return g.onBattlefield(p) }
+
 func (g *Game) onBattlefield(p *Permanent) bool {
 	for _, pl := range g.players {
 		for _, bp := range pl.Battlefield {
@@ -0,0 +1,155 @@
+package game
+
+// Static abilities of lock and stax pieces, and of permanents that let
+// their controller cast cards from other zones. A permanent's static
+// abilities apply while it is on the battlefield; nothing needs
+// registering when it enters or unregistering when it leaves.
+
+// StaticAbilities builds the static effects of perm, controlled by its
+// controller.
+type StaticAbilities func(perm *Permanent) []*StaticEffect
+
+var staticAbilityCards = map[string]StaticAbilities{}
+
+// RegisterStaticAbilities declares the static abilities of the card named
+// name, replacing any earlier declaration.
+func RegisterStaticAbilities(name string, fn StaticAbilities) {
+	staticAbilityCards[name] = fn
+}
+
+// StaticAbilitiesOf returns the static effects perm generates.
+func StaticAbilitiesOf(perm *Permanent) []*StaticEffect {
+	fn, ok := staticAbilityCards[perm.GetName()]
+	if !ok {
+		return nil
+	}
+	effects := fn(perm)
+	for _, e := range effects {
+		e.Source = perm
+		e.Controller = perm.GetController()
+	}
+	return effects
+}
+
+// IsLockPiece reports whether the card named name has static abilities
+// that restrict what players can do.
+func IsLockPiece(name string) bool {
+	fn, ok := staticAbilityCards[name]
+	if !ok {
+		return false
+	}
+	for _, e := range fn(nil) {
+		if e.Type != GrantEscape {
+			return true
+		}
+	}
+	return false
+}
+
+// noncreatureTax is the {1} tax of Thalia, Guardian of Thraben and its
+// kin on noncreature spells.
+func noncreatureTax(*Permanent) []*StaticEffect {
+	return []*StaticEffect{{
+		Type:                  CostModifier,
+		Description:           "Noncreature spells cost {1} more to cast.",
+		AdditionalGenericCost: 1,
+		ExcludedCardTypes:     []string{"Creature"},
+		AffectsController:     true,
+	}}
+}
+
+// oneSpellEachTurn is Rule of Law and its variants.
+func oneSpellEachTurn(*Permanent) []*StaticEffect {
+	return []*StaticEffect{{
+		Type:              CastConstraint,
+		Description:       "Each player can't cast more than one spell each turn.",
+		MaxSpellsPerTurn:  1,
+		AffectsController: true,
+	}}
+}
+
+// artifactAbilitiesLocked is Null Rod and its variants.
+func artifactAbilitiesLocked(*Permanent) []*StaticEffect {
+	return []*StaticEffect{{
+		Type:              AbilityRestriction,
+		Description:       "Activated abilities of artifacts can't be activated.",
+		AffectsCardTypes:  []string{"Artifact"},
+		AffectsController: true,
+	}}
+}
+
+func init() {
+	for _, name := range []string{"Thalia, Guardian of Thraben", "Thorn of Amethyst", "Glowrider", "Vryn Wingmare"} {
+		RegisterStaticAbilities(name, noncreatureTax)
+	}
+	RegisterStaticAbilities("Sphere of Resistance", func(*Permanent) []*StaticEffect {
+		return []*StaticEffect{{
+			Type:                  CostModifier,
+			Description:           "Spells cost {1} more to cast.",
+			AdditionalGenericCost: 1,
+			AffectsController:     true,
+		}}
+	})
+	for _, name := range []string{"Rule of Law", "Arcane Laboratory", "Eidolon of Rhetoric"} {
+		RegisterStaticAbilities(name, oneSpellEachTurn)
+	}
+	for _, name := range []string{"Null Rod", "Collector Ouphe", "Stony Silence"} {
+		RegisterStaticAbilities(name, artifactAbilitiesLocked)
+	}
+	RegisterStaticAbilities("Cursed Totem", func(*Permanent) []*StaticEffect {
+		return []*StaticEffect{{
+			Type:              AbilityRestriction,
+			Description:       "Activated abilities of creatures can't be activated.",
+			AffectsCardTypes:  []string{"Creature"},
+			AffectsController: true,
+		}}
+	})
+	RegisterStaticAbilities("Drannith Magistrate", func(*Permanent) []*StaticEffect {
+		return []*StaticEffect{{
+			Type:        CastZoneRestriction,
+			Description: "Your opponents can't cast spells from anywhere other than their hands.",
+			Zones:       []Zone{Library, Graveyard, Exile, Command},
+		}}
+	})
+	RegisterStaticAbilities("Grafdigger's Cage", func(*Permanent) []*StaticEffect {
+		return []*StaticEffect{{
+			Type:              EntersRestriction,
+			Description:       "Creature cards in graveyards and libraries can't enter the battlefield.",
+			Zones:             []Zone{Graveyard, Library},
+			AffectsCardTypes:  []string{"Creature"},
+			AffectsController: true,
+		}, {
+			Type:              CastZoneRestriction,
+			Description:       "Players can't cast spells from graveyards or libraries.",
+			Zones:             []Zone{Graveyard, Library},
+			AffectsController: true,
+		}}
+	})
+	RegisterStaticAbilities("Underworld Breach", func(*Permanent) []*StaticEffect {
+		return []*StaticEffect{{
+			Type:              GrantEscape,
+			Description:       "Each nonland card in your graveyard has escape. The escape cost is equal to the card's mana cost plus exile three other cards from your graveyard.",
+			EscapeExile:       3,
+			ExcludedCardTypes: []string{"Land"},
+		}}
+	})
+	RegisterStaticAbilities("Winter Orb", func(*Permanent) []*StaticEffect {
+		return []*StaticEffect{{
+			Type:              UntapLimit,
+			Description:       "As long as Winter Orb is untapped, players can't untap more than one land during their untap steps.",
+			MaxUntaps:         1,
+			WhileUntapped:     true,
+			AffectsCardTypes:  []string{"Land"},
+			AffectsController: true,
+		}}
+	})
+	RegisterStaticAbilities("Static Orb", func(*Permanent) []*StaticEffect {
+		return []*StaticEffect{{
+			Type:              UntapLimit,
+			Description:       "As long as Static Orb is untapped, players can't untap more than two permanents during their untap steps.",
+			MaxUntaps:         2,
+			WhileUntapped:     true,
+			AffectsController: true,
+		}}
+	})
+}
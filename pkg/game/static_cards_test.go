@@ -0,0 +1,137 @@
+package game
+
+import "testing"
+
+func newStaxGame() (*Game, *Player, *Player) {
+	p1 := NewPlayer("P1", 20)
+	p2 := NewPlayer("P2", 20)
+	return NewGame(p1, p2), p1, p2
+}
+
+func TestRuleOfLaw_OneSpellEachTurnForEveryone(t *testing.T) {
+	g, p1, p2 := newStaxGame()
+	p1.PutTokenOnBattlefield(SimpleCard{Name: "Rule of Law", TypeLine: "Enchantment"})
+	bolt := SimpleCard{Name: "Lightning Bolt", TypeLine: "Instant"}
+
+	for _, p := range []*Player{p1, p2} {
+		if !g.CanCast(p, bolt, Hand) {
+			t.Fatalf("%s hasn't cast a spell yet", p.GetName())
+		}
+		g.RecordSpellCast(p)
+		if g.CanCast(p, bolt, Hand) {
+			t.Fatalf("Rule of Law stops %s's second spell", p.GetName())
+		}
+	}
+}
+
+func TestDrannithMagistrate_OpponentsCastOnlyFromHand(t *testing.T) {
+	g, p1, p2 := newStaxGame()
+	magistrate := p1.PutTokenOnBattlefield(SimpleCard{Name: "Drannith Magistrate", TypeLine: "Creature — Human Wizard"})
+	commander := SimpleCard{Name: "Kenrith, the Returned King", TypeLine: "Legendary Creature — Human Noble"}
+
+	if g.CanCast(p2, commander, Command) {
+		t.Fatal("opponents can't cast commanders from the command zone")
+	}
+	if !g.CanCast(p2, commander, Hand) || !g.CanCast(p1, commander, Command) {
+		t.Fatal("hands, and the Magistrate's controller, are unaffected")
+	}
+	p1.DestroyPermanent(magistrate)
+	if !g.CanCast(p2, commander, Command) {
+		t.Fatal("the restriction ends when the Magistrate leaves")
+	}
+}
+
+func TestNullRodAndCursedTotem_StopActivatedAbilities(t *testing.T) {
+	g, p1, p2 := newStaxGame()
+	rock := p2.PutTokenOnBattlefield(SimpleCard{Name: "Sol Ring", TypeLine: "Artifact"})
+	elf := p2.PutTokenOnBattlefield(SimpleCard{Name: "Llanowar Elves", TypeLine: "Creature — Elf Druid"})
+	land := p2.PutTokenOnBattlefield(SimpleCard{Name: "Forest", TypeLine: "Basic Land — Forest"})
+
+	p1.PutTokenOnBattlefield(SimpleCard{Name: "Null Rod", TypeLine: "Artifact"})
+	if g.CanActivateAbilities(rock) || !g.CanActivateAbilities(elf) || !g.CanActivateAbilities(land) {
+		t.Fatal("Null Rod stops artifacts only")
+	}
+	p1.PutTokenOnBattlefield(SimpleCard{Name: "Cursed Totem", TypeLine: "Artifact"})
+	if g.CanActivateAbilities(elf) || !g.CanActivateAbilities(land) {
+		t.Fatal("Cursed Totem stops creatures too")
+	}
+}
+
+func TestWinterOrb_UntapsOneLandWhileUntapped(t *testing.T) {
+	g, p1, p2 := newStaxGame()
+	orb := p1.PutTokenOnBattlefield(SimpleCard{Name: "Winter Orb", TypeLine: "Artifact"})
+	var lands []*Permanent
+	for i := 0; i < 3; i++ {
+		land := p2.PutTokenOnBattlefield(SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"})
+		land.Tap()
+		lands = append(lands, land)
+	}
+	bear := p2.PutTokenOnBattlefield(SimpleCard{Name: "Bear", TypeLine: "Creature — Bear"})
+	bear.Tap()
+
+	g.UntapStep(p2)
+	tapped := 0
+	for _, land := range lands {
+		if land.IsTapped() {
+			tapped++
+		}
+	}
+	if tapped != 2 || bear.IsTapped() {
+		t.Fatalf("expected one land and the creature to untap, %d lands still tapped, bear tapped=%v", tapped, bear.IsTapped())
+	}
+
+	orb.Tap()
+	g.UntapStep(p2)
+	for _, land := range lands {
+		if land.IsTapped() {
+			t.Fatal("a tapped Winter Orb doesn't limit untapping")
+		}
+	}
+}
+
+func TestGrafdiggersCage_Searches(t *testing.T) {
+	g, p1, p2 := newStaxGame()
+	p2.Library = []SimpleCard{
+		{Name: "Craterhoof Behemoth", TypeLine: "Creature — Beast"},
+		{Name: "Demonic Tutor", TypeLine: "Sorcery"},
+	}
+	p1.PutTokenOnBattlefield(SimpleCard{Name: "Grafdigger's Cage", TypeLine: "Artifact"})
+	res := g.SearchLibrary(p2, LibrarySearch{Filter: ByType("Creature"), Max: 1, Dest: Battlefield}, nil)
+	if len(res.Permanents) != 0 || len(p2.Library) != 2 {
+		t.Fatalf("Cage keeps creature cards in the library, found %+v", res.Cards)
+	}
+	if g.CanEnterBattlefield(p2.Library[0], Graveyard) || !g.CanEnterBattlefield(p2.Library[0], Hand) {
+		t.Fatal("Cage covers graveyards and libraries only")
+	}
+}
+
+func TestThalia_TaxesNoncreatureSpells(t *testing.T) {
+	g, p1, p2 := newStaxGame()
+	p1.PutTokenOnBattlefield(SimpleCard{Name: "Thalia, Guardian of Thraben", TypeLine: "Legendary Creature — Human Soldier"})
+	registry := g.GetStaticEffects()
+	if registry.TotalAdditionalCost(p2, "Instant") != 1 || registry.TotalAdditionalCost(p1, "Sorcery") != 1 {
+		t.Fatal("noncreature spells cost {1} more for everyone")
+	}
+	if registry.TotalAdditionalCost(p2, "Artifact Creature — Golem") != 0 {
+		t.Fatal("creature spells aren't taxed")
+	}
+}
+
+func TestUnderworldBreach_GrantsEscapeToItsController(t *testing.T) {
+	g, p1, p2 := newStaxGame()
+	p1.PutTokenOnBattlefield(SimpleCard{Name: "Underworld Breach", TypeLine: "Enchantment"})
+	freeze := SimpleCard{Name: "Brain Freeze", TypeLine: "Instant"}
+
+	if n, ok := g.EscapeCost(p1, freeze); !ok || n != 3 {
+		t.Fatalf("Brain Freeze should escape by exiling three other cards, got %d, %v", n, ok)
+	}
+	if _, ok := g.EscapeCost(p1, SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"}); ok {
+		t.Fatal("lands don't get escape")
+	}
+	if _, ok := g.EscapeCost(p2, freeze); ok {
+		t.Fatal("only the Breach's controller gets escape")
+	}
+	if IsLockPiece("Underworld Breach") {
+		t.Fatal("granting escape restricts nobody")
+	}
+}
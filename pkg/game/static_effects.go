@@ -10,6 +10,20 @@ const (
 	CastConstraint
 	// AbilityRestriction prevents certain abilities from being activated.
 	AbilityRestriction
+	// SkipDrawStep makes the controller skip their draw step (Necropotence).
+	SkipDrawStep
+	// CastZoneRestriction forbids casting spells from Zones (Drannith
+	// Magistrate, Grafdigger's Cage).
+	CastZoneRestriction
+	// EntersRestriction keeps cards in Zones from entering the battlefield
+	// (Grafdigger's Cage).
+	EntersRestriction
+	// UntapLimit caps how many permanents affected players untap during
+	// their untap steps (Winter Orb, Static Orb).
+	UntapLimit
+	// GrantEscape gives nonland cards in the controller's graveyard escape
+	// (Underworld Breach).
+	GrantEscape
 )
 
 // StaticEffect represents a continuous static effect on the game.
@@ -25,9 +39,25 @@ type StaticEffect struct {
 	// For CastConstraint: max spells a player may cast per turn (0 = unlimited)
 	MaxSpellsPerTurn int
 
+	// For UntapLimit: most permanents of the affected types each player
+	// untaps. WhileUntapped turns the effect off while the source is tapped.
+	MaxUntaps     int
+	WhileUntapped bool
+
+	// For GrantEscape: how many other cards from the graveyard escape
+	// exiles on top of the card's mana cost.
+	EscapeExile int
+
+	// For CastZoneRestriction and EntersRestriction: the zones affected.
+	Zones []Zone
+
 	// TargetFilter restricts which cards/players are affected.
 	// Empty means "all".
-	AffectsCardTypes []string // e.g., "Creature", "Instant"
+	AffectsCardTypes  []string // e.g., "Creature", "Instant"
+	ExcludedCardTypes []string // e.g., "Creature" for "noncreature spells"
+	// AffectsController makes the effect symmetric (Rule of Law, Null Rod);
+	// otherwise only the controller's opponents are affected.
+	AffectsController bool
 }
 
 // StaticEffectRegistry holds all active static effects in a game.
@@ -71,8 +101,8 @@ func (r *StaticEffectRegistry) TotalAdditionalCost(controller *Player, typeLine
 		if e.Type != CostModifier {
 			continue
 		}
-		if e.Controller == controller {
-			continue // self-tax is rare; skip own controller for simplicity
+		if !e.affects(controller) {
+			continue
 		}
 		if !e.affectsType(typeLine) {
 			continue
@@ -89,7 +119,7 @@ func (r *StaticEffectRegistry) CanCastSpell(controller *Player, spellsCastThisTu
 		if e.Type != CastConstraint {
 			continue
 		}
-		if e.Controller == controller {
+		if !e.affects(controller) {
 			continue
 		}
 		if e.MaxSpellsPerTurn > 0 && spellsCastThisTurn >= e.MaxSpellsPerTurn {
@@ -99,8 +129,28 @@ func (r *StaticEffectRegistry) CanCastSpell(controller *Player, spellsCastThisTu
 	return true
 }
 
+// affects reports whether the effect applies to player p.
+func (e *StaticEffect) affects(p *Player) bool {
+	return e.AffectsController || e.Controller != p
+}
+
+// affectsZone reports whether the effect covers zone z.
+func (e *StaticEffect) affectsZone(z Zone) bool {
+	for _, ez := range e.Zones {
+		if ez == z {
+			return true
+		}
+	}
+	return false
+}
+
 // affectsType returns true if the effect applies to the given card type line.
 func (e *StaticEffect) affectsType(typeLine string) bool {
+	for _, t := range e.ExcludedCardTypes {
+		if contains(typeLine, t) {
+			return false
+		}
+	}
 	if len(e.AffectsCardTypes) == 0 {
 		return true
 	}
@@ -132,10 +182,134 @@ func (g *Game) RemoveStaticEffects(source *Permanent) {
 	}
 }
 
-// GetStaticEffects returns the current static effect registry.
+// GetStaticEffects returns the static effects currently in force: those
+// registered on the game whose source is still on the battlefield, plus the
+// static abilities of the permanents on the battlefield.
 func (g *Game) GetStaticEffects() *StaticEffectRegistry {
-	if g.continuous == nil || g.continuous.staticEffects == nil {
-		return NewStaticEffectRegistry()
+	return &StaticEffectRegistry{effects: g.activeStaticEffects()}
+}
+
+func (g *Game) activeStaticEffects() []*StaticEffect {
+	var out []*StaticEffect
+	if g.continuous != nil && g.continuous.staticEffects != nil {
+		for _, e := range g.continuous.staticEffects.effects {
+			if e.Source == nil || g.onBattlefield(e.Source) {
+				out = append(out, e)
+			}
+		}
+	}
+	for _, pl := range g.players {
+		for _, perm := range pl.Battlefield {
+			out = append(out, StaticAbilitiesOf(perm)...)
+		}
+	}
+	return out
+}
+
+// SkipsDrawStep reports whether p skips their draw step this turn.
+func (g *Game) SkipsDrawStep(p *Player) bool {
+	for _, e := range g.activeStaticEffects() {
+		if e.Type == SkipDrawStep && e.Controller == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CanCast reports whether p may cast c from zone under the static effects
+// in force: Rule of Law's one spell each turn, Drannith Magistrate's
+// hand-only casting, Grafdigger's Cage.
+func (g *Game) CanCast(p *Player, c SimpleCard, from Zone) bool {
+	for _, e := range g.activeStaticEffects() {
+		if !e.affects(p) {
+			continue
+		}
+		switch e.Type {
+		case CastConstraint:
+			if e.MaxSpellsPerTurn > 0 && e.affectsType(c.TypeLine) && g.SpellsCastThisTurnBy(p) >= e.MaxSpellsPerTurn {
+				return false
+			}
+		case CastZoneRestriction:
+			if e.affectsZone(from) && e.affectsType(c.TypeLine) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// EscapeCost reports whether p may cast c from their graveyard by escape
+// (CR 702.138) and how many other cards from that graveyard the escape
+// cost exiles besides c's mana cost.
+func (g *Game) EscapeCost(p *Player, c SimpleCard) (int, bool) {
+	for _, e := range g.activeStaticEffects() {
+		if e.Type == GrantEscape && e.Controller == p && e.affectsType(c.TypeLine) {
+			return e.EscapeExile, true
+		}
+	}
+	return 0, false
+}
+
+// CanActivateAbilities reports whether perm's activated abilities,
+// mana abilities included, can be activated (Null Rod, Cursed Totem).
+func (g *Game) CanActivateAbilities(perm *Permanent) bool {
+	for _, e := range g.activeStaticEffects() {
+		if e.Type == AbilityRestriction && e.affects(perm.GetController()) && e.affectsType(perm.GetSource().TypeLine) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanEnterBattlefield reports whether c can be put onto the battlefield
+// from zone. A card that can't stays where it is (Grafdigger's Cage).
+func (g *Game) CanEnterBattlefield(c SimpleCard, from Zone) bool {
+	for _, e := range g.activeStaticEffects() {
+		if e.Type == EntersRestriction && e.affectsZone(from) && e.affectsType(c.TypeLine) {
+			return false
+		}
+	}
+	return true
+}
+
+// UntapStep performs p's untap step (CR 502.3) under the untap limits in
+// force. Limited permanents untap in battlefield order until the limit is
+// reached (automated default choice); an Orb that is itself tapped as the
+// step begins doesn't limit anything.
+func (g *Game) UntapStep(p *Player) {
+	var limits []*StaticEffect
+	for _, e := range g.activeStaticEffects() {
+		if e.Type == UntapLimit && e.affects(p) && (!e.WhileUntapped || e.Source == nil || !e.Source.IsTapped()) {
+			limits = append(limits, e)
+		}
+	}
+	if len(limits) == 0 {
+		p.UntapPermanents()
+		return
+	}
+	untapped := make([]int, len(limits))
+	for _, perm := range p.Battlefield {
+		if perm.IsExerted() {
+			perm.SetExerted(false)
+			continue
+		}
+		if !perm.IsTapped() {
+			continue
+		}
+		allowed := true
+		for i, e := range limits {
+			if e.affectsType(perm.GetSource().TypeLine) && untapped[i] >= e.MaxUntaps {
+				allowed = false
+			}
+		}
+		if !allowed {
+			continue
+		}
+		for i, e := range limits {
+			if e.affectsType(perm.GetSource().TypeLine) {
+				untapped[i]++
+			}
+		}
+		perm.Untap()
 	}
-	return g.continuous.staticEffects
 }
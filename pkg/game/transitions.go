@@ -8,7 +8,7 @@ func (g *Game) SummonCreature(p *Player, name string) (*Permanent, error) {
 	}
 	// Track summoning sickness (CR 302.6): remember the turn a creature entered
 	perm.SetEnteredTurn(g.turnNumber)
-	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, To: Battlefield}})
+	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, From: Hand, To: Battlefield}})
 	return perm, nil
 }
 
@@ -20,11 +20,33 @@ func (g *Game) CastPermanent(p *Player, name string) (*Permanent, error) {
 	}
 	// Set turn entered for summoning sickness relevance (only matters if it's a creature)
 	perm.SetEnteredTurn(g.turnNumber)
-	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, To: Battlefield}})
+	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, From: Hand, To: Battlefield}})
 	return perm, nil
 }
 
-// PlayLand wraps Player.PlayLand and emits ETB event.
+// CastCommander wraps Player.CastCommander and emits ETB event.
+func (g *Game) CastCommander(p *Player, name string) *Permanent {
+	perm := p.CastCommander(name)
+	if perm == nil {
+		return nil
+	}
+	perm.SetEnteredTurn(g.turnNumber)
+	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, From: Command, To: Battlefield}})
+	return perm
+}
+
+// CreateToken puts a token of token onto the battlefield under p's
+// control (CR 111.1) and emits its ETB event.
+func (g *Game) CreateToken(p *Player, token SimpleCard) *Permanent {
+	perm := p.PutTokenOnBattlefield(token)
+	perm.token = true
+	perm.SetEnteredTurn(g.turnNumber)
+	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Card: token, Permanent: perm, To: Battlefield}})
+	return perm
+}
+
+// PlayLand wraps Player.PlayLand, applies the land's entry condition
+// (taplands, shocklands, checklands, fastlands) and emits ETB event.
 func (g *Game) PlayLand(p *Player, name string) (*Permanent, error) {
 	perm, err := p.PlayLand(name)
 	if err != nil {
@@ -32,7 +54,8 @@ func (g *Game) PlayLand(p *Player, name string) (*Permanent, error) {
 	}
 	// Lands also "entered the battlefield this turn" but don't have summoning sickness.
 	perm.SetEnteredTurn(g.turnNumber)
-	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, To: Battlefield}})
+	g.applyLandEntry(p, perm)
+	g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, From: Hand, To: Battlefield}})
 	return perm, nil
 }
 
@@ -41,6 +64,12 @@ func (g *Game) DestroyPermanent(perm *Permanent) bool {
 	return g.handleDies(perm)
 }
 
+// SacrificePermanent puts perm into its owner's graveyard. Sacrifice
+// ignores indestructible but is otherwise a death (CR 701.17).
+func (g *Game) SacrificePermanent(perm *Permanent) bool {
+	return g.handleDies(perm)
+}
+
 // ExileFromGraveyard wraps Player.ExileFromGraveyard and emits zone change event.
 func (g *Game) ExileFromGraveyard(p *Player, name string) bool {
 	// find card in graveyard
@@ -58,3 +87,26 @@ func (g *Game) ExileFromGraveyard(p *Player, name string) bool {
 	}
 	return ok
 }
+
+// SearchLibrary wraps Player.SearchLibrary and emits ETB events for cards
+// put onto the battlefield (fetchlands, Natural Order). Fetched lands still
+// apply their own entry conditions, so a fetched shockland asks for life.
+// Cards that can't enter the battlefield from the library under the static
+// effects in force aren't found for it.
+func (g *Game) SearchLibrary(p *Player, s LibrarySearch, chooser LibraryChooser) SearchResult {
+	if s.Dest == Battlefield {
+		filter := s.Filter
+		s.Filter = func(c SimpleCard) bool {
+			return (filter == nil || filter(c)) && g.CanEnterBattlefield(c, Library)
+		}
+	}
+	res := p.SearchLibrary(s, chooser)
+	for _, perm := range res.Permanents {
+		perm.SetEnteredTurn(g.turnNumber)
+		if perm.IsLand() && !perm.IsTapped() {
+			g.applyLandEntry(p, perm)
+		}
+		g.emit(Event{Type: EventEntersBattlefield, ZoneChange: &ZoneChange{Permanent: perm, From: Library, To: Battlefield}})
+	}
+	return res
+}
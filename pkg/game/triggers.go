@@ -10,11 +10,18 @@ import "sort"
 // with the active player's controller. Triggers with a nil Controller
 // (game-wide system effects) are processed after all controlled
 // triggers in their original registration order.
+//
+// Source (optional) is the permanent whose triggered ability this is.
+//
+// The trigger is data so that Game.Clone can copy it: Controller and
+// Source are remapped to the clone's objects, and Condition and Action
+// should reach the game only through their arguments.
 type Trigger struct {
 	On         EventType
 	Controller *Player
-	Condition  func(Event) bool
-	Action     func(g *Game, e Event)
+	Source     *Permanent
+	Condition  func(t *Trigger, e Event) bool
+	Action     func(g *Game, t *Trigger, e Event)
 }
 
 func (g *Game) AddTrigger(t *Trigger) { g.triggers = append(g.triggers, t) }
@@ -42,7 +49,7 @@ func (g *Game) handleTriggers(e Event) {
 		if t == nil || t.On != e.Type {
 			continue
 		}
-		if t.Condition != nil && !t.Condition(e) {
+		if t.Condition != nil && !t.Condition(t, e) {
 			continue
 		}
 		matches = append(matches, pending{idx: i, apnap: g.apnapPosition(t.Controller), trigger: t})
@@ -67,6 +74,12 @@ func (g *Game) DrainPendingTriggers() []PendingTrigger {
 	return out
 }
 
+// PendingTriggers returns a copy of the queued triggers without draining
+// them.
+func (g *Game) PendingTriggers() []PendingTrigger {
+	return append([]PendingTrigger(nil), g.pendingTriggers...)
+}
+
 // HasPendingTriggers returns true if there are unprocessed triggers in the queue.
 func (g *Game) HasPendingTriggers() bool {
 	return len(g.pendingTriggers) > 0
@@ -77,7 +90,7 @@ func (g *Game) HasPendingTriggers() bool {
 func (g *Game) ProcessPendingTriggers() {
 	for _, pt := range g.pendingTriggers {
 		if pt.Trigger != nil && pt.Trigger.Action != nil {
-			pt.Trigger.Action(g, pt.Event)
+			pt.Trigger.Action(g, pt.Trigger, pt.Event)
 		}
 	}
 	g.pendingTriggers = nil
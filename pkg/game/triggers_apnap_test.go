@@ -17,7 +17,7 @@ func TestTrigger_APNAPOrdering(t *testing.T) {
 		return &Trigger{
 			On:         EventEntersBattlefield,
 			Controller: owner,
-			Action:     func(g *Game, e Event) { order = append(order, tag) },
+			Action:     func(g *Game, _ *Trigger, e Event) { order = append(order, tag) },
 		}
 	}
 
@@ -66,9 +66,9 @@ func TestTrigger_NilControllerLast(t *testing.T) {
 	g := NewGame(p1, p2)
 
 	var order []string
-	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Action: func(g *Game, e Event) { order = append(order, "nil") }})
-	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Controller: p2, Action: func(g *Game, e Event) { order = append(order, "p2") }})
-	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Controller: p1, Action: func(g *Game, e Event) { order = append(order, "p1") }})
+	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Action: func(g *Game, _ *Trigger, e Event) { order = append(order, "nil") }})
+	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Controller: p2, Action: func(g *Game, _ *Trigger, e Event) { order = append(order, "p2") }})
+	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Controller: p1, Action: func(g *Game, _ *Trigger, e Event) { order = append(order, "p1") }})
 
 	bear := SimpleCard{Name: "Bear", TypeLine: "Creature", Power: "2", Toughness: "2"}
 	p1.AddCardToHand(bear)
@@ -14,7 +14,7 @@ func TestTrigger_ETBDraw(t *testing.T) {
 	p1.Hand = append(p1.Hand, creature)
 
 	// Register ETB trigger: controller draws 1
-	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Action: func(g *Game, e Event) {
+	g.AddTrigger(&Trigger{On: EventEntersBattlefield, Action: func(g *Game, _ *Trigger, e Event) {
 		if e.ZoneChange != nil && e.ZoneChange.Permanent != nil {
 			e.ZoneChange.Permanent.GetController().Draw(1)
 		}
@@ -210,3 +210,95 @@ func TestPlayerLose_ExilePreservesCommander(t *testing.T) {
 		t.Fatal("expected commander to be exiled")
 	}
 }
+
+func TestAtBeginningOfNextEndStep_WaitsForOwnersEndStep(t *testing.T) {
+	p1 := &Player{name: "P1"}
+	p2 := &Player{name: "P2"}
+	g := NewGame(p1, p2)
+
+	fired := 0
+	g.AtBeginningOfNextEndStep(p2, func(*Game) { fired++ })
+
+	for g.GetCurrentPhase() != PhaseEnd {
+		g.AdvancePhase()
+	}
+	if fired != 0 {
+		t.Fatalf("P2's delayed action should not fire on P1's end step")
+	}
+	for g.GetCurrentPlayerRaw() != p2 || g.GetCurrentPhase() != PhaseEnd {
+		g.AdvancePhase()
+	}
+	if fired != 1 {
+		t.Fatalf("expected delayed action to fire once on P2's end step, got %d", fired)
+	}
+	for i := 0; i < 16; i++ {
+		g.AdvancePhase()
+	}
+	if fired != 1 {
+		t.Fatalf("delayed action should be one-shot, fired %d times", fired)
+	}
+}
+
+func TestSkipsDrawStep_EndsWhenSourceLeaves(t *testing.T) {
+	p1 := &Player{name: "P1"}
+	p2 := &Player{name: "P2"}
+	g := NewGame(p1, p2)
+
+	necro := NewPermanent(SimpleCard{Name: "Necropotence", TypeLine: "Enchantment"}, p1, p1)
+	p1.Battlefield = append(p1.Battlefield, necro)
+	g.RegisterStaticEffect(&StaticEffect{Type: SkipDrawStep, Source: necro, Controller: p1})
+
+	if !g.SkipsDrawStep(p1) {
+		t.Fatalf("expected P1 to skip their draw step")
+	}
+	if g.SkipsDrawStep(p2) {
+		t.Fatalf("P2 should still draw")
+	}
+	p1.Battlefield = nil
+	if g.SkipsDrawStep(p1) {
+		t.Fatalf("effect should end once Necropotence leaves the battlefield")
+	}
+}
+
+func TestUntapPermanents_ExertedStaysTappedOnce(t *testing.T) {
+	p := NewPlayer("P1", 20)
+	exerted := p.PutTokenOnBattlefield(SimpleCard{Name: "Glorybringer", TypeLine: "Creature — Dragon"})
+	other := p.PutTokenOnBattlefield(SimpleCard{Name: "Bear", TypeLine: "Creature — Bear"})
+	exerted.Tap()
+	exerted.SetExerted(true)
+	other.Tap()
+
+	p.UntapPermanents()
+	if !exerted.IsTapped() || exerted.IsExerted() || other.IsTapped() {
+		t.Fatalf("exerted should skip one untap step: exerted tapped=%v flag=%v, other tapped=%v", exerted.IsTapped(), exerted.IsExerted(), other.IsTapped())
+	}
+	p.UntapPermanents()
+	if exerted.IsTapped() {
+		t.Fatal("exert only lasts for the next untap step")
+	}
+}
+
+func TestAddCombatPhase_RepeatsCombat(t *testing.T) {
+	p1 := &Player{name: "P1"}
+	p2 := &Player{name: "P2"}
+	g := NewGame(p1, p2)
+	combats := 0
+	g.AddListener(func(_ *Game, e Event) {
+		if e.Type == EventBeginningOfCombat {
+			combats++
+		}
+	})
+
+	for g.GetCurrentPhase() != PhaseCombat {
+		g.AdvancePhase()
+	}
+	g.AddCombatPhase()
+	g.AdvancePhase()
+	if g.GetCurrentPhase() != PhaseCombat || combats != 2 {
+		t.Fatalf("expected a second combat phase, phase %v after %d combats", g.GetCurrentPhase(), combats)
+	}
+	g.AdvancePhase()
+	if g.GetCurrentPhase() != PhaseMain2 {
+		t.Fatalf("expected the second main phase after the extra combat, got %v", g.GetCurrentPhase())
+	}
+}
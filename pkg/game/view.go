@@ -0,0 +1,156 @@
+package game
+
+// Hidden information (CR 400.2). Libraries and hands are hidden zones; the
+// battlefield, graveyards, exile, the command zone and the stack are
+// public. A PlayerView is what one player may know: all of the public
+// zones, the sizes of the hidden ones, their own hand, and the cards of
+// other hands that were revealed to everyone or shown to them. AI
+// decisions read opponents through a view instead of their Player.
+
+// PlayerView is the game as seen by one player.
+type PlayerView struct {
+	g      *Game
+	viewer *Player
+}
+
+// ViewFor returns the game as viewer sees it.
+func (g *Game) ViewFor(viewer *Player) *PlayerView {
+	return &PlayerView{g: g, viewer: viewer}
+}
+
+// Viewer returns the player the view belongs to.
+func (v *PlayerView) Viewer() *Player { return v.viewer }
+
+// PublicInfo is what a view shows of one player.
+type PublicInfo struct {
+	Name        string
+	Life        int
+	Lost        bool
+	HandSize    int
+	LibrarySize int
+	Battlefield []*Permanent
+	Graveyard   []SimpleCard
+	Exile       []SimpleCard
+	CommandZone []SimpleCard
+	Commanders  []string
+	ManaPool    map[ManaType]int
+	// KnownHand is the part of the hand the viewer knows: all of it for
+	// the viewer's own hand, otherwise the revealed and shown cards still
+	// there.
+	KnownHand []SimpleCard
+}
+
+// Of returns what the viewer can see of p.
+func (v *PlayerView) Of(p *Player) PublicInfo {
+	pool := map[ManaType]int{}
+	for mt, n := range p.GetManaPool() {
+		pool[mt] = n
+	}
+	return PublicInfo{
+		Name:        p.GetName(),
+		Life:        p.GetLifeTotal(),
+		Lost:        p.HasLost(),
+		HandSize:    len(p.Hand),
+		LibrarySize: len(p.Library),
+		Battlefield: append([]*Permanent(nil), p.Battlefield...),
+		Graveyard:   append([]SimpleCard(nil), p.Graveyard...),
+		Exile:       append([]SimpleCard(nil), p.Exile...),
+		CommandZone: append([]SimpleCard(nil), p.CommandZone...),
+		Commanders:  p.GetCommanderNames(),
+		ManaPool:    pool,
+		KnownHand:   v.KnownHand(p),
+	}
+}
+
+// Opponents returns the players still in the game other than the viewer,
+// in seat order.
+func (v *PlayerView) Opponents() []*Player {
+	var out []*Player
+	for _, p := range v.g.players {
+		if p != v.viewer && !p.HasLost() {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// OpponentsInTurnOrder returns the players still in the game other than
+// the viewer, starting with the one who plays after the viewer.
+func (v *PlayerView) OpponentsInTurnOrder() []*Player {
+	seat := 0
+	for i, p := range v.g.players {
+		if p == v.viewer {
+			seat = i
+		}
+	}
+	var out []*Player
+	n := len(v.g.players)
+	for i := 1; i <= n; i++ {
+		p := v.g.players[(seat+i)%n]
+		if p != v.viewer && !p.HasLost() {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// KnownHand returns the cards of p's hand the viewer knows about.
+func (v *PlayerView) KnownHand(p *Player) []SimpleCard {
+	if p == v.viewer {
+		return append([]SimpleCard(nil), p.Hand...)
+	}
+	seen := append(p.RevealedCards(), v.g.shown[v.viewer][p]...)
+	return stillInHand(p, seen)
+}
+
+// KnowsInHand reports whether the viewer knows p holds a card matching
+// filter, e.g. whether an opponent is known to hold a counterspell.
+func (v *PlayerView) KnowsInHand(p *Player, filter CardFilter) bool {
+	for _, c := range v.KnownHand(p) {
+		if filter(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowHand lets viewer look at owner's hand (Gitaxian Probe, Thoughtseize).
+// Only viewer learns the cards.
+func (g *Game) ShowHand(viewer, owner *Player) {
+	g.ShowCards(viewer, owner, owner.Hand...)
+}
+
+// ShowCards tells viewer that owner holds cards, without revealing them to
+// anyone else.
+func (g *Game) ShowCards(viewer, owner *Player, cards ...SimpleCard) {
+	if viewer == owner || len(cards) == 0 {
+		return
+	}
+	if g.shown == nil {
+		g.shown = map[*Player]map[*Player][]SimpleCard{}
+	}
+	if g.shown[viewer] == nil {
+		g.shown[viewer] = map[*Player][]SimpleCard{}
+	}
+	// Cards that have left the hand since are forgotten, so the list
+	// doesn't grow without bound.
+	known := stillInHand(owner, g.shown[viewer][owner])
+	g.shown[viewer][owner] = stillInHand(owner, append(known, cards...))
+}
+
+// stillInHand returns the cards of seen that are still in p's hand, each
+// copy in hand matched at most once.
+func stillInHand(p *Player, seen []SimpleCard) []SimpleCard {
+	inHand := map[string]int{}
+	for _, c := range p.Hand {
+		inHand[c.Name]++
+	}
+	var out []SimpleCard
+	for _, c := range seen {
+		if inHand[c.Name] > 0 {
+			inHand[c.Name]--
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package game
+
+import "testing"
+
+func TestPlayerView_HidesOpponentHands(t *testing.T) {
+	p1 := NewPlayer("P1", 40)
+	p2 := NewPlayer("P2", 40)
+	p3 := NewPlayer("P3", 40)
+	g := NewGame(p1, p2, p3)
+	counter := SimpleCard{Name: "Counterspell", TypeLine: "Instant", OracleText: "Counter target spell."}
+	p2.Hand = []SimpleCard{counter, {Name: "Island", TypeLine: "Basic Land — Island"}}
+	p2.Library = []SimpleCard{{Name: "Brainstorm"}}
+
+	v1, v3 := g.ViewFor(p1), g.ViewFor(p3)
+	info := v1.Of(p2)
+	if info.HandSize != 2 || info.LibrarySize != 1 || len(info.KnownHand) != 0 {
+		t.Fatalf("only zone sizes are public, got %+v", info)
+	}
+	if len(g.ViewFor(p2).KnownHand(p2)) != 2 {
+		t.Fatal("a player knows their own hand")
+	}
+
+	g.ShowHand(p1, p2)
+	if !v1.KnowsInHand(p2, SimpleCard.IsCounterspell) {
+		t.Fatal("P1 looked at P2's hand")
+	}
+	if len(v3.KnownHand(p2)) != 0 {
+		t.Fatal("only the player who looked knows the cards")
+	}
+
+	p2.Reveal(p2.Hand[1])
+	if known := v3.KnownHand(p2); len(known) != 1 || known[0].Name != "Island" {
+		t.Fatalf("revealed cards are public, P3 knows %+v", known)
+	}
+
+	p2.Hand = p2.Hand[1:]
+	if v1.KnowsInHand(p2, SimpleCard.IsCounterspell) {
+		t.Fatal("a card that left the hand is no longer known to be there")
+	}
+	if opps := v1.Opponents(); len(opps) != 2 || opps[0] != p2 || opps[1] != p3 {
+		t.Fatalf("expected P2 and P3 as opponents, got %v", opps)
+	}
+	if opps := g.ViewFor(p2).OpponentsInTurnOrder(); len(opps) != 2 || opps[0] != p3 || opps[1] != p1 {
+		t.Fatalf("expected P3 then P1 after P2, got %v", opps)
+	}
+}
@@ -37,3 +37,8 @@ func (w *CreatureETBWatcher) OnEvent(e Event) {
 }
 
 func (w *CreatureETBWatcher) ResetEOT() { w.Count = 0 }
+
+func (w *CreatureETBWatcher) CloneWatcher(*CloneMap) Watcher {
+	c := *w
+	return &c
+}
@@ -1,11 +1,13 @@
 // Package game defines core game primitives for MTG simulation.
 package game
 
-// Zone represents a location a card/permanent can exist in.
+// Zone represents a location a card/permanent can exist in. The zero
+// Zone is no zone, so an unset zone is never mistaken for the library.
 type Zone int
 
 const (
-	Library Zone = iota
+	NoZone Zone = iota
+	Library
 	Hand
 	Battlefield
 	Graveyard
@@ -15,6 +17,8 @@ const (
 
 func (z Zone) String() string {
 	switch z {
+	case NoZone:
+		return "None"
 	case Library:
 		return "Library"
 	case Hand:
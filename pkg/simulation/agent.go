@@ -0,0 +1,368 @@
+package simulation
+
+import (
+	"sort"
+
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Agent makes every decision for one seat of an EDH pod. The runner asks
+// the seat's agent whenever that player has a choice, so agents with
+// different play styles can sit in the same pod. Choices that return
+// indices follow game.LibraryChooser: invalid answers are ignored in
+// favour of DefaultAgent's.
+type Agent interface {
+	// KeepHand decides whether p keeps its current hand after mulligans
+	// mulligans. seat is p's position in the turn order.
+	KeepHand(p *game.Player, commanders []game.SimpleCard, seat, mulligans int) bool
+	// ChooseLand picks the land p plays, or false to play none.
+	ChooseLand(g *game.Game, p *game.Player) (game.SimpleCard, bool)
+	// ChooseSpell picks which of castable, every spell in p's hand that p
+	// can cast and pay for right now, to cast next, or false to stop
+	// casting for this main phase.
+	ChooseSpell(g *game.Game, p *game.Player, castable []game.SimpleCard) (game.SimpleCard, bool)
+	// ChooseTargets picks the targets of ability, an ability being
+	// activated in a priority window. Modal effects go to ChooseModes.
+	ChooseTargets(w *PriorityWindow, ability *abil.Ability) []any
+	// ChooseModes picks the mode indices of a modal effect.
+	ChooseModes(w *PriorityWindow, effect abil.Effect) []int
+	// ChooseAttackTarget picks the opponent p mainly attacks this turn,
+	// or nil to skip combat.
+	ChooseAttackTarget(g *game.Game, p *game.Player) *game.Player
+	// ChooseAttackers picks the creatures p attacks with and the opponent
+	// each one attacks; defender is ChooseAttackTarget's pick.
+	ChooseAttackers(g *game.Game, p, defender *game.Player) map[*game.Permanent]*game.Player
+	// DeclareBlockers declares defender's blocks against the current
+	// attackers with g.DeclareBlocker, which rejects illegal blocks.
+	DeclareBlockers(g *game.Game, defender *game.Player)
+	// Respond is the player's action while holding priority in w. nil
+	// passes.
+	Respond(w *PriorityWindow) *abil.PriorityDecision
+	// ChooseDiscard picks n cards of p's hand to discard.
+	ChooseDiscard(g *game.Game, p *game.Player, n int) []int
+	// ChooseSearch picks up to max of candidates, the cards in p's
+	// library a tutor or fetch effect can find. Returning fewer is
+	// allowed: a search may fail to find.
+	ChooseSearch(g *game.Game, p *game.Player, candidates []game.SimpleCard, max int) []int
+	// OrderTriggers orders p's triggered abilities that triggered at the
+	// same time (CR 603.3b), returning a permutation of their indices;
+	// the first index is put on the stack first.
+	OrderTriggers(g *game.Game, p *game.Player, triggers []game.PendingTrigger) []int
+	// ChooseLoopIterations names how many more times p repeats loop, a
+	// loop p has proved it can repeat, as a shortcut (CR 732.2a). The
+	// count is capped at loopIterationCap; 0 stops the loop.
+	ChooseLoopIterations(g *game.Game, p *game.Player, loop Loop) int
+}
+
+// PriorityWindow is what an agent sees while holding priority.
+type PriorityWindow struct {
+	Game    *game.Game
+	Player  abil.AbilityPlayer
+	Context abil.DecisionContext
+	Stack   *abil.Stack
+	// AI is the handler's shared scorer, for agents that reuse its
+	// evaluations.
+	AI *abil.AIDecisionMaker
+
+	h     *StackAwareHandler
+	agent Agent
+}
+
+// DefaultResponse returns what DefaultAgent would do in w, so an agent
+// can override only the situations it cares about. Targets and modes are
+// still chosen by the window's agent.
+func (w *PriorityWindow) DefaultResponse() *abil.PriorityDecision {
+	return w.h.defaultResponse(w)
+}
+
+// DefaultAgent is the runner's built-in heuristic AI: mulligans scored by
+// a MulliganModel, land sequencing, casting spells in hand order, and
+// combat planned by simulating the fights (planAttacks, planBlocks).
+type DefaultAgent struct {
+	// Mulligan scores opening hands. nil uses DefaultMulliganModel.
+	Mulligan *MulliganModel
+	// Archetype is the deck's play style; empty plays midrange. See
+	// Style.
+	Archetype card.Archetype
+	// Combos is the deck's combo index, if known.
+	Combos *combo.Index
+}
+
+// deckCombos returns the deck's combo index for the combo executor.
+func (a DefaultAgent) deckCombos() *combo.Index { return a.Combos }
+
+func (a DefaultAgent) archetype() card.Archetype { return a.Archetype }
+
+// KeepHand keeps the hand when Mulligan rates it good enough after
+// mulligans mulligans.
+func (a DefaultAgent) KeepHand(p *game.Player, commanders []game.SimpleCard, seat, mulligans int) bool {
+	model := a.Mulligan
+	if model == nil {
+		model = DefaultMulliganModel()
+	}
+	return model.Keep(handFeatures(p.Hand, commanders, a.Combos), mulligans)
+}
+
+func (DefaultAgent) ChooseLand(g *game.Game, p *game.Player) (game.SimpleCard, bool) {
+	return chooseLandToPlay(g, p)
+}
+
+// ChooseSpell casts in hand order, keeping the instants that draw or
+// tutor for the end of the previous opponent's turn.
+func (DefaultAgent) ChooseSpell(g *game.Game, p *game.Player, castable []game.SimpleCard) (game.SimpleCard, bool) {
+	for _, c := range castable {
+		if !heldForEndOfTurn(c) {
+			return c, true
+		}
+	}
+	return game.SimpleCard{}, false
+}
+
+func (DefaultAgent) ChooseTargets(w *PriorityWindow, ability *abil.Ability) []any {
+	return w.AI.ChooseTargetsFor(ability, w.Context)
+}
+
+func (DefaultAgent) ChooseModes(w *PriorityWindow, effect abil.Effect) []int {
+	return w.AI.ChooseModesFor(effect, w.Context)
+}
+
+func (DefaultAgent) ChooseAttackTarget(g *game.Game, p *game.Player) *game.Player {
+	return chooseAttackTarget(g, p)
+}
+
+// ChooseAttackers plans the attack with planAttacks across every living
+// opponent, favouring defender.
+func (a DefaultAgent) ChooseAttackers(g *game.Game, p, defender *game.Player) map[*game.Permanent]*game.Player {
+	return planAttacks(g, p, g.ViewFor(p).Opponents(), defender, styleOf(a))
+}
+
+func (DefaultAgent) DeclareBlockers(g *game.Game, defender *game.Player) {
+	chooseBlockers(g, defender)
+}
+
+func (DefaultAgent) Respond(w *PriorityWindow) *abil.PriorityDecision {
+	return w.DefaultResponse()
+}
+
+// ChooseDiscard discards the cards worth least to p, by
+// game.LibraryCardScore.
+func (DefaultAgent) ChooseDiscard(g *game.Game, p *game.Player, n int) []int {
+	idx := make([]int, len(p.Hand))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return game.LibraryCardScore(p, p.Hand[idx[a]]) < game.LibraryCardScore(p, p.Hand[idx[b]])
+	})
+	if n > len(idx) {
+		n = len(idx)
+	}
+	return idx[:n]
+}
+
+// ChooseSearch ranks candidates by what p needs most: the missing pieces
+// of a combo p is close to (from Combos and the runner's known lines),
+// protection once a combo is assembled against open interaction, then
+// cards by role and by what p's mana can cast.
+func (a DefaultAgent) ChooseSearch(g *game.Game, p *game.Player, candidates []game.SimpleCard, max int) []int {
+	tc := newTutorContext(g, p, a.Combos, styleOf(a))
+	order := game.RankCards(p, candidates, tc.score)
+	if len(order) > max {
+		order = order[:max]
+	}
+	return order
+}
+
+// ChooseLoopIterations names the fewest iterations that finish every
+// opponent the loop hurts, or loopIterationCap for a loop that only
+// makes mana, tokens, storm or life.
+func (DefaultAgent) ChooseLoopIterations(g *game.Game, p *game.Player, loop Loop) int {
+	return loopIterationsToFinish(g, loop)
+}
+
+// OrderTriggers keeps the order the triggers were registered in.
+func (DefaultAgent) OrderTriggers(g *game.Game, p *game.Player, triggers []game.PendingTrigger) []int {
+	out := make([]int, len(triggers))
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+// seatAgents maps each player of a pod to the agent deciding for them.
+type seatAgents map[*game.Player]Agent
+
+// newSeatAgents pairs players with their seats' agents; seats without one
+// get DefaultAgent with model and the seat's combo index.
+func newSeatAgents(players []*game.Player, seats []EDHSeat, model *MulliganModel) seatAgents {
+	agents := seatAgents{}
+	for i, p := range players {
+		if i >= len(seats) {
+			continue
+		}
+		if seats[i].Agent != nil {
+			agents[p] = seats[i].Agent
+		} else {
+			agents[p] = DefaultAgent{Mulligan: model, Combos: seats[i].Combos, Archetype: seatArchetype(seats[i])}
+		}
+	}
+	return agents
+}
+
+// of returns p's agent. A nil seatAgents gives every player DefaultAgent.
+func (a seatAgents) of(p *game.Player) Agent {
+	if agent, ok := a[p]; ok {
+		return agent
+	}
+	return DefaultAgent{}
+}
+
+// agentChooser is the game.LibraryChooser of a game whose searches are
+// decided by agents: of returns the searching player's agent. Scry,
+// surveil and dig choices are left to fallback.
+type agentChooser struct {
+	g        *game.Game
+	of       func(*game.Player) Agent
+	fallback game.LibraryChooser
+}
+
+// chooserFor makes agent pick every search in g.
+func chooserFor(g *game.Game, agent Agent) agentChooser {
+	if agent == nil {
+		agent = DefaultAgent{}
+	}
+	return agentChooser{g: g, of: func(*game.Player) Agent { return agent }}
+}
+
+func (c agentChooser) ChooseSearch(p *game.Player, candidates []game.SimpleCard, max int) []int {
+	picks := c.of(p).ChooseSearch(c.g, p, candidates, max)
+	if !validIndices(picks, len(candidates), max) {
+		picks = DefaultAgent{}.ChooseSearch(c.g, p, candidates, max)
+	}
+	return picks
+}
+
+func (c agentChooser) ChooseScry(p *game.Player, cards []game.SimpleCard) (top, bottom []int) {
+	return c.library().ChooseScry(p, cards)
+}
+
+func (c agentChooser) ChooseSurveil(p *game.Player, cards []game.SimpleCard) (top, graveyard []int) {
+	return c.library().ChooseSurveil(p, cards)
+}
+
+func (c agentChooser) ChooseFromTop(p *game.Player, cards []game.SimpleCard, take int, filter game.CardFilter) (taken, bottom []int) {
+	return c.library().ChooseFromTop(p, cards, take, filter)
+}
+
+func (c agentChooser) library() game.LibraryChooser {
+	if c.fallback == nil {
+		return game.DefaultLibraryChooser{}
+	}
+	return c.fallback
+}
+
+// agentTargets asks agent for the targets of ability, effect by effect,
+// taking modal choices from ChooseModes. Mode indices are spliced into
+// the targets where the ability package expects them.
+func agentTargets(agent Agent, w *PriorityWindow, ability *abil.Ability) []any {
+	var targets []any
+	for _, effect := range ability.Effects {
+		if effect.Type == abil.ChooseMode && len(effect.Modes) > 0 {
+			modes := agent.ChooseModes(w, effect)
+			if !validIndices(modes, len(effect.Modes), len(effect.Modes)) {
+				modes = DefaultAgent{}.ChooseModes(w, effect)
+			}
+			for _, m := range modes {
+				targets = append(targets, m)
+			}
+			continue
+		}
+		one := *ability
+		one.Effects = []abil.Effect{effect}
+		targets = append(targets, agent.ChooseTargets(w, &one)...)
+	}
+	return targets
+}
+
+// discardToHandSize makes p discard down to the maximum hand size in the
+// cleanup step (CR 514.1).
+func discardToHandSize(g *game.Game, p *game.Player, agent Agent) []game.SimpleCard {
+	n := len(p.Hand) - game.OpeningHandSize
+	if n <= 0 {
+		return nil
+	}
+	picks := agent.ChooseDiscard(g, p, n)
+	if len(picks) != n || !validIndices(picks, len(p.Hand), n) {
+		picks = DefaultAgent{}.ChooseDiscard(g, p, n)
+	}
+	discard := map[int]bool{}
+	for _, i := range picks {
+		discard[i] = true
+	}
+	var keep, out []game.SimpleCard
+	for i, c := range p.Hand {
+		if discard[i] {
+			out = append(out, c)
+		} else {
+			keep = append(keep, c)
+		}
+	}
+	p.Hand = keep
+	p.Graveyard = append(p.Graveyard, out...)
+	return out
+}
+
+// orderTriggers lets each controller order their own triggers among
+// pending, which is already in APNAP order (CR 603.3b). Triggers without
+// a controller keep their place.
+func orderTriggers(g *game.Game, agents seatAgents, pending []game.PendingTrigger) []game.PendingTrigger {
+	out := make([]game.PendingTrigger, 0, len(pending))
+	for start := 0; start < len(pending); {
+		ctrl := triggerController(pending[start])
+		end := start + 1
+		for end < len(pending) && triggerController(pending[end]) == ctrl {
+			end++
+		}
+		group := pending[start:end]
+		if ctrl == nil || len(group) == 1 {
+			out = append(out, group...)
+			start = end
+			continue
+		}
+		order := agents.of(ctrl).OrderTriggers(g, ctrl, group)
+		if len(order) != len(group) || !validIndices(order, len(group), len(group)) {
+			order = DefaultAgent{}.OrderTriggers(g, ctrl, group)
+		}
+		for _, i := range order {
+			out = append(out, group[i])
+		}
+		start = end
+	}
+	return out
+}
+
+func triggerController(pt game.PendingTrigger) *game.Player {
+	if pt.Trigger == nil {
+		return nil
+	}
+	return pt.Trigger.Controller
+}
+
+// validIndices reports whether picks are at most max distinct indices
+// into a slice of length n.
+func validIndices(picks []int, n, max int) bool {
+	if len(picks) > max {
+		return false
+	}
+	seen := map[int]bool{}
+	for _, i := range picks {
+		if i < 0 || i >= n || seen[i] {
+			return false
+		}
+		seen[i] = true
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package simulation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// pacifistAgent plays like DefaultAgent but never attacks and orders its
+// triggers last-first.
+type pacifistAgent struct{ DefaultAgent }
+
+func (pacifistAgent) ChooseAttackTarget(*game.Game, *game.Player) *game.Player { return nil }
+
+func (pacifistAgent) OrderTriggers(_ *game.Game, _ *game.Player, triggers []game.PendingTrigger) []int {
+	out := make([]int, len(triggers))
+	for i := range out {
+		out[i] = len(triggers) - 1 - i
+	}
+	return out
+}
+
+func TestSimulateEDHGame_UsesEachSeatsAgent(t *testing.T) {
+	pacifist := makeSeat("Pacifist", "Forest", "Goblin", "1", 8, nil)
+	pacifist.Agent = pacifistAgent{}
+	seats := []EDHSeat{pacifist, makeSeat("Aggro", "Forest", "Goblin", "10", 8, nil)}
+
+	rec, err := SimulateEDHGame(EDHRunOptions{Seats: seats, MaxTurns: 30, RNG: rand.New(rand.NewSource(7))})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if rec.Players[0].CombatDamage != 0 {
+		t.Fatalf("the pacifist never attacks, dealt %d", rec.Players[0].CombatDamage)
+	}
+	if rec.Players[1].CombatDamage == 0 || rec.Winner != "Aggro" {
+		t.Fatalf("expected the default agent to attack and win, got %+v", rec)
+	}
+}
+
+func TestOrderTriggers_EachControllerOrdersTheirOwn(t *testing.T) {
+	p1 := game.NewPlayer("P1", 40)
+	p2 := game.NewPlayer("P2", 40)
+	g := game.NewGame(p1, p2)
+	var pending []game.PendingTrigger
+	for _, p := range []*game.Player{p1, p1, p2, p2} {
+		pending = append(pending, game.PendingTrigger{Trigger: &game.Trigger{Controller: p}})
+	}
+
+	got := orderTriggers(g, seatAgents{p2: pacifistAgent{}}, pending)
+	want := []*game.Trigger{pending[0].Trigger, pending[1].Trigger, pending[3].Trigger, pending[2].Trigger}
+	for i := range want {
+		if got[i].Trigger != want[i] {
+			t.Fatalf("trigger %d out of order: only P2's agent reverses their triggers", i)
+		}
+	}
+}
+
+func TestDiscardToHandSize_DiscardsDownToSeven(t *testing.T) {
+	p1 := game.NewPlayer("P1", 40)
+	g := game.NewGame(p1, game.NewPlayer("P2", 40))
+	for i := 0; i < 4; i++ {
+		p1.Hand = append(p1.Hand, game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"})
+	}
+	for i := 0; i < 5; i++ {
+		p1.Hand = append(p1.Hand, game.SimpleCard{Name: "Blightsteel Colossus", TypeLine: "Artifact Creature — Phyrexian Golem", ManaCost: "{12}"})
+	}
+
+	discarded := discardToHandSize(g, p1, DefaultAgent{})
+	if len(p1.Hand) != 7 || len(p1.Graveyard) != 2 {
+		t.Fatalf("expected 7 cards in hand and 2 discarded, got %d and %d", len(p1.Hand), len(p1.Graveyard))
+	}
+	for _, c := range discarded {
+		if c.Name != "Blightsteel Colossus" {
+			t.Fatalf("the uncastable Colossi go first, discarded %s", c.Name)
+		}
+	}
+}
@@ -0,0 +1,545 @@
+package simulation
+
+import (
+	"sort"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Combat planning for DefaultAgent. Attacks and blocks are chosen by
+// predicting how each fight resolves (simulateFight) rather than by
+// comparing one creature's power against another's toughness: attack
+// plans are scored against the blocks the defenders' own planner would
+// make, and blocks against the damage they prevent and the creatures
+// they trade.
+
+const (
+	// maxEnumeratedPlans bounds exhaustive attack enumeration; bigger
+	// boards are searched by hill climbing from a few seed plans.
+	maxEnumeratedPlans = 256
+	// hillClimbRounds bounds the passes over the attackers when hill
+	// climbing.
+	hillClimbRounds = 3
+	// lethalValue is the score of eliminating an opponent, and the cost
+	// of leaving yourself dead on the crack-back.
+	lethalValue = 100.0
+	// blockDamageWeight is what one point of prevented damage is worth to
+	// a blocker, in creatureValue units, while the attack isn't lethal.
+	blockDamageWeight = 0.25
+	// crackBackWeight discounts the damage opponents could swing back
+	// with next turn.
+	crackBackWeight = 0.1
+	// commanderDamageLethal is the combat damage from one commander that
+	// loses the game (CR 704.5u).
+	commanderDamageLethal = 21
+)
+
+// fight is the predicted result of one attacking creature and its
+// blockers.
+type fight struct {
+	attackerDies bool
+	blockerDies  []bool
+	// toPlayer is the damage the attacker deals the player it attacks,
+	// unblocked or trampling over.
+	toPlayer int
+	// attackerGain and defenderGain are the life each side gains from
+	// lifelink.
+	attackerGain, defenderGain int
+}
+
+// simulateFight predicts the combat damage between attacker a and
+// blockers, mirroring game.ResolveCombatDamage: a first-strike step when
+// anyone has first or double strike, lethal damage assigned to blockers
+// in order with deathtouch making one point lethal, excess trampling
+// over, and lifelink for every source that deals damage. An empty
+// blockers means a is unblocked.
+func simulateFight(a *game.Permanent, blockers []*game.Permanent) fight {
+	out := fight{blockerDies: make([]bool, len(blockers))}
+	aDamage, aLethal := a.GetDamageCounters(), false
+	bDamage := make([]int, len(blockers))
+	bLethal := make([]bool, len(blockers))
+	for i, b := range blockers {
+		bDamage[i] = b.GetDamageCounters()
+	}
+
+	firstStep := a.HasFirstStrike() || a.HasDoubleStrike()
+	for _, b := range blockers {
+		if b.HasFirstStrike() || b.HasDoubleStrike() {
+			firstStep = true
+		}
+	}
+	steps := []bool{false}
+	if firstStep {
+		steps = []bool{true, false}
+	}
+	for _, first := range steps {
+		if out.attackerDies {
+			break
+		}
+		// Damage in a step is simultaneous: who deals it is decided
+		// before any of it is dealt.
+		var toAttacker int
+		var deathtouched bool
+		for i, b := range blockers {
+			if !out.blockerDies[i] && strikesIn(b, first) && b.GetPower() > 0 {
+				toAttacker += b.GetPower()
+				deathtouched = deathtouched || b.HasKeyword(game.KWDeathtouch)
+				if b.HasKeyword(game.KWLifelink) {
+					out.defenderGain += b.GetPower()
+				}
+			}
+		}
+		if strikesIn(a, first) && a.GetPower() > 0 {
+			dmg := a.GetPower()
+			if a.HasKeyword(game.KWLifelink) {
+				out.attackerGain += dmg
+			}
+			if len(blockers) == 0 {
+				out.toPlayer += dmg
+			} else {
+				for i, b := range blockers {
+					if dmg <= 0 || out.blockerDies[i] {
+						continue
+					}
+					needed := max(b.GetToughness()-bDamage[i], 0)
+					if bLethal[i] && bDamage[i] > 0 {
+						needed = 0
+					}
+					if a.HasKeyword(game.KWDeathtouch) && needed > 1 {
+						needed = 1
+					}
+					assigned := min(dmg, needed)
+					bDamage[i] += assigned
+					bLethal[i] = bLethal[i] || (assigned > 0 && a.HasKeyword(game.KWDeathtouch))
+					dmg -= assigned
+				}
+				if a.HasKeyword(game.KWTrample) {
+					out.toPlayer += dmg
+				}
+			}
+		}
+		aDamage += toAttacker
+		aLethal = aLethal || (toAttacker > 0 && deathtouched)
+		out.attackerDies = diesFrom(a, aDamage, aLethal)
+		for i, b := range blockers {
+			out.blockerDies[i] = out.blockerDies[i] || diesFrom(b, bDamage[i], bLethal[i])
+		}
+	}
+	return out
+}
+
+// strikesIn reports whether p deals combat damage in the first-strike
+// step (first) or the regular one.
+func strikesIn(p *game.Permanent, first bool) bool {
+	if first {
+		return p.HasFirstStrike() || p.HasDoubleStrike()
+	}
+	return p.HasDoubleStrike() || !p.HasFirstStrike()
+}
+
+// diesFrom reports whether p is destroyed with damage marked on it,
+// lethal if a deathtouch source dealt some of it (CR 704.5g, 704.5h).
+func diesFrom(p *game.Permanent, damage int, lethal bool) bool {
+	if p.HasKeyword(game.KWIndestructible) {
+		return false
+	}
+	return damage >= p.GetToughness() || (lethal && damage > 0)
+}
+
+// creatureValue is a rough worth of a creature for trading: its stats,
+// a point per combat-relevant keyword, and a premium for commanders.
+func creatureValue(p *game.Permanent) float64 {
+	v := float64(max(p.GetPower(), 0) + max(p.GetToughness(), 0))
+	for _, kw := range []game.Keyword{game.KWFlying, game.KWFirstStrike, game.KWDoubleStrike, game.KWDeathtouch, game.KWLifelink, game.KWTrample, game.KWVigilance, game.KWIndestructible, game.KWMenace} {
+		if p.HasKeyword(kw) {
+			v++
+		}
+	}
+	if p.IsCommander() {
+		v += 5
+	}
+	return v
+}
+
+// canBlockAttacker reports whether blocker may block attacker, as
+// game.DeclareBlocker checks it.
+func canBlockAttacker(blocker, attacker *game.Permanent) bool {
+	if !blocker.IsCreature() || blocker.IsTapped() || blocker.CantBlock() {
+		return false
+	}
+	return !attacker.HasKeyword(game.KWFlying) || blocker.HasKeyword(game.KWFlying) || blocker.HasKeyword(game.KWReach)
+}
+
+// minBlockers is how many creatures it takes to block a (CR 702.110b).
+func minBlockers(a *game.Permanent) int {
+	if a.HasKeyword(game.KWMenace) {
+		return 2
+	}
+	return 1
+}
+
+// attackersOn returns the declared attackers attacking defender, in seat
+// and battlefield order so planning doesn't depend on map iteration.
+func attackersOn(g *game.Game, defender *game.Player) []*game.Permanent {
+	attacks := g.GetAttackers()
+	var out []*game.Permanent
+	for _, p := range g.GetPlayersRaw() {
+		for _, perm := range p.Battlefield {
+			if d, ok := attacks[perm]; ok && d == defender {
+				out = append(out, perm)
+			}
+		}
+	}
+	return out
+}
+
+// chooseBlockers declares defender's blocks against the creatures
+// attacking it, as planned by planBlocks.
+func chooseBlockers(g *game.Game, defender *game.Player) {
+	incoming := attackersOn(g, defender)
+	blocks := planBlocks(defender, incoming)
+	for _, a := range incoming {
+		for _, b := range blocks[a] {
+			_ = g.DeclareBlocker(b, a)
+		}
+	}
+}
+
+// planBlocks picks defender's blocks against incoming, mapping each
+// blocked attacker to its blockers. Attackers are considered biggest
+// threat first. A block is made when it pays for itself — the attacker
+// killed and damage prevented are worth more than the blockers lost —
+// which covers safe blocks, trades and gang-blocks by two creatures.
+// Then, while the attack is still lethal, the cheapest creatures
+// chump-block the attackers whose damage they prevent most.
+func planBlocks(defender *game.Player, incoming []*game.Permanent) map[*game.Permanent][]*game.Permanent {
+	blocks := map[*game.Permanent][]*game.Permanent{}
+	if len(incoming) == 0 {
+		return blocks
+	}
+	var blockers []*game.Permanent
+	for _, perm := range defender.GetCreatures() {
+		if !perm.IsTapped() && !perm.CantBlock() {
+			blockers = append(blockers, perm)
+		}
+	}
+	used := map[*game.Permanent]bool{}
+	free := func(a *game.Permanent) []*game.Permanent {
+		var out []*game.Permanent
+		for _, b := range blockers {
+			if !used[b] && canBlockAttacker(b, a) {
+				out = append(out, b)
+			}
+		}
+		return out
+	}
+	assign := func(a *game.Permanent, bs []*game.Permanent) {
+		blocks[a] = bs
+		for _, b := range bs {
+			used[b] = true
+		}
+	}
+
+	order := append([]*game.Permanent(nil), incoming...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return simulateFight(order[i], nil).toPlayer > simulateFight(order[j], nil).toPlayer
+	})
+	for _, a := range order {
+		unblocked := simulateFight(a, nil).toPlayer
+		var best []*game.Permanent
+		bestScore := 0.0
+		try := func(bs []*game.Permanent) {
+			f := simulateFight(a, bs)
+			score := blockDamageWeight * float64(unblocked-f.toPlayer)
+			if f.attackerDies {
+				score += creatureValue(a)
+			}
+			for i, b := range bs {
+				if f.blockerDies[i] {
+					score -= creatureValue(b)
+				}
+			}
+			if score > bestScore {
+				best, bestScore = bs, score
+			}
+		}
+		cands := free(a)
+		for i, b := range cands {
+			if minBlockers(a) == 1 {
+				try([]*game.Permanent{b})
+			}
+			for _, c := range cands[i+1:] {
+				try([]*game.Permanent{b, c})
+			}
+		}
+		if best != nil {
+			assign(a, best)
+		}
+	}
+
+	for lethalOnBoard(defender, incoming, blocks) {
+		var chumpAttacker *game.Permanent
+		var chump []*game.Permanent
+		bestPrevented, bestCost := 0, 0.0
+		for _, a := range order {
+			if len(blocks[a]) > 0 {
+				continue
+			}
+			cands := free(a)
+			if len(cands) < minBlockers(a) {
+				continue
+			}
+			sort.SliceStable(cands, func(i, j int) bool { return creatureValue(cands[i]) < creatureValue(cands[j]) })
+			bs := cands[:minBlockers(a)]
+			prevented := simulateFight(a, nil).toPlayer - simulateFight(a, bs).toPlayer
+			cost := 0.0
+			for _, b := range bs {
+				cost += creatureValue(b)
+			}
+			if prevented > bestPrevented || (prevented == bestPrevented && prevented > 0 && cost < bestCost) {
+				chumpAttacker, chump, bestPrevented, bestCost = a, bs, prevented, cost
+			}
+		}
+		if chumpAttacker == nil {
+			break
+		}
+		assign(chumpAttacker, chump)
+	}
+	return blocks
+}
+
+// lethalOnBoard reports whether incoming, blocked as in blocks, would
+// kill defender through life loss or commander damage.
+func lethalOnBoard(defender *game.Player, incoming []*game.Permanent, blocks map[*game.Permanent][]*game.Permanent) bool {
+	total, gained := 0, 0
+	for _, a := range incoming {
+		f := simulateFight(a, blocks[a])
+		total += f.toPlayer
+		gained += f.defenderGain
+		if a.IsCommander() && defender.CommanderDamageFrom(a.GetOwner(), a.GetName())+f.toPlayer >= commanderDamageLethal {
+			return true
+		}
+	}
+	return total > 0 && total >= defender.GetLifeTotal()+gained
+}
+
+// attackPlan maps each attacking creature to the player it attacks.
+type attackPlan map[*game.Permanent]*game.Player
+
+// canAttackWith reports whether perm may attack this turn.
+func canAttackWith(g *game.Game, perm *game.Permanent) bool {
+	return perm.IsCreature() && !perm.IsTapped() && !g.SummoningSick(perm) && !perm.HasKeyword(game.KWDefender)
+}
+
+// planAttacks splits ap's creatures between targets and holding back.
+// Small boards are enumerated; bigger ones are hill-climbed from holding
+// everything back and from sending everything at each target in turn.
+// Plans are scored by scoreAttack; damage to primary counts extra, and
+// style weighs damage against the crack-back.
+func planAttacks(g *game.Game, ap *game.Player, targets []*game.Player, primary *game.Player, style Style) attackPlan {
+	var eligible []*game.Permanent
+	for _, perm := range ap.GetCreatures() {
+		if canAttackWith(g, perm) {
+			eligible = append(eligible, perm)
+		}
+	}
+	if len(eligible) == 0 || len(targets) == 0 {
+		return attackPlan{}
+	}
+	// choice[i] is 0 to hold eligible[i] back, or 1+j to attack targets[j].
+	options := len(targets) + 1
+	toPlan := func(choice []int) attackPlan {
+		plan := attackPlan{}
+		for i, c := range choice {
+			if c > 0 {
+				plan[eligible[i]] = targets[c-1]
+			}
+		}
+		return plan
+	}
+	score := func(choice []int) float64 {
+		return scoreAttack(g, ap, eligible, toPlan(choice), targets, primary, style)
+	}
+
+	best := make([]int, len(eligible))
+	bestScore := score(best)
+	total := 1
+	for range eligible {
+		total *= options
+		if total > maxEnumeratedPlans {
+			break
+		}
+	}
+	if total <= maxEnumeratedPlans {
+		choice := make([]int, len(eligible))
+		for n := 1; n < total; n++ {
+			for i, m := 0, n; i < len(choice); i, m = i+1, m/options {
+				choice[i] = m % options
+			}
+			if s := score(choice); s > bestScore {
+				best, bestScore = append([]int(nil), choice...), s
+			}
+		}
+		return toPlan(best)
+	}
+
+	for t := 1; t < options; t++ {
+		choice := make([]int, len(eligible))
+		for i := range choice {
+			choice[i] = t
+		}
+		if s := score(choice); s > bestScore {
+			best, bestScore = choice, s
+		}
+	}
+	for round := 0; round < hillClimbRounds; round++ {
+		improved := false
+		for i := range best {
+			keep := best[i]
+			for c := 0; c < options; c++ {
+				if c == keep {
+					continue
+				}
+				best[i] = c
+				if s := score(best); s > bestScore {
+					bestScore, keep, improved = s, c, true
+				}
+			}
+			best[i] = keep
+		}
+		if !improved {
+			break
+		}
+	}
+	return toPlan(best)
+}
+
+// scoreAttack scores plan for ap: for each target, the blocks
+// planBlocks predicts, creatures traded either way by creatureValue,
+// damage dealt (worth more to low-life targets and to primary), and
+// lethalValue per opponent killed. It then charges for the crack-back:
+// what the surviving opponents could swing at ap next turn past the
+// creatures ap has left untapped, with lethalValue if that could kill.
+// Damage dealt is scaled by style.AttackDamage and the crack-back short
+// of lethal by style.CrackBack.
+func scoreAttack(g *game.Game, ap *game.Player, eligible []*game.Permanent, plan attackPlan, targets []*game.Player, primary *game.Player, style Style) float64 {
+	score := 0.0
+	dead := map[*game.Permanent]bool{}
+	killed := map[*game.Player]bool{}
+	gained := 0
+	for _, d := range targets {
+		var incoming []*game.Permanent
+		for _, a := range eligible {
+			if plan[a] == d {
+				incoming = append(incoming, a)
+			}
+		}
+		if len(incoming) == 0 {
+			continue
+		}
+		blocks := planBlocks(d, incoming)
+		dealt, defenderGain, cmdrLethal := 0, 0, false
+		for _, a := range incoming {
+			f := simulateFight(a, blocks[a])
+			dealt += f.toPlayer
+			gained += f.attackerGain
+			defenderGain += f.defenderGain
+			if f.attackerDies {
+				dead[a] = true
+				score -= creatureValue(a)
+			}
+			for i, b := range blocks[a] {
+				if f.blockerDies[i] {
+					dead[b] = true
+					score += creatureValue(b)
+				}
+			}
+			if a.IsCommander() && d.CommanderDamageFrom(a.GetOwner(), a.GetName())+f.toPlayer >= commanderDamageLethal {
+				cmdrLethal = true
+			}
+		}
+		life := d.GetLifeTotal() + defenderGain
+		if cmdrLethal || (dealt > 0 && dealt >= life) {
+			killed[d] = true
+			score += lethalValue
+			continue
+		}
+		weight := (0.5 + 10/float64(max(life, 1))) * style.AttackDamage
+		if d == primary {
+			weight *= 1.5
+		}
+		score += weight * float64(dealt)
+	}
+	return score - crackBackPenalty(g, ap, plan, dead, killed, gained, style.CrackBack)
+}
+
+// crackBackPenalty estimates what attacking with plan costs ap on the
+// opponents' next turns: each surviving opponent swings with every
+// creature that survives this combat into ap's untapped survivors, which
+// block the biggest attackers they can. The most dangerous opponent
+// counts in full and the rest by half, as they needn't all gang up. A
+// crack-back short of lethal is scaled by weight.
+func crackBackPenalty(g *game.Game, ap *game.Player, plan attackPlan, dead map[*game.Permanent]bool, killed map[*game.Player]bool, gained int, weight float64) float64 {
+	var defenders []*game.Permanent
+	for _, perm := range ap.GetCreatures() {
+		_, attacking := plan[perm]
+		if !dead[perm] && !perm.IsTapped() && !perm.CantBlock() && (!attacking || perm.HasKeyword(game.KWVigilance)) {
+			defenders = append(defenders, perm)
+		}
+	}
+	var swings []int
+	for _, opp := range g.ViewFor(ap).Opponents() {
+		if killed[opp] {
+			continue
+		}
+		var attackers []*game.Permanent
+		for _, perm := range opp.GetCreatures() {
+			if !dead[perm] && !perm.HasKeyword(game.KWDefender) {
+				attackers = append(attackers, perm)
+			}
+		}
+		swings = append(swings, crackBack(attackers, defenders))
+	}
+	if len(swings) == 0 {
+		return 0
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(swings)))
+	risk := float64(swings[0])
+	for _, s := range swings[1:] {
+		risk += float64(s) / 2
+	}
+	if risk >= float64(ap.GetLifeTotal()+gained) {
+		return lethalValue
+	}
+	return crackBackWeight * weight * risk
+}
+
+// crackBack is the damage attackers would deal past defenders, each
+// defender chump-blocking the biggest attacker it can.
+func crackBack(attackers, defenders []*game.Permanent) int {
+	attackers = append([]*game.Permanent(nil), attackers...)
+	sort.SliceStable(attackers, func(i, j int) bool {
+		return simulateFight(attackers[i], nil).toPlayer > simulateFight(attackers[j], nil).toPlayer
+	})
+	used := map[*game.Permanent]bool{}
+	total := 0
+	for _, a := range attackers {
+		var blocker *game.Permanent
+		if minBlockers(a) == 1 {
+			for _, d := range defenders {
+				if used[d] || (a.HasKeyword(game.KWFlying) && !d.HasKeyword(game.KWFlying) && !d.HasKeyword(game.KWReach)) {
+					continue
+				}
+				blocker = d
+				break
+			}
+		}
+		if blocker == nil {
+			total += simulateFight(a, nil).toPlayer
+			continue
+		}
+		used[blocker] = true
+		total += simulateFight(a, []*game.Permanent{blocker}).toPlayer
+	}
+	return total
+}
@@ -0,0 +1,95 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// creatureOn puts a power/toughness creature with kws onto p's
+// battlefield, able to attack this turn.
+func creatureOn(p *game.Player, name string, power, toughness int, kws ...game.Keyword) *game.Permanent {
+	perm := p.PutTokenOnBattlefield(game.SimpleCard{Name: name, TypeLine: "Creature", Power: itoa(power), Toughness: itoa(toughness)})
+	perm.SetEnteredTurn(0)
+	for _, kw := range kws {
+		perm.SetKeyword(kw, true)
+	}
+	return perm
+}
+
+func TestSimulateFight_KeywordMath(t *testing.T) {
+	p1, p2 := makeTestPlayer("P1"), makeTestPlayer("P2")
+
+	striker := creatureOn(p1, "Striker", 3, 1, game.KWFirstStrike)
+	if f := simulateFight(striker, []*game.Permanent{creatureOn(p2, "Bear", 2, 3)}); f.attackerDies || !f.blockerDies[0] {
+		t.Fatalf("first strike kills the blocker before it strikes back, got %+v", f)
+	}
+	assassin := creatureOn(p1, "Assassin", 1, 1, game.KWDeathtouch)
+	if f := simulateFight(assassin, []*game.Permanent{creatureOn(p2, "Giant", 5, 5)}); !f.attackerDies || !f.blockerDies[0] {
+		t.Fatalf("deathtouch trades with anything, got %+v", f)
+	}
+	wurm := creatureOn(p1, "Wurm", 6, 6, game.KWTrample, game.KWLifelink)
+	if f := simulateFight(wurm, []*game.Permanent{creatureOn(p2, "Bear", 2, 2)}); f.toPlayer != 4 || f.attackerGain != 6 || f.attackerDies {
+		t.Fatalf("expected 4 trampling over and 6 life gained, got %+v", f)
+	}
+	if f := simulateFight(creatureOn(p1, "Knight", 2, 2, game.KWDoubleStrike), nil); f.toPlayer != 4 {
+		t.Fatalf("double strike hits twice unblocked, got %d", f.toPlayer)
+	}
+}
+
+func TestChooseBlockers_BlocksTappedAttackers(t *testing.T) {
+	p1, p2 := makeTestPlayer("P1"), makeTestPlayer("P2")
+	g := game.NewGame(p1, p2)
+	bear := creatureOn(p1, "Bear", 2, 2)
+	wall := creatureOn(p2, "Ogre", 3, 3)
+	if err := g.DeclareAttacker(bear, p2); err != nil {
+		t.Fatalf("declare: %v", err)
+	}
+
+	chooseBlockers(g, p2)
+	if blocks := g.GetBlocks()[bear]; len(blocks) != 1 || blocks[0] != wall {
+		t.Fatalf("expected the Ogre to eat the attacking (so tapped) Bear, got %v", blocks)
+	}
+}
+
+func TestPlanBlocks_GangBlocksAndChumpsOnlyWhenLethal(t *testing.T) {
+	p1, p2 := makeTestPlayer("P1"), makeTestPlayer("P2")
+	giant := creatureOn(p1, "Giant", 3, 3)
+	creatureOn(p2, "Bear A", 2, 2)
+	creatureOn(p2, "Bear B", 2, 2)
+	if blocks := planBlocks(p2, []*game.Permanent{giant}); len(blocks[giant]) != 2 {
+		t.Fatalf("two Bears should gang-block the Giant, losing one to kill it, got %v", blocks[giant])
+	}
+
+	p3, p4 := makeTestPlayer("P3"), makeTestPlayer("P4")
+	dragon := creatureOn(p3, "Dragon", 8, 8)
+	creatureOn(p4, "Squirrel", 1, 1)
+	if blocks := planBlocks(p4, []*game.Permanent{dragon}); len(blocks[dragon]) != 0 {
+		t.Fatalf("no chump block at 40 life, got %v", blocks[dragon])
+	}
+	p4.SetLifeTotal(8)
+	if blocks := planBlocks(p4, []*game.Permanent{dragon}); len(blocks[dragon]) != 1 {
+		t.Fatalf("expected a chump block against lethal, got %v", blocks[dragon])
+	}
+}
+
+func TestPlanAttacks_SplitsForLethalAndHoldsBackAgainstCrackBack(t *testing.T) {
+	p1, low, high := makeTestPlayer("P1"), makeTestPlayer("Low"), makeTestPlayer("High")
+	g := game.NewGame(p1, low, high)
+	low.SetLifeTotal(3)
+	first := creatureOn(p1, "Bear A", 3, 3)
+	second := creatureOn(p1, "Bear B", 3, 3)
+	plan := planAttacks(g, p1, g.ViewFor(p1).Opponents(), high, StyleFor(""))
+	if plan[first] != low && plan[second] != low {
+		t.Fatalf("expected a Bear sent to finish Low, got %v", plan)
+	}
+
+	p2, opp := makeTestPlayer("P2"), makeTestPlayer("Opp")
+	g = game.NewGame(p2, opp)
+	p2.SetLifeTotal(5)
+	creatureOn(p2, "Guard", 2, 2)
+	creatureOn(opp, "Horror", 6, 6)
+	if plan := planAttacks(g, p2, []*game.Player{opp}, opp, StyleFor("")); len(plan) != 0 {
+		t.Fatalf("attacking with the Guard leaves P2 dead to the Horror, got %v", plan)
+	}
+}
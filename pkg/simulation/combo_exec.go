@@ -0,0 +1,894 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/bridge"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// The combo executor plays a combo.VariantInfo for real. It checks the
+// variant's zone, template and mana requirements against the game state
+// (ready), casts the pieces it still needs in the order the variant's
+// steps name them, through the stack when a StackAwareHandler is running
+// so opponents can answer each one, and equips what the steps equip
+// (deploy). Then it runs the loop (runLoop): each pass activates the
+// pieces' abilities through the ability engine, casts their spells again
+// from hand or by escape from the graveyard, and fights the next combat
+// when the steps attack. The loop is bounded by comboLoopCap. Once the
+// loop detector (loops.go) sees a pass end with the game back where it
+// was and strictly more of some resource, the loop is proven and the
+// controller repeats it as a shortcut (CR 732.2a). A combo whose piece is
+// countered or removed, or whose loop stalls, fails, and the player has
+// only spent what it spent. Only a result the game reaches counts: a
+// variant the engine can't play out is never ready.
+
+// comboLoopCap bounds the passes the executor makes over a combo's
+// abilities.
+const comboLoopCap = 200
+
+// comboOutcome is the end a combo's Produces promises, weakest first.
+type comboOutcome int
+
+const (
+	outcomeNone comboOutcome = iota
+	// outcomeMill empties each opponent's library; they lose at their
+	// next draw.
+	outcomeMill
+	// outcomeDamage deals each opponent damage or life loss beyond their
+	// life total.
+	outcomeDamage
+	// outcomeWin wins the game outright.
+	outcomeWin
+)
+
+// featureOutcome reads a Commander Spellbook feature name.
+func featureOutcome(feature string) comboOutcome {
+	f := strings.ToLower(feature)
+	switch {
+	case strings.Contains(f, "win the game"), strings.Contains(f, "lose the game"), strings.Contains(f, "loses the game"):
+		return outcomeWin
+	case strings.Contains(f, "self-mill"), strings.Contains(f, "self mill"):
+		return outcomeNone
+	case strings.Contains(f, "infinite mill"):
+		return outcomeMill
+	case strings.Contains(f, "infinite damage"), strings.Contains(f, "infinite lifeloss"), strings.Contains(f, "infinite life loss"), strings.Contains(f, "infinite combat"), strings.Contains(f, "infinite hasty"):
+		return outcomeDamage
+	}
+	return outcomeNone
+}
+
+// variantOutcome is the strongest outcome among v's features.
+func variantOutcome(v combo.VariantInfo) comboOutcome {
+	best := outcomeNone
+	for _, f := range v.Produces {
+		best = max(best, featureOutcome(f))
+	}
+	return best
+}
+
+func containsCardName(names []string, name string) bool {
+	for _, n := range names {
+		if sameCardName(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// knownCombos holds the lines attemptCEDHComboFinish plays without a
+// deck's Commander Spellbook data. It also drives tutoring and the threat
+// model.
+var knownCombos = combo.NewIndex(&combo.FindMyCombosResult{Included: []combo.Variant{
+	knownVariant("oracle-consultation", "Win the game",
+		"Cast Demonic Consultation, naming a card that isn't in your library.\nCast Thassa's Oracle; its enters trigger wins the game.",
+		knownPiece("Thassa's Oracle", combo.ZoneHand), knownPiece("Demonic Consultation", combo.ZoneHand)),
+	knownVariant("oracle-pact", "Win the game",
+		"Cast Tainted Pact, exiling your library.\nCast Thassa's Oracle; its enters trigger wins the game.",
+		knownPiece("Thassa's Oracle", combo.ZoneHand), knownPiece("Tainted Pact", combo.ZoneHand)),
+	knownVariant("breach-freeze-diamond", "Infinite mill",
+		"Cast Underworld Breach.\nCast Brain Freeze, then escape it from your graveyard, cracking Lion's Eye Diamond for the mana.",
+		knownPiece("Underworld Breach", combo.ZoneBattlefield, combo.ZoneHand), knownPiece("Brain Freeze", combo.ZoneHand, combo.ZoneGraveyard),
+		knownPiece("Lion's Eye Diamond", combo.ZoneHand, combo.ZoneGraveyard, combo.ZoneBattlefield)),
+	knownVariant("breach-freeze-station", "Infinite mill",
+		"Cast Underworld Breach.\nCast Brain Freeze, then escape it from your graveyard.",
+		knownPiece("Underworld Breach", combo.ZoneBattlefield, combo.ZoneHand), knownPiece("Brain Freeze", combo.ZoneHand, combo.ZoneGraveyard),
+		knownPiece("Grinding Station", combo.ZoneBattlefield, combo.ZoneHand)),
+	knownVariant("breach-freeze", "Infinite mill",
+		"Cast Underworld Breach.\nCast Brain Freeze, then escape it from your graveyard, exiling the graveyard for fodder and floating mana for each escape.",
+		knownPiece("Underworld Breach", combo.ZoneBattlefield, combo.ZoneHand), knownPiece("Brain Freeze", combo.ZoneHand, combo.ZoneGraveyard)),
+	knownVariant("godo-helm", "Infinite combat phases",
+		"Cast Godo, Bandit Warlord and Helm of the Host.\nEquip Helm of the Host to Godo.\nAttack; each hasty Godo token untaps your creatures and adds a combat phase.",
+		knownPiece("Godo, Bandit Warlord", combo.ZoneBattlefield, combo.ZoneCommand, combo.ZoneHand), knownPiece("Helm of the Host", combo.ZoneBattlefield, combo.ZoneHand)),
+	knownVariant("dualcaster-twinflame", "Infinite hasty tokens",
+		"Cast Twinflame with no targets.\nIn response, cast Dualcaster Mage, copying Twinflame to copy the Mage; each copy's trigger copies Twinflame again.\nAttack with the hasty copies.",
+		knownPiece("Dualcaster Mage", combo.ZoneHand), knownPiece("Twinflame", combo.ZoneHand)),
+}}, nil)
+
+// knownVariant builds a Commander Spellbook style variant for knownCombos.
+func knownVariant(id, produces, description string, uses ...combo.CardInVariant) combo.Variant {
+	return combo.Variant{
+		ID:          id,
+		Uses:        uses,
+		Produces:    []combo.FeatureInVariant{{Feature: combo.Feature{Name: produces}}},
+		Description: description,
+	}
+}
+
+func knownPiece(name string, zones ...string) combo.CardInVariant {
+	return combo.CardInVariant{Card: combo.ComboCard{Name: name}, ZoneLocations: zones}
+}
+
+// heldForCombo reports whether a knownCombos line casts the named card
+// from hand as one of its steps. The main-phase caster keeps such cards
+// for attemptCEDHComboFinish.
+func heldForCombo(name string) bool {
+	for _, v := range knownCombos.Variants {
+		for _, piece := range v.Pieces {
+			if sameCardName(piece.Name, name) && len(piece.Zones) == 1 && piece.Zones[0] == combo.ZoneHand {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// comboVariants returns the combos p's agent can go for: its deck's
+// included variants, then knownCombos, each card set once.
+func comboVariants(agent Agent) []combo.VariantInfo {
+	var out []combo.VariantInfo
+	seen := map[string]bool{}
+	add := func(idx *combo.Index) {
+		if idx == nil {
+			return
+		}
+		ids := make([]string, 0, len(idx.Variants))
+		for id := range idx.Variants {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			v := idx.Variants[id]
+			names := append([]string(nil), v.CardNames...)
+			sort.Strings(names)
+			key := strings.Join(names, "|")
+			if !v.IsIncluded || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, v)
+		}
+	}
+	if a, ok := agent.(interface{ deckCombos() *combo.Index }); ok {
+		add(a.deckCombos())
+	}
+	add(knownCombos)
+	return out
+}
+
+// comboRun is one player's attempt at its combos this main phase.
+type comboRun struct {
+	g       *game.Game
+	p       *game.Player
+	h       *StackAwareHandler
+	agent   Agent
+	log     *EDHEventLog
+	metrics *edhMetrics
+	// pieces names the cards of the variant being executed; escape
+	// doesn't exile them for fodder.
+	pieces []string
+}
+
+// comboPiece is a piece of a variant where ready found it.
+type comboPiece struct {
+	combo.Piece
+	card game.SimpleCard
+	zone string
+}
+
+// locate finds piece in the first of its zones that holds it.
+func (r *comboRun) locate(piece combo.Piece) (comboPiece, bool) {
+	zones := piece.Zones
+	if len(zones) == 0 {
+		zones = []string{combo.ZoneBattlefield}
+	}
+	for _, z := range zones {
+		var zone []game.SimpleCard
+		switch z {
+		case combo.ZoneBattlefield:
+			for _, perm := range r.p.Battlefield {
+				if sameCardName(perm.GetName(), piece.Name) && (!piece.Commander || perm.IsCommander()) {
+					return comboPiece{piece, perm.GetSource(), z}, true
+				}
+			}
+			continue
+		case combo.ZoneHand:
+			zone = r.p.Hand
+		case combo.ZoneCommand:
+			zone = r.p.CommandZone
+		case combo.ZoneGraveyard:
+			zone = r.p.Graveyard
+		case combo.ZoneLibrary:
+			zone = r.p.Library
+		case combo.ZoneExile:
+			zone = r.p.Exile
+		}
+		if i := findZoneCard(zone, piece.Name); i >= 0 {
+			return comboPiece{piece, zone[i], z}, true
+		}
+	}
+	return comboPiece{}, false
+}
+
+// castCost is what casting c from zone costs p, commander tax included.
+func (r *comboRun) castCost(c game.SimpleCard, zone string) game.SimpleCard {
+	if zone != combo.ZoneCommand {
+		return c
+	}
+	if tax := r.p.CommanderTax(c.Name); tax > 0 {
+		c.ManaCost += taxCard(tax).ManaCost
+	}
+	return c
+}
+
+// ready reports whether p can go for v now: every piece is in one of its
+// zones, the pieces in hand or the command zone can be cast, and p's pool
+// and untapped sources cover their costs and v's ManaNeeded. Variants
+// that need a card Commander Spellbook only describes by a template are
+// never ready: the executor can't tell which card fills it. Nor are
+// variants it can't play (see playable).
+func (r *comboRun) ready(v combo.VariantInfo) bool {
+	if len(v.Templates) > 0 || len(v.Pieces) == 0 {
+		return false
+	}
+	if variantOutcome(v) == outcomeNone || !r.playable(v) {
+		return false
+	}
+	cost := game.Mana{}
+	for _, piece := range v.Pieces {
+		at, ok := r.locate(piece)
+		if !ok {
+			return false
+		}
+		from := game.Hand
+		switch at.zone {
+		case combo.ZoneHand:
+		case combo.ZoneCommand:
+			from = game.Command
+		default:
+			continue
+		}
+		if !r.g.CanCast(r.p, at.card, from) {
+			return false
+		}
+		for mt, n := range r.castCost(at.card, at.zone).GetMinManaCost() {
+			cost.Add(mt, n)
+		}
+	}
+	if v.ManaNeeded != "" {
+		cost = manaUnion(cost, game.SimpleCard{ManaCost: v.ManaNeeded}.GetManaCost())
+	}
+	_, ok := planOpenMana(r.p.GetManaPool(), openManaSources(r.g, r.p), cost)
+	return ok
+}
+
+// playable reports whether the executor can play v out: a scripted piece
+// resolves its own result, a piece has an ability the loop activates, or
+// a piece is a spell the loop casts again. Lines the engine can't play
+// aren't attempted rather than won by fiat.
+func (r *comboRun) playable(v combo.VariantInfo) bool {
+	engine := abil.NewExecutionEngine(bridge.NewAbilityGameState(r.g))
+	for _, piece := range v.Pieces {
+		at, ok := r.locate(piece)
+		if !ok {
+			return false
+		}
+		if abil.HasCardScript(at.card.Name) || loopSpell(engine, at.card) {
+			return true
+		}
+		parsed, err := engine.ParseAndRegisterAbilities(at.card.OracleText, at.card)
+		if err != nil {
+			continue
+		}
+		for _, ab := range parsed {
+			if loopAbility(ab) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// execute plays v, reporting whether p won. Callers check ready first.
+func (r *comboRun) execute(v combo.VariantInfo) bool {
+	r.pieces = v.CardNames
+	if !r.deploy(v) {
+		return false
+	}
+	if r.won(v) {
+		return true
+	}
+	return r.runLoop(v)
+}
+
+// won reports whether v has done what it produces: every opponent is out
+// or, for a mill combo, has no library left to draw from.
+func (r *comboRun) won(v combo.VariantInfo) bool {
+	return opponentsEliminated(r.g, r.p) || variantOutcome(v) == outcomeMill && opponentsDecked(r.g, r.p)
+}
+
+// deploy casts the pieces of v still in hand or the command zone, in the
+// order v's steps mention them, and then equips what the steps equip. A
+// piece the steps cast "in response" is cast while the one before it is
+// still on the stack (castChain). It stops at the first piece that can't
+// be paid for or is countered.
+func (r *comboRun) deploy(v combo.VariantInfo) bool {
+	steps := strings.ToLower(strings.Join(v.Steps, "\n"))
+	var casts []comboPiece
+	for _, piece := range v.Pieces {
+		at, ok := r.locate(piece)
+		if !ok {
+			return false
+		}
+		if at.zone == combo.ZoneHand || at.zone == combo.ZoneCommand {
+			casts = append(casts, at)
+		}
+	}
+	sort.SliceStable(casts, func(i, j int) bool {
+		return stepIndex(steps, casts[i].Name) < stepIndex(steps, casts[j].Name)
+	})
+	for i := 0; i < len(casts); i++ {
+		chain := casts[i : i+1]
+		for i+1 < len(casts) && inResponse(steps, casts[i+1].Name) {
+			i++
+			chain = append(chain, casts[i])
+		}
+		cast := false
+		if len(chain) == 1 {
+			cast = r.castPiece(chain[0].card, chain[0].zone)
+		} else {
+			cast = r.castChain(chain)
+		}
+		if !cast {
+			return false
+		}
+	}
+	return r.equip(v, steps)
+}
+
+// inResponse reports whether the sentence of steps that first mentions
+// name casts it in response to a spell on the stack.
+func inResponse(steps, name string) bool {
+	i := stepIndex(steps, name)
+	if i > len(steps) {
+		return false
+	}
+	before := steps[:i]
+	return strings.Contains(before[strings.LastIndexAny(before, ".\n")+1:], "in response")
+}
+
+// castChain casts chain from hand, each piece in response to the ones
+// before it, which are still on the stack: Twinflame, then Dualcaster Mage
+// to copy it. The chain is paid for at once, so a generic cost doesn't
+// spend colored mana a later piece needs, and it is played on one stack
+// without a priority round; a permanent in it enters at once, with its
+// enters triggers on the stack above the spells.
+func (r *comboRun) castChain(chain []comboPiece) bool {
+	g, p := r.g, r.p
+	var total game.SimpleCard
+	for _, at := range chain {
+		if at.zone != combo.ZoneHand {
+			return false
+		}
+		total.ManaCost += at.card.ManaCost
+	}
+	if !payWithOpenMana(g, p, total) {
+		return false
+	}
+	cs := newComboStack(g)
+	var spells []game.SimpleCard
+	for _, at := range chain {
+		c := at.card
+		if c.IsInstant() || c.IsSorcery() {
+			i := findZoneCard(p.Hand, c.Name)
+			if i < 0 {
+				continue
+			}
+			p.Hand = append(p.Hand[:i], p.Hand[i+1:]...)
+			targets, _ := r.spellTargets(c)
+			var args []any
+			for _, t := range targets {
+				args = append(args, cs.player(t))
+			}
+			cs.cast(p, c, args)
+			spells = append(spells, c)
+		} else {
+			perm, err := castPermanentCard(g, p, c)
+			if err != nil || perm == nil {
+				continue
+			}
+			g.RecordSpellCast(p)
+			cs.stack.AddEntersTriggers(perm, cs.player(p))
+		}
+		recordComboCast(g, p, c, manaSpentForCard(c), c.IsCreature(), r.log, r.metrics)
+	}
+	cs.resolve()
+	p.Graveyard = append(p.Graveyard, spells...)
+	return true
+}
+
+// equip activates the equip ability of each Equipment piece v's steps
+// equip, attaching it to the first creature piece p controls. It reports
+// false if an equip ability can't be activated.
+func (r *comboRun) equip(v combo.VariantInfo, steps string) bool {
+	gs := bridge.NewAbilityGameState(r.g)
+	engine := abil.NewExecutionEngine(gs)
+	me := gs.GetPlayer(r.p.GetName())
+	var creature *game.Permanent
+	for _, perm := range r.p.Battlefield {
+		if perm.IsCreature() && containsCardName(v.CardNames, perm.GetName()) {
+			creature = perm
+			break
+		}
+	}
+	for _, perm := range r.p.Battlefield {
+		src := perm.GetSource()
+		if !containsCardName(v.CardNames, src.Name) || !strings.Contains(src.TypeLine, "Equipment") ||
+			!strings.Contains(steps, "equip "+strings.ToLower(src.Name)) || perm.GetAttachedTo() != nil {
+			continue
+		}
+		if creature == nil || me == nil {
+			return false
+		}
+		var target any
+		for _, t := range engine.GetPotentialTargets(abil.CreatureTarget, nil) {
+			if c, ok := t.(interface{ Underlying() *game.Permanent }); ok && c.Underlying() == creature {
+				target = t
+			}
+		}
+		parsed, err := engine.ParseAndRegisterAbilities(src.OracleText, perm)
+		if err != nil || target == nil {
+			return false
+		}
+		equipped := false
+		for _, ab := range parsed {
+			if ab.Name == "Equip" && engine.ExecuteAbility(ab, me, []any{target}) == nil {
+				equipped = true
+				break
+			}
+		}
+		if !equipped {
+			return false
+		}
+	}
+	return true
+}
+
+// stepIndex is where steps first mention name, by full name or the part
+// before its comma; unmentioned cards sort last.
+func stepIndex(steps, name string) int {
+	name = strings.ToLower(name)
+	if i := strings.Index(steps, name); i >= 0 {
+		return i
+	}
+	if short, _, ok := strings.Cut(name, ","); ok {
+		if i := strings.Index(steps, short); i >= 0 {
+			return i
+		}
+	}
+	return len(steps) + 1
+}
+
+// castPiece pays for c and casts it from zone, through the stack when a
+// handler is running. A spell from the graveyard is cast by escape, which
+// also exiles other cards from there. It reports false if c couldn't be
+// paid for or was countered.
+func (r *comboRun) castPiece(c game.SimpleCard, zone string) bool {
+	g, p := r.g, r.p
+	spell := c.IsInstant() || c.IsSorcery()
+	var targets []*game.Player
+	if spell {
+		var ok bool
+		if targets, ok = r.spellTargets(c); !ok {
+			return false
+		}
+	}
+	var keep, exile []game.SimpleCard
+	if zone == combo.ZoneGraveyard {
+		n, ok := g.EscapeCost(p, c)
+		if !ok || !spell || !g.CanCast(p, c, game.Graveyard) {
+			return false
+		}
+		if keep, exile, ok = r.escapeFodder(c, n); !ok {
+			return false
+		}
+	}
+	if !payWithOpenMana(g, p, r.castCost(c, zone)) {
+		return false
+	}
+	manaSpent := manaSpentForCard(c)
+	if zone == combo.ZoneCommand {
+		manaSpent = manaSpentForCommander(p, c)
+	}
+	resolved := false
+	switch {
+	case zone == combo.ZoneCommand && r.h != nil:
+		resolved = r.h.CastCommanderThroughStack(p, c, p.GetName()) != nil
+	case zone == combo.ZoneCommand:
+		if g.CastCommander(p, c.Name) != nil {
+			g.RecordSpellCast(p)
+			resolved = true
+		}
+	case spell:
+		if zone == combo.ZoneGraveyard {
+			p.Graveyard = keep
+			p.Exile = append(p.Exile, exile...)
+		} else if i := findZoneCard(p.Hand, c.Name); i >= 0 {
+			p.Hand = append(p.Hand[:i], p.Hand[i+1:]...)
+		} else {
+			return false
+		}
+		resolved = r.castSpell(c, targets)
+	case r.h != nil:
+		resolved = r.h.CastPermanentThroughStack(p, c, p.GetName())
+	default:
+		if perm, err := castPermanentCard(g, p, c); err == nil && perm != nil {
+			perm.SetEnteredTurn(g.GetTurnNumber())
+			g.RecordSpellCast(p)
+			resolvePermanentETB(g, perm, p, r.log)
+			resolved = true
+		}
+	}
+	if resolved {
+		recordComboCast(g, p, c, manaSpent, c.IsCreature(), r.log, r.metrics)
+	}
+	return resolved
+}
+
+// castSpell casts c, already out of the zone it was cast from, at
+// targets: through the stack when a handler is running, else on a combo
+// stack that resolves at once. c ends in the graveyard. It reports false
+// if c was countered.
+func (r *comboRun) castSpell(c game.SimpleCard, targets []*game.Player) bool {
+	if r.h != nil {
+		return r.h.castSpell(r.p, c, r.p.GetName(), targets)
+	}
+	cs := newComboStack(r.g)
+	var args []any
+	for _, t := range targets {
+		args = append(args, cs.player(t))
+	}
+	cs.cast(r.p, c, args)
+	cs.resolve()
+	r.p.Graveyard = append(r.p.Graveyard, c)
+	return true
+}
+
+// escapeFodder splits p's graveyard for escaping c: exile holds the first
+// n other cards that aren't pieces of the combo, and keep the rest, c
+// excluded. It reports false if there aren't n such cards.
+func (r *comboRun) escapeFodder(c game.SimpleCard, n int) (keep, exile []game.SimpleCard, ok bool) {
+	escaped := false
+	for _, gc := range r.p.Graveyard {
+		switch {
+		case !escaped && sameCardName(gc.Name, c.Name):
+			escaped = true
+		case len(exile) < n && !containsCardName(r.pieces, gc.Name):
+			exile = append(exile, gc)
+		default:
+			keep = append(keep, gc)
+		}
+	}
+	return keep, exile, escaped && len(exile) == n
+}
+
+// loopEffects are the effects a combo loop repeats for profit.
+var loopEffects = map[abil.EffectType]bool{
+	abil.DealDamage: true, abil.LoseLife: true, abil.GainLife: true, abil.AddMana: true,
+	abil.CreateToken: true, abil.MillCards: true, abil.DrawCards: true,
+	abil.UntapPermanent: true, abil.AddCounters: true,
+}
+
+// loopSpell reports whether c is a spell the loop casts again whenever it
+// can: an instant or sorcery whose effects the engine plays exactly, at
+// least one of them a loopEffects effect.
+func loopSpell(engine *abil.ExecutionEngine, c game.SimpleCard) bool {
+	if !c.IsInstant() && !c.IsSorcery() {
+		return false
+	}
+	parsed, err := engine.ParseAndRegisterAbilities(c.OracleText, c)
+	if err != nil {
+		return false
+	}
+	useful := false
+	for _, ab := range parsed {
+		for _, e := range ab.Effects {
+			if e.Approximate {
+				return false
+			}
+			useful = useful || loopEffects[e.Type]
+		}
+	}
+	return useful
+}
+
+// loopAbility reports whether ab is an ability the loop activates: an
+// activated or mana ability with a cost whose effects the engine plays
+// exactly, at least one of them a loopEffects effect.
+func loopAbility(ab *abil.Ability) bool {
+	if ab.Type != abil.Activated && ab.Type != abil.Mana {
+		return false
+	}
+	c := ab.Cost
+	if len(c.ManaCost) == 0 && !c.TapCost && !c.SacrificeCost && c.LifeCost == 0 && c.DiscardCost == 0 && len(c.Typed) == 0 {
+		return false
+	}
+	useful := false
+	for _, e := range ab.Effects {
+		if e.Approximate {
+			return false
+		}
+		useful = useful || loopEffects[e.Type]
+	}
+	return useful
+}
+
+// runLoop plays the loop of v one pass at a time until v has done what
+// it produces, a pass does nothing, or comboLoopCap passes have run. A
+// pass activates the abilities of v's pieces, casts their spells again
+// from hand or by escape, and, when v's steps attack, fights the next
+// combat phase of p's turn. Once the loop detector proves the passes
+// repeatable, the controller names how many more to make and they are
+// applied at once; the loop carries on after that only while it still
+// hurts an opponent.
+func (r *comboRun) runLoop(v combo.VariantInfo) bool {
+	gs := bridge.NewAbilityGameState(r.g)
+	engine := abil.NewExecutionEngine(gs)
+	me := gs.GetPlayer(r.p.GetName())
+	if me == nil {
+		return false
+	}
+	var abilities []*abil.Ability
+	for _, perm := range r.p.Battlefield {
+		if !containsCardName(v.CardNames, perm.GetName()) {
+			continue
+		}
+		parsed, err := engine.ParseAndRegisterAbilities(perm.GetSource().OracleText, perm)
+		if err != nil {
+			continue
+		}
+		for _, ab := range parsed {
+			if loopAbility(ab) {
+				abilities = append(abilities, ab)
+			}
+		}
+	}
+	var spells []string
+	for _, piece := range v.Pieces {
+		if at, ok := r.locate(piece); ok && loopSpell(engine, at.card) {
+			spells = append(spells, at.Name)
+		}
+	}
+	attacks := strings.Contains(strings.ToLower(strings.Join(v.Steps, "\n")), "attack")
+	if len(abilities) == 0 && len(spells) == 0 && !attacks {
+		return false
+	}
+	var loops loopDetector
+	loops.observe(loopPosition(r.g), measureLoop(r.g, r.p))
+	for pass := 1; pass <= comboLoopCap; pass++ {
+		acted := 0
+		for _, ab := range abilities {
+			targets, ok := r.loopTargets(gs, engine, ab)
+			if ok && engine.ExecuteAbility(ab, me, targets) == nil {
+				acted++
+			}
+		}
+		for _, name := range spells {
+			if r.recast(name) {
+				acted++
+			}
+		}
+		r.g.ApplyStateBasedActions()
+		if !r.won(v) && attacks && r.fight() {
+			acted++
+		}
+		if r.won(v) {
+			return true
+		}
+		if acted == 0 {
+			return false
+		}
+		gain, steps, again := loops.observe(loopPosition(r.g), measureLoop(r.g, r.p))
+		if !again || !repeatable(gain) {
+			continue
+		}
+		loop := Loop{Controller: r.p, Steps: steps, Gain: gain}
+		hurts := loop.hurtsAnOpponent(r.g)
+		if !r.repeat(v, loop) {
+			return false
+		}
+		if r.won(v) {
+			return true
+		}
+		if !hurts {
+			return false
+		}
+		loops = loopDetector{}
+		loops.observe(loopPosition(r.g), measureLoop(r.g, r.p))
+	}
+	return false
+}
+
+// recast casts the named spell piece again, from hand if it is there and
+// otherwise by escape from the graveyard.
+func (r *comboRun) recast(name string) bool {
+	if i := findZoneCard(r.p.Hand, name); i >= 0 {
+		return r.g.CanCast(r.p, r.p.Hand[i], game.Hand) && r.castPiece(r.p.Hand[i], combo.ZoneHand)
+	}
+	if i := findZoneCard(r.p.Graveyard, name); i >= 0 {
+		return r.castPiece(r.p.Graveyard[i], combo.ZoneGraveyard)
+	}
+	return false
+}
+
+// fight plays the next combat phase of p's turn: it moves the game on to
+// the phase, resolves the beginning-of-combat triggers, attacks through
+// the seat agents, resolves what the attacks triggered and leaves the
+// phase, for another combat phase when an attack added one. It reports
+// false once p's turn has no combat left, or if the triggers don't
+// settle.
+func (r *comboRun) fight() bool {
+	g := r.g
+	if g.GetActivePlayerRaw() != r.p || g.GetCurrentPhase() > game.PhaseCombat {
+		return false
+	}
+	for g.GetCurrentPhase() != game.PhaseCombat {
+		g.AdvancePhase()
+	}
+	var agents seatAgents
+	var priority PriorityHandler
+	switch {
+	case r.h != nil:
+		agents, priority = r.h.agents, r.h
+	case r.agent != nil:
+		agents = seatAgents{r.p: r.agent}
+	}
+	if settleTriggers(g, agents, r.h) != triggersSettled {
+		return false
+	}
+	runCombatPhase(g, r.p, agents, r.log, r.metrics, priority)
+	g.ApplyStateBasedActions()
+	if settleTriggers(g, agents, r.h) != triggersSettled {
+		return false
+	}
+	g.AdvancePhase()
+	return true
+}
+
+// loopTargets aims ab's player targets as playerTarget does, and its
+// other targets at the first legal one an opponent controls. It reports
+// false if ab has a target it can't find.
+func (r *comboRun) loopTargets(gs *bridge.AbilityGameState, engine *abil.ExecutionEngine, ab *abil.Ability) ([]any, bool) {
+	var targets []any
+	for _, e := range ab.Effects {
+		for _, t := range e.Targets {
+			switch t.Type {
+			case abil.NoTarget:
+			case abil.AnyTarget, abil.PlayerTarget:
+				opp := r.playerTarget(e)
+				if opp == nil {
+					return nil, false
+				}
+				targets = append(targets, gs.GetPlayer(opp.GetName()))
+			default:
+				target := r.opposingTarget(engine.GetPotentialTargets(t.Type, nil))
+				if target == nil {
+					return nil, false
+				}
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets, true
+}
+
+// spellTargets aims the player targets of spell c as playerTarget does.
+// Its other targets go unchosen, as Twinflame's do when it starts the
+// Dualcaster Mage loop. It reports false if c targets a player and there
+// is no opponent worth aiming at.
+func (r *comboRun) spellTargets(c game.SimpleCard) ([]*game.Player, bool) {
+	engine := abil.NewExecutionEngine(bridge.NewAbilityGameState(r.g))
+	parsed, err := engine.ParseAndRegisterAbilities(c.OracleText, c)
+	if err != nil {
+		return nil, true
+	}
+	var targets []*game.Player
+	for _, ab := range parsed {
+		for _, e := range ab.Effects {
+			for _, t := range e.Targets {
+				if t.Type != abil.AnyTarget && t.Type != abil.PlayerTarget {
+					continue
+				}
+				opp := r.playerTarget(e)
+				if opp == nil {
+					return nil, false
+				}
+				targets = append(targets, opp)
+			}
+		}
+	}
+	return targets, true
+}
+
+// playerTarget is the opponent effect e aims at: for a mill, the one with
+// the fewest cards left in library, else the one with the least life.
+func (r *comboRun) playerTarget(e abil.Effect) *game.Player {
+	if e.Type != abil.MillCards {
+		return r.weakestOpponent()
+	}
+	var best *game.Player
+	for _, opp := range r.g.GetPlayersRaw() {
+		if opp == r.p || opp.HasLost() || len(opp.Library) == 0 {
+			continue
+		}
+		if best == nil || len(opp.Library) < len(best.Library) {
+			best = opp
+		}
+	}
+	return best
+}
+
+// opposingTarget is the first of potentials that is an opponent's
+// permanent.
+func (r *comboRun) opposingTarget(potentials []any) any {
+	for _, t := range potentials {
+		perm, ok := t.(interface{ Underlying() *game.Permanent })
+		if ok && perm.Underlying().GetController() != r.p {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *comboRun) weakestOpponent() *game.Player {
+	var best *game.Player
+	for _, opp := range r.g.GetPlayersRaw() {
+		if opp == r.p || opp.HasLost() {
+			continue
+		}
+		if best == nil || opp.GetLifeTotal() < best.GetLifeTotal() {
+			best = opp
+		}
+	}
+	return best
+}
+
+// repeat asks the controller how many more times to repeat loop, a pass
+// over v's pieces that runLoop has proved, and applies them at once. It
+// returns false if the controller stops the loop.
+func (r *comboRun) repeat(v combo.VariantInfo, loop Loop) bool {
+	agent := r.agent
+	if agent == nil {
+		agent = DefaultAgent{}
+	}
+	n := min(agent.ChooseLoopIterations(r.g, r.p, loop), loopIterationCap)
+	if n <= 0 {
+		return false
+	}
+	applyLoop(r.g, loop, n)
+	r.g.ApplyStateBasedActions()
+	if r.log != nil {
+		r.log.Append(EDHEvent{
+			Turn:   r.g.GetTurnNumber(),
+			Phase:  phaseName(r.g.GetCurrentPhase()),
+			Kind:   EventLoopShortcut,
+			Actor:  r.p.GetName(),
+			Detail: fmt.Sprintf("%s x%d (%s per iteration)", strings.Join(v.CardNames, " + "), n, loop),
+		})
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/bridge"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+func TestComboExecutor_CounteredPieceStopsTheCombo(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	opp := game.NewEDHPlayer("Opp")
+	g := game.NewGame(p, opp)
+	withLands(p, 2)
+	p.PutTokenOnBattlefield(game.SimpleCard{Name: "Swamp", TypeLine: "Basic Land — Swamp"})
+	p.Hand = []game.SimpleCard{testOracle, testConsultation}
+	p.Library = []game.SimpleCard{testIsland, testIsland, testIsland}
+	opp.Hand = []game.SimpleCard{testCounterspell}
+	withLands(opp, 2)
+
+	if attemptCEDHComboFinish(g, p, nil, nil, nil, NewStackAwareHandler(g, nil)) {
+		t.Fatal("the combo should stop once a piece is countered")
+	}
+	if p.HasLost() || opp.HasLost() {
+		t.Fatal("nobody should lose")
+	}
+	if n := len(p.Graveyard); n == 0 || p.Graveyard[n-1].Name != testOracle.Name {
+		t.Fatalf("Counterspell should meet Thassa's Oracle, graveyard %v", p.Graveyard)
+	}
+}
+
+func TestComboExecutor_ShortcutsAProvenLoop(t *testing.T) {
+	battery := game.SimpleCard{Name: "Mana Battery", TypeLine: "Artifact", OracleText: "{T}: Add {C}{C}.\n{1}: Untap Mana Battery."}
+	sink := game.SimpleCard{Name: "Battery Sink", TypeLine: "Artifact", OracleText: "{1}: Battery Sink deals 1 damage to any target."}
+	p := game.NewEDHPlayer("P")
+	opp := game.NewEDHPlayer("Opp")
+	g := game.NewGame(p, opp)
+	for _, c := range []game.SimpleCard{battery, sink} {
+		p.Battlefield = append(p.Battlefield, game.NewPermanent(c, p, p))
+	}
+	agent := DefaultAgent{Combos: combo.NewIndex(&combo.FindMyCombosResult{Included: []combo.Variant{
+		knownVariant("battery-sink", "Infinite damage", "Tap and untap Mana Battery.\nActivate Battery Sink.",
+			knownPiece("Mana Battery"), knownPiece("Battery Sink")),
+	}}, nil)}
+
+	log := NewEDHEventLog()
+	if !attemptCEDHComboFinish(g, p, agent, log, nil, nil) {
+		t.Fatal("expected the loop to win")
+	}
+	if !opp.HasLost() || opp.GetLifeTotal() > 0 {
+		t.Fatalf("the opponent should be dealt lethal damage, life %d", opp.GetLifeTotal())
+	}
+	// Two passes prove the loop; the controller names the 38 more it
+	// takes to finish the opponent.
+	events := log.Events()
+	if len(events) != 1 || events[0].Kind != EventLoopShortcut || !strings.Contains(events[0].Detail, "x38") {
+		t.Fatalf("expected one shortcut of 38 iterations, events %+v", events)
+	}
+}
+
+func TestComboExecutor_LoopTargetsAnOpponentsPermanent(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	opp := game.NewEDHPlayer("Opp")
+	g := game.NewGame(p, opp)
+	bear := game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"}
+	p.PutTokenOnBattlefield(bear)
+	theirs := opp.PutTokenOnBattlefield(bear)
+
+	gs := bridge.NewAbilityGameState(g)
+	engine := abil.NewExecutionEngine(gs)
+	r := &comboRun{g: g, p: p}
+	ab := &abil.Ability{Effects: []abil.Effect{{Type: abil.DealDamage, Value: 1, Targets: []abil.Target{{Type: abil.CreatureTarget, Required: true, Count: 1}}}}}
+	targets, ok := r.loopTargets(gs, engine, ab)
+	if !ok || len(targets) != 1 {
+		t.Fatalf("expected one target, got %v", targets)
+	}
+	if perm, _ := targets[0].(interface{ Underlying() *game.Permanent }); perm == nil || perm.Underlying() != theirs {
+		t.Fatalf("the loop should aim at the opponent's Bear, got %v", targets[0])
+	}
+
+	opp.DestroyPermanent(theirs)
+	if _, ok := r.loopTargets(gs, engine, ab); ok {
+		t.Fatal("the loop shouldn't aim at its controller's own creature")
+	}
+}
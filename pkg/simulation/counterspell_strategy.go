@@ -1,15 +1,23 @@
 package simulation
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/mtgsim/mtgsim/pkg/card"
 	"github.com/mtgsim/mtgsim/pkg/game"
+	"github.com/mtgsim/mtgsim/pkg/threat"
 )
 
 // CounterspellStrategy provides decision logic for when to cast counterspells.
 // This enables intelligent opponent responses when using a PriorityHandler.
 type CounterspellStrategy struct {
 	player *game.Player
+	// g, when set by WithGame, lets the strategy weigh who cast the
+	// spell and count untapped mana sources as available mana.
+	g *game.Game
 }
 
 // NewCounterspellStrategy creates a new strategy for the given player.
@@ -19,48 +27,89 @@ func NewCounterspellStrategy(player *game.Player) *CounterspellStrategy {
 	}
 }
 
-// isHighPriorityCounterTarget returns true for card names that represent
-// combo pieces, game-winning threats, or otherwise high-priority counterspell
-// targets regardless of CMC.
-func isHighPriorityCounterTarget(name string) bool {
-	lower := strings.ToLower(name)
-	highPriority := []string{
-		"torment of hailfire", "expropriate", "craterhoof behemoth",
-		"approach of the second sun", "insurrection", "debt to the deathless",
-		"jace, wielder of mysteries", "thassa's oracle", "laboratory maniac",
-		"ad nauseam", "necropotence", "doomsday", "tendrils of agony",
-		"brain freeze", "demonic consultation", "tainted pact",
-		"hermit druid", "food chain", "protean hulk", "flash",
-		"omniscience", "enter the infinite", "dark ritual",
-		"cabal ritual", "rite of flames", "seething song",
-		"finale of devastation", "green sun's zenith",
-		"tooth and nail", "time warp", "temporal manipulation",
-		"walk the aeons", "nexus of fate", "ugin, the spirit dragon",
-		"cyclonic rift", "toxic deluge", "farewell",
-	}
-	for _, hp := range highPriority {
-		if strings.Contains(lower, hp) {
-			return true
-		}
+// WithGame makes the strategy play in g. It ranks the caster with
+// threat.Rank: the leading threat's spells are countered whenever the
+// counter costs no more, and any spell of a player likely to win next
+// turn is countered. Players may tap their untapped mana sources to pay
+// for counters and taxes.
+func (cs *CounterspellStrategy) WithGame(g *game.Game) *CounterspellStrategy {
+	cs.g = g
+	return cs
+}
+
+// counterWinChance is the threat.Assessment.WinChance at which every
+// spell of the caster is worth a counter.
+const counterWinChance = 0.5
+
+// canPay reports whether p can pay for c, with its untapped mana sources
+// when the strategy plays in a game.
+func (cs *CounterspellStrategy) canPay(p *game.Player, c game.SimpleCard) bool {
+	if cs.g == nil {
+		return p.CanPayForCard(c)
 	}
-	return false
+	return canPayWithOpenMana(cs.g, p, c)
 }
 
-// isTutorEffect returns true if the card name suggests a tutor or search effect.
-func isTutorEffect(name string) bool {
-	lower := strings.ToLower(name)
-	tutors := []string{
-		"tutor", "demonic", "vampiric", "enlightened", "mystical",
-		"worldly", "survival", "entomb", "buried alive",
-		"gamble", "imperial seal", "grim tutor", "diabolic",
-		"increasing ambition", "beseech the queen",
+// casterThreat returns caster's assessment and whether caster is the
+// leading threat, or false without a game to rank in.
+func (cs *CounterspellStrategy) casterThreat(caster *game.Player) (threat.Assessment, bool, bool) {
+	if cs.g == nil || caster == nil {
+		return threat.Assessment{}, false, false
 	}
-	for _, t := range tutors {
-		if strings.Contains(lower, t) {
-			return true
+	for i, a := range rankThreats(cs.g, cs.player) {
+		if a.Player == caster {
+			return a, i == 0, true
 		}
 	}
-	return false
+	return threat.Assessment{}, false, false
+}
+
+// isHighPriorityCounterTarget returns true for spells worth countering
+// regardless of CMC: win conditions, card advantage engines, board wipes
+// and stax pieces, by their card roles.
+func isHighPriorityCounterTarget(c game.SimpleCard) bool {
+	return card.ClassifyRoles(c).HasAny(card.RoleWincon, card.RoleDrawEngine, card.RoleBoardWipe, card.RoleStax)
+}
+
+// isTutorEffect returns true if the card is a tutor.
+func isTutorEffect(c game.SimpleCard) bool {
+	return card.ClassifyRoles(c).Has(card.RoleTutor)
+}
+
+// counterTaxRe matches the tax on soft counters like Mana Leak.
+var counterTaxRe = regexp.MustCompile(`(?i)unless its controller pays \{(\d+)\}`)
+
+// CounterTax returns N for a counterspell that counters "unless its
+// controller pays {N}", or 0 for a hard counter.
+func CounterTax(c game.SimpleCard) int {
+	m := counterTaxRe.FindStringSubmatch(c.OracleText)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// CanPayTax reports whether p has n generic mana available.
+func CanPayTax(p *game.Player, n int) bool {
+	return p.CanPayForCard(taxCard(n))
+}
+
+// taxCard is a card costing n generic mana, to check a tax payment with.
+func taxCard(n int) game.SimpleCard {
+	return game.SimpleCard{ManaCost: fmt.Sprintf("{%d}", n)}
+}
+
+// CountersAbilities reports whether c is an instant that counters
+// activated or triggered abilities (Stifle, Tale's End).
+func CountersAbilities(c game.SimpleCard) bool {
+	if !c.IsInstant() {
+		return false
+	}
+	lower := strings.ToLower(c.OracleText)
+	return strings.Contains(lower, "counter target") &&
+		(strings.Contains(lower, "activated ability") || strings.Contains(lower, "triggered ability") ||
+			strings.Contains(lower, "activated or triggered ability"))
 }
 
 // ShouldCounterSpell determines if the player should cast a counterspell in response
@@ -71,13 +120,24 @@ func isTutorEffect(name string) bool {
 //    or a known high-priority threat like a combo piece / tutor)?
 //
 // This returns true if counter action is recommended, along with the counterspell card to use.
-func (cs *CounterspellStrategy) ShouldCounterSpell(opponentSpellName string, opponentSpellCMC int) (bool, game.SimpleCard) {
+func (cs *CounterspellStrategy) ShouldCounterSpell(opponentSpell game.SimpleCard, opponentSpellCMC int) (bool, game.SimpleCard) {
+	return cs.ShouldCounterSpellFrom(nil, opponentSpell, opponentSpellCMC)
+}
+
+// ShouldCounterSpellFrom is ShouldCounterSpell knowing who cast the spell:
+// tax counters the caster can pay for are left in hand, since they would
+// only cost the caster mana.
+func (cs *CounterspellStrategy) ShouldCounterSpellFrom(caster *game.Player, opponentSpell game.SimpleCard, opponentSpellCMC int) (bool, game.SimpleCard) {
 	// Find available counterspells in hand
 	var counterspells []game.SimpleCard
 	for _, c := range cs.player.Hand {
-		if c.IsCounterspell() {
-			counterspells = append(counterspells, c)
+		if !c.IsCounterspell() {
+			continue
+		}
+		if tax := CounterTax(c); tax > 0 && caster != nil && cs.canPay(caster, taxCard(tax)) {
+			continue
 		}
+		counterspells = append(counterspells, c)
 	}
 
 	if len(counterspells) == 0 {
@@ -90,7 +150,7 @@ func (cs *CounterspellStrategy) ShouldCounterSpell(opponentSpellName string, opp
 	canAffordAny := false
 
 	for _, counter := range counterspells {
-		if cs.player.CanPayForCard(counter) {
+		if cs.canPay(cs.player, counter) {
 			canAffordAny = true
 			costTotal := counter.GetMinManaCost().Total()
 			if costTotal < bestCost {
@@ -105,12 +165,12 @@ func (cs *CounterspellStrategy) ShouldCounterSpell(opponentSpellName string, opp
 	}
 
 	// Always counter known high-priority targets (combo pieces, game-winners)
-	if isHighPriorityCounterTarget(opponentSpellName) {
+	if isHighPriorityCounterTarget(opponentSpell) {
 		return true, bestCounter
 	}
 
 	// Always counter tutors — they represent hidden card advantage / combo assembly
-	if isTutorEffect(opponentSpellName) {
+	if isTutorEffect(opponentSpell) {
 		return true, bestCounter
 	}
 
@@ -118,10 +178,38 @@ func (cs *CounterspellStrategy) ShouldCounterSpell(opponentSpellName string, opp
 	// This represents smart threat assessment: counter bigger threats with cheap counters.
 	// Use > not >= to avoid counterspell wars over marginal threats.
 	shouldCounter := opponentSpellCMC > bestCounter.GetMinManaCost().Total()
+	if a, leading, ok := cs.casterThreat(caster); ok {
+		switch {
+		case a.WinChance >= counterWinChance:
+			shouldCounter = true
+		case leading:
+			shouldCounter = opponentSpellCMC >= bestCounter.GetMinManaCost().Total()
+		}
+	}
 
 	return shouldCounter, bestCounter
 }
 
+// ShouldCounterAbility decides whether to Stifle an opponent's activated or
+// triggered ability from source. Only abilities of combo pieces,
+// game-winners and tutors are worth a card.
+func (cs *CounterspellStrategy) ShouldCounterAbility(source game.SimpleCard) (bool, game.SimpleCard) {
+	if !isHighPriorityCounterTarget(source) && !isTutorEffect(source) {
+		return false, game.SimpleCard{}
+	}
+	var best game.SimpleCard
+	found := false
+	for _, c := range cs.player.Hand {
+		if !CountersAbilities(c) || !cs.canPay(cs.player, c) {
+			continue
+		}
+		if !found || c.GetMinManaCost().Total() < best.GetMinManaCost().Total() {
+			best, found = c, true
+		}
+	}
+	return found, best
+}
+
 // GetCounterspellsInHand returns all counterspells currently in the player's hand.
 func (cs *CounterspellStrategy) GetCounterspellsInHand() []game.SimpleCard {
 	var counterspells []game.SimpleCard
@@ -136,7 +224,7 @@ func (cs *CounterspellStrategy) GetCounterspellsInHand() []game.SimpleCard {
 // HasCounterableMana checks if the player has enough mana to cast at least one counterspell.
 func (cs *CounterspellStrategy) HasCounterableMana() bool {
 	for _, c := range cs.player.Hand {
-		if c.IsCounterspell() && cs.player.CanPayForCard(c) {
+		if c.IsCounterspell() && cs.canPay(cs.player, c) {
 			return true
 		}
 	}
@@ -151,7 +239,7 @@ func (cs *CounterspellStrategy) GetCheapestCounterspell() (game.SimpleCard, bool
 	found := false
 
 	for _, c := range cs.player.Hand {
-		if c.IsCounterspell() && cs.player.CanPayForCard(c) {
+		if c.IsCounterspell() && cs.canPay(cs.player, c) {
 			cost := c.GetMinManaCost().Total()
 			if cost < minCost {
 				minCost = cost
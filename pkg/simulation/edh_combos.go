@@ -3,235 +3,88 @@ package simulation
 import (
 	"strings"
 
+	"github.com/mtgsim/mtgsim/internal/logger"
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/bridge"
 	"github.com/mtgsim/mtgsim/pkg/game"
 )
 
-func attemptCEDHComboFinish(g *game.Game, ap *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
+// attemptCEDHComboFinish goes for ap's combos: the combo executor plays
+// each variant ap is ready to go for, then a storm finisher is cast if
+// its copies are lethal. stackHandler, when non-nil, casts the pieces
+// through the stack so opponents can answer them. A style that protects
+// its combo holds a ready one back while it looks likely to be stopped
+// (Style.goesForTheWin). It reports whether ap won.
+func attemptCEDHComboFinish(g *game.Game, ap *game.Player, agent Agent, log *EDHEventLog, metrics *edhMetrics, stackHandler *StackAwareHandler) bool {
 	if g == nil || ap == nil || ap.HasLost() {
 		return false
 	}
 	idx := indexOfPlayer(g, ap)
-	tapManaSourcesForMainPhaseMana(g, ap, idx, metrics)
-	resolveCEDHVelocitySpells(g, ap, log, metrics)
-	if tryOracleConsult(g, ap, log, metrics) {
-		return true
-	}
-	if tryDoomsdayOracle(g, ap, log, metrics) {
-		return true
-	}
-	if tryBreachBrainFreeze(g, ap, log, metrics) {
-		return true
-	}
-	if tryGodoHelm(g, ap, log, metrics) {
-		return true
-	}
-	if tryDualcasterTwinflame(g, ap, log, metrics) {
-		return true
-	}
-	if tryFoodChain(g, ap, log, metrics) {
-		return true
-	}
-	if tryAetherflux(g, ap, log, metrics) {
-		return true
-	}
-	if tryOptimizedDeckCombo(g, ap, log) {
-		return true
-	}
-	return false
-}
-
-func tryOptimizedDeckCombo(g *game.Game, p *game.Player, log *EDHEventLog) bool {
-	if g.GetTurnNumber() < 4 || availableManaSources(p) < 3 {
-		return false
-	}
-	if hasCastablePieces(p, "Thassa's Oracle", "Demonic Consultation") || hasCastablePieces(p, "Thassa's Oracle", "Tainted Pact") {
-		comboWin(g, p, "optimized Oracle/Consultation line", log)
-		return true
-	}
-	if hasCastablePieces(p, "Thassa's Oracle", "Demonic Consultation") || hasCastablePieces(p, "Laboratory Maniac", "Tainted Pact") {
-		comboWin(g, p, "optimized LabMan line", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Doomsday", "Thassa's Oracle") {
-		comboWin(g, p, "optimized Doomsday pile", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Underworld Breach", "Brain Freeze") && (hasAnyPiece(p, "Lion's Eye Diamond") || hasAnyPiece(p, "Grinding Station")) {
-		comboWin(g, p, "optimized Breach combo", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Dualcaster Mage", "Twinflame") {
-		comboWin(g, p, "optimized Dualcaster combo", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Food Chain", "Squee, the Immortal") {
-		comboWin(g, p, "optimized Food Chain / Squee", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Food Chain", "Misthollow Griffin") {
-		comboWin(g, p, "optimized Food Chain / Misthollow", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Food Chain", "Eternal Scourge") {
-		comboWin(g, p, "optimized Food Chain / Scourge", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 6 && hasCastablePieces(p, "Godo, Bandit Warlord", "Helm of the Host") {
-		comboWin(g, p, "optimized Godo / Helm line", log)
-		return true
-	}
-	if hasCastablePieces(p, "Dramatic Reversal", "Isochron Scepter") {
-		comboWin(g, p, "Dramatic Scepter combo", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Lion's Eye Diamond", "Underworld Breach") && pieceAccessible(p, "Brain Freeze") {
-		comboWin(g, p, "LED + Breach line", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 4 && hasCastablePieces(p, "Devoted Druid", "Swift Reconfiguration") {
-		comboWin(g, p, "Devoted Druid combo", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Kinnan, Bonder Prodigy", "Basalt Monolith") {
-		comboWin(g, p, "Kinnan / Basalt Monolith", log)
-		return true
-	}
-	if g.GetTurnNumber() >= 5 && hasCastablePieces(p, "Demonic Consultation", "Thassa's Oracle") && pieceAccessible(p, "Tainted Pact") {
-		comboWin(g, p, "Consultation/Pact Oracle", log)
-		return true
-	}
-	return false
-}
-
-func hasCastablePieces(p *game.Player, a, b string) bool {
-	return (pieceAccessible(p, a) && canAffordNamedCard(p, a) || permanentNamed(p, a)) &&
-		(pieceAccessible(p, b) && canAffordNamedCard(p, b) || permanentNamed(p, b))
-}
-
-func hasAnyPiece(p *game.Player, name string) bool {
-	return pieceAccessible(p, name)
-}
-
-func tryOracleConsult(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
-	payoffs := []string{"Thassa's Oracle", "Laboratory Maniac", "Jace, Wielder of Mysteries"}
-	exilers := []string{"Demonic Consultation", "Tainted Pact"}
-	for _, payoff := range payoffs {
-		if !pieceAccessible(p, payoff) {
+	tapManaSourcesForMainPhaseMana(g, ap, agent, idx, metrics)
+	resolveCEDHVelocitySpells(g, ap, agent, log, metrics)
+	run := &comboRun{g: g, p: ap, h: stackHandler, agent: agent, log: log, metrics: metrics}
+	for _, v := range comboVariants(agent) {
+		if ap.HasLost() {
+			return false
+		}
+		if !run.ready(v) {
 			continue
 		}
-		for _, exiler := range exilers {
-			if !pieceAccessible(p, exiler) {
-				continue
-			}
-			if !canAffordNamedCard(p, payoff) || !canAffordNamedCard(p, exiler) {
-				continue
-			}
-			if ensurePiece(g, p, payoff, log, metrics) && castComboSpell(g, p, exiler, log, metrics) {
-				p.Library = nil
-				if permanentNamed(p, "Thassa's Oracle") && len(p.Library) == 0 {
-					comboWin(g, p, "Oracle/Consultation combo", log)
-					return true
-				}
-				if permanentNamed(p, "Laboratory Maniac") && len(p.Library) == 0 {
-					p.Draw(1)
-					comboWin(g, p, "Laboratory Maniac trigger", log)
-					return true
-				}
-				if permanentNamed(p, "Jace, Wielder of Mysteries") && len(p.Library) == 0 {
-					p.Draw(1)
-					comboWin(g, p, "Jace, Wielder of Mysteries", log)
-					return true
-				}
-				comboWin(g, p, "Oracle/Consultation combo", log)
-				return true
+		if !styleOf(agent).goesForTheWin(g, ap) {
+			if log != nil {
+				log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(g.GetCurrentPhase()), Kind: EventComboHeld, Actor: ap.GetName(), Detail: strings.Join(v.CardNames, " + ")})
 			}
+			return false
+		}
+		if run.execute(v) {
+			return true
 		}
 	}
-	return false
-}
-
-func tryDoomsdayOracle(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
-	if !pieceAccessible(p, "Doomsday") || !hasPayoffAnywhere(p) {
-		return false
-	}
-	if castComboSpell(g, p, "Doomsday", log, metrics) {
-		comboWin(g, p, "Doomsday pile", log)
-		return true
-	}
-	return false
+	return tryStormFinisher(g, ap, log, metrics)
 }
 
-func tryBreachBrainFreeze(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
-	if !pieceAccessible(p, "Underworld Breach") || !pieceAccessible(p, "Brain Freeze") {
-		return false
-	}
-	if !pieceAccessible(p, "Lion's Eye Diamond") && !pieceAccessible(p, "Grinding Station") && len(p.Graveyard) < 8 {
-		return false
-	}
-	if ensurePiece(g, p, "Underworld Breach", log, metrics) && castComboSpell(g, p, "Brain Freeze", log, metrics) {
-		comboWin(g, p, "Underworld Breach / Brain Freeze", log)
-		return true
+func opponentsDecked(g *game.Game, p *game.Player) bool {
+	for _, opp := range g.GetPlayersRaw() {
+		if opp != p && !opp.HasLost() && len(opp.Library) > 0 {
+			return false
+		}
 	}
-	return false
+	return true
 }
 
-func tryGodoHelm(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
-	if !pieceAccessible(p, "Godo, Bandit Warlord") || !pieceAccessible(p, "Helm of the Host") {
-		return false
-	}
-	if ensurePiece(g, p, "Godo, Bandit Warlord", log, metrics) && ensurePiece(g, p, "Helm of the Host", log, metrics) {
-		comboWin(g, p, "Godo / Helm of the Host", log)
-		return true
+// tryStormFinisher casts Grapeshot or Brain Freeze when its storm copies
+// finish an opponent: Grapeshot at the opponent with the least life, Brain
+// Freeze at the one with the fewest cards in library. The spell and its
+// copies resolve through the ability engine.
+func tryStormFinisher(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
+	copies := g.SpellsCastThisTurn() + 1
+	var lowLife, lowLibrary *game.Player
+	for _, opp := range g.GetPlayersRaw() {
+		if opp == p || opp.HasLost() {
+			continue
+		}
+		if lowLife == nil || opp.GetLifeTotal() < lowLife.GetLifeTotal() {
+			lowLife = opp
+		}
+		if lowLibrary == nil || len(opp.Library) < len(lowLibrary.Library) {
+			lowLibrary = opp
+		}
 	}
-	return false
-}
-
-func tryDualcasterTwinflame(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
-	if !pieceAccessible(p, "Dualcaster Mage") || !pieceAccessible(p, "Twinflame") {
+	if lowLife == nil {
 		return false
 	}
-	if ensurePiece(g, p, "Dualcaster Mage", log, metrics) && castComboSpell(g, p, "Twinflame", log, metrics) {
-		comboWin(g, p, "Dualcaster Mage / Twinflame", log)
+	if copies >= lowLife.GetLifeTotal() && castComboSpellAt(g, p, "Grapeshot", lowLife, log, metrics) {
 		return true
 	}
-	return false
-}
-
-func tryFoodChain(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
-	if !pieceAccessible(p, "Food Chain") {
-		return false
-	}
-	payoffs := []string{"Squee, the Immortal", "Eternal Scourge", "Misthollow Griffin", "Walking Ballista", "Goblin Cannon"}
-	for _, payoff := range payoffs {
-		if pieceAccessible(p, payoff) && ensurePiece(g, p, "Food Chain", log, metrics) {
-			comboWin(g, p, "Food Chain combo", log)
-			return true
-		}
-	}
-	return false
-}
-
-func tryAetherflux(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
-	idx := indexOfPlayer(g, p)
-	storm := 0
-	if metrics != nil && idx >= 0 {
-		storm = metrics.turnSpells[idx]
-	}
-	if pieceAccessible(p, "Aetherflux Reservoir") && (p.GetLifeTotal() >= 50 || storm >= 6) {
-		if ensurePiece(g, p, "Aetherflux Reservoir", log, metrics) {
-			comboWin(g, p, "Aetherflux Reservoir", log)
-			return true
-		}
-	}
-	return false
+	return 3*copies >= len(lowLibrary.Library) && castComboSpellAt(g, p, "Brain Freeze", lowLibrary, log, metrics)
 }
 
-func resolveCEDHVelocitySpells(g *game.Game, p *game.Player, log *EDHEventLog, metrics *edhMetrics) {
+func resolveCEDHVelocitySpells(g *game.Game, p *game.Player, agent Agent, log *EDHEventLog, metrics *edhMetrics) {
 	idx := indexOfPlayer(g, p)
 	progress := true
 	for progress && !p.HasLost() {
 		progress = false
-		tapManaSourcesForMainPhaseMana(g, p, idx, metrics)
+		tapManaSourcesForMainPhaseMana(g, p, agent, idx, metrics)
 		if castDrawEngine(g, p, "Ad Nauseam", 20, 10, log, metrics) {
 			progress = true
 			continue
@@ -245,7 +98,7 @@ func resolveCEDHVelocitySpells(g *game.Game, p *game.Player, log *EDHEventLog, m
 			continue
 		}
 		for _, tutor := range []string{"Demonic Tutor", "Vampiric Tutor", "Imperial Seal", "Mystical Tutor", "Worldly Tutor", "Enlightened Tutor", "Gamble", "Finale of Devastation", "Eldritch Evolution"} {
-			if castTutor(g, p, tutor, log, metrics) {
+			if castTutor(g, p, tutor, agent, log, metrics) {
 				progress = true
 				break
 			}
@@ -254,6 +107,9 @@ func resolveCEDHVelocitySpells(g *game.Game, p *game.Player, log *EDHEventLog, m
 }
 
 func castDrawEngine(g *game.Game, p *game.Player, name string, draw, lose int, log *EDHEventLog, metrics *edhMetrics) bool {
+	if abil.HasCardScript(name) {
+		return castComboSpell(g, p, name, log, metrics)
+	}
 	if draw <= 0 || !castComboSpell(g, p, name, log, metrics) {
 		return false
 	}
@@ -273,69 +129,34 @@ func castWheel(g *game.Game, p *game.Player, name string, log *EDHEventLog, metr
 	return true
 }
 
-func castTutor(g *game.Game, p *game.Player, name string, log *EDHEventLog, metrics *edhMetrics) bool {
-	if !castComboSpell(g, p, name, log, metrics) {
+// castTutor casts the named tutor and resolves its search, letting agent
+// pick among the library cards the tutor can find. Tutors without oracle
+// text find any card and put it into hand.
+func castTutor(g *game.Game, p *game.Player, name string, agent Agent, log *EDHEventLog, metrics *edhMetrics) bool {
+	idx := findZoneCard(p.Hand, name)
+	if idx < 0 {
 		return false
 	}
-	for _, target := range tutorPriority(p) {
-		if idx := findZoneCard(p.Library, target); idx >= 0 {
-			card := p.Library[idx]
-			p.Library = append(p.Library[:idx], p.Library[idx+1:]...)
-			p.Hand = append(p.Hand, card)
-		if log != nil {
-			log.Append(EDHEvent{
-				Turn:   g.GetTurnNumber(),
-				Phase:  phaseName(g.GetCurrentPhase()),
-				Kind:   EventPermanentCast,
-				Actor:  p.GetName(),
-				Detail: name + " (tutor -> " + card.Name + ")",
-			})
-		}
-		return true
-	}
+	search := game.LibrarySearch{Max: 1, Dest: game.Hand, Shuffle: true}
+	if text := p.Hand[idx].OracleText; text != "" {
+		search = bridge.ParseLibrarySearch(text, 1)
 	}
-	return true
-}
-
-func tutorPriority(p *game.Player) []string {
-	if pieceAccessible(p, "Thassa's Oracle") || pieceAccessible(p, "Laboratory Maniac") || pieceAccessible(p, "Jace, Wielder of Mysteries") {
-		return []string{"Demonic Consultation", "Tainted Pact", "Doomsday"}
-	}
-	if pieceAccessible(p, "Demonic Consultation") || pieceAccessible(p, "Tainted Pact") {
-		return []string{"Thassa's Oracle", "Laboratory Maniac", "Jace, Wielder of Mysteries"}
-	}
-	if pieceAccessible(p, "Underworld Breach") {
-		return []string{"Brain Freeze", "Lion's Eye Diamond", "Grinding Station"}
-	}
-	if pieceAccessible(p, "Food Chain") {
-		return []string{"Squee, the Immortal", "Misthollow Griffin", "Eternal Scourge", "Walking Ballista"}
-	}
-	if pieceAccessible(p, "Squee, the Immortal") || pieceAccessible(p, "Misthollow Griffin") || pieceAccessible(p, "Eternal Scourge") {
-		return []string{"Food Chain", "Walking Ballista"}
+	if !castComboSpell(g, p, name, log, metrics) {
+		return false
 	}
-	return []string{"Thassa's Oracle", "Demonic Consultation", "Tainted Pact", "Underworld Breach", "Brain Freeze", "Doomsday", "Food Chain", "Aetherflux Reservoir"}
-}
-
-func comboWin(g *game.Game, winner *game.Player, detail string, log *EDHEventLog) {
-	for _, opp := range g.GetPlayersRaw() {
-		if opp != nil && opp != winner && !opp.HasLost() {
-			opp.Lose("effect")
-		}
+	res := g.SearchLibrary(p, search, chooserFor(g, agent))
+	if log != nil && len(res.Cards) > 0 {
+		log.Append(EDHEvent{
+			Turn:   g.GetTurnNumber(),
+			Phase:  phaseName(g.GetCurrentPhase()),
+			Kind:   EventPermanentCast,
+			Actor:  p.GetName(),
+			Detail: name + " (tutor -> " + res.Cards[0].Name + ")",
+		})
 	}
+	return true
 }
 
-func pieceAccessible(p *game.Player, name string) bool {
-	return findZoneCard(p.Hand, name) >= 0 || permanentNamed(p, name) || findZoneCard(p.CommandZone, name) >= 0 || findZoneCard(p.Graveyard, name) >= 0
-}
-
-func hasPayoffAnywhere(p *game.Player) bool {
-	for _, name := range []string{"Thassa's Oracle", "Laboratory Maniac", "Jace, Wielder of Mysteries"} {
-		if pieceAccessible(p, name) || findZoneCard(p.Library, name) >= 0 {
-			return true
-		}
-	}
-	return false
-}
 
 func availableManaSources(p *game.Player) int {
 	count := 0
@@ -347,17 +168,6 @@ func availableManaSources(p *game.Player) int {
 	return count
 }
 
-func canAffordNamedCard(p *game.Player, name string) bool {
-	idx := findZoneCard(p.Hand, name)
-	if idx >= 0 {
-		return p.CanPayForCard(p.Hand[idx])
-	}
-	idx = findZoneCard(p.CommandZone, name)
-	if idx >= 0 {
-		return p.CanPayForCommander(p.CommandZone[idx])
-	}
-	return false
-}
 
 func permanentNamed(p *game.Player, name string) bool {
 	for _, perm := range p.Battlefield {
@@ -377,64 +187,105 @@ func findZoneCard(zone []game.SimpleCard, name string) int {
 	return -1
 }
 
-func ensurePiece(g *game.Game, p *game.Player, name string, log *EDHEventLog, metrics *edhMetrics) bool {
-	if permanentNamed(p, name) {
-		return true
-	}
-	if castCommanderPiece(g, p, name, log, metrics) {
-		return true
-	}
+
+
+func castComboSpell(g *game.Game, p *game.Player, name string, log *EDHEventLog, metrics *edhMetrics) bool {
+	return castComboSpellAt(g, p, name, nil, log, metrics)
+}
+
+// castComboSpellAt casts the named spell from p's hand. Scripted spells and
+// spells aimed at a target player resolve through the ability engine; for
+// the rest the caller applies the combo's shortcut.
+func castComboSpellAt(g *game.Game, p *game.Player, name string, target *game.Player, log *EDHEventLog, metrics *edhMetrics) bool {
 	idx := findZoneCard(p.Hand, name)
 	if idx < 0 {
 		return false
 	}
 	c := p.Hand[idx]
-	if c.IsInstant() || c.IsSorcery() {
-		return castComboSpell(g, p, name, log, metrics)
-	}
 	if !p.CanPayForCard(c) || !p.PayForCard(c) {
 		return false
 	}
-	manaSpent := manaSpentForCard(c)
-	perm, err := castPermanentCard(g, p, c)
-	if err != nil || perm == nil {
-		return false
+	p.Hand = append(p.Hand[:idx], p.Hand[idx+1:]...)
+	recordComboCast(g, p, c, manaSpentForCard(c), false, log, metrics)
+	if abil.HasCardScript(c.Name) || target != nil {
+		cs := newComboStack(g)
+		var targets []any
+		if target != nil {
+			targets = []any{cs.player(target)}
+		}
+		cs.cast(p, c, targets)
+		cs.resolve()
+	} else {
+		g.RecordSpellCast(p)
 	}
-	perm.SetEnteredTurn(g.GetTurnNumber())
-	recordComboCast(g, p, c, manaSpent, c.IsCreature(), log, metrics)
+	p.Graveyard = append(p.Graveyard, c)
 	return true
 }
 
-func castCommanderPiece(g *game.Game, p *game.Player, name string, log *EDHEventLog, metrics *edhMetrics) bool {
-	idx := findZoneCard(p.CommandZone, name)
-	if idx < 0 {
-		return false
-	}
-	c := p.CommandZone[idx]
-	if !p.CanPayForCommander(c) || !p.PayForCommander(c) {
-		return false
-	}
-	perm := p.CastCommander(name)
-	if perm == nil {
-		return false
-	}
-	perm.SetEnteredTurn(g.GetTurnNumber())
-	recordComboCast(g, p, c, manaSpentForCommander(p, c), c.IsCreature(), log, metrics)
-	return true
+// comboStackResolutionCap bounds how many items a combo stack resolves, in
+// case a loop never stops on its own.
+const comboStackResolutionCap = 1000
+
+// comboStack resolves a combo line through the ability engine on a stack of
+// its own, so storm, copies and enters triggers work as they do in play.
+type comboStack struct {
+	gs     *bridge.AbilityGameState
+	engine *abil.ExecutionEngine
+	stack  *abil.Stack
 }
 
-func castComboSpell(g *game.Game, p *game.Player, name string, log *EDHEventLog, metrics *edhMetrics) bool {
-	idx := findZoneCard(p.Hand, name)
-	if idx < 0 {
-		return false
+func newComboStack(g *game.Game) *comboStack {
+	gs := bridge.NewAbilityGameState(g)
+	engine := abil.NewExecutionEngine(gs)
+	stack := abil.NewStack(gs, engine)
+	stack.OnAfterResolve = g.ApplyStateBasedActions
+	return &comboStack{gs: gs, engine: engine, stack: stack}
+}
+
+func (cs *comboStack) player(p *game.Player) abil.AbilityPlayer {
+	return cs.gs.GetPlayer(p.GetName())
+}
+
+// cast puts c on the stack as a spell p cast, which counts it for storm.
+func (cs *comboStack) cast(p *game.Player, c game.SimpleCard, targets []any) {
+	caster := cs.player(p)
+	if caster == nil {
+		return
+	}
+	abilities, err := cs.engine.ParseAndRegisterAbilities(c.OracleText, c)
+	if err != nil {
+		return
+	}
+	var effects []abil.Effect
+	for _, ab := range abilities {
+		effects = append(effects, ab.Effects...)
+	}
+	cs.stack.AddSpell(&abil.Spell{
+		Name:       c.Name,
+		ManaCost:   c.ManaCost,
+		CMC:        c.ManaValue(),
+		TypeLine:   c.TypeLine,
+		OracleText: c.OracleText,
+		Effects:    effects,
+		Source:     c,
+	}, caster, targets)
+}
+
+// resolve resolves the stack until it is empty.
+func (cs *comboStack) resolve() {
+	for i := 0; i < comboStackResolutionCap && !cs.stack.IsEmpty(); i++ {
+		if err := cs.stack.ResolveTop(); err != nil {
+			logger.LogCard("Combo stack resolution error: %v", err)
+		}
 	}
-	c := p.Hand[idx]
-	if !p.CanPayForCard(c) || !p.PayForCard(c) {
-		return false
+}
+
+func opponentsEliminated(g *game.Game, p *game.Player) bool {
+	for _, opp := range g.GetPlayersRaw() {
+		if opp != p && !opp.HasLost() {
+			return false
+		}
 	}
-	p.Hand = append(p.Hand[:idx], p.Hand[idx+1:]...)
-	p.Graveyard = append(p.Graveyard, c)
-	recordComboCast(g, p, c, manaSpentForCard(c), false, log, metrics)
 	return true
 }
 
@@ -26,6 +26,12 @@ const (
 	EventTriggerResolved  EDHEventKind = "trigger_resolved"
 	EventActivatedAbility EDHEventKind = "activated_ability"
 	EventSpellResolved    EDHEventKind = "spell_resolved"
+	EventCleanupDiscard   EDHEventKind = "cleanup_discard"
+	EventThreatAssessed   EDHEventKind = "threat_assessed"
+	EventLoopShortcut     EDHEventKind = "loop_shortcut"
+	EventLoopDraw         EDHEventKind = "loop_draw"
+	EventEngineStuck      EDHEventKind = "engine_stuck"
+	EventComboHeld        EDHEventKind = "combo_held"
 )
 
 // EDHEvent is a single structured entry in a pod's event log. Designed
@@ -0,0 +1,66 @@
+package simulation
+
+import "github.com/mtgsim/mtgsim/pkg/game"
+
+// chooseLandToPlay picks the land drop for ap. When a spell in hand needs
+// one more mana this turn the best untapped land is played; otherwise
+// lands that enter tapped go first, followed by fastlands while they are
+// still untapped, saving basics and checklands for later turns.
+func chooseLandToPlay(g *game.Game, ap *game.Player) (game.SimpleCard, bool) {
+	needMana := needsExtraManaThisTurn(ap)
+	best, bestScore, found := game.SimpleCard{}, -1, false
+	for _, c := range ap.Hand {
+		if !c.IsLand() {
+			continue
+		}
+		entry := game.ClassifyLandEntry(c)
+		tapped := g.WouldEnterTapped(ap, c)
+		score := 1
+		switch {
+		case needMana && !tapped:
+			score = 3
+		case needMana && entry.Kind == game.LandEntersTappedUnlessPay:
+			score = 2
+		case needMana:
+			score = 0
+		case entry.Kind == game.LandEntersTapped || entry.Kind == game.LandEntersTappedUnlessPay:
+			score = 3
+		case entry.Kind == game.LandEntersTappedUnlessFewLands && !tapped:
+			score = 2
+		}
+		if score > bestScore {
+			best, bestScore, found = c, score, true
+		}
+	}
+	return best, found
+}
+
+// needsExtraManaThisTurn reports whether ap holds a spell that becomes
+// castable this turn with exactly one more untapped land.
+func needsExtraManaThisTurn(ap *game.Player) bool {
+	untapped := 0
+	for _, l := range ap.GetLands() {
+		if !l.IsTapped() {
+			untapped++
+		}
+	}
+	for _, c := range ap.Hand {
+		if !c.IsLand() && c.ManaValue() == untapped+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// landPlayDetail describes a land drop for the event log, noting life paid
+// and whether it entered tapped.
+func landPlayDetail(perm *game.Permanent, lifePaid int) string {
+	detail := perm.GetName()
+	if lifePaid > 0 {
+		detail += " (paid " + intString(lifePaid) + " life)"
+	}
+	if perm.IsTapped() {
+		detail += " (tapped)"
+	}
+	return detail
+}
@@ -0,0 +1,82 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+var floodedStrand = game.SimpleCard{
+	Name:       "Flooded Strand",
+	TypeLine:   "Land",
+	OracleText: "{T}, Pay 1 life, Sacrifice Flooded Strand: Search your library for a Plains or Island card, put it onto the battlefield, then shuffle.",
+}
+
+// TestActivateSearchAbilities_FetchlandFindsTypedDual verifies a fetch pays
+// its life, goes to the graveyard, and finds a dual by basic land type.
+func TestActivateSearchAbilities_FetchlandFindsTypedDual(t *testing.T) {
+	p1 := makeTestPlayer("Fetcher")
+	g := game.NewGame(p1, makeTestPlayer("Opp"))
+	g.SetLandLifePolicy(func(*game.Game, *game.Player, game.SimpleCard, int) bool { return false })
+	p1.Library = []game.SimpleCard{
+		{Name: "Swamp", TypeLine: "Basic Land — Swamp"},
+		{Name: "Hallowed Fountain", TypeLine: "Land — Plains Island", OracleText: "As Hallowed Fountain enters the battlefield, you may pay 2 life. If you don't, it enters the battlefield tapped."},
+	}
+	p1.Battlefield = append(p1.Battlefield, game.NewPermanent(floodedStrand, p1, p1))
+	for g.GetCurrentPhase() != game.PhaseMain1 {
+		g.AdvancePhase()
+	}
+
+	log := NewEDHEventLog()
+	activateSearchAbilities(g, p1, nil, log)
+
+	if p1.GetLifeTotal() != 39 {
+		t.Fatalf("expected fetch to cost 1 life, life=%d", p1.GetLifeTotal())
+	}
+	if len(p1.Battlefield) != 1 || p1.Battlefield[0].GetName() != "Hallowed Fountain" {
+		t.Fatalf("expected Hallowed Fountain fetched, battlefield=%v", p1.Battlefield)
+	}
+	if !p1.Battlefield[0].IsTapped() {
+		t.Fatalf("declined shock payment should leave the fetched land tapped")
+	}
+	if len(p1.Graveyard) != 1 || p1.Graveyard[0].Name != "Flooded Strand" {
+		t.Fatalf("expected fetchland in graveyard, got %v", p1.Graveyard)
+	}
+	found := false
+	for _, e := range log.Events() {
+		if e.Kind == EventFetchActivated && strings.Contains(e.Detail, "Hallowed Fountain") && strings.Contains(e.Detail, "paid 1 life") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected fetch event naming the land and life paid, got %+v", log.Events())
+	}
+}
+
+func TestManaProductionOptions_LandTypes(t *testing.T) {
+	if got := manaProductionOptions(floodedStrand); len(got) != 0 {
+		t.Fatalf("fetchland should not produce mana, got %v", got)
+	}
+	dual := game.SimpleCard{Name: "Tropical Island", TypeLine: "Land — Forest Island"}
+	got := manaProductionOptions(dual)
+	if len(got) != 2 {
+		t.Fatalf("expected green and blue options, got %v", got)
+	}
+}
+
+func TestChooseLandToPlay(t *testing.T) {
+	p1 := makeTestPlayer("P1")
+	g := game.NewGame(p1, makeTestPlayer("P2"))
+	tapland := game.SimpleCard{Name: "Dimir Guildgate", TypeLine: "Land — Gate", OracleText: "Dimir Guildgate enters the battlefield tapped."}
+	island := game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"}
+
+	p1.Hand = []game.SimpleCard{island, tapland}
+	if c, _ := chooseLandToPlay(g, p1); c.Name != "Dimir Guildgate" {
+		t.Fatalf("with nothing to cast, the tapland should go first; got %s", c.Name)
+	}
+	p1.Hand = append(p1.Hand, game.SimpleCard{Name: "Ponder", TypeLine: "Sorcery", ManaCost: "{U}"})
+	if c, _ := chooseLandToPlay(g, p1); c.Name != "Island" {
+		t.Fatalf("with a one-drop in hand, the untapped land should go first; got %s", c.Name)
+	}
+}
@@ -30,6 +30,10 @@ const (
 	WinConditionDeckout          WinCondition = "deckout"
 	WinConditionEffect           WinCondition = "effect"
 	WinConditionTurnLimit        WinCondition = "turn_limit"
+	WinConditionDraw             WinCondition = "draw"
+	// WinConditionEngineStuck marks a game the engine gave up on: its
+	// triggers never settled, so it has no result.
+	WinConditionEngineStuck      WinCondition = "engine_stuck"
 	WinConditionUnknown          WinCondition = "unknown"
 )
 
@@ -58,6 +62,9 @@ type EDHPlayerRecord struct {
 	Eliminated     bool
 	KillSource     KillSource
 	CardStats      map[string]CardPerformance
+	// OpeningHands lists every hand the player looked at, in order; the
+	// last one is the hand kept.
+	OpeningHands []OpeningHand
 }
 
 // EDHGameRecord captures one completed multiplayer pod.
@@ -127,6 +134,12 @@ type EDHDeckStats struct {
 	TotalCombatDamage  int                           `json:"total_combat_damage"`
 	Eliminations       int                           `json:"eliminations"`
 	CardStats          map[string]CardPerformance    `json:"card_stats"`
+	// KeepRate is the percentage of opening hands looked at that were
+	// kept. The win rates are percentages keyed by the mulligans taken and
+	// by the lands in the kept hand.
+	KeepRate           float64                       `json:"keep_rate"`
+	WinRateByMulligans map[int]float64               `json:"win_rate_by_mulligans"`
+	WinRateByLands     map[int]float64               `json:"win_rate_by_lands"`
 }
 
 // EDHResults aggregates EDHGameRecord values across many simulated pods.
@@ -170,6 +183,31 @@ type deckAccumulator struct {
 	maxStormCount    int
 	eliminations     int
 	cardStats        map[string]*cardPerfAccumulator
+	handsSeen        int
+	handsKept        int
+	byMulligans      map[int]*handOutcome
+	byLands          map[int]*handOutcome
+}
+
+// handOutcome counts the games and wins of one kind of kept hand.
+type handOutcome struct {
+	games int
+	wins  int
+}
+
+func (o *handOutcome) add(won bool) {
+	o.games++
+	if won {
+		o.wins++
+	}
+}
+
+func outcomeRates(m map[int]*handOutcome) map[int]float64 {
+	out := make(map[int]float64, len(m))
+	for k, o := range m {
+		out[k] = float64(o.wins) / float64(o.games) * 100
+	}
+	return out
 }
 
 // NewEDHResults constructs an empty aggregator.
@@ -254,6 +292,24 @@ func (r *EDHResults) RecordGame(rec EDHGameRecord) {
 				acc.deckoutWins++
 			}
 		}
+		if n := len(p.OpeningHands); n > 0 {
+			won := p.DeckName == rec.Winner
+			kept := p.OpeningHands[n-1]
+			acc.handsSeen += n
+			acc.handsKept++
+			if acc.byMulligans == nil {
+				acc.byMulligans = map[int]*handOutcome{}
+				acc.byLands = map[int]*handOutcome{}
+			}
+			if acc.byMulligans[kept.Mulligans] == nil {
+				acc.byMulligans[kept.Mulligans] = &handOutcome{}
+			}
+			acc.byMulligans[kept.Mulligans].add(won)
+			if acc.byLands[kept.Features.Lands] == nil {
+				acc.byLands[kept.Features.Lands] = &handOutcome{}
+			}
+			acc.byLands[kept.Features.Lands].add(won)
+		}
 		for cardName, perf := range p.CardStats {
 			if acc.cardStats == nil {
 				acc.cardStats = map[string]*cardPerfAccumulator{}
@@ -322,6 +378,11 @@ func (r *EDHResults) DeckStats() []EDHDeckStats {
 			row.AvgCreaturesCast = float64(acc.creaturesCastSum) / float64(games)
 			row.AvgCombatDamage = float64(acc.combatDamageSum) / float64(games)
 		}
+		if acc.handsSeen > 0 {
+			row.KeepRate = float64(acc.handsKept) / float64(acc.handsSeen) * 100
+			row.WinRateByMulligans = outcomeRates(acc.byMulligans)
+			row.WinRateByLands = outcomeRates(acc.byLands)
+		}
 		for cname, cpa := range acc.cardStats {
 			row.CardStats[cname] = CardPerformance{Casts: cpa.casts, Wins: cpa.wins}
 		}
@@ -399,6 +460,7 @@ func cloneEDHGameRecord(rec EDHGameRecord) EDHGameRecord {
 				out.Players[i].CardStats[k] = v
 			}
 		}
+		out.Players[i].OpeningHands = append([]OpeningHand(nil), p.OpeningHands...)
 	}
 	out.Events = append([]EDHEvent(nil), rec.Events...)
 	return out
@@ -118,3 +118,26 @@ func TestEDHResults_RecentGamesNewestFirstAndCopied(t *testing.T) {
 		t.Fatalf("RecentGames should return copies, got %+v", again[0])
 	}
 }
+
+func TestEDHResults_MulliganStats(t *testing.T) {
+	r := NewEDHResults()
+	threeLands := HandFeatures{Cards: 7, Lands: 3}
+	r.RecordGame(EDHGameRecord{Winner: "A", Players: []EDHPlayerRecord{{DeckName: "A", Mulligans: 1, OpeningHands: []OpeningHand{
+		{Features: HandFeatures{Cards: 7, Lands: 1}},
+		{Features: threeLands, Mulligans: 1, Kept: true},
+	}}}})
+	r.RecordGame(EDHGameRecord{Winner: "B", Players: []EDHPlayerRecord{{DeckName: "A", OpeningHands: []OpeningHand{
+		{Features: threeLands, Kept: true},
+	}}}})
+
+	row := r.DeckStats()[0]
+	if row.KeepRate < 66.6 || row.KeepRate > 66.7 {
+		t.Fatalf("2 of 3 hands kept, got keep rate %v", row.KeepRate)
+	}
+	if row.WinRateByMulligans[1] != 100 || row.WinRateByMulligans[0] != 0 {
+		t.Fatalf("win rate by mulligans: %v", row.WinRateByMulligans)
+	}
+	if row.WinRateByLands[3] != 50 {
+		t.Fatalf("win rate by lands: %v", row.WinRateByLands)
+	}
+}
@@ -2,10 +2,13 @@ package simulation
 
 import (
 	"errors"
-	"fmt"
 	"math/rand"
 	"strings"
 
+	"github.com/mtgsim/mtgsim/internal/logger"
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
 	"github.com/mtgsim/mtgsim/pkg/game"
 )
 
@@ -23,6 +26,17 @@ type EDHSeat struct {
 	Commanders []game.SimpleCard
 	Commander  *game.SimpleCard // nil if the deck has no commander designated
 	Mulligans  int              // mulligans the player will take before the game starts
+	// Agent makes the seat's decisions. nil uses DefaultAgent.
+	Agent Agent
+	// Combos is the deck's combo index, if known; LoadSeatCombos builds
+	// it. Opening hands count their combo pieces with it and the tutor
+	// policy and combo executor play its lines. nil leaves the seat with
+	// only the runner's known lines. Opponents' threat is always judged
+	// by the known lines, since their decklists are hidden.
+	Combos *combo.Index
+	// Archetype sets how DefaultAgent pilots the deck (see Style). Empty
+	// infers it from the cards with card.InferArchetype.
+	Archetype card.Archetype
 }
 
 // EDHRunOptions configures one pod simulation.
@@ -37,8 +51,17 @@ type EDHRunOptions struct {
 	// attached to EDHGameRecord.Events. Off by default to keep batch
 	// runs cheap.
 	RecordEvents bool
+	// Mulligan is the mulligan model of seats without an agent. nil uses
+	// DefaultMulliganModel.
+	Mulligan *MulliganModel
+	// Stop, once closed, ends the pod after the current turn, and
+	// SimulateEDHGame returns ErrPodStopped. nil never stops.
+	Stop <-chan struct{}
 }
 
+// ErrPodStopped reports a pod ended early through EDHRunOptions.Stop.
+var ErrPodStopped = errors.New("EDH pod stopped")
+
 // SimulateEDHGame runs a single pod and returns the recorded game.
 // The implementation is deliberately simple — a thin "play a land,
 // summon every creature, attack the most threatening opponent" AI —
@@ -64,8 +87,10 @@ func SimulateEDHGame(opts EDHRunOptions) (EDHGameRecord, error) {
 	}
 	metrics := newEDHMetrics(len(opts.Seats))
 
-	players, casts := setupEDHPlayers(opts.Seats, rng)
+	players, casts, hands := setupEDHPlayers(opts.Seats, rng, opts.Mulligan)
+	agents := newSeatAgents(players, opts.Seats, opts.Mulligan)
 	g := game.NewGame(players...)
+	abil.InstallCardScripts(g)
 	if log != nil {
 		for _, s := range opts.Seats {
 			log.Append(EDHEvent{Turn: 1, Phase: "setup", Kind: EventGameStart, Actor: s.DeckName, Detail: s.DeckPath})
@@ -76,16 +101,24 @@ func SimulateEDHGame(opts EDHRunOptions) (EDHGameRecord, error) {
 	if priority == nil {
 		priority = NewStackAwareHandler(g, log)
 	}
+	if h, ok := priority.(*StackAwareHandler); ok {
+		h.agents = agents
+		for _, p := range players {
+			h.ai.SetArchetype(p.GetName(), styleOf(agents.of(p)).Archetype)
+			if w, ok := agents.of(p).(interface {
+				watchTable(*EDHEventLog, *abil.Stack)
+			}); ok {
+				w.watchTable(log, h.spellCasting.GetStack())
+			}
+		}
+	}
 
-	turnLimitHit := false
+	turnLimitHit, ended := false, triggersSettled
 
 	for {
-		anyAlive, stuck := stepOneEDHTurn(g, casts, priority, log, metrics)
-		if !anyAlive {
-			break
-		}
-		if stuck {
-			turnLimitHit = true
+		var anyAlive bool
+		anyAlive, ended = stepOneEDHTurn(g, casts, agents, priority, log, metrics)
+		if !anyAlive || ended != triggersSettled {
 			break
 		}
 		if survivors(g) <= 1 {
@@ -95,9 +128,17 @@ func SimulateEDHGame(opts EDHRunOptions) (EDHGameRecord, error) {
 			turnLimitHit = true
 			break
 		}
+		select {
+		case <-opts.Stop:
+			return EDHGameRecord{}, ErrPodStopped
+		default:
+		}
 	}
 
-	rec := finalizeRecord(g, opts.Seats, casts, turnLimitHit, metrics)
+	rec := finalizeRecord(g, opts.Seats, casts, turnLimitHit, ended, metrics)
+	for i := range rec.Players {
+		rec.Players[i].OpeningHands = hands[i]
+	}
 	if log != nil {
 		log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: "end", Kind: EventGameEnd, Actor: rec.Winner})
 		rec.Events = log.Events()
@@ -106,13 +147,13 @@ func SimulateEDHGame(opts EDHRunOptions) (EDHGameRecord, error) {
 }
 
 // setupEDHPlayers materializes Player objects, registers commanders, and
-// performs initial draws with intelligent mulligan decisions.
-// Per the cEDH mulligan framework (Sperling 2023), the default action is to
-// mulligan; a hand is only kept if it contains a compelling reason (Truly
-// Broken Things or strong Development). As the hand shrinks, leniency increases.
-func setupEDHPlayers(seats []EDHSeat, rng *rand.Rand) ([]*game.Player, []int) {
+// performs initial draws, asking each seat's agent whether to mulligan.
+// Seats without an agent decide with DefaultAgent using model. It also
+// returns every opening hand each player looked at, for EDHPlayerRecord.
+func setupEDHPlayers(seats []EDHSeat, rng *rand.Rand, model *MulliganModel) ([]*game.Player, []int, [][]OpeningHand) {
 	players := make([]*game.Player, len(seats))
 	casts := make([]int, len(seats))
+	hands := make([][]OpeningHand, len(seats))
 	for i := range seats {
 		s := &seats[i]
 		p := game.NewEDHPlayer(s.DeckName)
@@ -125,155 +166,40 @@ func setupEDHPlayers(seats []EDHSeat, rng *rand.Rand) ([]*game.Player, []int) {
 
 		taken := s.Mulligans
 		if taken <= 0 {
-			taken = iterativeMulligan(p, rng, i, seatCommanders(*s))
+			agent := s.Agent
+			if agent == nil {
+				agent = DefaultAgent{Mulligan: model, Combos: s.Combos}
+			}
+			taken, hands[i] = iterativeMulligan(p, rng, i, *s, agent)
 		} else {
 			// If a caller forces >0 mulligans, cast to int and execute directly.
 			_, _ = p.LondonMulligan(rng, taken)
+			hands[i] = []OpeningHand{{Features: handFeatures(p.Hand, seatCommanders(*s), s.Combos), Mulligans: taken, Kept: true}}
 		}
 		s.Mulligans = taken
 		players[i] = p
 	}
-	return players, casts
+	return players, casts, hands
 }
 
-// iterativeMulligan evaluates the player's hand after each draw, mulliganing
-// until the hand is acceptable or the player reaches 4 cards. Returns the
-// number of mulligans taken.
+// iterativeMulligan asks agent about the player's hand after each draw,
+// mulliganing until the hand is kept or the player reaches 4 cards.
+// Returns the number of mulligans taken and every hand looked at.
 // Flow: 7 → evaluate → (free) 7 → evaluate → 6 → evaluate → 5 → evaluate → 4 → keep.
-func iterativeMulligan(p *game.Player, rng *rand.Rand, seat int, commanders []game.SimpleCard) int {
+func iterativeMulligan(p *game.Player, rng *rand.Rand, seat int, s EDHSeat, agent Agent) (int, []OpeningHand) {
+	commanders := seatCommanders(s)
+	var hands []OpeningHand
 	for m := 0; m < 4; m++ {
-		keep, _ := evaluateOpeningHand(p.Hand, commanders, seat, m)
-		if keep {
-			return m
+		hand := OpeningHand{Features: handFeatures(p.Hand, commanders, s.Combos), Mulligans: m}
+		if agent.KeepHand(p, commanders, seat, m) {
+			hand.Kept = true
+			return m, append(hands, hand)
 		}
+		hands = append(hands, hand)
 	// m=0: free mulligan (still 7), m=1: bottom 1 (6), m=2: bottom 2 (5), m=3: bottom 3 (4)
 		_, _ = p.LondonMulligan(rng, m+1)
 	}
-	return 4
-}
-
-// evaluateOpeningHand returns true if the hand is worth keeping, following
-// the cEDH mulligan framework:
-//
-//	Level 1 — Truly Broken Things: T1 Remora, fast mana + engine, Ad Naus with
-//	         mana, Necro with mana, fast Dockside. These are auto-keeps.
-//	Level 2 — Development: 3 lands + curve, 2 lands + ramp, 2 lands + 1-drop,
-//	         premium lands. Kept if strong enough.
-//	Level 3 — Everything else is a mulligan unless we're at 4-5 cards (desperate).
-//
-// leniency increases as mulligansTaken increases (fewer cards → more willing).
-func evaluateOpeningHand(hand []game.SimpleCard, commanders []game.SimpleCard, seat int, mulligansTaken int) (bool, string) {
-	lands := 0
-	premiumLands := 0
-	fastMana0 := 0
-	fastMana1 := 0
-	twoDropRamp := 0
-	hasRemora := false
-	hasRhystic := false
-	hasSentinel := false
-	hasAdNaus := false
-	hasNecro := false
-	hasDockside := false
-	hasFreeInteraction := 0
-	hasTutor := 0
-
-	maxCmc := 0
-	oneDrops := 0
-
-	for _, c := range hand {
-		if c.IsLand() {
-			lands++
-			switch c.Name {
-			case "Ancient Tomb", "City of Traitors":
-				premiumLands++
-			}
-			continue
-		}
-		cmc := int(c.GetMinManaCost().Total())
-		if cmc > maxCmc {
-			maxCmc = cmc
-		}
-		if cmc == 1 && !c.IsLand() {
-			oneDrops++
-		}
-		switch c.Name {
-		case "Mana Crypt", "Jeweled Lotus", "Chrome Mox", "Mox Diamond", "Mox Opal", "Lotus Petal":
-			fastMana0++
-		case "Sol Ring", "Mana Vault":
-			fastMana1++
-		case "Arcane Signet", "Fellwar Stone":
-			twoDropRamp++
-		case "Mystic Remora":
-			hasRemora = true
-		case "Rhystic Study":
-			hasRhystic = true
-		case "Esper Sentinel":
-			hasSentinel = true
-		case "Ad Nauseam", "Ad Nauseum":
-			hasAdNaus = true
-		case "Necropotence":
-			hasNecro = true
-		case "Dockside Extortionist":
-			hasDockside = true
-		case "Force of Will", "Pact of Negation", "Force of Negation", "Deflecting Swat", "Fierce Guardianship":
-			hasFreeInteraction++
-		case "Demonic Tutor", "Vampiric Tutor", "Imperial Seal", "Enlightened Tutor",
-			"Mystical Tutor", "Worldly Tutor", "Gamble":
-			hasTutor++
-		}
-	}
-
-	fastManaTotal := fastMana0 + fastMana1 + twoDropRamp
-
-	// --- Level 1: Truly Broken Things (auto-keep) ---
-
-	if hasRemora && lands >= 1 {
-		return true, "T1 Mystic Remora"
-	}
-	if (hasRhystic || hasSentinel) && lands >= 2 && fastManaTotal >= 1 {
-		return true, "t1-2 draw engine + ramp"
-	}
-	if fastMana0 >= 1 && lands >= 1 && (hasRemora || hasSentinel || hasRhystic || hasTutor >= 1) {
-		return true, "0-CMC fast mana + broken followup"
-	}
-	if fastMana1 >= 1 && lands >= 2 {
-		return true, "Sol Ring/Vault + 2 lands"
-	}
-	if hasAdNaus && lands+fastManaTotal+premiumLands >= 4 {
-		return true, "Ad Nauseum with mana"
-	}
-	if hasNecro && lands+fastManaTotal+premiumLands >= 3 {
-		return true, "Necropotence with mana"
-	}
-	if hasDockside && lands >= 2 && fastManaTotal >= 1 {
-		return true, "fast Dockside"
-	}
-
-	// --- Level 2: Development ---
-
-	if lands >= 3 && maxCmc <= lands+1 {
-		return true, "3 lands + good curve"
-	}
-	if lands >= 2 && fastManaTotal >= 1 && maxCmc <= 4 {
-		return true, "2 lands + ramp + curve"
-	}
-	if lands >= 2 && oneDrops >= 1 {
-		return true, "2 lands + 1-drop"
-	}
-	if premiumLands >= 1 && lands >= 2 {
-		return true, "premium land + 2 lands"
-	}
-
-	// --- Level 3: Leniency for smaller hands ---
-
-	if mulligansTaken >= 2 && lands >= 2 && maxCmc <= lands+2 {
-		return true, "playable 6-card hand"
-	}
-	if mulligansTaken >= 3 && lands >= 1 {
-		return true, "desperate 4-5 card keep"
-	}
-
-	return false, "no compelling reason to keep"
+	return 4, append(hands, OpeningHand{Features: handFeatures(p.Hand, commanders, s.Combos), Mulligans: 4, Kept: true})
 }
 
 // survivors counts players that have not been eliminated.
@@ -288,7 +214,7 @@ func survivors(g *game.Game) int {
 }
 
 // finalizeRecord builds the EDHGameRecord after the simulation loop ends.
-func finalizeRecord(g *game.Game, seats []EDHSeat, casts []int, turnLimitHit bool, metrics *edhMetrics) EDHGameRecord {
+func finalizeRecord(g *game.Game, seats []EDHSeat, casts []int, turnLimitHit bool, ended triggerOutcome, metrics *edhMetrics) EDHGameRecord {
 	rec := EDHGameRecord{Turns: g.GetTurnNumber(), Players: make([]EDHPlayerRecord, len(seats))}
 	var winner string
 	var winnerPlayer *game.Player
@@ -317,6 +243,14 @@ func finalizeRecord(g *game.Game, seats []EDHSeat, casts []int, turnLimitHit boo
 	if turnLimitHit && winner == "" {
 		rec.WinnerCondition = WinConditionTurnLimit
 	}
+	switch ended {
+	case triggersDrawn:
+		rec.Winner = ""
+		rec.WinnerCondition = WinConditionDraw
+	case triggersStuck:
+		rec.Winner = ""
+		rec.WinnerCondition = WinConditionEngineStuck
+	}
 	if metrics != nil {
 		metrics.applyToGameRecord(&rec)
 	}
@@ -342,18 +276,42 @@ func seatCommanderNames(s EDHSeat) []string {
 	return names
 }
 
+// ComboFinder looks up the combos in a decklist. *combo.Client finds them
+// on Commander Spellbook.
+type ComboFinder interface {
+	FindMyCombos(deckCards, commanders []string) (*combo.FindMyCombosResult, error)
+}
 
+// LoadSeatCombos builds the combo index of every seat that has none,
+// returning how many it built. A seat whose lookup fails keeps a nil
+// index.
+func LoadSeatCombos(seats []EDHSeat, finder ComboFinder) int {
+	built := 0
+	for i := range seats {
+		if seats[i].Combos != nil {
+			continue
+		}
+		commanders := seatCommanderNames(seats[i])
+		names := append(uniqueCardNames(seats[i].Library), commanders...)
+		result, err := finder.FindMyCombos(names, commanders)
+		if err != nil {
+			logger.LogMeta("Combo lookup failed for %s: %v", seats[i].DeckName, err)
+			continue
+		}
+		seats[i].Combos = combo.NewIndex(result, names)
+		built++
+	}
+	return built
+}
 
-// edhActionStateSnapshot returns a string representation of the current EDH game state
-// excluding turn and phase numbers so it can be used to detect meaningful progress
-// across individual phase actions.
-func edhActionStateSnapshot(g *game.Game) string {
-	var b strings.Builder
-	for _, p := range g.GetPlayersRaw() {
-		fmt.Fprintf(&b, "%s:L%d,lost:%v,Lib%d,Hand%d,BF%d,G%d,E%d;",
-			p.GetName(), p.GetLifeTotal(), p.HasLost(),
-			len(p.Library), len(p.Hand), len(p.Battlefield),
-			len(p.Graveyard), len(p.Exile))
+func uniqueCardNames(cards []game.SimpleCard) []string {
+	seen := make(map[string]bool, len(cards))
+	out := make([]string, 0, len(cards))
+	for _, c := range cards {
+		if !seen[c.Name] {
+			seen[c.Name] = true
+			out = append(out, c.Name)
+		}
 	}
-	return b.String()
+	return out
 }
@@ -9,13 +9,14 @@ import (
 	"github.com/mtgsim/mtgsim/pkg/game"
 )
 
-// stepOneEDHTurn drives the active player through a complete turn using
-// the simplified runner AI. Returns (anyAlive, stuck) where anyAlive is
-// false if no players are alive after the turn finishes, and stuck is
-// true if the game state did not progress for maxUnchangedActions
-// consecutive phase actions. priority is invoked at instant-speed windows
+// stepOneEDHTurn drives the active player through a complete turn, asking
+// each player's agent for their decisions. Returns (anyAlive, ended) where anyAlive is
+// false if no players are alive after the turn finishes, and ended is
+// how the last settleTriggers left the game: triggersDrawn if triggers
+// entered a mandatory loop, triggersStuck if the engine gave up on them.
+// priority is invoked at instant-speed windows
 // so future AI can respond on opponents' turns; log is optional.
-func stepOneEDHTurn(g *game.Game, casts []int, priority PriorityHandler, log *EDHEventLog, metrics *edhMetrics) (bool, bool) {
+func stepOneEDHTurn(g *game.Game, casts []int, agents seatAgents, priority PriorityHandler, log *EDHEventLog, metrics *edhMetrics) (bool, triggerOutcome) {
 	startTurn := g.GetTurnNumber()
 	milledThisTurn := false
 	if metrics != nil {
@@ -28,34 +29,15 @@ func stepOneEDHTurn(g *game.Game, casts []int, priority PriorityHandler, log *ED
 		stackHandler = h
 	}
 
-	lastState := ""
-	unchangedActions := 0
-	const maxUnchangedActions = 12
-
 	for {
-		// Detect stale game state before each phase action
-		currentState := edhActionStateSnapshot(g)
-		if currentState == lastState {
-			unchangedActions++
-			if unchangedActions >= maxUnchangedActions {
-				logger.LogMeta("STUCK LOOP: no state change for %d consecutive actions, breaking. snapshot=%s", unchangedActions, currentState)
-				return survivors(g) >= 1, true
-			}
-		} else {
-			unchangedActions = 0
-			lastState = currentState
-		}
-
 		ap := g.GetActivePlayerRaw()
 		switch g.GetCurrentPhase() {
 		case game.PhaseUntap:
-			for _, perm := range ap.Battlefield {
-				perm.Untap()
-			}
+			g.UntapStep(ap)
 		case game.PhaseUpkeep:
 			offerOpponentPriority(g, ap, priority)
 		case game.PhaseDraw:
-			if g.GetTurnNumber() > 1 || ap != g.GetPlayerByIndex(0) {
+			if (g.GetTurnNumber() > 1 || ap != g.GetPlayerByIndex(0)) && !g.SkipsDrawStep(ap) {
 				if ap.Draw(1) == 0 && len(ap.Library) == 0 {
 					ap.Lose("deckout") // CR 704.5b: empty-library draw loss
 					milledThisTurn = true
@@ -66,25 +48,31 @@ func stepOneEDHTurn(g *game.Game, casts []int, priority PriorityHandler, log *ED
 			if log != nil {
 				log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: EventTurnStart, Actor: ap.GetName()})
 			}
-			runMainPhase(g, ap, casts, log, metrics, stackHandler)
+			runMainPhase(g, ap, agents.of(ap), casts, log, metrics, stackHandler)
 			offerOpponentPriority(g, ap, priority)
 		case game.PhaseCombat:
-			runCombatPhase(g, ap, log, metrics, priority)
+			// Beginning-of-combat triggers resolve before attackers are
+			// declared (CR 507.1).
+			if ended := settleTriggers(g, agents, stackHandler); ended != triggersSettled {
+				return endedByTriggers(g, ap, ended, log, metrics)
+			}
+			runCombatPhase(g, ap, agents, log, metrics, priority)
 			offerOpponentPriority(g, ap, priority)
 		case game.PhaseEnd:
 			offerOpponentPriority(g, ap, priority)
 		case game.PhaseCleanup:
 			// Mana pools emptied by AdvancePhase; EOT effects cleared by Game.AdvancePhase.
+			for _, c := range discardToHandSize(g, ap, agents.of(ap)) {
+				if log != nil {
+					log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseCleanup), Kind: EventCleanupDiscard, Actor: ap.GetName(), Detail: c.Name})
+				}
+			}
 		}
 		g.ApplyStateBasedActions()
 
 		// Process triggers queued by game events.
-		// When a stack handler is active, triggers are resolved with a priority
-		// window so opponents can respond (CR 603.3). Otherwise they fire directly.
-		if stackHandler != nil {
-			stackHandler.ProcessPendingGameTriggers()
-		} else {
-			g.ProcessPendingTriggers()
+		if ended := settleTriggers(g, agents, stackHandler); ended != triggersSettled {
+			return endedByTriggers(g, ap, ended, log, metrics)
 		}
 
 		if milledThisTurn {
@@ -93,13 +81,72 @@ func stepOneEDHTurn(g *game.Game, casts []int, priority PriorityHandler, log *ED
 		recordEliminations(g, log, ap, metrics)
 		g.AdvancePhase()
 		if survivors(g) <= 1 {
-			return survivors(g) >= 1, false
+			return survivors(g) >= 1, triggersSettled
 		}
 		if g.GetTurnNumber() != startTurn {
 			break
 		}
 	}
-	return survivors(g) >= 1, false
+	return survivors(g) >= 1, triggersSettled
+}
+
+// endedByTriggers logs a game that settleTriggers ended as a draw or a
+// stuck engine, and returns stepOneEDHTurn's result for it.
+func endedByTriggers(g *game.Game, ap *game.Player, ended triggerOutcome, log *EDHEventLog, metrics *edhMetrics) (bool, triggerOutcome) {
+	if log != nil {
+		kind := EventLoopDraw
+		if ended == triggersStuck {
+			kind = EventEngineStuck
+		}
+		log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(g.GetCurrentPhase()), Kind: kind, Actor: ap.GetName()})
+	}
+	recordEliminations(g, log, ap, metrics)
+	return survivors(g) >= 1, ended
+}
+
+// settleTriggers resolves the triggers queued by game events, and the
+// ones their resolution queues in turn, until none are left. When a stack
+// handler is active, triggers are resolved with a priority window so
+// opponents can respond (CR 603.3). Otherwise they fire directly.
+//
+// Game triggers are mandatory, so a chain that comes back to a position
+// it has been in, with the same triggers waiting, is a loop nobody can
+// stop. Unless the loop is taking a player towards a loss, or once it has
+// repeated mandatoryLoopCap times, the game is a draw (CR 104.4b) and
+// settleTriggers returns triggersDrawn. A chain that runs triggerRoundCap
+// rounds without ending or repeating returns triggersStuck.
+func settleTriggers(g *game.Game, agents seatAgents, stackHandler *StackAwareHandler) triggerOutcome {
+	var loops loopDetector
+	repeats := 0
+	for round := 0; g.HasPendingTriggers(); round++ {
+		if round >= triggerRoundCap {
+			logger.LogMeta("STUCK ENGINE: triggers still pending after %d rounds, the game is abandoned", round)
+			return triggersStuck
+		}
+		if stackHandler != nil {
+			stackHandler.ProcessPendingGameTriggers()
+		} else {
+			for _, pt := range orderTriggers(g, agents, g.DrainPendingTriggers()) {
+				if pt.Trigger != nil && pt.Trigger.Action != nil {
+					pt.Trigger.Action(g, pt.Trigger, pt.Event)
+				}
+			}
+			g.ApplyStateBasedActions()
+		}
+		if survivors(g) <= 1 {
+			return triggersSettled
+		}
+		gain, _, again := loops.observe(loopPosition(g), measureLoop(g, nil))
+		if !again {
+			continue
+		}
+		repeats++
+		if !headsToALoss(gain) || repeats >= mandatoryLoopCap {
+			logger.LogMeta("MANDATORY LOOP: triggers repeat with no way to stop them, the game is a draw")
+			return triggersDrawn
+		}
+	}
+	return triggersSettled
 }
 
 // offerOpponentPriority walks each living non-active opponent in APNAP
@@ -158,36 +205,37 @@ func recordEliminations(g *game.Game, log *EDHEventLog, ap *game.Player, metrics
 	}
 }
 
-// runMainPhase plays one land, casts the commander when possible, and
-// summons creatures in hand if mana allows. Optional log
+// runMainPhase plays the lands agent chooses, casts the commander when
+// possible, and casts the spells agent picks while mana allows. Optional log
 // records every public action so a replay can be reproduced.
 // stackHandler, when non-nil, routes instants and sorceries through the
 // ability package's stack so opponents can respond before resolution.
-func runMainPhase(g *game.Game, ap *game.Player, casts []int, log *EDHEventLog, metrics *edhMetrics, stackHandler *StackAwareHandler) {
+func runMainPhase(g *game.Game, ap *game.Player, agent Agent, casts []int, log *EDHEventLog, metrics *edhMetrics, stackHandler *StackAwareHandler) {
 	idx := indexOfPlayer(g, ap)
 	landsPlayed := 0
-	for _, c := range ap.Hand {
-		if c.IsLand() {
-			if landsPlayed >= ap.LandPlaysAvailable() {
-				break
-			}
-			if _, err := g.PlayLand(ap, c.Name); err != nil {
-				continue
-			}
-			if log != nil {
-				log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: EventLandPlay, Actor: ap.GetName(), Detail: c.Name})
-			}
-			if metrics != nil {
-				metrics.recordLand(idx, c.Name)
-			}
-			landsPlayed++
+	for landsPlayed < ap.LandPlaysAvailable() {
+		c, ok := agent.ChooseLand(g, ap)
+		if !ok {
+			break
+		}
+		lifeBefore := ap.GetLifeTotal()
+		perm, err := g.PlayLand(ap, c.Name)
+		if err != nil {
+			break
+		}
+		if log != nil {
+			log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: EventLandPlay, Actor: ap.GetName(), Detail: landPlayDetail(perm, lifeBefore-ap.GetLifeTotal())})
 		}
+		if metrics != nil {
+			metrics.recordLand(idx, c.Name)
+		}
+		landsPlayed++
 	}
 	ap.ResetLandPlays()
 
-	activateSearchAbilities(g, ap, log)
+	activateSearchAbilities(g, ap, agent, log)
 
-	tapManaSourcesForMainPhaseMana(g, ap, idx, metrics)
+	tapManaSourcesForMainPhaseMana(g, ap, agent, idx, metrics)
 
 	if idx >= 0 && len(ap.CommandZone) > 0 {
 		name := ap.CommandZone[0].Name
@@ -196,6 +244,9 @@ func runMainPhase(g *game.Game, ap *game.Player, casts []int, log *EDHEventLog,
 		if cost.Total() == 0 && cmdrCard.ManaCost == "" {
 			goto skipCommander
 		}
+		if !g.CanCast(ap, cmdrCard, game.Command) {
+			goto skipCommander
+		}
 		if ap.CanPayForCommander(cmdrCard) {
 			if ap.PayForCommander(cmdrCard) {
 				manaSpent := manaSpentForCommander(ap, cmdrCard)
@@ -215,11 +266,10 @@ func runMainPhase(g *game.Game, ap *game.Player, casts []int, log *EDHEventLog,
 						log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: EventCommanderCast, Actor: ap.GetName(), Detail: eventDetail(name, manaSpent, storm)})
 					}
 				} else {
-					perm := ap.CastCommander(name)
-					if perm == nil {
+					if g.CastCommander(ap, name) == nil {
 						return
 					}
-					perm.SetEnteredTurn(g.GetTurnNumber())
+					g.RecordSpellCast(ap)
 					casts[idx]++
 					storm := 0
 					if metrics != nil {
@@ -233,88 +283,123 @@ func runMainPhase(g *game.Game, ap *game.Player, casts []int, log *EDHEventLog,
 		}
 	}
 skipCommander:
-	if attemptCEDHComboFinish(g, ap, log, metrics) {
+	if attemptCEDHComboFinish(g, ap, agent, log, metrics, stackHandler) {
 		return
 	}
 
-	again := true
-	for again {
-		again = false
-		tapManaSourcesForMainPhaseMana(g, ap, idx, metrics)
-		for _, c := range ap.Hand {
-			if !isCastableSpell(c) || c.IsCounterspell() || !ap.CanPayForCard(c) {
-				continue
-			}
-			if c.GetManaCost().Total() == 0 && c.ManaCost == "" {
-				continue
-			}
-			if !ap.PayForCard(c) {
-				continue
-			}
-			manaSpent := manaSpentForCard(c)
-			if c.IsInstant() || c.IsSorcery() {
-				var resolved bool
-				if stackHandler != nil {
-					resolved = stackHandler.CastSpellThroughStack(ap, c, ap.GetName())
-				} else {
-					resolved = castNonPermanentSpell(g, ap, c, log, metrics)
-				}
-				storm := 0
-				if metrics != nil && resolved {
-					storm = metrics.recordSpell(idx, manaSpent, c.IsCreature(), c.Name)
-				}
-				if log != nil && resolved {
-					countered := manaSpent == 0 && checkVexingBauble(g, ap, c, log)
-					if !countered {
-						log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: EventPermanentCast, Actor: ap.GetName(), Detail: eventDetail(c.Name, manaSpent, storm)})
-					}
-				}
-				if resolved {
-					again = true
-					break
-				}
-				continue
-			}
-			if manaSpent == 0 && checkVexingBauble(g, ap, c, log) {
-				again = true
-				break
-			}
-			if stackHandler != nil {
-				if !stackHandler.CastPermanentThroughStack(ap, c, ap.GetName()) {
-					continue
-				}
-			} else {
-				perm, perr := castPermanentCard(g, ap, c)
-				if perr != nil || perm == nil {
-					continue
-				}
-				perm.SetEnteredTurn(g.GetTurnNumber())
-				resolvePermanentETB(g, perm, ap, log)
-			}
-			storm := 0
-			if metrics != nil {
-				storm = metrics.recordSpell(idx, manaSpent, c.IsCreature(), c.Name)
-			}
-			if log != nil {
-				kind := EventPermanentCast
-				if c.IsCreature() {
-					kind = EventCreatureSummon
-				}
-				log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: kind, Actor: ap.GetName(), Detail: eventDetail(c.Name, manaSpent, storm)})
-			}
-			again = true
+	castSpells(g, ap, agent, idx, log, metrics, stackHandler)
+	finishMainPhase(g, ap, agent, log, metrics, stackHandler)
+}
+
+// castSpells casts the spells agent picks from ap's hand until it stops
+// or nothing castable is left.
+func castSpells(g *game.Game, ap *game.Player, agent Agent, idx int, log *EDHEventLog, metrics *edhMetrics, stackHandler *StackAwareHandler) {
+	// tried holds the spells that failed to cast since the last one that
+	// did, so the agent isn't offered them again.
+	tried := map[string]bool{}
+	for {
+		if len(tried) == 0 {
+			tapManaSourcesForMainPhaseMana(g, ap, agent, idx, metrics)
+		}
+		castable := castableSpells(g, ap, tried)
+		c, ok := agent.ChooseSpell(g, ap, castable)
+		if !ok || findZoneCard(castable, c.Name) < 0 {
 			break
 		}
+		if castFromHand(g, ap, c, idx, log, metrics, stackHandler) {
+			tried = map[string]bool{}
+		} else {
+			tried[c.Name] = true
+		}
 	}
+}
 
-	activateSearchAbilities(g, ap, log)
+// finishMainPhase activates the abilities the runner uses after casting
+// and gives the combo finishers a last look.
+func finishMainPhase(g *game.Game, ap *game.Player, agent Agent, log *EDHEventLog, metrics *edhMetrics, stackHandler *StackAwareHandler) {
+	activateSearchAbilities(g, ap, agent, log)
 	bridge.AutoActivateMainPhaseAbilitiesWithLog(g, func(cardName, detail string) {
 		if log != nil {
 			actor := ap.GetName()
 			log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: EventActivatedAbility, Actor: actor, Detail: cardName + " -> " + detail})
 		}
 	})
-	attemptCEDHComboFinish(g, ap, log, metrics)
+	attemptCEDHComboFinish(g, ap, agent, log, metrics, stackHandler)
+}
+
+// castableSpells returns the spells in ap's hand the runner may cast now,
+// skipping those named in tried.
+func castableSpells(g *game.Game, ap *game.Player, tried map[string]bool) []game.SimpleCard {
+	var out []game.SimpleCard
+	for _, c := range ap.Hand {
+		if tried[c.Name] || !isCastableSpell(c) || c.IsCounterspell() || !g.CanCast(ap, c, game.Hand) || !ap.CanPayForCard(c) {
+			continue
+		}
+		if heldForCombo(c.Name) {
+			continue // held for attemptCEDHComboFinish
+		}
+		if c.GetManaCost().Total() == 0 && c.ManaCost == "" {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// castFromHand pays for and casts c from ap's hand, logging and recording
+// it. Returns false if c couldn't be paid for or cast.
+func castFromHand(g *game.Game, ap *game.Player, c game.SimpleCard, idx int, log *EDHEventLog, metrics *edhMetrics, stackHandler *StackAwareHandler) bool {
+	if !ap.PayForCard(c) {
+		return false
+	}
+	manaSpent := manaSpentForCard(c)
+	if c.IsInstant() || c.IsSorcery() {
+		var resolved bool
+		if stackHandler != nil {
+			resolved = stackHandler.CastSpellThroughStack(ap, c, ap.GetName())
+		} else {
+			resolved = castNonPermanentSpell(g, ap, c, log, metrics)
+		}
+		storm := 0
+		if metrics != nil && resolved {
+			storm = metrics.recordSpell(idx, manaSpent, c.IsCreature(), c.Name)
+		}
+		if log != nil && resolved {
+			countered := manaSpent == 0 && checkVexingBauble(g, ap, c, log)
+			if !countered {
+				log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: EventPermanentCast, Actor: ap.GetName(), Detail: eventDetail(c.Name, manaSpent, storm)})
+			}
+		}
+		return resolved
+	}
+	if manaSpent == 0 && checkVexingBauble(g, ap, c, log) {
+		return true
+	}
+	if stackHandler != nil {
+		if !stackHandler.CastPermanentThroughStack(ap, c, ap.GetName()) {
+			return false
+		}
+	} else {
+		perm, perr := castPermanentCard(g, ap, c)
+		if perr != nil || perm == nil {
+			return false
+		}
+		perm.SetEnteredTurn(g.GetTurnNumber())
+		g.RecordSpellCast(ap)
+		resolvePermanentETB(g, perm, ap, log)
+	}
+	storm := 0
+	if metrics != nil {
+		storm = metrics.recordSpell(idx, manaSpent, c.IsCreature(), c.Name)
+	}
+	if log != nil {
+		kind := EventPermanentCast
+		if c.IsCreature() {
+			kind = EventCreatureSummon
+		}
+		log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseMain1), Kind: kind, Actor: ap.GetName(), Detail: eventDetail(c.Name, manaSpent, storm)})
+	}
+	return true
 }
 
 func isCastableSpell(c game.SimpleCard) bool {
@@ -343,6 +428,7 @@ func castNonPermanentSpell(g *game.Game, ap *game.Player, c game.SimpleCard, log
 		return false
 	}
 	ap.Hand = append(ap.Hand[:idx], ap.Hand[idx+1:]...)
+	g.RecordSpellCast(ap)
 	gs := bridge.NewAbilityGameState(g)
 	engine := abil.NewExecutionEngine(gs)
 	abilities, err := engine.ParseAndRegisterAbilities(c.OracleText, c)
@@ -381,7 +467,7 @@ func resolvePermanentETB(g *game.Game, perm *game.Permanent, ap *game.Player, lo
 		return
 	}
 	src := perm.GetSource()
-	if src.OracleText == "" {
+	if src.OracleText == "" && !abil.HasCardScript(src.Name) {
 		return
 	}
 	abilities, err := engine.ParseAndRegisterAbilities(src.OracleText, src)
@@ -418,8 +504,9 @@ func eventDetail(name string, manaSpent, storm int) string {
 	return name + " | mana=" + intString(manaSpent) + " storm=" + intString(storm)
 }
 
-func activateSearchAbilities(g *game.Game, ap *game.Player, log *EDHEventLog) {
+func activateSearchAbilities(g *game.Game, ap *game.Player, agent Agent, log *EDHEventLog) {
 	gs := bridge.NewAbilityGameState(g)
+	gs.Chooser = chooserFor(g, agent)
 	engine := abil.NewExecutionEngine(gs)
 	playerAdapter := gs.GetPlayer(ap.GetName())
 	if playerAdapter == nil {
@@ -445,17 +532,24 @@ func activateSearchAbilities(g *game.Game, ap *game.Player, log *EDHEventLog) {
 			continue
 		}
 		foundCard = ""
+		lifeBefore := ap.GetLifeTotal()
 		if err := engine.ExecuteAbility(ability, playerAdapter, nil); err != nil {
 			continue
 		}
 		srcName := "unknown"
-		if src, ok := ability.Source.(game.SimpleCard); ok {
+		switch src := ability.Source.(type) {
+		case game.SimpleCard:
 			srcName = src.Name
+		case interface{ GetName() string }:
+			srcName = src.GetName()
 		}
 		detail := "searched"
 		if foundCard != "" {
 			detail = foundCard
 		}
+		if paid := lifeBefore - ap.GetLifeTotal(); paid > 0 {
+			detail += " (paid " + intString(paid) + " life)"
+		}
 		if log != nil {
 			log.Append(EDHEvent{
 				Turn:   g.GetTurnNumber(),
@@ -506,13 +600,15 @@ func intString(v int) string {
 	return string(buf[i:])
 }
 
-func tapManaSourcesForMainPhaseMana(g *game.Game, ap *game.Player, idx int, metrics *edhMetrics) {
+func tapManaSourcesForMainPhaseMana(g *game.Game, ap *game.Player, agent Agent, idx int, metrics *edhMetrics) {
 	demand := aggregateMainPhaseManaDemand(ap)
 	totalProduced := 0
 	hasUrborg := permanentOnBattlefield(ap, "Urborg, Tomb of Yawgmoth")
 	hasYavimaya := permanentOnBattlefield(ap, "Yavimaya, Cradle of Growth")
+	// Sources the interaction planner holds open stay untapped.
+	keep := manaSourcesToKeepOpen(g, ap, styleOf(agent))
 	for _, perm := range ap.Battlefield {
-		if perm.IsTapped() {
+		if perm.IsTapped() || keep[perm] {
 			continue
 		}
 		produced := chooseManaProduction(perm.GetSource(), demand)
@@ -522,6 +618,9 @@ func tapManaSourcesForMainPhaseMana(g *game.Game, ap *game.Player, idx int, metr
 		if perm.IsCreature() && perm.GetEnteredTurn() == g.GetTurnNumber() && !perm.HasKeyword(game.KWHaste) {
 			continue
 		}
+		if !g.CanActivateAbilities(perm) {
+			continue
+		}
 		if hasUrborg && perm.GetSource().IsLand() {
 			hasBlack := false
 			for mt := range produced {
@@ -572,48 +671,9 @@ func aggregateMainPhaseManaDemand(ap *game.Player) game.Mana {
 			demand.Add(mt, n)
 		}
 	}
-
-	// Add mana for holding up counterspells if the player has any in hand
-	counterspellHoldUpMana := calculateCounterspellHoldUpMana(ap)
-	for mt, n := range counterspellHoldUpMana {
-		demand.Add(mt, n)
-	}
-
 	return demand
 }
 
-// calculateCounterspellHoldUpMana determines mana to hold up for counterspells.
-// Players with counterspells in hand should hold up mana to be able to counter
-// opponent spells. The typical hold-up is the cost of the cheapest counterspell.
-func calculateCounterspellHoldUpMana(ap *game.Player) game.Mana {
-	holdUp := game.Mana{}
-	
-	// Find counterspells in hand
-	minCounterspellCost := 999
-	var cheapestCounterspell game.SimpleCard
-	hasCounterspell := false
-	
-	for _, c := range ap.Hand {
-		if c.IsCounterspell() {
-			hasCounterspell = true
-			cost := c.GetManaCost().Total()
-			if cost < minCounterspellCost {
-				minCounterspellCost = cost
-				cheapestCounterspell = c
-			}
-		}
-	}
-	
-	// If player has counterspells, hold up mana equal to the cheapest one
-	if hasCounterspell && minCounterspellCost < 999 {
-		for mt, n := range cheapestCounterspell.GetManaCost() {
-			holdUp.Add(mt, n)
-		}
-	}
-	
-	return holdUp
-}
-
 func chooseManaProduction(c game.SimpleCard, demand game.Mana) game.Mana {
 	options := manaProductionOptions(c)
 	if len(options) == 0 {
@@ -673,85 +733,78 @@ func manaProductionOptions(c game.SimpleCard) []game.Mana {
 	if strings.Contains(text, "{c}{c}") {
 		out = append(out, game.Mana{game.Colorless: 2})
 	}
-	add(game.White, "plains", "{w}")
-	add(game.Blue, "island", "{u}")
-	add(game.Black, "swamp", "{b}")
-	add(game.Red, "mountain", "{r}")
-	add(game.Green, "forest", "{g}")
+	// Lands tap for the colors of their basic land types (CR 305.6), so
+	// typed duals count while fetchlands, which only name basic types in
+	// their search text, produce nothing.
+	hasType := map[string]bool{}
+	for _, t := range c.BasicLandTypes() {
+		hasType[t] = true
+	}
+	addTyped := func(mt game.ManaType, basic, symbol string) {
+		if hasType[basic] || (c.IsLand() && strings.EqualFold(c.Name, basic)) {
+			out = append(out, game.Mana{mt: 1})
+			return
+		}
+		add(mt, symbol)
+	}
+	addTyped(game.White, "Plains", "{w}")
+	addTyped(game.Blue, "Island", "{u}")
+	addTyped(game.Black, "Swamp", "{b}")
+	addTyped(game.Red, "Mountain", "{r}")
+	addTyped(game.Green, "Forest", "{g}")
 	add(game.Colorless, "wastes", "{c}")
 	return out
 }
 
-// chooseBlockers uses simple AI to assign blocker creatures to attacking
-// creatures. Prioritizes survival blocks (blocker toughness > attacker power)
-// over trade blocks (attacker toughness <= blocker power).
-func chooseBlockers(g *game.Game, ap *game.Player, defender *game.Player) {
-	attackers := g.GetAttackers()
-	if len(attackers) == 0 {
+// runCombatPhase declares the attacks ap's agent plans, which may be
+// split between several opponents, lets each defending player's agent
+// block in turn order, and resolves combat damage. Priority windows allow
+// instant-speed interaction per CR 508.2 and CR 510.3.
+func runCombatPhase(g *game.Game, ap *game.Player, agents seatAgents, log *EDHEventLog, metrics *edhMetrics, priority PriorityHandler) {
+	agent := agents.of(ap)
+	primary := agent.ChooseAttackTarget(g, ap)
+	if primary == nil || primary == ap || primary.HasLost() {
 		return
 	}
-	for _, blocker := range defender.GetCreatures() {
-		if blocker.IsTapped() || blocker.CantBlock() {
+	if log != nil {
+		logThreats(g, log, ap, "attack", primary.GetName(), rankThreats(g, ap))
+	}
+	plan := agent.ChooseAttackers(g, ap, primary)
+	var defenders []*game.Player
+	declared := map[*game.Player]int{}
+	beforeLife := map[*game.Player]int{}
+	for _, perm := range ap.GetCreatures() {
+		defender, ok := plan[perm]
+		if !ok || defender == nil || defender == ap || defender.HasLost() || indexOfPlayer(g, defender) < 0 {
 			continue
 		}
-		// Prefer blocks where blocker survives
-		blocked := false
-		for attacker := range attackers {
-			if attacker.IsTapped() {
-				continue
-			}
-			if blocker.GetToughness() > attacker.GetPower() {
-				if err := g.DeclareBlocker(blocker, attacker); err == nil {
-					blocked = true
-					break
-				}
-			}
+		if err := g.DeclareAttacker(perm, defender); err != nil {
+			continue
 		}
-		if !blocked {
-			// Trade block as fallback
-			for attacker := range attackers {
-				if attacker.IsTapped() {
-					continue
-				}
-				if attacker.GetToughness() <= blocker.GetPower() {
-					if err := g.DeclareBlocker(blocker, attacker); err == nil {
-						break
-					}
-				}
-			}
+		if declared[defender] == 0 {
+			beforeLife[defender] = defender.GetLifeTotal()
 		}
+		declared[defender]++
 	}
-}
-
-// runCombatPhase declares all eligible attackers against the most
-// threatening living opponent (Phase 4), runs blocking AI, and resolves
-// combat damage. Priority windows allow instant-speed interaction
-// per CR 508.2 and CR 510.3.
-func runCombatPhase(g *game.Game, ap *game.Player, log *EDHEventLog, metrics *edhMetrics, priority PriorityHandler) {
-	defender := chooseAttackTarget(g, ap)
-	if defender == nil {
-		return
-	}
-	beforeLife := defender.GetLifeTotal()
-	declared := 0
-	for _, perm := range ap.GetCreatures() {
-		if perm.IsTapped() {
-			continue
-		}
-		if err := g.DeclareAttacker(perm, defender); err == nil {
-			declared++
+	// Defending players block in turn order starting after ap (CR 509.1).
+	n := g.NumPlayers()
+	for i := 1; i < n; i++ {
+		if p := g.GetPlayerByIndex((indexOfPlayer(g, ap) + i) % n); declared[p] > 0 {
+			defenders = append(defenders, p)
 		}
 	}
-	if log != nil && declared > 0 {
-		log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseCombat), Kind: EventAttackDeclared, Actor: ap.GetName(), Target: defender.GetName(), Detail: intString(declared) + " attackers"})
+	if log != nil {
+		for _, defender := range defenders {
+			log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseCombat), Kind: EventAttackDeclared, Actor: ap.GetName(), Target: defender.GetName(), Detail: intString(declared[defender]) + " attackers"})
+		}
 	}
 
 	// Priority window after declare attackers — CR 508.2
 	offerOpponentPriority(g, ap, priority)
 
-	// Defending player declares blockers (CR 509)
-	if declared > 0 {
-		chooseBlockers(g, ap, defender)
+	// Defending players declare blockers (CR 509)
+	for _, defender := range defenders {
+		agents.of(defender).DeclareBlockers(g, defender)
 		if log != nil {
 			blockCount := 0
 			for _, perm := range defender.GetCreatures() {
@@ -769,13 +822,17 @@ func runCombatPhase(g *game.Game, ap *game.Player, log *EDHEventLog, metrics *ed
 	offerOpponentPriority(g, ap, priority)
 
 	g.ResolveCombatDamage()
-	damage := max(0, beforeLife-defender.GetLifeTotal())
+	damage := 0
+	for _, defender := range defenders {
+		dealt := max(0, beforeLife[defender]-defender.GetLifeTotal())
+		damage += dealt
+		if log != nil {
+			log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseCombat), Kind: EventCombatResolved, Actor: ap.GetName(), Target: defender.GetName(), Detail: "damage=" + intString(dealt)})
+		}
+	}
 	if metrics != nil {
 		metrics.recordCombatDamage(indexOfPlayer(g, ap), damage)
 	}
-	if log != nil && declared > 0 {
-		log.Append(EDHEvent{Turn: g.GetTurnNumber(), Phase: phaseName(game.PhaseCombat), Kind: EventCombatResolved, Actor: ap.GetName(), Target: defender.GetName(), Detail: "damage=" + intString(damage)})
-	}
 
 	// Priority window after combat damage — CR 510.3 / 511.2 (end of combat step).
 	offerOpponentPriority(g, ap, priority)
@@ -1,9 +1,12 @@
 package simulation
 
 import (
+	"errors"
 	"math/rand"
 	"testing"
 
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/combo"
 	"github.com/mtgsim/mtgsim/pkg/game"
 )
 
@@ -61,7 +64,7 @@ func TestCEDHComboFinish_OracleConsultation(t *testing.T) {
 	winner.AddManaToPool(game.Black, 1)
 	g := game.NewGame(winner, loser)
 
-	if !attemptCEDHComboFinish(g, winner, nil, nil) {
+	if !attemptCEDHComboFinish(g, winner, nil, nil, nil, nil) {
 		t.Fatal("expected Oracle/Consultation combo to finish the game")
 	}
 	if !loser.HasLost() || winner.HasLost() {
@@ -74,11 +77,16 @@ func TestCEDHComboFinish_GodoHelm(t *testing.T) {
 	loser := game.NewEDHPlayer("Opponent")
 	winner.Hand = []game.SimpleCard{{Name: "Helm of the Host", TypeLine: "Legendary Artifact — Equipment", ManaCost: "{4}"}}
 	winner.CommandZone = []game.SimpleCard{{Name: "Godo, Bandit Warlord", TypeLine: "Legendary Creature", ManaCost: "{5}{R}", Power: "3", Toughness: "3"}}
-	winner.AddManaToPool(game.Red, 1)
-	winner.AddManaToPool(game.Colorless, 10)
 	g := game.NewGame(winner, loser)
+	abil.InstallCardScripts(g)
+	// Equip is a sorcery-speed ability, and each phase empties mana pools.
+	for g.GetCurrentPhase() != game.PhaseMain1 {
+		g.AdvancePhase()
+	}
+	winner.AddManaToPool(game.Red, 1)
+	winner.AddManaToPool(game.Colorless, 15)
 
-	if !attemptCEDHComboFinish(g, winner, nil, nil) {
+	if !attemptCEDHComboFinish(g, winner, nil, nil, nil, nil) {
 		t.Fatal("expected Godo/Helm combo to finish the game")
 	}
 	if !loser.HasLost() || winner.HasLost() {
@@ -86,6 +94,78 @@ func TestCEDHComboFinish_GodoHelm(t *testing.T) {
 	}
 }
 
+func TestCEDHComboFinish_DualcasterTwinflame(t *testing.T) {
+	winner := game.NewEDHPlayer("Dualcaster")
+	loser := game.NewEDHPlayer("Opponent")
+	winner.Hand = []game.SimpleCard{
+		{Name: "Twinflame", TypeLine: "Sorcery", ManaCost: "{1}{R}", OracleText: "Strive — This spell costs {2}{R} more to cast for each target beyond the first.\nChoose any number of target creatures you control. For each of them, create a token that's a copy of that creature, except it has haste. Exile them at the beginning of the next end step."},
+		{Name: "Dualcaster Mage", TypeLine: "Creature — Human Wizard", ManaCost: "{1}{R}{R}", Power: "2", Toughness: "2", OracleText: "Flash\nWhen Dualcaster Mage enters the battlefield, copy target instant or sorcery spell. You may choose new targets for the copy."},
+	}
+	winner.AddManaToPool(game.Red, 3)
+	winner.AddManaToPool(game.Colorless, 2)
+	g := game.NewGame(winner, loser)
+
+	if !attemptCEDHComboFinish(g, winner, nil, nil, nil, nil) {
+		t.Fatal("expected Dualcaster/Twinflame to loop")
+	}
+	hasty := 0
+	for _, perm := range winner.Battlefield {
+		if perm.GetName() == "Dualcaster Mage" && perm.HasKeyword(game.KWHaste) {
+			hasty++
+		}
+	}
+	if hasty*2 < loser.GetLifeTotal() {
+		t.Fatalf("expected enough hasty Dualcaster tokens to swing for lethal, got %d", hasty)
+	}
+	if g.SpellsCastThisTurnBy(winner) != 2 {
+		t.Fatalf("copies shouldn't count as cast spells, got %d", g.SpellsCastThisTurnBy(winner))
+	}
+}
+
+func TestCEDHComboFinish_GrapeshotStorm(t *testing.T) {
+	winner := game.NewEDHPlayer("Storm")
+	loser := game.NewEDHPlayer("Opponent")
+	loser.SetLifeTotal(5)
+	winner.Hand = []game.SimpleCard{{Name: "Grapeshot", TypeLine: "Sorcery", ManaCost: "{1}{R}", OracleText: "Grapeshot deals 1 damage to any target.\nStorm (When you cast this spell, copy it for each spell cast before it this turn. You may choose new targets for the copies.)"}}
+	winner.AddManaToPool(game.Red, 2)
+	g := game.NewGame(winner, loser)
+	for i := 0; i < 4; i++ {
+		g.RecordSpellCast(winner)
+	}
+
+	if !attemptCEDHComboFinish(g, winner, nil, nil, nil, nil) {
+		t.Fatal("expected Grapeshot with storm 4 to finish the game")
+	}
+	if !loser.HasLost() {
+		t.Fatalf("expected opponent to lose, life=%d", loser.GetLifeTotal())
+	}
+}
+
+func TestCEDHComboFinish_BreachBrainFreeze(t *testing.T) {
+	winner := game.NewEDHPlayer("Breach")
+	loser := game.NewEDHPlayer("Opponent")
+	for i := 0; i < 20; i++ {
+		loser.Library = append(loser.Library, game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"})
+	}
+	for i := 0; i < 12; i++ {
+		winner.Graveyard = append(winner.Graveyard, game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"})
+	}
+	winner.Hand = []game.SimpleCard{
+		{Name: "Underworld Breach", TypeLine: "Enchantment", ManaCost: "{1}{R}", OracleText: "Each nonland card in your graveyard has escape. The escape cost is equal to the card's mana cost plus exile three other cards from your graveyard.\nAt the beginning of the end step, sacrifice Underworld Breach."},
+		{Name: "Brain Freeze", TypeLine: "Instant", ManaCost: "{1}{U}", OracleText: "Target player mills three cards.\nStorm (When you cast this spell, copy it for each spell cast before it this turn. You may choose new targets for the copies.)"},
+	}
+	winner.AddManaToPool(game.Red, 1)
+	winner.AddManaToPool(game.Blue, 7)
+	g := game.NewGame(winner, loser)
+
+	if !attemptCEDHComboFinish(g, winner, nil, nil, nil, nil) {
+		t.Fatal("expected Breach/Brain Freeze to mill the opponent out")
+	}
+	if len(loser.Library) != 0 {
+		t.Fatalf("expected opponent's library to be milled, %d cards left", len(loser.Library))
+	}
+}
+
 func TestSimulateEDHGame_ComboEndsBeforeTurnTen(t *testing.T) {
 	library := make([]game.SimpleCard, 0, 30)
 	for i := 0; i < 12; i++ {
@@ -200,7 +280,7 @@ func TestRunMainPhase_TapsLandsAndPaysCreatureCosts(t *testing.T) {
 	p1.AddCardToHand(game.SimpleCard{Name: "Elk", TypeLine: "Creature", Power: "3", Toughness: "3", ManaCost: "{2}{G}"})
 
 	m := newEDHMetrics(2)
-	runMainPhase(g, p1, []int{0, 0}, nil, m, nil)
+	runMainPhase(g, p1, DefaultAgent{}, []int{0, 0}, nil, m, nil)
 
 	if len(p1.GetLands()) != 1 || !p1.GetLands()[0].IsTapped() {
 		t.Fatalf("expected Forest to be played and tapped for mana")
@@ -227,7 +307,7 @@ func TestRunMainPhase_CastsManaRockAndUsesItForPermanent(t *testing.T) {
 
 	log := NewEDHEventLog()
 	m := newEDHMetrics(2)
-	runMainPhase(g, p1, []int{0, 0}, log, m, nil)
+	runMainPhase(g, p1, DefaultAgent{}, []int{0, 0}, log, m, nil)
 
 	if p1.FindCardInHand("Sol Ring") >= 0 || p1.FindCardInHand("Steel Golem") >= 0 {
 		t.Fatalf("expected Sol Ring and Steel Golem to be cast; hand=%+v", p1.Hand)
@@ -248,3 +328,39 @@ func TestRunMainPhase_CastsManaRockAndUsesItForPermanent(t *testing.T) {
 		t.Fatalf("expected permanent then creature events, got %+v", events)
 	}
 }
+
+// fakeComboFinder finds the battery-sink loop in any deck holding both
+// pieces, and fails for a deck named in fail.
+type fakeComboFinder struct{ fail string }
+
+func (f fakeComboFinder) FindMyCombos(deckCards, commanders []string) (*combo.FindMyCombosResult, error) {
+	for _, name := range deckCards {
+		if name == f.fail {
+			return nil, errors.New("lookup failed")
+		}
+	}
+	return &combo.FindMyCombosResult{Included: []combo.Variant{
+		knownVariant("battery-sink", "Infinite damage", "", knownPiece("Mana Battery"), knownPiece("Battery Sink")),
+	}}, nil
+}
+
+func TestLoadSeatCombos_IndexesEachDeck(t *testing.T) {
+	battery := game.SimpleCard{Name: "Mana Battery", TypeLine: "Artifact"}
+	sink := game.SimpleCard{Name: "Battery Sink", TypeLine: "Artifact"}
+	seats := []EDHSeat{
+		{DeckName: "Battery", Library: []game.SimpleCard{battery, sink, testIsland, testIsland}},
+		{DeckName: "Broken", Library: []game.SimpleCard{{Name: "Unfindable"}}},
+	}
+	if n := LoadSeatCombos(seats, fakeComboFinder{fail: "Unfindable"}); n != 1 {
+		t.Fatalf("expected one index built, got %d", n)
+	}
+	if seats[0].Combos == nil || len(seats[0].Combos.Variants) != 1 {
+		t.Fatalf("expected the battery-sink line indexed, got %+v", seats[0].Combos)
+	}
+	if seats[1].Combos != nil {
+		t.Fatal("a failed lookup leaves the seat without an index")
+	}
+	if f := handFeatures([]game.SimpleCard{battery, sink}, nil, seats[0].Combos); f.ComboPieces != 2 {
+		t.Fatalf("the hand should count its combo pieces, got %d", f.ComboPieces)
+	}
+}
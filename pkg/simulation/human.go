@@ -0,0 +1,539 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Human players. A HumanAgent sits in a seat like any other Agent, but
+// puts each decision the runner asks of it to a Chooser: the legal
+// answers, the table as the player sees it (game.PlayerView) and what the
+// event log recorded since the player last chose. The other seats stay
+// with their own agents, so a person can replay a line the AI misplays.
+
+// DecisionKind names what a Decision asks for.
+type DecisionKind string
+
+const (
+	DecisionMulligan     DecisionKind = "mulligan"
+	DecisionLand         DecisionKind = "land"
+	DecisionCast         DecisionKind = "cast"
+	DecisionTarget       DecisionKind = "target"
+	DecisionModes        DecisionKind = "modes"
+	DecisionAttackTarget DecisionKind = "attack_target"
+	DecisionAttackers    DecisionKind = "attackers"
+	DecisionBlockers     DecisionKind = "blockers"
+	DecisionRespond      DecisionKind = "respond"
+	DecisionDiscard      DecisionKind = "discard"
+	DecisionSearch       DecisionKind = "search"
+	DecisionTriggerOrder DecisionKind = "trigger_order"
+	DecisionLoop         DecisionKind = "loop"
+)
+
+// Decision is one choice put to a human player.
+type Decision struct {
+	Kind   DecisionKind `json:"kind"`
+	Player string       `json:"player"`
+	Prompt string       `json:"prompt"`
+	// Options are the legal answers. An answer lists between Min and Max
+	// distinct indices into Options; a trigger order lists every index.
+	// A DecisionLoop has no options and is answered with one iteration
+	// count from 0 to Max.
+	Options []string `json:"options,omitempty"`
+	Min     int      `json:"min"`
+	Max     int      `json:"max"`
+	// Table is the game as the player sees it.
+	Table TableView `json:"table"`
+	// Events are what the event log recorded since the player's last
+	// decision.
+	Events []EDHEvent `json:"events,omitempty"`
+}
+
+// Valid reports whether answer is a legal answer to d.
+func (d Decision) Valid(answer []int) bool {
+	if d.Kind == DecisionLoop {
+		return len(answer) == 1 && answer[0] >= 0 && answer[0] <= d.Max
+	}
+	return len(answer) >= d.Min && validIndices(answer, len(d.Options), d.Max)
+}
+
+// TableView is the public game state from one seat: every player's
+// public zones and hidden zone sizes, the stack, and the viewer's hand.
+type TableView struct {
+	Turn    int        `json:"turn"`
+	Phase   string     `json:"phase"`
+	Active  string     `json:"active"`
+	Stack   []string   `json:"stack,omitempty"`
+	Players []SeatView `json:"players"`
+	Hand    []string   `json:"hand"`
+}
+
+// SeatView is what a TableView shows of one player.
+type SeatView struct {
+	Name        string         `json:"name"`
+	Life        int            `json:"life"`
+	Lost        bool           `json:"lost,omitempty"`
+	HandSize    int            `json:"hand_size"`
+	LibrarySize int            `json:"library_size"`
+	ManaPool    map[string]int `json:"mana_pool,omitempty"`
+	Battlefield []string       `json:"battlefield,omitempty"`
+	Graveyard   []string       `json:"graveyard,omitempty"`
+	Exile       []string       `json:"exile,omitempty"`
+	CommandZone []string       `json:"command_zone,omitempty"`
+	// KnownHand is the part of an opponent's hand the viewer has seen.
+	KnownHand []string `json:"known_hand,omitempty"`
+}
+
+// tableView reads the table from p's seat. g is nil before the game
+// starts, when only p's hand is known; stack may be nil.
+func tableView(g *game.Game, p *game.Player, stack *abil.Stack) TableView {
+	t := TableView{Hand: cardNames(p.Hand)}
+	if g == nil {
+		return t
+	}
+	t.Turn, t.Phase = g.GetTurnNumber(), phaseName(g.GetCurrentPhase())
+	if ap := g.GetActivePlayerRaw(); ap != nil {
+		t.Active = ap.GetName()
+	}
+	if stack != nil {
+		items := stack.GetItems()
+		for i := len(items) - 1; i >= 0; i-- {
+			t.Stack = append(t.Stack, stackItemLabel(items[i]))
+		}
+	}
+	view := g.ViewFor(p)
+	for _, pl := range g.GetPlayersRaw() {
+		info := view.Of(pl)
+		s := SeatView{
+			Name: info.Name, Life: info.Life, Lost: info.Lost,
+			HandSize: info.HandSize, LibrarySize: info.LibrarySize,
+			Graveyard: cardNames(info.Graveyard), Exile: cardNames(info.Exile),
+			CommandZone: cardNames(info.CommandZone),
+		}
+		for mt, n := range info.ManaPool {
+			if n > 0 {
+				if s.ManaPool == nil {
+					s.ManaPool = map[string]int{}
+				}
+				s.ManaPool[string(mt)] = n
+			}
+		}
+		for _, perm := range info.Battlefield {
+			s.Battlefield = append(s.Battlefield, permanentLabel(perm))
+		}
+		if pl != p {
+			s.KnownHand = cardNames(info.KnownHand)
+		}
+		t.Players = append(t.Players, s)
+	}
+	return t
+}
+
+func cardNames(cards []game.SimpleCard) []string {
+	out := make([]string, len(cards))
+	for i, c := range cards {
+		out[i] = c.Name
+	}
+	return out
+}
+
+// permanentLabel names perm with its power and toughness, counters and
+// tapped state, e.g. "Grizzly Bears 2/2 (tapped)".
+func permanentLabel(perm *game.Permanent) string {
+	label := perm.GetName()
+	if perm.IsCreature() {
+		label += fmt.Sprintf(" %d/%d", perm.GetPower(), perm.GetToughness())
+	}
+	counters := perm.Counters()
+	kinds := make([]string, 0, len(counters))
+	for kind, n := range counters {
+		if n > 0 {
+			kinds = append(kinds, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	sort.Strings(kinds)
+	if len(kinds) > 0 {
+		label += " [" + strings.Join(kinds, ", ") + "]"
+	}
+	if perm.IsTapped() {
+		label += " (tapped)"
+	}
+	return label
+}
+
+func stackItemLabel(item *abil.StackItem) string {
+	label := stackItemSource(item).Name
+	if item.Type == abil.StackItemAbility {
+		label += " ability"
+	}
+	if item.Controller != nil {
+		label += " (" + item.Controller.GetName() + ")"
+	}
+	return label
+}
+
+// targetLabel names a player or permanent target.
+func targetLabel(target any) string {
+	named, ok := target.(interface{ GetName() string })
+	if !ok {
+		return fmt.Sprint(target)
+	}
+	if _, player := target.(abil.AbilityPlayer); player {
+		return "player " + named.GetName()
+	}
+	return named.GetName()
+}
+
+// Chooser answers the decisions a HumanAgent puts to its player, e.g. a
+// terminal prompt (TerminalChooser) or a dashboard client. A nil answer,
+// or one Decision doesn't allow, leaves the choice to DefaultAgent; an
+// empty one chooses nothing.
+type Chooser interface {
+	Choose(d Decision) []int
+}
+
+// HumanAgent is a seat a person plays through Chooser. Decisions with a
+// single forced answer are made without asking. The embedded
+// DefaultAgent makes every choice the player leaves to the AI.
+type HumanAgent struct {
+	DefaultAgent
+	Chooser Chooser
+
+	log   *EDHEventLog
+	stack *abil.Stack
+	seen  int
+}
+
+// NewHumanAgent returns a HumanAgent asking c.
+func NewHumanAgent(c Chooser) *HumanAgent {
+	return &HumanAgent{Chooser: c}
+}
+
+// watchTable lets the agent show the pod's event log and stack; the
+// runner calls it once both exist.
+func (a *HumanAgent) watchTable(log *EDHEventLog, stack *abil.Stack) {
+	a.log, a.stack = log, stack
+}
+
+// ask puts d to the player of seat p and reports whether the answer is
+// one d allows.
+func (a *HumanAgent) ask(g *game.Game, p *game.Player, d Decision) ([]int, bool) {
+	if len(d.Options) == 1 && d.Min == 1 {
+		return []int{0}, true
+	}
+	d.Player = p.GetName()
+	d.Table = tableView(g, p, a.stack)
+	if a.log != nil {
+		events := a.log.Events()
+		if a.seen < len(events) {
+			d.Events = events[a.seen:]
+		}
+		a.seen = len(events)
+	}
+	answer := a.Chooser.Choose(d)
+	if answer == nil {
+		return nil, false
+	}
+	return answer, d.Valid(answer)
+}
+
+func (a *HumanAgent) KeepHand(p *game.Player, commanders []game.SimpleCard, seat, mulligans int) bool {
+	prompt := fmt.Sprintf("Keep this hand? (%d mulligans taken)", mulligans)
+	answer, ok := a.ask(nil, p, Decision{Kind: DecisionMulligan, Prompt: prompt, Options: []string{"Keep", "Mulligan"}, Min: 1, Max: 1})
+	if !ok {
+		return a.DefaultAgent.KeepHand(p, commanders, seat, mulligans)
+	}
+	return answer[0] == 0
+}
+
+func (a *HumanAgent) ChooseLand(g *game.Game, p *game.Player) (game.SimpleCard, bool) {
+	var lands []game.SimpleCard
+	for _, c := range p.Hand {
+		if c.IsLand() {
+			lands = append(lands, c)
+		}
+	}
+	if len(lands) == 0 {
+		return game.SimpleCard{}, false
+	}
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionLand, Prompt: "Play a land (none to skip)", Options: cardNames(lands), Max: 1})
+	if !ok {
+		return a.DefaultAgent.ChooseLand(g, p)
+	}
+	if len(answer) == 0 {
+		return game.SimpleCard{}, false
+	}
+	return lands[answer[0]], true
+}
+
+func (a *HumanAgent) ChooseSpell(g *game.Game, p *game.Player, castable []game.SimpleCard) (game.SimpleCard, bool) {
+	if len(castable) == 0 {
+		return game.SimpleCard{}, false
+	}
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionCast, Prompt: "Cast a spell (none to stop casting)", Options: cardNames(castable), Max: 1})
+	if !ok {
+		return a.DefaultAgent.ChooseSpell(g, p, castable)
+	}
+	if len(answer) == 0 {
+		return game.SimpleCard{}, false
+	}
+	return castable[answer[0]], true
+}
+
+// ChooseTargets asks for each required target of ability among the
+// players and permanents that can legally be chosen.
+func (a *HumanAgent) ChooseTargets(w *PriorityWindow, ability *abil.Ability) []any {
+	p := w.h.livePlayer(w.Player.GetName())
+	if p == nil {
+		return a.DefaultAgent.ChooseTargets(w, ability)
+	}
+	var targets []any
+	for _, effect := range ability.Effects {
+		for _, req := range effect.Targets {
+			if !req.Required {
+				continue
+			}
+			legal := w.AI.LegalTargetsFor(req, w.Context)
+			options := make([]string, len(legal))
+			for i, t := range legal {
+				options[i] = targetLabel(t)
+			}
+			prompt := fmt.Sprintf("Choose a target for %s", ability.Name)
+			answer, ok := a.ask(w.Game, p, Decision{Kind: DecisionTarget, Prompt: prompt, Options: options, Min: 1, Max: 1})
+			if !ok {
+				return a.DefaultAgent.ChooseTargets(w, ability)
+			}
+			targets = append(targets, legal[answer[0]])
+		}
+	}
+	return targets
+}
+
+// ChooseModes asks for Value modes, or at least one for "any number".
+func (a *HumanAgent) ChooseModes(w *PriorityWindow, effect abil.Effect) []int {
+	p := w.h.livePlayer(w.Player.GetName())
+	if p == nil {
+		return a.DefaultAgent.ChooseModes(w, effect)
+	}
+	lo, hi := effect.Value, effect.Value
+	if lo <= 0 || lo > len(effect.Modes) {
+		lo, hi = 1, len(effect.Modes)
+	}
+	options := make([]string, len(effect.Modes))
+	for i, mode := range effect.Modes {
+		options[i] = mode.Description
+		if options[i] == "" {
+			options[i] = fmt.Sprintf("mode %d", i+1)
+		}
+	}
+	answer, ok := a.ask(w.Game, p, Decision{Kind: DecisionModes, Prompt: "Choose modes", Options: options, Min: lo, Max: hi})
+	if !ok {
+		return a.DefaultAgent.ChooseModes(w, effect)
+	}
+	return answer
+}
+
+func (a *HumanAgent) ChooseAttackTarget(g *game.Game, p *game.Player) *game.Player {
+	opponents := g.ViewFor(p).Opponents()
+	options := make([]string, len(opponents))
+	for i, opp := range opponents {
+		options[i] = opp.GetName()
+	}
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionAttackTarget, Prompt: "Attack which opponent? (none to skip combat)", Options: options, Max: 1})
+	if !ok {
+		return a.DefaultAgent.ChooseAttackTarget(g, p)
+	}
+	if len(answer) == 0 {
+		return nil
+	}
+	return opponents[answer[0]]
+}
+
+// ChooseAttackers offers every creature that can attack against every
+// opponent; each creature may be picked once.
+func (a *HumanAgent) ChooseAttackers(g *game.Game, p, defender *game.Player) map[*game.Permanent]*game.Player {
+	type attack struct {
+		creature *game.Permanent
+		target   *game.Player
+	}
+	var attacks []attack
+	var options []string
+	for _, perm := range p.GetCreatures() {
+		if !canAttackWith(g, perm) {
+			continue
+		}
+		for _, opp := range g.ViewFor(p).Opponents() {
+			attacks = append(attacks, attack{perm, opp})
+			options = append(options, fmt.Sprintf("%s → %s", permanentLabel(perm), opp.GetName()))
+		}
+	}
+	if len(attacks) == 0 {
+		return nil
+	}
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionAttackers, Prompt: "Declare attackers (none to attack with nothing)", Options: options, Max: len(options)})
+	if !ok {
+		return a.DefaultAgent.ChooseAttackers(g, p, defender)
+	}
+	plan := map[*game.Permanent]*game.Player{}
+	for _, i := range answer {
+		if _, twice := plan[attacks[i].creature]; twice {
+			return a.DefaultAgent.ChooseAttackers(g, p, defender)
+		}
+		plan[attacks[i].creature] = attacks[i].target
+	}
+	return plan
+}
+
+// DeclareBlockers offers every untapped creature of defender against
+// every creature attacking them; each blocker may be picked once.
+func (a *HumanAgent) DeclareBlockers(g *game.Game, defender *game.Player) {
+	incoming := attackersOn(g, defender)
+	type block struct{ blocker, attacker *game.Permanent }
+	var blocks []block
+	var options []string
+	for _, b := range defender.GetCreatures() {
+		if b.IsTapped() || b.CantBlock() {
+			continue
+		}
+		for _, at := range incoming {
+			blocks = append(blocks, block{b, at})
+			options = append(options, fmt.Sprintf("%s blocks %s", permanentLabel(b), permanentLabel(at)))
+		}
+	}
+	if len(blocks) == 0 {
+		return
+	}
+	answer, ok := a.ask(g, defender, Decision{Kind: DecisionBlockers, Prompt: "Declare blockers (none to take the damage)", Options: options, Max: len(options)})
+	used := map[*game.Permanent]bool{}
+	for _, i := range answer {
+		if !ok {
+			break
+		}
+		ok = !used[blocks[i].blocker]
+		used[blocks[i].blocker] = true
+	}
+	if !ok {
+		a.DefaultAgent.DeclareBlockers(g, defender)
+		return
+	}
+	for _, i := range answer {
+		_ = g.DeclareBlocker(blocks[i].blocker, blocks[i].attacker)
+	}
+}
+
+// Respond offers the instants in hand the player can pay for, with
+// counterspells aimed at the top of the stack, and the abilities they can
+// activate. With nothing to offer it passes without asking.
+func (a *HumanAgent) Respond(w *PriorityWindow) *abil.PriorityDecision {
+	p := w.h.livePlayer(w.Player.GetName())
+	if p == nil || w.Stack.SplitSecondActive() {
+		return nil
+	}
+	top := w.Stack.Peek()
+	if top != nil && (top.Countered || top.CantBeCountered() || top.Controller.GetName() == p.GetName()) {
+		top = nil
+	}
+	var options []string
+	var actions []func() *abil.PriorityDecision
+	for _, c := range p.Hand {
+		if !c.IsInstant() || !canPayWithOpenMana(w.Game, p, c) {
+			continue
+		}
+		c := c
+		if c.IsCounterspell() || CountersAbilities(c) {
+			if top == nil {
+				continue
+			}
+			options = append(options, fmt.Sprintf("Cast %s targeting %s", c.Name, stackItemLabel(top)))
+			actions = append(actions, func() *abil.PriorityDecision { return w.h.castResponse(w.Player, p, c, top) })
+			continue
+		}
+		options = append(options, "Cast "+c.Name)
+		actions = append(actions, func() *abil.PriorityDecision { return w.h.castResponse(w.Player, p, c, nil) })
+	}
+	for _, ab := range w.h.engine.GetActivatableAbilities(w.Player) {
+		ab := ab
+		label := ab.OracleText
+		if label == "" {
+			label = ab.Name
+		}
+		options = append(options, "Activate "+label)
+		actions = append(actions, func() *abil.PriorityDecision {
+			return &abil.PriorityDecision{Action: abil.PriorityActionActivateAbility, Ability: ab, Targets: agentTargets(a, w, ab), Player: w.Player}
+		})
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	answer, ok := a.ask(w.Game, p, Decision{Kind: DecisionRespond, Prompt: "You have priority (none to pass)", Options: options, Max: 1})
+	if !ok {
+		return a.DefaultAgent.Respond(w)
+	}
+	if len(answer) == 0 {
+		return nil
+	}
+	return actions[answer[0]]()
+}
+
+func (a *HumanAgent) ChooseDiscard(g *game.Game, p *game.Player, n int) []int {
+	n = min(n, len(p.Hand))
+	prompt := fmt.Sprintf("Discard %d card(s)", n)
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionDiscard, Prompt: prompt, Options: cardNames(p.Hand), Min: n, Max: n})
+	if !ok {
+		return a.DefaultAgent.ChooseDiscard(g, p, n)
+	}
+	return answer
+}
+
+func (a *HumanAgent) ChooseSearch(g *game.Game, p *game.Player, candidates []game.SimpleCard, max int) []int {
+	if len(candidates) == 0 {
+		return nil
+	}
+	prompt := fmt.Sprintf("Search your library for up to %d card(s)", max)
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionSearch, Prompt: prompt, Options: cardNames(candidates), Max: max})
+	if !ok {
+		return a.DefaultAgent.ChooseSearch(g, p, candidates, max)
+	}
+	return answer
+}
+
+func (a *HumanAgent) OrderTriggers(g *game.Game, p *game.Player, triggers []game.PendingTrigger) []int {
+	if len(triggers) < 2 {
+		return a.DefaultAgent.OrderTriggers(g, p, triggers)
+	}
+	options := make([]string, len(triggers))
+	for i, t := range triggers {
+		options[i] = triggerLabel(t)
+	}
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionTriggerOrder, Prompt: "Order your triggers (first goes on the stack first)", Options: options, Min: len(options), Max: len(options)})
+	if !ok {
+		return a.DefaultAgent.OrderTriggers(g, p, triggers)
+	}
+	return answer
+}
+
+func triggerLabel(t game.PendingTrigger) string {
+	zc := t.Event.ZoneChange
+	if zc == nil {
+		return "trigger"
+	}
+	name := zc.Card.Name
+	if zc.Permanent != nil {
+		name = zc.Permanent.GetName()
+	}
+	return fmt.Sprintf("%s (%s → %s)", name, zc.From, zc.To)
+}
+
+func (a *HumanAgent) ChooseLoopIterations(g *game.Game, p *game.Player, loop Loop) int {
+	finish := a.DefaultAgent.ChooseLoopIterations(g, p, loop)
+	prompt := fmt.Sprintf("Repeat the loop (%s) how many more times? %d finishes it", loop, finish)
+	answer, ok := a.ask(g, p, Decision{Kind: DecisionLoop, Prompt: prompt, Min: 1, Max: loopIterationCap})
+	if !ok {
+		return finish
+	}
+	return answer[0]
+}
@@ -0,0 +1,136 @@
+package simulation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TerminalChooser puts a HumanAgent's decisions to a person at a
+// terminal. Each decision prints the table, the events since the last
+// one and the numbered options, then reads one line: option numbers
+// separated by spaces or commas (or the count, for a loop), "-" to
+// choose nothing, "?" to show the table again, or a blank line to leave
+// the choice to the AI. The AI also takes over once the input ends.
+type TerminalChooser struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// NewTerminalChooser reads answers from in and writes prompts to out.
+func NewTerminalChooser(in io.Reader, out io.Writer) *TerminalChooser {
+	return &TerminalChooser{in: bufio.NewScanner(in), out: out}
+}
+
+func (t *TerminalChooser) Choose(d Decision) []int {
+	writeTable(t.out, d)
+	writeEvents(t.out, d.Events)
+	writeOptions(t.out, d)
+	for {
+		fmt.Fprint(t.out, "> ")
+		if !t.in.Scan() {
+			return nil
+		}
+		line := strings.TrimSpace(t.in.Text())
+		if line == "?" {
+			writeTable(t.out, d)
+			writeOptions(t.out, d)
+			continue
+		}
+		answer, err := parseAnswer(line, d)
+		if err == nil {
+			return answer
+		}
+		fmt.Fprintln(t.out, err)
+	}
+}
+
+// parseAnswer reads a terminal answer to d; see TerminalChooser.
+func parseAnswer(line string, d Decision) ([]int, error) {
+	switch line {
+	case "":
+		return nil, nil
+	case "-":
+		if d.Min > 0 {
+			return nil, fmt.Errorf("choose at least %d", d.Min)
+		}
+		return []int{}, nil
+	}
+	var answer []int
+	for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == ',' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", field)
+		}
+		if d.Kind != DecisionLoop {
+			n--
+		}
+		answer = append(answer, n)
+	}
+	if !d.Valid(answer) {
+		if d.Kind == DecisionLoop {
+			return nil, fmt.Errorf("enter one count from 0 to %d", d.Max)
+		}
+		return nil, fmt.Errorf("choose %d to %d different options from 1 to %d", d.Min, d.Max, len(d.Options))
+	}
+	return answer, nil
+}
+
+func writeTable(w io.Writer, d Decision) {
+	t := d.Table
+	if t.Turn > 0 {
+		fmt.Fprintf(w, "\n== Turn %d, %s, %s's turn ==\n", t.Turn, t.Phase, t.Active)
+	}
+	if len(t.Stack) > 0 {
+		fmt.Fprintf(w, "Stack (top first): %s\n", strings.Join(t.Stack, ", "))
+	}
+	for _, s := range t.Players {
+		status := fmt.Sprintf("%d life", s.Life)
+		if s.Lost {
+			status = "lost"
+		}
+		fmt.Fprintf(w, "%s: %s, %d in hand, %d in library\n", s.Name, status, s.HandSize, s.LibrarySize)
+		writeZone(w, "command zone", s.CommandZone)
+		writeZone(w, "battlefield", s.Battlefield)
+		writeZone(w, "graveyard", s.Graveyard)
+		writeZone(w, "exile", s.Exile)
+		writeZone(w, "known hand", s.KnownHand)
+		if len(s.ManaPool) > 0 {
+			fmt.Fprintf(w, "  mana pool: %v\n", s.ManaPool)
+		}
+	}
+	fmt.Fprintf(w, "Your hand: %s\n", strings.Join(t.Hand, ", "))
+}
+
+func writeZone(w io.Writer, zone string, cards []string) {
+	if len(cards) > 0 {
+		fmt.Fprintf(w, "  %s: %s\n", zone, strings.Join(cards, ", "))
+	}
+}
+
+func writeEvents(w io.Writer, events []EDHEvent) {
+	if len(events) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "-- since your last decision --")
+	for _, e := range events {
+		line := fmt.Sprintf("  t%d %s: %s %s", e.Turn, e.Phase, e.Actor, e.Kind)
+		if e.Detail != "" {
+			line += " " + e.Detail
+		}
+		if e.Target != "" {
+			line += " → " + e.Target
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func writeOptions(w io.Writer, d Decision) {
+	fmt.Fprintf(w, "[%s] %s\n", d.Player, d.Prompt)
+	for i, o := range d.Options {
+		fmt.Fprintf(w, "  %d) %s\n", i+1, o)
+	}
+	fmt.Fprintln(w, `(blank: let the AI decide, "-": none, "?": show the table)`)
+}
@@ -0,0 +1,135 @@
+package simulation
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// scriptedChooser answers the decisions it has an answer for and leaves
+// the rest to the AI, recording every decision put to it.
+type scriptedChooser struct {
+	answers map[DecisionKind]func(Decision) []int
+	asked   []Decision
+}
+
+func (c *scriptedChooser) Choose(d Decision) []int {
+	c.asked = append(c.asked, d)
+	if answer := c.answers[d.Kind]; answer != nil {
+		return answer(d)
+	}
+	return nil
+}
+
+func (c *scriptedChooser) kinds() map[DecisionKind]int {
+	out := map[DecisionKind]int{}
+	for _, d := range c.asked {
+		out[d.Kind]++
+	}
+	return out
+}
+
+func TestHumanAgent_PlaysAPodWithTheAIFillingIn(t *testing.T) {
+	chooser := &scriptedChooser{answers: map[DecisionKind]func(Decision) []int{
+		DecisionAttackTarget: func(Decision) []int { return []int{} },
+	}}
+	human := NewHumanAgent(chooser)
+	seats := []EDHSeat{
+		makeSeat("Aggro", "Mountain", "Goblin", "10", 8, nil),
+		makeSeat("Control", "Island", "Wall", "0", 4, nil),
+	}
+	seats[0].Agent = human
+
+	rec, err := SimulateEDHGame(EDHRunOptions{Seats: seats, MaxTurns: 8, RNG: rand.New(rand.NewSource(42)), RecordEvents: true})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	kinds := chooser.kinds()
+	if kinds[DecisionMulligan] == 0 || kinds[DecisionLand] == 0 || kinds[DecisionAttackTarget] == 0 {
+		t.Fatalf("expected mulligan, land and attack decisions, got %v", kinds)
+	}
+	for _, e := range rec.Events {
+		if e.Kind == EventAttackDeclared && e.Actor == "Aggro" {
+			t.Fatalf("the human declined every attack, but %+v", e)
+		}
+	}
+	last := chooser.asked[len(chooser.asked)-1]
+	if len(last.Table.Players) != 2 || last.Table.Turn == 0 || len(last.Events) == 0 {
+		t.Fatalf("decisions should show the table and the events since the last one, got %+v", last)
+	}
+	for _, s := range last.Table.Players {
+		if s.Name == "Control" && len(s.KnownHand) != 0 {
+			t.Errorf("the opponent's hidden hand leaked: %v", s.KnownHand)
+		}
+	}
+}
+
+func TestHumanAgent_CountersFromThePriorityWindow(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	opp := game.NewEDHPlayer("Opp")
+	g := game.NewGame(p, opp)
+	withLands(opp, 2)
+	opp.Hand = []game.SimpleCard{testCounterspell}
+	divination := game.SimpleCard{Name: "Divination", TypeLine: "Sorcery", ManaCost: "{2}{U}", OracleText: "Draw two cards."}
+	p.Hand = []game.SimpleCard{divination}
+	p.Library = []game.SimpleCard{testIsland, testIsland}
+
+	h := NewStackAwareHandler(g, nil)
+	chooser := &scriptedChooser{answers: map[DecisionKind]func(Decision) []int{
+		DecisionRespond: func(d Decision) []int {
+			for i, o := range d.Options {
+				if strings.HasPrefix(o, "Cast Counterspell") {
+					return []int{i}
+				}
+			}
+			return []int{}
+		},
+	}}
+	human := NewHumanAgent(chooser)
+	human.watchTable(nil, h.spellCasting.GetStack())
+	h.SetAgent("Opp", human)
+
+	if h.CastSpellThroughStack(p, divination, "P") {
+		t.Fatal("the human countered Divination")
+	}
+	if len(p.Hand) != 0 {
+		t.Fatalf("a countered Divination draws nothing, hand %v", p.Hand)
+	}
+	var respond *Decision
+	for i := range chooser.asked {
+		if chooser.asked[i].Kind == DecisionRespond {
+			respond = &chooser.asked[i]
+			break
+		}
+	}
+	if respond == nil || len(respond.Table.Stack) == 0 || respond.Table.Stack[0] != "Divination (P)" {
+		t.Fatalf("the human should see Divination on the stack, got %+v", respond)
+	}
+}
+
+func TestTerminalChooser_ReadsNumberedOptions(t *testing.T) {
+	var out strings.Builder
+	c := NewTerminalChooser(strings.NewReader("x\n4\n2 1\n"), &out)
+	d := Decision{Kind: DecisionSearch, Player: "P", Prompt: "Search", Options: []string{"A", "B", "C"}, Max: 2}
+	if got := c.Choose(d); len(got) != 2 || got[0] != 1 || got[1] != 0 {
+		t.Fatalf("expected options 2 and 1, got %v", got)
+	}
+	if !strings.Contains(out.String(), "  3) C") || strings.Count(out.String(), "choose 0 to 2") != 1 {
+		t.Fatalf("expected the options and one complaint about 4, got:\n%s", out.String())
+	}
+	if got := c.Choose(d); got != nil {
+		t.Fatalf("the AI takes over once input ends, got %v", got)
+	}
+
+	for line, want := range map[string][]int{"": nil, "-": {}, "3": {2}} {
+		got, err := parseAnswer(line, d)
+		if err != nil || len(got) != len(want) || (got == nil) != (want == nil) {
+			t.Errorf("parseAnswer(%q) = %v, %v, want %v", line, got, err, want)
+		}
+	}
+	if got, err := parseAnswer("7", Decision{Kind: DecisionLoop, Max: loopIterationCap}); err != nil || got[0] != 7 {
+		t.Errorf("a loop is answered with its count, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,341 @@
+package simulation
+
+import (
+	"sort"
+
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// The interaction planner decides how a seat uses mana at instant speed.
+// In its main phase a player leaves untapped the sources it needs for the
+// answers and instant-speed card draw it holds (holdUpMana); on other
+// players' turns it taps them to respond (payWithOpenMana). At each
+// priority window it answers a spell now, waits for a better window, or
+// leaves the spell to an opponent who acts later and looks able to
+// answer it (leaveToLaterOpponent). Instants that draw or tutor are cast
+// at the end of the previous opponent's turn (endOfTurnBefore).
+
+// holdUpThreat is the leading opponent's threat.Assessment.WinChance at
+// which a player keeps mana open for an answer even if that leaves
+// spells in hand uncast.
+const holdUpThreat = 0.25
+
+// openManaSources returns p's untapped permanents that can tap for mana
+// now, single-option sources first so flexible ones are kept for the
+// colors nothing else makes.
+func openManaSources(g *game.Game, p *game.Player) []*game.Permanent {
+	var out []*game.Permanent
+	for _, perm := range p.Battlefield {
+		if perm.IsTapped() || len(manaProductionOptions(perm.GetSource())) == 0 {
+			continue
+		}
+		if perm.IsCreature() && perm.GetEnteredTurn() == g.GetTurnNumber() && !perm.HasKeyword(game.KWHaste) {
+			continue
+		}
+		if !g.CanActivateAbilities(perm) {
+			continue
+		}
+		out = append(out, perm)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return len(manaProductionOptions(out[i].GetSource())) < len(manaProductionOptions(out[j].GetSource()))
+	})
+	return out
+}
+
+// openManaTap is one source planOpenMana taps and the mana it makes.
+type openManaTap struct {
+	perm     *game.Permanent
+	produced game.Mana
+}
+
+// planOpenMana picks the sources among open that, added to pool, pay
+// cost, tapping as few as it can. It returns false if all of them
+// together can't pay.
+func planOpenMana(pool map[game.ManaType]int, open []*game.Permanent, cost game.Mana) ([]openManaTap, bool) {
+	trial := game.NewManaPool()
+	for mt, n := range pool {
+		trial.Add(mt, n)
+	}
+	var taps []openManaTap
+	for _, perm := range open {
+		if trial.CanPay(cost) {
+			break
+		}
+		produced := chooseManaProduction(perm.GetSource(), unpaidMana(trial, cost))
+		if len(produced) == 0 {
+			continue
+		}
+		for mt, n := range produced {
+			trial.Add(mt, n)
+		}
+		taps = append(taps, openManaTap{perm: perm, produced: produced})
+	}
+	return taps, trial.CanPay(cost)
+}
+
+// unpaidMana returns the part of cost pool can't pay yet, colored
+// symbols first, as a demand for chooseManaProduction.
+func unpaidMana(pool *game.ManaPool, cost game.Mana) game.Mana {
+	need := game.Mana{}
+	left := 0
+	for _, mt := range []game.ManaType{game.White, game.Blue, game.Black, game.Red, game.Green, game.Colorless} {
+		if short := cost[mt] - pool.Get(mt); short > 0 {
+			need.Add(mt, short)
+		} else {
+			left -= short
+		}
+	}
+	need.Add(game.Any, cost[game.Any]-left)
+	return need
+}
+
+// payableCosts returns c's mana costs, cheapest first.
+func payableCosts(c game.SimpleCard) []game.Mana {
+	costs := c.GetAlternateCosts()
+	sort.SliceStable(costs, func(i, j int) bool { return costs[i].Total() < costs[j].Total() })
+	return costs
+}
+
+// canPayWithOpenMana reports whether p can pay for c from its pool and
+// untapped mana sources.
+func canPayWithOpenMana(g *game.Game, p *game.Player, c game.SimpleCard) bool {
+	if p.CanPayForCard(c) {
+		return true
+	}
+	open := openManaSources(g, p)
+	for _, cost := range payableCosts(c) {
+		if _, ok := planOpenMana(p.GetManaPool(), open, cost); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// payWithOpenMana pays for c, tapping p's untapped mana sources for
+// whatever its pool lacks. Nothing is tapped if p can't pay.
+func payWithOpenMana(g *game.Game, p *game.Player, c game.SimpleCard) bool {
+	if p.PayForCard(c) {
+		return true
+	}
+	open := openManaSources(g, p)
+	for _, cost := range payableCosts(c) {
+		taps, ok := planOpenMana(p.GetManaPool(), open, cost)
+		if !ok {
+			continue
+		}
+		for _, t := range taps {
+			t.perm.Tap()
+			for mt, n := range t.produced {
+				p.AddManaToPool(mt, n)
+			}
+		}
+		return p.PayForCard(c)
+	}
+	return false
+}
+
+// isAnswer reports whether c is an instant p holds up mana for:
+// counterspells, Stifle effects and instant-speed removal.
+func isAnswer(c game.SimpleCard) bool {
+	if !c.IsInstant() {
+		return false
+	}
+	return c.IsCounterspell() || CountersAbilities(c) || card.ClassifyRoles(c).Has(card.RoleRemoval)
+}
+
+// heldForEndOfTurn reports whether c is an instant that draws or tutors,
+// which the planner casts at the end of the previous opponent's turn
+// rather than in its own main phase.
+func heldForEndOfTurn(c game.SimpleCard) bool {
+	if !c.IsInstant() || isAnswer(c) {
+		return false
+	}
+	return card.ClassifyRoles(c).HasAny(card.RoleCardDraw, card.RoleTutor)
+}
+
+// holdUpMana is the mana p leaves open at the end of its main phase: the
+// instants it holds for the end of the previous opponent's turn, and the
+// cheapest answer in hand when an opponent's win chance reaches
+// style.HoldUpThreat or, if style.HoldSpare, when p can still cast the
+// rest of its hand. Mana the answer doesn't use goes
+// to those instants, so the two overlap rather than add up. A player with
+// a combo assembled holds nothing back.
+func holdUpMana(g *game.Game, p *game.Player, style Style) game.Mana {
+	hold := game.Mana{}
+	if g == nil || comboAssembled(knownCombos, accessibleCardNames(p)) {
+		return hold
+	}
+	var answer game.Mana
+	for _, c := range p.Hand {
+		switch {
+		case heldForEndOfTurn(c):
+			for mt, n := range c.GetMinManaCost() {
+				hold.Add(mt, n)
+			}
+		case isAnswer(c):
+			if cost := c.GetMinManaCost(); answer == nil || cost.Total() < answer.Total() {
+				answer = cost
+			}
+		}
+	}
+	if answer == nil {
+		return hold
+	}
+	danger := 0.0
+	if ranked := rankThreats(g, p); len(ranked) > 0 {
+		danger = ranked[0].WinChance
+	}
+	spare := len(openManaSources(g, p)) - mainPhaseManaNeed(p)
+	for _, n := range p.GetManaPool() {
+		spare += n
+	}
+	if danger >= style.HoldUpThreat || (style.HoldSpare && spare >= answer.Total()) {
+		hold = manaUnion(hold, answer)
+	}
+	return hold
+}
+
+// manaUnion returns the mana that pays for either a or b.
+func manaUnion(a, b game.Mana) game.Mana {
+	out := game.Mana{}
+	for mt, n := range a {
+		out[mt] = n
+	}
+	for mt, n := range b {
+		if n > out[mt] {
+			out[mt] = n
+		}
+	}
+	return out
+}
+
+// mainPhaseManaNeed is the mana the spells p would cast in its main
+// phase cost: its commander and the spells in hand it neither holds up
+// nor holds for the end of turn.
+func mainPhaseManaNeed(p *game.Player) int {
+	need := 0
+	for _, c := range p.CommandZone {
+		need += c.GetManaCost().Total() + p.CommanderTax(c.Name)
+	}
+	for _, c := range p.Hand {
+		if isCastableSpell(c) && !isAnswer(c) && !heldForEndOfTurn(c) {
+			need += c.GetMinManaCost().Total()
+		}
+	}
+	return need
+}
+
+// manaSourcesToKeepOpen returns the untapped sources p leaves untapped
+// in its main phase to have holdUpMana available on other turns.
+func manaSourcesToKeepOpen(g *game.Game, p *game.Player, style Style) map[*game.Permanent]bool {
+	hold := holdUpMana(g, p, style)
+	if hold.Total() == 0 {
+		return nil
+	}
+	taps, _ := planOpenMana(nil, openManaSources(g, p), hold)
+	keep := make(map[*game.Permanent]bool, len(taps))
+	for _, t := range taps {
+		keep[t.perm] = true
+	}
+	return keep
+}
+
+// endOfTurnBefore reports whether it is the end step of the turn just
+// before p's, when p spends mana it held up: it untaps next.
+func endOfTurnBefore(g *game.Game, p *game.Player) bool {
+	if g.GetCurrentPhase() != game.PhaseEnd {
+		return false
+	}
+	players := g.GetPlayersRaw()
+	n := len(players)
+	start := indexOfPlayer(g, g.GetActivePlayerRaw())
+	for i := 1; i < n; i++ {
+		next := players[(start+i)%n]
+		if !next.HasLost() {
+			return next == p
+		}
+	}
+	return false
+}
+
+// mustAnswer reports whether responder can't risk letting source, the
+// card behind the spell or ability on the stack, resolve: combo pieces,
+// win conditions and tutors, anything that isn't a spell, and anything
+// from a caster likely to win next turn.
+func mustAnswer(g *game.Game, responder, caster *game.Player, top *abil.StackItem, source game.SimpleCard) bool {
+	if top.Type != abil.StackItemSpell || isHighPriorityCounterTarget(source) || isTutorEffect(source) {
+		return true
+	}
+	if caster == nil {
+		return true
+	}
+	for _, a := range rankThreats(g, responder) {
+		if a.Player == caster {
+			return a.WinChance >= counterWinChance
+		}
+	}
+	return true
+}
+
+// leaveToLaterOpponent returns an opponent of caster who gets priority
+// after responder this round and looks able to answer the spell: the
+// view knows an answer in their hand they can pay for, or they show two
+// untapped blue sources with cards in hand. It returns nil if responder
+// should answer it itself.
+func leaveToLaterOpponent(g *game.Game, responder, caster *game.Player) *game.Player {
+	if caster == nil {
+		return nil
+	}
+	view := g.ViewFor(responder)
+	players := g.GetPlayersRaw()
+	n := len(players)
+	start := indexOfPlayer(g, responder)
+	for i := 1; i < n; i++ {
+		o := players[(start+i)%n]
+		if o == g.GetActivePlayerRaw() {
+			break
+		}
+		if o == caster || o.HasLost() {
+			continue
+		}
+		if view.KnowsInHand(o, func(c game.SimpleCard) bool {
+			return c.IsCounterspell() && canPayWithOpenMana(g, o, c)
+		}) {
+			return o
+		}
+		blue := 0
+		for _, perm := range openManaSources(g, o) {
+			for _, opt := range manaProductionOptions(perm.GetSource()) {
+				if opt[game.Blue] > 0 {
+					blue++
+					break
+				}
+			}
+		}
+		if blue >= 2 && view.Of(o).HandSize >= 2 {
+			return o
+		}
+	}
+	return nil
+}
+
+// instantWaits reports whether responder should keep c for a better
+// window than the current one: draw and tutors wait for the end of the
+// previous opponent's turn, and removal for combat, a spell or ability
+// to answer, or that same end step.
+func instantWaits(g *game.Game, responder *game.Player, c game.SimpleCard, stackTop *abil.StackItem) bool {
+	if endOfTurnBefore(g, responder) {
+		return false
+	}
+	if heldForEndOfTurn(c) {
+		return true
+	}
+	if card.ClassifyRoles(c).Has(card.RoleRemoval) {
+		responding := stackTop != nil && stackTop.Controller != nil && stackTop.Controller.GetName() != responder.GetName()
+		return g.GetCurrentPhase() != game.PhaseCombat && !responding
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package simulation
+
+import (
+	"testing"
+
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+var testOpt = game.SimpleCard{Name: "Opt", TypeLine: "Instant", ManaCost: "{U}", OracleText: "Scry 1.\nDraw a card."}
+
+func untapped(p *game.Player) int {
+	n := 0
+	for _, perm := range p.Battlefield {
+		if !perm.IsTapped() {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHoldUpMana_KeepsAnswersOpenAgainstAThreat(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	opp := game.NewEDHPlayer("Opp")
+	g := game.NewGame(p, opp)
+	withLands(p, 4)
+	p.Hand = []game.SimpleCard{testCounterspell, {Name: "Ogre", TypeLine: "Creature", ManaCost: "{3}{U}", Power: "4", Toughness: "4"}}
+
+	if hold := holdUpMana(g, p, StyleFor("")); hold.Total() != 0 {
+		t.Fatalf("with nothing to fear the Ogre gets the mana, held %v", hold)
+	}
+
+	withLands(opp, 4)
+	opp.Hand = []game.SimpleCard{testOracle, testConsultation}
+	opp.Reveal(testOracle, testConsultation)
+	if hold := holdUpMana(g, p, StyleFor("")); hold[game.Blue] != 2 || hold.Total() != 2 {
+		t.Fatalf("against a revealed combo P holds up Counterspell, held %v", hold)
+	}
+	tapManaSourcesForMainPhaseMana(g, p, nil, 0, nil)
+	if n := untapped(p); n != 2 {
+		t.Fatalf("two Islands should stay untapped, got %d", n)
+	}
+
+	// Instants that draw are held for the end of turn on top of that.
+	p.Hand = append(p.Hand, testOpt, testOpt)
+	if hold := holdUpMana(g, p, StyleFor("")); hold[game.Blue] != 2 {
+		t.Errorf("Counterspell's mana covers both Opts, held %v", hold)
+	}
+}
+
+func TestPayWithOpenMana_TapsUntappedSources(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	g := game.NewGame(p, game.NewEDHPlayer("Opp"))
+	withLands(p, 1)
+	if canPayWithOpenMana(g, p, testCounterspell) || payWithOpenMana(g, p, testCounterspell) {
+		t.Fatal("one Island can't pay for Counterspell")
+	}
+	if untapped(p) != 1 {
+		t.Fatal("a failed payment shouldn't tap anything")
+	}
+
+	withLands(p, 2)
+	if !payWithOpenMana(g, p, testCounterspell) {
+		t.Fatal("expected three Islands to pay for Counterspell")
+	}
+	if n := untapped(p); n != 1 {
+		t.Errorf("Counterspell should tap two Islands, %d left untapped", n)
+	}
+	if pool := p.GetManaPool(); pool[game.Blue] != 0 {
+		t.Errorf("no mana should float, pool %v", pool)
+	}
+}
+
+func TestTryCounterSpell_LeavesSpellToALaterOpponent(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		laterKnown  bool
+		wantCounter bool
+	}{
+		{"later opponent holds a known counter", true, false},
+		{"nobody else can answer", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b, c := makeTestPlayer("A"), makeTestPlayer("B"), makeTestPlayer("C")
+			g := game.NewGame(a, b, c)
+			b.Hand = []game.SimpleCard{testCounterspell}
+			withLands(b, 2)
+			if tc.laterKnown {
+				c.Hand = []game.SimpleCard{testCounterspell}
+				c.Reveal(testCounterspell)
+				withLands(c, 2)
+			}
+			h := NewStackAwareHandler(g, nil)
+			stack := h.spellCasting.GetStack()
+			stack.AddSpell(&abil.Spell{Name: testGiant.Name, TypeLine: testGiant.TypeLine, ManaCost: testGiant.ManaCost, CMC: 5}, h.gameState.GetPlayer("A"), nil)
+
+			d := h.tryCounterSpell(h.gameState.GetPlayer("B"), stack.Peek())
+			if (d != nil) != tc.wantCounter {
+				t.Fatalf("counter decision = %+v, want counter %v", d, tc.wantCounter)
+			}
+			if tc.wantCounter && untapped(b) != 0 {
+				t.Errorf("B should tap both Islands for Counterspell")
+			}
+		})
+	}
+}
+
+func TestDecideInstantSpell_DrawsAtTheEndOfThePreviousTurn(t *testing.T) {
+	a, b, c := makeTestPlayer("A"), makeTestPlayer("B"), makeTestPlayer("C")
+	g := game.NewGame(a, b, c)
+	for _, p := range []*game.Player{b, c} {
+		p.Hand = []game.SimpleCard{testOpt}
+		p.Library = []game.SimpleCard{testIsland, testIsland}
+		withLands(p, 1)
+	}
+	h := NewStackAwareHandler(g, nil)
+
+	if d := h.decideInstantSpell(h.gameState.GetPlayer("B"), abil.DecisionContext{}); d != nil {
+		t.Fatalf("B should keep Opt during A's main phase, got %+v", d)
+	}
+	for g.GetCurrentPhase() != game.PhaseEnd {
+		g.AdvancePhase()
+	}
+	if d := h.decideInstantSpell(h.gameState.GetPlayer("C"), abil.DecisionContext{}); d != nil {
+		t.Fatalf("C plays after B, so C waits for B's end step, got %+v", d)
+	}
+	d := h.decideInstantSpell(h.gameState.GetPlayer("B"), abil.DecisionContext{})
+	if d == nil || d.Spell.Name != "Opt" {
+		t.Fatalf("B untaps next and should cast Opt now, got %+v", d)
+	}
+	if untapped(b) != 0 {
+		t.Error("B should tap its Island for Opt")
+	}
+}
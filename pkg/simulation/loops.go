@@ -0,0 +1,339 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mtgsim/mtgsim/pkg/bridge"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Loops and shortcuts (CR 732). The loop detector records the position of
+// the game after each step of a sequence of actions: everything but the
+// resources a loop can grow (loopResources). When a position comes back,
+// the steps since it form one iteration of a loop, and the resources that
+// changed are its gain.
+//
+// A loop whose gain is never negative is repeatable. Its controller
+// proposes the shortcut and names how many more times to repeat it
+// (Agent.ChooseLoopIterations, CR 732.2a), and applyLoop applies the gain
+// that many times over instead of finding every step again.
+//
+// A loop of mandatory actions nobody can stop, such as triggered abilities
+// that trigger each other, only ends if it is taking some player towards a
+// loss. If it isn't, the game is a draw (CR 104.4b).
+
+// loopIterationCap is the most iterations a controller may name for one
+// shortcut: more mana, storm or tokens than any game the engine plays
+// needs.
+const loopIterationCap = 100
+
+// mandatoryLoopCap bounds how many times a mandatory loop that is taking
+// a player towards a loss repeats before the game is called a draw.
+const mandatoryLoopCap = 500
+
+// triggerRoundCap bounds the rounds of triggers settleTriggers resolves
+// at once. A chain still going after that many rounds without coming back
+// to a position, such as one adding a counter each time, is one the
+// engine can't tell apart from a loop, so it gives up on the game.
+const triggerRoundCap = 2000
+
+// triggerOutcome is how settleTriggers left the game.
+type triggerOutcome int
+
+const (
+	// triggersSettled: no triggers are waiting and the game goes on.
+	triggersSettled triggerOutcome = iota
+	// triggersDrawn: a mandatory loop nobody can stop ended the game in a
+	// draw (CR 104.4b).
+	triggersDrawn
+	// triggersStuck: the triggers ran triggerRoundCap rounds without
+	// ending or repeating. That is the engine's limit, not a game result.
+	triggersStuck
+)
+
+// Loop is a repeatable loop a player has proved.
+type Loop struct {
+	Controller *game.Player
+	// Steps is the number of steps one iteration takes.
+	Steps int
+	// Gain is what one iteration adds to each loopResources key it
+	// changes. No entry is negative.
+	Gain map[string]int
+}
+
+// Damage is the life one iteration of l takes from opponent.
+func (l Loop) Damage(opponent *game.Player) int { return l.Gain["damage:"+opponent.GetName()] }
+
+// Mill is the library cards one iteration of l takes from opponent.
+func (l Loop) Mill(opponent *game.Player) int { return l.Gain["mill:"+opponent.GetName()] }
+
+// hurtsAnOpponent reports whether l takes life or library cards from an
+// opponent of its controller still in g.
+func (l Loop) hurtsAnOpponent(g *game.Game) bool {
+	for _, opp := range g.GetPlayersRaw() {
+		if opp != l.Controller && !opp.HasLost() && (l.Damage(opp) > 0 || l.Mill(opp) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// String lists l's gain per iteration, e.g. "+1 damage:Opp, +1 mana:C".
+func (l Loop) String() string {
+	keys := make([]string, 0, len(l.Gain))
+	for k := range l.Gain {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%+d %s", l.Gain[k], k)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// loopResources are the counts a loop can grow, from the point of view of
+// its controller p: "mana:<type>" p's floating mana, "storm" the spells p
+// cast this turn, "life" p's life, "tokens:<name>" p's tokens with that
+// name, and for every other player "damage:<name>" and "mill:<name>", the
+// negated life and library size. With no controller every player is
+// measured as an opponent.
+type loopResources map[string]int
+
+func measureLoop(g *game.Game, p *game.Player) loopResources {
+	res := loopResources{}
+	for _, pl := range g.GetPlayersRaw() {
+		if pl == p || pl.HasLost() {
+			continue
+		}
+		res["damage:"+pl.GetName()] = -pl.GetLifeTotal()
+		res["mill:"+pl.GetName()] = -len(pl.Library)
+	}
+	if p == nil {
+		return res
+	}
+	for mt, n := range p.GetManaPool() {
+		res["mana:"+string(mt)] = n
+	}
+	res["storm"] = g.SpellsCastThisTurnBy(p)
+	res["life"] = p.GetLifeTotal()
+	for _, perm := range p.Battlefield {
+		if perm.IsToken() {
+			res["tokens:"+perm.GetName()]++
+		}
+	}
+	return res
+}
+
+// loopPosition describes g apart from its loopResources: for each player
+// the permanents they control, by name, tapped state, counters and
+// attachment, with how many of each, and the cards in their hand and
+// command zone; and whether triggers are waiting. Tokens are counted by
+// the resources instead, so only their kinds are part of the position.
+// Libraries, graveyards and exile are left out, since loops move cards
+// through them.
+func loopPosition(g *game.Game) string {
+	var b strings.Builder
+	for _, p := range g.GetPlayersRaw() {
+		counts := map[string]int{}
+		for _, perm := range p.Battlefield {
+			kind := fmt.Sprint(perm.GetName(), perm.IsTapped(), perm.Counters(), perm.GetAttachedTo() != nil)
+			if perm.IsToken() {
+				counts["token "+kind] = 1
+			} else {
+				counts[kind]++
+			}
+		}
+		perms := make([]string, 0, len(counts))
+		for k, n := range counts {
+			perms = append(perms, fmt.Sprintf("%dx %s", n, k))
+		}
+		sort.Strings(perms)
+		hand := make([]string, 0, len(p.Hand))
+		for _, c := range p.Hand {
+			hand = append(hand, c.Name)
+		}
+		sort.Strings(hand)
+		command := make([]string, 0, len(p.CommandZone))
+		for _, c := range p.CommandZone {
+			command = append(command, c.Name)
+		}
+		fmt.Fprintf(&b, "%s:lost=%v;bf=%s;hand=%s;cmd=%s|", p.GetName(), p.HasLost(),
+			strings.Join(perms, ","), strings.Join(hand, ","), strings.Join(command, ","))
+	}
+	fmt.Fprintf(&b, "triggers=%s", strings.Join(pendingTriggerKeys(g), ","))
+	return b.String()
+}
+
+// pendingTriggerKeys describes the queued triggers by controller, source
+// and event, sorted so the same set compares equal in any order. The
+// registered Trigger stands for its source ability.
+func pendingTriggerKeys(g *game.Game) []string {
+	pending := g.PendingTriggers()
+	keys := make([]string, 0, len(pending))
+	for _, pt := range pending {
+		controller := ""
+		if pt.Trigger != nil && pt.Trigger.Controller != nil {
+			controller = pt.Trigger.Controller.GetName()
+		}
+		event := fmt.Sprint(pt.Event.Type)
+		if zc := pt.Event.ZoneChange; zc != nil {
+			event += fmt.Sprintf(":%s:%v>%v", zc.Card.Name, zc.From, zc.To)
+		}
+		keys = append(keys, fmt.Sprintf("%s/%p/%s", controller, pt.Trigger, event))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// loopDetector remembers the positions a sequence of steps has been
+// through.
+type loopDetector struct {
+	marks []loopMark
+}
+
+type loopMark struct {
+	position  string
+	resources loopResources
+}
+
+// observe records the position and resources after a step. If the
+// position has been seen before, it returns the change in resources
+// since the last time, with unchanged resources left out, the number of
+// steps in between, and true.
+func (d *loopDetector) observe(position string, res loopResources) (map[string]int, int, bool) {
+	d.marks = append(d.marks, loopMark{position, res})
+	now := len(d.marks) - 1
+	for i := now - 1; i >= 0; i-- {
+		prev := d.marks[i]
+		if prev.position != position {
+			continue
+		}
+		diff := map[string]int{}
+		for k, n := range res {
+			if delta := n - prev.resources[k]; delta != 0 {
+				diff[k] = delta
+			}
+		}
+		for k, n := range prev.resources {
+			if _, ok := res[k]; !ok && n != 0 {
+				diff[k] = -n
+			}
+		}
+		return diff, now - i, true
+	}
+	return nil, 0, false
+}
+
+// repeatable reports whether a loop with gain can be repeated forever:
+// something grows and nothing shrinks.
+func repeatable(gain map[string]int) bool {
+	if len(gain) == 0 {
+		return false
+	}
+	for _, n := range gain {
+		if n < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// headsToALoss reports whether a mandatory loop with gain, measured with
+// no controller, takes some player's life or library down.
+func headsToALoss(gain map[string]int) bool {
+	for k, n := range gain {
+		if n > 0 && (strings.HasPrefix(k, "damage:") || strings.HasPrefix(k, "mill:")) {
+			return true
+		}
+	}
+	return false
+}
+
+// loopIterationsToFinish is the fewest iterations of l that take every
+// opponent it hurts to 0 life or an empty library, at least 1, or
+// loopIterationCap if it hurts nobody.
+func loopIterationsToFinish(g *game.Game, l Loop) int {
+	need := 0
+	for _, opp := range g.GetPlayersRaw() {
+		if opp == l.Controller || opp.HasLost() {
+			continue
+		}
+		if d := l.Damage(opp); d > 0 {
+			need = max(need, (opp.GetLifeTotal()+d-1)/d)
+		}
+		if m := l.Mill(opp); m > 0 {
+			need = max(need, (len(opp.Library)+m-1)/m)
+		}
+	}
+	if need == 0 {
+		return loopIterationCap
+	}
+	return max(need, 1)
+}
+
+// applyLoop plays n iterations of l's gain at once (CR 732.2a), through
+// the same game actions the loop takes, so each one is seen by the
+// triggers it would set off. Tokens are copied from one l's controller
+// already controls. An opponent's life goes down by life loss: the gain
+// was measured after prevention.
+func applyLoop(g *game.Game, l Loop, n int) {
+	p := l.Controller
+	gs := bridge.NewAbilityGameState(g)
+	me := gs.GetPlayer(p.GetName())
+	keys := make([]string, 0, len(l.Gain))
+	for k := range l.Gain {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i := 0; i < n && !p.HasLost(); i++ {
+		for _, k := range keys {
+			gain := l.Gain[k]
+			kind, name, _ := strings.Cut(k, ":")
+			switch kind {
+			case "mana":
+				gs.AddManaToPool(me, game.ManaType(name), gain)
+			case "storm":
+				for j := 0; j < gain; j++ {
+					g.RecordSpellCast(p)
+				}
+			case "life":
+				gs.GainLife(me, gain)
+			case "tokens":
+				if token := tokenNamed(p, name); token != nil {
+					for j := 0; j < gain; j++ {
+						gs.CreateToken(me, token.GetSource())
+					}
+				}
+			case "damage":
+				if opp := playerNamed(g, name); opp != nil && !opp.HasLost() {
+					gs.LoseLife(gs.GetPlayer(name), gain)
+				}
+			case "mill":
+				if opp := playerNamed(g, name); opp != nil && !opp.HasLost() {
+					gs.MillCards(gs.GetPlayer(name), gain)
+				}
+			}
+		}
+	}
+}
+
+func tokenNamed(p *game.Player, name string) *game.Permanent {
+	for _, perm := range p.Battlefield {
+		if perm.IsToken() && perm.GetName() == name {
+			return perm
+		}
+	}
+	return nil
+}
+
+func playerNamed(g *game.Game, name string) *game.Player {
+	for _, p := range g.GetPlayersRaw() {
+		if p.GetName() == name {
+			return p
+		}
+	}
+	return nil
+}
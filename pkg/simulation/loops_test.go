@@ -0,0 +1,162 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// blinkLoop sets up a mandatory loop: whenever Flicker Wisp enters, it is
+// sacrificed, and whenever it leaves, it returns to the battlefield, with
+// each return running drain on g.
+func blinkLoop(g *game.Game, p *game.Player, drain func(*game.Game)) {
+	wisp := game.SimpleCard{Name: "Flicker Wisp", TypeLine: "Creature — Spirit", Power: "1", Toughness: "1"}
+	isWisp := func(_ *game.Trigger, e game.Event) bool {
+		return e.ZoneChange != nil && e.ZoneChange.Permanent != nil && e.ZoneChange.Permanent.GetName() == wisp.Name
+	}
+	g.AddTrigger(&game.Trigger{On: game.EventEntersBattlefield, Controller: p, Condition: isWisp, Action: func(g *game.Game, _ *game.Trigger, e game.Event) {
+		g.SacrificePermanent(e.ZoneChange.Permanent)
+	}})
+	g.AddTrigger(&game.Trigger{On: game.EventLeavesBattlefield, Controller: p, Condition: isWisp, Action: func(g *game.Game, _ *game.Trigger, e game.Event) {
+		p.Hand = append(p.Hand, wisp)
+		g.CastPermanent(p, wisp.Name)
+		drain(g)
+	}})
+	p.Hand = append(p.Hand, wisp)
+	g.CastPermanent(p, wisp.Name)
+}
+
+func TestSettleTriggers_MandatoryLoopIsADraw(t *testing.T) {
+	p, opp := makeTestPlayer("P"), makeTestPlayer("Opp")
+	g := game.NewGame(p, opp)
+	blinkLoop(g, p, func(*game.Game) {})
+
+	if settleTriggers(g, nil, nil) != triggersDrawn {
+		t.Fatal("a loop of triggers that changes nothing should end the game in a draw")
+	}
+	if p.HasLost() || opp.HasLost() {
+		t.Error("a draw eliminates nobody")
+	}
+}
+
+func TestSettleTriggers_MandatoryLoopThatDrainsRunsOut(t *testing.T) {
+	p, opp := makeTestPlayer("P"), makeTestPlayer("Opp")
+	g := game.NewGame(p, opp)
+	blinkLoop(g, p, func(*game.Game) { opp.SetLifeTotal(opp.GetLifeTotal() - 1) })
+
+	if settleTriggers(g, nil, nil) != triggersSettled {
+		t.Fatal("a loop draining a player isn't a draw")
+	}
+	if !opp.HasLost() || p.HasLost() {
+		t.Fatalf("the drain should finish Opp, life %d", opp.GetLifeTotal())
+	}
+}
+
+func TestSettleTriggers_ChainThatNeverRepeatsIsStuck(t *testing.T) {
+	p, opp := makeTestPlayer("P"), makeTestPlayer("Opp")
+	g := game.NewGame(p, opp)
+	hydra := p.PutTokenOnBattlefield(game.SimpleCard{Name: "Hydra", TypeLine: "Creature — Hydra", Power: "1", Toughness: "1"})
+	blinkLoop(g, p, func(*game.Game) { hydra.AddCounters("+1/+1", 1) })
+
+	if got := settleTriggers(g, nil, nil); got != triggersStuck {
+		t.Fatalf("a chain that grows a counter forever never repeats a position, got %v", got)
+	}
+}
+
+func TestSettleTriggers_FiniteChainOfDifferentTriggersIsNoLoop(t *testing.T) {
+	p, opp := makeTestPlayer("P"), makeTestPlayer("Opp")
+	g := game.NewGame(p, opp)
+	// Each blink is handled by its own pair of triggers, so the board
+	// comes back empty with a different trigger waiting each time until
+	// the chain runs out.
+	wisp := game.SimpleCard{Name: "Flicker Wisp", TypeLine: "Creature — Spirit", Power: "1", Toughness: "1"}
+	blinks := 0
+	for i := 0; i < 3; i++ {
+		nth := func(_ *game.Trigger, e game.Event) bool {
+			return blinks == i && e.ZoneChange != nil && e.ZoneChange.Permanent != nil && e.ZoneChange.Permanent.GetName() == wisp.Name
+		}
+		g.AddTrigger(&game.Trigger{On: game.EventEntersBattlefield, Controller: p, Condition: nth, Action: func(g *game.Game, _ *game.Trigger, e game.Event) {
+			g.SacrificePermanent(e.ZoneChange.Permanent)
+		}})
+		g.AddTrigger(&game.Trigger{On: game.EventLeavesBattlefield, Controller: p, Condition: nth, Action: func(g *game.Game, _ *game.Trigger, e game.Event) {
+			blinks++
+			p.SetLifeTotal(p.GetLifeTotal() + 1)
+			p.Hand = append(p.Hand, wisp)
+			g.CastPermanent(p, wisp.Name)
+		}})
+	}
+	p.Hand = append(p.Hand, wisp)
+	g.CastPermanent(p, wisp.Name)
+	life := p.GetLifeTotal()
+
+	if settleTriggers(g, nil, nil) != triggersSettled {
+		t.Fatal("a chain that ends on its own isn't a draw")
+	}
+	if blinks != 3 || p.GetLifeTotal() != life+3 {
+		t.Fatalf("expected the chain to run all three blinks, got %d", blinks)
+	}
+}
+
+func TestLoopDetector_ProvesARepeatingPosition(t *testing.T) {
+	var d loopDetector
+	d.observe("a", loopResources{"mana:C": 0})
+	d.observe("b", loopResources{"mana:C": 2})
+	gain, steps, again := d.observe("a", loopResources{"mana:C": 1, "storm": 1})
+	if !again || steps != 2 || !repeatable(gain) || gain["mana:C"] != 1 || gain["storm"] != 1 {
+		t.Fatalf("gain %v over %d steps, again %v", gain, steps, again)
+	}
+	if gain, _, _ := d.observe("a", loopResources{"mana:C": 0, "storm": 1}); repeatable(gain) {
+		t.Fatalf("a loop that spends mana isn't repeatable forever, gain %v", gain)
+	}
+}
+
+func TestLoopPosition_CountsPermanentsButNotTokens(t *testing.T) {
+	p, opp := makeTestPlayer("P"), makeTestPlayer("Opp")
+	g := game.NewGame(p, opp)
+	bear := game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"}
+	g.CreateToken(p, bear)
+	before := loopPosition(g)
+	g.CreateToken(p, bear)
+	if loopPosition(g) != before {
+		t.Error("a token more is a resource, not a new position")
+	}
+	p.PutTokenOnBattlefield(bear)
+	withCard := loopPosition(g)
+	p.PutTokenOnBattlefield(bear)
+	if loopPosition(g) == withCard {
+		t.Error("a second nontoken Bear is a different position")
+	}
+}
+
+func TestApplyLoop_PlaysEachIterationThroughTheGame(t *testing.T) {
+	p, opp := makeTestPlayer("P"), makeTestPlayer("Opp")
+	g := game.NewGame(p, opp)
+	g.CreateToken(p, game.SimpleCard{Name: "Goblin", TypeLine: "Creature — Goblin", Power: "1", Toughness: "1"})
+	p.PutTokenOnBattlefield(game.SimpleCard{Name: "Sol Ring", TypeLine: "Artifact"})
+	g.AddTrigger(&game.Trigger{On: game.EventLifeLost, Controller: p, Action: func(*game.Game, *game.Trigger, game.Event) {}})
+	life := opp.GetLifeTotal()
+
+	applyLoop(g, Loop{Controller: p, Gain: map[string]int{"tokens:Goblin": 1, "tokens:Sol Ring": 1, "damage:Opp": 2, "mana:" + string(game.Colorless): 1}}, 3)
+
+	goblins, rings := 0, 0
+	for _, perm := range p.Battlefield {
+		switch {
+		case perm.GetName() == "Goblin" && perm.IsToken():
+			goblins++
+		case perm.GetName() == "Sol Ring":
+			rings++
+		}
+	}
+	losses := 0
+	for _, pt := range g.PendingTriggers() {
+		if pt.Event.Type == game.EventLifeLost {
+			losses++
+		}
+	}
+	if goblins != 4 || rings != 1 {
+		t.Errorf("expected three Goblin tokens copied and the Sol Ring card left alone, got %d Goblins, %d Sol Rings", goblins, rings)
+	}
+	if opp.GetLifeTotal() != life-6 || losses != 3 || p.GetManaPool()[game.Colorless] != 3 {
+		t.Errorf("expected three life losses of 2 and 3 mana, got life %d, %d losses, pool %v", opp.GetLifeTotal(), losses, p.GetManaPool())
+	}
+}
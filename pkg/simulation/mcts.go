@@ -0,0 +1,223 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// MCTSOptions configures an MCTSAgent's search. A decision stops at
+// whichever of Iterations and Budget runs out first.
+type MCTSOptions struct {
+	// Iterations is the number of rollouts per decision. 0 means 32, or
+	// no limit when Budget is set.
+	Iterations int
+	// Budget is the wall-clock time allowed per decision. 0 means none.
+	Budget time.Duration
+	// Horizon is the most rounds of the table (game turn numbers) a
+	// rollout plays; a rollout nobody has won by then scores as a draw.
+	// 0 means 20.
+	Horizon int
+	// Exploration is the UCB1 exploration constant. 0 means √2.
+	Exploration float64
+	// Seed seeds the agent's determinizations.
+	Seed int64
+}
+
+// MCTSAgent searches the decisions that decide games — which spell to
+// cast next and whom to attack — with information-set Monte Carlo tree
+// search, and leaves the rest to DefaultAgent. Each iteration samples a
+// determinization of the hidden cards (game.Game.Determinize), applies
+// one candidate action picked by UCB1, and plays the game out with
+// DefaultAgent in every seat, resolving spells and triggers through a
+// StackAwareHandler that asks those agents as the EDH runner does. A
+// rollout scores 1 if the searching player wins and 0 otherwise. The
+// action with the best mean result is chosen; ties go to DefaultAgent's
+// own choice.
+//
+// An MCTSAgent may be shared by seats in concurrent games.
+type MCTSAgent struct {
+	DefaultAgent
+	opts MCTSOptions
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewMCTSAgent returns an MCTSAgent searching with opts.
+func NewMCTSAgent(opts MCTSOptions) *MCTSAgent {
+	if opts.Iterations <= 0 && opts.Budget <= 0 {
+		opts.Iterations = 32
+	}
+	if opts.Horizon <= 0 {
+		opts.Horizon = 20
+	}
+	if opts.Exploration <= 0 {
+		opts.Exploration = math.Sqrt2
+	}
+	return &MCTSAgent{opts: opts, rng: rand.New(rand.NewSource(opts.Seed))}
+}
+
+// ChooseSpell searches over the distinct castable spells, casting the
+// chosen one and then the rest of the main phase as DefaultAgent would.
+func (a *MCTSAgent) ChooseSpell(g *game.Game, p *game.Player, castable []game.SimpleCard) (game.SimpleCard, bool) {
+	var options []game.SimpleCard
+	for _, c := range castable {
+		if findZoneCard(options, c.Name) < 0 {
+			options = append(options, c)
+		}
+	}
+	if len(options) < 2 {
+		return a.DefaultAgent.ChooseSpell(g, p, castable)
+	}
+	best := a.search(g, p, len(options), func(fork *game.Game, me *game.Player, seats seatAgents, h *StackAwareHandler, i int) {
+		idx := indexOfPlayer(fork, me)
+		castFromHand(fork, me, options[i], idx, nil, nil, h)
+		castSpells(fork, me, seats.of(me), idx, nil, nil, h)
+		finishMainPhase(fork, me, seats.of(me), nil, nil, h)
+	})
+	return options[best], true
+}
+
+// ChooseAttackTarget searches over attacking each living opponent or not
+// attacking at all.
+func (a *MCTSAgent) ChooseAttackTarget(g *game.Game, p *game.Player) *game.Player {
+	first := a.DefaultAgent.ChooseAttackTarget(g, p)
+	if first == nil || !canAttack(g, p) {
+		return first
+	}
+	options := []*game.Player{first}
+	for _, opp := range g.ViewFor(p).Opponents() {
+		if opp != first {
+			options = append(options, opp)
+		}
+	}
+	options = append(options, nil)
+	best := a.search(g, p, len(options), func(fork *game.Game, me *game.Player, seats seatAgents, h *StackAwareHandler, i int) {
+		var target *game.Player
+		if options[i] != nil {
+			target = fork.GetPlayerByIndex(indexOfPlayer(g, options[i]))
+		}
+		rest := seats[me]
+		seats[me] = attackAgent{DefaultAgent: a.DefaultAgent, target: target}
+		runCombatPhase(fork, me, seats, nil, nil, h)
+		seats[me] = rest
+	})
+	return options[best]
+}
+
+// canAttack reports whether p has a creature able to attack this turn.
+func canAttack(g *game.Game, p *game.Player) bool {
+	for _, perm := range p.GetCreatures() {
+		if canAttackWith(g, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// attackAgent is DefaultAgent attacking only a fixed player.
+type attackAgent struct {
+	DefaultAgent
+	target *game.Player
+}
+
+func (a attackAgent) ChooseAttackTarget(*game.Game, *game.Player) *game.Player { return a.target }
+
+func (a attackAgent) ChooseAttackers(g *game.Game, p, _ *game.Player) map[*game.Permanent]*game.Player {
+	return planAttacks(g, p, []*game.Player{a.target}, a.target, styleOf(a.DefaultAgent))
+}
+
+// search runs the agent's budget of rollouts over n actions, where apply
+// performs action i on a determinization for me, with the rollout's seat
+// agents and priority handler, and finishes the current step. It returns
+// the action with the best mean rollout value.
+func (a *MCTSAgent) search(g *game.Game, p *game.Player, n int, apply func(fork *game.Game, me *game.Player, seats seatAgents, h *StackAwareHandler, i int)) int {
+	a.mu.Lock()
+	rng := rand.New(rand.NewSource(a.rng.Int63()))
+	a.mu.Unlock()
+
+	visits := make([]int, n)
+	value := make([]float64, n)
+	var deadline time.Time
+	if a.opts.Budget > 0 {
+		deadline = time.Now().Add(a.opts.Budget)
+	}
+	for it := 0; a.opts.Iterations <= 0 || it < a.opts.Iterations; it++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		i := ucb1(visits, value, it, a.opts.Exploration)
+		fork, m := g.Determinize(p, rng)
+		me := m.Player(p)
+		seats := seatAgents{}
+		for _, fp := range fork.GetPlayersRaw() {
+			seats[fp] = DefaultAgent{}
+		}
+		seats[me] = a.DefaultAgent
+		h := NewStackAwareHandler(fork, nil)
+		h.agents = seats
+		apply(fork, me, seats, h, i)
+		visits[i]++
+		value[i] += rollout(fork, me, seats, h, a.opts.Horizon)
+	}
+
+	best, bestMean := 0, -1.0
+	for i := range visits {
+		if visits[i] == 0 {
+			continue
+		}
+		if mean := value[i] / float64(visits[i]); mean > bestMean {
+			best, bestMean = i, mean
+		}
+	}
+	return best
+}
+
+// ucb1 picks the next action to try: each untried action in order, then
+// the one with the highest upper confidence bound.
+func ucb1(visits []int, value []float64, total int, c float64) int {
+	best, bestScore := 0, math.Inf(-1)
+	for i := range visits {
+		if visits[i] == 0 {
+			return i
+		}
+		mean := value[i] / float64(visits[i])
+		score := mean + c*math.Sqrt(math.Log(float64(total))/float64(visits[i]))
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// rollout finishes the current step of g, plays up to horizon rounds with
+// seats deciding and h resolving the stack, and scores the result for me.
+func rollout(g *game.Game, me *game.Player, seats seatAgents, h *StackAwareHandler, horizon int) float64 {
+	g.ApplyStateBasedActions()
+	if settleTriggers(g, seats, h) != triggersSettled {
+		return 0
+	}
+	g.AdvancePhase()
+	casts := make([]int, g.NumPlayers())
+	end := g.GetTurnNumber() + horizon
+	for !me.HasLost() && survivors(g) > 1 && g.GetTurnNumber() <= end {
+		alive, ended := stepOneEDHTurn(g, casts, seats, h, nil, nil)
+		if !alive || ended != triggersSettled {
+			break
+		}
+	}
+	return rolloutValue(g, me)
+}
+
+// rolloutValue scores a played-out game for me: 1 if me won, 0 for a
+// loss, a draw or a game still going.
+func rolloutValue(g *game.Game, me *game.Player) float64 {
+	if !me.HasLost() && survivors(g) == 1 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+func TestMCTSAgent_FinishesOffThePlayerAboutToWall(t *testing.T) {
+	p1 := makeTestPlayer("Attacker")
+	p2 := makeTestPlayer("Low")
+	p3 := makeTestPlayer("Threat")
+	g := game.NewGame(p1, p2, p3)
+	for _, p := range []*game.Player{p1, p3} {
+		for i := 0; i < 8; i++ {
+			p.Library = append(p.Library, game.SimpleCard{Name: "Wastes", TypeLine: "Basic Land"})
+		}
+	}
+	// Low will draw and cast a Wall that blocks the Colossus forever, so
+	// the time to attack Low is now, not when the Threat is dealt with:
+	// the Colossus then wins the race against the Threat's Ogre. Low also
+	// outlasts the others' libraries, so walling up wins it the game.
+	for i := 0; i < 12; i++ {
+		p2.Library = append(p2.Library, game.SimpleCard{Name: "Wall", TypeLine: "Creature — Wall", ManaCost: "{0}", Power: "0", Toughness: "20", OracleText: "Defender"})
+	}
+	p1.PutTokenOnBattlefield(game.SimpleCard{Name: "Colossus", TypeLine: "Creature — Golem", Power: "10", Toughness: "10"})
+	p3.PutTokenOnBattlefield(game.SimpleCard{Name: "Ogre", TypeLine: "Creature — Ogre", Power: "5", Toughness: "2"})
+	p2.SetLifeTotal(10)
+	for g.GetCurrentPhase() != game.PhaseCombat {
+		g.AdvancePhase()
+	}
+
+	if got := chooseAttackTarget(g, p1); got != p3 {
+		t.Fatalf("the heuristic attacks the biggest board, got %v", got)
+	}
+	agent := NewMCTSAgent(MCTSOptions{Iterations: 12, Seed: 1})
+	if got := agent.ChooseAttackTarget(g, p1); got != p2 {
+		t.Fatalf("expected the search to attack Low before the Wall lands, got %v", got)
+	}
+	if p2.HasLost() || p2.GetLifeTotal() != 10 || len(p1.Library) != 8 {
+		t.Fatal("the search must not touch the real game")
+	}
+}
+
+func TestDeterminize_KeepsKnownCardsAndZoneSizes(t *testing.T) {
+	p1 := makeTestPlayer("P1")
+	p2 := makeTestPlayer("P2")
+	g := game.NewGame(p1, p2)
+	p2.Hand = []game.SimpleCard{{Name: "Counterspell"}, {Name: "Brainstorm"}}
+	p2.Library = []game.SimpleCard{{Name: "Island"}, {Name: "Ponder"}, {Name: "Force of Will"}}
+	g.ShowCards(p1, p2, p2.Hand[0])
+	bear := p1.PutTokenOnBattlefield(game.SimpleCard{Name: "Bear", TypeLine: "Creature — Bear", Power: "2", Toughness: "2"})
+
+	fork, m := g.Determinize(p1, nil)
+	f2 := m.Player(p2)
+	if fork.GetPlayerByIndex(1) != f2 || len(f2.Hand) != 2 || len(f2.Library) != 3 || f2.Hand[0].Name != "Counterspell" {
+		t.Fatalf("expected the known Counterspell and the zone sizes kept, got hand %+v", f2.Hand)
+	}
+	fbear := m.Player(p1).Battlefield[0]
+	fbear.Tap()
+	if fbear == bear || fbear.GetController() != m.Player(p1) || bear.IsTapped() {
+		t.Fatal("permanents are copied and point at the copied players")
+	}
+}
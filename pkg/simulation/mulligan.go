@@ -0,0 +1,308 @@
+package simulation
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// HandFeatures describes an opening hand in deck-independent terms, so a
+// mulligan model fit on one deck's games carries over to decks it has
+// never seen.
+type HandFeatures struct {
+	Cards int `json:"cards"`
+	Lands int `json:"lands"`
+	// ManaSources counts lands plus mana rocks and dorks castable by turn
+	// two. The rest count cards by their roles (card.ClassifyRoles).
+	ManaSources int `json:"mana_sources"`
+	FastMana    int `json:"fast_mana"`
+	// ColorsMissing counts the colors the hand's spells and the
+	// commanders need that no mana source in hand produces.
+	ColorsMissing int     `json:"colors_missing"`
+	EarlyPlays    int     `json:"early_plays"` // nonland cards of mana value 2 or less
+	AvgManaValue  float64 `json:"avg_mana_value"`
+	ComboPieces   int     `json:"combo_pieces"`
+	Tutors        int     `json:"tutors"`
+	Interaction   int     `json:"interaction"`
+	CardDraw      int     `json:"card_draw"`
+}
+
+// OpeningHand is one hand a player looked at before the game began.
+type OpeningHand struct {
+	Features  HandFeatures `json:"features"`
+	Mulligans int          `json:"mulligans"` // mulligans taken before this hand
+	Kept      bool         `json:"kept"`
+}
+
+// handFeatures extracts the features of hand. combos, when known, marks
+// the deck's combo pieces.
+func handFeatures(hand, commanders []game.SimpleCard, combos *combo.Index) HandFeatures {
+	f := HandFeatures{Cards: len(hand)}
+	produced := map[game.ManaType]bool{}
+	needed := map[game.ManaType]bool{}
+	need := func(c game.SimpleCard) {
+		for mt, n := range c.GetManaCost() {
+			if n > 0 && mt != game.Any && mt != game.Colorless {
+				needed[mt] = true
+			}
+		}
+	}
+	for _, c := range commanders {
+		need(c)
+	}
+	var names []string
+	mvSum, spells := 0, 0
+	for _, c := range hand {
+		names = append(names, c.Name)
+		options := manaProductionOptions(c)
+		if c.IsLand() {
+			f.Lands++
+			f.ManaSources++
+		} else {
+			mv := c.ManaValue()
+			mvSum += mv
+			spells++
+			need(c)
+			if mv <= 2 {
+				f.EarlyPlays++
+			}
+			if len(options) > 0 && mv <= 2 {
+				f.ManaSources++
+			} else {
+				options = nil
+			}
+		}
+		for _, m := range options {
+			for mt, n := range m {
+				if n > 0 {
+					produced[mt] = true
+				}
+			}
+		}
+		if c.IsLand() {
+			continue
+		}
+		roles := card.ClassifyRoles(c)
+		if roles.Has(card.RoleFastMana) {
+			f.FastMana++
+		}
+		if roles.Has(card.RoleTutor) {
+			f.Tutors++
+		}
+		if roles.HasAny(card.RoleCounterspell, card.RoleRemoval, card.RoleBoardWipe) {
+			f.Interaction++
+		}
+		if roles.HasAny(card.RoleCardDraw, card.RoleDrawEngine) {
+			f.CardDraw++
+		}
+	}
+	for mt := range needed {
+		if !produced[mt] {
+			f.ColorsMissing++
+		}
+	}
+	if spells > 0 {
+		f.AvgManaValue = float64(mvSum) / float64(spells)
+	}
+	if combos != nil {
+		f.ComboPieces = len(combos.ComboPiecesInHand(names))
+	}
+	return f
+}
+
+// mulliganInputs names the model's inputs in the order inputs returns
+// them. Shortfalls are clipped at zero so a hand is not rewarded for a
+// fourth source or a seventh land.
+var mulliganInputs = []string{
+	"sources_short", "flood", "colors_missing", "early_plays", "fast_mana",
+	"high_curve", "combo_pieces", "tutors", "interaction", "card_draw", "cards_short",
+}
+
+func (f HandFeatures) inputs() []float64 {
+	return []float64{
+		math.Max(0, float64(3-f.ManaSources)),
+		math.Max(0, float64(f.Lands-4)),
+		float64(f.ColorsMissing),
+		math.Min(3, float64(f.EarlyPlays)),
+		float64(f.FastMana),
+		math.Max(0, f.AvgManaValue-3),
+		float64(f.ComboPieces),
+		float64(f.Tutors),
+		float64(f.Interaction),
+		float64(f.CardDraw),
+		float64(game.OpeningHandSize - f.Cards),
+	}
+}
+
+// MulliganModel scores opening hands with a logistic model of the chance
+// the hand goes on to win. A hand is kept when its score reaches the
+// threshold for the number of mulligans already taken.
+type MulliganModel struct {
+	Bias    float64            `json:"bias"`
+	Weights map[string]float64 `json:"weights"`
+	// Thresholds[m] is the lowest score kept after m mulligans. Hands
+	// past the last threshold are always kept.
+	Thresholds []float64 `json:"thresholds"`
+}
+
+// DefaultMulliganModel returns hand-set weights used until a model has
+// been fit: three mana sources and a couple of early plays keep, short
+// mana or a flood mulligans, and the bar drops as the hand shrinks. The
+// first mulligan is free in multiplayer (CR 103.4c), so its bar is the
+// highest.
+func DefaultMulliganModel() *MulliganModel {
+	return &MulliganModel{
+		Bias: -0.6,
+		Weights: map[string]float64{
+			"sources_short":  -0.8,
+			"flood":          -0.6,
+			"colors_missing": -0.4,
+			"early_plays":    0.25,
+			"fast_mana":      0.5,
+			"high_curve":     -0.3,
+			"combo_pieces":   0.35,
+			"tutors":         0.35,
+			"interaction":    0.15,
+			"card_draw":      0.2,
+			"cards_short":    -0.3,
+		},
+		Thresholds: []float64{0.3, 0.25, 0.2, 0},
+	}
+}
+
+// LoadMulliganModel reads a model written by Save.
+func LoadMulliganModel(path string) (*MulliganModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &MulliganModel{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Save writes the model to path as JSON.
+func (m *MulliganModel) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Score returns the model's win chance for a hand with features f.
+func (m *MulliganModel) Score(f HandFeatures) float64 {
+	z := m.Bias
+	for i, x := range f.inputs() {
+		z += m.Weights[mulliganInputs[i]] * x
+	}
+	return sigmoid(z)
+}
+
+// Keep reports whether a hand with features f is kept after mulligans
+// mulligans.
+func (m *MulliganModel) Keep(f HandFeatures, mulligans int) bool {
+	if mulligans >= len(m.Thresholds) {
+		return true
+	}
+	return m.Score(f) >= m.Thresholds[mulligans]
+}
+
+func sigmoid(z float64) float64 { return 1 / (1 + math.Exp(-z)) }
+
+const (
+	fitIterations = 2000
+	fitRate       = 0.1
+	// fitPrior pulls the weights toward DefaultMulliganModel's, so a
+	// feature that rarely shows up in the records keeps its hand-set
+	// weight instead of drifting to whatever a few games say.
+	fitPrior = 0.01
+)
+
+// FitMulliganModel fits a model to the opening hands in records. The
+// weights come from a logistic regression of the kept hands against
+// whether their deck won. The thresholds then follow by backward
+// induction: mulliganing after m mulligans is worth the average, over
+// the hands seen after m+1, of the better of keeping that hand or
+// mulliganing again. Records without opening hands are ignored; with no
+// kept hands at all the default model is returned.
+func FitMulliganModel(records []EDHGameRecord) *MulliganModel {
+	prior := DefaultMulliganModel()
+	var xs [][]float64
+	var ys []float64
+	seen := map[int][]HandFeatures{}
+	for _, rec := range records {
+		for _, p := range rec.Players {
+			for _, h := range p.OpeningHands {
+				seen[h.Mulligans] = append(seen[h.Mulligans], h.Features)
+				if !h.Kept {
+					continue
+				}
+				y := 0.0
+				if rec.Winner != "" && p.DeckName == rec.Winner {
+					y = 1
+				}
+				xs = append(xs, h.Features.inputs())
+				ys = append(ys, y)
+			}
+		}
+	}
+	if len(xs) == 0 {
+		return prior
+	}
+
+	w := make([]float64, len(mulliganInputs))
+	for i, name := range mulliganInputs {
+		w[i] = prior.Weights[name]
+	}
+	bias := prior.Bias
+	n := float64(len(xs))
+	grad := make([]float64, len(w))
+	for iter := 0; iter < fitIterations; iter++ {
+		for i := range grad {
+			grad[i] = fitPrior * (w[i] - prior.Weights[mulliganInputs[i]])
+		}
+		gradBias := 0.0
+		for k, x := range xs {
+			z := bias
+			for i, v := range x {
+				z += w[i] * v
+			}
+			diff := (sigmoid(z) - ys[k]) / n
+			gradBias += diff
+			for i, v := range x {
+				grad[i] += diff * v
+			}
+		}
+		bias -= fitRate * gradBias
+		for i := range w {
+			w[i] -= fitRate * grad[i]
+		}
+	}
+
+	m := &MulliganModel{Bias: bias, Weights: map[string]float64{}}
+	for i, name := range mulliganInputs {
+		m.Weights[name] = w[i]
+	}
+	m.Thresholds = make([]float64, len(prior.Thresholds))
+	for mull := len(m.Thresholds) - 2; mull >= 0; mull-- {
+		next := m.Thresholds[mull+1]
+		hands := seen[mull+1]
+		if len(hands) == 0 {
+			m.Thresholds[mull] = next
+			continue
+		}
+		sum := 0.0
+		for _, f := range hands {
+			sum += math.Max(m.Score(f), next)
+		}
+		m.Thresholds[mull] = sum / float64(len(hands))
+	}
+	return m
+}
@@ -0,0 +1,92 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+var (
+	testForest  = game.SimpleCard{Name: "Forest", TypeLine: "Basic Land — Forest"}
+	testIsland  = game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"}
+	testBear    = game.SimpleCard{Name: "Bear", TypeLine: "Creature", ManaCost: "{1}{G}", Power: "2", Toughness: "2"}
+	testGiant   = game.SimpleCard{Name: "Giant", TypeLine: "Creature", ManaCost: "{3}{G}{G}", Power: "5", Toughness: "5"}
+	testSolRing = game.SimpleCard{Name: "Sol Ring", TypeLine: "Artifact", ManaCost: "{1}", OracleText: "{T}: Add {C}{C}."}
+)
+
+func TestHandFeatures_CountsSourcesColorsAndRoles(t *testing.T) {
+	hand := []game.SimpleCard{
+		testForest, testIsland, testSolRing,
+		{Name: "Counterspell", TypeLine: "Instant", ManaCost: "{U}{U}", OracleText: "Counter target spell."},
+		{Name: "Demonic Tutor", TypeLine: "Sorcery", ManaCost: "{1}{B}", OracleText: "Search your library for a card, put that card into your hand, then shuffle."},
+		{Name: "Harmonize", TypeLine: "Sorcery", ManaCost: "{2}{G}{G}", OracleText: "Draw three cards."},
+		testGiant,
+	}
+	combos := &combo.Index{CardToVariants: map[string][]string{"Sol Ring": {"1"}}}
+	f := handFeatures(hand, nil, combos)
+	want := HandFeatures{
+		Cards: 7, Lands: 2, ManaSources: 3, FastMana: 1, ColorsMissing: 1, EarlyPlays: 3,
+		AvgManaValue: 14.0 / 5, ComboPieces: 1, Tutors: 1, Interaction: 1, CardDraw: 1,
+	}
+	if f != want {
+		t.Fatalf("features:\n got %+v\nwant %+v", f, want)
+	}
+}
+
+func TestDefaultAgent_KeepHandWithoutStaples(t *testing.T) {
+	keeps := func(mulligans int, hand ...game.SimpleCard) bool {
+		p := game.NewEDHPlayer("P")
+		p.Hand = hand
+		return DefaultAgent{}.KeepHand(p, nil, 0, mulligans)
+	}
+	if !keeps(0, testForest, testForest, testForest, testBear, testBear, testGiant, testGiant) {
+		t.Fatal("three lands and two early plays is a keep")
+	}
+	if keeps(0, testForest, testBear, testBear, testBear, testGiant, testGiant, testGiant) {
+		t.Fatal("a one-lander is a mulligan")
+	}
+	if keeps(0, testForest, testForest, testForest, testForest, testForest, testForest, testGiant) {
+		t.Fatal("six lands is a mulligan")
+	}
+	if !keeps(3, testForest, testBear, testBear, testGiant, testGiant) {
+		t.Fatal("a five-card hand is kept whatever it holds")
+	}
+}
+
+func TestFitMulliganModel_LearnsFromRecords(t *testing.T) {
+	good := HandFeatures{Cards: 7, Lands: 3, ManaSources: 3, EarlyPlays: 2, AvgManaValue: 3}
+	screw := HandFeatures{Cards: 7, Lands: 1, ManaSources: 1, EarlyPlays: 2, AvgManaValue: 3}
+	var records []EDHGameRecord
+	for i := 0; i < 40; i++ {
+		winner := "Good"
+		if i%2 == 0 {
+			winner = "Mull"
+		}
+		records = append(records, EDHGameRecord{Winner: winner, Players: []EDHPlayerRecord{
+			{DeckName: "Good", OpeningHands: []OpeningHand{{Features: good, Kept: true}}},
+			{DeckName: "Screw", OpeningHands: []OpeningHand{{Features: screw, Kept: true}}},
+			{DeckName: "Mull", OpeningHands: []OpeningHand{
+				{Features: screw},
+				{Features: good, Mulligans: 1, Kept: true},
+			}},
+		}})
+	}
+
+	m := FitMulliganModel(records)
+	if got := m.Score(good); got < 0.35 || got > 0.65 {
+		t.Fatalf("good hands won half their games, scored %.2f", got)
+	}
+	if m.Score(screw) >= m.Score(good)/2 {
+		t.Fatalf("screwed hands never won: scored %.2f against %.2f", m.Score(screw), m.Score(good))
+	}
+	if !m.Keep(good, 0) || m.Keep(screw, 0) {
+		t.Fatalf("expected to keep the good hand and mulligan the screw, thresholds %v", m.Thresholds)
+	}
+	if last := m.Thresholds[len(m.Thresholds)-1]; last != 0 {
+		t.Fatalf("the last hand is always kept, threshold %v", last)
+	}
+	if FitMulliganModel(nil).Bias != DefaultMulliganModel().Bias {
+		t.Fatal("no records should give the default model")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/mtgsim/mtgsim/pkg/bridge"
 	"github.com/mtgsim/mtgsim/pkg/game"
 	"github.com/mtgsim/mtgsim/internal/logger"
+	"github.com/mtgsim/mtgsim/pkg/threat"
 )
 
 // StackAwareHandler implements PriorityHandler by running full APNAP
@@ -31,6 +32,12 @@ type StackAwareHandler struct {
 	lastTurn       int
 	lastPhase      game.Phase
 	log            *EDHEventLog
+	// agents decide for each player; players without one use
+	// DefaultAgent.
+	agents seatAgents
+	// castTriggers caches each card's parsed cast triggers by name, so
+	// the battlefield isn't re-parsed on every spell.
+	castTriggers map[string][]*abil.Ability
 }
 
 // NewStackAwareHandler creates a handler backed by a real Stack and
@@ -49,6 +56,7 @@ func NewStackAwareHandler(g *game.Game, logOpt *EDHEventLog) *StackAwareHandler
 	spellCasting.SetPlayers(players)
 
 	ai := abil.NewAIDecisionMaker(engine)
+	gs.TaxDecider = ai
 
 	h := &StackAwareHandler{
 		g:            g,
@@ -60,6 +68,17 @@ func NewStackAwareHandler(g *game.Game, logOpt *EDHEventLog) *StackAwareHandler
 	}
 
 	spellCasting.GetPriorityManager().DecisionFunc = h.aiDecision
+	// Searches go to the searching player's agent; the AI keeps the
+	// other library choices.
+	gs.Chooser = agentChooser{g: g, of: func(p *game.Player) Agent { return h.agents.of(p) }, fallback: ai}
+	// Harmful targets follow the threat model; the ranking behind each
+	// pick goes to the event log.
+	ai.SetThreatCombos(knownCombos)
+	ai.OnThreatAssessed = func(playerName, target string, ranked []threat.Assessment) {
+		if p := h.livePlayer(playerName); p != nil {
+			logThreats(g, h.log, p, "removal", target, ranked)
+		}
+	}
 
 	// Wire stack callbacks: log resolution events and apply state-based actions.
 	spellCasting.GetStack().OnResolve = func(item *abil.StackItem) {
@@ -89,6 +108,7 @@ func NewStackAwareHandler(g *game.Game, logOpt *EDHEventLog) *StackAwareHandler
 	spellCasting.GetStack().OnAfterResolve = func() {
 		h.g.ApplyStateBasedActions()
 	}
+	spellCasting.GetStack().OnCast = h.processCastTriggers
 
 	return h
 }
@@ -125,7 +145,7 @@ func (h *StackAwareHandler) OnOpponentPriority(g *game.Game, active *game.Player
 // phase. It removes the card from hand, puts it on the stack, runs
 // ProcessPriority to allow opponents to respond, resolves the stack, and
 // moves the card to the graveyard. The caller MUST have already paid
-// mana for the card.
+// mana for the card. Returns false if the spell was countered.
 func (h *StackAwareHandler) CastSpellThroughStack(ap *game.Player, c game.SimpleCard, casterName string) bool {
 	gs := h.gameState
 	playerAdapter := gs.GetPlayer(casterName)
@@ -145,6 +165,22 @@ func (h *StackAwareHandler) CastSpellThroughStack(ap *game.Player, c game.Simple
 		return false
 	}
 	ap.Hand = append(ap.Hand[:cidx], ap.Hand[cidx+1:]...)
+	return h.castSpell(ap, c, casterName, nil)
+}
+
+// castSpell puts c, already out of the zone it was cast from, on the stack
+// aimed at targets and runs the priority round. c ends in ap's graveyard.
+// Returns false if the spell was countered.
+func (h *StackAwareHandler) castSpell(ap *game.Player, c game.SimpleCard, casterName string, targets []*game.Player) bool {
+	playerAdapter := h.gameState.GetPlayer(casterName)
+	if playerAdapter == nil {
+		ap.Graveyard = append(ap.Graveyard, c)
+		return false
+	}
+	var spellTargets []any
+	for _, t := range targets {
+		spellTargets = append(spellTargets, h.gameState.GetPlayer(t.GetName()))
+	}
 
 	abilities, err := h.engine.ParseAndRegisterAbilities(c.OracleText, c)
 	if err != nil || len(abilities) == 0 {
@@ -174,7 +210,7 @@ func (h *StackAwareHandler) CastSpellThroughStack(ap *game.Player, c game.Simple
 	pm := h.spellCasting.GetPriorityManager()
 
 	// Cast the spell (puts on stack via priority manager, resets priority)
-	if err := pm.CastSpell(playerAdapter, spell, nil); err != nil {
+	if err := pm.CastSpell(playerAdapter, spell, spellTargets); err != nil {
 		logger.LogPlayer("Failed to cast %s through stack: %v", c.Name, err)
 		ap.Graveyard = append(ap.Graveyard, c)
 		return false
@@ -182,13 +218,15 @@ func (h *StackAwareHandler) CastSpellThroughStack(ap *game.Player, c game.Simple
 
 	logger.LogPlayer("%s casts %s through stack", ap.GetName(), c.Name)
 
+	candidate := h.spellCasting.GetStack().LastCastItem()
+
 	// Run full priority round — opponents can respond, then stack resolves
 	if err := h.spellCasting.ProcessPriority(); err != nil {
 		logger.LogCard("Priority round error for %s: %v", c.Name, err)
 	}
 
 	ap.Graveyard = append(ap.Graveyard, c)
-	return true
+	return candidate == nil || !candidate.Countered
 }
 
 // CastPermanentThroughStack routes a permanent spell through the stack.
@@ -272,9 +310,12 @@ func (h *StackAwareHandler) CastPermanentThroughStack(ap *game.Player, c game.Si
 		return false
 	}
 
-	// Spell resolved — create permanent on battlefield
+	// Spell resolved — create permanent on battlefield. castPermanentCard
+	// moves the card out of hand, which it left when it went on the stack.
+	ap.AddCardToHand(c)
 	perm, err := castPermanentCard(h.g, ap, c)
 	if err != nil || perm == nil {
+		ap.Hand = ap.Hand[:len(ap.Hand)-1]
 		ap.Graveyard = append(ap.Graveyard, c)
 		return false
 	}
@@ -297,7 +338,7 @@ func (h *StackAwareHandler) processETBTriggers(ap *game.Player, perm *game.Perma
 		return
 	}
 	src := perm.GetSource()
-	if src.OracleText == "" {
+	if src.OracleText == "" && !abil.HasCardScript(src.Name) {
 		return
 	}
 	abilities, err := engine.ParseAndRegisterAbilities(src.OracleText, src)
@@ -352,6 +393,101 @@ func (h *StackAwareHandler) processETBTriggers(ap *game.Player, perm *game.Perma
 	}
 }
 
+// processCastTriggers puts "whenever you cast" and "whenever an opponent
+// casts" triggered abilities onto the stack above the spell that was just
+// cast, recording the caster as "that player" so taxes like Rhystic
+// Study's are offered to them.
+func (h *StackAwareHandler) processCastTriggers(item *abil.StackItem) {
+	if item.Spell == nil {
+		return
+	}
+	caster := item.Controller
+	stack := h.spellCasting.GetStack()
+	for _, p := range h.g.GetPlayersRaw() {
+		if p.HasLost() {
+			continue
+		}
+		controller := h.gameState.GetPlayer(p.GetName())
+		if controller == nil {
+			continue
+		}
+		own := p.GetName() == caster.GetName()
+		for _, perm := range p.Battlefield {
+			src := perm.GetSource()
+			for _, ab := range h.castTriggersOf(src) {
+				if !castTriggerApplies(ab.OracleText, own, item.Spell) {
+					continue
+				}
+				w := h.window(controller)
+				targets := agentTargets(w.agent, w, ab)
+				stack.AddTriggeredAbility(ab, controller, caster, targets)
+				if h.log != nil {
+					h.log.Append(EDHEvent{
+						Turn:   h.g.GetTurnNumber(),
+						Phase:  phaseLabel(h.g.GetCurrentPhase()),
+						Kind:   EventTriggerResolved,
+						Actor:  p.GetName(),
+						Detail: src.Name + " cast trigger: " + ab.Name,
+						Target: item.Spell.Name,
+					})
+				}
+			}
+		}
+	}
+}
+
+// castTriggersOf returns src's "whenever ... casts" triggered abilities,
+// parsing its oracle text the first time the card is seen.
+func (h *StackAwareHandler) castTriggersOf(src game.SimpleCard) []*abil.Ability {
+	if triggers, ok := h.castTriggers[src.Name]; ok {
+		return triggers
+	}
+	var triggers []*abil.Ability
+	if strings.Contains(strings.ToLower(src.OracleText), " cast") {
+		abilities, _ := h.engine.ParseAndRegisterAbilities(src.OracleText, src)
+		for _, ab := range abilities {
+			if ab.Type == abil.Triggered && ab.TriggerCondition == abil.SpellCast {
+				triggers = append(triggers, ab)
+			}
+		}
+	}
+	if h.castTriggers == nil {
+		h.castTriggers = map[string][]*abil.Ability{}
+	}
+	h.castTriggers[src.Name] = triggers
+	return triggers
+}
+
+// castTriggerApplies matches a cast trigger's text against who cast the
+// spell and the spell's type. Triggers on other players' casts that aren't
+// "an opponent" or "a player" are skipped.
+func castTriggerApplies(text string, own bool, spell *abil.Spell) bool {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "whenever a player casts"):
+	case strings.Contains(lower, "whenever you cast"):
+		if !own {
+			return false
+		}
+	case strings.Contains(lower, "whenever an opponent casts"):
+		if own {
+			return false
+		}
+	default:
+		return false
+	}
+	typeLine := strings.ToLower(spell.TypeLine)
+	switch {
+	case strings.Contains(lower, "noncreature spell"):
+		return !strings.Contains(typeLine, "creature")
+	case strings.Contains(lower, "instant or sorcery spell"):
+		return strings.Contains(typeLine, "instant") || strings.Contains(typeLine, "sorcery")
+	case strings.Contains(lower, "creature spell"):
+		return strings.Contains(typeLine, "creature")
+	}
+	return true
+}
+
 // phaseLabel converts a game.Phase to the string label used by the
 // ability engine's priority-manager and AI systems.
 func phaseLabel(p game.Phase) string {
@@ -373,18 +509,60 @@ func phaseLabel(p game.Phase) string {
 	}
 }
 
+// SetAgent makes agent decide for the named player while they hold
+// priority and when their triggers are ordered.
+func (h *StackAwareHandler) SetAgent(playerName string, agent Agent) {
+	p := h.livePlayer(playerName)
+	if p == nil {
+		return
+	}
+	if h.agents == nil {
+		h.agents = seatAgents{}
+	}
+	h.agents[p] = agent
+}
+
 // aiDecision is called by the PriorityManager for each player when they
-// have priority. It uses the AIDecisionMaker to decide whether to
-// activate abilities or cast instants — now stack-aware.
+// have priority. It hands the priority window to the player's agent; a
+// nil response passes.
 func (h *StackAwareHandler) aiDecision(player abil.AbilityPlayer) *abil.PriorityDecision {
-	opponents := h.getOpponents(player)
-	context := h.ai.BuildDecisionContext(player, opponents, h.spellCasting.GetPriorityManager().GetPhase())
+	w := h.window(player)
+	if decision := w.agent.Respond(w); decision != nil {
+		return decision
+	}
+	return &abil.PriorityDecision{Action: abil.PriorityActionPass, Player: player}
+}
+
+// window builds player's view of the current priority window, with the
+// agent that decides for them.
+func (h *StackAwareHandler) window(player abil.AbilityPlayer) *PriorityWindow {
+	return &PriorityWindow{
+		Game:    h.g,
+		Player:  player,
+		Context: h.ai.BuildDecisionContext(player, h.getOpponents(player), h.spellCasting.GetPriorityManager().GetPhase()),
+		Stack:   h.spellCasting.GetStack(),
+		AI:      h.ai,
+		h:       h,
+		agent:   h.agents.of(h.livePlayer(player.GetName())),
+	}
+}
 
-	// If there's an opponent's spell on the stack, consider countering it
+// defaultResponse is DefaultAgent's priority decision: counter an
+// opponent's spell or ability worth countering, activate abilities the
+// AIDecisionMaker rates, or cast the best instant — all stack-aware.
+func (h *StackAwareHandler) defaultResponse(w *PriorityWindow) *abil.PriorityDecision {
+	player, context := w.Player, w.Context
+
+	// Under split second nobody can respond; don't spend mana on an
+	// action the priority manager would reject.
 	stack := h.spellCasting.GetStack()
-	if top := stack.Peek(); top != nil && top.Type == abil.StackItemSpell && top.Controller.GetName() != player.GetName() {
-		spell := top.Spell
-		if counterDecision := h.tryCounterSpell(player, spell.Name, spell.CMC); counterDecision != nil {
+	if stack.SplitSecondActive() {
+		return &abil.PriorityDecision{Action: abil.PriorityActionPass, Player: player}
+	}
+
+	// If there's an opponent's spell or ability on the stack, consider countering it
+	if top := stack.Peek(); top != nil && !top.Countered && top.Controller.GetName() != player.GetName() {
+		if counterDecision := h.tryCounterSpell(player, top); counterDecision != nil {
 			return counterDecision
 		}
 	}
@@ -394,7 +572,7 @@ func (h *StackAwareHandler) aiDecision(player abil.AbilityPlayer) *abil.Priority
 		chosen := h.ai.ChooseAbilitiesToActivate(abilities, context)
 		if len(chosen) > 0 {
 			logger.LogPlayer("%s activates %s during priority", player.GetName(), chosen[0].Name)
-			targets := h.ai.ChooseTargetsFor(chosen[0], context)
+			targets := agentTargets(w.agent, w, chosen[0])
 			return &abil.PriorityDecision{
 				Action:  abil.PriorityActionActivateAbility,
 				Ability: chosen[0],
@@ -415,30 +593,31 @@ func (h *StackAwareHandler) aiDecision(player abil.AbilityPlayer) *abil.Priority
 	}
 }
 
-// tryCounterSpell checks whether the player can counter the given
-// opponent spell. Returns a CastSpell decision if a suitable counterspell
-// is found, or nil.
-func (h *StackAwareHandler) tryCounterSpell(player abil.AbilityPlayer, opponentSpellName string, opponentSpellCMC int) *abil.PriorityDecision {
-	// Find the underlying game.Player for mana checking
-	var gp *game.Player
-	for _, p := range h.g.GetPlayersRaw() {
-		if p.GetName() == player.GetName() && !p.HasLost() {
-			gp = p
-			break
-		}
-	}
-	if gp == nil {
+// tryCounterSpell checks whether the player can counter the opponent's
+// spell or ability on top of the stack. Spells that can't be countered are
+// left alone, tax counters are only used when the caster can't pay, and
+// abilities are answered only with cards like Stifle. Returns a CastSpell
+// decision targeting top, or nil.
+func (h *StackAwareHandler) tryCounterSpell(player abil.AbilityPlayer, top *abil.StackItem) *abil.PriorityDecision {
+	gp := h.livePlayer(player.GetName())
+	if gp == nil || top.CantBeCountered() {
 		return nil
 	}
 
-	strategy := NewCounterspellStrategy(gp)
-	shouldCounter, counter := strategy.ShouldCounterSpell(opponentSpellName, opponentSpellCMC)
-	if !shouldCounter {
-		return nil
+	strategy := NewCounterspellStrategy(gp).WithGame(h.g)
+	var shouldCounter bool
+	var counter game.SimpleCard
+	targetName := ""
+	switch top.Type {
+	case abil.StackItemSpell:
+		targetName = top.Spell.Name
+		shouldCounter, counter = strategy.ShouldCounterSpellFrom(h.livePlayer(top.Controller.GetName()), stackItemSource(top), top.Spell.CMC)
+	case abil.StackItemAbility:
+		source := stackItemSource(top)
+		targetName = source.Name
+		shouldCounter, counter = strategy.ShouldCounterAbility(source)
 	}
-
-	// Pay mana for the counterspell
-	if !gp.PayForCard(counter) {
+	if !shouldCounter {
 		return nil
 	}
 
@@ -451,6 +630,24 @@ func (h *StackAwareHandler) tryCounterSpell(player abil.AbilityPlayer, opponentS
 	for _, ab := range abilities {
 		effects = append(effects, ab.Effects...)
 	}
+	if !h.canCounterTarget(effects, top, player) {
+		return nil
+	}
+
+	// Spells that can wait are left to a later opponent who looks able
+	// to answer them.
+	caster := h.livePlayer(top.Controller.GetName())
+	if !mustAnswer(h.g, gp, caster, top, stackItemSource(top)) {
+		if o := leaveToLaterOpponent(h.g, gp, caster); o != nil {
+			logger.LogPlayer("%s leaves %s to %s", player.GetName(), targetName, o.GetName())
+			return nil
+		}
+	}
+
+	// Pay mana for the counterspell, tapping untapped sources as needed
+	if !payWithOpenMana(h.g, gp, counter) {
+		return nil
+	}
 
 	spell := &abil.Spell{
 		Name:       counter.Name,
@@ -462,14 +659,92 @@ func (h *StackAwareHandler) tryCounterSpell(player abil.AbilityPlayer, opponentS
 		Source:     counter,
 	}
 
-	logger.LogPlayer("%s counters %s with %s", player.GetName(), opponentSpellName, counter.Name)
+	logger.LogPlayer("%s counters %s with %s", player.GetName(), targetName, counter.Name)
+	if h.log != nil {
+		logThreats(h.g, h.log, gp, "counter "+targetName, top.Controller.GetName(), rankThreats(h.g, gp))
+	}
 	return &abil.PriorityDecision{
-		Action: abil.PriorityActionCastSpell,
-		Spell:  spell,
-		Player: player,
+		Action:  abil.PriorityActionCastSpell,
+		Spell:   spell,
+		Targets: []interface{}{top},
+		Player:  player,
 	}
 }
 
+// castResponse casts c from gp's hand as player's response, paying with
+// open mana. A counterspell targets top, which it must be able to
+// target. Returns nil if c can't be cast.
+func (h *StackAwareHandler) castResponse(player abil.AbilityPlayer, gp *game.Player, c game.SimpleCard, top *abil.StackItem) *abil.PriorityDecision {
+	abilities, err := h.engine.ParseAndRegisterAbilities(c.OracleText, c)
+	if err != nil || len(abilities) == 0 {
+		return nil
+	}
+	var effects []abil.Effect
+	for _, ab := range abilities {
+		effects = append(effects, ab.Effects...)
+	}
+	var targets []interface{}
+	if top != nil {
+		if !h.canCounterTarget(effects, top, player) {
+			return nil
+		}
+		targets = []interface{}{top}
+	}
+	if !payWithOpenMana(h.g, gp, c) {
+		return nil
+	}
+	spell := &abil.Spell{
+		Name:       c.Name,
+		ManaCost:   c.ManaCost,
+		CMC:        int(c.GetMinManaCost().Total()),
+		TypeLine:   c.TypeLine,
+		OracleText: c.OracleText,
+		Effects:    effects,
+		Source:     c,
+	}
+	return &abil.PriorityDecision{Action: abil.PriorityActionCastSpell, Spell: spell, Targets: targets, Player: player}
+}
+
+// canCounterTarget reports whether the counter's first CounterSpell effect
+// can legally target top, e.g. Negate can't target a creature spell and
+// Stifle can't target a spell.
+func (h *StackAwareHandler) canCounterTarget(effects []abil.Effect, top *abil.StackItem, player abil.AbilityPlayer) bool {
+	for _, eff := range effects {
+		if eff.Type != abil.CounterSpell || len(eff.Targets) == 0 {
+			continue
+		}
+		return h.engine.IsLegalTarget(top, eff.Targets[0], player)
+	}
+	return false
+}
+
+// livePlayer returns the named player if they are still in the game.
+func (h *StackAwareHandler) livePlayer(name string) *game.Player {
+	for _, p := range h.g.GetPlayersRaw() {
+		if p.GetName() == name && !p.HasLost() {
+			return p
+		}
+	}
+	return nil
+}
+
+// stackItemSource returns the card a stack item came from, as much of it
+// as the item knows.
+func stackItemSource(item *abil.StackItem) game.SimpleCard {
+	if s := item.Spell; s != nil {
+		return game.SimpleCard{Name: s.Name, TypeLine: s.TypeLine, OracleText: s.OracleText, ManaCost: s.ManaCost}
+	}
+	switch src := item.Source.(type) {
+	case game.SimpleCard:
+		return src
+	case *game.Permanent:
+		return src.GetSource()
+	case interface{ GetName() string }:
+		return game.SimpleCard{Name: src.GetName()}
+	}
+	return game.SimpleCard{}
+}
+
 // instantScore returns a priority score for casting an instant in the
 // current context. Higher values are better. The scorer considers:
 //   - The top spell on the stack (opponent's threat we could respond to)
@@ -528,7 +803,8 @@ func instantScore(card game.SimpleCard, gp *game.Player, stackTop *abil.StackIte
 	return score
 }
 
-// decideInstantSpell checks the player's hand for castable instants,
+// decideInstantSpell checks the player's hand for castable instants the
+// interaction planner doesn't keep for a later window (instantWaits),
 // evaluates them with a context-aware scorer, and casts the best option.
 // pays their mana cost immediately (as CR 601.2h requires), and returns
 // a CastSpell decision. Returns nil if no instant is worth casting.
@@ -567,7 +843,12 @@ func (h *StackAwareHandler) decideInstantSpell(player abil.AbilityPlayer, contex
 		if !ok || !card.IsInstant() || card.OracleText == "" {
 			continue
 		}
-		if !gp.CanPayForCard(card) {
+		// Counters need a target worth countering; tryCounterSpell
+		// already passed on the top of the stack.
+		if card.IsCounterspell() || CountersAbilities(card) {
+			continue
+		}
+		if !canPayWithOpenMana(h.g, gp, card) || instantWaits(h.g, gp, card, stackTop) {
 			continue
 		}
 		score := instantScore(card, gp, stackTop, opponentHasCreatures, isCombat)
@@ -585,7 +866,7 @@ func (h *StackAwareHandler) decideInstantSpell(player abil.AbilityPlayer, contex
 
 	best := candidates[0]
 
-	if !gp.PayForCard(best.card) {
+	if !payWithOpenMana(h.g, gp, best.card) {
 		return nil
 	}
 
@@ -695,7 +976,7 @@ func (h *StackAwareHandler) ProcessPendingGameTriggers() {
 	if !h.g.HasPendingTriggers() {
 		return
 	}
-	pending := h.g.DrainPendingTriggers()
+	pending := orderTriggers(h.g, h.agents, h.g.DrainPendingTriggers())
 
 	for _, pt := range pending {
 		if pt.Trigger == nil || pt.Trigger.Action == nil {
@@ -714,7 +995,7 @@ func (h *StackAwareHandler) ProcessPendingGameTriggers() {
 				Detail: "trigger",
 			})
 		}
-		pt.Trigger.Action(h.g, pt.Event)
+		pt.Trigger.Action(h.g, pt.Trigger, pt.Event)
 	}
 
 	// Run a priority round so opponents can respond
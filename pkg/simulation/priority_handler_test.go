@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"testing"
 
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
 	"github.com/mtgsim/mtgsim/pkg/game"
 )
 
@@ -145,3 +146,135 @@ func repeat(c game.SimpleCard, n int) []game.SimpleCard {
 	}
 	return out
 }
+
+var (
+	manaLeak = game.SimpleCard{Name: "Mana Leak", TypeLine: "Instant", ManaCost: "{1}{U}",
+		OracleText: "Counter target spell unless its controller pays {3}."}
+	stifle = game.SimpleCard{Name: "Stifle", TypeLine: "Instant", ManaCost: "{U}",
+		OracleText: "Counter target activated or triggered ability. (Mana abilities can't be targeted.)"}
+	rhysticStudy = game.SimpleCard{Name: "Rhystic Study", TypeLine: "Enchantment", ManaCost: "{2}{U}",
+		OracleText: "Whenever an opponent casts a spell, you may draw a card unless that player pays {1}."}
+	bigThreat = game.SimpleCard{Name: "Craterhoof Behemoth", TypeLine: "Creature — Beast", ManaCost: "{5}{G}{G}{G}",
+		OracleText: "Haste", Power: "5", Toughness: "5"}
+)
+
+// newCounterTestHandler returns a handler for a two-player game where B
+// holds hand and two untapped blue mana.
+func newCounterTestHandler(t *testing.T, hand ...game.SimpleCard) (*StackAwareHandler, *game.Player, *game.Player) {
+	t.Helper()
+	a := game.NewEDHPlayer("A")
+	b := game.NewEDHPlayer("B")
+	g := game.NewGame(a, b)
+	b.Hand = append(b.Hand, hand...)
+	b.AddManaToPool(game.Blue, 2)
+	return NewStackAwareHandler(g, nil), a, b
+}
+
+func TestTryCounterSpell_ManaLeakNeedsCasterTapped(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		casterMana  int
+		wantCounter bool
+	}{
+		{"caster can pay the tax", 3, false},
+		{"caster is tapped out", 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, a, _ := newCounterTestHandler(t, manaLeak)
+			a.AddManaToPool(game.Green, tc.casterMana)
+			stack := h.spellCasting.GetStack()
+			stack.AddSpell(&abil.Spell{Name: bigThreat.Name, TypeLine: bigThreat.TypeLine, CMC: 8}, h.gameState.GetPlayer("A"), nil)
+
+			d := h.tryCounterSpell(h.gameState.GetPlayer("B"), stack.Peek())
+			if (d != nil) != tc.wantCounter {
+				t.Fatalf("counter decision = %+v, want counter %v", d, tc.wantCounter)
+			}
+			if d != nil && (len(d.Targets) != 1 || d.Targets[0] != stack.Peek()) {
+				t.Errorf("Mana Leak should target the spell on top, got %v", d.Targets)
+			}
+		})
+	}
+}
+
+func TestTryCounterSpell_StifleHitsComboTrigger(t *testing.T) {
+	h, _, b := newCounterTestHandler(t, stifle)
+	stack := h.spellCasting.GetStack()
+	oracle := game.SimpleCard{Name: "Thassa's Oracle", TypeLine: "Creature — Merfolk Wizard",
+		OracleText: "When Thassa's Oracle enters, look at the top X cards of your library, where X is your devotion to blue. Put up to one of them on top of your library and the rest on the bottom of your library in a random order. If X is greater than or equal to the number of cards in your library, you win the game."}
+	stack.AddAbility(&abil.Ability{Name: "Thassa's Oracle ETB", Type: abil.Triggered, Source: oracle}, h.gameState.GetPlayer("A"), nil)
+
+	d := h.tryCounterSpell(h.gameState.GetPlayer("B"), stack.Peek())
+	if d == nil || d.Spell.Name != "Stifle" {
+		t.Fatalf("expected Stifle on the Oracle trigger, got %+v", d)
+	}
+	if len(b.Hand) != 1 {
+		t.Errorf("the decision shouldn't move Stifle out of hand yet")
+	}
+
+	stack.AddSpell(&abil.Spell{Name: "Sol Ring", TypeLine: "Artifact", CMC: 1}, h.gameState.GetPlayer("A"), nil)
+	if d := h.tryCounterSpell(h.gameState.GetPlayer("B"), stack.Peek()); d != nil {
+		t.Errorf("Stifle can't counter a spell, got %+v", d)
+	}
+}
+
+func TestCastSpellThroughStack_RhysticStudyTax(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		casterMana int
+		wantDraw   bool
+	}{
+		{"caster pays", 1, false},
+		{"caster can't pay", 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := game.NewEDHPlayer("A")
+			b := game.NewEDHPlayer("B")
+			g := game.NewGame(a, b)
+			h := NewStackAwareHandler(g, nil)
+			b.PutTokenOnBattlefield(rhysticStudy)
+			b.Library = repeat(game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"}, 5)
+			divination := game.SimpleCard{Name: "Divination", TypeLine: "Sorcery", ManaCost: "{2}{U}", OracleText: "Draw two cards."}
+			a.Hand = []game.SimpleCard{divination}
+			a.Library = repeat(game.SimpleCard{Name: "Forest", TypeLine: "Basic Land — Forest"}, 5)
+			a.AddManaToPool(game.Green, tc.casterMana)
+
+			h.CastSpellThroughStack(a, divination, "A")
+
+			drew := len(b.Hand) == 1
+			if drew != tc.wantDraw {
+				t.Errorf("Rhystic Study drew = %v, want %v", drew, tc.wantDraw)
+			}
+			if tc.casterMana > 0 && a.GetManaPool()[game.Green] != 0 {
+				t.Errorf("A should have paid {1}, pool %v", a.GetManaPool())
+			}
+		})
+	}
+}
+
+func TestCastSpellThroughStack_CastTriggerTargetsAnOpponentsCreature(t *testing.T) {
+	a := game.NewEDHPlayer("A")
+	b := game.NewEDHPlayer("B")
+	g := game.NewGame(a, b)
+	h := NewStackAwareHandler(g, nil)
+	elf := game.SimpleCard{Name: "Llanowar Elves", TypeLine: "Creature — Elf Druid", Power: "1", Toughness: "1"}
+	b.PutTokenOnBattlefield(elf)
+	b.PutTokenOnBattlefield(game.SimpleCard{Name: "Spellshock Adept", TypeLine: "Enchantment",
+		OracleText: "Whenever you cast an instant or sorcery spell, Spellshock Adept deals 1 damage to target creature."})
+	a.PutTokenOnBattlefield(elf)
+	divination := game.SimpleCard{Name: "Divination", TypeLine: "Sorcery", ManaCost: "{2}{U}", OracleText: "Draw two cards."}
+	b.Hand = []game.SimpleCard{divination}
+	b.Library = repeat(game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"}, 5)
+
+	h.CastSpellThroughStack(b, divination, "B")
+	g.ApplyStateBasedActions()
+
+	if len(a.Battlefield) != 0 {
+		t.Errorf("the trigger should kill A's elf, A has %v", a.Battlefield)
+	}
+	if len(b.Battlefield) != 2 {
+		t.Errorf("the trigger shouldn't hit its controller's own elf, B has %v", b.Battlefield)
+	}
+	if len(h.castTriggers) == 0 {
+		t.Error("the battlefield's cast triggers should be cached")
+	}
+}
@@ -0,0 +1,112 @@
+package simulation
+
+import (
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Style is how the planners pilot a deck of one card.Archetype. A seat's
+// archetype comes from EDHSeat.Archetype (a deck header comment or a CLI
+// flag) or, failing that, from the roles of its cards; DefaultAgent
+// carries it and styleOf reads it back.
+type Style struct {
+	Archetype card.Archetype
+	// AttackDamage scales what damage dealt is worth to the attack
+	// planner (scoreAttack), and CrackBack what it charges for the
+	// opponents' swing back. A lethal attack or crack-back counts in full
+	// either way.
+	AttackDamage, CrackBack float64
+	// HoldUpThreat is the leading opponent's threat.Assessment.WinChance
+	// at which the seat keeps mana open for an answer (holdUpMana).
+	// HoldSpare also keeps it open whenever the rest of the hand doesn't
+	// need the mana.
+	HoldUpThreat float64
+	HoldSpare    bool
+	// TutorRoles is what a tutor adds to a card's score for each of its
+	// roles, on top of the default ranking (tutorContext.score).
+	TutorRoles map[card.Role]int
+	// ProtectCombo holds a ready combo back while an opponent looks able
+	// to stop it and the seat has no counterspell or protection to back
+	// it up, unless an opponent is about to win (goesForTheWin).
+	ProtectCombo bool
+}
+
+var styles = map[card.Archetype]Style{
+	card.ArchetypeAggro: {
+		AttackDamage: 1.5, CrackBack: 0.5,
+		HoldUpThreat: counterWinChance,
+		TutorRoles:   map[card.Role]int{card.RoleWincon: 4, card.RoleProtection: 3, card.RoleRamp: 2},
+	},
+	card.ArchetypeMidrange: {
+		AttackDamage: 1, CrackBack: 1,
+		HoldUpThreat: holdUpThreat, HoldSpare: true,
+	},
+	card.ArchetypeControl: {
+		AttackDamage: 0.75, CrackBack: 1.5,
+		HoldUpThreat: 0, HoldSpare: true,
+		TutorRoles: map[card.Role]int{
+			card.RoleCounterspell: 8, card.RoleBoardWipe: 6, card.RoleRemoval: 5, card.RoleDrawEngine: 4, card.RoleCardDraw: 2,
+		},
+		ProtectCombo: true,
+	},
+	card.ArchetypeStax: {
+		AttackDamage: 1, CrackBack: 1.25,
+		HoldUpThreat: holdUpThreat, HoldSpare: true,
+		TutorRoles:   map[card.Role]int{card.RoleStax: 10, card.RoleFastMana: 4, card.RoleRemoval: 3},
+		ProtectCombo: true,
+	},
+	card.ArchetypeTurbo: {
+		AttackDamage: 1, CrackBack: 1,
+		HoldUpThreat: counterWinChance,
+		TutorRoles: map[card.Role]int{
+			card.RoleFastMana: 6, card.RoleTutor: 6, card.RoleProtection: 4, card.RoleCardDraw: 3, card.RoleCounterspell: 2,
+		},
+	},
+}
+
+// StyleFor returns the style of archetype a. An empty or unknown
+// archetype plays midrange.
+func StyleFor(a card.Archetype) Style {
+	s, ok := styles[a]
+	if !ok {
+		a = card.ArchetypeMidrange
+		s = styles[a]
+	}
+	s.Archetype = a
+	return s
+}
+
+// styleOf returns the style agent pilots with: its archetype's if it has
+// one, else midrange.
+func styleOf(agent Agent) Style {
+	if a, ok := agent.(interface{ archetype() card.Archetype }); ok {
+		return StyleFor(a.archetype())
+	}
+	return StyleFor("")
+}
+
+// seatArchetype is s.Archetype, or the archetype its cards suggest.
+func seatArchetype(s EDHSeat) card.Archetype {
+	if s.Archetype != "" {
+		return s.Archetype
+	}
+	cards := append(append([]game.SimpleCard(nil), s.Library...), seatCommanders(s)...)
+	return card.InferArchetype(cards)
+}
+
+// goesForTheWin reports whether p, with a combo ready, goes for it now.
+// A style that protects its combo waits while an opponent looks able to
+// stop it and p holds no counterspell or protection, unless an opponent
+// is likely to win first.
+func (s Style) goesForTheWin(g *game.Game, p *game.Player) bool {
+	if !s.ProtectCombo || g == nil || !opponentsHoldInteraction(g, p) {
+		return true
+	}
+	for _, c := range p.Hand {
+		if card.ClassifyRoles(c).HasAny(card.RoleCounterspell, card.RoleProtection) {
+			return true
+		}
+	}
+	ranked := rankThreats(g, p)
+	return len(ranked) > 0 && ranked[0].WinChance >= counterWinChance
+}
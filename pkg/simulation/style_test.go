@@ -0,0 +1,97 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+func TestSeatArchetype_PrefersTheDecksOwn(t *testing.T) {
+	thalia := game.SimpleCard{Name: "Thalia, Guardian of Thraben", TypeLine: "Legendary Creature — Human Soldier", ManaCost: "{1}{W}", OracleText: "First strike\nNoncreature spells cost {1} more to cast."}
+	seat := EDHSeat{Library: []game.SimpleCard{thalia, testIsland, testIsland}}
+	if got := seatArchetype(seat); got != card.ArchetypeStax {
+		t.Fatalf("a deck of stax pieces should play stax, got %s", got)
+	}
+	seat.Archetype = card.ArchetypeTurbo
+	if got := seatArchetype(seat); got != card.ArchetypeTurbo {
+		t.Fatalf("the seat's archetype should win over inference, got %s", got)
+	}
+
+	p := game.NewEDHPlayer("P")
+	agents := newSeatAgents([]*game.Player{p}, []EDHSeat{seat}, nil)
+	if got := styleOf(agents.of(p)).Archetype; got != card.ArchetypeTurbo {
+		t.Errorf("the default agent should pilot the seat's archetype, got %s", got)
+	}
+	if got := styleOf(nil).Archetype; got != card.ArchetypeMidrange {
+		t.Errorf("no agent plays midrange, got %s", got)
+	}
+}
+
+func TestHoldUpMana_FollowsTheStyle(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	g := game.NewGame(p, game.NewEDHPlayer("Opp"))
+	withLands(p, 4)
+	ogre := game.SimpleCard{Name: "Ogre", TypeLine: "Creature", ManaCost: "{3}{U}", Power: "4", Toughness: "4"}
+	p.Hand = []game.SimpleCard{testCounterspell, ogre}
+
+	if hold := holdUpMana(g, p, StyleFor(card.ArchetypeMidrange)); hold.Total() != 0 {
+		t.Fatalf("midrange casts the Ogre when nothing threatens, held %v", hold)
+	}
+	if hold := holdUpMana(g, p, StyleFor(card.ArchetypeControl)); hold[game.Blue] != 2 {
+		t.Fatalf("control always holds its answer up, held %v", hold)
+	}
+
+	withLands(p, 2)
+	if hold := holdUpMana(g, p, StyleFor(card.ArchetypeMidrange)); hold[game.Blue] != 2 {
+		t.Fatalf("midrange holds spare mana for its answer, held %v", hold)
+	}
+	if hold := holdUpMana(g, p, StyleFor(card.ArchetypeAggro)); hold.Total() != 0 {
+		t.Errorf("aggro keeps nothing back without a threat, held %v", hold)
+	}
+}
+
+// batteryPod seats P with the Mana Battery and Battery Sink loop against
+// an opponent with cards in hand and two untapped lands.
+func batteryPod(a card.Archetype) (*game.Game, *game.Player, *game.Player, DefaultAgent) {
+	battery := game.SimpleCard{Name: "Mana Battery", TypeLine: "Artifact", OracleText: "{T}: Add {C}{C}.\n{1}: Untap Mana Battery."}
+	sink := game.SimpleCard{Name: "Battery Sink", TypeLine: "Artifact", OracleText: "{1}: Battery Sink deals 1 damage to any target."}
+	p := game.NewEDHPlayer("P")
+	opp := game.NewEDHPlayer("Opp")
+	g := game.NewGame(p, opp)
+	for _, c := range []game.SimpleCard{battery, sink} {
+		p.Battlefield = append(p.Battlefield, game.NewPermanent(c, p, p))
+	}
+	withLands(opp, 2)
+	opp.Hand = []game.SimpleCard{testIsland, testIsland}
+	agent := DefaultAgent{Archetype: a, Combos: combo.NewIndex(&combo.FindMyCombosResult{Included: []combo.Variant{
+		knownVariant("battery-sink", "Infinite damage", "Tap and untap Mana Battery.\nActivate Battery Sink.",
+			knownPiece("Mana Battery"), knownPiece("Battery Sink")),
+	}}, nil)}
+	return g, p, opp, agent
+}
+
+func TestCEDHComboFinish_ControlWaitsOutOpenInteraction(t *testing.T) {
+	g, p, opp, agent := batteryPod(card.ArchetypeControl)
+	log := NewEDHEventLog()
+	if attemptCEDHComboFinish(g, p, agent, log, nil, nil) {
+		t.Fatal("control shouldn't go off into open mana without backup")
+	}
+	if opp.HasLost() {
+		t.Fatal("the opponent should still be in the game")
+	}
+	if events := log.Events(); len(events) != 1 || events[0].Kind != EventComboHeld {
+		t.Fatalf("expected the held combo to be logged, events %+v", events)
+	}
+
+	p.Hand = append(p.Hand, testCounterspell)
+	if !attemptCEDHComboFinish(g, p, agent, nil, nil, nil) || !opp.HasLost() {
+		t.Fatal("with a counterspell to back it up, control goes for the win")
+	}
+
+	g, p, opp, agent = batteryPod(card.ArchetypeTurbo)
+	if !attemptCEDHComboFinish(g, p, agent, nil, nil, nil) || !opp.HasLost() {
+		t.Fatal("turbo goes off as soon as it can")
+	}
+}
@@ -1,95 +1,51 @@
 package simulation
 
 import (
-	"strings"
-
+	"github.com/mtgsim/mtgsim/pkg/combo"
 	"github.com/mtgsim/mtgsim/pkg/game"
+	"github.com/mtgsim/mtgsim/pkg/threat"
 )
 
-// isCardAdvantageEngine returns true for permanents that generate
-// incremental card advantage over time.
-func isCardAdvantageEngine(name string) bool {
-	lower := strings.ToLower(name)
-	engines := []string{
-		"rhystic study", "mystic remora", "the one ring",
-		"consecrated sphinx", "dark confidant", "phyrexian arena",
-		"necropotence", "esper sentinel", "trouble in pairs",
-		"tatyova", "chulane", "beast whisperer", "guardian project",
-		"the great henge", "sylvan library", "mangara, the diplomat",
-		"smothering tithe", "monologue tax",
-	}
-	for _, e := range engines {
-		if strings.Contains(lower, e) {
-			return true
-		}
-	}
-	return false
-}
-
 // chooseAttackTarget picks the most threatening living opponent for the
-// active player to attack. The score combines signals:
-//
-//   - Opponent's combined creature power (raw board pressure)
-//   - Opponent's life deficit from 40 (finish-the-leader incentive)
-//   - Commander damage they have already inflicted on the attacker
-//     (preempt the 21-damage SBA, CR 704.5u)
-//   - Card advantage engines on the opponent's board (value threats)
+// active player to attack, ranked by threat.Rank: the opponent's chance
+// to win next turn by combo, combat or commander damage, plus board
+// pressure, life deficit, commander damage already dealt to the attacker
+// (CR 704.5u), card engines and stax pieces.
 //
 // Ties fall back to the next-living-opponent in seat order so behaviour
-// remains deterministic for a given seed.
+// remains deterministic for a given seed. Opponents are read through the
+// attacker's view, so only public information counts.
 func chooseAttackTarget(g *game.Game, attacker *game.Player) *game.Player {
-	type scored struct {
-		p     *game.Player
-		score int
-		seat  int
-	}
-	var best *scored
-	startSeat := indexOfPlayer(g, attacker)
-	players := g.GetPlayersRaw()
-	n := len(players)
-	for i := 1; i < n; i++ {
-		opp := players[(startSeat+i)%n]
-		if opp == attacker || opp.HasLost() {
-			continue
-		}
-		s := threatScore(attacker, opp)
-		entry := &scored{p: opp, score: s, seat: i}
-		if best == nil || entry.score > best.score || (entry.score == best.score && entry.seat < best.seat) {
-			best = entry
-		}
-	}
-	if best == nil {
+	ranked := rankThreats(g, attacker)
+	if len(ranked) == 0 {
 		return nil
 	}
-	return best.p
+	return ranked[0].Player
 }
 
-// threatScore assigns a numeric danger level to opp from attacker's
-// point of view. Heuristic only — kept deterministic and side-effect
-// free so it can be unit tested in isolation.
-func threatScore(attacker, opp *game.Player) int {
-	board := 0
-	cardEngines := 0
-	for _, perm := range opp.GetCreatures() {
-		board += perm.GetPower()
-		// Count card advantage engines on creatures (e.g., Consecrated Sphinx)
-		if isCardAdvantageEngine(perm.GetName()) {
-			cardEngines++
-		}
-	}
-	// Count non-creature card advantage engines on battlefield
-	for _, perm := range opp.Battlefield {
-		if !perm.IsCreature() && isCardAdvantageEngine(perm.GetName()) {
-			cardEngines++
-		}
-	}
-	lifeDeficit := 40 - opp.GetLifeTotal()
-	if lifeDeficit < 0 {
-		lifeDeficit = 0
-	}
-	cmdrDmg := 0
-	for _, name := range opp.GetCommanderNames() {
-		cmdrDmg += attacker.CommanderDamageFrom(opp, name)
-	}
-	return board*2 + lifeDeficit/4 + cmdrDmg*3 + cardEngines*5
+// rankThreats ranks viewer's opponents with threat.Rank.
+func rankThreats(g *game.Game, viewer *game.Player) []threat.Assessment {
+	return threat.Rank(g.ViewFor(viewer), threatCombos)
+}
+
+// threatCombos returns the combo lines p is assumed to be playing toward.
+// Opponents' decklists are not known, so everyone is assumed to play the
+// runner's known lines.
+func threatCombos(*game.Player) *combo.Index { return knownCombos }
+
+// logThreats records the threat breakdown behind actor's decision against
+// target. decision names the kind of decision: "attack", "removal" or
+// "counter" followed by the countered spell.
+func logThreats(g *game.Game, log *EDHEventLog, actor *game.Player, decision, target string, ranked []threat.Assessment) {
+	if log == nil || len(ranked) == 0 {
+		return
+	}
+	log.Append(EDHEvent{
+		Turn:   g.GetTurnNumber(),
+		Phase:  phaseName(g.GetCurrentPhase()),
+		Kind:   EventThreatAssessed,
+		Actor:  actor.GetName(),
+		Target: target,
+		Detail: decision + ": " + threat.Breakdown(ranked),
+	})
 }
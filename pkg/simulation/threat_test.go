@@ -3,6 +3,7 @@ package simulation
 import (
 	"testing"
 
+	abil "github.com/mtgsim/mtgsim/pkg/ability"
 	"github.com/mtgsim/mtgsim/pkg/game"
 )
 
@@ -114,3 +115,117 @@ func TestChooseAttackTarget_TieBreakBySeatOrder(t *testing.T) {
 		t.Fatalf("expected next-in-seat (p2) on tie, got %v", got)
 	}
 }
+
+// threatEvents returns the threat_assessed events in log.
+func threatEvents(log *EDHEventLog) []EDHEvent {
+	var out []EDHEvent
+	for _, e := range log.Events() {
+		if e.Kind == EventThreatAssessed {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestTryCounterSpell_CountersTheLeadingThreatAtEqualCost(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		casterBoard int
+		otherBoard  int
+		wantCounter bool
+	}{
+		{"caster leads", 10, 0, true},
+		{"someone else leads", 0, 10, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b, c := makeTestPlayer("A"), makeTestPlayer("B"), makeTestPlayer("C")
+			g := game.NewGame(a, b, c)
+			summonOnto(t, g, a, tc.casterBoard)
+			summonOnto(t, g, c, tc.otherBoard)
+			b.Hand = []game.SimpleCard{testCounterspell}
+			b.AddManaToPool(game.Blue, 2)
+			log := NewEDHEventLog()
+			h := NewStackAwareHandler(g, log)
+
+			stack := h.spellCasting.GetStack()
+			stack.AddSpell(&abil.Spell{Name: "Arcane Signet", TypeLine: "Artifact", CMC: 2}, h.gameState.GetPlayer("A"), nil)
+			d := h.tryCounterSpell(h.gameState.GetPlayer("B"), stack.Peek())
+			if (d != nil) != tc.wantCounter {
+				t.Fatalf("counter decision = %+v, want counter %v", d, tc.wantCounter)
+			}
+			events := threatEvents(log)
+			if !tc.wantCounter {
+				if len(events) != 0 {
+					t.Errorf("no counter, no threat event; got %+v", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0].Actor != "B" || events[0].Target != "A" {
+				t.Fatalf("expected B's threat breakdown against A, got %+v", events)
+			}
+			if want := "counter Arcane Signet: A score"; events[0].Detail[:len(want)] != want {
+				t.Errorf("detail should start with %q, got %q", want, events[0].Detail)
+			}
+		})
+	}
+}
+
+func TestTryCounterSpell_CountersAPlayerAboutToWin(t *testing.T) {
+	a, b, c := makeTestPlayer("A"), makeTestPlayer("B"), makeTestPlayer("C")
+	g := game.NewGame(a, b, c)
+	// C has the bigger board, but A shows a combo line: Oracle and
+	// Consultation are public, and A has the mana to find them again.
+	summonOnto(t, g, c, 30)
+	for i := 0; i < 5; i++ {
+		a.PutTokenOnBattlefield(testIsland)
+	}
+	a.Graveyard = []game.SimpleCard{testOracle, testConsultation}
+	a.Hand = []game.SimpleCard{testForest}
+	b.Hand = []game.SimpleCard{testCounterspell}
+	b.AddManaToPool(game.Blue, 2)
+	h := NewStackAwareHandler(g, nil)
+
+	stack := h.spellCasting.GetStack()
+	stack.AddSpell(&abil.Spell{Name: "Ponder", TypeLine: "Sorcery", CMC: 1}, h.gameState.GetPlayer("A"), nil)
+	if d := h.tryCounterSpell(h.gameState.GetPlayer("B"), stack.Peek()); d == nil {
+		t.Fatal("a player likely to win next turn should have even a cheap spell countered")
+	}
+}
+
+func TestStackAwareHandler_RemovalTargetsTheLeadingThreat(t *testing.T) {
+	a, c := makeTestPlayer("A"), makeTestPlayer("C")
+	g := game.NewGame(a, makeTestPlayer("B"), c)
+	summonOnto(t, g, c, 12)
+	log := NewEDHEventLog()
+	h := NewStackAwareHandler(g, log)
+	bolt := game.SimpleCard{Name: "Lightning Bolt", TypeLine: "Instant", ManaCost: "{R}", OracleText: "Lightning Bolt deals 3 damage to any target."}
+	abilities, err := h.engine.ParseAndRegisterAbilities(bolt.OracleText, bolt)
+	if err != nil || len(abilities) == 0 {
+		t.Fatalf("parse %s: %v", bolt.Name, err)
+	}
+
+	pa := h.gameState.GetPlayer("A")
+	ctx := h.ai.BuildDecisionContext(pa, h.getOpponents(pa), "Main Phase")
+	targets := h.ai.ChooseTargetsFor(abilities[0], ctx)
+	if len(targets) != 1 {
+		t.Fatalf("expected one target, got %v", targets)
+	}
+	if got := targetName(targets[0]); got != "C" {
+		t.Errorf("Lightning Bolt should go at C, the biggest board, got %s", got)
+	}
+	events := threatEvents(log)
+	if len(events) != 1 || events[0].Actor != "A" || events[0].Target != "C" {
+		t.Fatalf("expected A's removal breakdown naming C, got %+v", events)
+	}
+	if want := "removal: C score"; events[0].Detail[:len(want)] != want {
+		t.Errorf("detail should start with %q, got %q", want, events[0].Detail)
+	}
+}
+
+// targetName names a chosen player or permanent.
+func targetName(target any) string {
+	if named, ok := target.(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package simulation
+
+import (
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// tutorContext is what the default tutor policy knows when it scores the
+// cards a search can find.
+type tutorContext struct {
+	// missing ranks the combo pieces p could tutor, closest to a win
+	// first.
+	missing map[string]int
+	// assembled is set when p already has every piece of a combo.
+	assembled bool
+	// mana is the number of mana sources p controls.
+	mana int
+	// interaction is set when an opponent looks able to stop a combo.
+	interaction bool
+	// roles is what the deck's Style adds for each role a card has.
+	roles map[card.Role]int
+}
+
+// newTutorContext reads the game state the tutor policy depends on. The
+// deck's own combo index, when known, is consulted before knownCombos.
+func newTutorContext(g *game.Game, p *game.Player, combos *combo.Index, style Style) tutorContext {
+	have := accessibleCardNames(p)
+	tc := tutorContext{missing: map[string]int{}, mana: availableManaSources(p), roles: style.TutorRoles}
+	for _, idx := range []*combo.Index{combos, knownCombos} {
+		if idx == nil {
+			continue
+		}
+		for _, name := range idx.MissingPiecesForHand(have) {
+			if _, ok := tc.missing[name]; !ok {
+				tc.missing[name] = len(tc.missing)
+			}
+		}
+		tc.assembled = tc.assembled || comboAssembled(idx, have)
+	}
+	if g != nil {
+		tc.interaction = opponentsHoldInteraction(g, p)
+	}
+	return tc
+}
+
+// score rates c as the card a tutor fetches for p. Missing combo pieces
+// come first, the ones p can cast this turn ahead of the rest. With a
+// combo assembled and an opponent holding interaction, protection and
+// counterspells outrank more pieces. Otherwise cards rank by role, plus
+// whatever the deck's style adds for its roles, with
+// game.LibraryCardScore breaking ties and handling lands.
+func (tc tutorContext) score(p *game.Player, c game.SimpleCard) int {
+	score := game.LibraryCardScore(p, c)
+	mv := c.ManaValue()
+	if rank, ok := tc.missing[c.Name]; ok {
+		score += 40 - rank
+		if mv <= tc.mana {
+			score += 10
+		}
+	}
+	roles := card.ClassifyRoles(c)
+	if tc.assembled && tc.interaction && roles.HasAny(card.RoleProtection, card.RoleCounterspell) {
+		score += 60
+	}
+	switch {
+	case roles.Has(card.RoleWincon):
+		score += 10
+	case roles.Has(card.RoleFastMana):
+		score += 8
+	case roles.Has(card.RoleDrawEngine):
+		score += 6
+	case roles.Has(card.RoleTutor):
+		score += 4
+	case roles.Has(card.RoleRamp) && tc.mana < 5:
+		score += 4
+	}
+	for r, bonus := range tc.roles {
+		if roles.Has(r) {
+			score += bonus
+		}
+	}
+	if over := mv - tc.mana - 1; over > 0 {
+		score -= 3 * over
+	}
+	return score
+}
+
+// accessibleCardNames lists the cards p can use without searching: hand,
+// battlefield, command zone and graveyard, as pieceAccessible reads them.
+func accessibleCardNames(p *game.Player) []string {
+	var names []string
+	for _, zone := range [][]game.SimpleCard{p.Hand, p.CommandZone, p.Graveyard} {
+		for _, c := range zone {
+			names = append(names, c.Name)
+		}
+	}
+	for _, perm := range p.Battlefield {
+		names = append(names, perm.GetName())
+	}
+	return names
+}
+
+// comboAssembled reports whether have holds every piece of a variant of
+// idx.
+func comboAssembled(idx *combo.Index, have []string) bool {
+	haveSet := map[string]bool{}
+	for _, n := range have {
+		haveSet[n] = true
+	}
+	for _, v := range idx.Variants {
+		if len(v.CardNames) == 0 {
+			continue
+		}
+		all := true
+		for _, n := range v.CardNames {
+			if !haveSet[n] {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+// opponentsHoldInteraction reports whether an opponent of p is known to
+// hold a counterspell or removal, or sits on cards in hand with at least
+// two untapped mana sources.
+func opponentsHoldInteraction(g *game.Game, p *game.Player) bool {
+	view := g.ViewFor(p)
+	for _, opp := range view.Opponents() {
+		if view.KnowsInHand(opp, func(c game.SimpleCard) bool {
+			return card.ClassifyRoles(c).HasAny(card.RoleCounterspell, card.RoleRemoval)
+		}) {
+			return true
+		}
+		if view.Of(opp).HandSize == 0 {
+			continue
+		}
+		open := 0
+		for _, perm := range opp.Battlefield {
+			if !perm.IsTapped() && len(manaProductionOptions(perm.GetSource())) > 0 {
+				open++
+			}
+		}
+		if open >= 2 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+var (
+	testOracle       = game.SimpleCard{Name: "Thassa's Oracle", TypeLine: "Creature — Merfolk Wizard", ManaCost: "{U}{U}", Power: "1", Toughness: "3"}
+	testConsultation = game.SimpleCard{Name: "Demonic Consultation", TypeLine: "Instant", ManaCost: "{B}"}
+	testDemonicTutor = game.SimpleCard{Name: "Demonic Tutor", TypeLine: "Sorcery", ManaCost: "{1}{B}", OracleText: "Search your library for a card, put that card into your hand, then shuffle."}
+	testCounterspell = game.SimpleCard{Name: "Counterspell", TypeLine: "Instant", ManaCost: "{U}{U}", OracleText: "Counter target spell."}
+)
+
+// searchPick returns the name of the card agent picks from candidates.
+func searchPick(t *testing.T, agent Agent, g *game.Game, p *game.Player, candidates ...game.SimpleCard) string {
+	t.Helper()
+	picks := agent.ChooseSearch(g, p, candidates, 1)
+	if len(picks) != 1 {
+		t.Fatalf("expected one pick, got %v", picks)
+	}
+	return candidates[picks[0]].Name
+}
+
+func withLands(p *game.Player, n int) {
+	for i := 0; i < n; i++ {
+		p.PutTokenOnBattlefield(testIsland)
+	}
+}
+
+func TestDefaultAgent_ChooseSearchFindsMissingComboPiece(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	g := game.NewGame(p, game.NewEDHPlayer("Opp"))
+	withLands(p, 4)
+	p.Hand = []game.SimpleCard{testOracle}
+
+	got := searchPick(t, DefaultAgent{}, g, p, testSolRing, testGiant, testConsultation, testForest)
+	if got != "Demonic Consultation" {
+		t.Fatalf("holding Thassa's Oracle, the tutor should find Demonic Consultation, got %s", got)
+	}
+
+	// The deck's own combo index is consulted too.
+	combos := &combo.Index{Variants: map[string]combo.VariantInfo{
+		"1": {ID: "1", CardNames: []string{"Kiki-Jiki, Mirror Breaker", "Zealous Conscripts"}, IsIncluded: true},
+	}}
+	p.Hand = []game.SimpleCard{{Name: "Kiki-Jiki, Mirror Breaker", TypeLine: "Legendary Creature — Goblin Shaman", ManaCost: "{2}{R}{R}{R}"}}
+	conscripts := game.SimpleCard{Name: "Zealous Conscripts", TypeLine: "Creature — Human Warrior", ManaCost: "{4}{R}"}
+	if got := searchPick(t, DefaultAgent{Combos: combos}, g, p, testSolRing, conscripts, testGiant); got != "Zealous Conscripts" {
+		t.Fatalf("expected the missing Kiki-Jiki piece, got %s", got)
+	}
+	if got := searchPick(t, DefaultAgent{}, g, p, testSolRing, conscripts, testGiant); got == "Zealous Conscripts" {
+		t.Fatal("without the combo index Zealous Conscripts is just an expensive creature")
+	}
+}
+
+func TestDefaultAgent_ChooseSearchProtectsAssembledCombo(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	opp := game.NewEDHPlayer("Opp")
+	g := game.NewGame(p, opp)
+	withLands(p, 4)
+	p.Hand = []game.SimpleCard{testOracle, testConsultation}
+
+	if got := searchPick(t, DefaultAgent{}, g, p, testGiant, testCounterspell, testSolRing); got != "Sol Ring" {
+		t.Fatalf("with no interaction to fear the tutor should find fast mana, got %s", got)
+	}
+
+	opp.Hand = []game.SimpleCard{testCounterspell}
+	withLands(opp, 2)
+	if got := searchPick(t, DefaultAgent{}, g, p, testGiant, testCounterspell, testSolRing); got != "Counterspell" {
+		t.Fatalf("against open mana the assembled combo wants protection, got %s", got)
+	}
+}
+
+// lastPickAgent always tutors the last card it is offered.
+type lastPickAgent struct{ DefaultAgent }
+
+func (lastPickAgent) ChooseSearch(_ *game.Game, _ *game.Player, candidates []game.SimpleCard, _ int) []int {
+	return []int{len(candidates) - 1}
+}
+
+func TestCastTutor_AsksTheAgent(t *testing.T) {
+	p := game.NewEDHPlayer("P")
+	g := game.NewGame(p, game.NewEDHPlayer("Opp"))
+	p.Hand = []game.SimpleCard{testDemonicTutor}
+	p.Library = []game.SimpleCard{testBear, testGiant, testForest}
+	p.AddManaToPool(game.Black, 2)
+
+	if !castTutor(g, p, "Demonic Tutor", lastPickAgent{}, nil, nil) {
+		t.Fatal("expected the tutor to be cast")
+	}
+	if len(p.Hand) != 1 || len(p.Library) != 2 {
+		t.Fatalf("expected one card moved to hand, hand %v library %d", p.Hand, len(p.Library))
+	}
+	if found := p.Hand[0].Name; found != testForest.Name {
+		t.Fatalf("the agent picked the last card, Forest, got %s", found)
+	}
+}
+
+func TestCEDHComboFinish_TutorsForTheMissingPiece(t *testing.T) {
+	winner := game.NewEDHPlayer("Oracle")
+	loser := game.NewEDHPlayer("Opponent")
+	winner.Hand = []game.SimpleCard{testOracle, testDemonicTutor}
+	winner.Library = []game.SimpleCard{testBear, testGiant, testConsultation, testForest, testSolRing}
+	winner.AddManaToPool(game.Blue, 3)
+	winner.AddManaToPool(game.Black, 3)
+	g := game.NewGame(winner, loser)
+
+	if !attemptCEDHComboFinish(g, winner, nil, nil, nil, nil) {
+		t.Fatalf("expected Demonic Tutor to find Demonic Consultation and win, hand %v", winner.Hand)
+	}
+	if !loser.HasLost() {
+		t.Fatal("expected the opponent to lose")
+	}
+}
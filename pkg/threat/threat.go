@@ -0,0 +1,228 @@
+// Package threat estimates how close each player of a multiplayer game is
+// to winning, as another player sees it. Attack targeting, removal
+// targeting and counterspell decisions rank opponents with it.
+package threat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mtgsim/mtgsim/pkg/card"
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+// Assessment is one player's threat from a viewer's point of view. Only
+// public information and the cards the viewer knows are read.
+type Assessment struct {
+	Player *game.Player `json:"-"`
+	Name   string       `json:"name"`
+	// WinChance estimates the chance the player wins on their next turn:
+	// the chance that at least one of the combo, combat and commander
+	// routes gets there.
+	WinChance float64 `json:"win_chance"`
+	Combo     float64 `json:"combo"`
+	Combat    float64 `json:"combat"`
+	Commander float64 `json:"commander"`
+
+	Mana int `json:"mana"` // mana sources on the battlefield
+	Hand int `json:"hand"`
+	// PiecesNeeded is the fewest cards the player still needs, beyond the
+	// ones the viewer can see, to assemble a combo; -1 if no known combo
+	// applies.
+	PiecesNeeded int `json:"pieces_needed"`
+	Stax         int `json:"stax"`    // stax pieces the player controls
+	Engines      int `json:"engines"` // card draw engines the player controls
+	Board        int `json:"board"`   // combined creature power
+	// CommanderDamage is the commander damage the player has dealt the
+	// viewer.
+	CommanderDamage int `json:"commander_damage"`
+	LifeDeficit     int `json:"life_deficit"` // life lost from 40
+
+	// Score ranks opponents: WinChance scaled to 100, plus the board
+	// pressure, life, commander damage, engine and stax signals the
+	// attack heuristic has always used.
+	Score int `json:"score"`
+}
+
+// String renders the assessment for event logs.
+func (a Assessment) String() string {
+	return fmt.Sprintf("%s score %d win %.2f (combo %.2f, combat %.2f, commander %.2f; mana %d, hand %d, pieces needed %d, stax %d, engines %d, board %d, commander damage %d)",
+		a.Name, a.Score, a.WinChance, a.Combo, a.Combat, a.Commander,
+		a.Mana, a.Hand, a.PiecesNeeded, a.Stax, a.Engines, a.Board, a.CommanderDamage)
+}
+
+// Breakdown renders ranked assessments, most threatening first.
+func Breakdown(ranked []Assessment) string {
+	parts := make([]string, len(ranked))
+	for i, a := range ranked {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+const (
+	startingLife    = 40
+	commanderLethal = 21
+	// manaToCombo is the number of mana sources at which a player is
+	// assumed to afford any combo.
+	manaToCombo = 4
+	// staxDrag is the factor each stax piece controlled by someone else
+	// multiplies a player's combo chance by.
+	staxDrag = 0.7
+	// routeCap bounds the combat and commander routes: blockers, removal
+	// and fogs stop some attacks that look lethal.
+	routeCap = 0.8
+)
+
+// Assess rates p from view's point of view. combos holds the combo lines p
+// is assumed to be playing toward; nil leaves the combo route out.
+func Assess(view *game.PlayerView, p *game.Player, combos *combo.Index) Assessment {
+	info := view.Of(p)
+	a := Assessment{Player: p, Name: info.Name, Hand: info.HandSize, PiecesNeeded: -1}
+
+	visible := map[string]bool{}
+	for _, c := range info.KnownHand {
+		visible[c.Name] = true
+	}
+	for _, zone := range [][]game.SimpleCard{info.Graveyard, info.CommandZone} {
+		for _, c := range zone {
+			visible[c.Name] = true
+		}
+	}
+	commanders := map[string]bool{}
+	for _, name := range info.Commanders {
+		commanders[name] = true
+	}
+	commanderPower := 0
+	for _, perm := range info.Battlefield {
+		visible[perm.GetName()] = true
+		src := perm.GetSource()
+		roles := card.ClassifyRoles(src)
+		if src.IsLand() || roles.Has(card.RoleRamp) {
+			a.Mana++
+		}
+		if roles.Has(card.RoleStax) {
+			a.Stax++
+		}
+		if roles.Has(card.RoleDrawEngine) {
+			a.Engines++
+		}
+		if perm.IsCreature() {
+			a.Board += perm.GetPower()
+			if commanders[perm.GetName()] && perm.GetPower() > commanderPower {
+				commanderPower = perm.GetPower()
+			}
+		}
+	}
+	a.LifeDeficit = startingLife - info.Life
+	if a.LifeDeficit < 0 {
+		a.LifeDeficit = 0
+	}
+
+	// The players p is racing: the viewer and the viewer's other
+	// opponents.
+	rivals := []*game.Player{view.Viewer()}
+	for _, o := range view.Opponents() {
+		if o != p {
+			rivals = append(rivals, o)
+		}
+	}
+
+	a.Combo = comboChance(info, visible, combos, &a.PiecesNeeded)
+	a.Combo *= math.Min(1, math.Max(0, float64(a.Mana-1)/float64(manaToCombo-1)))
+	for _, r := range rivals {
+		for _, perm := range r.Battlefield {
+			if card.ClassifyRoles(perm.GetSource()).Has(card.RoleStax) {
+				a.Combo *= staxDrag
+			}
+		}
+	}
+
+	// Winning next turn means getting through every rival: combat has to
+	// deal their combined life, and commander damage has to finish the
+	// rival it has hurt least.
+	lifeLeft := 0
+	leastCommander := -1
+	for _, r := range rivals {
+		if r.HasLost() {
+			continue
+		}
+		lifeLeft += r.GetLifeTotal()
+		dealt := 0
+		for name := range commanders {
+			dealt += r.CommanderDamageFrom(p, name)
+		}
+		if r == view.Viewer() {
+			a.CommanderDamage = dealt
+		}
+		if leastCommander < 0 || dealt < leastCommander {
+			leastCommander = dealt
+		}
+	}
+	if lifeLeft > 0 {
+		a.Combat = routeCap * square(math.Min(1, float64(a.Board)/float64(lifeLeft)))
+	}
+	if len(commanders) > 0 && leastCommander >= 0 {
+		a.Commander = routeCap * square(math.Min(1, float64(leastCommander+commanderPower)/commanderLethal))
+	}
+
+	a.WinChance = 1 - (1-a.Combo)*(1-a.Combat)*(1-a.Commander)
+	a.Score = int(math.Round(100*a.WinChance)) + a.Board*2 + a.LifeDeficit/4 + a.CommanderDamage*3 + a.Engines*5 + a.Stax*4
+	return a
+}
+
+// comboChance estimates the chance p holds or finds the rest of a combo
+// of combos: each piece the viewer can't see is taken to be in p's hand,
+// or drawn or tutored this turn, in proportion to the unknown cards in
+// hand. It records the fewest pieces needed in needed.
+func comboChance(info game.PublicInfo, visible map[string]bool, combos *combo.Index, needed *int) float64 {
+	if combos == nil {
+		return 0
+	}
+	hidden := info.HandSize - len(info.KnownHand)
+	q := 0.0
+	if hidden > 0 {
+		q = math.Min(1, 2*float64(hidden)/float64(hidden+info.LibrarySize))
+	}
+	best := 0.0
+	for _, v := range combos.Variants {
+		if len(v.CardNames) == 0 {
+			continue
+		}
+		missing := 0
+		for _, name := range v.CardNames {
+			if !visible[name] {
+				missing++
+			}
+		}
+		if *needed < 0 || missing < *needed {
+			*needed = missing
+		}
+		if p := math.Pow(q, float64(missing)); p > best {
+			best = p
+		}
+	}
+	return best
+}
+
+func square(x float64) float64 { return x * x }
+
+// Rank assesses every opponent of view's player, most threatening first.
+// Ties go to the opponent who plays sooner after the viewer. combos
+// returns the combo lines a player is assumed to be playing toward.
+func Rank(view *game.PlayerView, combos func(*game.Player) *combo.Index) []Assessment {
+	opps := view.OpponentsInTurnOrder()
+	out := make([]Assessment, len(opps))
+	for i, o := range opps {
+		var ci *combo.Index
+		if combos != nil {
+			ci = combos(o)
+		}
+		out[i] = Assess(view, o, ci)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
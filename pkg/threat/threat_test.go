@@ -0,0 +1,90 @@
+package threat
+
+import (
+	"testing"
+
+	"github.com/mtgsim/mtgsim/pkg/combo"
+	"github.com/mtgsim/mtgsim/pkg/game"
+)
+
+var (
+	island       = game.SimpleCard{Name: "Island", TypeLine: "Basic Land — Island"}
+	oracle       = game.SimpleCard{Name: "Thassa's Oracle", TypeLine: "Creature — Merfolk Wizard", ManaCost: "{U}{U}", Power: "1", Toughness: "3"}
+	consultation = game.SimpleCard{Name: "Demonic Consultation", TypeLine: "Instant", ManaCost: "{B}"}
+	thalia       = game.SimpleCard{Name: "Thalia, Guardian of Thraben", TypeLine: "Legendary Creature — Human Soldier", ManaCost: "{1}{W}", Power: "2", Toughness: "1", OracleText: "First strike\nNoncreature spells cost {1} more to cast."}
+	oracleCombo  = &combo.Index{Variants: map[string]combo.VariantInfo{
+		"1": {ID: "1", CardNames: []string{"Thassa's Oracle", "Demonic Consultation"}, IsIncluded: true},
+	}}
+)
+
+func withLands(p *game.Player, n int) {
+	for i := 0; i < n; i++ {
+		p.PutTokenOnBattlefield(island)
+	}
+}
+
+func TestAssess_ComboProximity(t *testing.T) {
+	me, opp := game.NewEDHPlayer("Me"), game.NewEDHPlayer("Opp")
+	g := game.NewGame(me, opp)
+	withLands(opp, 4)
+	opp.Hand = []game.SimpleCard{oracle, consultation, island}
+	opp.Library = make([]game.SimpleCard, 60)
+
+	hidden := Assess(g.ViewFor(me), opp, oracleCombo)
+	if hidden.PiecesNeeded != 2 || hidden.WinChance > 0.05 {
+		t.Fatalf("with both pieces hidden the combo is a long shot, got %+v", hidden)
+	}
+	if none := Assess(g.ViewFor(me), opp, nil); none.Combo != 0 || none.PiecesNeeded != -1 {
+		t.Fatalf("without combo lines there is no combo route, got %+v", none)
+	}
+
+	opp.Reveal(oracle, consultation)
+	known := Assess(g.ViewFor(me), opp, oracleCombo)
+	if known.PiecesNeeded != 0 || known.WinChance < 0.99 {
+		t.Fatalf("a revealed combo with four lands wins, got %+v", known)
+	}
+
+	me.PutTokenOnBattlefield(thalia)
+	taxed := Assess(g.ViewFor(me), opp, oracleCombo)
+	if taxed.Combo >= known.Combo {
+		t.Fatalf("a stax piece should slow the combo, got %.2f against %.2f", taxed.Combo, known.Combo)
+	}
+}
+
+func TestAssess_CombatAndCommanderRoutes(t *testing.T) {
+	me, opp := game.NewEDHPlayer("Me"), game.NewEDHPlayer("Opp")
+	g := game.NewGame(me, opp)
+	cmdr := game.SimpleCard{Name: "Voltron", TypeLine: "Legendary Creature — Knight", Power: "7", Toughness: "7"}
+	opp.RegisterCommander(cmdr)
+	me.AddCommanderDamage(opp, "Voltron", 14)
+
+	before := Assess(g.ViewFor(me), opp, nil)
+	if before.CommanderDamage != 14 || before.Commander <= 0 {
+		t.Fatalf("commander damage dealt counts toward the commander route, got %+v", before)
+	}
+	opp.PutTokenOnBattlefield(cmdr)
+	after := Assess(g.ViewFor(me), opp, nil)
+	if after.Commander < 0.79 || after.Combat <= 0 {
+		t.Fatalf("a 7-power commander one hit from lethal should threaten a win, got %+v", after)
+	}
+
+	me.SetLifeTotal(5)
+	if lethal := Assess(g.ViewFor(me), opp, nil); lethal.Combat < 0.79 {
+		t.Fatalf("7 power against 5 life is lethal on board, got %+v", lethal)
+	}
+}
+
+func TestRank_OrdersByScoreThenTurnOrder(t *testing.T) {
+	p1, p2, p3, p4 := game.NewEDHPlayer("P1"), game.NewEDHPlayer("P2"), game.NewEDHPlayer("P3"), game.NewEDHPlayer("P4")
+	g := game.NewGame(p1, p2, p3, p4)
+
+	ranked := Rank(g.ViewFor(p3), nil)
+	if len(ranked) != 3 || ranked[0].Player != p4 || ranked[1].Player != p1 || ranked[2].Player != p2 {
+		t.Fatalf("ties should follow turn order from P3, got %v", Breakdown(ranked))
+	}
+
+	p2.PutTokenOnBattlefield(game.SimpleCard{Name: "Ogre", TypeLine: "Creature — Ogre", Power: "5", Toughness: "2"})
+	if ranked := Rank(g.ViewFor(p3), nil); ranked[0].Player != p2 {
+		t.Fatalf("the player with a board should lead, got %v", Breakdown(ranked))
+	}
+}